@@ -0,0 +1,101 @@
+// Copyright (c) Kusari <https://www.kusari.dev/>
+// SPDX-License-Identifier: MIT
+
+package api
+
+import (
+	"encoding/json"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// SecurityAnalysisSchemaVersion is the newest SecurityAnalysis format this
+// version of the CLI knows. Analyses in a newer one may have fields it
+// can't show; they are kept in Extra.
+const SecurityAnalysisSchemaVersion = "1"
+
+// securityAnalysisFields is SecurityAnalysis without its JSON methods.
+type securityAnalysisFields SecurityAnalysis
+
+// securityAnalysisKeys are the JSON names of the fields of
+// SecurityAnalysis, which aren't kept in Extra.
+var securityAnalysisKeys = jsonKeys(reflect.TypeFor[SecurityAnalysis]())
+
+// UnmarshalJSON decodes an analysis, keeping the fields it doesn't know in
+// Extra.
+func (sa *SecurityAnalysis) UnmarshalJSON(data []byte) error {
+	var fields securityAnalysisFields
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return err
+	}
+	var all map[string]json.RawMessage
+	if err := json.Unmarshal(data, &all); err != nil {
+		return err
+	}
+	for key := range securityAnalysisKeys {
+		delete(all, key)
+	}
+	fields.Extra = nil
+	if len(all) > 0 {
+		fields.Extra = all
+	}
+	*sa = SecurityAnalysis(fields)
+	return nil
+}
+
+// MarshalJSON encodes an analysis with the fields in Extra.
+func (sa SecurityAnalysis) MarshalJSON() ([]byte, error) {
+	data, err := json.Marshal(securityAnalysisFields(sa))
+	if err != nil || len(sa.Extra) == 0 {
+		return data, err
+	}
+	var all map[string]json.RawMessage
+	if err := json.Unmarshal(data, &all); err != nil {
+		return nil, err
+	}
+	for key, value := range sa.Extra {
+		if _, known := securityAnalysisKeys[key]; !known {
+			all[key] = value
+		}
+	}
+	return json.Marshal(all)
+}
+
+// NewerSchema reports whether the analysis is in a newer format than
+// SecurityAnalysisSchemaVersion.
+func (sa *SecurityAnalysis) NewerSchema() bool {
+	return sa != nil && NewerVersion(sa.SchemaVersion, SecurityAnalysisSchemaVersion)
+}
+
+// NewerVersion reports whether the schema version v is newer than known.
+// Versions are integers; an empty one is older than any, and one that
+// isn't a number is taken to be newer.
+func NewerVersion(v, known string) bool {
+	if v == "" {
+		return false
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil {
+		return true
+	}
+	k, _ := strconv.Atoi(known)
+	return n > k
+}
+
+// jsonKeys returns the JSON names of the encoded fields of the struct t.
+func jsonKeys(t reflect.Type) map[string]struct{} {
+	keys := map[string]struct{}{}
+	for i := range t.NumField() {
+		f := t.Field(i)
+		name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+		if name == "-" || !f.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+		keys[name] = struct{}{}
+	}
+	return keys
+}
@@ -4,15 +4,125 @@
 package api
 
 type BundleMeta struct {
-	PatchName     string `json:"patch_name"`
-	CurrentBranch string `json:"current_branch"`
-	DirName       string `json:"dir_name"`
-	DiffCmd       string `json:"diff_cmd"`
-	Remote        string `json:"remote"`
-	GitDirty      bool   `json:"git_dirty"`
-	ScanType      string `json:"scan_type,omitempty"`
+	PatchName     string   `json:"patch_name"`
+	CurrentBranch string   `json:"current_branch"`
+	DirName       string   `json:"dir_name"`
+	DiffCmd       string   `json:"diff_cmd"`
+	Staged        bool     `json:"staged,omitempty"`         // The patch is of the index against DiffCmd, not the working tree
+	CommittedOnly bool     `json:"committed_only,omitempty"` // The patch and source are of HEAD, leaving out uncommitted changes
+	Remote        string   `json:"remote"`
+	GitDirty      bool     `json:"git_dirty"`
+	NoGit         bool     `json:"no_git,omitempty"` // The source is a plain directory snapshot, packaged without git
+	ScanType      string   `json:"scan_type,omitempty"`
+	Checks        []string `json:"checks,omitempty"`     // Sub-scans a full scan is limited to; empty runs them all
+	IaCPaths      []string `json:"iac_paths,omitempty"`  // Globs of the files an IaC scan packaged and diffed
+	ScannedBy     string   `json:"scanned_by,omitempty"` // Email or subject of the logged-in user
 	// Incremental scanning fields
 	CommitSHA         string            `json:"commit_sha,omitempty"`          // Current HEAD commit SHA
 	ChangedFiles      []string          `json:"changed_files,omitempty"`       // Files changed in this scan
 	ChangedFileHashes map[string]string `json:"changed_file_hashes,omitempty"` // SHA256 hashes of changed file contents
+	// Reviewer orientation fields
+	ChangedFileStats []FileStat    `json:"changed_file_stats,omitempty"` // Lines added and deleted per changed file
+	HeadAuthor       *CommitAuthor `json:"head_author,omitempty"`        // Author of the HEAD commit
+	// RepoFingerprint identifies the repository across its clones and
+	// checkouts: "remote:" and the hash of its normalized origin URL, or
+	// "root:" and that of its root commit when it has no origin.
+	RepoFingerprint string `json:"repo_fingerprint,omitempty"`
+	// PatchNormalization records how the patch was rewritten from git's
+	// output; nil if it is git's output as is.
+	PatchNormalization *PatchNormalization `json:"patch_normalization,omitempty"`
+	// Image describes what an image check packaged; nil for repository
+	// scans.
+	Image *ImageMeta `json:"image,omitempty"`
+	// SkippedFiles are the source files left out of the package.
+	SkippedFiles []SkippedFile `json:"skipped_files,omitempty"`
+	// Delta is set when the bundle holds only the files changed since an
+	// earlier full scan bundle, as its manifest diff lists.
+	Delta bool `json:"delta,omitempty"`
+}
+
+// Reasons a source file was left out of a package.
+const (
+	SkipReasonSize   = "size"   // Larger than the maximum file size
+	SkipReasonBinary = "binary" // A binary file, by extension or content
+)
+
+// SkippedFile is a source file left out of a package, and why.
+type SkippedFile struct {
+	Path   string `json:"path"`
+	Size   int64  `json:"size"`
+	Reason string `json:"reason"`
+}
+
+// ImageMeta is the container image, or the Dockerfile building one, that
+// an image check analyzes.
+type ImageMeta struct {
+	Ref        string      `json:"ref,omitempty"`         // Image reference checked, when no Dockerfile was given
+	Dockerfile string      `json:"dockerfile,omitempty"`  // Name of the Dockerfile in the bundle
+	BaseImages []BaseImage `json:"base_images,omitempty"` // Images the Dockerfile builds FROM
+	Inspected  bool        `json:"inspected,omitempty"`   // The bundle holds the output of docker image inspect
+}
+
+// BaseImage is an image a Dockerfile stage builds FROM.
+type BaseImage struct {
+	Ref    string `json:"ref"`
+	Stage  string `json:"stage,omitempty"` // Name given with AS
+	Line   int    `json:"line"`
+	Pinned bool   `json:"pinned"` // Referenced by digest, so rebuilds use the same image
+}
+
+// PatchNormalization lists the files whose lines in the patch were rewritten
+// so that they are LF-terminated UTF-8. No lines are added or removed, so
+// the hunk line numbers still match the files.
+type PatchNormalization struct {
+	CRLFFiles   []string `json:"crlf_files,omitempty"`   // Carriage returns were stripped from line endings
+	Latin1Files []string `json:"latin1_files,omitempty"` // Lines that weren't UTF-8 were decoded as ISO-8859-1
+}
+
+// BundleManifest lists every other file of a scan bundle with its size and
+// SHA-256, so the platform and auditors can verify that the archive holds
+// exactly what was packaged.
+type BundleManifest struct {
+	Files []ManifestFile `json:"files"`
+}
+
+// ManifestDiff is how the files of a full scan differ from those of an
+// earlier bundle of the same repository, the base, for a delta bundle that
+// holds only the added and changed files. The platform rebuilds the full
+// bundle from the base's files and these, which Digest then verifies.
+type ManifestDiff struct {
+	// BaseSortKey locates the result of the base bundle.
+	BaseSortKey string `json:"base_sort_key"`
+	// BaseDigest and Digest are the SHA-256 of the manifests of the base
+	// and of the full bundle.
+	BaseDigest string         `json:"base_digest"`
+	Digest     string         `json:"digest"`
+	Added      []ManifestFile `json:"added"`
+	Changed    []ManifestFile `json:"changed"`
+	Removed    []string       `json:"removed"`
+	// Unchanged counts the files taken from the base.
+	Unchanged int `json:"unchanged"`
+}
+
+// ManifestFile is one file of a bundle.
+type ManifestFile struct {
+	Path   string `json:"path"`
+	Size   int64  `json:"size"`
+	SHA256 string `json:"sha256"`
+}
+
+// FileStat is the size of the change to one file. Binary files have no
+// line counts.
+type FileStat struct {
+	Path    string `json:"path"`
+	Added   int    `json:"added"`
+	Deleted int    `json:"deleted"`
+	Binary  bool   `json:"binary,omitempty"`
+}
+
+// CommitAuthor is the author of a commit.
+type CommitAuthor struct {
+	Name  string `json:"name"`
+	Email string `json:"email"`
+	Date  string `json:"date"` // ISO 8601
 }
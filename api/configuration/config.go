@@ -1,13 +1,80 @@
 package configuration
 
 type Config struct {
+	// Extends is the URL or path (relative to the file) of a base config,
+	// typically an organization's, whose settings apply where the file
+	// has none.
+	Extends string `yaml:"extends,omitempty"`
+
 	GitHubActionVersionPinningCheckEnabled bool `yaml:"github_action_version_pinning_check_enabled"` // Check whether GH Action versions are pinned
 	ContainerVersionPinningCheckEnabled    bool `yaml:"container_version_pinning_check_enabled"`     // Check whether container versions are pinned
 	PostCommentOnFailure                   bool `yaml:"post_comment_on_failure"`                     // Also post comment when status check fails
 	PostCommentOnSuccess                   bool `yaml:"post_comment_on_success"`                     // Also post comment when status check succeeds
 
+	// CommentMode is how a new summary comment replaces the previous one:
+	// CommentModeUpdate edits it in place, CommentModeMinimize minimizes it
+	// (GitHub) or resolves it (GitLab) and posts a fresh one.
+	CommentMode string `yaml:"comment_mode"`
+	// CommentStyle is what is posted on a pull request: CommentStyleFull
+	// posts the summary and inline comments, CommentStyleCompact a one-line
+	// status comment with a badge, CommentStyleReaction only a 👍/👎
+	// reaction.
+	CommentStyle string `yaml:"comment_style"`
+	// CommentTemplates overrides snippets of the full comment's template,
+	// keyed by snippet: header, code_mitigation, dependency_mitigation or
+	// footer. Each is a Go text/template.
+	CommentTemplates map[string]string `yaml:"comment_templates,omitempty"`
+
+	// PR Label Configuration (one label per outcome; empty names are never applied)
+	PRLabelsEnabled    bool   `yaml:"pr_labels_enabled"`     // Label PRs with the analysis outcome (default: false)
+	PRLabelPassed      string `yaml:"pr_label_passed"`       // Label for changes with no findings
+	PRLabelBlocked     string `yaml:"pr_label_blocked"`      // Label for changes that should not proceed
+	PRLabelNeedsReview string `yaml:"pr_label_needs_review"` // Label for changes that passed with findings, or failed analysis
+
+	// Security Review Configuration (requested when changes should not proceed)
+	SecurityReviewers       []string `yaml:"security_reviewers"`        // Users, or GitHub teams as org/team, to request review from
+	SecurityReviewThreshold int      `yaml:"security_review_threshold"` // Minimum number of findings before review is requested
+
+	// Issue Configuration (for 'kusari results create-issues')
+	IssueLabels    []string `yaml:"issue_labels"`    // Labels for opened issues; also used to find issues opened before
+	IssueAssignees []string `yaml:"issue_assignees"` // Users to assign opened issues to
+
+	// Jira Configuration (for 'kusari results create-issues --platform jira')
+	JiraProjectKey   string         `yaml:"jira_project_key,omitempty"`   // Project to file tickets in
+	JiraIssueType    string         `yaml:"jira_issue_type"`              // Issue type of filed tickets
+	JiraCustomFields map[string]any `yaml:"jira_custom_fields,omitempty"` // Extra fields set on new tickets, keyed by field ID (e.g. customfield_10010)
+
+	// ITSM Configuration (records filed for diff scans that should not proceed, at the
+	// endpoints given with --servicenow-url and --ticket-webhook-url)
+	ServiceNowTable       string         `yaml:"servicenow_table"`                  // Table to file records in, e.g. incident or change_request
+	ServiceNowFields      map[string]any `yaml:"servicenow_fields,omitempty"`       // Record fields over the defaults; string values are templates
+	TicketWebhookTemplate string         `yaml:"ticket_webhook_template,omitempty"` // Payload template (Go text/template producing JSON)
+
+	// IaC Scan Configuration (for 'kusari repo scan --iac')
+	IaCPaths []string `yaml:"iac_paths"` // Globs of the files an IaC scan packages, with ** matching any number of directories
+
 	// SBOM Generation Configuration (for merged PRs to main/master)
 	SBOMGenerationEnabled      bool   `yaml:"sbom_generation_enabled"`                 // Enable SBOM generation on merged PRs (default: false)
 	SBOMSubjectNameOverride    string `yaml:"sbom_subject_name_override,omitempty"`    // Override SBOM subject name in Kusari Platform
 	SBOMSubjectVersionOverride string `yaml:"sbom_subject_version_override,omitempty"` // Override SBOM subject version in Kusari Platform
+
+	// SBOM subject name normalization (for 'kusari platform upload')
+	SubjectNameRules   []string       `yaml:"subject_name_rules,omitempty"`   // Applied in order: "REGEX => REPLACEMENT" or "lowercase"
+	SubjectNameAliases map[string]any `yaml:"subject_name_aliases,omitempty"` // Normalized subject names mapped to the name to upload under
+
+	// Upload tags (for 'kusari platform upload')
+	DefaultTags []string `yaml:"default_tags,omitempty"` // Tags added to every upload, next to --tag and those of the CI pipeline
 }
+
+// Comment modes
+const (
+	CommentModeUpdate   = "update"
+	CommentModeMinimize = "minimize"
+)
+
+// Comment styles
+const (
+	CommentStyleFull     = "full"
+	CommentStyleCompact  = "compact"
+	CommentStyleReaction = "reaction"
+)
@@ -3,6 +3,8 @@
 
 package api
 
+import "encoding/json"
+
 // UserInspectorResult represents the structure for our DynamoDB table
 type UserInspectorResult struct {
 	User       string     `docstore:"user" json:"user"` // Primary key
@@ -82,6 +84,10 @@ type DependencyMitigationItem struct {
 }
 
 type SecurityAnalysis struct {
+	// SchemaVersion is the version of this format the platform wrote the
+	// analysis in; empty before versions were recorded. See
+	// SecurityAnalysisSchemaVersion.
+	SchemaVersion                 string                     `docstore:"schema_version" json:"schema_version,omitempty"`
 	Recommendation                string                     `docstore:"recommendation" json:"recommendation"`
 	Justification                 string                     `docstore:"justification" json:"justification"`
 	RequiredCodeMitigations       []CodeMitigationItem       `docstore:"code_mitigations" json:"code_mitigations,omitempty"`
@@ -89,6 +95,9 @@ type SecurityAnalysis struct {
 	ShouldProceed                 bool                       `docstore:"should_proceed" json:"should_proceed"`
 	FailedAnalysis                bool                       `docstore:"failed_analysis" json:"failed_analysis"`
 	HealthScore                   int                        `docstore:"health_score" json:"health_score"` // 0-5 scale for full repo scans
+	// Extra holds the fields of a newer platform's analysis that this
+	// version doesn't know, so they are written back out unchanged.
+	Extra map[string]json.RawMessage `docstore:"-" json:"-"`
 }
 
 type Meta struct {
@@ -0,0 +1,67 @@
+// Copyright (c) Kusari <https://www.kusari.dev/>
+// SPDX-License-Identifier: MIT
+
+package api
+
+import (
+	"cmp"
+	"crypto/sha256"
+	"encoding/hex"
+	"slices"
+)
+
+// Finding types, as in FindingID.
+const (
+	FindingCode       = "code"
+	FindingDependency = "dependency"
+)
+
+// FindingID returns the stable ID of a finding of kind (FindingCode or
+// FindingDependency) in path, with content: the same in every run that
+// reports it, whatever order the platform returns findings in. The line is
+// left out, as it moves when the file is edited.
+func FindingID(kind, path, content string) string {
+	sum := sha256.Sum256([]byte(kind + "\x00" + path + "\x00" + content))
+	return hex.EncodeToString(sum[:8])
+}
+
+// ID returns the stable ID of the finding.
+func (m CodeMitigationItem) ID() string {
+	return FindingID(FindingCode, m.Path, m.Content)
+}
+
+// ID returns the stable ID of the finding.
+func (m DependencyMitigationItem) ID() string {
+	return FindingID(FindingDependency, "", m.Content)
+}
+
+// SortFindings sorts the mitigations of a deterministically, so runs
+// reporting the same findings list them the same: code mitigations by
+// path, line and content hash, dependency mitigations by content hash.
+func (a *SecurityAnalysis) SortFindings() {
+	slices.SortStableFunc(a.RequiredCodeMitigations, func(x, y CodeMitigationItem) int {
+		return cmp.Or(
+			cmp.Compare(x.Path, y.Path),
+			cmp.Compare(x.LineNumber, y.LineNumber),
+			cmp.Compare(contentHash(x.Content), contentHash(y.Content)),
+		)
+	})
+	slices.SortStableFunc(a.RequiredDependencyMitigations, func(x, y DependencyMitigationItem) int {
+		return cmp.Compare(contentHash(x.Content), contentHash(y.Content))
+	})
+}
+
+// SortedFindings returns a copy of a with its mitigations sorted as by
+// SortFindings, leaving a as it is.
+func (a *SecurityAnalysis) SortedFindings() *SecurityAnalysis {
+	sorted := *a
+	sorted.RequiredCodeMitigations = slices.Clone(a.RequiredCodeMitigations)
+	sorted.RequiredDependencyMitigations = slices.Clone(a.RequiredDependencyMitigations)
+	sorted.SortFindings()
+	return &sorted
+}
+
+func contentHash(content string) string {
+	sum := sha256.Sum256([]byte(content))
+	return hex.EncodeToString(sum[:])
+}
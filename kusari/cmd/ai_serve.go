@@ -32,6 +32,9 @@ For debugging, you can run it directly with --verbose to see detailed logging.`,
 			if cmd.Flags().Changed("verbose") {
 				cfg.Verbose = verbose
 			}
+			if bundleEncryption != "" {
+				cfg.BundleEncryption = bundleEncryption
+			}
 
 			server, err := ai.NewServer(cfg)
 			if err != nil {
@@ -0,0 +1,101 @@
+// Copyright (c) Kusari <https://www.kusari.dev/>
+// SPDX-License-Identifier: MIT
+
+package cmd
+
+import (
+	"io"
+	"os"
+	"strings"
+
+	"github.com/kusaridev/kusari-cli/v2/pkg/annotate"
+	"github.com/kusaridev/kusari-cli/v2/pkg/clierrors"
+	"github.com/kusaridev/kusari-cli/v2/pkg/output"
+	"github.com/spf13/cobra"
+)
+
+func annotateCmd() *cobra.Command {
+	var (
+		inputPath string
+		last      bool
+		baseRef   string
+		diffPath  string
+	)
+
+	cmd := &cobra.Command{
+		Use:   "annotate [directory]",
+		Short: "Show the diff of a change with its findings inline",
+		Long: `Print the diff a result was produced from, like git diff, with each code
+finding shown under the line it is about. Findings on lines the diff
+doesn't show, in files it doesn't touch, and on dependencies follow it.
+
+The diff is taken with git diff against --base-ref, which defaults to the
+base the result was scanned against, in directory, which defaults to the
+one scanned. --diff reads a diff from a file, or - for stdin, instead.`,
+		Example: `  # Review the last scan's findings in its diff
+  kusari annotate --last
+
+  # Annotate a saved SARIF result against main
+  kusari annotate --input results.sarif --base-ref main .
+
+  # Annotate a diff made elsewhere
+  git diff origin/main | kusari annotate --last --diff -`,
+		Args: cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cmd.SilenceUsage = true
+
+			res, err := loadResult(inputPath, last)
+			if err != nil {
+				return err
+			}
+
+			var diff []byte
+			if diffPath != "" {
+				diff, err = readFileOrStdin(diffPath)
+				if err != nil {
+					return clierrors.NewValidationError("failed to read diff: %v", err)
+				}
+			} else {
+				dir := res.Repo
+				if len(args) > 0 {
+					dir = args[0]
+				}
+				if dir == "" {
+					dir = "."
+				}
+				base := baseRef
+				if base == "" {
+					base = res.BaseRef
+				}
+				if base == "" {
+					return clierrors.NewValidationError("the result doesn't record a base ref; pass --base-ref or --diff")
+				}
+				if strings.HasPrefix(base, "-") {
+					return clierrors.NewValidationError("invalid --base-ref %q", base)
+				}
+				diff, err = annotate.GitDiff(dir, base)
+				if err != nil {
+					return err
+				}
+			}
+
+			opts := annotate.Options{Color: output.Color() && output.IsTerminal(os.Stdout)}
+			return annotate.Render(os.Stdout, diff, res.Analysis, opts)
+		},
+	}
+
+	addResultFlags(cmd, &inputPath, &last)
+	cmd.Flags().StringVar(&baseRef, "base-ref", "", "Git rev to diff against (default: the base the result was scanned against)")
+	cmd.Flags().StringVar(&diffPath, "diff", "", "Read the diff from a file, or - for stdin, instead of running git diff")
+	cmd.MarkFlagsMutuallyExclusive("base-ref", "diff")
+
+	return cmd
+}
+
+// readFileOrStdin reads path, or stdin for "-".
+func readFileOrStdin(path string) ([]byte, error) {
+	if path == "-" {
+		return io.ReadAll(os.Stdin)
+	}
+	return os.ReadFile(path)
+}
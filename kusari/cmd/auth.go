@@ -12,12 +12,16 @@ func Auth() *cobra.Command {
 	cmd := &cobra.Command{
 		Use:   "auth",
 		Short: "Authentication operations",
-		Long:  "Authenticate to Kusari and select Kusari workspace/tenant",
+		Long:  "Authenticate to Kusari, select Kusari workspace/tenant, and switch between stored identities",
 	}
 
 	cmd.AddCommand(login())
 	cmd.AddCommand(selectWorkspace())
 	cmd.AddCommand(selectTenant())
+	cmd.AddCommand(authStatus())
+	cmd.AddCommand(authRefresh())
+	cmd.AddCommand(authList())
+	cmd.AddCommand(authSwitch())
 
 	return cmd
 }
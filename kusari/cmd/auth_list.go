@@ -0,0 +1,51 @@
+// Copyright (c) Kusari <https://www.kusari.dev/>
+// SPDX-License-Identifier: MIT
+
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/kusaridev/kusari-cli/v2/pkg/auth"
+	"github.com/spf13/cobra"
+)
+
+var authListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List stored login identities",
+	Long:  `List the identities stored by previous logins. The active identity is marked with '*'. Use 'kusari auth switch' to change it.`,
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cmd.SilenceUsage = true
+
+		identities, active, err := auth.ListIdentities()
+		if err != nil {
+			return err
+		}
+		if len(identities) == 0 {
+			fmt.Println("No stored identities. Run `kusari auth login`.")
+			return nil
+		}
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "\t#\tUSER\tAUTH ENDPOINT\tWORKSPACE\tTENANT")
+		for i, id := range identities {
+			marker := ""
+			if id.Key == active {
+				marker = "*"
+			}
+			workspace, tenant := "", ""
+			if id.Workspace != nil {
+				workspace, tenant = id.Workspace.Description, id.Workspace.Tenant
+			}
+			fmt.Fprintf(w, "%s\t%d\t%s\t%s\t%s\t%s\n", marker, i+1, id.User, id.AuthEndpoint, workspace, tenant)
+		}
+		return w.Flush()
+	},
+}
+
+func authList() *cobra.Command {
+	return authListCmd
+}
@@ -4,8 +4,7 @@
 package cmd
 
 import (
-	"fmt"
-
+	"github.com/kusaridev/kusari-cli/v2/pkg/constants"
 	l "github.com/kusaridev/kusari-cli/v2/pkg/login"
 	"github.com/kusaridev/kusari-cli/v2/pkg/port"
 	"github.com/spf13/cobra"
@@ -17,19 +16,22 @@ var (
 	authEndpoint string
 	clientSecret string
 	useSso       bool
+	callbackPort string
 )
 
 func init() {
-	logincmd.Flags().StringVarP(&authEndpoint, "auth-endpoint", "p", "https://auth.us.kusari.cloud/", "authentication endpoint URL")
+	logincmd.Flags().StringVarP(&authEndpoint, "auth-endpoint", "p", constants.DefaultAuthEndpoint, "authentication endpoint URL")
 	logincmd.Flags().StringVarP(&clientId, "client-id", "c", "4lnk6jccl3hc4lkcudai5lt36u", "OAuth2 client ID")
 	logincmd.Flags().StringVarP(&clientSecret, "client-secret", "s", "", "OAuth client secret ")
 	logincmd.Flags().BoolVar(&useSso, "use-sso", false, "Use SSO (SAML) authentication")
+	logincmd.Flags().StringVar(&callbackPort, "callback-port", "", "Local port or range (e.g. 62001-62009) for the login callback; 0 picks any free port if your identity provider allows wildcard loopback redirects (default: a free port in 62001-62009)")
 
 	// Bind flags to viper
 	mustBindPFlag("auth-endpoint", logincmd.Flags().Lookup("auth-endpoint"))
 	mustBindPFlag("client-id", logincmd.Flags().Lookup("client-id"))
 	mustBindPFlag("client-secret", logincmd.Flags().Lookup("client-secret"))
 	mustBindPFlag("use-sso", logincmd.Flags().Lookup("use-sso"))
+	mustBindPFlag("callback-port", logincmd.Flags().Lookup("callback-port"))
 }
 
 var logincmd = &cobra.Command{
@@ -42,6 +44,7 @@ var logincmd = &cobra.Command{
 		clientId = viper.GetString("client-id")
 		clientSecret = viper.GetString("client-secret")
 		useSso = viper.GetBool("use-sso")
+		callbackPort = viper.GetString("callback-port")
 	},
 }
 
@@ -55,8 +58,12 @@ func login() *cobra.Command {
 			effectiveClientId = "7ippro0e5e8qd3oragd4k1h39i"
 		}
 
-		redirectPort := port.GenerateRandomPortOrDefault()
-		redirectUrl := fmt.Sprintf("http://localhost:%s/callback", redirectPort)
+		// The port in redirectUrl is replaced with the one actually bound.
+		redirectPort := callbackPort
+		if redirectPort == "" {
+			redirectPort = port.GenerateRandomPortOrDefault()
+		}
+		redirectUrl := "http://localhost/callback"
 
 		return l.Login(cmd.Context(), effectiveClientId, clientSecret, redirectUrl, authEndpoint, redirectPort, consoleUrl, platformUrl, verbose, useSso)
 	}
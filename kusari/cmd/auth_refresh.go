@@ -0,0 +1,125 @@
+// Copyright (c) Kusari <https://www.kusari.dev/>
+// SPDX-License-Identifier: MIT
+
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/kusaridev/kusari-cli/v2/pkg/auth"
+	"github.com/kusaridev/kusari-cli/v2/pkg/clierrors"
+	"github.com/spf13/cobra"
+)
+
+var (
+	refreshDaemon       bool
+	refreshBefore       time.Duration
+	refreshPrintService string
+)
+
+func init() {
+	authRefreshCmd.Flags().BoolVar(&refreshDaemon, "daemon", false, "Keep running and refresh the stored token before each expiry")
+	authRefreshCmd.Flags().DurationVar(&refreshBefore, "refresh-before", 5*time.Minute, "With --daemon, how long before expiry to refresh")
+	authRefreshCmd.Flags().StringVar(&refreshPrintService, "print-service", "", "Print a service definition that runs the daemon (systemd or launchd) and exit")
+}
+
+var authRefreshCmd = &cobra.Command{
+	Use:   "refresh",
+	Short: "Refresh the stored login token",
+	Long: `Refresh the stored login token using its refresh token, without opening a browser.
+
+With --daemon the command keeps running and refreshes the token shortly before
+each expiry, so long-running or scheduled jobs on a shared workstation account
+don't fail with "token expired". Use --print-service to generate a systemd user
+unit or launchd agent that runs the daemon in the background.
+
+Examples:
+  kusari auth refresh
+  kusari auth refresh --daemon --refresh-before 10m
+  kusari auth refresh --print-service systemd > ~/.config/systemd/user/kusari-auth-refresh.service`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cmd.SilenceUsage = true
+
+		if refreshPrintService != "" {
+			return printRefreshService(refreshPrintService)
+		}
+
+		if !refreshDaemon {
+			token, err := auth.RefreshStoredToken(cmd.Context())
+			if err != nil {
+				return err
+			}
+			fmt.Printf("Token refreshed; valid until %s\n", token.Expiry.Local().Format(time.RFC1123))
+			return nil
+		}
+
+		ctx, stop := signal.NotifyContext(cmd.Context(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+
+		logf := func(format string, args ...any) {
+			fmt.Fprintf(os.Stderr, time.Now().Format(time.RFC3339)+" "+format, args...)
+		}
+		logf("Keeping the stored token fresh (refreshing %s before expiry)\n", refreshBefore)
+		return auth.KeepAlive(ctx, refreshBefore, logf)
+	},
+}
+
+// printRefreshService writes a service definition that runs the refresh
+// daemon for the current user.
+func printRefreshService(kind string) error {
+	exe, err := os.Executable()
+	if err != nil {
+		return fmt.Errorf("failed to resolve kusari executable: %w", err)
+	}
+	before := refreshBefore.String()
+
+	switch kind {
+	case "systemd":
+		fmt.Printf(`[Unit]
+Description=Keep the Kusari CLI login token fresh
+
+[Service]
+ExecStart=%s auth refresh --daemon --refresh-before %s
+Restart=on-failure
+RestartSec=60
+
+[Install]
+WantedBy=default.target
+`, exe, before)
+	case "launchd":
+		fmt.Printf(`<?xml version="1.0" encoding="UTF-8"?>
+<!DOCTYPE plist PUBLIC "-//Apple//DTD PLIST 1.0//EN" "http://www.apple.com/DTDs/PropertyList-1.0.dtd">
+<plist version="1.0">
+<dict>
+  <key>Label</key>
+  <string>dev.kusari.auth-refresh</string>
+  <key>ProgramArguments</key>
+  <array>
+    <string>%s</string>
+    <string>auth</string>
+    <string>refresh</string>
+    <string>--daemon</string>
+    <string>--refresh-before</string>
+    <string>%s</string>
+  </array>
+  <key>RunAtLoad</key>
+  <true/>
+  <key>KeepAlive</key>
+  <true/>
+</dict>
+</plist>
+`, exe, before)
+	default:
+		return clierrors.NewValidationError("unknown service type %q (must be systemd or launchd)", kind)
+	}
+	return nil
+}
+
+func authRefresh() *cobra.Command {
+	return authRefreshCmd
+}
@@ -19,13 +19,9 @@ var selectWorkspaceCmd = &cobra.Command{
 		cmd.SilenceUsage = true
 
 		// Load the token to verify user is authenticated
-		token, err := auth.LoadToken("kusari")
+		token, err := auth.DefaultTokenProvider().Token(cmd.Context())
 		if err != nil {
-			return fmt.Errorf("you must be logged in to select a workspace. Run `kusari auth login`")
-		}
-
-		if err := auth.CheckTokenExpiry(token); err != nil {
-			return err
+			return fmt.Errorf("you must be logged in to select a workspace. Run `kusari auth login`: %w", err)
 		}
 
 		// Fetch available workspaces
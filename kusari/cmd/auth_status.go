@@ -0,0 +1,78 @@
+// Copyright (c) Kusari <https://www.kusari.dev/>
+// SPDX-License-Identifier: MIT
+
+package cmd
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/kusaridev/kusari-cli/v2/pkg/auth"
+	"github.com/spf13/cobra"
+)
+
+var authStatusCmd = &cobra.Command{
+	Use:   "status",
+	Short: "Show the current login identity",
+	Long:  `Show who you are logged in as, whether the ID token was validated, when the token expires, and the active workspace and tenant.`,
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cmd.SilenceUsage = true
+
+		provider := auth.DefaultTokenProvider()
+		token, err := provider.Token(cmd.Context())
+		if provider.Machine() {
+			if err != nil {
+				return fmt.Errorf("authentication using %s failed: %w", provider.Name(), err)
+			}
+			fmt.Printf("Credentials: %s (overrides any stored login)\n", provider.Name())
+			return nil
+		}
+		expired := auth.IsTokenExpired(err)
+		if err != nil && !expired {
+			return fmt.Errorf("not logged in. Run `kusari auth login`")
+		}
+
+		identity, err := auth.ActiveIdentity()
+		if err != nil {
+			return err
+		}
+		if identity != nil {
+			fmt.Printf("User: %s\n", identity.User)
+			if identity.Email != "" && identity.Email != identity.User {
+				fmt.Printf("Email: %s\n", identity.Email)
+			}
+			if identity.Subject != "" {
+				fmt.Printf("Subject: %s\n", identity.Subject)
+			}
+			fmt.Printf("Auth endpoint: %s\n", identity.AuthEndpoint)
+			if identity.Verified {
+				fmt.Println("ID token: verified")
+			} else {
+				fmt.Println("ID token: not verified")
+			}
+		} else {
+			fmt.Println("User: unknown (log in again to record your identity)")
+		}
+
+		if expired {
+			fmt.Println("Token expired. Run `kusari auth login`")
+		} else {
+			fmt.Printf("Token expires: %s (in %s)\n", token.Expiry.Local().Format(time.RFC1123), time.Until(token.Expiry).Round(time.Minute))
+		}
+
+		if workspace, err := auth.LoadWorkspace(platformUrl, ""); err == nil {
+			fmt.Printf("Workspace: %s\n", workspace.Description)
+			if workspace.Tenant != "" {
+				fmt.Printf("Tenant: %s\n", workspace.Tenant)
+			}
+		} else {
+			fmt.Println("Workspace: none selected")
+		}
+		return nil
+	},
+}
+
+func authStatus() *cobra.Command {
+	return authStatusCmd
+}
@@ -0,0 +1,84 @@
+// Copyright (c) Kusari <https://www.kusari.dev/>
+// SPDX-License-Identifier: MIT
+
+package cmd
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/kusaridev/kusari-cli/v2/pkg/auth"
+	"github.com/kusaridev/kusari-cli/v2/pkg/clierrors"
+	"github.com/kusaridev/kusari-cli/v2/pkg/output"
+	"github.com/spf13/cobra"
+)
+
+var authSwitchCmd = &cobra.Command{
+	Use:   "switch [identity]",
+	Short: "Switch the active login identity",
+	Long: `Switch the active login identity without re-authenticating. The identity can be
+given as its number or user from 'kusari auth list'; if omitted you are prompted
+to pick one. The workspace and tenant last selected for that identity are restored.`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cmd.SilenceUsage = true
+
+		identities, active, err := auth.ListIdentities()
+		if err != nil {
+			return err
+		}
+		if len(identities) == 0 {
+			return fmt.Errorf("no stored identities. Run `kusari auth login`")
+		}
+
+		var ref string
+		if len(args) == 1 {
+			ref = args[0]
+		} else {
+			if !output.Interactive() {
+				return clierrors.NewValidationError("an identity is required in non-interactive mode: kusari auth switch <number|user>")
+			}
+			fmt.Println("\nStored identities:")
+			for i, id := range identities {
+				marker := " "
+				if id.Key == active {
+					marker = "*"
+				}
+				fmt.Printf(" %s[%d] %s (%s)\n", marker, i+1, id.User, id.AuthEndpoint)
+			}
+			fmt.Printf("\nSelect an identity (1-%d): ", len(identities))
+			input, err := bufio.NewReader(os.Stdin).ReadString('\n')
+			if err != nil {
+				return fmt.Errorf("failed to read input: %w", err)
+			}
+			ref = strings.TrimSpace(input)
+		}
+
+		selected, err := auth.FindIdentity(identities, ref)
+		if err != nil {
+			return err
+		}
+
+		switched, err := auth.SwitchIdentity(selected.Key)
+		if err != nil {
+			return err
+		}
+
+		fmt.Printf("Switched to %s (%s).\n", switched.User, switched.AuthEndpoint)
+		if switched.Workspace != nil {
+			fmt.Printf("Workspace '%s' is now your active workspace.\n", switched.Workspace.Description)
+			if switched.Workspace.Tenant != "" {
+				fmt.Printf("Tenant '%s' is now your active tenant.\n", switched.Workspace.Tenant)
+			}
+		} else {
+			fmt.Println("No workspace recorded for this identity. Run `kusari auth select-workspace`.")
+		}
+		return nil
+	},
+}
+
+func authSwitch() *cobra.Command {
+	return authSwitchCmd
+}
@@ -0,0 +1,117 @@
+// Copyright (c) Kusari <https://www.kusari.dev/>
+// SPDX-License-Identifier: MIT
+
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/kusaridev/kusari-cli/v2/pkg/cleanup"
+	"github.com/kusaridev/kusari-cli/v2/pkg/clierrors"
+	"github.com/kusaridev/kusari-cli/v2/pkg/output"
+	"github.com/spf13/cobra"
+)
+
+func Cache() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "cache",
+		Short: "Manage local caches",
+		Long:  "Manage the caches, saved results and temporary files the CLI keeps on disk",
+	}
+
+	cmd.AddCommand(cacheClean())
+
+	return cmd
+}
+
+func cacheClean() *cobra.Command {
+	var (
+		olderThan string
+		only      []string
+		dryRun    bool
+	)
+
+	cmd := &cobra.Command{
+		Use:   "clean",
+		Short: "Remove stale caches, saved results and leftover temp files",
+		Long: fmt.Sprintf(`Remove what the CLI leaves behind that is older than --older-than:
+
+  scan-cache   cached diff scans in ~/.kusari/scan-cache.json, and those of
+               repositories that no longer exist
+  delta-bases  bases of --delta scans in ~/.kusari/delta-bases.json; the
+               next full scan of their repository uploads the whole bundle
+  results      results saved for 'kusari results --last' in ~/.kusari/results
+  uploads      interrupted uploads kept for 'kusari repo scan --resume' in
+               ~/.kusari/uploads
+  waybill      Waybill binaries of earlier CLI versions in ~/.kusari/bin
+  temp         kusari-* and waybill-*.tar.gz in the temp directory (%s),
+               left by scans and downloads that crashed
+
+--older-than takes a Go duration or a number of days, e.g. 12h or 30d; 0
+removes everything. Keep it above the longest scan when other scans may be
+running, or their temp directories go too.
+
+Examples:
+  kusari cache clean --dry-run
+  kusari cache clean --older-than 30d --only results,temp`, os.TempDir()),
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cmd.SilenceUsage = true
+
+			age, err := parseAge(olderThan)
+			if err != nil {
+				return clierrors.NewValidationError("invalid --older-than %q (use a duration like 12h or a number of days like 7d)", olderThan)
+			}
+
+			for _, k := range only {
+				if !slices.Contains(cleanup.Kinds, k) {
+					return clierrors.NewValidationError("invalid --only %q (must be one of %s)", k, strings.Join(cleanup.Kinds, ", "))
+				}
+			}
+
+			items, err := cleanup.Clean(cleanup.Options{OlderThan: age, Kinds: only, DryRun: dryRun})
+			var freed int64
+			for _, item := range items {
+				freed += item.Size
+				if item.Size > 0 {
+					fmt.Printf("%-11s  %8s  %s\n", item.Kind, output.FormatSize(item.Size), item.Path)
+				} else {
+					fmt.Printf("%-11s  %8s  %s\n", item.Kind, "", item.Path)
+				}
+			}
+
+			verb := "Removed"
+			if dryRun {
+				verb = "Would remove"
+			}
+			fmt.Fprintf(os.Stderr, "%s %d item(s), %s\n", verb, len(items), output.FormatSize(freed))
+			return err
+		},
+	}
+
+	cmd.Flags().StringVar(&olderThan, "older-than", "7d", "Only remove items older than this")
+	cmd.Flags().StringSliceVar(&only, "only", nil, "Only clean these kinds: "+strings.Join(cleanup.Kinds, ", "))
+	cmd.Flags().BoolVar(&dryRun, "dry-run", false, "List what would be removed without removing it")
+	return cmd
+}
+
+// parseAge parses a Go duration, or a whole number of days such as "7d".
+func parseAge(s string) (time.Duration, error) {
+	if days, ok := strings.CutSuffix(s, "d"); ok {
+		n, err := strconv.Atoi(days)
+		if err != nil || n < 0 {
+			return 0, fmt.Errorf("invalid number of days %q", days)
+		}
+		return time.Duration(n) * 24 * time.Hour, nil
+	}
+	d, err := time.ParseDuration(s)
+	if err != nil || d < 0 {
+		return 0, fmt.Errorf("invalid duration %q", s)
+	}
+	return d, nil
+}
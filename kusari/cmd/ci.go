@@ -0,0 +1,109 @@
+// Copyright (c) Kusari <https://www.kusari.dev/>
+// SPDX-License-Identifier: MIT
+
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/kusaridev/kusari-cli/v2/pkg/ci"
+	"github.com/kusaridev/kusari-cli/v2/pkg/clierrors"
+	"github.com/spf13/cobra"
+)
+
+func CI() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "ci",
+		Short: "CI/CD integration helpers",
+		Long:  "Generate CI/CD pipeline definitions that run the Kusari CLI",
+	}
+
+	cmd.AddCommand(ciGenerate())
+
+	return cmd
+}
+
+func ciGenerate() *cobra.Command {
+	var (
+		platform    string
+		opts        ci.Options
+		outputPath  string
+		force       bool
+		cliVersion  string
+		nightlyCron string
+	)
+
+	cmd := &cobra.Command{
+		Use:   "generate",
+		Short: "Generate a CI pipeline that runs Kusari",
+		Long: `Generate a ready-to-use pipeline definition for GitHub Actions, GitLab CI,
+Azure Pipelines, or Jenkins. Choose any combination of workflows:
+
+  --pr-scan       diff scan with Kusari Inspector on every pull/merge request
+  --nightly       full scan (risk check) on a schedule
+  --release-sbom  generate and upload an SBOM when a release is tagged
+
+The pipeline installs a pinned kusari-cli version (this CLI's version by
+default) so it keeps behaving the same until you bump it.
+
+Examples:
+  kusari ci generate --platform github
+  kusari ci generate --platform gitlab --nightly --release-sbom --tenant demo
+  kusari ci generate --platform jenkins --pr-scan=false --nightly --output -`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cmd.SilenceUsage = true
+
+			p := ci.Platform(platform)
+			if ci.DefaultPath(p) == "" {
+				return clierrors.NewValidationError("invalid --platform %q (must be github, gitlab, azure, or jenkins)", platform)
+			}
+			if !opts.PRScan && !opts.NightlyScan && !opts.ReleaseSBOM {
+				return clierrors.NewValidationError("select at least one of --pr-scan, --nightly, or --release-sbom")
+			}
+
+			opts.CLIVersion = cliVersion
+			opts.NightlyCron = nightlyCron
+			content, err := ci.Render(p, opts)
+			if err != nil {
+				return err
+			}
+
+			if outputPath == "-" {
+				_, err := os.Stdout.Write(content)
+				return err
+			}
+			if outputPath == "" {
+				outputPath = ci.DefaultPath(p)
+			}
+			if _, err := os.Stat(outputPath); err == nil && !force {
+				return clierrors.NewValidationError("%s already exists (use --force to overwrite, or --output - to print instead)", outputPath)
+			}
+			if err := os.MkdirAll(filepath.Dir(outputPath), 0755); err != nil {
+				return fmt.Errorf("failed to create %s: %w", filepath.Dir(outputPath), err)
+			}
+			if err := os.WriteFile(outputPath, content, 0644); err != nil {
+				return fmt.Errorf("failed to write %s: %w", outputPath, err)
+			}
+			fmt.Printf("Wrote %s\n", outputPath)
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&platform, "platform", "", "CI platform: github, gitlab, azure, or jenkins (required)")
+	cmd.Flags().BoolVar(&opts.PRScan, "pr-scan", true, "Diff scan on pull/merge requests")
+	cmd.Flags().BoolVar(&opts.NightlyScan, "nightly", false, "Scheduled full scan (risk check)")
+	cmd.Flags().BoolVar(&opts.ReleaseSBOM, "release-sbom", false, "Generate and upload an SBOM on release tags")
+	cmd.Flags().StringVar(&nightlyCron, "cron", ci.DefaultNightlyCron, "Cron schedule for --nightly")
+	cmd.Flags().StringVar(&opts.Tenant, "tenant", "", "Tenant for SBOM upload (default: read from a KUSARI_TENANT CI variable)")
+	cmd.Flags().StringVar(&cliVersion, "cli-version", getVersion(), "kusari-cli version the pipeline installs")
+	cmd.Flags().StringVarP(&outputPath, "output", "o", "", "File to write, or - for stdout (default: the platform's conventional path)")
+	cmd.Flags().BoolVar(&force, "force", false, "Overwrite an existing file")
+	if err := cmd.MarkFlagRequired("platform"); err != nil {
+		panic(err)
+	}
+
+	return cmd
+}
@@ -2,6 +2,12 @@ package cmd
 
 import "github.com/spf13/cobra"
 
+var (
+	// strictConfig fails on unknown or mistyped settings of kusari.yaml, for
+	// the subcommands that parse it.
+	strictConfig bool
+)
+
 func KusariConfiguration() *cobra.Command {
 	cmd := &cobra.Command{
 		Use:   "config",
@@ -10,9 +16,11 @@ func KusariConfiguration() *cobra.Command {
 		Long:    "Generate kusari-cli configuration file",
 		Aliases: []string{"configuration"}, // alias to help existing users. Drop for 1.0
 	}
+	cmd.PersistentFlags().BoolVar(&strictConfig, "strict", false, "Fail when the config file has unknown settings or settings of the wrong type, instead of dropping or converting them")
 
 	cmd.AddCommand(generateConfig())
 	cmd.AddCommand(updateConfig())
+	cmd.AddCommand(pushConfig())
 
 	return cmd
 }
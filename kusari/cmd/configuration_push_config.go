@@ -0,0 +1,166 @@
+// Copyright (c) Kusari <https://www.kusari.dev/>
+// SPDX-License-Identifier: MIT
+
+package cmd
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/kusaridev/kusari-cli/v2/pkg/clierrors"
+	"github.com/kusaridev/kusari-cli/v2/pkg/configuration"
+	"github.com/kusaridev/kusari-cli/v2/pkg/github"
+	"github.com/spf13/cobra"
+)
+
+func pushConfig() *cobra.Command {
+	var (
+		org           string
+		repos         []string
+		reposFile     string
+		file          string
+		branch        string
+		title         string
+		appID         string
+		appPrivateKey string
+	)
+
+	cmd := &cobra.Command{
+		Use:   "push",
+		Short: fmt.Sprintf("Roll a %s config file out to many GitHub repositories", configuration.ConfigFilename),
+		Long: `Commit the config file to a branch of each repository of a GitHub
+organization and open a pull request of it into the default branch, to
+standardize the Kusari Inspector configuration of many repositories.
+
+The repositories are given with --repos, or --repos-file with one per line
+(# starts a comment). Running it again with a changed file resets the
+branch and updates the open pull requests; repositories whose default
+branch already has the file are left alone. A branch someone else pushed
+commits to is never reset, and --branch can't be the default branch; such
+a repository fails, which doesn't stop the others.
+
+The file is checked to be a valid config first. It authenticates with
+GITHUB_TOKEN (or GH_TOKEN), or as a GitHub App the organization owns with
+--app-id and --app-private-key, which needs contents and pull requests
+write permission. GITHUB_API_URL points it at GitHub Enterprise Server.
+
+Examples:
+  kusari config push --org acme --repos api,web,worker
+  kusari config push --org acme --repos-file repos.txt --app-id 123456 --app-private-key app.pem`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cmd.SilenceUsage = true
+			configuration.SetStrict(strictConfig)
+
+			if org == "" {
+				return clierrors.NewValidationError("--org is required")
+			}
+			if reposFile != "" {
+				listed, err := readRepoList(reposFile)
+				if err != nil {
+					return err
+				}
+				repos = append(repos, listed...)
+			}
+			if len(repos) == 0 {
+				return clierrors.NewValidationError("no repositories given (use --repos or --repos-file)")
+			}
+
+			gitHubURL := github.GetGitHubAPIURLFromEnv()
+			var token string
+			if appID != "" {
+				if appPrivateKey == "" {
+					return clierrors.NewValidationError("--app-id needs --app-private-key")
+				}
+				key, err := os.ReadFile(appPrivateKey)
+				if err != nil {
+					return fmt.Errorf("failed to read GitHub App private key: %w", err)
+				}
+				token, err = github.AppToken(github.AppOptions{AppID: appID, PrivateKey: key, Org: org, GitHubURL: gitHubURL})
+				if err != nil {
+					return err
+				}
+			} else {
+				token = github.GetTokenFromEnv()
+				if token == "" {
+					return clierrors.NewValidationError("no GitHub token found (set GITHUB_TOKEN or GH_TOKEN, or use --app-id)")
+				}
+			}
+
+			pushed, err := configuration.Push(file, github.PushOptions{
+				Org:           org,
+				Repos:         repos,
+				Path:          configuration.ConfigFilename,
+				Branch:        branch,
+				CommitMessage: fmt.Sprintf("Update %s", configuration.ConfigFilename),
+				Title:         title,
+				Body: fmt.Sprintf("This updates %s, the Kusari Inspector configuration, to the one "+
+					"standardized for the %s organization.", configuration.ConfigFilename, org),
+				GitHubURL: gitHubURL,
+				Token:     token,
+				Verbose:   verbose,
+			})
+			if err != nil {
+				return err
+			}
+
+			var failed int
+			var firstErr error
+			for _, p := range pushed {
+				if p.Err != nil {
+					fmt.Fprintf(os.Stderr, "Warning: failed to push to %s/%s: %v\n", org, p.Repo, p.Err)
+					failed++
+					if firstErr == nil {
+						firstErr = p.Err
+					}
+					continue
+				}
+				if p.URL != "" {
+					fmt.Printf("%s/%s: %s %s\n", org, p.Repo, p.Outcome, p.URL)
+				} else {
+					fmt.Printf("%s/%s: %s\n", org, p.Repo, p.Outcome)
+				}
+			}
+			if firstErr != nil {
+				return fmt.Errorf("failed to push to %d of %d repositories: %w", failed, len(pushed), firstErr)
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&org, "org", "", "GitHub organization of the repositories")
+	cmd.Flags().StringSliceVar(&repos, "repos", nil, "Comma-separated repositories of the organization to push to")
+	cmd.Flags().StringVar(&reposFile, "repos-file", "", "File listing the repositories to push to, one per line")
+	cmd.Flags().StringVar(&file, "file", configuration.ConfigFilename, "Config file to push")
+	cmd.Flags().StringVar(&branch, "branch", "kusari/config", "Branch the config file is committed to")
+	cmd.Flags().StringVar(&title, "title", "Standardize Kusari Inspector configuration", "Title of the pull requests")
+	cmd.Flags().StringVar(&appID, "app-id", "", "ID of a GitHub App of the organization to authenticate as")
+	cmd.Flags().StringVar(&appPrivateKey, "app-private-key", "", "PEM private key file of the GitHub App")
+
+	return cmd
+}
+
+// readRepoList reads the repositories listed in path, one per line,
+// skipping blank lines and # comments.
+func readRepoList(path string) ([]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read repository list: %w", err)
+	}
+	defer func() { _ = f.Close() }()
+
+	var repos []string
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line, _, _ := strings.Cut(scanner.Text(), "#")
+		if line = strings.TrimSpace(line); line != "" {
+			repos = append(repos, line)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read repository list: %w", err)
+	}
+	return repos, nil
+}
@@ -13,6 +13,7 @@ import (
 func updateConfig() *cobra.Command {
 	updatecmd.RunE = func(cmd *cobra.Command, args []string) error {
 		cmd.SilenceUsage = true
+		configuration.SetStrict(strictConfig)
 
 		return configuration.UpdateConfig()
 	}
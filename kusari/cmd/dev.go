@@ -0,0 +1,126 @@
+// Copyright (c) Kusari <https://www.kusari.dev/>
+// SPDX-License-Identifier: MIT
+
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/kusaridev/kusari-cli/v2/pkg/clierrors"
+	"github.com/kusaridev/kusari-cli/v2/pkg/fakeplatform"
+	"github.com/spf13/cobra"
+)
+
+func Dev() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "dev",
+		Short: "Tools for developing against the Kusari platform",
+	}
+
+	cmd.AddCommand(devMockServer())
+
+	return cmd
+}
+
+func devMockServer() *cobra.Command {
+	var (
+		host         string
+		port         int
+		scenario     string
+		scenarioFile string
+		quiet        bool
+	)
+
+	cmd := &cobra.Command{
+		Use:   "mock-server",
+		Short: "Serve a fake Kusari platform for testing CI integrations",
+		Long: `Serve a fake Kusari platform that answers scans, SBOM uploads and the
+software API from a scenario, without analyzing anything, to test a CI
+integration end to end without consuming analysis quota. Point the CLI at it
+with --platform-url and --tenant-endpoint (or KUSARI_PLATFORM_URL and
+KUSARI_TENANT_ENDPOINT); any KUSARI_API_KEY is accepted. Stops on Ctrl-C.
+
+Scenarios:
+  pass              every scan passes with no findings (the default)
+  block             every scan finds an issue and recommends not to proceed
+  fail              every scan fails processing on the platform
+  slow              scans and ingestion take a few polls to complete
+  forbidden         the API key may not upload (presigning returns 403)
+  ingestion-failed  uploaded SBOMs fail ingestion
+
+--scenario-file reads a JSON scenario instead, setting any of workspaces,
+processing, analysis, failed_details, presign_status, presign_body,
+ingestion, ingestion_message and software.
+
+Examples:
+  kusari dev mock-server --scenario block
+  kusari dev mock-server --port 0 --scenario-file ci/scenario.json`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cmd.SilenceUsage = true
+
+			if port < 0 || port > 65535 {
+				return clierrors.NewValidationError("invalid --port %d", port)
+			}
+			var s fakeplatform.Scenario
+			var err error
+			if scenarioFile != "" {
+				if cmd.Flags().Changed("scenario") {
+					return clierrors.NewValidationError("pass either --scenario or --scenario-file, not both")
+				}
+				s, err = fakeplatform.LoadScenario(scenarioFile)
+				scenario = scenarioFile
+			} else if s, err = fakeplatform.LookupScenario(scenario); err != nil {
+				err = clierrors.NewValidationError("%v", err)
+			}
+			if err != nil {
+				return err
+			}
+
+			l, err := net.Listen("tcp", net.JoinHostPort(host, strconv.Itoa(port)))
+			if err != nil {
+				return fmt.Errorf("failed to listen: %w", err)
+			}
+			server := fakeplatform.New(s)
+			if !quiet {
+				server.Log = os.Stderr
+			}
+			srv := &http.Server{Handler: server, ReadHeaderTimeout: 10 * time.Second}
+
+			ctx, stop := signal.NotifyContext(cmd.Context(), os.Interrupt, syscall.SIGTERM)
+			defer stop()
+			go func() {
+				<-ctx.Done()
+				shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+				defer cancel()
+				_ = srv.Shutdown(shutdownCtx)
+			}()
+
+			url := "http://" + l.Addr().String()
+			fmt.Fprintf(os.Stderr, "Fake Kusari platform (scenario %s) listening on %s\n", scenario, url)
+			fmt.Fprintf(os.Stderr, "Point the CLI at it with:\n  export KUSARI_API_KEY=fake KUSARI_PLATFORM_URL=%s/ KUSARI_TENANT_ENDPOINT=%s\n", url, url)
+			if err := srv.Serve(l); !errors.Is(err, http.ErrServerClosed) {
+				return fmt.Errorf("mock server failed: %w", err)
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&host, "host", "127.0.0.1", "Address to listen on")
+	cmd.Flags().IntVar(&port, "port", 8787, "Port to listen on (0 for any free port)")
+	cmd.Flags().StringVar(&scenario, "scenario", "pass", "Predefined scenario to serve ("+strings.Join(fakeplatform.ScenarioNames(), ", ")+")")
+	cmd.Flags().StringVar(&scenarioFile, "scenario-file", "", "JSON file of the scenario to serve, instead of --scenario")
+	cmd.Flags().BoolVar(&quiet, "quiet", false, "Don't log each request to stderr")
+
+	return cmd
+}
@@ -0,0 +1,99 @@
+// Copyright (c) Kusari <https://www.kusari.dev/>
+// SPDX-License-Identifier: MIT
+
+package cmd
+
+import (
+	"strings"
+
+	"github.com/kusaridev/kusari-cli/v2/pkg/clierrors"
+	"github.com/kusaridev/kusari-cli/v2/pkg/repo"
+	"github.com/spf13/cobra"
+)
+
+func Image() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "image",
+		Short: "Container image operations",
+		Long:  "Analyze container images and the Dockerfiles that build them",
+	}
+
+	cmd.AddCommand(imageCheck())
+
+	return cmd
+}
+
+func imageCheck() *cobra.Command {
+	var (
+		wait         bool
+		outputFormat string
+		outputs      []string
+		fullOutput   bool
+		inspect      bool
+	)
+
+	cmd := &cobra.Command{
+		Use:   "check <Dockerfile|image-ref>",
+		Short: "Check a container image with Kusari Inspector",
+		Long: `Submit a Dockerfile, or a container image reference, for a container-focused
+analysis in Kusari Inspector: base image pinning and the vulnerability
+posture of the images.
+    <Dockerfile|image-ref>  A Dockerfile, a directory holding one, or an image
+                            reference such as golang:1.25 or ghcr.io/org/app@sha256:...
+
+The base images of a Dockerfile are listed before the upload, with whether
+each is pinned by digest. Builds FROM an unpinned tag can pick up a
+different image each time.
+
+--inspect also sends what 'docker image inspect' reports about the image,
+or about the Dockerfile's base images: their config, layers and digests.
+It needs the docker CLI, and only covers images that have been pulled;
+the digest to pin each unpinned base image to is then suggested too.
+
+The results take the same output formats as 'kusari repo scan'.
+
+Examples:
+  kusari image check Dockerfile
+  kusari image check . --inspect
+  kusari image check ghcr.io/org/app:1.4 --output sarif-file=image.sarif,markdown`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cmd.SilenceUsage = true
+
+			if len(outputs) > 0 {
+				outputFormat = strings.Join(outputs, ",")
+			} else if outputFormat != "markdown" && outputFormat != "sarif" {
+				return clierrors.NewValidationError("invalid output format: %s (must be 'markdown' or 'sarif')", outputFormat)
+			}
+			if _, err := repo.ParseOutputs(outputFormat); err != nil {
+				return err
+			}
+
+			preUpload, postResult, err := hookScripts()
+			if err != nil {
+				return err
+			}
+
+			return repo.ImageCheck(args[0], inspect, repo.ScanOptions{
+				PlatformURL:      platformUrl,
+				ConsoleURL:       consoleUrl,
+				Verbose:          verbose,
+				Wait:             wait,
+				OutputFormat:     outputFormat,
+				FullOutput:       fullOutput,
+				PreUploadHook:    preUpload,
+				PostResultHook:   postResult,
+				BundleEncryption: repo.BundleEncryption(bundleEncryption),
+			})
+		},
+	}
+
+	cmd.Flags().BoolVarP(&wait, "wait", "w", true, "wait for results")
+	cmd.Flags().StringVarP(&outputFormat, "output-format", "", "markdown", "output format (markdown or sarif)")
+	cmd.Flags().StringSliceVar(&outputs, "output", nil, "outputs to write, comma-separated or repeated, as for 'kusari repo scan'; overrides --output-format")
+	cmd.Flags().BoolVar(&fullOutput, "full-output", false, "output full results instead of truncated")
+	cmd.Flags().BoolVar(&inspect, "inspect", false, "also send the docker image inspect output of the image or base images (they must be pulled)")
+	addHookFlags(cmd)
+
+	return cmd
+}
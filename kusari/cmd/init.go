@@ -0,0 +1,189 @@
+// Copyright (c) Kusari <https://www.kusari.dev/>
+// SPDX-License-Identifier: MIT
+
+package cmd
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/kusaridev/kusari-cli/v2/pkg/auth"
+	"github.com/kusaridev/kusari-cli/v2/pkg/ci"
+	"github.com/kusaridev/kusari-cli/v2/pkg/clierrors"
+	"github.com/kusaridev/kusari-cli/v2/pkg/configuration"
+	"github.com/kusaridev/kusari-cli/v2/pkg/githook"
+	"github.com/kusaridev/kusari-cli/v2/pkg/output"
+	"github.com/spf13/cobra"
+)
+
+var (
+	initYes   bool
+	initCI    string
+	initHook  bool
+	initForce bool
+)
+
+func init() {
+	initCmd.Flags().BoolVarP(&initYes, "yes", "y", false, "Accept the default answer to every question (non-interactive)")
+	initCmd.Flags().StringVar(&initCI, "ci", "", "CI system to add a pipeline for: github, gitlab, azure, jenkins, or none (default: detected)")
+	initCmd.Flags().BoolVar(&initHook, "hook", false, "Install a git pre-push hook that runs Kusari Inspector")
+	initCmd.Flags().BoolVar(&initForce, "force", false, "Overwrite existing pipeline files and hooks")
+}
+
+// prompter asks yes/no questions, answering with the default when
+// non-interactive so init never blocks in CI.
+type prompter struct {
+	reader      *bufio.Reader
+	interactive bool
+}
+
+func (p *prompter) confirm(question string, def bool) bool {
+	hint := "[y/N]"
+	if def {
+		hint = "[Y/n]"
+	}
+	if !p.interactive {
+		return def
+	}
+	for {
+		fmt.Printf("%s %s ", question, hint)
+		input, err := p.reader.ReadString('\n')
+		if err != nil {
+			return def
+		}
+		switch strings.ToLower(strings.TrimSpace(input)) {
+		case "":
+			return def
+		case "y", "yes":
+			return true
+		case "n", "no":
+			return false
+		}
+	}
+}
+
+var initCmd = &cobra.Command{
+	Use:   "init",
+	Short: "Set up a repository for Kusari",
+	Long: `Set up the current repository for Kusari in one step:
+
+  1. check that you are logged in
+  2. create or update kusari.yaml
+  3. add a CI pipeline that scans changes (see 'kusari ci generate' for more options)
+  4. optionally install a git pre-push hook that scans before each push
+
+Run with --yes to accept all defaults without prompting.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cmd.SilenceUsage = true
+
+		p := &prompter{
+			reader:      bufio.NewReader(os.Stdin),
+			interactive: !initYes && output.Interactive(),
+		}
+
+		// 1. Auth
+		if provider := auth.DefaultTokenProvider(); provider.Machine() {
+			fmt.Printf("Authentication: using %s\n", provider.Name())
+		} else if _, err := provider.Token(cmd.Context()); err != nil {
+			fmt.Println("Authentication: not logged in. Run `kusari auth login` before scanning.")
+		} else {
+			fmt.Println("Authentication: logged in")
+		}
+
+		// 2. kusari.yaml
+		if _, err := os.Stat(configuration.ConfigFilename); err == nil {
+			if p.confirm(fmt.Sprintf("Update %s with any new settings?", configuration.ConfigFilename), true) {
+				if err := configuration.UpdateConfig(); err != nil {
+					return err
+				}
+				fmt.Printf("Updated %s\n", configuration.ConfigFilename)
+			}
+		} else if p.confirm(fmt.Sprintf("Create %s with default settings?", configuration.ConfigFilename), true) {
+			if err := configuration.GenerateConfig(false); err != nil {
+				return err
+			}
+			fmt.Printf("Created %s\n", configuration.ConfigFilename)
+		}
+
+		// 3. CI pipeline
+		platform := ci.Platform(initCI)
+		if initCI == "" {
+			platform = ci.Detect(".")
+		}
+		switch platform {
+		case "", "none":
+			fmt.Println("CI: no GitHub or GitLab setup detected; pass --ci github|gitlab to add a pipeline.")
+		case ci.GitHub, ci.GitLab, ci.Azure, ci.Jenkins:
+			if p.confirm(fmt.Sprintf("Add a %s pipeline that scans changes with Kusari Inspector?", platform), true) {
+				path, err := writePipeline(platform)
+				if err != nil {
+					return err
+				}
+				if path != "" {
+					fmt.Printf("Wrote %s. Add KUSARI_CLIENT_ID and KUSARI_CLIENT_SECRET as CI secrets.\n", path)
+				}
+			}
+		default:
+			return clierrors.NewValidationError("invalid --ci value %q (must be github, gitlab, azure, jenkins, or none)", initCI)
+		}
+
+		// 4. git hook
+		if initHook || p.confirm("Install a git pre-push hook that runs Kusari Inspector?", false) {
+			path, err := githook.Install(".", initForce)
+			if errors.Is(err, githook.ErrHookExists) {
+				fmt.Printf("Skipped hook: %v\n", err)
+			} else if err != nil {
+				return err
+			} else {
+				fmt.Printf("Installed %s\n", path)
+			}
+		}
+
+		fmt.Println("\nDone. Run `kusari repo scan . origin/HEAD` to scan your current changes.")
+		return nil
+	},
+}
+
+// writePipeline writes the scan pipeline for platform. For GitLab the job is
+// appended to an existing .gitlab-ci.yml. It returns "" when nothing was
+// written because the pipeline is already present.
+func writePipeline(platform ci.Platform) (string, error) {
+	content, err := ci.Render(platform, ci.Options{CLIVersion: getVersion(), PRScan: true})
+	if err != nil {
+		return "", err
+	}
+	path := ci.DefaultPath(platform)
+
+	existing, err := os.ReadFile(path)
+	switch {
+	case err == nil && platform == ci.GitLab:
+		// Never replace a whole .gitlab-ci.yml, even with --force.
+		if strings.Contains(string(existing), "kusari-inspector:") {
+			fmt.Printf("Skipped CI: %s already has a kusari-inspector job.\n", path)
+			return "", nil
+		}
+		content = append(append(existing, '\n'), content...)
+	case err == nil && !initForce:
+		fmt.Printf("Skipped CI: %s already exists (use --force to replace it).\n", path)
+		return "", nil
+	case err != nil && !os.IsNotExist(err):
+		return "", fmt.Errorf("failed to read %s: %w", path, err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return "", fmt.Errorf("failed to create %s: %w", filepath.Dir(path), err)
+	}
+	if err := os.WriteFile(path, content, 0644); err != nil {
+		return "", fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	return path, nil
+}
+
+func initRepo() *cobra.Command {
+	return initCmd
+}
@@ -0,0 +1,70 @@
+// Copyright (c) Kusari <https://www.kusari.dev/>
+// SPDX-License-Identifier: MIT
+
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/kusaridev/kusari-cli/v2/pkg/lsp"
+	"github.com/kusaridev/kusari-cli/v2/pkg/repo"
+	"github.com/spf13/cobra"
+)
+
+func lspCmd() *cobra.Command {
+	var baseRef string
+
+	cmd := &cobra.Command{
+		Use:   "lsp",
+		Short: "Serve Kusari findings as Language Server Protocol diagnostics",
+		Long: `Run a Language Server Protocol server over stdio that shows the findings of the
+latest Kusari Inspector scan of the open repository as diagnostics.
+
+Diagnostics come from the cached results of the last SARIF scan, re-read whenever a file is
+opened or saved. Run the "kusari.refresh" command (workspace/executeCommand) to scan the
+working tree against --base-ref and refresh them.
+
+This command is meant to be started by an editor, not run directly.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cmd.SilenceUsage = true
+
+			// stdout carries the protocol; anything else the scan code
+			// prints goes to stderr instead.
+			protocolOut := os.Stdout
+			os.Stdout = os.Stderr
+
+			source := func(ctx context.Context, root string, rescan bool) (*lsp.Findings, error) {
+				if rescan {
+					opts := repo.ScanOptions{Dir: root, Rev: baseRef, PlatformURL: platformUrl, ConsoleURL: consoleUrl, Verbose: verbose, Wait: true, OutputFormat: "sarif", FullOutput: true,
+						BundleEncryption: repo.BundleEncryption(bundleEncryption)}
+					if err := repo.Scan(opts); err != nil {
+						return nil, err
+					}
+				}
+				entry, _, err := repo.LastScanResult(root)
+				if err != nil || entry == nil {
+					if rescan {
+						return nil, fmt.Errorf("scan finished but no results were cached")
+					}
+					return nil, err
+				}
+				findings, err := lsp.ParseFindings(entry.Results)
+				if err != nil && !rescan {
+					// The last scan was run with markdown output; wait
+					// for a refresh rather than erroring on every open.
+					return nil, nil
+				}
+				return findings, err
+			}
+
+			return lsp.NewServer(os.Stdin, protocolOut, source).Run(cmd.Context())
+		},
+	}
+
+	cmd.Flags().StringVar(&baseRef, "base-ref", "HEAD", "Git revision to compare the working tree to when refreshing")
+
+	return cmd
+}
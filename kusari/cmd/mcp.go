@@ -0,0 +1,28 @@
+// Copyright (c) Kusari <https://www.kusari.dev/>
+// SPDX-License-Identifier: MIT
+
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+)
+
+// MCP returns the parent command for the Model Context Protocol server.
+// `kusari mcp serve` is the same server as `kusari ai serve`, for editors
+// that are configured with an MCP command directly rather than through
+// `kusari ai install`.
+func MCP() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "mcp",
+		Short: "Model Context Protocol server for editors and AI assistants",
+		Long: `Run Kusari as a Model Context Protocol (MCP) server over stdio.
+
+The server exposes local change scans, repository risk checks, the last scan's results,
+SBOM upload, and vulnerability queries as MCP tools, so IDE assistants can run Kusari
+analysis of the current workspace and read findings programmatically.`,
+	}
+
+	cmd.AddCommand(serve())
+
+	return cmd
+}
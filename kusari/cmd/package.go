@@ -0,0 +1,125 @@
+// Copyright (c) Kusari <https://www.kusari.dev/>
+// SPDX-License-Identifier: MIT
+
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/kusaridev/kusari-cli/v2/pkg/clierrors"
+	"github.com/kusaridev/kusari-cli/v2/pkg/pico"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+func Package() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "package",
+		Short: "Package operations",
+		Long:  "Look up what the Kusari platform knows about a package",
+		PersistentPreRun: func(cmd *cobra.Command, args []string) {
+			platformUrl = viper.GetString("platform-url")
+			verbose = viper.GetBool("verbose")
+			// platform binds these keys to its own flags; rebind them to
+			// ours for this run.
+			mustBindPFlag("tenant-endpoint", cmd.Flags().Lookup("tenant-endpoint"))
+			mustBindPFlag("tenant", cmd.Flags().Lookup("tenant"))
+			resolveTenant()
+		},
+	}
+
+	cmd.PersistentFlags().StringP("tenant-endpoint", "t", "", "Kusari Tenant endpoint URL (for dev/testing, overrides --tenant)")
+	cmd.PersistentFlags().String("tenant", "", "Tenant name (e.g., 'demo' for https://demo.api.us.kusari.cloud)")
+
+	cmd.AddCommand(packageInfo())
+
+	return cmd
+}
+
+func packageInfo() *cobra.Command {
+	var jsonOutput bool
+
+	cmd := &cobra.Command{
+		Use:   "info <purl>",
+		Short: "Check a package before depending on it",
+		Long: `Look up a single package version by its package URL and print a verdict:
+whether it is on the workspace's blocked package list, its known advisories
+and how the workspace's policies judge it. Handy when evaluating a new
+dependency before adding it.
+
+The verdict is block (blocked, or a policy fails), warn (known advisories,
+a policy warns, or the package is end of life or deprecated) or ok. A block
+verdict exits with code 6, as blocked packages in an upload do.
+
+Examples:
+  kusari package info pkg:npm/lodash@4.17.20
+  kusari package info pkg:golang/github.com/gin-gonic/gin@v1.9.0 --json`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cmd.SilenceUsage = true
+			purl := args[0]
+
+			if err := pico.ValidatePurl(purl); err != nil {
+				return clierrors.NewValidationError("%v", err)
+			}
+			if platformTenantEndpoint == "" {
+				return fmt.Errorf("no tenant configured. Use --tenant flag or run `kusari auth login` to select a tenant")
+			}
+
+			client := pico.NewClient(platformTenantEndpoint)
+			info, err := client.GetPackageInfo(context.Background(), purl)
+			if err != nil {
+				return fmt.Errorf("failed to get package info: %w", err)
+			}
+			verdict, _ := info.Verdict()
+
+			if jsonOutput {
+				out, err := json.MarshalIndent(info, "", "  ")
+				if err != nil {
+					return fmt.Errorf("failed to format output: %w", err)
+				}
+				fmt.Println(string(out))
+			} else {
+				printPackageInfo(purl, info)
+			}
+
+			if verdict == pico.VerdictBlock {
+				return &clierrors.BlockedPackagesError{Purl: purl}
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().BoolVar(&jsonOutput, "json", false, "print the platform's answer as JSON instead of a verdict")
+
+	return cmd
+}
+
+// printPackageInfo prints the verdict on purl, why, and its advisories.
+func printPackageInfo(purl string, info *pico.PackageInfo) {
+	verdict, reasons := info.Verdict()
+	fmt.Printf("%s: %s\n", purl, strings.ToUpper(verdict))
+	for _, r := range reasons {
+		fmt.Printf("  - %s\n", r)
+	}
+	if len(info.Advisories) == 0 {
+		return
+	}
+	fmt.Println("\nAdvisories:")
+	for _, a := range info.Advisories {
+		line := "  " + a.ID
+		if a.Severity != "" {
+			line += " (" + a.Severity + ")"
+		}
+		if a.Summary != "" {
+			line += ": " + a.Summary
+		}
+		if a.FixedIn != "" {
+			line += "; fixed in " + a.FixedIn
+		}
+		fmt.Println(line)
+	}
+}
@@ -33,6 +33,10 @@ func Platform() *cobra.Command {
 	platformCmd.AddCommand(software())
 	platformCmd.AddCommand(components())
 	platformCmd.AddCommand(generate())
+	platformCmd.AddCommand(flush())
+	platformCmd.AddCommand(configure())
+	platformCmd.AddCommand(reupload())
+	platformCmd.AddCommand(prune())
 
 	return platformCmd
 }
@@ -42,36 +46,42 @@ var platformCmd = &cobra.Command{
 	Short: "Kusari platform operations",
 	Long:  "Handle interactions with the Kusari platform operations ",
 	PersistentPreRun: func(cmd *cobra.Command, args []string) {
-		// Update from viper (this gets env vars + config + flags)
-		platformTenantEndpoint = viper.GetString("tenant-endpoint")
-		platformTenant = viper.GetString("tenant")
+		resolveTenant()
+	},
+}
 
-		// If tenant-endpoint is provided, use it directly (for dev/testing)
-		if platformTenantEndpoint != "" {
-			return
-		}
+// resolveTenant sets platformTenant and platformTenantEndpoint from
+// --tenant-endpoint, --tenant or the stored workspace, in that order.
+func resolveTenant() {
+	// Update from viper (this gets env vars + config + flags)
+	platformTenantEndpoint = viper.GetString("tenant-endpoint")
+	platformTenant = viper.GetString("tenant")
 
-		// If tenant is provided via flag, construct the endpoint
-		if platformTenant != "" {
-			platformTenantEndpoint = fmt.Sprintf("https://%s.api.us.kusari.cloud", platformTenant)
-			return
-		}
+	// If tenant-endpoint is provided, use it directly (for dev/testing)
+	if platformTenantEndpoint != "" {
+		return
+	}
 
-		// Neither flag provided - try to load from workspace config
-		workspace, err := auth.LoadWorkspace(platformUrl, "")
-		if err != nil {
-			// Store the error to provide helpful message later if command fails
-			if verbose {
-				fmt.Fprintf(os.Stderr, "Warning: Could not load workspace configuration: %v\n", err)
-			}
-			return
-		}
+	// If tenant is provided via flag, construct the endpoint
+	if platformTenant != "" {
+		platformTenantEndpoint = auth.TenantEndpoint(platformTenant, auth.RegionFromPlatformURL(platformUrl))
+		return
+	}
 
-		if workspace.Tenant != "" {
-			platformTenant = workspace.Tenant
-			platformTenantEndpoint = fmt.Sprintf("https://%s.api.us.kusari.cloud", platformTenant)
-		} else if verbose {
-			fmt.Fprintf(os.Stderr, "Warning: Workspace loaded but no tenant configured\n")
+	// Neither flag provided - try to load from workspace config
+	workspace, err := auth.LoadWorkspace(platformUrl, "")
+	if err != nil {
+		// Store the error to provide helpful message later if command fails
+		if verbose {
+			fmt.Fprintf(os.Stderr, "Warning: Could not load workspace configuration: %v\n", err)
 		}
-	},
+		return
+	}
+
+	if workspace.Tenant != "" {
+		platformTenant = workspace.Tenant
+		platformTenantEndpoint = workspace.TenantEndpoint()
+	} else if verbose {
+		fmt.Fprintf(os.Stderr, "Warning: Workspace loaded but no tenant configured\n")
+	}
 }
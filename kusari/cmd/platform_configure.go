@@ -0,0 +1,109 @@
+// Copyright (c) Kusari <https://www.kusari.dev/>
+// SPDX-License-Identifier: MIT
+
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/kusaridev/kusari-cli/v2/pkg/auth"
+	l "github.com/kusaridev/kusari-cli/v2/pkg/login"
+	"github.com/kusaridev/kusari-cli/v2/pkg/pico"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+func configure() *cobra.Command {
+	var (
+		region    string
+		skipCheck bool
+	)
+
+	cmd := &cobra.Command{
+		Use:   "configure",
+		Short: "Pick the tenant platform commands use, and check it is reachable",
+		Long: fmt.Sprintf(`Build the tenant endpoint instead of writing --tenant-endpoint by hand:
+pick a workspace and one of its tenants from those of your login, check
+that the tenant's API answers with your credentials, and save the choice
+as your active workspace and tenant. Platform commands then use it
+without --tenant.
+
+The workspace comes from %s or a prompt, the tenant from --tenant, %s
+or a prompt. The region defaults to that of the platform URL (%s for
+the default platform); --region overrides it.
+
+Examples:
+  kusari platform configure
+  kusari platform configure --tenant demo --region us
+  %s=platform-team kusari platform configure --tenant demo`, auth.WorkspaceEnv, auth.TenantEnv, auth.DefaultRegion, auth.WorkspaceEnv),
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cmd.SilenceUsage = true
+
+			token, err := auth.DefaultTokenProvider().Token(cmd.Context())
+			if err != nil {
+				return fmt.Errorf("you must be logged in to configure a tenant. Run `kusari auth login`: %w", err)
+			}
+
+			workspaces, workspaceTenants, err := l.FetchWorkspaces(platformUrl, token.AccessToken)
+			if err != nil {
+				return fmt.Errorf("failed to fetch workspaces: %w", err)
+			}
+			current, _ := auth.LoadWorkspace(platformUrl, "")
+			infos := make([]auth.WorkspaceInfo, len(workspaces))
+			for i, ws := range workspaces {
+				infos[i] = auth.WorkspaceInfo{ID: ws.ID, Description: ws.Description, PlatformUrl: platformUrl}
+				if current != nil {
+					infos[i].AuthEndpoint = current.AuthEndpoint
+					infos[i].IsMachine = current.IsMachine
+				}
+			}
+			selected, err := auth.SelectWorkspace(infos)
+			if err != nil {
+				return fmt.Errorf("failed to select workspace: %w", err)
+			}
+
+			tenants := workspaceTenants[selected.ID]
+			if len(tenants) == 0 {
+				return fmt.Errorf("no tenants available for workspace %s", selected.Description)
+			}
+			var tenant string
+			// The flag or env value only: the pre-run fills platformTenant
+			// from the stored workspace too.
+			if ref := viper.GetString("tenant"); ref != "" {
+				tenant, err = auth.FindTenant(tenants, ref)
+			} else {
+				tenant, err = auth.SelectTenant(tenants)
+			}
+			if err != nil {
+				return fmt.Errorf("failed to select tenant: %w", err)
+			}
+
+			if region == "" {
+				region = auth.RegionFromPlatformURL(platformUrl)
+			}
+			selected.Tenant = tenant
+			selected.Region = region
+			endpoint := selected.TenantEndpoint()
+			fmt.Printf("Tenant endpoint: %s\n", endpoint)
+
+			if !skipCheck {
+				if _, err := pico.NewClient(endpoint).GetSoftwareList(cmd.Context(), "", 0, 1); err != nil {
+					return fmt.Errorf("tenant %s in region %s is not reachable (check --region, or use --skip-check to save it anyway): %w", tenant, region, err)
+				}
+				fmt.Println("✓ Tenant API reachable with your credentials")
+			}
+
+			if err := auth.SaveWorkspace(*selected); err != nil {
+				return fmt.Errorf("failed to save tenant selection: %w", err)
+			}
+			fmt.Printf("\nSaved: workspace '%s', tenant '%s' (%s) is now active.\n", selected.Description, tenant, region)
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&region, "region", "", "Region of the tenant, e.g. us (default: the platform URL's)")
+	cmd.Flags().BoolVar(&skipCheck, "skip-check", false, "Save the tenant without checking that its API is reachable")
+
+	return cmd
+}
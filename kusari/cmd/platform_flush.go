@@ -0,0 +1,28 @@
+// Copyright (c) Kusari <https://www.kusari.dev/>
+// SPDX-License-Identifier: MIT
+
+package cmd
+
+import (
+	"github.com/kusaridev/kusari-cli/v2/pkg/repo"
+	"github.com/spf13/cobra"
+)
+
+func flush() *cobra.Command {
+	return &cobra.Command{
+		Use:   "flush",
+		Short: "Upload the packages of interrupted scans",
+		Long: `Upload every scan package kept in ~/.kusari/uploads by a scan that was
+interrupted or failed to upload, without repackaging, and print where each
+result will be. Packages are discarded once uploaded; a package that fails
+to upload again is kept for the next flush.
+
+To wait for the results of the last interrupted scan instead, run
+'kusari repo scan --resume'.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cmd.SilenceUsage = true
+			return repo.Flush(verbose)
+		},
+	}
+}
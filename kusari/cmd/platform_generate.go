@@ -88,28 +88,7 @@ Examples:
 				return nil
 			}
 			warnIfDeprecatedComponentName(cmd)
-			return repo.Upload(
-				sbomOutputPath(args, defaultOutput),
-				platformTenantEndpoint,
-				platformUrl,
-				uploadAlias,
-				uploadDocumentType,
-				uploadOpenVex,
-				uploadTag,
-				uploadSoftwareID,
-				uploadSbomSubject,
-				uploadSbomSubjectNameOverride,
-				uploadSbomSubjectVersionOverride,
-				uploadCheckBlocked,
-				uploadWait,
-				uploadForge,
-				uploadOrg,
-				uploadRepo,
-				uploadSubrepoPath,
-				uploadCommitSha,
-				uploadResultsFile,
-				uploadMapComponents,
-			)
+			return repo.Upload(cmd.Context(), uploadOptions(sbomOutputPath(args, defaultOutput)))
 		},
 	}
 	cmd.Flags().BoolVar(&generateUpload, "upload", false, "After generating, upload the SBOM to the Kusari platform")
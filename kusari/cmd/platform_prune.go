@@ -0,0 +1,130 @@
+// Copyright (c) Kusari <https://www.kusari.dev/>
+// SPDX-License-Identifier: MIT
+
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/kusaridev/kusari-cli/v2/pkg/clierrors"
+	"github.com/kusaridev/kusari-cli/v2/pkg/pico"
+	"github.com/spf13/cobra"
+)
+
+// prunableSBOM is an SBOM prune lists, with the software entry it belongs to.
+type prunableSBOM struct {
+	software pico.SoftwareEntry
+	sbom     pico.SBOMVersion
+}
+
+func prune() *cobra.Command {
+	var (
+		olderThan  string
+		keepLatest int
+		search     string
+		yes        bool
+	)
+
+	cmd := &cobra.Command{
+		Use:   "prune",
+		Short: "Delete old SBOM versions of each software",
+		Long: `List the SBOMs of each software entry uploaded more than --older-than ago,
+sparing the --keep-latest most recent of each whatever their age, and with
+--yes delete them from the platform, to keep storage and noise down.
+
+Without --yes nothing is deleted: the SBOMs that would be are listed, so
+run it once to check them. --older-than takes a Go duration or a number of
+days, e.g. 720h or 180d. --search limits pruning to the software whose name
+matches.
+
+Examples:
+  kusari platform prune --older-than 180d --keep-latest 5
+  kusari platform prune --older-than 180d --keep-latest 5 --yes
+  kusari platform prune --older-than 90d --keep-latest 1 --search payments --yes`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cmd.SilenceUsage = true
+
+			age, err := parseAge(olderThan)
+			if err != nil {
+				return clierrors.NewValidationError("invalid --older-than %q (use a duration like 720h or a number of days like 180d)", olderThan)
+			}
+			if keepLatest < 0 {
+				return clierrors.NewValidationError("--keep-latest must not be negative")
+			}
+			if platformTenantEndpoint == "" {
+				return fmt.Errorf("no tenant configured. Use --tenant flag or run `kusari auth login` to select a tenant")
+			}
+
+			client := pico.NewClient(platformTenantEndpoint)
+			ctx := cmd.Context()
+
+			software, err := client.ListAllSoftware(ctx, search)
+			if err != nil {
+				return fmt.Errorf("failed to list software: %w", err)
+			}
+			cutoff := time.Now().Add(-age)
+			var prunable []prunableSBOM
+			for _, s := range software {
+				sboms, err := client.ListSBOMs(ctx, s.ID)
+				if err != nil {
+					return fmt.Errorf("failed to list SBOMs of %s: %w", s.Name, err)
+				}
+				old, err := pico.Prunable(sboms, cutoff, keepLatest)
+				if err != nil {
+					return fmt.Errorf("failed to prune %s: %w", s.Name, err)
+				}
+				for _, sbom := range old {
+					prunable = append(prunable, prunableSBOM{software: s, sbom: sbom})
+				}
+			}
+
+			if len(prunable) == 0 {
+				fmt.Fprintf(os.Stderr, "No SBOMs older than %s to prune in %d software\n", olderThan, len(software))
+				return nil
+			}
+			printPrunable(prunable)
+			if !yes {
+				fmt.Fprintf(os.Stderr, "Would delete %d SBOM(s); run again with --yes to delete them\n", len(prunable))
+				return nil
+			}
+
+			var failed int
+			var firstErr error
+			for _, p := range prunable {
+				if err := client.DeleteSBOM(ctx, p.software.ID, p.sbom.ID); err != nil {
+					fmt.Fprintf(os.Stderr, "Warning: failed to delete SBOM %d of %s: %v\n", p.sbom.ID, p.software.Name, err)
+					failed++
+					if firstErr == nil {
+						firstErr = err
+					}
+				}
+			}
+			fmt.Fprintf(os.Stderr, "Deleted %d SBOM(s)\n", len(prunable)-failed)
+			if firstErr != nil {
+				return fmt.Errorf("failed to delete %d of %d SBOMs: %w", failed, len(prunable), firstErr)
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&olderThan, "older-than", "180d", "Only prune SBOMs uploaded longer ago than this")
+	cmd.Flags().IntVar(&keepLatest, "keep-latest", 5, "Number of most recent SBOMs of each software never pruned")
+	cmd.Flags().StringVar(&search, "search", "", "Only prune the software whose name matches this search term")
+	cmd.Flags().BoolVar(&yes, "yes", false, "Delete the SBOMs listed instead of only listing them")
+
+	return cmd
+}
+
+// printPrunable prints the SBOMs to prune as a table.
+func printPrunable(prunable []prunableSBOM) {
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	_, _ = fmt.Fprintln(w, "SOFTWARE\tSOFTWARE ID\tSBOM ID\tVERSION\tUPLOADED")
+	for _, p := range prunable {
+		_, _ = fmt.Fprintf(w, "%s\t%d\t%d\t%s\t%s\n", p.software.Name, p.software.ID, p.sbom.ID, p.sbom.Version, p.sbom.CreatedAt.Format(time.DateOnly))
+	}
+	_ = w.Flush()
+}
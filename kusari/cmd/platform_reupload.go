@@ -0,0 +1,79 @@
+// Copyright (c) Kusari <https://www.kusari.dev/>
+// SPDX-License-Identifier: MIT
+
+package cmd
+
+import (
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/kusaridev/kusari-cli/v2/pkg/clierrors"
+	"github.com/kusaridev/kusari-cli/v2/pkg/repo"
+	"github.com/spf13/cobra"
+)
+
+func reupload() *cobra.Command {
+	var (
+		opts  repo.ReuploadOptions
+		since string
+	)
+
+	cmd := &cobra.Command{
+		Use:   "reupload",
+		Short: "Re-ingest an archive of historical SBOMs",
+		Long: `Upload every SBOM under --from-dir again, oldest first, for migrating to a
+new tenant or recovering from an ingestion incident. The creation time
+recorded in each SBOM (or the file's modification time when it has none)
+is kept in the upload metadata as original_timestamp, and --since skips
+SBOMs created before a date. Only SPDX and CycloneDX JSON documents up to
+256MiB are uploaded; hidden files and directories, such as .git, are
+skipped.
+
+The SBOMs uploaded are recorded in a checkpoint file (--checkpoint,
+` + repo.DefaultReuploadCheckpoint + ` in the archive by default), saved every
+--batch-size uploads and when the run stops. Running the same command again
+after an interruption or failure resumes where it stopped; an SBOM changed
+since it was uploaded is uploaded again. --rate caps the uploads per second
+to stay clear of the platform's rate limits.
+
+Examples:
+  kusari platform reupload --from-dir archive/ --since 2024-01-01 --tenant demo
+  kusari platform reupload --from-dir archive/ --dry-run
+  kusari platform reupload --from-dir archive/ --rate 5 --batch-size 100 \
+    --checkpoint /var/tmp/reupload.json`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cmd.SilenceUsage = true
+
+			if since != "" {
+				t, err := time.Parse(time.DateOnly, since)
+				if err != nil {
+					if t, err = time.Parse(time.RFC3339, since); err != nil {
+						return clierrors.NewValidationError("invalid --since %q (use YYYY-MM-DD or RFC 3339)", since)
+					}
+				}
+				opts.Since = t
+			}
+			if info, err := os.Stat(opts.Dir); err != nil || !info.IsDir() {
+				return clierrors.NewValidationError("--from-dir %s is not a directory", opts.Dir)
+			}
+			opts.TenantEndpoint = platformTenantEndpoint
+
+			ctx, stop := signal.NotifyContext(cmd.Context(), os.Interrupt, syscall.SIGTERM)
+			defer stop()
+			return repo.Reupload(ctx, opts)
+		},
+	}
+
+	cmd.Flags().StringVar(&opts.Dir, "from-dir", "", "Directory of SBOMs to re-ingest")
+	cmd.Flags().StringVar(&since, "since", "", "Skip SBOMs created before this date (YYYY-MM-DD or RFC 3339)")
+	cmd.Flags().IntVar(&opts.BatchSize, "batch-size", 50, "Uploads between checkpoint saves")
+	cmd.Flags().Float64Var(&opts.Rate, "rate", 2, "Maximum uploads per second (0 for no limit)")
+	cmd.Flags().StringVar(&opts.Checkpoint, "checkpoint", "", "Checkpoint file recording the SBOMs uploaded (default: "+repo.DefaultReuploadCheckpoint+" in --from-dir)")
+	cmd.Flags().BoolVar(&opts.DryRun, "dry-run", false, "List the SBOMs that would be uploaded, oldest first, without uploading")
+	_ = cmd.MarkFlagRequired("from-dir")
+
+	return cmd
+}
@@ -0,0 +1,129 @@
+// Copyright (c) Kusari <https://www.kusari.dev/>
+// SPDX-License-Identifier: MIT
+
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/kusaridev/kusari-cli/v2/pkg/clierrors"
+	"github.com/kusaridev/kusari-cli/v2/pkg/policy"
+	"github.com/spf13/cobra"
+)
+
+func Policy() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "policy",
+		Short: "Evaluate organization policies",
+		Long:  "Evaluate Rego policies against Kusari Inspector results and SBOMs",
+	}
+
+	cmd.AddCommand(policyEval())
+
+	return cmd
+}
+
+func policyEval() *cobra.Command {
+	var (
+		policyPaths  []string
+		analysisPath string
+		sbomPaths    []string
+		format       string
+	)
+
+	cmd := &cobra.Command{
+		Use:   "eval",
+		Short: "Allow or deny results and SBOMs against Rego policies",
+		Long: `Evaluate the Rego policies under --policy against Inspector results and SBOM
+metadata, and exit with code 8 if any deny rule matches.
+
+Policies are written in package kusari. Each entry of the deny set is a reason
+to reject; entries of the warn set are printed but don't fail the command.
+The input document is:
+
+  input.analysis   the Inspector verdict: should_proceed, health_score,
+                   code_mitigations, dependency_mitigations, ...
+  input.health     per-category risk check results, with their checks
+  input.score      the overall risk check score
+  input.sboms[_]   path, format, name, version, packages[_] (name, version,
+                   purl, licenses)
+
+Example policy:
+
+  package kusari
+
+  deny contains msg if {
+      some sbom in input.sboms
+      some pkg in sbom.packages
+      "AGPL-3.0-only" in pkg.licenses
+      msg := sprintf("%s uses forbidden license AGPL-3.0-only", [pkg.name])
+  }
+
+Examples:
+  kusari repo scan . origin/main --output-format sarif > results.sarif
+  kusari policy eval --policy ./policies/ --analysis results.sarif
+  kusari policy eval --policy ./policies/ --sbom sbom.spdx.json --format json`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cmd.SilenceUsage = true
+
+			if format != "text" && format != "json" {
+				return clierrors.NewValidationError("invalid --format %q (must be text or json)", format)
+			}
+			if analysisPath == "" && len(sbomPaths) == 0 {
+				return clierrors.NewValidationError("nothing to evaluate: pass --analysis and/or --sbom")
+			}
+
+			input := policy.NewInput()
+			if analysisPath != "" {
+				if err := input.LoadAnalysis(analysisPath); err != nil {
+					return clierrors.NewValidationError("%v", err)
+				}
+			}
+			for _, p := range sbomPaths {
+				if err := input.LoadSBOM(p); err != nil {
+					return clierrors.NewValidationError("%v", err)
+				}
+			}
+
+			decision, err := policy.Eval(cmd.Context(), policyPaths, input)
+			if err != nil {
+				return clierrors.NewValidationError("%v", err)
+			}
+
+			if format == "json" {
+				out, err := json.MarshalIndent(decision, "", "  ")
+				if err != nil {
+					return err
+				}
+				fmt.Println(string(out))
+			} else {
+				for _, w := range decision.Warn {
+					fmt.Printf("WARN: %s\n", w)
+				}
+				for _, d := range decision.Deny {
+					fmt.Printf("DENY: %s\n", d)
+				}
+				if decision.Allow {
+					fmt.Println("Allowed by policy.")
+				}
+			}
+
+			if !decision.Allow {
+				return &clierrors.PolicyDeniedError{Reasons: decision.Deny}
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().StringSliceVar(&policyPaths, "policy", nil, "Rego policy file or directory (repeatable, required)")
+	cmd.Flags().StringVar(&analysisPath, "analysis", "", "Inspector results: SARIF from 'repo scan --output-format sarif', or platform results JSON")
+	cmd.Flags().StringSliceVar(&sbomPaths, "sbom", nil, "SPDX or CycloneDX JSON SBOM (repeatable)")
+	cmd.Flags().StringVar(&format, "format", "text", "Output format: text or json")
+	if err := cmd.MarkFlagRequired("policy"); err != nil {
+		panic(err)
+	}
+
+	return cmd
+}
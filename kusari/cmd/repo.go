@@ -4,9 +4,19 @@
 package cmd
 
 import (
+	"os"
+
+	"github.com/kusaridev/kusari-cli/v2/pkg/clierrors"
 	"github.com/spf13/cobra"
 )
 
+// Environment variables that stand in for the repo command arguments, so
+// a container entrypoint can be configured without a command line.
+const (
+	scanDirEnv = "KUSARI_SCAN_DIR"
+	scanRevEnv = "KUSARI_SCAN_REV"
+)
+
 func Repo() *cobra.Command {
 	cmd := &cobra.Command{
 		Use:   "repo",
@@ -19,3 +29,15 @@ func Repo() *cobra.Command {
 
 	return cmd
 }
+
+// argOrEnv returns args[i], or the value of env when fewer arguments were
+// given.
+func argOrEnv(args []string, i int, name, env string) (string, error) {
+	if i < len(args) {
+		return args[i], nil
+	}
+	if v := os.Getenv(env); v != "" {
+		return v, nil
+	}
+	return "", clierrors.NewValidationError("missing <%s> argument (or set %s)", name, env)
+}
@@ -8,17 +8,46 @@ import (
 	"github.com/spf13/cobra"
 )
 
+var (
+	// riskChecks are the sub-scans given to --checks.
+	riskChecks []string
+	// deltaUpload uploads only the files changed since the last risk check.
+	deltaUpload bool
+	// noGit packages the directory as a plain snapshot, without git.
+	noGit bool
+)
+
 func init() {
 	riskcheckcmd.Flags().BoolVarP(&wait, "wait", "w", true, "wait for results")
+	riskcheckcmd.Flags().BoolVar(&committedOnly, "committed-only", false, "package only what is committed at HEAD, leaving out uncommitted changes")
+	riskcheckcmd.Flags().StringSliceVar(&riskChecks, "checks", nil, "comma-separated sub-scans to limit the risk check to, e.g. dependencies,ci-config,secrets (default: all)")
+	riskcheckcmd.Flags().BoolVar(&noGit, "no-git", false, "package every file of the directory as a plain snapshot, without git (for a directory that isn't a git repository, or a machine without git)")
+	riskcheckcmd.Flags().BoolVar(&deltaUpload, "delta", false, "upload only the files changed since the last --delta risk check of this repository and branch")
+	addAttestFlags(riskcheckcmd)
+	addLockFlags(riskcheckcmd)
+	addPackagingFlags(riskcheckcmd)
 }
 
 func riskcheck() *cobra.Command {
 	riskcheckcmd.RunE = func(cmd *cobra.Command, args []string) error {
 		cmd.SilenceUsage = true
 
-		dir := args[0]
+		dir, err := argOrEnv(args, 0, "directory", scanDirEnv)
+		if err != nil {
+			return err
+		}
+		opts, err := scanOptions(dir)
+		if err != nil {
+			return err
+		}
+		if opts.Checks, err = repo.ParseChecks(riskChecks); err != nil {
+			return err
+		}
+		opts.CommittedOnly = committedOnly
+		opts.Delta = deltaUpload
+		opts.NoGit = noGit
 
-		return repo.RiskCheck(dir, platformUrl, consoleUrl, verbose, wait)
+		return repo.RiskCheck(opts)
 	}
 
 	return riskcheckcmd
@@ -29,7 +58,31 @@ var riskcheckcmd = &cobra.Command{
 	Use:   "risk-check <directory>",
 	Short: "Risk-check a repo with Kusari Inspector",
 	Long: `Submit the directory for summary analysis in Kusari Inspector.
-    <directory>  A directory containing a git repository to analyze`,
-	Args:   cobra.ExactArgs(1),
+    <directory>  A directory containing a git repository to analyze (or KUSARI_SCAN_DIR)
+
+Git must be installed. --no-git packages every file of the directory
+instead, without git, for a directory that isn't a git repository (a
+release tarball, say) or a machine without git: .gitignore isn't applied,
+and the bundle metadata is flagged no_git, with no remote or commit.
+
+When an earlier risk check of the same repository and branch exists, the
+results end with what changed since: the score delta per category and the
+checks that started or stopped failing.
+
+Uncommitted changes in the working tree are packaged too; --committed-only
+packages HEAD instead.
+
+--checks limits the risk check to some sub-scans, e.g.
+--checks dependencies,ci-config,secrets for a quicker targeted audit. The
+platform is asked to run only those, and only their categories are shown.
+
+--delta uploads only the files added or changed since the last --delta risk
+check of the same repository and branch, with the manifest diff the
+platform rebuilds the whole package from. The first one, and any whose
+delta isn't smaller, uploads the whole package.
+
+--attest writes a signed in-toto attestation of the risk check; see
+'kusari repo scan --help'.`,
+	Args:   cobra.MaximumNArgs(1),
 	Hidden: true,
 }
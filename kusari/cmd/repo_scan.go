@@ -5,7 +5,12 @@ package cmd
 
 import (
 	"fmt"
+	"os"
+	"strings"
+	"time"
 
+	"github.com/kusaridev/kusari-cli/v2/pkg/clierrors"
+	"github.com/kusaridev/kusari-cli/v2/pkg/configuration"
 	"github.com/kusaridev/kusari-cli/v2/pkg/repo"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
@@ -14,24 +19,204 @@ import (
 var (
 	wait            bool
 	outputFormat    string
+	outputs         []string
 	commentPlatform string
+	commentDryRun   string
 	fullOutput      bool
 	overrideBranch  string
+	resumeScan      bool
+	staged          bool
+	patchOnly       bool
+	secretsOnly     bool
+	iacScan         bool
+	iacPaths        []string
+	committedOnly   bool
+	attestPath      string
+	attestUpload    bool
+	onlyPaths       []string
+	minLevel        string
+	suppress        []string
+	lockWait        time.Duration
+	maxFileSize     string
+	includeBinaries bool
+	gitDirFlag      string
+	preUploadHook   string
+	postResultHook  string
+	serviceNowURL   string
+	ticketWebhook   string
+	ticketHeaders   []string
 )
 
 func init() {
 	scancmd.Flags().BoolVarP(&wait, "wait", "w", true, "wait for results")
 	scancmd.Flags().StringVarP(&outputFormat, "output-format", "", "markdown", "output format (markdown or sarif)")
+	scancmd.Flags().StringSliceVar(&outputs, "output", nil, "outputs to write, comma-separated or repeated: markdown, sarif, json, defectdojo or plugin:NAME to stdout, or FORMAT-file=PATH (e.g. sarif-file=kusari.sarif,markdown); overrides --output-format")
 	scancmd.Flags().StringVar(&commentPlatform, "comment", "", "post results as a comment to the specified platform's PR/MR (e.g., 'gitlab', 'github')")
+	scancmd.Flags().StringVar(&commentDryRun, "comment-dry-run", "", "write the comments --comment would post to this file, or stdout when no file is given, instead of posting them")
+	scancmd.Flags().Lookup("comment-dry-run").NoOptDefVal = "-"
 	scancmd.Flags().BoolVar(&fullOutput, "full-output", false, "output full results instead of truncated")
 	scancmd.Flags().StringVar(&overrideBranch, "override-branch", "", "override the detected branch name (useful in CI environments with detached HEAD state)")
+	scancmd.Flags().BoolVar(&resumeScan, "resume", false, "upload the package of the last interrupted scan (of <directory>, if given) instead of scanning again")
+	scancmd.Flags().BoolVar(&staged, "staged", false, "scan only the changes staged for commit, against <git-rev> (default HEAD)")
+	scancmd.Flags().BoolVar(&committedOnly, "committed-only", false, "scan only the commits up to HEAD against <git-rev>, leaving out uncommitted changes")
+	scancmd.Flags().BoolVar(&patchOnly, "patch-only", false, "upload only the patch and metadata, not the repository's source files (less thorough analysis)")
+	scancmd.Flags().BoolVar(&secretsOnly, "secrets-only", false, "look only for secrets in the diff, uploading just the patch, for a quick pre-push check")
+	scancmd.Flags().BoolVar(&iacScan, "iac", false, "scan only infrastructure as code (Terraform, Kubernetes manifests, ...), packaging just the files matching --iac-paths")
+	scancmd.Flags().StringSliceVar(&iacPaths, "iac-paths", nil, "path globs of the files an --iac scan packages, comma-separated or repeated (default: iac_paths of kusari.yaml)")
+	scancmd.Flags().StringSliceVar(&onlyPaths, "only-paths", nil, "show only code findings under these path globs, comma-separated or repeated (e.g. 'src/**')")
+	scancmd.Flags().StringVar(&minLevel, "min-level", "", "show only findings at or above this level: note, warning or error")
+	scancmd.Flags().StringSliceVar(&suppress, "suppress", nil, "leave out the findings with these IDs (the finding_id of the SARIF output), comma-separated or repeated")
+	addAttestFlags(scancmd)
+	addLockFlags(scancmd)
+	addPackagingFlags(scancmd)
+	addHookFlags(scancmd)
+	scancmd.Flags().StringVar(&serviceNowURL, "servicenow-url", "", "ServiceNow instance to file a record in when the changes should not proceed, e.g. https://acme.service-now.com")
+	scancmd.Flags().StringVar(&ticketWebhook, "ticket-webhook-url", "", "URL to POST a JSON ticket payload to when the changes should not proceed")
+	scancmd.Flags().StringArrayVar(&ticketHeaders, "ticket-webhook-header", nil, `Header sent with --ticket-webhook-url, as "Name: value"; $KUSARI_ITSM_* variables are expanded (repeatable)`)
 
 	// Bind flags to viper
 	mustBindPFlag("wait", scancmd.Flags().Lookup("wait"))
 	mustBindPFlag("output-format", scancmd.Flags().Lookup("output-format"))
+	mustBindPFlag("output", scancmd.Flags().Lookup("output"))
 	mustBindPFlag("comment", scancmd.Flags().Lookup("comment"))
+	mustBindPFlag("comment-dry-run", scancmd.Flags().Lookup("comment-dry-run"))
 	mustBindPFlag("full-output", scancmd.Flags().Lookup("full-output"))
 	mustBindPFlag("override-branch", scancmd.Flags().Lookup("override-branch"))
+	mustBindPFlag("resume", scancmd.Flags().Lookup("resume"))
+	mustBindPFlag("staged", scancmd.Flags().Lookup("staged"))
+	mustBindPFlag("committed-only", scancmd.Flags().Lookup("committed-only"))
+	mustBindPFlag("patch-only", scancmd.Flags().Lookup("patch-only"))
+	mustBindPFlag("secrets-only", scancmd.Flags().Lookup("secrets-only"))
+	mustBindPFlag("iac", scancmd.Flags().Lookup("iac"))
+	mustBindPFlag("iac-paths", scancmd.Flags().Lookup("iac-paths"))
+	mustBindPFlag("only-paths", scancmd.Flags().Lookup("only-paths"))
+	mustBindPFlag("min-level", scancmd.Flags().Lookup("min-level"))
+	mustBindPFlag("suppress", scancmd.Flags().Lookup("suppress"))
+	mustBindPFlag("attest", scancmd.Flags().Lookup("attest"))
+	mustBindPFlag("attest-upload", scancmd.Flags().Lookup("attest-upload"))
+	mustBindPFlag("lock-wait", scancmd.Flags().Lookup("lock-wait"))
+	mustBindPFlag("max-file-size", scancmd.Flags().Lookup("max-file-size"))
+	mustBindPFlag("include-binaries", scancmd.Flags().Lookup("include-binaries"))
+	mustBindPFlag("git-dir", scancmd.Flags().Lookup("git-dir"))
+	mustBindPFlag("pre-upload-hook", scancmd.Flags().Lookup("pre-upload-hook"))
+	mustBindPFlag("post-result-hook", scancmd.Flags().Lookup("post-result-hook"))
+	mustBindPFlag("servicenow-url", scancmd.Flags().Lookup("servicenow-url"))
+	mustBindPFlag("ticket-webhook-url", scancmd.Flags().Lookup("ticket-webhook-url"))
+}
+
+// addAttestFlags registers the scan attestation flags on scan and
+// risk-check.
+func addAttestFlags(cmd *cobra.Command) {
+	cmd.Flags().StringVar(&attestPath, "attest", "", "write an in-toto attestation of the scan, signed with cosign keyless signing, to this file")
+	cmd.Flags().BoolVar(&attestUpload, "attest-upload", false, "also upload the signed attestation to the platform (requires --attest)")
+}
+
+// addLockFlags registers the flag controlling how scan and risk-check wait
+// for a concurrent scan of the same repository.
+func addLockFlags(cmd *cobra.Command) {
+	cmd.Flags().DurationVar(&lockWait, "lock-wait", 0, "wait up to this long (e.g. 10m) for another scan of the same repository to finish, instead of failing at once")
+}
+
+// addPackagingFlags registers the flags choosing which source files scan
+// and risk-check package.
+func addPackagingFlags(cmd *cobra.Command) {
+	cmd.Flags().StringVar(&maxFileSize, "max-file-size", "10MiB", "leave source files larger than this (e.g. 50MB) out of the package, listing them; 0 packages every file")
+	cmd.Flags().BoolVar(&includeBinaries, "include-binaries", false, "package binary files (images, archives, compiled objects, ...), which are left out by default")
+	cmd.Flags().StringVar(&gitDirFlag, "git-dir", "", "git directory of the repository, e.g. a bare repository, whose work tree is <directory> (as git --git-dir and --work-tree)")
+}
+
+// addHookFlags registers the scan hook flags on scan and image check.
+func addHookFlags(cmd *cobra.Command) {
+	cmd.Flags().StringVar(&preUploadHook, "pre-upload-hook", "", "script to run with the path of the package before it is uploaded, e.g. to redact it; a failure stops the scan")
+	cmd.Flags().StringVar(&postResultHook, "post-result-hook", "", "script to run with the path of the saved result JSON once the results are in")
+}
+
+// hookScripts returns the scripts of the scan hooks: those of the hook
+// flags, or for a hook without one, that of ~/.kusari/kusari.yaml.
+func hookScripts() (preUpload, postResult string, err error) {
+	preUpload, postResult, err = repo.UserHooks()
+	if err != nil {
+		return "", "", clierrors.NewValidationError("%v", err)
+	}
+	if preUploadHook != "" {
+		preUpload = preUploadHook
+	}
+	if postResultHook != "" {
+		postResult = postResultHook
+	}
+	return preUpload, postResult, nil
+}
+
+// scanOptions returns the options of a scan or risk-check of dir from the
+// flags they share: the attestation, lock and packaging flags.
+func scanOptions(dir string) (repo.ScanOptions, error) {
+	if attestUpload && attestPath == "" {
+		return repo.ScanOptions{}, clierrors.NewValidationError("--attest-upload requires --attest")
+	}
+	maxSize, err := repo.ParseSize(maxFileSize)
+	if err != nil {
+		return repo.ScanOptions{}, err
+	}
+	if maxSize == 0 {
+		// --max-file-size 0 packages every file
+		maxSize = -1
+	}
+	return repo.ScanOptions{
+		Dir:              dir,
+		PlatformURL:      platformUrl,
+		ConsoleURL:       consoleUrl,
+		Verbose:          verbose,
+		Wait:             wait,
+		Attest:           repo.AttestOptions{Path: attestPath, Upload: attestUpload, CLIVersion: getVersion()},
+		BundleEncryption: repo.BundleEncryption(bundleEncryption),
+		LockWait:         lockWait,
+		MaxFileSize:      maxSize,
+		IncludeBinaries:  includeBinaries,
+		GitDir:           gitDirFlag,
+	}, nil
+}
+
+// ticketSinks returns where the ITSM flags file blocked scans.
+func ticketSinks() (repo.TicketSinks, error) {
+	if ticketWebhook == "" && len(ticketHeaders) > 0 {
+		return repo.TicketSinks{}, clierrors.NewValidationError("--ticket-webhook-header needs --ticket-webhook-url")
+	}
+	headers := map[string]string{}
+	for _, h := range ticketHeaders {
+		name, value, ok := strings.Cut(h, ":")
+		if !ok || strings.TrimSpace(name) == "" {
+			return repo.TicketSinks{}, clierrors.NewValidationError("invalid --ticket-webhook-header %q (want \"Name: value\")", h)
+		}
+		headers[strings.TrimSpace(name)] = strings.TrimSpace(value)
+	}
+	return repo.TicketSinks{ServiceNowURL: serviceNowURL, WebhookURL: ticketWebhook, WebhookHeaders: headers}, nil
+}
+
+// iacGlobs returns the globs of the files an IaC scan packages, taking
+// them from the kusari.yaml of dir when --iac-paths isn't given; nil when
+// --iac isn't.
+func iacGlobs(dir string) ([]string, error) {
+	if !iacScan {
+		if len(iacPaths) > 0 {
+			return nil, clierrors.NewValidationError("--iac-paths requires --iac")
+		}
+		return nil, nil
+	}
+	if secretsOnly {
+		return nil, clierrors.NewValidationError("--iac and --secrets-only can't be used together")
+	}
+	paths := iacPaths
+	if len(paths) == 0 {
+		cfg, err := configuration.Load(dir)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: %v, using the default IaC paths\n", err)
+		}
+		paths = cfg.IaCPaths
+	}
+	if len(paths) == 0 {
+		return nil, clierrors.NewValidationError("--iac needs path globs: set iac_paths in kusari.yaml or pass --iac-paths")
+	}
+	return paths, nil
 }
 
 func scan() *cobra.Command {
@@ -39,14 +224,80 @@ func scan() *cobra.Command {
 		cmd.SilenceUsage = true
 
 		// Validate output format
-		if outputFormat != "markdown" && outputFormat != "sarif" {
-			return fmt.Errorf("invalid output format: %s (must be 'markdown' or 'sarif')", outputFormat)
+		if len(outputs) > 0 {
+			outputFormat = strings.Join(outputs, ",")
+		} else if outputFormat != "markdown" && outputFormat != "sarif" {
+			return clierrors.NewValidationError("invalid output format: %s (must be 'markdown' or 'sarif')", outputFormat)
+		}
+		if _, err := repo.ParseOutputs(outputFormat); err != nil {
+			return err
+		}
+		if err := repo.ValidateLevel(minLevel); err != nil {
+			return err
+		}
+		filter := repo.ResultFilter{Paths: onlyPaths, MinLevel: minLevel, Suppress: suppress}
+		if commentDryRun != "" && commentPlatform == "" {
+			return clierrors.NewValidationError("--comment-dry-run requires --comment")
+		}
+		sinks, err := ticketSinks()
+		if err != nil {
+			return err
+		}
+
+		if resumeScan {
+			dir := ""
+			if len(args) > 0 {
+				dir = args[0]
+			}
+			return repo.Resume(repo.ScanOptions{
+				Dir:             dir,
+				Verbose:         verbose,
+				Wait:            wait,
+				OutputFormat:    outputFormat,
+				CommentPlatform: commentPlatform,
+				CommentDryRun:   commentDryRun,
+				FullOutput:      fullOutput,
+				Filter:          filter,
+				TicketSinks:     sinks,
+			})
 		}
 
-		dir := args[0]
-		ref := args[1]
+		dir, err := argOrEnv(args, 0, "directory", scanDirEnv)
+		if err != nil {
+			return err
+		}
+		opts, err := scanOptions(dir)
+		if err != nil {
+			return err
+		}
+		if opts.IaC, err = iacGlobs(dir); err != nil {
+			return err
+		}
+		ref, err := argOrEnv(args, 1, "git-rev", scanRevEnv)
+		if err != nil {
+			if !staged {
+				return err
+			}
+			ref = "HEAD"
+		}
 
-		return repo.Scan(dir, ref, platformUrl, consoleUrl, verbose, wait, outputFormat, commentPlatform, fullOutput, overrideBranch)
+		opts.Rev = ref
+		opts.OutputFormat = outputFormat
+		opts.CommentPlatform = commentPlatform
+		opts.CommentDryRun = commentDryRun
+		opts.FullOutput = fullOutput
+		opts.OverrideBranch = overrideBranch
+		opts.Staged = staged
+		opts.CommittedOnly = committedOnly
+		opts.PatchOnly = patchOnly
+		opts.SecretsOnly = secretsOnly
+		opts.Filter = filter
+		opts.TicketSinks = sinks
+		opts.PreUploadHook, opts.PostResultHook, err = hookScripts()
+		if err != nil {
+			return err
+		}
+		return repo.Scan(opts)
 	}
 
 	return scancmd
@@ -57,14 +308,152 @@ var scancmd = &cobra.Command{
 	Short: "Scan a change with Kusari Inspector",
 	Long: `Generate a change set against a repository, then submit the directory and diff for analysis in Kusari Inspector.
     <directory>  A directory containing a git repository to analyze
-    <git-rev>    Git revision to compare to the working tree`,
-	Args: cobra.ExactArgs(2),
+    <git-rev>    Git revision to compare to the working tree
+
+Either argument may instead be given as KUSARI_SCAN_DIR or KUSARI_SCAN_REV.
+
+--staged analyzes only what is staged for commit: the diff of the index
+against <git-rev> (HEAD by default), with the staged files as the package.
+Unstaged and untracked changes are left out, which suits pre-commit hooks:
+
+    kusari repo scan . --staged
+
+Uncommitted changes are otherwise part of the diff and the package, with a
+warning. --committed-only diffs HEAD against <git-rev> and packages HEAD:
+
+    kusari repo scan . origin/main --committed-only
+
+--patch-only uploads the patch and metadata without the repository's source
+files, for repositories that can't leave the machine in full. Findings then
+rely on the patch alone, so the analysis is less thorough.
+
+--secrets-only also uploads just the patch, and asks only for leaked
+secrets in it instead of the full analysis. It returns in seconds, which
+suits a pre-push hook:
+
+    kusari repo scan . origin/main --secrets-only
+
+--iac asks for an analysis focused on infrastructure as code, and packages
+and diffs only the files matching the iac_paths globs of kusari.yaml
+(Terraform, Bicep, YAML manifests and Dockerfiles by default), or
+--iac-paths, so manifest reviews don't ship the whole application:
+
+    kusari repo scan . origin/main --iac --iac-paths 'deploy/**,**/*.tf'
+
+The package is kept in ~/.kusari/uploads until the results are in. If a scan
+is interrupted or its upload fails, --resume uploads it again, without
+repackaging, and waits for the results; 'kusari platform flush' uploads every
+pending package without waiting.
+
+--output writes several formats in one run, e.g. a SARIF file for code
+scanning plus a summary in the job log:
+
+    kusari repo scan . origin/main --output sarif-file=kusari.sarif,markdown,json-file=result.json
+
+defectdojo writes DefectDojo Generic Findings Import JSON, for import with
+the "Generic Findings Import" scan type. plugin:NAME pipes the json output
+to a kusari-output-NAME executable on the PATH, a custom formatter or
+notification sink, and writes what it prints.
+
+--pre-upload-hook and --post-result-hook run a script with the package
+before it is uploaded, e.g. to redact it, and with the saved result JSON
+once the results are in. Without the flags, the hooks mapping of
+~/.kusari/kusari.yaml sets them for every scan:
+
+    hooks:
+      pre_upload: ./scripts/strip.sh    # relative to ~/.kusari
+      post_result: /usr/local/bin/notify
+
+Hooks are never read from the scanned repository's kusari.yaml, and get
+only PATH, HOME and the locale of the CLI's environment, so a change can't
+run code with the CI's secrets:
+
+    kusari repo scan . origin/main --pre-upload-hook ./ci/strip.sh
+
+Source files larger than --max-file-size (10MiB by default), such as
+binary blobs and datasets kept in git, are left out of the package. They
+are listed, and recorded as skipped_files in the bundle metadata so the
+analysis knows they exist. Manifests and lockfiles are always packaged:
+
+    kusari repo scan . origin/main --max-file-size 50MB
+
+Binary files, known by their extension or a NUL byte in their first 8000
+bytes as git does, are left out and recorded the same way, since they are
+rarely useful to the analysis. Vendored dependencies (.jar, .whl, .so, ...)
+are kept. --verbose prints how many, and
+--include-binaries packages them.
+
+<directory> may be a worktree added with git worktree add. To scan the
+work tree of a bare repository, pass the repository with --git-dir:
+
+    kusari repo scan --git-dir /srv/git/app.git ./app-checkout origin/main
+
+--comment-dry-run renders the summary and inline comments exactly as
+--comment would post them, markers included, without calling the forge's
+API, e.g. to check a comment_style change in CI:
+
+    kusari repo scan . origin/main --comment github --comment-dry-run=comments.md
+
+--servicenow-url and --ticket-webhook-url file changes that should not
+proceed in an ITSM tool. Ticket endpoints and headers are only taken from
+flags and KUSARI_* variables, never from the scanned repository:
+
+    kusari repo scan . origin/main --ticket-webhook-url https://itsm.example.com/hook \
+      --ticket-webhook-header 'Authorization: Bearer ${KUSARI_ITSM_TOKEN}'
+
+--only-paths and --min-level narrow what every output shows, e.g. to a
+team's part of a monorepo:
+
+    kusari repo scan . origin/main --only-paths 'services/billing/**' --min-level error
+
+Dependency findings have no path, so --only-paths leaves them out. Findings
+take the level of the verdict: error when the change should not proceed,
+warning otherwise.
+
+Findings are listed in a stable order (path, line, then content) and each
+has an ID that stays the same across runs while its file and content do:
+the finding_id and partial fingerprint of the SARIF output, the ID column
+of 'kusari results export', and what 'kusari results create-issues'
+dedupes on. --suppress leaves out findings by ID, e.g. for
+accepted risks:
+
+    kusari repo scan . origin/main --suppress 3fe4b082a88aa722
+
+--attest writes an in-toto statement of the scan (the commit, the digest of
+the package, the CLI version and where the result is) signed with cosign
+keyless signing, so audits can show which CLI version scanned a commit.
+It needs cosign on PATH; --attest-upload also stores it with the result:
+
+    kusari repo scan . origin/main --attest scan.intoto.json --attest-upload`,
+	Args: cobra.RangeArgs(0, 2),
 	PersistentPreRun: func(cmd *cobra.Command, args []string) {
 		// Update from viper (this gets env vars + config + flags)
 		wait = viper.GetBool("wait")
 		outputFormat = viper.GetString("output-format")
+		outputs = viper.GetStringSlice("output")
 		commentPlatform = viper.GetString("comment")
+		commentDryRun = viper.GetString("comment-dry-run")
 		fullOutput = viper.GetBool("full-output")
 		overrideBranch = viper.GetString("override-branch")
+		resumeScan = viper.GetBool("resume")
+		staged = viper.GetBool("staged")
+		committedOnly = viper.GetBool("committed-only")
+		patchOnly = viper.GetBool("patch-only")
+		secretsOnly = viper.GetBool("secrets-only")
+		iacScan = viper.GetBool("iac")
+		iacPaths = viper.GetStringSlice("iac-paths")
+		onlyPaths = viper.GetStringSlice("only-paths")
+		minLevel = viper.GetString("min-level")
+		suppress = viper.GetStringSlice("suppress")
+		attestPath = viper.GetString("attest")
+		attestUpload = viper.GetBool("attest-upload")
+		lockWait = viper.GetDuration("lock-wait")
+		maxFileSize = viper.GetString("max-file-size")
+		includeBinaries = viper.GetBool("include-binaries")
+		gitDirFlag = viper.GetString("git-dir")
+		preUploadHook = viper.GetString("pre-upload-hook")
+		postResultHook = viper.GetString("post-result-hook")
+		serviceNowURL = viper.GetString("servicenow-url")
+		ticketWebhook = viper.GetString("ticket-webhook-url")
 	},
 }
@@ -0,0 +1,499 @@
+// Copyright (c) Kusari <https://www.kusari.dev/>
+// SPDX-License-Identifier: MIT
+
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/kusaridev/kusari-cli/v2/pkg/clierrors"
+	"github.com/kusaridev/kusari-cli/v2/pkg/comment"
+	"github.com/kusaridev/kusari-cli/v2/pkg/configuration"
+	"github.com/kusaridev/kusari-cli/v2/pkg/github"
+	"github.com/kusaridev/kusari-cli/v2/pkg/gitlab"
+	"github.com/kusaridev/kusari-cli/v2/pkg/jira"
+	"github.com/kusaridev/kusari-cli/v2/pkg/output"
+	"github.com/kusaridev/kusari-cli/v2/pkg/repo"
+	"github.com/kusaridev/kusari-cli/v2/pkg/results"
+	"github.com/spf13/cobra"
+)
+
+func Results() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "results",
+		Short: "Work with Kusari Inspector results",
+		Long:  "Show, export and report on Kusari Inspector results outside the console",
+	}
+
+	cmd.AddCommand(resultsShow())
+	cmd.AddCommand(resultsExport())
+	cmd.AddCommand(resultsReport())
+	cmd.AddCommand(resultsCreateIssues())
+
+	return cmd
+}
+
+// addResultFlags adds the --input and --last flags that pick the result a
+// results subcommand works on.
+func addResultFlags(cmd *cobra.Command, inputPath *string, last *bool) {
+	cmd.Flags().StringVar(inputPath, "input", "", "Inspector results: SARIF from 'repo scan --output-format sarif', or platform results JSON")
+	cmd.Flags().BoolVar(last, "last", false, "Use the most recent result saved under ~/.kusari/results")
+	cmd.MarkFlagsMutuallyExclusive("input", "last")
+	cmd.MarkFlagsOneRequired("input", "last")
+}
+
+// loadResult loads the result named by --input, or the last saved one.
+func loadResult(inputPath string, last bool) (*results.Result, error) {
+	if last {
+		res, path, err := results.Last()
+		if errors.Is(err, results.ErrNoSavedResults) {
+			return nil, clierrors.NewValidationError("%v", err)
+		}
+		if err != nil {
+			return nil, err
+		}
+		if verbose {
+			fmt.Fprintf(os.Stderr, "Using %s\n", path)
+		}
+		warnNewerResult(res)
+		return res, nil
+	}
+	res, err := results.Load(inputPath)
+	if err != nil {
+		return nil, clierrors.NewValidationError("%v", err)
+	}
+	warnNewerResult(res)
+	return res, nil
+}
+
+// warnNewerResult warns when res is newer than this version of the CLI
+// knows.
+func warnNewerResult(res *results.Result) {
+	if warning := res.SchemaWarning(); warning != "" {
+		fmt.Fprintf(os.Stderr, "Warning: %s\n", warning)
+	}
+}
+
+func resultsShow() *cobra.Command {
+	var (
+		inputPath string
+		last      bool
+	)
+
+	cmd := &cobra.Command{
+		Use:   "show",
+		Short: "Show a saved result again without rescanning",
+		Long: `Render an Inspector result in the terminal, the way 'kusari repo scan' and
+'kusari repo risk-check' print it.
+
+Every result those commands fetch is saved under ~/.kusari/results (the
+newest 50 are kept), so --last shows the most recent one offline.
+
+Examples:
+  kusari results show --last
+  kusari results show --input results.sarif`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cmd.SilenceUsage = true
+
+			res, err := loadResult(inputPath, last)
+			if err != nil {
+				return err
+			}
+
+			if !res.SavedAt.IsZero() {
+				what := res.Kind
+				if res.Repo != "" {
+					what += " of " + res.Repo
+				}
+				if res.BaseRef != "" {
+					what += " against " + res.BaseRef
+				}
+				fmt.Fprintf(os.Stderr, "%s, %s\n", titleCase(what), res.SavedAt.Local().Format("2006-01-02 15:04:05 MST"))
+			}
+			if res.ConsoleURL != "" {
+				fmt.Fprintf(os.Stderr, "You can also view your results here: %s\n", output.Hyperlink(os.Stderr, res.ConsoleURL, res.ConsoleURL))
+			}
+
+			markdown := res.Markdown
+			if markdown == "" && res.Analysis != nil {
+				markdown = comment.FormatCommentFallback(res.Analysis, res.ConsoleURL)
+			}
+			output.PrintMarkdown(markdown)
+			return nil
+		},
+	}
+
+	addResultFlags(cmd, &inputPath, &last)
+
+	return cmd
+}
+
+func titleCase(s string) string {
+	if s == "" {
+		return s
+	}
+	return strings.ToUpper(s[:1]) + s[1:]
+}
+
+func resultsExport() *cobra.Command {
+	var (
+		inputPath string
+		last      bool
+		format    string
+		outPath   string
+	)
+
+	cmd := &cobra.Command{
+		Use:   "export",
+		Short: "Export findings to CSV, Excel or DefectDojo",
+		Long: `Flatten the code and dependency mitigations of an Inspector result into a
+spreadsheet, one finding per row: type, path, line, content, code, severity,
+status and the finding's stable ID.
+
+--format defectdojo writes DefectDojo Generic Findings Import JSON instead,
+for import with the "Generic Findings Import" scan type.
+
+--format defaults to the extension of --out (.json is defectdojo). XLSX output needs --out; CSV and
+DefectDojo JSON are written to stdout when --out is omitted or '-'.
+
+Examples:
+  kusari repo scan . origin/main --output-format sarif > results.sarif
+  kusari results export --input results.sarif --out findings.xlsx
+  kusari results export --input results.sarif --format csv > findings.csv
+  kusari results export --last --out findings.csv
+  kusari results export --last --out dojo.json`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cmd.SilenceUsage = true
+
+			if format == "" {
+				format = strings.TrimPrefix(strings.ToLower(filepath.Ext(outPath)), ".")
+				switch format {
+				case "":
+					format = "csv"
+				case "json":
+					// The only JSON export
+					format = "defectdojo"
+				}
+			}
+			if format != "csv" && format != "xlsx" && format != "defectdojo" {
+				return clierrors.NewValidationError("invalid --format %q (must be csv, xlsx or defectdojo)", format)
+			}
+			toStdout := outPath == "" || outPath == "-"
+			if format == "xlsx" && toStdout {
+				return clierrors.NewValidationError("--format xlsx requires --out")
+			}
+
+			res, err := loadResult(inputPath, last)
+			if err != nil {
+				return err
+			}
+			rows := res.Rows()
+
+			var w io.Writer = os.Stdout
+			if !toStdout {
+				f, err := os.Create(outPath)
+				if err != nil {
+					return fmt.Errorf("failed to create %s: %w", outPath, err)
+				}
+				defer f.Close()
+				w = f
+			}
+
+			switch format {
+			case "xlsx":
+				err = results.WriteXLSX(w, rows)
+			case "defectdojo":
+				err = results.WriteDefectDojo(w, res, time.Now())
+			default:
+				err = results.WriteCSV(w, rows)
+			}
+			if err != nil {
+				return fmt.Errorf("failed to write %s: %w", format, err)
+			}
+
+			if !toStdout {
+				fmt.Fprintf(os.Stderr, "Wrote %d findings to %s\n", len(rows), outPath)
+			}
+			return nil
+		},
+	}
+
+	addResultFlags(cmd, &inputPath, &last)
+	cmd.Flags().StringVar(&format, "format", "", "Output format: csv, xlsx or defectdojo (default from --out extension, else csv)")
+	cmd.Flags().StringVar(&outPath, "out", "", "Output file ('-' for stdout)")
+	return cmd
+}
+
+// reportFormats maps the kinds accepted by --output to their writers.
+var reportFormats = map[string]func(ctx context.Context, res *results.Result, opts results.ReportOptions, path string) error{
+	"html-file": func(_ context.Context, res *results.Result, opts results.ReportOptions, path string) error {
+		f, err := os.Create(path)
+		if err != nil {
+			return fmt.Errorf("failed to create %s: %w", path, err)
+		}
+		defer f.Close()
+		if err := results.WriteHTML(f, res, opts); err != nil {
+			return err
+		}
+		return f.Close()
+	},
+	"pdf-file": results.WritePDF,
+}
+
+func resultsReport() *cobra.Command {
+	var (
+		inputPath string
+		last      bool
+		pdfPath   string
+		outputs   []string
+		title     string
+	)
+
+	cmd := &cobra.Command{
+		Use:   "report",
+		Short: "Render findings as a PDF or HTML report",
+		Long: `Render the summary, health scores, findings and recommendations of an
+Inspector result as a Kusari-branded report, for reviewers who don't use the
+console.
+
+--output takes kind=path and may be repeated:
+
+  html-file=PATH   a single HTML file with inline styles and script,
+                   collapsible findings and highlighted code snippets;
+                   suitable for publishing as a CI artifact
+  pdf-file=PATH    the same report printed to PDF (--pdf PATH is short
+                   for this)
+
+The PDF is printed with headless Chrome or Chromium. If neither is on PATH,
+set ` + results.EnvChromeBin + ` to the browser binary.
+
+Examples:
+  kusari repo scan . origin/main --output-format sarif > results.sarif
+  kusari results report --input results.sarif --pdf report.pdf
+  kusari results report --last --output html-file=report.html
+  kusari results report --input results.sarif --output html-file=report.html
+  kusari results report --input results.json --output html-file=report.html --output pdf-file=report.pdf --title "Acme Q3 review"`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cmd.SilenceUsage = true
+
+			if pdfPath != "" {
+				outputs = append(outputs, "pdf-file="+pdfPath)
+			}
+			if len(outputs) == 0 {
+				return clierrors.NewValidationError("nothing to write: pass --output html-file=PATH or --pdf PATH")
+			}
+			type target struct{ kind, path string }
+			targets := make([]target, 0, len(outputs))
+			for _, o := range outputs {
+				kind, path, ok := strings.Cut(o, "=")
+				if _, known := reportFormats[kind]; !ok || !known || path == "" {
+					return clierrors.NewValidationError("invalid --output %q (must be html-file=PATH or pdf-file=PATH)", o)
+				}
+				targets = append(targets, target{kind, path})
+			}
+
+			res, err := loadResult(inputPath, last)
+			if err != nil {
+				return err
+			}
+
+			opts := results.ReportOptions{Title: title, GeneratedAt: time.Now()}
+			for _, t := range targets {
+				if err := reportFormats[t.kind](cmd.Context(), res, opts, t.path); err != nil {
+					return err
+				}
+				fmt.Fprintf(os.Stderr, "Wrote report to %s\n", t.path)
+			}
+			return nil
+		},
+	}
+
+	addResultFlags(cmd, &inputPath, &last)
+	cmd.Flags().StringArrayVar(&outputs, "output", nil, "Report to write, as html-file=PATH or pdf-file=PATH (repeatable)")
+	cmd.Flags().StringVar(&pdfPath, "pdf", "", "Write a PDF report to this file (same as --output pdf-file=PATH)")
+	cmd.Flags().StringVar(&title, "title", "", "Report title (default \"Kusari Inspector Report\")")
+	return cmd
+}
+
+// platformJira is the create-issues --platform for Jira, which is an issue
+// tracker only and so not one of the repo platforms.
+const platformJira = "jira"
+
+func resultsCreateIssues() *cobra.Command {
+	var (
+		inputPath string
+		last      bool
+		platform  string
+		repoSlug  string
+		projectID string
+		groupBy   string
+		dryRun    bool
+		jiraURL   string
+	)
+
+	cmd := &cobra.Command{
+		Use:   "create-issues",
+		Short: "Open GitHub, GitLab or Jira issues for unresolved findings",
+		Long: `Open an issue for each finding of an Inspector result, or for each file
+with --group-by file, so findings of full-repo risk checks are tracked after
+the run.
+
+Each issue carries a hidden marker, and findings that already have an issue,
+open or closed, are skipped; a finding closed as won't fix is not reopened.
+Issues get the issue_labels and issue_assignees of kusari.yaml in the current
+directory (default label: kusari). Issues opened before are looked up by those
+labels, so keep them stable.
+
+The platform, repository and token default to the CI environment:
+GITHUB_REPOSITORY and GITHUB_TOKEN or GH_TOKEN on GitHub, CI_PROJECT_ID and
+GITLAB_TOKEN on GitLab.
+
+With --platform jira, tickets are filed in the jira_project_key project of
+kusari.yaml as jira_issue_type, with jira_custom_fields set on new tickets.
+Each ticket gets a fingerprint label (kusari-finding-...), and a finding that
+already has a ticket has its summary and description updated instead. The
+site is --jira-url or JIRA_URL, never kusari.yaml, since the credentials are
+sent to it; set JIRA_API_TOKEN, plus JIRA_USER for Jira Cloud (basic auth
+with an API token).
+
+Examples:
+  kusari repo risk-check . && kusari results create-issues --last
+  kusari results create-issues --input results.sarif --platform github --repo acme/app
+  kusari results create-issues --last --platform gitlab --project 1234 --group-by file --dry-run
+  kusari results create-issues --last --platform jira`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cmd.SilenceUsage = true
+
+			if groupBy != "finding" && groupBy != "file" {
+				return clierrors.NewValidationError("invalid --group-by %q (must be finding or file)", groupBy)
+			}
+			if platform == "" {
+				switch {
+				case os.Getenv("GITHUB_REPOSITORY") != "":
+					platform = repo.PlatformGitHub
+				case os.Getenv("CI_PROJECT_ID") != "":
+					platform = repo.PlatformGitLab
+				default:
+					return clierrors.NewValidationError("--platform is required outside GitHub Actions and GitLab CI")
+				}
+			}
+
+			cfg, err := configuration.Load(".")
+			if err != nil {
+				return clierrors.NewValidationError("%v", err)
+			}
+
+			res, err := loadResult(inputPath, last)
+			if err != nil {
+				return err
+			}
+			issues := res.Issues(groupBy == "file")
+			if len(issues) == 0 {
+				fmt.Fprintln(os.Stderr, "No findings - no issues to open")
+				return nil
+			}
+			if dryRun {
+				for _, i := range issues {
+					fmt.Println(i.Title)
+				}
+				fmt.Fprintf(os.Stderr, "Would open up to %d issue(s); findings that already have one are skipped\n", len(issues))
+				return nil
+			}
+
+			var created []string
+			switch platform {
+			case repo.PlatformGitHub:
+				if repoSlug == "" {
+					repoSlug = os.Getenv("GITHUB_REPOSITORY")
+				}
+				owner, name, ok := strings.Cut(repoSlug, "/")
+				if !ok || owner == "" || name == "" {
+					return clierrors.NewValidationError("--repo must be owner/repo")
+				}
+				token := github.GetTokenFromEnv()
+				if token == "" {
+					return clierrors.NewValidationError("no GitHub token found (set GITHUB_TOKEN or GH_TOKEN)")
+				}
+				created, err = github.CreateIssues(issues, github.IssueOptions{
+					Owner:     owner,
+					Repo:      name,
+					GitHubURL: github.GetGitHubAPIURLFromEnv(),
+					Token:     token,
+					Labels:    cfg.IssueLabels,
+					Assignees: cfg.IssueAssignees,
+					Verbose:   verbose,
+				})
+			case repo.PlatformGitLab:
+				if projectID == "" {
+					projectID = os.Getenv("CI_PROJECT_ID")
+				}
+				if projectID == "" {
+					return clierrors.NewValidationError("--project is required outside GitLab CI")
+				}
+				token := gitlab.GetTokenFromEnv()
+				if token == "" {
+					return clierrors.NewValidationError("no GitLab token found (set GITLAB_TOKEN, GITLAB_OAUTH_TOKEN or CI_JOB_TOKEN)")
+				}
+				if err := gitlab.CheckTokenScopes(gitlab.GetGitLabAPIURLFromEnv(), token, gitlab.ScopeAPI); err != nil {
+					return clierrors.NewValidationError("%v", err)
+				}
+				created, err = gitlab.CreateIssues(issues, gitlab.IssueOptions{
+					ProjectID: projectID,
+					GitLabURL: gitlab.GetGitLabAPIURLFromEnv(),
+					Token:     token,
+					Labels:    cfg.IssueLabels,
+					Assignees: cfg.IssueAssignees,
+					Verbose:   verbose,
+				})
+			case platformJira:
+				if cfg.JiraProjectKey == "" {
+					return clierrors.NewValidationError("no Jira project (set jira_project_key in kusari.yaml)")
+				}
+				client, err := jira.NewClientFromEnv(jiraURL)
+				if err != nil {
+					return clierrors.NewValidationError("%v", err)
+				}
+				created, updated, err := client.Sync(cmd.Context(), issues, jira.SyncOptions{
+					ProjectKey:   cfg.JiraProjectKey,
+					IssueType:    cfg.JiraIssueType,
+					Labels:       cfg.IssueLabels,
+					CustomFields: cfg.JiraCustomFields,
+					Verbose:      verbose,
+				})
+				for _, u := range created {
+					fmt.Println(u)
+				}
+				fmt.Fprintf(os.Stderr, "Opened %d ticket(s), updated %d\n", len(created), len(updated))
+				return err
+			default:
+				return clierrors.NewValidationError("invalid --platform %q (must be %s, %s or %s)", platform, repo.PlatformGitHub, repo.PlatformGitLab, platformJira)
+			}
+
+			for _, u := range created {
+				fmt.Println(u)
+			}
+			fmt.Fprintf(os.Stderr, "Opened %d issue(s), %d already tracked\n", len(created), len(issues)-len(created))
+			return err
+		},
+	}
+
+	addResultFlags(cmd, &inputPath, &last)
+	cmd.Flags().StringVar(&platform, "platform", "", "Issue tracker: github, gitlab or jira (default from the CI environment)")
+	cmd.Flags().StringVar(&repoSlug, "repo", "", "GitHub repository as owner/repo (default $GITHUB_REPOSITORY)")
+	cmd.Flags().StringVar(&projectID, "project", "", "GitLab project ID or path (default $CI_PROJECT_ID)")
+	cmd.Flags().StringVar(&groupBy, "group-by", "finding", "One issue per finding or per file")
+	cmd.Flags().BoolVar(&dryRun, "dry-run", false, "Print the issues that would be opened without opening them")
+	cmd.Flags().StringVar(&jiraURL, "jira-url", "", "Jira site for --platform jira, e.g. https://acme.atlassian.net (default $JIRA_URL)")
+	return cmd
+}
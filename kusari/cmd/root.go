@@ -4,11 +4,22 @@
 package cmd
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"os"
 	"runtime/debug"
 	"strings"
+	"time"
 
+	"github.com/kusaridev/kusari-cli/v2/pkg/auth"
+	"github.com/kusaridev/kusari-cli/v2/pkg/clierrors"
 	"github.com/kusaridev/kusari-cli/v2/pkg/constants"
+	"github.com/kusaridev/kusari-cli/v2/pkg/fips"
+	"github.com/kusaridev/kusari-cli/v2/pkg/output"
+	"github.com/kusaridev/kusari-cli/v2/pkg/readonly"
+	"github.com/kusaridev/kusari-cli/v2/pkg/transport"
+	"github.com/kusaridev/kusari-cli/v2/pkg/versioncheck"
 	"github.com/spf13/cobra"
 	"github.com/spf13/pflag"
 	"github.com/spf13/viper"
@@ -18,6 +29,31 @@ var (
 	consoleUrl  string
 	platformUrl string
 	verbose     bool
+	quiet       bool
+	noColor     bool
+	wide        bool
+	width       int
+
+	plainProgress bool
+
+	tokenEncryption  string
+	bundleEncryption string
+	nonInteractive   bool
+	errorFormat      string
+	userAgent        string
+	caBundle         string
+	allowedHosts     []string
+	debugHTTP        string
+	recordDir        string
+	replayDir        string
+	versionCheck     string
+	readOnly         bool
+	httpTimeout      time.Duration
+	deadline         time.Duration
+
+	// cancelRun cancels the context commands run with, once the --deadline
+	// passes.
+	cancelRun context.CancelCauseFunc
 
 	// Version information (injected at build time)
 	version = "dev"
@@ -97,6 +133,25 @@ func init() {
 	rootCmd.PersistentFlags().StringVarP(&consoleUrl, "console-url", "", constants.DefaultConsoleURL, "console url")
 	rootCmd.PersistentFlags().StringVarP(&platformUrl, "platform-url", "", constants.DefaultPlatformURL, "platform url")
 	rootCmd.PersistentFlags().BoolVarP(&verbose, "verbose", "v", false, "Verbose output")
+	rootCmd.PersistentFlags().BoolVarP(&quiet, "quiet", "q", false, "Suppress spinners and progress messages; only print results and errors")
+	rootCmd.PersistentFlags().BoolVar(&plainProgress, "plain-progress", false, "Print plain-text status lines instead of spinners and status symbols (always on when stderr is not a terminal)")
+	rootCmd.PersistentFlags().BoolVar(&noColor, "no-color", false, "Disable colored output (also honors the NO_COLOR environment variable)")
+	rootCmd.PersistentFlags().BoolVar(&wide, "wide", false, "Do not wrap rendered results or truncate table columns")
+	rootCmd.PersistentFlags().IntVar(&width, "width", 0, "Wrap rendered results at this many columns (default: terminal width)")
+	rootCmd.PersistentFlags().BoolVar(&nonInteractive, "non-interactive", false, "Never prompt; take every input from flags and KUSARI_* environment variables, and fail when one is missing")
+	rootCmd.PersistentFlags().StringVar(&errorFormat, "error-format", "", "How to print a failing command's error on stderr: text or json (default: json with --non-interactive, else text)")
+	rootCmd.PersistentFlags().StringVar(&tokenEncryption, "token-encryption", "", "Encrypt ~/.kusari/tokens.json at rest: none, passphrase (uses KUSARI_TOKEN_KEY or prompts), or machine")
+	rootCmd.PersistentFlags().StringVar(&bundleEncryption, "bundle-encryption", "", "Encrypt scan packages with the workspace's public key before upload: none, auto (when the workspace has a key), or required")
+	rootCmd.PersistentFlags().StringVar(&versionCheck, "version-check", versioncheck.ModeWarn, "What to do when the platform no longer supports this CLI version: warn, enforce (exit with code 9) or off")
+	rootCmd.PersistentFlags().StringVar(&debugHTTP, "debug-http", "", "Record every HTTP request and response, with secrets redacted and bodies truncated, under this directory for a bug report")
+	rootCmd.PersistentFlags().StringVar(&recordDir, "record", "", "Record every HTTP transaction, with secrets redacted, as fixtures in this directory for --replay")
+	rootCmd.PersistentFlags().StringVar(&replayDir, "replay", "", "Answer every HTTP request from the fixtures --record wrote to this directory instead of sending it")
+	rootCmd.PersistentFlags().BoolVar(&readOnly, "read-only", false, "Refuse every operation that changes something (uploads, comments, issues, deletions), e.g. for auditors; reads still work")
+	rootCmd.PersistentFlags().DurationVar(&httpTimeout, "http-timeout", 0, "Timeout of each HTTP request, e.g. 2m for large comments over a slow link (default: 10s to 90s depending on the request, none for uploads)")
+	rootCmd.PersistentFlags().DurationVar(&deadline, "deadline", 0, "Give up on the whole command, retries and polling included, after this long, e.g. 5m for fast-failing CI (default: none)")
+	rootCmd.PersistentFlags().StringVar(&caBundle, "ca-bundle", "", "PEM file of CA certificates to trust on top of the system's, e.g. for a TLS-inspecting proxy")
+	rootCmd.PersistentFlags().StringSliceVar(&allowedHosts, "allowed-hosts", nil, "Refuse to contact any host but these, comma-separated, with * matching any characters (e.g. '*.kusari.cloud,*.s3.us-east-1.amazonaws.com'), for air-gapped deployments")
+	rootCmd.PersistentFlags().StringVar(&userAgent, "user-agent", "", "Product token appended to the User-Agent of every request, e.g. my-pipeline/1.0")
 
 	// Set environment variable prefix (optional)
 	viper.SetEnvPrefix("KUSARI") // Will look for KUSARI_CONSOLE_URL, KUSARI_VERBOSE, etc.
@@ -107,6 +162,33 @@ func init() {
 	mustBindPFlag("console-url", rootCmd.PersistentFlags().Lookup("console-url"))
 	mustBindPFlag("platform-url", rootCmd.PersistentFlags().Lookup("platform-url"))
 	mustBindPFlag("verbose", rootCmd.PersistentFlags().Lookup("verbose"))
+	mustBindPFlag("quiet", rootCmd.PersistentFlags().Lookup("quiet"))
+	mustBindPFlag("no-color", rootCmd.PersistentFlags().Lookup("no-color"))
+	mustBindPFlag("plain-progress", rootCmd.PersistentFlags().Lookup("plain-progress"))
+	mustBindPFlag("wide", rootCmd.PersistentFlags().Lookup("wide"))
+	mustBindPFlag("width", rootCmd.PersistentFlags().Lookup("width"))
+	mustBindPFlag("token-encryption", rootCmd.PersistentFlags().Lookup("token-encryption"))
+	mustBindPFlag("bundle-encryption", rootCmd.PersistentFlags().Lookup("bundle-encryption"))
+	mustBindPFlag("non-interactive", rootCmd.PersistentFlags().Lookup("non-interactive"))
+	mustBindPFlag("error-format", rootCmd.PersistentFlags().Lookup("error-format"))
+	mustBindPFlag("user-agent", rootCmd.PersistentFlags().Lookup("user-agent"))
+	mustBindPFlag("debug-http", rootCmd.PersistentFlags().Lookup("debug-http"))
+	mustBindPFlag("record", rootCmd.PersistentFlags().Lookup("record"))
+	mustBindPFlag("replay", rootCmd.PersistentFlags().Lookup("replay"))
+	mustBindPFlag("version-check", rootCmd.PersistentFlags().Lookup("version-check"))
+	mustBindPFlag("read-only", rootCmd.PersistentFlags().Lookup("read-only"))
+	mustBindPFlag("http-timeout", rootCmd.PersistentFlags().Lookup("http-timeout"))
+	mustBindPFlag("deadline", rootCmd.PersistentFlags().Lookup("deadline"))
+	mustBindPFlag("ca-bundle", rootCmd.PersistentFlags().Lookup("ca-bundle"))
+	mustBindPFlag("allowed-hosts", rootCmd.PersistentFlags().Lookup("allowed-hosts"))
+
+	// Unknown or malformed flags are usage errors; report them as such so
+	// they exit with ExitValidation rather than ExitGeneral.
+	rootCmd.SetFlagErrorFunc(func(cmd *cobra.Command, err error) error {
+		// The usage text would break JSON error output.
+		cmd.SilenceUsage = jsonErrors()
+		return &clierrors.ValidationError{Message: err.Error()}
+	})
 }
 
 func initConfig() {
@@ -121,6 +203,93 @@ func initConfig() {
 			fmt.Println("Using config file:", viper.ConfigFileUsed())
 		}
 	}
+
+	// Applied here rather than in PersistentPreRun so subcommands that
+	// define their own PersistentPreRun still pick it up.
+	consoleUrl = viper.GetString("console-url")
+	platformUrl = viper.GetString("platform-url")
+	quiet = viper.GetBool("quiet")
+	noColor = viper.GetBool("no-color")
+	wide = viper.GetBool("wide")
+	width = viper.GetInt("width")
+	output.Configure(quiet, noColor)
+	plainProgress = viper.GetBool("plain-progress")
+	output.SetPlainProgress(plainProgress)
+	output.SetVerbose(viper.GetBool("verbose"))
+	output.SetWidth(width, wide)
+	nonInteractive = viper.GetBool("non-interactive")
+	output.SetNonInteractive(nonInteractive)
+	errorFormat = viper.GetString("error-format")
+	if errorFormat != "" && errorFormat != "text" && errorFormat != "json" {
+		fmt.Fprintf(os.Stderr, "Warning: unknown --error-format %q; using text\n", errorFormat)
+	}
+	rootCmd.SilenceUsage = jsonErrors()
+
+	tokenEncryption = viper.GetString("token-encryption")
+	mode, err := auth.ParseTokenEncryption(tokenEncryption)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: %v; leaving token file encryption unchanged\n", err)
+	}
+	auth.SetTokenEncryption(mode)
+
+	// Validated when a scan starts, so a typo fails the scan rather than
+	// uploading in plaintext.
+	bundleEncryption = viper.GetString("bundle-encryption")
+
+	readOnly = viper.GetBool("read-only")
+	readonly.Set(readOnly)
+
+	userAgent = viper.GetString("user-agent")
+	transport.Install(getVersion(), userAgent)
+	// KUSARI_ALLOWED_HOSTS comes as one string, split on spaces only.
+	allowedHosts = nil
+	for _, hosts := range viper.GetStringSlice("allowed-hosts") {
+		allowedHosts = append(allowedHosts, strings.Split(hosts, ",")...)
+	}
+	if err := transport.SetAllowedHosts(allowedHosts); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+	}
+	if caBundle = viper.GetString("ca-bundle"); caBundle != "" {
+		if err := transport.SetCABundle(caBundle); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: %v; using the system's CA certificates only\n", err)
+		}
+	}
+	if debugHTTP = viper.GetString("debug-http"); debugHTTP != "" {
+		if dir, err := transport.SetCapture(debugHTTP); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: %v; not recording HTTP transactions\n", err)
+		} else {
+			output.Progressf(os.Stderr, "Recording HTTP transactions to %s\n", dir)
+		}
+	}
+	recordDir = viper.GetString("record")
+	replayDir = viper.GetString("replay")
+	if replayDir != "" {
+		if recordDir != "" {
+			fmt.Fprintf(os.Stderr, "Warning: --record is ignored with --replay\n")
+		}
+		// A replay that can't load its fixtures fails every request
+		// rather than reaching the network.
+		if err := transport.SetReplay(replayDir); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+		} else {
+			output.Progressf(os.Stderr, "Replaying HTTP transactions from %s\n", replayDir)
+		}
+	} else if recordDir != "" {
+		if err := transport.SetRecord(recordDir); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: %v; not recording HTTP transactions\n", err)
+		} else {
+			output.Progressf(os.Stderr, "Recording HTTP transactions as fixtures in %s\n", recordDir)
+		}
+	}
+	httpTimeout = viper.GetDuration("http-timeout")
+	transport.SetTimeout(httpTimeout)
+	if deadline = viper.GetDuration("deadline"); deadline > 0 {
+		expired := transport.SetDeadline(deadline)
+		if cancelRun != nil {
+			time.AfterFunc(deadline, func() { cancelRun(expired) })
+		}
+	}
+	output.Debug("request ID", "id", transport.RequestID())
 }
 
 var rootCmd = &cobra.Command{
@@ -129,8 +298,6 @@ var rootCmd = &cobra.Command{
 	Long:  "Kusari CLI - Interact with Kusari products",
 	PersistentPreRun: func(cmd *cobra.Command, args []string) {
 		// Update from viper (this gets env vars + config + flags)
-		consoleUrl = viper.GetString("console-url")
-		platformUrl = viper.GetString("platform-url")
 		verbose = viper.GetBool("verbose")
 	},
 }
@@ -138,12 +305,86 @@ var rootCmd = &cobra.Command{
 func Execute() error {
 
 	rootCmd.AddCommand(Auth())
-	rootCmd.AddCommand(Repo())
-	rootCmd.AddCommand(Platform())
+	rootCmd.AddCommand(initRepo())
+	rootCmd.AddCommand(withVersionCheck(Repo()))
+	rootCmd.AddCommand(withVersionCheck(Image()))
+	rootCmd.AddCommand(withVersionCheck(Platform()))
+	rootCmd.AddCommand(withVersionCheck(Workspace()))
+	rootCmd.AddCommand(withVersionCheck(Package()))
+	rootCmd.AddCommand(SBOM())
+	rootCmd.AddCommand(CI())
+	rootCmd.AddCommand(Policy())
+	rootCmd.AddCommand(Results())
+	rootCmd.AddCommand(annotateCmd())
+	rootCmd.AddCommand(Webhook())
+	rootCmd.AddCommand(Dev())
+	rootCmd.AddCommand(schemaCmd())
+	rootCmd.AddCommand(withVersionCheck(Schedule()))
+	rootCmd.AddCommand(Cache())
 	rootCmd.AddCommand(KusariConfiguration())
 	rootCmd.AddCommand(AI())
+	rootCmd.AddCommand(MCP())
+	rootCmd.AddCommand(lspCmd())
+	rootCmd.AddCommand(versionCmd())
+	rootCmd.AddCommand(exitCodesHelp)
+
+	// Errors are printed here rather than by cobra so they can be written
+	// as JSON for callers that parse stderr.
+	rootCmd.SilenceErrors = true
+	ctx, cancel := context.WithCancelCause(context.Background())
+	defer cancel(nil)
+	cancelRun = cancel
+	err := fips.Check()
+	if err == nil {
+		err = rootCmd.ExecuteContext(ctx)
+	}
+	// Work the --deadline cut short fails with context.Canceled; say why.
+	if cause := context.Cause(ctx); err != nil && cause != nil && errors.Is(err, context.Canceled) {
+		err = fmt.Errorf("%w: %v", cause, err)
+	}
+	if err != nil {
+		printError(err)
+	}
+	return err
+}
+
+// jsonErrors reports whether errors are printed as JSON. It reads viper
+// directly because initConfig doesn't run when flag parsing fails.
+func jsonErrors() bool {
+	format := viper.GetString("error-format")
+	if format == "" {
+		return viper.GetBool("non-interactive")
+	}
+	return format == "json"
+}
+
+// printError writes err to stderr in the --error-format format. Once a
+// request was sent, the request ID is included so support can find it in
+// the platform's logs.
+func printError(err error) {
+	requestID := ""
+	if transport.Sent() {
+		requestID = transport.RequestID()
+	}
+	if jsonErrors() {
+		if jsonErr := clierrors.WriteJSON(os.Stderr, err, requestID); jsonErr == nil {
+			return
+		}
+	}
+	msg := output.Linkify(os.Stderr, err.Error())
+	if requestID != "" {
+		rootCmd.PrintErrln(rootCmd.ErrPrefix(), msg, "(request ID: "+requestID+")")
+		return
+	}
+	rootCmd.PrintErrln(rootCmd.ErrPrefix(), msg)
+}
 
-	return rootCmd.Execute()
+// exitCodesHelp is a help topic (no Run), shown as `kusari help exit-codes`.
+var exitCodesHelp = &cobra.Command{
+	Use:   "exit-codes",
+	Short: "Process exit codes and what they mean",
+	Long: "kusari exits with a distinct code per failure class so CI pipelines can branch on it:\n\n" +
+		clierrors.ExitCodeHelp(),
 }
 
 func mustBindPFlag(key string, flag *pflag.Flag) {
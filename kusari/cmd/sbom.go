@@ -0,0 +1,145 @@
+// Copyright (c) Kusari <https://www.kusari.dev/>
+// SPDX-License-Identifier: MIT
+
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/kusaridev/kusari-cli/v2/pkg/clierrors"
+	"github.com/kusaridev/kusari-cli/v2/pkg/sbom"
+	"github.com/spf13/cobra"
+)
+
+func SBOM() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "sbom",
+		Short: "SBOM operations",
+		Long:  "Rework SPDX and CycloneDX JSON SBOMs before uploading them",
+	}
+
+	cmd.AddCommand(sbomMerge())
+	cmd.AddCommand(sbomConvert())
+
+	return cmd
+}
+
+func sbomMerge() *cobra.Command {
+	var (
+		out     string
+		name    string
+		version string
+	)
+
+	cmd := &cobra.Command{
+		Use:   "merge <sbom> <sbom>...",
+		Short: "Merge SBOMs into one",
+		Long: `Merge several SPDX or CycloneDX JSON SBOMs of the same format into one, so a
+build that emits one SBOM per module becomes a single platform entry.
+
+Components (CycloneDX) or packages (SPDX) found in several SBOMs are listed
+once: by purl, or else by name and version. Dependencies and relationships
+are kept, and what each SBOM described becomes a dependency of the merged
+SBOM's subject, named by --name and --version or else after the first SBOM.
+The SBOMs merged from are recorded in the CycloneDX metadata properties
+(kusari:sbom:merged-from) or the SPDX creation info comment, along with
+the tools that generated them.
+
+Examples:
+  kusari sbom merge --out merged.cdx.json api.cdx.json worker.cdx.json
+  kusari sbom merge --out app.spdx.json --name app --version 1.4.0 */sbom.spdx.json`,
+		Args: cobra.MinimumNArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cmd.SilenceUsage = true
+
+			docs := make([]*sbom.Document, 0, len(args))
+			for _, path := range args {
+				d, err := sbom.Read(path)
+				if err != nil {
+					return clierrors.NewValidationError("%v", err)
+				}
+				docs = append(docs, d)
+			}
+			merged, err := sbom.Merge(docs, sbom.MergeOptions{Name: name, Version: version, Tool: getVersion()})
+			if err != nil {
+				return clierrors.NewValidationError("%v", err)
+			}
+			if err := merged.Write(out); err != nil {
+				return err
+			}
+			fmt.Fprintf(os.Stderr, "Merged %d SBOMs into %s\n", len(docs), out)
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVarP(&out, "out", "o", "", "file to write the merged SBOM to (required)")
+	cmd.Flags().StringVar(&name, "name", "", "name of what the merged SBOM describes (default: that of the first SBOM)")
+	cmd.Flags().StringVar(&version, "version", "", "version of what the merged SBOM describes (default: that of the first SBOM)")
+	_ = cmd.MarkFlagRequired("out")
+
+	return cmd
+}
+
+func sbomConvert() *cobra.Command {
+	var (
+		to  string
+		out string
+	)
+
+	cmd := &cobra.Command{
+		Use:   "convert --to <spdx|cyclonedx> <sbom>",
+		Short: "Convert an SBOM between SPDX and CycloneDX",
+		Long: `Convert an SPDX 2.x JSON SBOM to CycloneDX 1.5 JSON, or a CycloneDX JSON SBOM
+to SPDX 2.3 JSON, for teams standardizing on one format. The converted SBOM
+is written to --out, or to stdout.
+
+Packages are kept with their purl, CPE, version, supplier, licenses and
+hashes, as are the dependencies between them and what the SBOM describes.
+SPDX files and snippets, and CycloneDX services, are dropped. Converting
+the same SBOM again gives the same serial number or namespace.
+
+'kusari platform upload --convert-to' converts SBOMs this way as it
+uploads them.
+
+Examples:
+  kusari sbom convert --to cyclonedx sbom.spdx.json > sbom.cdx.json
+  kusari sbom convert --to spdx --out sbom.spdx.json sbom.cdx.json`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cmd.SilenceUsage = true
+
+			format, err := sbom.ParseFormat(to)
+			if err != nil {
+				return clierrors.NewValidationError("--to: %v", err)
+			}
+			d, err := sbom.Read(args[0])
+			if err != nil {
+				return clierrors.NewValidationError("%v", err)
+			}
+			converted, err := sbom.Convert(d, format, getVersion())
+			if err != nil {
+				return err
+			}
+			if out != "" {
+				if err := converted.Write(out); err != nil {
+					return err
+				}
+				fmt.Fprintf(os.Stderr, "Converted %s from %s to %s in %s\n", args[0], d.Format, format, out)
+				return nil
+			}
+			data, err := converted.Marshal()
+			if err != nil {
+				return err
+			}
+			_, err = os.Stdout.Write(data)
+			return err
+		},
+	}
+
+	cmd.Flags().StringVar(&to, "to", "", "format to convert to: spdx or cyclonedx (required)")
+	cmd.Flags().StringVarP(&out, "out", "o", "", "file to write the converted SBOM to (default: stdout)")
+	_ = cmd.MarkFlagRequired("to")
+
+	return cmd
+}
@@ -0,0 +1,271 @@
+// Copyright (c) Kusari <https://www.kusari.dev/>
+// SPDX-License-Identifier: MIT
+
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/kusaridev/kusari-cli/v2/pkg/clierrors"
+	"github.com/kusaridev/kusari-cli/v2/pkg/repo"
+	"github.com/kusaridev/kusari-cli/v2/pkg/results"
+	"github.com/kusaridev/kusari-cli/v2/pkg/schedule"
+	"github.com/spf13/cobra"
+)
+
+func Schedule() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "schedule",
+		Short: "Scheduled scans of local repositories",
+		Long: `Run Kusari scans on a schedule from this machine, with cron or a systemd
+user timer, for repositories without CI coverage.`,
+	}
+
+	cmd.AddCommand(scheduleInstall())
+	cmd.AddCommand(scheduleRun())
+	cmd.AddCommand(scheduleRemove())
+
+	return cmd
+}
+
+// scheduleJobFlags are the flags install and run share.
+type scheduleJobFlags struct {
+	name          string
+	full          bool
+	rev           string
+	notify        string
+	notifyHeaders []string
+}
+
+func (f *scheduleJobFlags) register(cmd *cobra.Command) {
+	cmd.Flags().StringVar(&f.name, "name", "", "Job name (default: the repository directory name)")
+	cmd.Flags().BoolVar(&f.full, "full", false, "Risk-check the whole repository (kusari repo risk-check)")
+	cmd.Flags().StringVar(&f.rev, "rev", "", "Diff-scan changes against this git ref instead (kusari repo scan)")
+	cmd.Flags().StringVar(&f.notify, "notify", "", "Webhook URL to post the results of each run to")
+	cmd.Flags().StringArrayVar(&f.notifyHeaders, "notify-header", nil, `Header sent with --notify, as "Name: value"; $VAR is expanded when the job runs (repeatable)`)
+}
+
+func (f *scheduleJobFlags) validate() error {
+	if f.full == (f.rev != "") {
+		return clierrors.NewValidationError("pass either --full or --rev <git-ref>")
+	}
+	if f.notify == "" && len(f.notifyHeaders) > 0 {
+		return clierrors.NewValidationError("--notify-header needs --notify")
+	}
+	for _, h := range f.notifyHeaders {
+		if name, _, ok := strings.Cut(h, ":"); !ok || strings.TrimSpace(name) == "" {
+			return clierrors.NewValidationError("invalid --notify-header %q (want \"Name: value\")", h)
+		}
+	}
+	return nil
+}
+
+func (f *scheduleJobFlags) apply(j *schedule.Job) {
+	j.Full = f.full
+	j.Rev = f.rev
+	j.Notify = f.notify
+	j.NotifyHeaders = f.notifyHeaders
+}
+
+func scheduleInstall() *cobra.Command {
+	var (
+		flags     scheduleJobFlags
+		cron      string
+		scheduler string
+		printOnly bool
+	)
+
+	cmd := &cobra.Command{
+		Use:   "install [directory]",
+		Short: "Install a recurring scan of a repository",
+		Long: `Install a cron entry (the default) or a systemd user timer that runs
+'kusari schedule run' on the repository (the current directory by default)
+on the --cron schedule. Each run scans the repository and, with --notify,
+posts a JSON summary to the webhook:
+
+  {"job": ..., "repository": ..., "kind": "risk-check", "status": "flagged",
+   "health_score": 3, "console_url": ..., "findings": [...]}
+
+status is passed, flagged or error. Installing a job again under the same
+--name (the directory name by default) replaces it.
+
+The job runs without your shell environment: it uses the login stored by
+'kusari auth login', so log in (or store an API key) as the same user.
+Cron output is appended to ~/.kusari/logs/schedule-<name>.log; systemd
+output goes to the journal.
+
+Examples:
+  kusari schedule install --cron "0 2 * * *" --full
+  kusari schedule install ~/src/app --full --notify https://hooks.example.com/kusari \
+    --notify-header 'Authorization: Bearer $HOOK_TOKEN'
+  kusari schedule install --scheduler systemd --rev origin/main --print`,
+		Args: cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cmd.SilenceUsage = true
+
+			if err := flags.validate(); err != nil {
+				return err
+			}
+			if scheduler != schedule.Cron && scheduler != schedule.Systemd {
+				return clierrors.NewValidationError("invalid --scheduler %q (must be cron or systemd)", scheduler)
+			}
+			if _, err := schedule.OnCalendar(cron); err != nil {
+				return clierrors.NewValidationError("%v", err)
+			}
+
+			dir := "."
+			if len(args) > 0 {
+				dir = args[0]
+			}
+			if _, err := os.Stat(filepath.Join(dir, ".git")); err != nil {
+				return clierrors.NewValidationError("no .git directory found in %s: directory must be root of repo", dir)
+			}
+			job, err := schedule.NewJob(dir, flags.name)
+			if err != nil {
+				return clierrors.NewValidationError("%v", err)
+			}
+			job.Cron = cron
+			flags.apply(job)
+
+			if scheduler == schedule.Systemd {
+				if printOnly {
+					service, timer, err := job.SystemdUnits()
+					if err != nil {
+						return err
+					}
+					fmt.Printf("# %s.service\n%s\n# %s.timer\n%s", job.UnitName(), service, job.UnitName(), timer)
+					return nil
+				}
+				unitDir, err := schedule.InstallSystemd(job)
+				if err != nil {
+					return err
+				}
+				fmt.Printf("Installed %s.timer in %s\n", job.UnitName(), unitDir)
+				return nil
+			}
+
+			if printOnly {
+				line, err := job.CronLine()
+				if err != nil {
+					return err
+				}
+				fmt.Println(line)
+				return nil
+			}
+			if err := schedule.InstallCron(job); err != nil {
+				return err
+			}
+			fmt.Printf("Installed cron job %q (%s) for %s\n", job.Name, job.Cron, job.Dir)
+			return nil
+		},
+	}
+
+	flags.register(cmd)
+	cmd.Flags().StringVar(&cron, "cron", schedule.DefaultCron, "Cron schedule (minute hour day month weekday)")
+	cmd.Flags().StringVar(&scheduler, "scheduler", schedule.Cron, "Install with cron or systemd (a user timer)")
+	cmd.Flags().BoolVar(&printOnly, "print", false, "Print the crontab line or systemd units instead of installing them")
+	return cmd
+}
+
+func scheduleRun() *cobra.Command {
+	var flags scheduleJobFlags
+
+	cmd := &cobra.Command{
+		Use:   "run <directory>",
+		Short: "Run a scheduled scan once",
+		Long: `Scan the repository and post the results to --notify. This is what
+installed jobs run; run it by hand to test a job.`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cmd.SilenceUsage = true
+
+			if err := flags.validate(); err != nil {
+				return err
+			}
+			job, err := schedule.NewJob(args[0], flags.name)
+			if err != nil {
+				return clierrors.NewValidationError("%v", err)
+			}
+			flags.apply(job)
+
+			start := time.Now()
+			var runErr error
+			outcome := repo.OutcomeAnalyzed
+			opts := repo.ScanOptions{Dir: job.Dir, PlatformURL: platformUrl, ConsoleURL: consoleUrl, Verbose: verbose, Wait: true,
+				BundleEncryption: repo.BundleEncryption(bundleEncryption)}
+			if job.Full {
+				runErr = repo.RiskCheck(opts)
+			} else {
+				opts.Rev = job.Rev
+				opts.OutputFormat = "markdown"
+				outcome, runErr = repo.ScanWithOutcome(opts)
+			}
+
+			if job.Notify == "" {
+				return runErr
+			}
+			// Only analyzed scans save a result; the others have nothing
+			// new to report.
+			var n schedule.Notification
+			if runErr == nil && outcome != repo.OutcomeAnalyzed {
+				n = schedule.NewUnchangedNotification(job)
+			} else {
+				var res *results.Result
+				if last, _, err := results.Last(); err == nil && !last.SavedAt.Before(start) {
+					res = last
+				}
+				n = schedule.NewNotification(job, res, runErr)
+			}
+			headers := map[string]string{}
+			for _, h := range job.NotifyHeaders {
+				name, value, _ := strings.Cut(h, ":")
+				headers[strings.TrimSpace(name)] = strings.TrimSpace(value)
+			}
+			notifyErr := schedule.Notify(cmd.Context(), job.Notify, headers, n)
+			if notifyErr != nil {
+				notifyErr = clierrors.NewNetworkError("failed to post notification", notifyErr)
+			}
+			return errors.Join(runErr, notifyErr)
+		},
+	}
+
+	flags.register(cmd)
+	return cmd
+}
+
+func scheduleRemove() *cobra.Command {
+	return &cobra.Command{
+		Use:   "remove <name>",
+		Short: "Remove an installed scan",
+		Long:  "Remove the cron entry and systemd timer installed under name, if any.",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cmd.SilenceUsage = true
+
+			removed := false
+			if ok, err := schedule.RemoveCron(args[0]); err != nil {
+				fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+			} else if ok {
+				removed = true
+				fmt.Printf("Removed cron job %q\n", args[0])
+			}
+			ok, err := schedule.RemoveSystemd(args[0])
+			if err != nil {
+				return err
+			}
+			if ok {
+				removed = true
+				fmt.Printf("Removed systemd timer %q\n", args[0])
+			}
+			if !removed {
+				return clierrors.NewValidationError("no scheduled scan named %q", args[0])
+			}
+			return nil
+		},
+	}
+}
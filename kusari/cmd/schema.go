@@ -0,0 +1,49 @@
+// Copyright (c) Kusari <https://www.kusari.dev/>
+// SPDX-License-Identifier: MIT
+
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/kusaridev/kusari-cli/v2/pkg/results"
+	"github.com/spf13/cobra"
+)
+
+func schemaCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "schema NAME",
+		Short: "Print the JSON Schema of the documents the CLI reads and writes",
+		Long: fmt.Sprintf(`Print the JSON Schema (draft 2020-12) of a document, for validating and
+generating code against the output of the CLI:
+
+  result             what 'kusari repo scan --output json' writes
+  security-analysis  the verdict and mitigations in a result
+  inspector-result   a result record, as returned by the platform
+
+Documents written with --output json carry the schema_version they follow
+(currently %s), which is also in the $id of the schemas. The version only
+changes when a field is removed, renamed or changes type.
+
+Examples:
+  kusari schema security-analysis
+  kusari schema result > kusari-result.schema.json`, results.SchemaVersion),
+		ValidArgs: results.SchemaNames(),
+		Args:      cobra.MatchAll(cobra.ExactArgs(1), cobra.OnlyValidArgs),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cmd.SilenceUsage = true
+
+			s, err := results.Schema(args[0])
+			if err != nil {
+				return err
+			}
+			out, err := json.MarshalIndent(s, "", "  ")
+			if err != nil {
+				return fmt.Errorf("failed to marshal schema: %w", err)
+			}
+			fmt.Println(string(out))
+			return nil
+		},
+	}
+}
@@ -14,14 +14,19 @@ import (
 
 var (
 	uploadFilePath                   string
+	uploadSarif                      string
+	uploadDepGraph                   string
 	uploadAlias                      string
 	uploadDocumentType               string
 	uploadOpenVex                    bool
-	uploadTag                        string
+	uploadTags                       []string
+	uploadCITags                     bool
 	uploadSoftwareID                 string
 	uploadSbomSubject                string
 	uploadComponentName              string
 	uploadCheckBlocked               bool
+	uploadBlockedReport              string
+	uploadWaivers                    string
 	uploadSbomSubjectNameOverride    string
 	uploadSbomSubjectVersionOverride string
 	uploadWait                       bool
@@ -32,6 +37,8 @@ var (
 	uploadCommitSha                  string
 	uploadResultsFile                string
 	uploadMapComponents              bool
+	uploadConvertTo                  string
+	uploadNameRules                  string
 )
 
 // addUploadFlags registers the upload-related flags on a cobra command.
@@ -39,7 +46,9 @@ var (
 // derives the file path from --output instead.
 func addUploadFlags(cmd *cobra.Command, includeFilePath bool) {
 	if includeFilePath {
-		cmd.Flags().StringVarP(&uploadFilePath, "file-path", "f", "", "Path to file or directory to upload (required)")
+		cmd.Flags().StringVarP(&uploadFilePath, "file-path", "f", "", "Path to file or directory to upload (required unless --sarif or --dep-graph)")
+		cmd.Flags().StringVar(&uploadSarif, "sarif", "", "Path to a third-party SARIF file (e.g. from semgrep or CodeQL) to upload instead of --file-path")
+		cmd.Flags().StringVar(&uploadDepGraph, "dep-graph", "", "Project directory whose dependency graph to resolve (go mod graph, npm ls, pip freeze) and upload instead of --file-path")
 	}
 	cmd.Flags().StringVarP(&uploadAlias, "alias", "a", "", "Stored in the SBOM's upload metadata; not currently used by the Kusari platform (optional)")
 	if err := cmd.Flags().MarkDeprecated("alias", "it is not used by the Kusari platform and will be removed in a future release"); err != nil {
@@ -47,7 +56,8 @@ func addUploadFlags(cmd *cobra.Command, includeFilePath bool) {
 	}
 	cmd.Flags().StringVarP(&uploadDocumentType, "document-type", "d", "", "Type of the document (image or build) sbom (optional)")
 	cmd.Flags().BoolVar(&uploadOpenVex, "openvex", false, "Indicate that this is an OpenVEX document (optional, only works with files)")
-	cmd.Flags().StringVar(&uploadTag, "tag", "", "Tag value to set in the document wrapper upload meta (optional, e.g. govulncheck)")
+	cmd.Flags().StringSliceVar(&uploadTags, "tag", nil, "Tag value to set in the document wrapper upload meta, comma-separated or repeated (optional, e.g. govulncheck); the first is the tag of OpenVEX documents")
+	cmd.Flags().BoolVar(&uploadCITags, "ci-tags", true, "Also tag the upload with the branch, release tag and pipeline ID of the CI pipeline it runs in")
 	cmd.Flags().StringVar(&uploadSoftwareID, "software-id", "", "Kusari Platform Software ID value to set in the document wrapper upload meta (optional)")
 	cmd.Flags().StringVar(&uploadSbomSubject, "sbom-subject", "", "Kusari Platform Software sbom subject substring value to set in the document wrapper upload meta (optional, for OpenVEX docs only)")
 	cmd.Flags().StringVar(&uploadComponentName, "component-name", "", "Kusari Platform component name (optional)")
@@ -55,6 +65,8 @@ func addUploadFlags(cmd *cobra.Command, includeFilePath bool) {
 		panic(err)
 	}
 	cmd.Flags().BoolVar(&uploadCheckBlocked, "check-blocked-packages", false, "Check if any of the SBOMs uses a package contained in the blocked package list")
+	cmd.Flags().StringVar(&uploadBlockedReport, "blocked-report", "", "Write the blocked packages found (purl, SBOM subject, IDs) to this file as JSON (requires --check-blocked-packages)")
+	cmd.Flags().StringVar(&uploadWaivers, "waivers", "", "YAML file of blocked packages approved until an expiry date; they are reported as warnings instead of failing (requires --check-blocked-packages)")
 	cmd.Flags().StringVar(&uploadSbomSubjectNameOverride, "sbom-subject-name-override", "", "SBOM Subject Name override (optional, for SBOMs only)")
 	cmd.Flags().StringVar(&uploadSbomSubjectVersionOverride, "sbom-subject-version-override", "", "SBOM Subject Version override (optional, from SBOMs only)")
 	cmd.Flags().BoolVar(&uploadWait, "wait", true, "Wait for ingestion status (default: true)")
@@ -65,17 +77,20 @@ func addUploadFlags(cmd *cobra.Command, includeFilePath bool) {
 	cmd.Flags().StringVar(&uploadCommitSha, "commit-sha", "", "Commit SHA (from git) (optional, for SBOMs only)")
 	cmd.Flags().StringVar(&uploadResultsFile, "results-file", "", "Write machine-readable JSON results (software and component IDs for each ingested SBOM) to this file (requires --wait)")
 	cmd.Flags().BoolVar(&uploadMapComponents, "map-components", false, "After ingestion, ensure each ingested software is mapped to a component: create (or reuse) a component named after the software and assign the software to it (requires --wait)")
+	cmd.Flags().StringVar(&uploadConvertTo, "convert-to", "", "Convert SBOMs to this format (spdx or cyclonedx) before uploading them (optional)")
+	cmd.Flags().StringVar(&uploadNameRules, "name-rules", "", "YAML file of subject_name_rules and subject_name_aliases normalizing SBOM subject names (default: those of kusari.yaml)")
 }
 
-// uploadStringVars / uploadBoolVars are the single source of truth for the
+// uploadStringVars / uploadBoolVars / uploadSliceVars are the single source of truth for the
 // upload-related viper keys and their backing package-level variables.
 // bindUploadFlagsToViper and loadUploadFromViper both iterate these maps,
 // so adding a new flag is a one-place change.
 var uploadStringVars = map[string]*string{
 	"file-path":                     &uploadFilePath,
+	"sarif":                         &uploadSarif,
+	"dep-graph":                     &uploadDepGraph,
 	"alias":                         &uploadAlias,
 	"document-type":                 &uploadDocumentType,
-	"tag":                           &uploadTag,
 	"software-id":                   &uploadSoftwareID,
 	"sbom-subject":                  &uploadSbomSubject,
 	"component-name":                &uploadComponentName,
@@ -87,6 +102,10 @@ var uploadStringVars = map[string]*string{
 	"subrepo-path":                  &uploadSubrepoPath,
 	"commit-sha":                    &uploadCommitSha,
 	"results-file":                  &uploadResultsFile,
+	"blocked-report":                &uploadBlockedReport,
+	"waivers":                       &uploadWaivers,
+	"convert-to":                    &uploadConvertTo,
+	"name-rules":                    &uploadNameRules,
 }
 
 var uploadBoolVars = map[string]*bool{
@@ -94,6 +113,11 @@ var uploadBoolVars = map[string]*bool{
 	"check-blocked-packages": &uploadCheckBlocked,
 	"wait":                   &uploadWait,
 	"map-components":         &uploadMapComponents,
+	"ci-tags":                &uploadCITags,
+}
+
+var uploadSliceVars = map[string]*[]string{
+	"tag": &uploadTags,
 }
 
 // bindUploadFlagsToViper points viper at the upload-related flags on the
@@ -113,6 +137,9 @@ func bindUploadFlagsToViper(cmd *cobra.Command) {
 	for key := range uploadBoolVars {
 		bind(key)
 	}
+	for key := range uploadSliceVars {
+		bind(key)
+	}
 }
 
 // loadUploadFromViper materializes env-var/config/CLI values into the
@@ -124,6 +151,9 @@ func loadUploadFromViper() {
 	for key, ptr := range uploadBoolVars {
 		*ptr = viper.GetBool(key)
 	}
+	for key, ptr := range uploadSliceVars {
+		*ptr = viper.GetStringSlice(key)
+	}
 }
 
 // uploadPreRun wires both the rebind and the load. Reused by upload and
@@ -133,6 +163,45 @@ func uploadPreRun(cmd *cobra.Command, _ []string) {
 	loadUploadFromViper()
 }
 
+// uploadOptions assembles repo.UploadOptions from the package-level upload*
+// vars. filePath is passed in because generate derives it from --output.
+func uploadOptions(filePath string) repo.UploadOptions {
+	var firstTag string
+	var moreTags []string
+	if len(uploadTags) > 0 {
+		firstTag, moreTags = uploadTags[0], uploadTags[1:]
+	}
+	return repo.UploadOptions{
+		FilePath:                   filePath,
+		TenantEndpoint:             platformTenantEndpoint,
+		PlatformURL:                platformUrl,
+		Alias:                      uploadAlias,
+		DocType:                    uploadDocumentType,
+		IsOpenVex:                  uploadOpenVex,
+		Tag:                        firstTag,
+		Tags:                       moreTags,
+		CITags:                     uploadCITags,
+		SoftwareID:                 uploadSoftwareID,
+		SbomSubject:                uploadSbomSubject,
+		SbomSubjectNameOverride:    uploadSbomSubjectNameOverride,
+		SbomSubjectVersionOverride: uploadSbomSubjectVersionOverride,
+		CheckBlockedPackages:       uploadCheckBlocked,
+		BlockedReport:              uploadBlockedReport,
+		Waivers:                    uploadWaivers,
+		Wait:                       uploadWait,
+		Forge:                      uploadForge,
+		Org:                        uploadOrg,
+		Repo:                       uploadRepo,
+		SubrepoPath:                uploadSubrepoPath,
+		CommitSha:                  uploadCommitSha,
+		ResultsFile:                uploadResultsFile,
+		MapComponents:              uploadMapComponents,
+		ConvertTo:                  uploadConvertTo,
+		CLIVersion:                 getVersion(),
+		NameRules:                  uploadNameRules,
+	}
+}
+
 // warnIfDeprecatedComponentName prints deprecation messages when
 // component-name or alias were sourced from config/env. CLI uses are
 // already warned about by cobra's MarkDeprecated; this covers the gap.
@@ -156,28 +225,10 @@ func upload() *cobra.Command {
 		cmd.SilenceUsage = true
 		warnIfDeprecatedComponentName(cmd)
 
-		return repo.Upload(
-			uploadFilePath,
-			platformTenantEndpoint,
-			platformUrl,
-			uploadAlias,
-			uploadDocumentType,
-			uploadOpenVex,
-			uploadTag,
-			uploadSoftwareID,
-			uploadSbomSubject,
-			uploadSbomSubjectNameOverride,
-			uploadSbomSubjectVersionOverride,
-			uploadCheckBlocked,
-			uploadWait,
-			uploadForge,
-			uploadOrg,
-			uploadRepo,
-			uploadSubrepoPath,
-			uploadCommitSha,
-			uploadResultsFile,
-			uploadMapComponents,
-		)
+		opts := uploadOptions(uploadFilePath)
+		opts.Sarif = uploadSarif
+		opts.DepGraph = uploadDepGraph
+		return repo.Upload(cmd.Context(), opts)
 	}
 
 	return uploadcmd
@@ -185,10 +236,42 @@ func upload() *cobra.Command {
 
 var uploadcmd = &cobra.Command{
 	Use:   "upload",
-	Short: "Upload SBOM or OpenVEX files to Kusari platform",
+	Short: "Upload SBOM, OpenVEX, SARIF files or dependency graphs to Kusari platform",
 	Long: `Upload SBOM or OpenVEX files to Kusari platform using presigned S3 URLs.
 Can upload individual files or entire directories.
 
+With --sarif, upload the SARIF results of another scanner (semgrep, CodeQL,
+...) so the platform aggregates them with Kusari's own. The tools, and the
+number of results by SARIF level, by Kusari severity and by rule, are added
+to the upload metadata. Levels map to severities as error=high,
+warning=medium, note=low and none=info, unless the rule has a CVSS
+security-severity property.
+
+With --dep-graph, resolve the full dependency graph of a project with its
+package managers (go mod graph for go.mod, npm ls for package.json, pip
+freeze for Python manifests) and upload one DEPENDENCY_GRAPH document per
+ecosystem. Unlike a flat SBOM, the graph records which package requires
+which, for reachability analysis. Run it where the dependencies are
+installed.
+
+With --convert-to, SBOMs are converted to SPDX or CycloneDX before they
+are uploaded, as 'kusari sbom convert' does, so the platform always
+receives the preferred format. SBOMs already in that format, and other
+files, are uploaded as they are.
+
+SBOM subject names are normalized by the subject_name_rules and
+subject_name_aliases of kusari.yaml, or of the --name-rules file, so
+"my-app", "my_app" and "MyApp" from different build systems land under one
+software entry. The normalized name is sent as the subject name override;
+--sbom-subject-name-override skips normalization.
+
+Uploads are tagged with each --tag, the default_tags of kusari.yaml and,
+in GitHub Actions, GitLab CI, Azure Pipelines or Jenkins, the branch,
+release tag and pipeline ID of the build (branch:main, release:v1.2.0,
+pipeline:4242), so an SBOM version can be traced back to the build that
+produced it. The tags are sent comma-separated as the tags upload
+metadata; --ci-tags=false leaves out those of the CI pipeline.
+
 Examples:
   # CI/CD: Upload using tenant name with API key (required in CI/CD)
   kusari platform upload --file-path sbom.json --tenant demo
@@ -203,10 +286,30 @@ Examples:
   kusari platform upload --file-path report.json --tenant demo \
     --openvex --tag govulncheck --software-id 12345
 
+  # CI/CD: Upload semgrep findings
+  kusari platform upload --sarif semgrep.sarif --tenant demo \
+    --forge github.com --org myorg --repo myrepo
+
+  # CI/CD: Upload the dependency graph of the current project
+  kusari platform upload --dep-graph . --tenant demo \
+    --forge github.com --org myorg --repo myrepo
+
+  # CI/CD: Upload a directory of mixed SBOMs, all as CycloneDX
+  kusari platform upload --file-path ./sboms/ --tenant demo --convert-to cyclonedx
+
+  # CI/CD: Tag an SBOM upload, next to the CI pipeline tags
+  kusari platform upload --file-path sbom.json --tenant demo \
+    --tag env:prod --tag team:payments
+
   # CI/CD: Upload with blocked package checking
   kusari platform upload --file-path sbom.json --tenant demo \
     --check-blocked-packages
 
+  # CI/CD: Let approved blocked packages through until their waivers expire,
+  # and keep a JSON report of what was found
+  kusari platform upload --file-path sbom.json --tenant demo \
+    --check-blocked-packages --waivers waivers.yaml --blocked-report blocked.json
+
   # CI/CD: Upload with repository traceability metadata
   kusari platform upload --file-path sbom.json --tenant demo \
     --forge github.com --org myorg --repo myrepo --subrepo-path app/frontend
@@ -11,8 +11,8 @@ import (
 )
 
 // TestLoadUploadFromViper guards against drift between uploadStringVars /
-// uploadBoolVars and the package-level upload* variables. If a new key is
-// added to either map without a corresponding var (or vice versa), this
+// uploadBoolVars / uploadSliceVars and the package-level upload* variables.
+// If a new key is added to any map without a corresponding var (or vice versa), this
 // test will catch it at build time (compile error on &nonexistentVar) or
 // at run time (wrong value materialized).
 func TestLoadUploadFromViper(t *testing.T) {
@@ -21,9 +21,10 @@ func TestLoadUploadFromViper(t *testing.T) {
 
 	stringExpected := map[string]string{
 		"file-path":                     "fp",
+		"sarif":                         "sf",
+		"dep-graph":                     "dg",
 		"alias":                         "a",
 		"document-type":                 "dt",
-		"tag":                           "tg",
 		"software-id":                   "sid",
 		"sbom-subject":                  "ss",
 		"component-name":                "cn",
@@ -35,12 +36,20 @@ func TestLoadUploadFromViper(t *testing.T) {
 		"subrepo-path":                  "srp",
 		"commit-sha":                    "csh",
 		"results-file":                  "rf",
+		"blocked-report":                "br",
+		"waivers":                       "wv",
+		"convert-to":                    "cyclonedx",
+		"name-rules":                    "nr",
 	}
 	boolExpected := map[string]bool{
 		"openvex":                true,
 		"check-blocked-packages": true,
 		"wait":                   true,
 		"map-components":         true,
+		"ci-tags":                true,
+	}
+	sliceExpected := map[string][]string{
+		"tag": {"tg", "env:prod"},
 	}
 
 	for k, v := range stringExpected {
@@ -49,6 +58,9 @@ func TestLoadUploadFromViper(t *testing.T) {
 	for k, v := range boolExpected {
 		viper.Set(k, v)
 	}
+	for k, v := range sliceExpected {
+		viper.Set(k, v)
+	}
 
 	loadUploadFromViper()
 
@@ -67,6 +79,13 @@ func TestLoadUploadFromViper(t *testing.T) {
 			assert.Equal(t, want, *ptr, "viper key %q did not flow into its var", key)
 		}
 	}
+	for key, want := range sliceExpected {
+		ptr, ok := uploadSliceVars[key]
+		assert.True(t, ok, "uploadSliceVars missing key %q", key)
+		if ok {
+			assert.Equal(t, want, *ptr, "viper key %q did not flow into its var", key)
+		}
+	}
 
 	// And every map key must have been covered by the test (drift in the
 	// other direction: var added, test not updated).
@@ -78,4 +97,8 @@ func TestLoadUploadFromViper(t *testing.T) {
 		_, ok := boolExpected[key]
 		assert.True(t, ok, "uploadBoolVars has key %q not covered by test", key)
 	}
+	for key := range uploadSliceVars {
+		_, ok := sliceExpected[key]
+		assert.True(t, ok, "uploadSliceVars has key %q not covered by test", key)
+	}
 }
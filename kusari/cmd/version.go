@@ -0,0 +1,108 @@
+// Copyright (c) Kusari <https://www.kusari.dev/>
+// SPDX-License-Identifier: MIT
+
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/kusaridev/kusari-cli/v2/pkg/clierrors"
+	"github.com/kusaridev/kusari-cli/v2/pkg/fips"
+	"github.com/kusaridev/kusari-cli/v2/pkg/versioncheck"
+	"github.com/spf13/cobra"
+)
+
+// versionInfo is `kusari version --format json`.
+type versionInfo struct {
+	Version   string `json:"version"`
+	Commit    string `json:"commit"`
+	BuildDate string `json:"build_date"`
+	// CryptoMode is the crypto module in use: standard, fips140 or
+	// boringcrypto.
+	CryptoMode string `json:"crypto_mode"`
+	*versioncheck.Status
+}
+
+func versionCmd() *cobra.Command {
+	var (
+		check   bool
+		channel string
+		format  string
+	)
+
+	cmd := &cobra.Command{
+		Use:   "version",
+		Short: "Print the CLI version and check for newer releases",
+		Long: `Print the version, commit and build date of the CLI, and the crypto
+module it runs with: standard, fips140 (Go's FIPS 140-3 module) or
+boringcrypto.
+
+With --check, also look up the latest stable release and the latest
+pre-release on GitHub, and report whether a newer one is available on the
+--channel followed: stable (the default) or beta, which includes
+pre-releases. --format json is meant for fleet management tooling;
+update_available is true when the install is outdated.
+
+Examples:
+  kusari version
+  kusari version --check --channel beta
+  kusari version --check --format json`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cmd.SilenceUsage = true
+
+			if format != "text" && format != "json" {
+				return clierrors.NewValidationError("invalid --format %q (must be text or json)", format)
+			}
+			if channel != versioncheck.ChannelStable && channel != versioncheck.ChannelBeta {
+				return clierrors.NewValidationError("invalid --channel %q (must be stable or beta)", channel)
+			}
+
+			info := versionInfo{Version: getVersion(), Commit: getCommit(), BuildDate: getBuildDate(), CryptoMode: fips.Mode()}
+			if check {
+				status, err := versioncheck.CheckReleases(cmd.Context(), versioncheck.ReleasesURL, info.Version, channel)
+				if err != nil {
+					return clierrors.NewNetworkError("failed to check for new releases", err)
+				}
+				info.Status = status
+			}
+
+			if format == "json" {
+				out, err := json.MarshalIndent(info, "", "  ")
+				if err != nil {
+					return err
+				}
+				fmt.Println(string(out))
+				return nil
+			}
+
+			fmt.Printf("kusari %s (commit: %s, built at: %s, crypto: %s)\n", info.Version, info.Commit, info.BuildDate, info.CryptoMode)
+			if info.Status == nil {
+				return nil
+			}
+			printRelease("Latest stable", info.LatestStable)
+			printRelease("Latest pre-release", info.LatestPrerelease)
+			switch {
+			case info.UpdateAvailable:
+				fmt.Printf("\nUpdate available on the %s channel: %s\n", channel, info.Latest.URL)
+			case info.Latest != nil:
+				fmt.Printf("\nUp to date on the %s channel.\n", channel)
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().BoolVar(&check, "check", false, "Compare against the latest releases on GitHub")
+	cmd.Flags().StringVar(&channel, "channel", versioncheck.ChannelStable, "Release channel to check: stable or beta")
+	cmd.Flags().StringVar(&format, "format", "text", "Output format: text or json")
+	return cmd
+}
+
+func printRelease(label string, r *versioncheck.Release) {
+	if r == nil {
+		fmt.Printf("%s: none\n", label)
+		return
+	}
+	fmt.Printf("%s: %s (%s)\n", label, r.Version, r.PublishedAt.Format("2006-01-02"))
+}
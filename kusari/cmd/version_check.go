@@ -0,0 +1,50 @@
+// Copyright (c) Kusari <https://www.kusari.dev/>
+// SPDX-License-Identifier: MIT
+
+package cmd
+
+import (
+	"github.com/kusaridev/kusari-cli/v2/pkg/clierrors"
+	"github.com/kusaridev/kusari-cli/v2/pkg/versioncheck"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// withVersionCheck makes every runnable command under cmd check, before it
+// runs, that the platform still supports this CLI version. It is applied
+// to the command groups that talk to the Kusari platform.
+func withVersionCheck(cmd *cobra.Command) *cobra.Command {
+	for _, c := range cmd.Commands() {
+		withVersionCheck(c)
+	}
+	if !cmd.Runnable() {
+		return cmd
+	}
+
+	preRunE, preRun := cmd.PreRunE, cmd.PreRun
+	cmd.PreRunE = func(cmd *cobra.Command, args []string) error {
+		if err := checkVersion(cmd); err != nil {
+			return err
+		}
+		if preRunE != nil {
+			return preRunE(cmd, args)
+		}
+		if preRun != nil {
+			preRun(cmd, args)
+		}
+		return nil
+	}
+	return cmd
+}
+
+func checkVersion(cmd *cobra.Command) error {
+	mode := viper.GetString("version-check")
+	if !versioncheck.ValidMode(mode) {
+		return clierrors.NewValidationError("invalid --version-check %q: must be warn, enforce or off", mode)
+	}
+	err := versioncheck.Check(cmd.Context(), viper.GetString("platform-url"), getVersion(), mode)
+	if err != nil {
+		cmd.SilenceUsage = true
+	}
+	return err
+}
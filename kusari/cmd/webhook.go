@@ -0,0 +1,172 @@
+// Copyright (c) Kusari <https://www.kusari.dev/>
+// SPDX-License-Identifier: MIT
+
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/kusaridev/kusari-cli/v2/pkg/clierrors"
+	"github.com/kusaridev/kusari-cli/v2/pkg/webhook"
+	"github.com/spf13/cobra"
+)
+
+// webhookSecretEnv holds the webhook secret when --secret isn't given.
+const webhookSecretEnv = "KUSARI_WEBHOOK_SECRET"
+
+func Webhook() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "webhook",
+		Short: "Debug platform webhook deliveries",
+		Long: `Verify and inspect the analysis events the Kusari platform delivers to
+webhooks. Deliveries are signed with the HMAC-SHA256 of the body under the
+webhook's secret, sent as ` + webhook.SignatureHeader + `: sha256=<hex>.`,
+	}
+
+	cmd.AddCommand(webhookVerify())
+	cmd.AddCommand(webhookListen())
+
+	return cmd
+}
+
+// webhookSecret returns --secret, or the secret in the environment.
+func webhookSecret(secret string) ([]byte, error) {
+	if secret == "" {
+		secret = os.Getenv(webhookSecretEnv)
+	}
+	if secret == "" {
+		return nil, clierrors.NewValidationError("missing webhook secret: pass --secret or set %s", webhookSecretEnv)
+	}
+	return []byte(secret), nil
+}
+
+func webhookVerify() *cobra.Command {
+	var (
+		secret      string
+		signature   string
+		payloadPath string
+	)
+
+	cmd := &cobra.Command{
+		Use:   "verify",
+		Short: "Verify the signature of a saved webhook delivery",
+		Long: `Check a delivery's signature against its body and print the event it
+carries. Exits with a validation error when the signature doesn't match.
+
+Examples:
+  kusari webhook verify --payload delivery.json --signature sha256=5d41...
+  pbpaste | KUSARI_WEBHOOK_SECRET=s3cret kusari webhook verify --signature "$SIG"`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cmd.SilenceUsage = true
+
+			key, err := webhookSecret(secret)
+			if err != nil {
+				return err
+			}
+			body, err := readFileOrStdin(payloadPath)
+			if err != nil {
+				return clierrors.NewValidationError("failed to read payload: %v", err)
+			}
+			if err := webhook.Verify(key, body, signature); err != nil {
+				return clierrors.NewValidationError("%v", err)
+			}
+			fmt.Println("✓ Signature valid")
+			e, err := webhook.ParseEvent(body)
+			if err != nil {
+				return clierrors.NewValidationError("%v", err)
+			}
+			fmt.Println(e.Summary())
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&secret, "secret", "", "Webhook secret (default: $"+webhookSecretEnv+")")
+	cmd.Flags().StringVar(&signature, "signature", "", "Value of the delivery's "+webhook.SignatureHeader+" header")
+	cmd.Flags().StringVar(&payloadPath, "payload", "-", "File holding the delivery's body, or - for stdin")
+	_ = cmd.MarkFlagRequired("signature")
+
+	return cmd
+}
+
+func webhookListen() *cobra.Command {
+	var (
+		secret string
+		host   string
+		port   int
+		path   string
+		raw    bool
+	)
+
+	cmd := &cobra.Command{
+		Use:   "listen",
+		Short: "Receive webhook deliveries and print them",
+		Long: `Serve an endpoint that verifies each delivery POSTed to it and prints
+the event, to debug a webhook before wiring it into your own systems.
+Deliveries with a bad signature are rejected with 401, so the platform
+reports them as failed. Expose the port with a tunnel (e.g. ngrok) to
+receive deliveries from the platform. Stops on Ctrl-C.
+
+Examples:
+  kusari webhook listen --port 8080 --secret s3cret
+  kusari webhook listen --host 0.0.0.0 --port 9000 --path /kusari --raw`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cmd.SilenceUsage = true
+
+			key, err := webhookSecret(secret)
+			if err != nil {
+				return err
+			}
+			if port < 0 || port > 65535 {
+				return clierrors.NewValidationError("invalid --port %d", port)
+			}
+			// ServeMux would read anything else as a method, host or
+			// wildcard pattern, and panic on it.
+			if !strings.HasPrefix(path, "/") || strings.ContainsAny(path, "{} \t\r\n") {
+				return clierrors.NewValidationError("invalid --path %q (must start with / and not contain braces or spaces)", path)
+			}
+
+			l, err := net.Listen("tcp", net.JoinHostPort(host, strconv.Itoa(port)))
+			if err != nil {
+				return fmt.Errorf("failed to listen: %w", err)
+			}
+			mux := http.NewServeMux()
+			mux.Handle(path, webhook.Handler(webhook.HandlerOptions{Secret: key, Out: os.Stdout, Raw: raw}))
+			srv := &http.Server{Handler: mux, ReadHeaderTimeout: 10 * time.Second}
+
+			ctx, stop := signal.NotifyContext(cmd.Context(), os.Interrupt, syscall.SIGTERM)
+			defer stop()
+			go func() {
+				<-ctx.Done()
+				shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+				defer cancel()
+				_ = srv.Shutdown(shutdownCtx)
+			}()
+
+			fmt.Fprintf(os.Stderr, "Listening for webhook deliveries on http://%s%s\n", l.Addr(), path)
+			if err := srv.Serve(l); !errors.Is(err, http.ErrServerClosed) {
+				return fmt.Errorf("webhook listener failed: %w", err)
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&secret, "secret", "", "Webhook secret (default: $"+webhookSecretEnv+")")
+	cmd.Flags().StringVar(&host, "host", "127.0.0.1", "Address to listen on")
+	cmd.Flags().IntVar(&port, "port", 8080, "Port to listen on (0 for any free port)")
+	cmd.Flags().StringVar(&path, "path", "/", "URL path to receive deliveries on")
+	cmd.Flags().BoolVar(&raw, "raw", false, "Also print each delivery's JSON body")
+
+	return cmd
+}
@@ -0,0 +1,21 @@
+// Copyright (c) Kusari <https://www.kusari.dev/>
+// SPDX-License-Identifier: MIT
+
+package cmd
+
+import (
+	"testing"
+
+	"github.com/kusaridev/kusari-cli/v2/pkg/clierrors"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWebhookListenRejectsBadPath(t *testing.T) {
+	for _, path := range []string{"kusari", "/{bad", "/a b", ""} {
+		cmd := webhookListen()
+		cmd.SetArgs([]string{"--secret", "x", "--port", "0", "--path", path})
+		err := cmd.Execute()
+		var verr *clierrors.ValidationError
+		assert.ErrorAs(t, err, &verr, path)
+	}
+}
@@ -0,0 +1,187 @@
+// Copyright (c) Kusari <https://www.kusari.dev/>
+// SPDX-License-Identifier: MIT
+
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/kusaridev/kusari-cli/v2/pkg/auth"
+	"github.com/kusaridev/kusari-cli/v2/pkg/clierrors"
+	l "github.com/kusaridev/kusari-cli/v2/pkg/login"
+	"github.com/kusaridev/kusari-cli/v2/pkg/output"
+	"github.com/spf13/cobra"
+)
+
+func Workspace() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "workspace",
+		Short: "Workspace operations",
+		Long: fmt.Sprintf(`Manage the locally cached list of workspaces and tenants.
+
+Commands that need a workspace and have none selected reuse the cached list
+for %s (override with %s; 0 disables the cache).`, l.DefaultWorkspaceCacheTTL, l.WorkspaceCacheTTLEnv),
+	}
+
+	cmd.AddCommand(workspaceRefresh())
+	cmd.AddCommand(workspaceUsage())
+
+	return cmd
+}
+
+func workspaceRefresh() *cobra.Command {
+	return &cobra.Command{
+		Use:   "refresh",
+		Short: "Re-fetch workspaces and tenants from the platform",
+		Long:  "Discard the cached workspace list and fetch a fresh one from the Kusari platform.",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cmd.SilenceUsage = true
+
+			token, err := auth.DefaultTokenProvider().Token(cmd.Context())
+			if err != nil {
+				return fmt.Errorf("failed to load auth token: %w (try running 'kusari auth login')", err)
+			}
+
+			if err := l.ClearWorkspaceCache(); err != nil {
+				return fmt.Errorf("failed to clear workspace cache: %w", err)
+			}
+
+			workspaces, workspaceTenants, err := l.FetchWorkspaces(platformUrl, token.AccessToken)
+			if err != nil {
+				return fmt.Errorf("failed to fetch workspaces: %w", err)
+			}
+
+			fmt.Println("Workspaces:")
+			for _, ws := range workspaces {
+				if tenants := workspaceTenants[ws.ID]; len(tenants) > 0 {
+					fmt.Printf("  %s (tenants: %s)\n", ws.Description, strings.Join(tenants, ", "))
+				} else {
+					fmt.Printf("  %s\n", ws.Description)
+				}
+			}
+			return nil
+		},
+	}
+}
+
+// quotaWarnFraction is the share of a quota used from which usage warns.
+const quotaWarnFraction = 0.9
+
+func workspaceUsage() *cobra.Command {
+	var format string
+
+	cmd := &cobra.Command{
+		Use:   "usage",
+		Short: "Show the usage and remaining quota of the active workspace",
+		Long: `Show the scans run, SBOMs ingested and storage used by the active
+workspace (KUSARI_WORKSPACE, else the one selected with 'kusari auth
+select-workspace') in the current billing period, against its quotas.
+Quotas at least 90% used are flagged, as scans and uploads fail once one
+runs out. --format json is meant for chargeback tooling.
+
+Examples:
+  kusari workspace usage
+  KUSARI_WORKSPACE=platform-team kusari workspace usage --format json`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cmd.SilenceUsage = true
+
+			if format != "table" && format != "json" {
+				return clierrors.NewValidationError("invalid --format %q (must be 'table' or 'json')", format)
+			}
+
+			token, err := auth.DefaultTokenProvider().Token(cmd.Context())
+			if err != nil {
+				return fmt.Errorf("failed to load auth token: %w (try running 'kusari auth login')", err)
+			}
+			workspace, err := activeWorkspace(token.AccessToken)
+			if err != nil {
+				return err
+			}
+
+			usage, err := l.FetchWorkspaceUsage(platformUrl, token.AccessToken, workspace.ID)
+			if err != nil {
+				return err
+			}
+			if usage.Workspace == "" {
+				usage.Workspace = workspace.Description
+			}
+
+			if format == "json" {
+				out, err := json.MarshalIndent(usage, "", "  ")
+				if err != nil {
+					return err
+				}
+				fmt.Println(string(out))
+				return nil
+			}
+			printWorkspaceUsage(usage)
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&format, "format", "table", "Output format: table or json")
+	return cmd
+}
+
+// activeWorkspace returns the workspace commands act on: the one named by
+// KUSARI_WORKSPACE, else the stored one, else the first.
+func activeWorkspace(accessToken string) (l.Workspace, error) {
+	if os.Getenv(auth.WorkspaceEnv) == "" {
+		if stored, err := auth.LoadWorkspace(platformUrl, ""); err == nil {
+			return l.Workspace{ID: stored.ID, Description: stored.Description}, nil
+		}
+	}
+	workspaces, _, err := l.FetchWorkspacesCached(platformUrl, accessToken)
+	if err != nil {
+		return l.Workspace{}, fmt.Errorf("failed to get workspaces: %w", err)
+	}
+	return l.DefaultWorkspace(workspaces)
+}
+
+func printWorkspaceUsage(usage *l.WorkspaceUsage) {
+	fmt.Printf("Workspace: %s\n", usage.Workspace)
+	if !usage.PeriodStart.IsZero() {
+		fmt.Printf("Period:    %s to %s\n", usage.PeriodStart.Format("2006-01-02"), usage.PeriodEnd.Format("2006-01-02"))
+	}
+	fmt.Println()
+
+	count := func(n int64) string { return strconv.FormatInt(n, 10) }
+	rows := []struct {
+		name   string
+		quota  l.Quota
+		format func(int64) string
+	}{
+		{"Scans", usage.Scans, count},
+		{"SBOMs ingested", usage.SBOMs, count},
+		{"Storage", usage.StorageBytes, output.FormatSize},
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	_, _ = fmt.Fprintln(w, "RESOURCE\tUSED\tLIMIT\tREMAINING\t")
+	var near []string
+	for _, r := range rows {
+		limit, remaining := "unlimited", "-"
+		if left, ok := r.quota.Remaining(); ok {
+			limit = r.format(*r.quota.Limit)
+			remaining = r.format(left)
+		}
+		flag := ""
+		if r.quota.NearLimit(quotaWarnFraction) {
+			flag = "⚠"
+			near = append(near, strings.ToLower(r.name))
+		}
+		_, _ = fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", r.name, r.format(r.quota.Used), limit, remaining, flag)
+	}
+	_ = w.Flush()
+
+	if len(near) > 0 {
+		fmt.Fprintf(os.Stderr, "\n⚠ Nearly out of %s quota; scans and uploads fail once it runs out.\n", strings.Join(near, ", "))
+	}
+}
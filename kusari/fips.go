@@ -0,0 +1,9 @@
+// Copyright (c) Kusari <https://www.kusari.dev/>
+// SPDX-License-Identifier: MIT
+
+//go:build fips
+
+// Turn on Go's FIPS 140-3 module in -tags fips builds.
+//go:debug fips140=on
+
+package main
@@ -7,6 +7,7 @@ import (
 	"os"
 
 	"github.com/kusaridev/kusari-cli/v2/kusari/cmd"
+	"github.com/kusaridev/kusari-cli/v2/pkg/clierrors"
 )
 
 var (
@@ -18,6 +19,6 @@ var (
 func main() {
 	cmd.SetVersionInfo(version, commit, date)
 	if err := cmd.Execute(); err != nil {
-		os.Exit(1)
+		os.Exit(clierrors.ExitCode(err))
 	}
 }
@@ -35,19 +35,19 @@ func isAuthError(err error) bool {
 // Returns an error if authentication is needed (token missing or expired).
 // The caller should handle the error by calling the authenticate tool.
 func (s *Server) ensureAuthenticated() error {
-	// Try to load existing token
-	token, err := auth.LoadToken("kusari")
-	if err != nil {
-		return fmt.Errorf("authentication required: no stored token found. Please call the 'authenticate' tool to log in")
-	}
-
-	// Token exists, check if expired
-	if err := auth.CheckTokenExpiry(token); err != nil {
+	// An API key or client credentials in the environment bypass the stored login
+	provider := auth.DefaultTokenProvider()
+	_, err := provider.Token(context.Background())
+	switch {
+	case err == nil:
+		return nil
+	case provider.Machine():
+		return fmt.Errorf("authentication failed using %s: %w", provider.Name(), err)
+	case auth.IsTokenExpired(err):
 		return fmt.Errorf("authentication required: token is expired. Please call the 'authenticate' tool to refresh your session")
+	default:
+		return fmt.Errorf("authentication required: no stored token found. Please call the 'authenticate' tool to log in")
 	}
-
-	// Token is valid
-	return nil
 }
 
 // triggerBrowserAuth opens a browser for OAuth and auto-selects the first workspace.
@@ -131,18 +131,22 @@ type AuthenticateResult struct {
 // This opens a browser for OAuth authentication and auto-selects the first workspace.
 func (s *Server) handleAuthenticate(ctx context.Context) (*AuthenticateResult, error) {
 	// Check if already authenticated
-	token, err := auth.LoadToken("kusari")
-	if err == nil {
-		if err := auth.CheckTokenExpiry(token); err == nil {
-			// Already have a valid token
-			workspace, _ := auth.LoadWorkspace(s.config.PlatformURL, "")
-			return &AuthenticateResult{
-				Success:   true,
-				Message:   "Already authenticated with a valid token",
-				Workspace: workspace.Description,
-				Tenant:    workspace.Tenant,
-			}, nil
-		}
+	provider := auth.DefaultTokenProvider()
+	if _, err := provider.Token(ctx); err == nil {
+		// Already have a valid token
+		workspace, _ := auth.LoadWorkspace(s.config.PlatformURL, "")
+		return &AuthenticateResult{
+			Success:   true,
+			Message:   "Already authenticated with a valid token",
+			Workspace: workspace.Description,
+			Tenant:    workspace.Tenant,
+		}, nil
+	} else if provider.Machine() {
+		// A browser login wouldn't be used over these credentials
+		return &AuthenticateResult{
+			Success: false,
+			Error:   fmt.Sprintf("authentication failed using %s: %v", provider.Name(), err),
+		}, nil
 	}
 
 	// Perform authentication
@@ -17,6 +17,9 @@ type Config struct {
 	ConsoleURL  string `yaml:"console_url"`
 	PlatformURL string `yaml:"platform_url"`
 	Verbose     bool   `yaml:"verbose"`
+	// BundleEncryption is the bundle encryption mode of scans, as for
+	// --bundle-encryption.
+	BundleEncryption string `yaml:"bundle_encryption"`
 }
 
 // NewConfig returns a Config with default values.
@@ -41,6 +44,9 @@ func LoadConfig() (*Config, error) {
 	if val := os.Getenv("KUSARI_VERBOSE"); val != "" {
 		cfg.Verbose = parseBool(val)
 	}
+	if val := os.Getenv("KUSARI_BUNDLE_ENCRYPTION"); val != "" {
+		cfg.BundleEncryption = val
+	}
 
 	return cfg, nil
 }
@@ -83,6 +89,9 @@ func LoadConfigWithFile(path string) (*Config, error) {
 	if val := os.Getenv("KUSARI_VERBOSE"); val != "" {
 		cfg.Verbose = parseBool(val)
 	}
+	if val := os.Getenv("KUSARI_BUNDLE_ENCRYPTION"); val != "" {
+		cfg.BundleEncryption = val
+	}
 
 	return cfg, nil
 }
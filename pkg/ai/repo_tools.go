@@ -0,0 +1,194 @@
+// Copyright (c) Kusari <https://www.kusari.dev/>
+// SPDX-License-Identifier: MIT
+
+package ai
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/kusaridev/kusari-cli/v2/pkg/auth"
+	"github.com/kusaridev/kusari-cli/v2/pkg/repo"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// RiskCheckRepoArgs defines the input for risk_check_repo tool.
+type RiskCheckRepoArgs struct {
+	RepoPath string `json:"repo_path,omitempty" mcp:"Path to the git repository to risk-check. Defaults to current directory."`
+}
+
+// GetScanResultsArgs defines the input for get_scan_results tool.
+type GetScanResultsArgs struct {
+	RepoPath string `json:"repo_path,omitempty" mcp:"Path to the git repository. Defaults to current directory."`
+}
+
+// UploadSBOMArgs defines the input for upload_sbom tool.
+type UploadSBOMArgs struct {
+	FilePath string `json:"file_path" mcp:"Path to the SBOM file, or a directory of SBOMs, to upload."`
+	Tenant   string `json:"tenant,omitempty" mcp:"Tenant name. Defaults to the tenant of the active workspace."`
+	Alias    string `json:"alias,omitempty" mcp:"Alias for the uploaded document."`
+	Wait     bool   `json:"wait,omitempty" mcp:"Wait for ingestion to complete before returning."`
+}
+
+// executeRiskCheck runs a full repository risk check using internal packages.
+func (s *Server) executeRiskCheck(args RiskCheckRepoArgs) (*ScanToolResult, error) {
+	repoPath := s.normalizeRepoPath(args.RepoPath)
+
+	if err := validateDirectory(repoPath); err != nil {
+		return nil, fmt.Errorf("invalid repository path: %w", err)
+	}
+
+	if err := validateGitRepo(repoPath); err != nil {
+		return nil, err
+	}
+
+	if err := s.ensureAuthenticated(); err != nil {
+		return nil, fmt.Errorf("authentication required: %w", err)
+	}
+
+	if s.config.Verbose {
+		fmt.Fprintf(os.Stderr, "[kusari-ai] Risk-checking %s\n", repoPath)
+	}
+
+	stdout, stderr, err := captureOutput(func() error {
+		return repo.RiskCheck(repo.ScanOptions{
+			Dir:              repoPath,
+			PlatformURL:      s.config.PlatformURL,
+			ConsoleURL:       s.config.ConsoleURL,
+			Verbose:          s.config.Verbose,
+			Wait:             true,
+			BundleEncryption: repo.BundleEncryption(s.config.BundleEncryption),
+		})
+	})
+	if err != nil {
+		return nil, fmt.Errorf("risk check failed: %w", err)
+	}
+
+	consoleURL := extractConsoleURL(stderr)
+	results := stdout
+	if results == "" {
+		results = "Risk check completed successfully."
+	}
+
+	return &ScanToolResult{
+		Success:    true,
+		ConsoleURL: consoleURL,
+		Results:    formatResultWithConsoleURL(results, consoleURL),
+	}, nil
+}
+
+// executeGetScanResults returns the last scan_local_changes (or `kusari repo
+// scan`) results cached for a repository, without starting a new scan.
+func (s *Server) executeGetScanResults(args GetScanResultsArgs) (string, error) {
+	repoPath := s.normalizeRepoPath(args.RepoPath)
+
+	entry, current, err := repo.LastScanResult(repoPath)
+	if err != nil {
+		return "", fmt.Errorf("failed to read scan cache: %w", err)
+	}
+	if entry == nil {
+		return "", fmt.Errorf("no recent scan results for %s. Call scan_local_changes first", repoPath)
+	}
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "Scanned against %s at %s.\n", entry.BaseRef, entry.Timestamp.Format("2006-01-02 15:04:05 MST"))
+	if !current {
+		sb.WriteString("The working tree has changed since this scan; call scan_local_changes for up-to-date results.\n")
+	}
+	sb.WriteString("\n---\n\n")
+	sb.WriteString(entry.Results)
+
+	return formatResultWithConsoleURL(sb.String(), entry.ConsoleURL), nil
+}
+
+// executeUploadSBOM uploads an SBOM to the tenant's platform.
+func (s *Server) executeUploadSBOM(ctx context.Context, args UploadSBOMArgs) (string, error) {
+	if args.FilePath == "" {
+		return "", fmt.Errorf("file_path is required")
+	}
+	filePath := s.normalizeRepoPath(args.FilePath)
+	if _, err := os.Stat(filePath); err != nil {
+		return "", fmt.Errorf("cannot access %s: %w", filePath, err)
+	}
+
+	if err := s.ensureAuthenticated(); err != nil {
+		return "", fmt.Errorf("authentication required: %w", err)
+	}
+
+	endpoint, err := s.tenantEndpoint(args.Tenant)
+	if err != nil {
+		return "", err
+	}
+
+	stdout, stderr, err := captureOutput(func() error {
+		return repo.Upload(ctx, repo.UploadOptions{
+			FilePath:       filePath,
+			TenantEndpoint: endpoint,
+			PlatformURL:    s.config.PlatformURL,
+			Alias:          args.Alias,
+			Wait:           args.Wait,
+		})
+	})
+	if err != nil {
+		return "", fmt.Errorf("upload failed: %w", err)
+	}
+
+	return strings.TrimSpace(stdout + "\n" + stderr), nil
+}
+
+// tenantEndpoint returns the API endpoint of tenant, in the region of the
+// platform URL as for 'kusari platform --tenant', or of the active
+// workspace's tenant, in its region, when tenant is empty.
+func (s *Server) tenantEndpoint(tenant string) (string, error) {
+	if tenant != "" {
+		return auth.TenantEndpoint(tenant, auth.RegionFromPlatformURL(s.config.PlatformURL)), nil
+	}
+	workspace, err := auth.LoadWorkspace(s.config.PlatformURL, "")
+	if err != nil || workspace.Tenant == "" {
+		return "", fmt.Errorf("no tenant given and the active workspace has none. Pass tenant or run 'kusari auth select-tenant'")
+	}
+	return workspace.TenantEndpoint(), nil
+}
+
+func (s *Server) handleRiskCheckRepo(ctx context.Context, req *mcp.CallToolRequest, args RiskCheckRepoArgs) (*mcp.CallToolResult, any, error) {
+	result, err := s.executeRiskCheck(args)
+	if err != nil {
+		return toolError(err), nil, nil
+	}
+	return toolText(result.Results), nil, nil
+}
+
+func (s *Server) handleGetScanResults(ctx context.Context, req *mcp.CallToolRequest, args GetScanResultsArgs) (*mcp.CallToolResult, any, error) {
+	results, err := s.executeGetScanResults(args)
+	if err != nil {
+		return toolError(err), nil, nil
+	}
+	return toolText(results), nil, nil
+}
+
+func (s *Server) handleUploadSBOM(ctx context.Context, req *mcp.CallToolRequest, args UploadSBOMArgs) (*mcp.CallToolResult, any, error) {
+	results, err := s.executeUploadSBOM(ctx, args)
+	if err != nil {
+		return toolError(err), nil, nil
+	}
+	return toolText(results), nil, nil
+}
+
+func toolText(text string) *mcp.CallToolResult {
+	return &mcp.CallToolResult{
+		Content: []mcp.Content{
+			&mcp.TextContent{Text: text},
+		},
+	}
+}
+
+func toolError(err error) *mcp.CallToolResult {
+	return &mcp.CallToolResult{
+		Content: []mcp.Content{
+			&mcp.TextContent{Text: fmt.Sprintf("Error: %v", err)},
+		},
+		IsError: true,
+	}
+}
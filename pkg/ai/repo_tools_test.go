@@ -0,0 +1,74 @@
+// Copyright (c) Kusari <https://www.kusari.dev/>
+// SPDX-License-Identifier: MIT
+
+package ai
+
+import (
+	"context"
+	"testing"
+
+	"github.com/kusaridev/kusari-cli/v2/pkg/auth"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestServer_RegistersRepoTools(t *testing.T) {
+	server, err := NewServer(NewConfig())
+	require.NoError(t, err)
+
+	var names []string
+	for _, tool := range server.GetRegisteredTools() {
+		names = append(names, tool.Name)
+	}
+	assert.Contains(t, names, "risk_check_repo")
+	assert.Contains(t, names, "get_scan_results")
+	assert.Contains(t, names, "upload_sbom")
+}
+
+func TestRiskCheck_ValidatesGitRepo(t *testing.T) {
+	server, err := NewServer(NewConfig())
+	require.NoError(t, err)
+
+	_, err = server.executeRiskCheck(RiskCheckRepoArgs{RepoPath: t.TempDir()})
+	assert.ErrorContains(t, err, ".git")
+}
+
+func TestGetScanResults_NoResults(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	server, err := NewServer(NewConfig())
+	require.NoError(t, err)
+
+	_, err = server.executeGetScanResults(GetScanResultsArgs{RepoPath: t.TempDir()})
+	assert.ErrorContains(t, err, "scan_local_changes")
+}
+
+func TestUploadSBOM_ValidatesFilePath(t *testing.T) {
+	server, err := NewServer(NewConfig())
+	require.NoError(t, err)
+
+	_, err = server.executeUploadSBOM(context.Background(), UploadSBOMArgs{})
+	assert.ErrorContains(t, err, "file_path is required")
+
+	_, err = server.executeUploadSBOM(context.Background(), UploadSBOMArgs{FilePath: "/nonexistent/sbom.json"})
+	assert.ErrorContains(t, err, "cannot access")
+}
+
+func TestTenantEndpoint(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	cfg := NewConfig()
+	cfg.PlatformURL = "https://platform.api.eu.kusari.cloud/"
+	server, err := NewServer(cfg)
+	require.NoError(t, err)
+
+	endpoint, err := server.tenantEndpoint("demo")
+	require.NoError(t, err)
+	assert.Equal(t, "https://demo.api.eu.kusari.cloud", endpoint)
+
+	_, err = server.tenantEndpoint("")
+	assert.ErrorContains(t, err, "no tenant given")
+
+	require.NoError(t, auth.SaveWorkspace(auth.WorkspaceInfo{ID: "ws-1", PlatformUrl: cfg.PlatformURL, Tenant: "acme", Region: "ap"}))
+	endpoint, err = server.tenantEndpoint("")
+	require.NoError(t, err)
+	assert.Equal(t, "https://acme.api.ap.kusari.cloud", endpoint)
+}
@@ -55,7 +55,7 @@ func NewServer(cfg *Config) (*Server, error) {
 	// Initialize Pico client - load tenant from workspace
 	workspace, err := auth.LoadWorkspace(cfg.PlatformURL, "")
 	if err == nil && workspace.Tenant != "" {
-		s.picoClient = pico.NewClient(fmt.Sprintf("https://%s.api.us.kusari.cloud", workspace.Tenant))
+		s.picoClient = pico.NewClient(workspace.TenantEndpoint())
 	}
 	// Note: picoClient may be nil if not authenticated yet, handlers will check
 
@@ -176,6 +176,61 @@ func (s *Server) registerTools() {
 				"required": []string{},
 			},
 		},
+		{
+			Name:        "risk_check_repo",
+			Description: "Run a full risk check of a git repository (not just local changes) with Kusari Inspector. Takes longer than scan_local_changes. Returns the repository's health summary and findings.",
+			InputSchema: map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"repo_path": map[string]interface{}{
+						"type":        "string",
+						"description": "Path to the git repository to risk-check. Defaults to current directory.",
+					},
+				},
+				"required": []string{},
+			},
+		},
+		{
+			Name:        "get_scan_results",
+			Description: "Get the results of the most recent scan_local_changes run for a repository without scanning again. Says whether the working tree has changed since.",
+			InputSchema: map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"repo_path": map[string]interface{}{
+						"type":        "string",
+						"description": "Path to the git repository. Defaults to current directory.",
+					},
+				},
+				"required": []string{},
+			},
+		},
+		{
+			Name:        "upload_sbom",
+			Description: "Upload an SBOM file (or a directory of SBOMs) to the Kusari platform for the current tenant.",
+			InputSchema: map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"file_path": map[string]interface{}{
+						"type":        "string",
+						"description": "Path to the SBOM file, or a directory of SBOMs, to upload.",
+					},
+					"tenant": map[string]interface{}{
+						"type":        "string",
+						"description": "Tenant name. Defaults to the tenant of the active workspace.",
+					},
+					"alias": map[string]interface{}{
+						"type":        "string",
+						"description": "Alias for the uploaded document.",
+					},
+					"wait": map[string]interface{}{
+						"type":        "boolean",
+						"description": "Wait for ingestion to complete before returning.",
+						"default":     false,
+					},
+				},
+				"required": []string{"file_path"},
+			},
+		},
 		{
 			Name:        "get_software_ids_by_repo",
 			Description: "STEP 1: Find software IDs for the current repository. Use this FIRST when the user asks about vulnerabilities affecting them/their code. Automatically traverses parent directories in monorepos to find registered software. Returns software IDs needed for get_software_vulnerabilities. IMPORTANT: If multiple software components are found, you MUST use AskUserQuestion to let the user select which software they want to query. Always include an 'All software components' option to query all of them.",
@@ -386,6 +441,21 @@ func (s *Server) registerTools() {
 		Description: "Scan uncommitted changes in the current git repository for security vulnerabilities, secrets, and SAST issues. This performs a diff-based scan of your local changes using AWS Lambda.",
 	}, s.handleScanLocalChanges)
 
+	mcp.AddTool(s.mcpServer, &mcp.Tool{
+		Name:        "risk_check_repo",
+		Description: "Run a full risk check of a git repository (not just local changes) with Kusari Inspector. Takes longer than scan_local_changes.",
+	}, s.handleRiskCheckRepo)
+
+	mcp.AddTool(s.mcpServer, &mcp.Tool{
+		Name:        "get_scan_results",
+		Description: "Get the results of the most recent scan_local_changes run for a repository without scanning again.",
+	}, s.handleGetScanResults)
+
+	mcp.AddTool(s.mcpServer, &mcp.Tool{
+		Name:        "upload_sbom",
+		Description: "Upload an SBOM file (or a directory of SBOMs) to the Kusari platform for the current tenant.",
+	}, s.handleUploadSBOM)
+
 	// Register Pico API tools - Priority tools (vulnerability workflow)
 	mcp.AddTool(s.mcpServer, &mcp.Tool{
 		Name:        "get_software_ids_by_repo",
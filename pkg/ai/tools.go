@@ -55,18 +55,18 @@ func (s *Server) executeScanLocalChanges(ctx context.Context, args ScanLocalChan
 
 	// Capture stdout/stderr from the scan function
 	stdout, stderr, err := captureOutput(func() error {
-		return repo.Scan(
-			repoPath,
-			baseRef,
-			s.config.PlatformURL,
-			s.config.ConsoleURL,
-			s.config.Verbose,
-			true, // wait for results
-			outputFormat,
-			"",   // no comment platform for MCP
-			true, // full output to get complete results in MCP response
-			args.OverrideBranch,
-		)
+		return repo.Scan(repo.ScanOptions{
+			Dir:              repoPath,
+			Rev:              baseRef,
+			PlatformURL:      s.config.PlatformURL,
+			ConsoleURL:       s.config.ConsoleURL,
+			Verbose:          s.config.Verbose,
+			Wait:             true, // wait for results
+			OutputFormat:     outputFormat,
+			FullOutput:       true, // full output to get complete results in MCP response
+			OverrideBranch:   args.OverrideBranch,
+			BundleEncryption: repo.BundleEncryption(s.config.BundleEncryption),
+		})
 	})
 
 	if err != nil {
@@ -159,18 +159,15 @@ func validateDirectory(path string) error {
 	return nil
 }
 
-// validateGitRepo checks if a directory contains a .git folder.
+// validateGitRepo checks if a directory contains a .git folder, or the
+// .git file of a worktree.
 func validateGitRepo(path string) error {
 	gitPath := filepath.Join(path, ".git")
-	info, err := os.Stat(gitPath)
-	if err != nil {
+	if _, err := os.Stat(gitPath); err != nil {
 		if os.IsNotExist(err) {
-			return fmt.Errorf("not a git repository (no .git directory found in %s)", path)
+			return fmt.Errorf("not a git repository (no .git found in %s)", path)
 		}
-		return fmt.Errorf("cannot access .git directory: %w", err)
-	}
-	if !info.IsDir() {
-		return fmt.Errorf(".git is not a directory in %s", path)
+		return fmt.Errorf("cannot access .git: %w", err)
 	}
 	return nil
 }
@@ -275,7 +272,7 @@ func (s *Server) getPicoClient() (*pico.Client, error) {
 		fmt.Fprintf(os.Stderr, "[kusari-ai] Initializing Pico client with tenant: %s\n", workspace.Tenant)
 	}
 
-	s.picoClient = pico.NewClient(fmt.Sprintf("https://%s.api.us.kusari.cloud", workspace.Tenant))
+	s.picoClient = pico.NewClient(workspace.TenantEndpoint())
 	return s.picoClient, nil
 }
 
@@ -144,6 +144,11 @@ func TestValidateGitRepo(t *testing.T) {
 
 	err = validateGitRepo(tmpDir)
 	assert.NoError(t, err)
+
+	// Test worktree (.git file)
+	worktree := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(worktree, ".git"), []byte("gitdir: "+gitDir), 0644))
+	assert.NoError(t, validateGitRepo(worktree))
 }
 
 func TestValidateDirectory(t *testing.T) {
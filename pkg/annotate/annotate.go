@@ -0,0 +1,287 @@
+// Copyright (c) Kusari <https://www.kusari.dev/>
+// SPDX-License-Identifier: MIT
+
+// Package annotate prints a unified diff with the code findings of an
+// Inspector result interleaved at the lines they are about, for reviewing
+// a change locally in the terminal.
+package annotate
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"maps"
+	"os/exec"
+	"path"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/kusaridev/kusari-cli/v2/api"
+	"github.com/kusaridev/kusari-cli/v2/pkg/output"
+)
+
+// maxCodeLines caps the suggested fix shown under each finding.
+const maxCodeLines = 10
+
+// SGR codes used on top of output's.
+const (
+	red    = "\033[31m"
+	green  = "\033[32m"
+	yellow = "\033[33m"
+	cyan   = "\033[36m"
+	dim    = "\033[2m"
+)
+
+// Options control the rendering.
+type Options struct {
+	// Color styles the diff and annotations with ANSI escapes.
+	Color bool
+}
+
+// GitDiff returns the diff of the working tree of the repository at dir
+// against base.
+func GitDiff(dir, base string) ([]byte, error) {
+	if err := exec.Command("git", "-C", dir, "rev-parse", "--verify", "--quiet", "--end-of-options", base).Run(); err != nil {
+		return nil, fmt.Errorf("not a valid git rev: %w, %v", err, base)
+	}
+	out, err := exec.Command("git", "-C", dir, "diff", "--no-color", "--no-ext-diff", base, "--").Output()
+	if err != nil {
+		return nil, fmt.Errorf("failed to run git diff: %w", err)
+	}
+	return out, nil
+}
+
+// Render writes diff to w with the code findings of sa under the lines
+// they are about. Findings on lines the diff doesn't show follow their
+// file, findings in files it doesn't touch and dependency findings come
+// last.
+func Render(w io.Writer, diff []byte, sa *api.SecurityAnalysis, opts Options) error {
+	r := &renderer{w: bufio.NewWriter(w), opts: opts, findings: map[string]map[int][]api.CodeMitigationItem{}}
+	if sa != nil {
+		for _, m := range sa.RequiredCodeMitigations {
+			p := cleanPath(m.Path)
+			if r.findings[p] == nil {
+				r.findings[p] = map[int][]api.CodeMitigationItem{}
+			}
+			r.findings[p][m.LineNumber] = append(r.findings[p][m.LineNumber], m)
+		}
+	}
+
+	var oldLine, newLine int
+	inHunk := false
+	for line := range strings.Lines(string(diff)) {
+		line = strings.TrimSuffix(line, "\n")
+		switch {
+		case strings.HasPrefix(line, "diff --git "):
+			r.endFile()
+			inHunk = false
+			r.file, r.oldFile = "", ""
+		case !inHunk && strings.HasPrefix(line, "--- "):
+			r.oldFile = diffPath(line[4:])
+		case !inHunk && strings.HasPrefix(line, "+++ "):
+			r.file = diffPath(line[4:])
+			if r.file == "" {
+				// Deleted
+				r.file = r.oldFile
+			}
+			r.startFile()
+		case strings.HasPrefix(line, "@@ "):
+			var ok bool
+			oldLine, newLine, ok = parseHunk(line)
+			if !ok {
+				r.println(line)
+				continue
+			}
+			inHunk = true
+			r.println(r.paint(line, cyan))
+		case inHunk && strings.HasPrefix(line, "+"):
+			r.println(r.margin(0, newLine) + r.paint(line, green))
+			r.annotate(newLine)
+			newLine++
+		case inHunk && strings.HasPrefix(line, "-"):
+			r.println(r.margin(oldLine, 0) + r.paint(line, red))
+			oldLine++
+		case inHunk && strings.HasPrefix(line, " "):
+			r.println(r.margin(oldLine, newLine) + line)
+			r.annotate(newLine)
+			oldLine++
+			newLine++
+		case inHunk && strings.HasPrefix(line, `\`):
+			r.println(r.margin(0, 0) + r.paint(line, dim))
+		default:
+			// Extended headers (index, mode, rename) are left out; binary
+			// file notices are shown
+			if r.file != "" {
+				r.println(r.paint(line, dim))
+			}
+		}
+	}
+	r.endFile()
+	r.rest(sa)
+	return r.w.Flush()
+}
+
+type renderer struct {
+	w    *bufio.Writer
+	opts Options
+	// findings by file and line; removed as they are shown
+	findings map[string]map[int][]api.CodeMitigationItem
+
+	file, oldFile string
+}
+
+func (r *renderer) paint(s string, codes ...string) string {
+	if !r.opts.Color {
+		return s
+	}
+	return output.Style(s, codes...)
+}
+
+func (r *renderer) println(s string) {
+	_, _ = r.w.WriteString(s)
+	_ = r.w.WriteByte('\n')
+}
+
+// margin numbers a diff line with its old and new line numbers; 0 leaves
+// a column blank.
+func (r *renderer) margin(oldLine, newLine int) string {
+	num := func(n int) string {
+		if n == 0 {
+			return strings.Repeat(" ", 5)
+		}
+		return fmt.Sprintf("%5d", n)
+	}
+	return r.paint(num(oldLine)+" "+num(newLine)+" │", dim)
+}
+
+func (r *renderer) startFile() {
+	r.println("")
+	title := "── " + r.file + " "
+	r.println(r.paint(title+strings.Repeat("─", max(0, 60-len([]rune(title)))), output.Bold))
+}
+
+// annotate prints the findings about line of the current file.
+func (r *renderer) annotate(line int) {
+	byLine := r.findings[r.file]
+	for _, m := range byLine[line] {
+		r.finding(m, strings.Repeat(" ", 13))
+	}
+	delete(byLine, line)
+}
+
+// finding prints one finding, indented.
+func (r *renderer) finding(m api.CodeMitigationItem, indent string) {
+	lines := strings.Split(strings.TrimSpace(m.Content), "\n")
+	r.println(indent + r.paint("▲ Kusari: "+lines[0], yellow, output.Bold))
+	for _, l := range lines[1:] {
+		r.println(indent + r.paint("  "+l, yellow))
+	}
+	code := strings.TrimRight(m.Code, "\n")
+	if code == "" {
+		return
+	}
+	r.println(indent + r.paint("  Suggested fix:", dim))
+	codeLines := strings.Split(code, "\n")
+	shown := codeLines[:min(len(codeLines), maxCodeLines)]
+	for _, l := range shown {
+		r.println(indent + "    " + l)
+	}
+	if extra := len(codeLines) - len(shown); extra > 0 {
+		r.println(indent + r.paint(fmt.Sprintf("    ... (%d more lines)", extra), dim))
+	}
+}
+
+// endFile prints the findings about lines of the current file that the
+// diff didn't show.
+func (r *renderer) endFile() {
+	if r.file == "" {
+		return
+	}
+	byLine := r.findings[r.file]
+	delete(r.findings, r.file)
+	if len(byLine) == 0 {
+		return
+	}
+	r.println(r.paint("   Findings outside the diff of "+r.file+":", output.Bold))
+	r.lineFindings(byLine)
+}
+
+func (r *renderer) lineFindings(byLine map[int][]api.CodeMitigationItem) {
+	for _, line := range slices.Sorted(maps.Keys(byLine)) {
+		for _, m := range byLine[line] {
+			if line > 0 {
+				r.println(r.paint(fmt.Sprintf("   line %d:", line), dim))
+			}
+			r.finding(m, "   ")
+		}
+	}
+}
+
+// rest prints the findings in files the diff doesn't touch, and the
+// dependency findings.
+func (r *renderer) rest(sa *api.SecurityAnalysis) {
+	files := slices.Sorted(maps.Keys(r.findings))
+	for _, f := range files {
+		name := f
+		if name == "" || name == "." {
+			name = "(no file)"
+		}
+		r.println("")
+		r.println(r.paint("── "+name+" (not in the diff)", output.Bold))
+		r.lineFindings(r.findings[f])
+	}
+	if sa == nil || len(sa.RequiredDependencyMitigations) == 0 {
+		return
+	}
+	r.println("")
+	r.println(r.paint("── Dependency findings", output.Bold))
+	for _, m := range sa.RequiredDependencyMitigations {
+		r.println("   " + r.paint("▲ ", yellow, output.Bold) + strings.TrimSpace(m.Content))
+	}
+}
+
+// parseHunk returns the first old and new line numbers of a hunk header.
+func parseHunk(line string) (oldStart, newStart int, ok bool) {
+	fields := strings.Fields(line)
+	if len(fields) < 3 {
+		return 0, 0, false
+	}
+	parse := func(s, sign string) (int, bool) {
+		s, found := strings.CutPrefix(s, sign)
+		if !found {
+			return 0, false
+		}
+		s, _, _ = strings.Cut(s, ",")
+		n, err := strconv.Atoi(s)
+		return n, err == nil
+	}
+	oldStart, ok1 := parse(fields[1], "-")
+	newStart, ok2 := parse(fields[2], "+")
+	return oldStart, newStart, ok1 && ok2
+}
+
+// diffPath returns the path of a ---/+++ diff header, or "" for
+// /dev/null.
+func diffPath(s string) string {
+	s, _, _ = strings.Cut(s, "\t")
+	if s == "/dev/null" {
+		return ""
+	}
+	if unquoted, err := strconv.Unquote(s); err == nil {
+		s = unquoted
+	}
+	for _, prefix := range []string{"a/", "b/"} {
+		if rest, ok := strings.CutPrefix(s, prefix); ok {
+			return rest
+		}
+	}
+	return s
+}
+
+// cleanPath normalizes a finding's path the way diff paths are written.
+func cleanPath(p string) string {
+	p = strings.ReplaceAll(p, "\\", "/")
+	p = strings.TrimPrefix(path.Clean(p), "./")
+	return strings.TrimPrefix(p, "/")
+}
@@ -0,0 +1,101 @@
+// Copyright (c) Kusari <https://www.kusari.dev/>
+// SPDX-License-Identifier: MIT
+
+package annotate
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/kusaridev/kusari-cli/v2/api"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testDiff = `diff --git a/main.go b/main.go
+index 1111111..2222222 100644
+--- a/main.go
++++ b/main.go
+@@ -1,4 +1,5 @@
+ package main
+-import "os"
++import "os/exec"
++
+ func main() {
+ 	run()
+diff --git a/old.txt b/old.txt
+deleted file mode 100644
+--- a/old.txt
++++ /dev/null
+@@ -1 +0,0 @@
+-gone
+\ No newline at end of file
+`
+
+func TestRender(t *testing.T) {
+	sa := &api.SecurityAnalysis{
+		RequiredCodeMitigations: []api.CodeMitigationItem{
+			{Path: "./main.go", LineNumber: 2, Content: "Command injection\nUser input reaches exec", Code: "exec.Command(\"ls\")"},
+			{Path: "main.go", LineNumber: 40, Content: "Unchecked error"},
+			{Path: "util.go", LineNumber: 3, Content: "Hardcoded secret"},
+		},
+		RequiredDependencyMitigations: []api.DependencyMitigationItem{{Content: "Upgrade lodash"}},
+	}
+
+	var buf bytes.Buffer
+	require.NoError(t, Render(&buf, []byte(testDiff), sa, Options{}))
+	out := buf.String()
+
+	assert.NotContains(t, out, "\033[")
+	assert.Contains(t, out, "── main.go ─")
+	assert.Contains(t, out, "    1     1 │ package main\n")
+	assert.Contains(t, out, "    2       │-import \"os\"\n")
+	assert.Contains(t, out, "          2 │+import \"os/exec\"\n"+
+		"             ▲ Kusari: Command injection\n"+
+		"               User input reaches exec\n"+
+		"               Suggested fix:\n"+
+		"                 exec.Command(\"ls\")\n"+
+		"          3 │+\n")
+	assert.Contains(t, out, "   Findings outside the diff of main.go:\n   line 40:\n   ▲ Kusari: Unchecked error\n")
+	assert.Contains(t, out, "── old.txt ─")
+	assert.Contains(t, out, "            │\\ No newline at end of file\n")
+	assert.Contains(t, out, "── util.go (not in the diff)\n   line 3:\n   ▲ Kusari: Hardcoded secret\n")
+	assert.Contains(t, out, "── Dependency findings\n   ▲ Upgrade lodash\n")
+	assert.NotContains(t, out, "index 1111111")
+	assert.Less(t, strings.Index(out, "old.txt"), strings.Index(out, "util.go"))
+}
+
+func TestRenderTruncatesSuggestedFix(t *testing.T) {
+	sa := &api.SecurityAnalysis{RequiredCodeMitigations: []api.CodeMitigationItem{
+		{Path: "main.go", LineNumber: 1, Content: "Issue", Code: strings.Repeat("line\n", 15)},
+	}}
+	var buf bytes.Buffer
+	require.NoError(t, Render(&buf, []byte(testDiff), sa, Options{}))
+	assert.Equal(t, maxCodeLines, strings.Count(buf.String(), "    line\n"))
+	assert.Contains(t, buf.String(), "... (5 more lines)")
+}
+
+func TestRenderColor(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, Render(&buf, []byte(testDiff), nil, Options{Color: true}))
+	assert.Contains(t, buf.String(), green+"+import \"os/exec\"")
+	assert.Contains(t, buf.String(), red+"-import \"os\"")
+}
+
+func TestParseHunk(t *testing.T) {
+	oldStart, newStart, ok := parseHunk("@@ -10,3 +12 @@ func main() {")
+	assert.True(t, ok)
+	assert.Equal(t, 10, oldStart)
+	assert.Equal(t, 12, newStart)
+
+	_, _, ok = parseHunk("@@ bogus @@")
+	assert.False(t, ok)
+}
+
+func TestDiffPath(t *testing.T) {
+	assert.Equal(t, "pkg/a.go", diffPath("b/pkg/a.go"))
+	assert.Equal(t, "", diffPath("/dev/null"))
+	assert.Equal(t, "with space.go", diffPath("b/with space.go\t"))
+	assert.Equal(t, "tab\there.go", diffPath(`"b/tab\there.go"`))
+}
@@ -18,10 +18,7 @@ func NewClient(authendpoint, clientID, clientSecret, redirectURL string) *Client
 			ClientID:    clientID,
 			RedirectURL: redirectURL,
 			Scopes:      []string{oidc.ScopeOpenID, "profile", "email"},
-			Endpoint: oauth2.Endpoint{
-				AuthURL:  authendpoint + "oauth2/authorize",
-				TokenURL: authendpoint + "oauth2/token",
-			},
+			Endpoint:    fallbackEndpoint(authendpoint),
 		},
 	}
 }
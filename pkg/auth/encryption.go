@@ -0,0 +1,289 @@
+// Copyright (c) Kusari <https://www.kusari.dev/>
+// SPDX-License-Identifier: MIT
+
+package auth
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hkdf"
+	"crypto/pbkdf2"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/kusaridev/kusari-cli/v2/pkg/fips"
+	"github.com/kusaridev/kusari-cli/v2/pkg/output"
+	"golang.org/x/term"
+)
+
+// TokenEncryption selects how the token file is protected at rest.
+type TokenEncryption string
+
+const (
+	// EncryptionNone stores tokens as plaintext JSON (file mode 0600).
+	EncryptionNone TokenEncryption = "none"
+	// EncryptionPassphrase derives the key from a passphrase taken from
+	// KUSARI_TOKEN_KEY or prompted for on the terminal.
+	EncryptionPassphrase TokenEncryption = "passphrase"
+	// EncryptionMachine derives the key from the OS machine ID and the
+	// current user, so the file can't be decrypted on another machine.
+	EncryptionMachine TokenEncryption = "machine"
+)
+
+const (
+	// TokenEncryptionEnv selects the TokenEncryption mode.
+	TokenEncryptionEnv = "KUSARI_TOKEN_ENCRYPTION"
+	// TokenKeyEnv holds the passphrase for EncryptionPassphrase. Setting it
+	// without TokenEncryptionEnv implies EncryptionPassphrase.
+	TokenKeyEnv = "KUSARI_TOKEN_KEY"
+
+	encryptedFileVersion = 1
+	pbkdf2Iterations     = 600_000
+	keyLength            = 32 // AES-256
+	saltLength           = 16
+	nonceLength          = 12 // GCM standard nonce
+	hkdfInfo             = "kusari-cli token encryption"
+)
+
+var (
+	tokenEncryption TokenEncryption
+
+	passphraseOnce  sync.Once
+	passphraseValue string
+	passphraseErr   error
+)
+
+// encryptedTokenFile is the on-disk envelope for an encrypted token file.
+// []byte fields are base64-encoded by encoding/json.
+type encryptedTokenFile struct {
+	Version    int             `json:"kusari_encrypted"`
+	KDF        TokenEncryption `json:"kdf"`
+	Salt       []byte          `json:"salt"`
+	Nonce      []byte          `json:"nonce"`
+	Ciphertext []byte          `json:"ciphertext"`
+}
+
+// SetTokenEncryption overrides the encryption mode for token storage. An
+// empty mode falls back to the environment (see TokenEncryptionEnv).
+func SetTokenEncryption(mode TokenEncryption) {
+	tokenEncryption = mode
+}
+
+// ParseTokenEncryption validates a user-supplied encryption mode.
+func ParseTokenEncryption(s string) (TokenEncryption, error) {
+	switch mode := TokenEncryption(strings.ToLower(strings.TrimSpace(s))); mode {
+	case "", EncryptionNone, EncryptionPassphrase, EncryptionMachine:
+		return mode, nil
+	default:
+		return "", fmt.Errorf("invalid token encryption %q (must be none, passphrase, or machine)", s)
+	}
+}
+
+// tokenEncryptionMode resolves the configured mode: SetTokenEncryption,
+// then KUSARI_TOKEN_ENCRYPTION, then passphrase if KUSARI_TOKEN_KEY is set.
+func tokenEncryptionMode() TokenEncryption {
+	if tokenEncryption != "" {
+		return tokenEncryption
+	}
+	if mode, err := ParseTokenEncryption(os.Getenv(TokenEncryptionEnv)); err == nil && mode != "" {
+		return mode
+	}
+	if os.Getenv(TokenKeyEnv) != "" {
+		return EncryptionPassphrase
+	}
+	return EncryptionNone
+}
+
+// isEncryptedTokenFile reports whether data is an encrypted envelope rather
+// than a plaintext token map.
+func isEncryptedTokenFile(data []byte) bool {
+	var probe struct {
+		Version int `json:"kusari_encrypted"`
+	}
+	return json.Unmarshal(data, &probe) == nil && probe.Version > 0
+}
+
+// encryptTokenData seals plaintext with AES-256-GCM under a key derived
+// according to mode.
+func encryptTokenData(plaintext []byte, mode TokenEncryption) ([]byte, error) {
+	salt := make([]byte, saltLength)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, NewAuthErrorWithCause(ErrTokenEncryption, "failed to generate salt", err)
+	}
+
+	key, err := deriveTokenKey(mode, salt)
+	if err != nil {
+		return nil, err
+	}
+
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+
+	// The AEAD generates the nonce and prepends it to the ciphertext; the
+	// envelope keeps them apart as before.
+	sealed := gcm.Seal(nil, nil, plaintext, []byte(mode))
+	envelope := encryptedTokenFile{
+		Version:    encryptedFileVersion,
+		KDF:        mode,
+		Salt:       salt,
+		Nonce:      sealed[:nonceLength],
+		Ciphertext: sealed[nonceLength:],
+	}
+	data, err := json.MarshalIndent(envelope, "", "  ")
+	if err != nil {
+		return nil, NewAuthErrorWithCause(ErrTokenEncryption, "failed to marshal encrypted tokens", err)
+	}
+	return data, nil
+}
+
+// decryptTokenData opens an encrypted envelope, returning the plaintext and
+// the mode it was encrypted with.
+func decryptTokenData(data []byte) ([]byte, TokenEncryption, error) {
+	var envelope encryptedTokenFile
+	if err := json.Unmarshal(data, &envelope); err != nil {
+		return nil, "", NewAuthErrorWithCause(ErrTokenEncryption, "failed to unmarshal encrypted tokens", err)
+	}
+	if envelope.Version != encryptedFileVersion {
+		return nil, "", NewAuthError(ErrTokenEncryption, fmt.Sprintf("unsupported encrypted token file version %d", envelope.Version))
+	}
+
+	key, err := deriveTokenKey(envelope.KDF, envelope.Salt)
+	if err != nil {
+		return nil, "", err
+	}
+
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, "", err
+	}
+
+	sealed := append(append([]byte{}, envelope.Nonce...), envelope.Ciphertext...)
+	plaintext, err := gcm.Open(nil, nil, sealed, []byte(envelope.KDF))
+	if err != nil {
+		return nil, "", NewAuthError(ErrTokenEncryption, "failed to decrypt token file (wrong passphrase or different machine?). Re-run `kusari auth login`.")
+	}
+	return plaintext, envelope.KDF, nil
+}
+
+// newGCM returns AES-GCM with nonces generated by the crypto module, the
+// form FIPS 140-only mode allows.
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, NewAuthErrorWithCause(ErrTokenEncryption, "failed to create cipher", err)
+	}
+	gcm, err := cipher.NewGCMWithRandomNonce(block)
+	if err != nil {
+		return nil, NewAuthErrorWithCause(ErrTokenEncryption, "failed to create GCM", err)
+	}
+	return gcm, nil
+}
+
+func deriveTokenKey(mode TokenEncryption, salt []byte) ([]byte, error) {
+	switch mode {
+	case EncryptionPassphrase:
+		if err := fips.Require("PBKDF2-HMAC-SHA256", "AES-256-GCM"); err != nil {
+			return nil, NewAuthErrorWithCause(ErrTokenEncryption, "token encryption unavailable", err)
+		}
+		passphrase, err := tokenPassphrase()
+		if err != nil {
+			return nil, err
+		}
+		key, err := pbkdf2.Key(sha256.New, passphrase, salt, pbkdf2Iterations, keyLength)
+		if err != nil {
+			return nil, NewAuthErrorWithCause(ErrTokenEncryption, "failed to derive key", err)
+		}
+		return key, nil
+	case EncryptionMachine:
+		if err := fips.Require("HKDF-SHA256", "AES-256-GCM"); err != nil {
+			return nil, NewAuthErrorWithCause(ErrTokenEncryption, "token encryption unavailable", err)
+		}
+		id, err := machineID()
+		if err != nil {
+			return nil, NewAuthErrorWithCause(ErrTokenEncryption, "failed to read machine ID", err)
+		}
+		secret := id + ":" + strconv.Itoa(os.Getuid())
+		key, err := hkdf.Key(sha256.New, []byte(secret), salt, hkdfInfo, keyLength)
+		if err != nil {
+			return nil, NewAuthErrorWithCause(ErrTokenEncryption, "failed to derive key", err)
+		}
+		return key, nil
+	default:
+		return nil, NewAuthError(ErrTokenEncryption, fmt.Sprintf("unsupported token encryption %q", mode))
+	}
+}
+
+// tokenPassphrase returns the passphrase from KUSARI_TOKEN_KEY, or prompts
+// for it once per process when stdin is a terminal.
+func tokenPassphrase() (string, error) {
+	if p := os.Getenv(TokenKeyEnv); p != "" {
+		return p, nil
+	}
+
+	passphraseOnce.Do(func() {
+		if !output.Interactive() {
+			passphraseErr = NewAuthError(ErrTokenEncryption, fmt.Sprintf("token file passphrase required and no terminal to prompt on: set %s", TokenKeyEnv))
+			return
+		}
+		fmt.Fprint(os.Stderr, "Kusari token passphrase: ")
+		b, err := term.ReadPassword(int(os.Stdin.Fd()))
+		fmt.Fprintln(os.Stderr)
+		if err != nil {
+			passphraseErr = NewAuthErrorWithCause(ErrTokenEncryption, "failed to read passphrase", err)
+			return
+		}
+		if len(b) == 0 {
+			passphraseErr = NewAuthError(ErrTokenEncryption, "empty passphrase")
+			return
+		}
+		passphraseValue = string(b)
+	})
+	return passphraseValue, passphraseErr
+}
+
+// machineID returns a stable per-machine identifier from the OS.
+func machineID() (string, error) {
+	switch runtime.GOOS {
+	case "darwin":
+		out, err := exec.Command("ioreg", "-rd1", "-c", "IOPlatformExpertDevice").Output()
+		if err != nil {
+			return "", err
+		}
+		for line := range bytes.Lines(out) {
+			if _, after, ok := bytes.Cut(line, []byte(`"IOPlatformUUID" = "`)); ok {
+				return string(bytes.TrimRight(bytes.TrimSpace(after), `"`)), nil
+			}
+		}
+		return "", fmt.Errorf("IOPlatformUUID not found")
+	case "windows":
+		out, err := exec.Command("reg", "query", `HKLM\SOFTWARE\Microsoft\Cryptography`, "/v", "MachineGuid").Output()
+		if err != nil {
+			return "", err
+		}
+		fields := strings.Fields(string(out))
+		if len(fields) == 0 {
+			return "", fmt.Errorf("MachineGuid not found")
+		}
+		return fields[len(fields)-1], nil
+	default:
+		for _, path := range []string{"/etc/machine-id", "/var/lib/dbus/machine-id"} {
+			if data, err := os.ReadFile(path); err == nil {
+				if id := strings.TrimSpace(string(data)); id != "" {
+					return id, nil
+				}
+			}
+		}
+		return "", fmt.Errorf("no machine-id found")
+	}
+}
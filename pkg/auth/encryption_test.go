@@ -0,0 +1,88 @@
+// Copyright (c) Kusari <https://www.kusari.dev/>
+// SPDX-License-Identifier: MIT
+
+package auth
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/oauth2"
+)
+
+func setupTokenHome(t *testing.T) string {
+	t.Helper()
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv(TokenEncryptionEnv, "")
+	t.Setenv(TokenKeyEnv, "")
+	SetTokenEncryption("")
+	t.Cleanup(func() { SetTokenEncryption("") })
+	return filepath.Join(home, configDirName, tokenFileName)
+}
+
+func TestEncryptDecryptTokenData_Passphrase(t *testing.T) {
+	t.Setenv(TokenKeyEnv, "correct horse battery staple")
+
+	sealed, err := encryptTokenData([]byte(`{"kusari":{}}`), EncryptionPassphrase)
+	require.NoError(t, err)
+	assert.True(t, isEncryptedTokenFile(sealed))
+	assert.NotContains(t, string(sealed), "kusari\":{")
+
+	plain, mode, err := decryptTokenData(sealed)
+	require.NoError(t, err)
+	assert.Equal(t, EncryptionPassphrase, mode)
+	assert.Equal(t, `{"kusari":{}}`, string(plain))
+
+	t.Setenv(TokenKeyEnv, "wrong")
+	_, _, err = decryptTokenData(sealed)
+	assert.Error(t, err)
+}
+
+func TestIsEncryptedTokenFile_Plaintext(t *testing.T) {
+	assert.False(t, isEncryptedTokenFile([]byte(`{"kusari":{"access_token":"x"}}`)))
+	assert.False(t, isEncryptedTokenFile([]byte(`not json`)))
+}
+
+func TestParseTokenEncryption(t *testing.T) {
+	mode, err := ParseTokenEncryption(" Machine ")
+	require.NoError(t, err)
+	assert.Equal(t, EncryptionMachine, mode)
+
+	_, err = ParseTokenEncryption("rot13")
+	assert.Error(t, err)
+}
+
+func TestLoadToken_MigratesPlaintext(t *testing.T) {
+	tokenPath := setupTokenHome(t)
+
+	require.NoError(t, SaveToken(&oauth2.Token{AccessToken: "abc"}, "kusari"))
+	data, err := os.ReadFile(tokenPath)
+	require.NoError(t, err)
+	assert.False(t, isEncryptedTokenFile(data))
+
+	t.Setenv(TokenKeyEnv, "s3cret")
+	token, err := LoadToken("kusari")
+	require.NoError(t, err)
+	assert.Equal(t, "abc", token.AccessToken)
+
+	data, err = os.ReadFile(tokenPath)
+	require.NoError(t, err)
+	assert.True(t, isEncryptedTokenFile(data))
+	assert.NotContains(t, string(data), "abc")
+
+	// An encrypted file stays encrypted even if encryption is no longer
+	// requested explicitly.
+	SetTokenEncryption(EncryptionNone)
+	require.NoError(t, SaveToken(&oauth2.Token{AccessToken: "def"}, "kusari"))
+	data, err = os.ReadFile(tokenPath)
+	require.NoError(t, err)
+	assert.True(t, isEncryptedTokenFile(data))
+
+	token, err = LoadToken("kusari")
+	require.NoError(t, err)
+	assert.Equal(t, "def", token.AccessToken)
+}
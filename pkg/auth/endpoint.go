@@ -0,0 +1,46 @@
+// Copyright (c) Kusari <https://www.kusari.dev/>
+// SPDX-License-Identifier: MIT
+
+package auth
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// DefaultRegion is the region of tenants whose region isn't known.
+const DefaultRegion = "us"
+
+// TenantEndpoint returns the API endpoint of tenant in region, e.g.
+// https://demo.api.us.kusari.cloud.
+func TenantEndpoint(tenant, region string) string {
+	if region == "" {
+		region = DefaultRegion
+	}
+	return fmt.Sprintf("https://%s.api.%s.kusari.cloud", tenant, region)
+}
+
+// RegionFromPlatformURL returns the region of a platform URL such as
+// https://platform.api.us.kusari.cloud/, or DefaultRegion when it doesn't
+// follow that layout.
+func RegionFromPlatformURL(platformUrl string) string {
+	u, err := url.Parse(platformUrl)
+	if err != nil {
+		return DefaultRegion
+	}
+	labels := strings.Split(u.Hostname(), ".")
+	if len(labels) == 5 && labels[1] == "api" && labels[3] == "kusari" && labels[4] == "cloud" {
+		return labels[2]
+	}
+	return DefaultRegion
+}
+
+// TenantEndpoint returns the API endpoint of the workspace's tenant, or ""
+// when it has none.
+func (w WorkspaceInfo) TenantEndpoint() string {
+	if w.Tenant == "" {
+		return ""
+	}
+	return TenantEndpoint(w.Tenant, w.Region)
+}
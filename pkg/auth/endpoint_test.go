@@ -0,0 +1,26 @@
+// Copyright (c) Kusari <https://www.kusari.dev/>
+// SPDX-License-Identifier: MIT
+
+package auth
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTenantEndpoint(t *testing.T) {
+	assert.Equal(t, "https://demo.api.us.kusari.cloud", TenantEndpoint("demo", ""))
+	assert.Equal(t, "https://demo.api.eu.kusari.cloud", TenantEndpoint("demo", "eu"))
+
+	assert.Equal(t, "", WorkspaceInfo{}.TenantEndpoint())
+	assert.Equal(t, "https://demo.api.us.kusari.cloud", WorkspaceInfo{Tenant: "demo"}.TenantEndpoint())
+	assert.Equal(t, "https://demo.api.dev.kusari.cloud", WorkspaceInfo{Tenant: "demo", Region: "dev"}.TenantEndpoint())
+}
+
+func TestRegionFromPlatformURL(t *testing.T) {
+	assert.Equal(t, "us", RegionFromPlatformURL("https://platform.api.us.kusari.cloud/"))
+	assert.Equal(t, "dev", RegionFromPlatformURL("https://platform.api.dev.kusari.cloud"))
+	assert.Equal(t, DefaultRegion, RegionFromPlatformURL("http://localhost:8080"))
+	assert.Equal(t, DefaultRegion, RegionFromPlatformURL("://bad"))
+}
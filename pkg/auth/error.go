@@ -3,7 +3,10 @@
 
 package auth
 
-import "fmt"
+import (
+	"errors"
+	"fmt"
+)
 
 // AuthError represents authentication-related errors
 type AuthError struct {
@@ -21,6 +24,10 @@ const (
 	ErrTokenExpired
 	ErrInvalidToken
 	ErrNetworkError
+	ErrTokenEncryption
+	// ErrInteractionRequired means the command needed to prompt but
+	// prompting is disabled or stdin is not a terminal.
+	ErrInteractionRequired
 )
 
 func (e *AuthError) Error() string {
@@ -37,3 +44,9 @@ func NewAuthError(code ErrorCode, message string) *AuthError {
 func NewAuthErrorWithCause(code ErrorCode, message string, cause error) *AuthError {
 	return &AuthError{Code: code, Message: message, Cause: cause}
 }
+
+// IsTokenExpired reports whether err is an AuthError for an expired token.
+func IsTokenExpired(err error) bool {
+	var authErr *AuthError
+	return errors.As(err, &authErr) && authErr.Code == ErrTokenExpired
+}
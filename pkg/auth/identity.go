@@ -0,0 +1,293 @@
+// Copyright (c) Kusari <https://www.kusari.dev/>
+// SPDX-License-Identifier: MIT
+
+package auth
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"golang.org/x/oauth2"
+)
+
+const (
+	identitiesFileName = "identities.json"
+
+	// activeTokenKey is the tokens.json entry every command reads via
+	// LoadToken("kusari"). Switching identities copies the chosen
+	// identity's token into it.
+	activeTokenKey = "kusari"
+)
+
+// Identity is one stored login, keyed by auth endpoint, client ID and user.
+type Identity struct {
+	Key          string         `json:"key"`
+	AuthEndpoint string         `json:"authEndpoint"`
+	ClientID     string         `json:"clientId"`
+	User         string         `json:"user"`
+	Email        string         `json:"email,omitempty"`
+	Subject      string         `json:"subject,omitempty"`
+	Verified     bool           `json:"verified"`            // ID token signature, issuer and audience were validated
+	Workspace    *WorkspaceInfo `json:"workspace,omitempty"` // Restored on switch
+}
+
+type identitiesFile struct {
+	Active     string     `json:"active"`
+	Identities []Identity `json:"identities"`
+}
+
+// identityKey builds the tokens.json key for an identity.
+func identityKey(authEndpoint, clientID, user string) string {
+	return strings.TrimSuffix(authEndpoint, "/") + "|" + clientID + "|" + user
+}
+
+// tokenUser returns a human-readable user for token from its (unverified)
+// ID token, falling back to clientID for machine tokens.
+func tokenUser(token *oauth2.Token, clientID string) string {
+	idToken, _ := token.Extra("id_token").(string)
+	parts := strings.Split(idToken, ".")
+	if len(parts) == 3 {
+		if payload, err := base64.RawURLEncoding.DecodeString(parts[1]); err == nil {
+			var claims IDClaims
+			if json.Unmarshal(payload, &claims) == nil {
+				return claims.user(clientID)
+			}
+		}
+	}
+	return clientID
+}
+
+// user picks the most readable identifier: email, username, then subject.
+func (c *IDClaims) user(fallback string) string {
+	for _, v := range []string{c.Email, c.Username, c.Subject} {
+		if v != "" {
+			return v
+		}
+	}
+	return fallback
+}
+
+func getIdentitiesFilePath() (string, error) {
+	configDir, err := getConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(configDir, identitiesFileName), nil
+}
+
+func loadIdentitiesFile() (*identitiesFile, error) {
+	path, err := getIdentitiesFilePath()
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return &identitiesFile{}, nil
+		}
+		return nil, NewAuthErrorWithCause(ErrTokenStorage, "failed to read identities file", err)
+	}
+
+	var f identitiesFile
+	if err := json.Unmarshal(data, &f); err != nil {
+		return nil, NewAuthErrorWithCause(ErrTokenStorage, "failed to unmarshal identities", err)
+	}
+	return &f, nil
+}
+
+func saveIdentitiesFile(f *identitiesFile) error {
+	configDir, err := getConfigDir()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(configDir, 0700); err != nil {
+		return NewAuthErrorWithCause(ErrTokenStorage, "failed to create config directory", err)
+	}
+
+	path, err := getIdentitiesFilePath()
+	if err != nil {
+		return err
+	}
+
+	data, err := json.MarshalIndent(f, "", "  ")
+	if err != nil {
+		return NewAuthErrorWithCause(ErrTokenStorage, "failed to marshal identities", err)
+	}
+	if err := os.WriteFile(path, data, 0600); err != nil {
+		return NewAuthErrorWithCause(ErrTokenStorage, "failed to write identities file", err)
+	}
+	return nil
+}
+
+func (f *identitiesFile) find(key string) *Identity {
+	for i := range f.Identities {
+		if f.Identities[i].Key == key {
+			return &f.Identities[i]
+		}
+	}
+	return nil
+}
+
+// SaveIdentity stores token as a named identity and makes it the active one,
+// leaving other stored identities intact. claims come from a validated ID
+// token; when nil the user is read from the unverified token instead.
+func SaveIdentity(token *oauth2.Token, authEndpoint, clientID string, claims *IDClaims) (*Identity, error) {
+	f, err := loadIdentitiesFile()
+	if err != nil {
+		return nil, err
+	}
+
+	user := tokenUser(token, clientID)
+	if claims != nil {
+		user = claims.user(clientID)
+	}
+	key := identityKey(authEndpoint, clientID, user)
+
+	tokens, stored, err := readTokenFile()
+	if err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("error, found token file, but could not read it: %w", err)
+	}
+	if tokens == nil {
+		tokens = make(map[string]*oauth2.Token)
+	}
+	tokens[key] = token
+	tokens[activeTokenKey] = token
+
+	if err := writeTokenFile(tokens, saveEncryptionMode(stored)); err != nil {
+		return nil, err
+	}
+
+	id := f.find(key)
+	if id == nil {
+		f.Identities = append(f.Identities, Identity{
+			Key:          key,
+			AuthEndpoint: authEndpoint,
+			ClientID:     clientID,
+			User:         user,
+		})
+		id = &f.Identities[len(f.Identities)-1]
+	}
+	id.Verified = claims != nil
+	if claims != nil {
+		id.Email, id.Subject = claims.Email, claims.Subject
+	}
+	f.Active = key
+	if err := saveIdentitiesFile(f); err != nil {
+		return nil, err
+	}
+	return id, nil
+}
+
+// ActiveIdentity returns the identity of the current login, or nil when no
+// identity has been recorded (e.g. tokens saved by an older CLI version).
+func ActiveIdentity() (*Identity, error) {
+	f, err := loadIdentitiesFile()
+	if err != nil {
+		return nil, err
+	}
+	return f.find(f.Active), nil
+}
+
+// ListIdentities returns the stored identities and the key of the active one.
+func ListIdentities() ([]Identity, string, error) {
+	f, err := loadIdentitiesFile()
+	if err != nil {
+		return nil, "", err
+	}
+	return f.Identities, f.Active, nil
+}
+
+// FindIdentity resolves ref to a stored identity. ref may be the full key,
+// the user, or the 1-based position shown by `kusari auth list`.
+func FindIdentity(identities []Identity, ref string) (*Identity, error) {
+	var matches []*Identity
+	for i := range identities {
+		id := &identities[i]
+		if id.Key == ref || fmt.Sprint(i+1) == ref {
+			return id, nil
+		}
+		if strings.EqualFold(id.User, ref) {
+			matches = append(matches, id)
+		}
+	}
+	switch len(matches) {
+	case 0:
+		return nil, fmt.Errorf("no stored identity matches %q. Run `kusari auth list`", ref)
+	case 1:
+		return matches[0], nil
+	default:
+		return nil, fmt.Errorf("%q matches %d identities; use the number or full key from `kusari auth list`", ref, len(matches))
+	}
+}
+
+// SwitchIdentity makes the identity with key the active one: its token
+// becomes the one returned by LoadToken("kusari") and its last selected
+// workspace, if any, is restored.
+func SwitchIdentity(key string) (*Identity, error) {
+	f, err := loadIdentitiesFile()
+	if err != nil {
+		return nil, err
+	}
+	id := f.find(key)
+	if id == nil {
+		return nil, fmt.Errorf("no stored identity %q. Run `kusari auth list`", key)
+	}
+
+	tokens, stored, err := readTokenFile()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read token file: %w", err)
+	}
+	token, ok := tokens[key]
+	if !ok {
+		return nil, NewAuthError(ErrInvalidToken, fmt.Sprintf("no token stored for %s. Run `kusari auth login` again", id.User))
+	}
+	tokens[activeTokenKey] = token
+
+	if err := writeTokenFile(tokens, saveEncryptionMode(stored)); err != nil {
+		return nil, err
+	}
+
+	f.Active = key
+	if err := saveIdentitiesFile(f); err != nil {
+		return nil, err
+	}
+
+	if id.Workspace != nil {
+		if err := writeWorkspaceFile(*id.Workspace); err != nil {
+			return nil, err
+		}
+	} else if err := ClearWorkspace(); err != nil {
+		return nil, err
+	}
+	return id, nil
+}
+
+// rememberWorkspace records workspace on the active identity so switching
+// back to it later restores the selection.
+func rememberWorkspace(workspace WorkspaceInfo) error {
+	f, err := loadIdentitiesFile()
+	if err != nil {
+		return err
+	}
+	id := f.find(f.Active)
+	if id == nil {
+		return nil
+	}
+	id.Workspace = &workspace
+	return saveIdentitiesFile(f)
+}
+
+// syncActiveIdentityToken keeps the active identity's entry in tokens in
+// step with the active token after a refresh or re-save.
+func syncActiveIdentityToken(tokens map[string]*oauth2.Token, token *oauth2.Token) {
+	f, err := loadIdentitiesFile()
+	if err != nil || f.Active == "" {
+		return
+	}
+	tokens[f.Active] = token
+}
@@ -0,0 +1,84 @@
+// Copyright (c) Kusari <https://www.kusari.dev/>
+// SPDX-License-Identifier: MIT
+
+package auth
+
+import (
+	"encoding/base64"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/oauth2"
+)
+
+func tokenForUser(access, email string) *oauth2.Token {
+	payload := base64.RawURLEncoding.EncodeToString([]byte(`{"email":"` + email + `"}`))
+	return (&oauth2.Token{AccessToken: access}).WithExtra(map[string]interface{}{
+		"id_token": "e30." + payload + ".sig",
+	})
+}
+
+func TestTokenUser(t *testing.T) {
+	assert.Equal(t, "a@example.com", tokenUser(tokenForUser("x", "a@example.com"), "client"))
+	assert.Equal(t, "client", tokenUser(&oauth2.Token{AccessToken: "x"}, "client"))
+}
+
+func TestSaveIdentity_SwitchRestoresTokenAndWorkspace(t *testing.T) {
+	setupTokenHome(t)
+
+	first, err := SaveIdentity(tokenForUser("tok-a", "a@example.com"), "https://auth.example/", "client", nil)
+	require.NoError(t, err)
+	require.NoError(t, SaveWorkspace(WorkspaceInfo{ID: "ws-a", Description: "Customer A", Tenant: "a"}))
+
+	second, err := SaveIdentity(tokenForUser("tok-b", "b@example.com"), "https://auth.example/", "client", nil)
+	require.NoError(t, err)
+	require.NoError(t, SaveWorkspace(WorkspaceInfo{ID: "ws-b", Description: "Customer B", Tenant: "b"}))
+	assert.NotEqual(t, first.Key, second.Key)
+
+	token, err := LoadToken("kusari")
+	require.NoError(t, err)
+	assert.Equal(t, "tok-b", token.AccessToken)
+
+	identities, active, err := ListIdentities()
+	require.NoError(t, err)
+	require.Len(t, identities, 2)
+	assert.Equal(t, second.Key, active)
+
+	found, err := FindIdentity(identities, "A@example.com")
+	require.NoError(t, err)
+	_, err = SwitchIdentity(found.Key)
+	require.NoError(t, err)
+
+	token, err = LoadToken("kusari")
+	require.NoError(t, err)
+	assert.Equal(t, "tok-a", token.AccessToken)
+
+	ws, err := LoadWorkspace("", "")
+	require.NoError(t, err)
+	assert.Equal(t, "ws-a", ws.ID)
+
+	// Re-login as an existing identity updates it in place.
+	_, err = SaveIdentity(tokenForUser("tok-a2", "a@example.com"), "https://auth.example", "client", nil)
+	require.NoError(t, err)
+	identities, _, err = ListIdentities()
+	require.NoError(t, err)
+	assert.Len(t, identities, 2)
+}
+
+func TestFindIdentity(t *testing.T) {
+	identities := []Identity{
+		{Key: "e1|c|u@x", User: "u@x"},
+		{Key: "e2|c|u@x", User: "u@x"},
+	}
+
+	id, err := FindIdentity(identities, "2")
+	require.NoError(t, err)
+	assert.Equal(t, "e2|c|u@x", id.Key)
+
+	_, err = FindIdentity(identities, "u@x")
+	assert.ErrorContains(t, err, "matches 2 identities")
+
+	_, err = FindIdentity(identities, "nobody")
+	assert.Error(t, err)
+}
@@ -5,17 +5,33 @@ package auth
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log"
 	"net"
 	"net/http"
 	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/coreos/go-oidc/v3/oidc"
+	"github.com/kusaridev/kusari-cli/v2/pkg/output"
+	"github.com/kusaridev/kusari-cli/v2/pkg/port"
 	"golang.org/x/oauth2"
 	"golang.org/x/oauth2/clientcredentials"
 )
 
+// discoveryTimeout bounds the OIDC discovery request so an IdP without a
+// discovery document doesn't stall login.
+const discoveryTimeout = 5 * time.Second
+
+// SkipIDTokenVerifyEnv, when true, lets a login whose ID token can't be
+// validated, because the IdP's discovery document or signing keys are
+// unreachable, store the token anyway, marked not verified.
+const SkipIDTokenVerifyEnv = "KUSARI_INSECURE_SKIP_ID_TOKEN_VERIFY"
+
 func Authenticate(ctx context.Context, clientId, clientSecret, redirectUrl, authEndpoint, redirectPort, consoleUrl, workspaceId string) (*oauth2.Token, error) {
 	baseURL, err := url.Parse(consoleUrl)
 	if err != nil {
@@ -37,10 +53,13 @@ func Authenticate(ctx context.Context, clientId, clientSecret, redirectUrl, auth
 		consoleAnalysisUrl = ""
 	}
 
-	oauth2Config := oauthConfig(clientId, redirectUrl, authEndpoint)
+	idp := discoverIdentityProvider(ctx, authEndpoint)
 
 	var token *oauth2.Token
+	var claims *IDClaims
 	if clientSecret != "" {
+		oauth2Config := oauthConfig(clientId, redirectUrl, idp.endpoint)
+
 		config := &clientcredentials.Config{
 			ClientID:     clientId,
 			ClientSecret: clientSecret,
@@ -51,6 +70,10 @@ func Authenticate(ctx context.Context, clientId, clientSecret, redirectUrl, auth
 			return nil, NewAuthErrorWithCause(ErrAuthFlow, "failed to exchange token", err)
 		}
 	} else {
+		if output.NonInteractive() {
+			return nil, NewAuthError(ErrInteractionRequired, fmt.Sprintf("browser login is disabled in non-interactive mode: set %s, or %s and %s", APIKeyEnv, ClientIDEnv, ClientSecretEnv))
+		}
+
 		// Generate and use state to prevent CSRF attacks
 		state, err := generateRandomString(32)
 		if err != nil {
@@ -60,11 +83,20 @@ func Authenticate(ctx context.Context, clientId, clientSecret, redirectUrl, auth
 		// use PKCE to protect the auth code exchange
 		codeVerifier := oauth2.GenerateVerifier()
 
-		// Get code.
-		l, err := net.Listen("tcp", fmt.Sprintf("localhost:%s", redirectPort))
+		// Get code. redirectPort may be a range or 0, so the redirect URL
+		// is rewritten to the port actually bound.
+		l, boundPort, err := port.Listen("localhost", redirectPort)
 		if err != nil {
-			return nil, NewAuthErrorWithCause(ErrNetworkError, "failed to listen", err)
+			return nil, NewAuthErrorWithCause(ErrNetworkError, "failed to listen for the login callback", err)
 		}
+		redirectUrl, err = withPort(redirectUrl, boundPort)
+		if err != nil {
+			_ = l.Close()
+			return nil, NewAuthErrorWithCause(ErrAuthFlow, "invalid redirect URL", err)
+		}
+		output.Debug("oauth callback", "url", redirectUrl)
+		oauth2Config := oauthConfig(clientId, redirectUrl, idp.endpoint)
+
 		var callbackRes = make(chan callbackResult)
 		go func() {
 			defer func() {
@@ -103,25 +135,134 @@ func Authenticate(ctx context.Context, clientId, clientSecret, redirectUrl, auth
 		if err != nil {
 			return nil, NewAuthErrorWithCause(ErrAuthFlow, "failed to exchange token", err)
 		}
+
+		claims, err = idp.verifyIDToken(ctx, token, clientId)
+		if err != nil {
+			return nil, err
+		}
 	}
 
-	provider := oauth2Config.Endpoint.TokenURL
-	if err := SaveToken(token, provider); err != nil {
+	if _, err := SaveIdentity(token, authEndpoint, clientId, claims); err != nil {
 		return nil, err
 	}
 
 	return token, nil
 }
 
-func oauthConfig(clientID string, redirectURL string, authendpoint string) *oauth2.Config {
-	// in here probably do the url concat logic.
+// withPort returns rawURL with its port replaced by p.
+func withPort(rawURL, p string) (string, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return "", err
+	}
+	u.Host = net.JoinHostPort(u.Hostname(), p)
+	return u.String(), nil
+}
+
+func oauthConfig(clientID string, redirectURL string, endpoint oauth2.Endpoint) *oauth2.Config {
 	return &oauth2.Config{
 		ClientID:    clientID,
 		RedirectURL: redirectURL,
 		Scopes:      []string{oidc.ScopeOpenID, "profile", "email"},
-		Endpoint: oauth2.Endpoint{
-			AuthURL:  authendpoint + "oauth2/authorize",
-			TokenURL: authendpoint + "oauth2/token",
-		},
+		Endpoint:    endpoint,
+	}
+}
+
+// identityProvider is what discovery learned about the IdP. keySet is nil
+// when discovery failed and ID tokens can't be validated; keysErr then
+// tells why.
+type identityProvider struct {
+	endpoint oauth2.Endpoint
+	issuer   string
+	keySet   oidc.KeySet
+	keysErr  error
+}
+
+// discoverIdentityProvider reads the IdP's /.well-known/openid-configuration
+// to resolve its endpoints and signing keys. If discovery fails, it falls
+// back to the oauth2/authorize and oauth2/token paths under authEndpoint.
+func discoverIdentityProvider(ctx context.Context, authEndpoint string) *identityProvider {
+	ctx = oidc.ClientContext(ctx, &http.Client{Timeout: discoveryTimeout})
+	// The issuer advertised by the document is used for ID token validation
+	// below, so accept one that differs from authEndpoint (e.g. behind a
+	// custom domain).
+	ctx = oidc.InsecureIssuerURLContext(ctx, authEndpoint)
+
+	fallback := &identityProvider{endpoint: fallbackEndpoint(authEndpoint)}
+
+	provider, err := oidc.NewProvider(ctx, authEndpoint)
+	if err != nil {
+		output.Debug("oidc discovery failed, using default endpoints", "authEndpoint", authEndpoint, "error", err)
+		fallback.keysErr = err
+		return fallback
+	}
+
+	endpoint := provider.Endpoint()
+	if endpoint.AuthURL == "" || endpoint.TokenURL == "" {
+		output.Debug("oidc discovery returned incomplete endpoints, using defaults", "authEndpoint", authEndpoint)
+		fallback.keysErr = errors.New("discovery document has no authorization or token endpoint")
+		return fallback
+	}
+
+	var doc struct {
+		Issuer  string `json:"issuer"`
+		JWKSURL string `json:"jwks_uri"`
+	}
+	idp := &identityProvider{endpoint: endpoint}
+	if err := provider.Claims(&doc); err == nil && doc.Issuer != "" && doc.JWKSURL != "" {
+		idp.issuer = doc.Issuer
+		idp.keySet = oidc.NewRemoteKeySet(ctx, doc.JWKSURL)
+	} else {
+		idp.keysErr = errors.New("discovery document has no issuer or jwks_uri")
+	}
+	return idp
+}
+
+// IDClaims are the identity claims taken from a validated ID token.
+type IDClaims struct {
+	Subject  string `json:"sub"`
+	Email    string `json:"email"`
+	Username string `json:"cognito:username"`
+	Issuer   string `json:"iss"`
+}
+
+// verifyIDToken validates token's ID token (signature, issuer, audience and
+// expiry) and returns its claims. It returns nil claims without error when
+// there is no ID token. An ID token that can't be validated, because the
+// IdP's signing keys are unavailable, is an error unless
+// SkipIDTokenVerifyEnv is set.
+func (idp *identityProvider) verifyIDToken(ctx context.Context, token *oauth2.Token, clientID string) (*IDClaims, error) {
+	rawIDToken, _ := token.Extra("id_token").(string)
+	if rawIDToken == "" {
+		return nil, nil
+	}
+	if idp.keySet == nil {
+		if skip, _ := strconv.ParseBool(os.Getenv(SkipIDTokenVerifyEnv)); skip {
+			fmt.Fprintf(os.Stderr, "Warning: ID token not validated (%s is set): %v\n", SkipIDTokenVerifyEnv, idp.keysErr)
+			return nil, nil
+		}
+		return nil, NewAuthErrorWithCause(ErrNetworkError,
+			fmt.Sprintf("can't validate the ID token: the identity provider's signing keys are unavailable (set %s=true to log in without validating it)", SkipIDTokenVerifyEnv), idp.keysErr)
+	}
+
+	verifier := oidc.NewVerifier(idp.issuer, idp.keySet, &oidc.Config{ClientID: clientID})
+	idToken, err := verifier.Verify(ctx, rawIDToken)
+	if err != nil {
+		return nil, NewAuthErrorWithCause(ErrInvalidToken, "ID token validation failed", err)
+	}
+
+	var claims IDClaims
+	if err := idToken.Claims(&claims); err != nil {
+		return nil, NewAuthErrorWithCause(ErrInvalidToken, "failed to parse ID token claims", err)
+	}
+	return &claims, nil
+}
+
+// fallbackEndpoint builds the Cognito-style endpoints under authEndpoint.
+func fallbackEndpoint(authEndpoint string) oauth2.Endpoint {
+	base := strings.TrimSuffix(authEndpoint, "/")
+	return oauth2.Endpoint{
+		AuthURL:  base + "/oauth2/authorize",
+		TokenURL: base + "/oauth2/token",
 	}
 }
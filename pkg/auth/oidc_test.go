@@ -0,0 +1,118 @@
+// Copyright (c) Kusari <https://www.kusari.dev/>
+// SPDX-License-Identifier: MIT
+
+package auth
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/coreos/go-oidc/v3/oidc"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/oauth2"
+)
+
+func TestDiscoverIdentityProvider_Discovery(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/.well-known/openid-configuration" {
+			http.NotFound(w, r)
+			return
+		}
+		_ = json.NewEncoder(w).Encode(map[string]string{
+			"issuer":                 "https://issuer.example",
+			"authorization_endpoint": "https://idp.example/authorize",
+			"token_endpoint":         "https://idp.example/token",
+		})
+	}))
+	defer server.Close()
+
+	endpoint := discoverIdentityProvider(context.Background(), server.URL+"/").endpoint
+	assert.Equal(t, "https://idp.example/authorize", endpoint.AuthURL)
+	assert.Equal(t, "https://idp.example/token", endpoint.TokenURL)
+}
+
+func TestDiscoverIdentityProvider_Fallback(t *testing.T) {
+	server := httptest.NewServer(http.NotFoundHandler())
+	defer server.Close()
+
+	idp := discoverIdentityProvider(context.Background(), server.URL+"/")
+	assert.Equal(t, server.URL+"/oauth2/authorize", idp.endpoint.AuthURL)
+	assert.Equal(t, server.URL+"/oauth2/token", idp.endpoint.TokenURL)
+	assert.Nil(t, idp.keySet)
+	assert.Error(t, idp.keysErr)
+}
+
+func signedIDToken(t *testing.T, key *rsa.PrivateKey, claims map[string]interface{}) string {
+	t.Helper()
+	enc := func(v interface{}) string {
+		b, err := json.Marshal(v)
+		require.NoError(t, err)
+		return base64.RawURLEncoding.EncodeToString(b)
+	}
+	signingInput := enc(map[string]string{"alg": "RS256", "typ": "JWT"}) + "." + enc(claims)
+	digest := sha256.Sum256([]byte(signingInput))
+	sig, err := rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, digest[:])
+	require.NoError(t, err)
+	return signingInput + "." + base64.RawURLEncoding.EncodeToString(sig)
+}
+
+func TestVerifyIDToken(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	idp := &identityProvider{
+		issuer: "https://issuer.example",
+		keySet: &oidc.StaticKeySet{PublicKeys: []crypto.PublicKey{&key.PublicKey}},
+	}
+	claims := map[string]interface{}{
+		"iss":   "https://issuer.example",
+		"aud":   "client",
+		"sub":   "user-123",
+		"email": "a@example.com",
+		"exp":   time.Now().Add(time.Hour).Unix(),
+	}
+	token := (&oauth2.Token{AccessToken: "x"}).WithExtra(map[string]interface{}{
+		"id_token": signedIDToken(t, key, claims),
+	})
+
+	got, err := idp.verifyIDToken(context.Background(), token, "client")
+	require.NoError(t, err)
+	assert.Equal(t, "a@example.com", got.Email)
+	assert.Equal(t, "user-123", got.Subject)
+
+	_, err = idp.verifyIDToken(context.Background(), token, "other-client")
+	assert.ErrorContains(t, err, "ID token validation failed")
+
+	claims["exp"] = time.Now().Add(-time.Hour).Unix()
+	expired := (&oauth2.Token{AccessToken: "x"}).WithExtra(map[string]interface{}{
+		"id_token": signedIDToken(t, key, claims),
+	})
+	_, err = idp.verifyIDToken(context.Background(), expired, "client")
+	assert.Error(t, err)
+
+	// No ID token (client credentials) is not an error.
+	got, err = idp.verifyIDToken(context.Background(), &oauth2.Token{AccessToken: "x"}, "client")
+	require.NoError(t, err)
+	assert.Nil(t, got)
+
+	// Without signing keys, the ID token can't be validated: that fails
+	// the login unless explicitly skipped.
+	offline := &identityProvider{keysErr: errors.New("connection refused")}
+	_, err = offline.verifyIDToken(context.Background(), token, "client")
+	assert.ErrorContains(t, err, "can't validate the ID token")
+	t.Setenv(SkipIDTokenVerifyEnv, "true")
+	got, err = offline.verifyIDToken(context.Background(), token, "client")
+	require.NoError(t, err)
+	assert.Nil(t, got)
+}
@@ -0,0 +1,145 @@
+// Copyright (c) Kusari <https://www.kusari.dev/>
+// SPDX-License-Identifier: MIT
+
+package auth
+
+import (
+	"context"
+	"os"
+	"sync"
+
+	"github.com/kusaridev/kusari-cli/v2/pkg/constants"
+	"golang.org/x/oauth2"
+	"golang.org/x/oauth2/clientcredentials"
+)
+
+// Environment variables that select a non-interactive TokenProvider.
+const (
+	APIKeyEnv       = "KUSARI_API_KEY"
+	ClientIDEnv     = "KUSARI_CLIENT_ID"
+	ClientSecretEnv = "KUSARI_CLIENT_SECRET"
+	AuthEndpointEnv = "KUSARI_AUTH_ENDPOINT"
+)
+
+// TokenProvider supplies the access token used for Kusari platform calls.
+type TokenProvider interface {
+	// Token returns a valid (unexpired) token.
+	Token(ctx context.Context) (*oauth2.Token, error)
+	// Machine reports whether the token belongs to a non-interactive
+	// (API key / CI) identity rather than a logged-in user.
+	Machine() bool
+	// Name describes where the token comes from, for status output.
+	Name() string
+}
+
+var (
+	defaultProvider     TokenProvider
+	defaultProviderOnce sync.Once
+)
+
+// DefaultTokenProvider returns the provider all commands use, chosen once per
+// process from the environment:
+//
+//   - KUSARI_API_KEY – a static, long-lived API key (no OAuth exchange)
+//   - KUSARI_CLIENT_ID + KUSARI_CLIENT_SECRET – an OAuth client-credentials
+//     exchange against KUSARI_AUTH_ENDPOINT, cached for the process
+//   - otherwise the token stored by `kusari auth login`
+func DefaultTokenProvider() TokenProvider {
+	defaultProviderOnce.Do(func() {
+		defaultProvider = tokenProviderFromEnv(os.Getenv)
+	})
+	return defaultProvider
+}
+
+func tokenProviderFromEnv(getenv func(string) string) TokenProvider {
+	if key := getenv(APIKeyEnv); key != "" {
+		return staticTokenProvider{key: key}
+	}
+
+	clientID, clientSecret := getenv(ClientIDEnv), getenv(ClientSecretEnv)
+	if clientID != "" && clientSecret != "" {
+		authEndpoint := getenv(AuthEndpointEnv)
+		if authEndpoint == "" {
+			authEndpoint = constants.DefaultAuthEndpoint
+		}
+		return newClientCredentialsProvider(clientID, clientSecret, authEndpoint)
+	}
+
+	return storedTokenProvider{}
+}
+
+// storedTokenProvider reads the token saved by `kusari auth login`.
+type storedTokenProvider struct{}
+
+func (storedTokenProvider) Token(_ context.Context) (*oauth2.Token, error) {
+	token, err := LoadToken(activeTokenKey)
+	if err != nil {
+		return nil, err
+	}
+	if err := CheckTokenExpiry(token); err != nil {
+		return nil, err
+	}
+	return token, nil
+}
+
+func (storedTokenProvider) Machine() bool { return false }
+
+func (storedTokenProvider) Name() string { return "stored login (~/.kusari/tokens.json)" }
+
+// staticTokenProvider uses a long-lived API key as the bearer token.
+type staticTokenProvider struct {
+	key string
+}
+
+func (p staticTokenProvider) Token(_ context.Context) (*oauth2.Token, error) {
+	return &oauth2.Token{AccessToken: p.key, TokenType: "Bearer"}, nil
+}
+
+func (staticTokenProvider) Machine() bool { return true }
+
+func (staticTokenProvider) Name() string { return APIKeyEnv }
+
+// clientCredentialsProvider exchanges a client ID and secret for a token on
+// first use and reuses it until it expires.
+type clientCredentialsProvider struct {
+	clientID     string
+	authEndpoint string
+	config       clientcredentials.Config
+
+	mu     sync.Mutex
+	source oauth2.TokenSource
+}
+
+func newClientCredentialsProvider(clientID, clientSecret, authEndpoint string) *clientCredentialsProvider {
+	return &clientCredentialsProvider{
+		clientID:     clientID,
+		authEndpoint: authEndpoint,
+		config: clientcredentials.Config{
+			ClientID:     clientID,
+			ClientSecret: clientSecret,
+		},
+	}
+}
+
+func (p *clientCredentialsProvider) Token(ctx context.Context) (*oauth2.Token, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.source == nil {
+		p.config.TokenURL = discoverIdentityProvider(ctx, p.authEndpoint).endpoint.TokenURL
+		// The source outlives this call, so don't tie it to ctx's lifetime.
+		p.source = oauth2.ReuseTokenSource(nil, p.config.TokenSource(context.WithoutCancel(ctx)))
+	}
+
+	token, err := p.source.Token()
+	if err != nil {
+		return nil, NewAuthErrorWithCause(ErrAuthFlow, "failed to exchange client credentials", err)
+	}
+	return token, nil
+}
+
+func (p *clientCredentialsProvider) Machine() bool { return true }
+
+func (p *clientCredentialsProvider) Name() string {
+	return "client credentials (" + ClientIDEnv + "=" + p.clientID + ")"
+}
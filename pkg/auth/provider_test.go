@@ -0,0 +1,81 @@
+// Copyright (c) Kusari <https://www.kusari.dev/>
+// SPDX-License-Identifier: MIT
+
+package auth
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/oauth2"
+)
+
+func envMap(m map[string]string) func(string) string {
+	return func(k string) string { return m[k] }
+}
+
+func TestTokenProviderFromEnv(t *testing.T) {
+	p := tokenProviderFromEnv(envMap(map[string]string{APIKeyEnv: "key", ClientIDEnv: "id", ClientSecretEnv: "secret"}))
+	assert.IsType(t, staticTokenProvider{}, p)
+	assert.True(t, p.Machine())
+
+	p = tokenProviderFromEnv(envMap(map[string]string{ClientIDEnv: "id", ClientSecretEnv: "secret"}))
+	assert.IsType(t, &clientCredentialsProvider{}, p)
+	assert.True(t, p.Machine())
+
+	p = tokenProviderFromEnv(envMap(map[string]string{ClientIDEnv: "id"}))
+	assert.IsType(t, storedTokenProvider{}, p)
+	assert.False(t, p.Machine())
+}
+
+func TestStaticTokenProvider(t *testing.T) {
+	token, err := staticTokenProvider{key: "kusari_api_key"}.Token(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, "kusari_api_key", token.AccessToken)
+	assert.True(t, token.Valid())
+}
+
+func TestClientCredentialsProvider_ReusesToken(t *testing.T) {
+	var exchanges atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/oauth2/token" {
+			http.NotFound(w, r)
+			return
+		}
+		exchanges.Add(1)
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{
+			"access_token": "machine-token",
+			"token_type":   "Bearer",
+			"expires_in":   3600,
+		})
+	}))
+	defer server.Close()
+
+	p := newClientCredentialsProvider("id", "secret", server.URL+"/")
+	for range 3 {
+		token, err := p.Token(context.Background())
+		require.NoError(t, err)
+		assert.Equal(t, "machine-token", token.AccessToken)
+	}
+	assert.Equal(t, int32(1), exchanges.Load())
+}
+
+func TestStoredTokenProvider_Expired(t *testing.T) {
+	setupTokenHome(t)
+
+	_, err := storedTokenProvider{}.Token(context.Background())
+	require.Error(t, err)
+	assert.False(t, IsTokenExpired(err), "no login is not an expired one")
+
+	require.NoError(t, SaveToken(&oauth2.Token{AccessToken: "x", Expiry: time.Now().Add(-time.Hour)}, activeTokenKey))
+	_, err = storedTokenProvider{}.Token(context.Background())
+	assert.True(t, IsTokenExpired(err))
+}
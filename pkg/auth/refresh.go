@@ -0,0 +1,103 @@
+// Copyright (c) Kusari <https://www.kusari.dev/>
+// SPDX-License-Identifier: MIT
+
+package auth
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"golang.org/x/oauth2"
+)
+
+// Bounds on the delay between failed refresh attempts in KeepAlive.
+const (
+	minRefreshRetry = 30 * time.Second
+	maxRefreshRetry = 10 * time.Minute
+)
+
+// RefreshStoredToken exchanges the stored refresh token for a new access
+// token, using the auth endpoint and client ID of the active identity, and
+// saves the result.
+func RefreshStoredToken(ctx context.Context) (*oauth2.Token, error) {
+	token, err := LoadToken(activeTokenKey)
+	if err != nil {
+		return nil, err
+	}
+	if token.RefreshToken == "" {
+		return nil, NewAuthError(ErrInvalidToken, "stored token has no refresh token. Re-run `kusari auth login`")
+	}
+
+	id, err := ActiveIdentity()
+	if err != nil {
+		return nil, err
+	}
+	if id == nil {
+		return nil, NewAuthError(ErrInvalidToken, "no identity recorded for the stored token. Re-run `kusari auth login`")
+	}
+
+	idp := discoverIdentityProvider(ctx, id.AuthEndpoint)
+	config := oauthConfig(id.ClientID, "", idp.endpoint)
+
+	// An empty access token forces the source to refresh. golang.org/x/oauth2
+	// keeps the old refresh token if the IdP doesn't rotate it.
+	refreshed, err := config.TokenSource(ctx, &oauth2.Token{RefreshToken: token.RefreshToken}).Token()
+	if err != nil {
+		return nil, NewAuthErrorWithCause(ErrTokenExpired, "failed to refresh token", err)
+	}
+
+	if err := SaveToken(refreshed, ""); err != nil {
+		return nil, err
+	}
+	return refreshed, nil
+}
+
+// KeepAlive refreshes the stored token lead before it expires, repeatedly,
+// until ctx is cancelled. Failed refreshes are retried with backoff and
+// reported through logf.
+func KeepAlive(ctx context.Context, lead time.Duration, logf func(format string, args ...any)) error {
+	retry := minRefreshRetry
+	for {
+		token, err := LoadToken(activeTokenKey)
+		if err != nil {
+			return err
+		}
+
+		if token.Expiry.IsZero() {
+			return fmt.Errorf("stored token does not expire; nothing to keep alive")
+		}
+		if wait := time.Until(token.Expiry) - lead; wait > 0 {
+			logf("Next refresh at %s\n", time.Now().Add(wait).Format(time.RFC3339))
+			if err := sleepContext(ctx, wait); err != nil {
+				return nil
+			}
+		}
+
+		refreshed, err := RefreshStoredToken(ctx)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			logf("Token refresh failed, retrying in %s: %v\n", retry, err)
+			if err := sleepContext(ctx, retry); err != nil {
+				return nil
+			}
+			retry = min(retry*2, maxRefreshRetry)
+			continue
+		}
+		retry = minRefreshRetry
+		logf("Token refreshed; valid until %s\n", refreshed.Expiry.Format(time.RFC3339))
+	}
+}
+
+func sleepContext(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
@@ -0,0 +1,60 @@
+// Copyright (c) Kusari <https://www.kusari.dev/>
+// SPDX-License-Identifier: MIT
+
+package auth
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/oauth2"
+)
+
+func TestRefreshStoredToken(t *testing.T) {
+	setupTokenHome(t)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/oauth2/token" {
+			http.NotFound(w, r)
+			return
+		}
+		require.NoError(t, r.ParseForm())
+		assert.Equal(t, "refresh_token", r.Form.Get("grant_type"))
+		assert.Equal(t, "refresh-1", r.Form.Get("refresh_token"))
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{
+			"access_token": "fresh",
+			"token_type":   "Bearer",
+			"expires_in":   3600,
+		})
+	}))
+	defer server.Close()
+
+	stale := &oauth2.Token{AccessToken: "stale", RefreshToken: "refresh-1", Expiry: time.Now().Add(-time.Minute)}
+	_, err := SaveIdentity(stale, server.URL+"/", "client", nil)
+	require.NoError(t, err)
+
+	refreshed, err := RefreshStoredToken(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, "fresh", refreshed.AccessToken)
+
+	stored, err := LoadToken("kusari")
+	require.NoError(t, err)
+	assert.Equal(t, "fresh", stored.AccessToken)
+	assert.Equal(t, "refresh-1", stored.RefreshToken, "refresh token is kept when the IdP doesn't rotate it")
+	assert.NoError(t, CheckTokenExpiry(stored))
+}
+
+func TestRefreshStoredToken_NoRefreshToken(t *testing.T) {
+	setupTokenHome(t)
+	require.NoError(t, SaveToken(&oauth2.Token{AccessToken: "x"}, ""))
+
+	_, err := RefreshStoredToken(context.Background())
+	assert.ErrorContains(t, err, "no refresh token")
+}
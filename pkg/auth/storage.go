@@ -28,6 +28,11 @@ func getConfigDir() (string, error) {
 	return filepath.Join(homeDir, configDirName), nil
 }
 
+// ConfigDir returns the directory holding the CLI's local state (~/.kusari).
+func ConfigDir() (string, error) {
+	return getConfigDir()
+}
+
 // getTokenFilePath returns the full path to the token file
 func getTokenFilePath() (string, error) {
 	configDir, err := getConfigDir()
@@ -37,9 +42,37 @@ func getTokenFilePath() (string, error) {
 	return filepath.Join(configDir, tokenFileName), nil
 }
 
-// SaveToken saves the token information to disk
-func SaveToken(token *oauth2.Token, provider string) error {
-	// func SaveToken(token *TokenInfo) error {
+// readTokenFile loads the token map from disk, decrypting it if needed.
+// It returns the encryption the file was stored with (EncryptionNone for
+// plaintext) and an error satisfying os.IsNotExist when there is no file.
+func readTokenFile() (map[string]*oauth2.Token, TokenEncryption, error) {
+	tokenPath, err := getTokenFilePath()
+	if err != nil {
+		return nil, "", err
+	}
+
+	data, err := os.ReadFile(tokenPath)
+	if err != nil {
+		return nil, "", err
+	}
+
+	stored := EncryptionNone
+	if isEncryptedTokenFile(data) {
+		data, stored, err = decryptTokenData(data)
+		if err != nil {
+			return nil, "", err
+		}
+	}
+
+	var tokens map[string]*oauth2.Token
+	if err := json.Unmarshal(data, &tokens); err != nil {
+		return nil, "", NewAuthErrorWithCause(ErrTokenStorage, "failed to unmarshal tokens", err)
+	}
+	return tokens, stored, nil
+}
+
+// writeTokenFile stores the token map on disk, encrypted according to mode.
+func writeTokenFile(tokens map[string]*oauth2.Token, mode TokenEncryption) error {
 	configDir, err := getConfigDir()
 	if err != nil {
 		return err
@@ -55,28 +88,18 @@ func SaveToken(token *oauth2.Token, provider string) error {
 		return err
 	}
 
-	// Load existing tokens
-	var tokens map[string]*oauth2.Token
-	if data, err := os.ReadFile(tokenPath); err == nil {
-		if err := json.Unmarshal(data, &tokens); err != nil {
-			return fmt.Errorf("error, found token file, but did not unmarshal: %w", err)
-		}
-	}
-	if tokens == nil {
-		// tokens = make(map[string]*TokenInfo)
-		tokens = make(map[string]*oauth2.Token)
-	}
-
-	// Store the new token
-	tokens["kusari"] = token
-	// tokens[provider] = token
-
-	// Write back to file
 	data, err := json.MarshalIndent(tokens, "", "  ")
 	if err != nil {
 		return NewAuthErrorWithCause(ErrTokenStorage, "failed to marshal tokens", err)
 	}
 
+	if mode != EncryptionNone {
+		data, err = encryptTokenData(data, mode)
+		if err != nil {
+			return err
+		}
+	}
+
 	if err := os.WriteFile(tokenPath, data, 0600); err != nil {
 		return NewAuthErrorWithCause(ErrTokenStorage, "failed to write token file", err)
 	}
@@ -84,25 +107,51 @@ func SaveToken(token *oauth2.Token, provider string) error {
 	return nil
 }
 
-// LoadToken loads token information from disk
-func LoadToken(provider string) (*oauth2.Token, error) {
-	// func LoadToken(provider string) (*TokenInfo, error) {
-	tokenPath, err := getTokenFilePath()
-	if err != nil {
-		return nil, err
+// SaveToken saves the token information to disk
+func SaveToken(token *oauth2.Token, provider string) error {
+	// Load existing tokens
+	tokens, stored, err := readTokenFile()
+	if err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("error, found token file, but could not read it: %w", err)
+	}
+	if tokens == nil {
+		tokens = make(map[string]*oauth2.Token)
 	}
 
-	data, err := os.ReadFile(tokenPath)
+	// Store the new token
+	tokens[activeTokenKey] = token
+	syncActiveIdentityToken(tokens, token)
+
+	return writeTokenFile(tokens, saveEncryptionMode(stored))
+}
+
+// saveEncryptionMode picks the mode for rewriting a file that was stored
+// with stored. An encrypted file is never silently downgraded to plaintext.
+func saveEncryptionMode(stored TokenEncryption) TokenEncryption {
+	mode := tokenEncryptionMode()
+	if mode == EncryptionNone && stored != "" {
+		return stored
+	}
+	return mode
+}
+
+// LoadToken loads token information from disk. If encryption is configured
+// and the file is still plaintext, it is transparently re-written encrypted.
+func LoadToken(provider string) (*oauth2.Token, error) {
+	tokens, stored, err := readTokenFile()
 	if err != nil {
 		if os.IsNotExist(err) {
 			return nil, NewAuthError(ErrInvalidToken, "no stored tokens found. Run `kusari auth login`.")
 		}
+		if _, ok := err.(*AuthError); ok {
+			return nil, err
+		}
 		return nil, NewAuthErrorWithCause(ErrTokenStorage, "failed to read token file", err)
 	}
 
-	var tokens map[string]*oauth2.Token
-	if err := json.Unmarshal(data, &tokens); err != nil {
-		return nil, NewAuthErrorWithCause(ErrTokenStorage, "failed to unmarshal tokens", err)
+	if mode := tokenEncryptionMode(); mode != EncryptionNone && stored == EncryptionNone {
+		// Best-effort migration; the tokens are still usable if it fails.
+		_ = writeTokenFile(tokens, mode)
 	}
 
 	token, exists := tokens[provider]
@@ -138,10 +187,11 @@ func CheckTokenExpiry(token *oauth2.Token) error {
 type WorkspaceInfo struct {
 	ID           string `json:"id"`
 	Description  string `json:"description"`
-	PlatformUrl  string `json:"platformUrl"`  // Track which platform this workspace belongs to
-	AuthEndpoint string `json:"authEndpoint"` // Track which auth endpoint this workspace belongs to
-	Tenant       string `json:"tenant"`       // The tenant name (e.g., "demo")
-	IsMachine    bool   `json:"isMachine"`    // True when authenticated with client credentials (API/CI mode)
+	PlatformUrl  string `json:"platformUrl"`      // Track which platform this workspace belongs to
+	AuthEndpoint string `json:"authEndpoint"`     // Track which auth endpoint this workspace belongs to
+	Tenant       string `json:"tenant"`           // The tenant name (e.g., "demo")
+	Region       string `json:"region,omitempty"` // The tenant's region (e.g., "us"); DefaultRegion when empty
+	IsMachine    bool   `json:"isMachine"`        // True when authenticated with client credentials (API/CI mode)
 }
 
 // getWorkspaceFilePath returns the full path to the workspace file
@@ -153,8 +203,16 @@ func getWorkspaceFilePath() (string, error) {
 	return filepath.Join(configDir, workspaceFileName), nil
 }
 
-// SaveWorkspace saves the selected workspace to disk
+// SaveWorkspace saves the selected workspace to disk and records it on the
+// active identity.
 func SaveWorkspace(workspace WorkspaceInfo) error {
+	if err := writeWorkspaceFile(workspace); err != nil {
+		return err
+	}
+	return rememberWorkspace(workspace)
+}
+
+func writeWorkspaceFile(workspace WorkspaceInfo) error {
 	configDir, err := getConfigDir()
 	if err != nil {
 		return err
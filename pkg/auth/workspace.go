@@ -9,14 +9,34 @@ import (
 	"os"
 	"strconv"
 	"strings"
+
+	"github.com/kusaridev/kusari-cli/v2/pkg/output"
+)
+
+// Environment variables that answer the workspace and tenant prompts, so
+// login and workspace selection can run without a terminal.
+const (
+	WorkspaceEnv = "KUSARI_WORKSPACE"
+	TenantEnv    = "KUSARI_TENANT"
 )
 
-// SelectWorkspace prompts the user to select a workspace from a list
+// SelectWorkspace prompts the user to select a workspace from a list.
+// KUSARI_WORKSPACE, when set, answers the prompt. Without it, selecting from
+// several workspaces fails in non-interactive mode.
 func SelectWorkspace(workspaces []WorkspaceInfo) (*WorkspaceInfo, error) {
 	if len(workspaces) == 0 {
 		return nil, fmt.Errorf("no workspaces available")
 	}
 
+	if ref := os.Getenv(WorkspaceEnv); ref != "" {
+		selected, err := FindWorkspace(workspaces, ref)
+		if err != nil {
+			return nil, err
+		}
+		fmt.Printf("Selected workspace from %s: %s\n", WorkspaceEnv, selected.Description)
+		return selected, nil
+	}
+
 	// If there's only one workspace, auto-select it
 	if len(workspaces) == 1 {
 		fmt.Printf("You only have one workspace available: %s\n", workspaces[0].Description)
@@ -24,6 +44,10 @@ func SelectWorkspace(workspaces []WorkspaceInfo) (*WorkspaceInfo, error) {
 		return &workspaces[0], nil
 	}
 
+	if !output.Interactive() {
+		return nil, NewAuthError(ErrInteractionRequired, fmt.Sprintf("%d workspaces available and no terminal to prompt on: set %s to a workspace ID or name", len(workspaces), WorkspaceEnv))
+	}
+
 	// Display available workspaces
 	fmt.Println("\nAvailable workspaces:")
 	for i, ws := range workspaces {
@@ -52,12 +76,23 @@ func SelectWorkspace(workspaces []WorkspaceInfo) (*WorkspaceInfo, error) {
 	}
 }
 
-// SelectTenant prompts the user to select a tenant from a list
+// SelectTenant prompts the user to select a tenant from a list.
+// KUSARI_TENANT, when set, answers the prompt. Without it, selecting from
+// several tenants fails in non-interactive mode.
 func SelectTenant(tenants []string) (string, error) {
 	if len(tenants) == 0 {
 		return "", fmt.Errorf("no tenants available")
 	}
 
+	if ref := os.Getenv(TenantEnv); ref != "" {
+		selected, err := FindTenant(tenants, ref)
+		if err != nil {
+			return "", err
+		}
+		fmt.Printf("Selected tenant from %s: %s\n", TenantEnv, selected)
+		return selected, nil
+	}
+
 	// If there's only one tenant, auto-select it
 	if len(tenants) == 1 {
 		fmt.Printf("You only have one tenant available: %s\n", tenants[0])
@@ -65,6 +100,10 @@ func SelectTenant(tenants []string) (string, error) {
 		return tenants[0], nil
 	}
 
+	if !output.Interactive() {
+		return "", NewAuthError(ErrInteractionRequired, fmt.Sprintf("%d tenants available and no terminal to prompt on: set %s to a tenant name", len(tenants), TenantEnv))
+	}
+
 	// Display available tenants
 	fmt.Println("\nAvailable tenants:")
 	for i, tenant := range tenants {
@@ -92,3 +131,36 @@ func SelectTenant(tenants []string) (string, error) {
 		return selected, nil
 	}
 }
+
+// FindWorkspace returns the workspace whose ID or description is ref.
+// Descriptions match case-insensitively and must be unambiguous.
+func FindWorkspace(workspaces []WorkspaceInfo, ref string) (*WorkspaceInfo, error) {
+	var matches []*WorkspaceInfo
+	for i := range workspaces {
+		ws := &workspaces[i]
+		if ws.ID == ref {
+			return ws, nil
+		}
+		if strings.EqualFold(ws.Description, ref) {
+			matches = append(matches, ws)
+		}
+	}
+	switch len(matches) {
+	case 0:
+		return nil, fmt.Errorf("no workspace matches %q", ref)
+	case 1:
+		return matches[0], nil
+	default:
+		return nil, fmt.Errorf("%q matches %d workspaces; use the workspace ID", ref, len(matches))
+	}
+}
+
+// FindTenant returns the tenant named ref, compared case-insensitively.
+func FindTenant(tenants []string, ref string) (string, error) {
+	for _, t := range tenants {
+		if strings.EqualFold(t, ref) {
+			return t, nil
+		}
+	}
+	return "", fmt.Errorf("no tenant matches %q (available: %s)", ref, strings.Join(tenants, ", "))
+}
@@ -0,0 +1,73 @@
+// Copyright (c) Kusari <https://www.kusari.dev/>
+// SPDX-License-Identifier: MIT
+
+package auth
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/kusaridev/kusari-cli/v2/pkg/output"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFindWorkspace(t *testing.T) {
+	workspaces := []WorkspaceInfo{
+		{ID: "ws1", Description: "Production"},
+		{ID: "ws2", Description: "Staging"},
+		{ID: "ws3", Description: "staging"},
+	}
+
+	ws, err := FindWorkspace(workspaces, "ws2")
+	require.NoError(t, err)
+	assert.Equal(t, "ws2", ws.ID)
+
+	ws, err = FindWorkspace(workspaces, "production")
+	require.NoError(t, err)
+	assert.Equal(t, "ws1", ws.ID)
+
+	_, err = FindWorkspace(workspaces, "Staging")
+	assert.ErrorContains(t, err, "matches 2 workspaces")
+
+	_, err = FindWorkspace(workspaces, "dev")
+	assert.ErrorContains(t, err, "no workspace matches")
+}
+
+func TestSelectWorkspace_NonInteractive(t *testing.T) {
+	output.SetNonInteractive(true)
+	t.Cleanup(func() { output.SetNonInteractive(false) })
+
+	workspaces := []WorkspaceInfo{{ID: "ws1", Description: "One"}, {ID: "ws2", Description: "Two"}}
+
+	t.Setenv(WorkspaceEnv, "")
+	_, err := SelectWorkspace(workspaces)
+	var authErr *AuthError
+	require.True(t, errors.As(err, &authErr))
+	assert.Equal(t, ErrInteractionRequired, authErr.Code)
+
+	t.Setenv(WorkspaceEnv, "two")
+	ws, err := SelectWorkspace(workspaces)
+	require.NoError(t, err)
+	assert.Equal(t, "ws2", ws.ID)
+}
+
+func TestSelectTenant_NonInteractive(t *testing.T) {
+	output.SetNonInteractive(true)
+	t.Cleanup(func() { output.SetNonInteractive(false) })
+
+	t.Setenv(TenantEnv, "")
+	_, err := SelectTenant([]string{"demo", "prod"})
+	var authErr *AuthError
+	require.True(t, errors.As(err, &authErr))
+	assert.Equal(t, ErrInteractionRequired, authErr.Code)
+
+	t.Setenv(TenantEnv, "PROD")
+	tenant, err := SelectTenant([]string{"demo", "prod"})
+	require.NoError(t, err)
+	assert.Equal(t, "prod", tenant)
+
+	t.Setenv(TenantEnv, "other")
+	_, err = SelectTenant([]string{"demo", "prod"})
+	assert.ErrorContains(t, err, "available: demo, prod")
+}
@@ -0,0 +1,171 @@
+// Copyright (c) Kusari <https://www.kusari.dev/>
+// SPDX-License-Identifier: MIT
+
+// Package ci renders CI pipeline definitions that run the Kusari CLI, and
+// reads the context of the pipeline the CLI runs in.
+package ci
+
+import (
+	"bytes"
+	"embed"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"text/template"
+)
+
+//go:embed templates/*.tmpl
+var templateFS embed.FS
+
+// Platform is a CI system a pipeline can be generated for.
+type Platform string
+
+const (
+	GitHub  Platform = "github"
+	GitLab  Platform = "gitlab"
+	Azure   Platform = "azure"
+	Jenkins Platform = "jenkins"
+)
+
+// Platforms lists every supported Platform.
+var Platforms = []Platform{GitHub, GitLab, Azure, Jenkins}
+
+// DefaultNightlyCron is the schedule used for NightlyScan when none is given.
+const DefaultNightlyCron = "0 3 * * *"
+
+var templateFiles = map[Platform]string{
+	GitHub:  "templates/github.yml.tmpl",
+	GitLab:  "templates/gitlab.yml.tmpl",
+	Azure:   "templates/azure.yml.tmpl",
+	Jenkins: "templates/jenkins.tmpl",
+}
+
+// Options parameterize a rendered pipeline.
+type Options struct {
+	// CLIVersion is the kusari-cli version the pipeline installs, so
+	// pipelines don't change behavior underneath you. Empty or "dev"
+	// installs the latest release.
+	CLIVersion string
+
+	// PRScan runs a diff scan (kusari repo scan) on pull/merge requests.
+	PRScan bool
+	// NightlyScan runs a full scan (kusari repo risk-check) on a schedule.
+	NightlyScan bool
+	// ReleaseSBOM generates and uploads an SBOM when a release is tagged.
+	ReleaseSBOM bool
+
+	// NightlyCron is the schedule for NightlyScan (default DefaultNightlyCron).
+	NightlyCron string
+	// Tenant is written into the pipeline for SBOM upload; when empty the
+	// pipeline reads a KUSARI_TENANT CI variable.
+	Tenant string
+}
+
+// templateData is what the templates see.
+type templateData struct {
+	Options
+	Version string
+}
+
+// TenantOr returns the configured tenant, or fallback (a CI variable
+// reference) when none was given.
+func (d templateData) TenantOr(fallback string) string {
+	if d.Tenant != "" {
+		return d.Tenant
+	}
+	return fallback
+}
+
+// pseudoVersion matches Go pseudo-versions (v0.0.0-20060102150405-abcdef123456)
+// reported by local builds; those can't be installed by other machines.
+var pseudoVersion = regexp.MustCompile(`\d{14}-[0-9a-f]{12}`)
+
+// installVersion returns the `go install` version suffix for CLIVersion.
+func (o Options) installVersion() string {
+	v := o.CLIVersion
+	if v == "" || v == "dev" || strings.Contains(v, "+") || pseudoVersion.MatchString(v) {
+		return "latest"
+	}
+	if !strings.HasPrefix(v, "v") {
+		v = "v" + v
+	}
+	return v
+}
+
+// DefaultPath returns where the pipeline file for p conventionally lives.
+func DefaultPath(p Platform) string {
+	switch p {
+	case GitHub:
+		return filepath.Join(".github", "workflows", "kusari.yml")
+	case GitLab:
+		return ".gitlab-ci.yml"
+	case Azure:
+		return "azure-pipelines.yml"
+	case Jenkins:
+		return "Jenkinsfile"
+	default:
+		return ""
+	}
+}
+
+// Render returns the pipeline for p running the workflows selected in opts.
+func Render(p Platform, opts Options) ([]byte, error) {
+	name, ok := templateFiles[p]
+	if !ok {
+		return nil, fmt.Errorf("unsupported CI platform %q (must be one of %s)", p, joinPlatforms())
+	}
+	if !opts.PRScan && !opts.NightlyScan && !opts.ReleaseSBOM {
+		return nil, fmt.Errorf("no workflows selected: enable at least one of PR scan, nightly scan, or release SBOM")
+	}
+	if opts.NightlyCron == "" {
+		opts.NightlyCron = DefaultNightlyCron
+	}
+
+	// [[ ]] delimiters leave the CI systems' own ${{ }} and $() syntax untouched.
+	tmpl, err := template.New(filepath.Base(name)).Delims("[[", "]]").ParseFS(templateFS, name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse %s pipeline template: %w", p, err)
+	}
+
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, templateData{Options: opts, Version: opts.installVersion()}); err != nil {
+		return nil, fmt.Errorf("failed to render %s pipeline: %w", p, err)
+	}
+	return buf.Bytes(), nil
+}
+
+func joinPlatforms() string {
+	names := make([]string, len(Platforms))
+	for i, p := range Platforms {
+		names[i] = string(p)
+	}
+	return strings.Join(names, ", ")
+}
+
+// Detect guesses the CI platform for the repository in dir from existing
+// pipeline files and the origin remote. It returns "" when unsure.
+func Detect(dir string) Platform {
+	if _, err := os.Stat(filepath.Join(dir, ".gitlab-ci.yml")); err == nil {
+		return GitLab
+	}
+	if _, err := os.Stat(filepath.Join(dir, ".github", "workflows")); err == nil {
+		return GitHub
+	}
+
+	cmd := exec.Command("git", "remote", "get-url", "origin")
+	cmd.Dir = dir
+	remote, err := cmd.Output()
+	if err != nil {
+		return ""
+	}
+	switch {
+	case strings.Contains(string(remote), "github"):
+		return GitHub
+	case strings.Contains(string(remote), "gitlab"):
+		return GitLab
+	}
+	return ""
+}
@@ -0,0 +1,114 @@
+// Copyright (c) Kusari <https://www.kusari.dev/>
+// SPDX-License-Identifier: MIT
+
+package ci
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+)
+
+func TestRender(t *testing.T) {
+	out, err := Render(GitHub, Options{CLIVersion: "v2.3.4", PRScan: true})
+	require.NoError(t, err)
+	assert.Contains(t, string(out), "kusari-cli/v2/kusari@v2.3.4")
+	assert.Contains(t, string(out), "${{ secrets.KUSARI_CLIENT_ID }}")
+
+	out, err = Render(GitLab, Options{CLIVersion: "dev", PRScan: true})
+	require.NoError(t, err)
+	assert.Contains(t, string(out), "kusari-cli/v2/kusari@latest")
+	assert.Contains(t, string(out), "--comment gitlab")
+
+	for version, want := range map[string]string{
+		"2.1.0": "@v2.1.0",
+		"v2.0.0-20261014082357-d7f4db397247+dirty": "@latest",
+		"": "@latest",
+	} {
+		out, err := Render(GitHub, Options{CLIVersion: version, PRScan: true})
+		require.NoError(t, err)
+		assert.Contains(t, string(out), "kusari-cli/v2/kusari"+want, version)
+	}
+
+	_, err = Render("travis", Options{PRScan: true})
+	assert.ErrorContains(t, err, "unsupported CI platform")
+}
+
+func TestDetect(t *testing.T) {
+	dir := t.TempDir()
+	assert.Equal(t, Platform(""), Detect(dir))
+
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, ".github", "workflows"), 0755))
+	assert.Equal(t, GitHub, Detect(dir))
+
+	require.NoError(t, os.WriteFile(filepath.Join(dir, ".gitlab-ci.yml"), nil, 0644))
+	assert.Equal(t, GitLab, Detect(dir))
+}
+
+func TestRender_AllPlatformsAndWorkflows(t *testing.T) {
+	for _, p := range Platforms {
+		for _, opts := range []Options{
+			{PRScan: true},
+			{NightlyScan: true},
+			{ReleaseSBOM: true, Tenant: "demo"},
+			{PRScan: true, NightlyScan: true, ReleaseSBOM: true, NightlyCron: "15 1 * * 0"},
+		} {
+			opts.CLIVersion = "v2.0.0"
+			out, err := Render(p, opts)
+			require.NoError(t, err, p)
+			assert.Contains(t, string(out), "@v2.0.0", p)
+			assert.NotContains(t, string(out), "[[", p)
+			assert.Equal(t, opts.PRScan, strings.Contains(string(out), "kusari repo scan"), p)
+			assert.Equal(t, opts.NightlyScan, strings.Contains(string(out), "kusari repo risk-check"), p)
+			assert.Equal(t, opts.ReleaseSBOM, strings.Contains(string(out), "kusari platform generate --upload"), p)
+			if opts.NightlyCron != "" {
+				assert.Contains(t, string(out), opts.NightlyCron, p)
+			}
+
+			if p != Jenkins {
+				var doc map[string]interface{}
+				require.NoError(t, yaml.Unmarshal(out, &doc), "%s renders invalid YAML:\n%s", p, out)
+			}
+		}
+	}
+
+	_, err := Render(GitHub, Options{})
+	assert.ErrorContains(t, err, "no workflows selected")
+}
+
+func TestRender_GitHubRefsNotInRun(t *testing.T) {
+	out, err := Render(GitHub, Options{CLIVersion: "v2.0.0", PRScan: true, NightlyScan: true, ReleaseSBOM: true})
+	require.NoError(t, err)
+
+	var doc struct {
+		Jobs map[string]struct {
+			Steps []struct {
+				Env map[string]string `yaml:"env"`
+				Run string            `yaml:"run"`
+			} `yaml:"steps"`
+		} `yaml:"jobs"`
+	}
+	require.NoError(t, yaml.Unmarshal(out, &doc))
+
+	var scan bool
+	for name, job := range doc.Jobs {
+		for _, step := range job.Steps {
+			// Branch names are attacker controlled; expanding them into the
+			// script would let a PR inject shell.
+			assert.NotContains(t, step.Run, "${{ github.head_ref }}", name)
+			assert.NotContains(t, step.Run, "${{ github.base_ref }}", name)
+			if strings.Contains(step.Run, "kusari repo scan") {
+				scan = true
+				assert.Equal(t, "${{ github.head_ref }}", step.Env["HEAD_REF"])
+				assert.Equal(t, "${{ github.base_ref }}", step.Env["BASE_REF"])
+				assert.Contains(t, step.Run, `--override-branch "$HEAD_REF"`)
+			}
+		}
+	}
+	assert.True(t, scan, "no PR scan step rendered")
+}
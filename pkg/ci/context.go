@@ -0,0 +1,83 @@
+// Copyright (c) Kusari <https://www.kusari.dev/>
+// SPDX-License-Identifier: MIT
+
+package ci
+
+import (
+	"os"
+	"strings"
+)
+
+// Context is what a CI pipeline tells about the build it runs.
+type Context struct {
+	Platform Platform
+	// Branch is the branch built, or the source branch of a pull/merge request.
+	Branch string
+	// Release is the tag built, when the build is for a tag.
+	Release string
+	// Pipeline identifies the pipeline run.
+	Pipeline string
+}
+
+// DetectContext reads the context of the CI pipeline the CLI runs in from
+// the environment. ok is false outside of a supported CI platform.
+func DetectContext() (c Context, ok bool) {
+	return detectContext(os.Getenv)
+}
+
+func detectContext(getenv func(string) string) (Context, bool) {
+	switch {
+	case getenv("GITHUB_ACTIONS") == "true":
+		c := Context{Platform: GitHub, Pipeline: getenv("GITHUB_RUN_ID")}
+		if getenv("GITHUB_REF_TYPE") == "tag" {
+			c.Release = getenv("GITHUB_REF_NAME")
+		} else if c.Branch = getenv("GITHUB_HEAD_REF"); c.Branch == "" {
+			// GITHUB_REF_NAME is "123/merge" for pull requests, which set
+			// GITHUB_HEAD_REF instead.
+			c.Branch = getenv("GITHUB_REF_NAME")
+		}
+		return c, true
+	case getenv("GITLAB_CI") == "true":
+		c := Context{Platform: GitLab, Release: getenv("CI_COMMIT_TAG"), Pipeline: getenv("CI_PIPELINE_ID")}
+		if c.Branch = getenv("CI_COMMIT_BRANCH"); c.Branch == "" {
+			c.Branch = getenv("CI_MERGE_REQUEST_SOURCE_BRANCH_NAME")
+		}
+		return c, true
+	case strings.EqualFold(getenv("TF_BUILD"), "true"):
+		c := Context{Platform: Azure, Pipeline: getenv("BUILD_BUILDID")}
+		ref := getenv("SYSTEM_PULLREQUEST_SOURCEBRANCH")
+		if ref == "" {
+			ref = getenv("BUILD_SOURCEBRANCH")
+		}
+		if tag, found := strings.CutPrefix(ref, "refs/tags/"); found {
+			c.Release = tag
+		} else {
+			c.Branch = strings.TrimPrefix(ref, "refs/heads/")
+		}
+		return c, true
+	case getenv("JENKINS_URL") != "":
+		// BUILD_TAG is jenkins-<job>-<number>, unlike BUILD_ID unique across jobs.
+		c := Context{Platform: Jenkins, Release: getenv("TAG_NAME"), Pipeline: getenv("BUILD_TAG")}
+		if c.Branch = getenv("CHANGE_BRANCH"); c.Branch == "" && c.Release == "" {
+			c.Branch = getenv("BRANCH_NAME")
+		}
+		return c, true
+	}
+	return Context{}, false
+}
+
+// Tags returns the context as branch:<branch>, release:<tag> and
+// pipeline:<id> tags, leaving out what is unknown.
+func (c Context) Tags() []string {
+	var tags []string
+	if c.Branch != "" {
+		tags = append(tags, "branch:"+c.Branch)
+	}
+	if c.Release != "" {
+		tags = append(tags, "release:"+c.Release)
+	}
+	if c.Pipeline != "" {
+		tags = append(tags, "pipeline:"+c.Pipeline)
+	}
+	return tags
+}
@@ -0,0 +1,69 @@
+// Copyright (c) Kusari <https://www.kusari.dev/>
+// SPDX-License-Identifier: MIT
+
+package ci
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDetectContext(t *testing.T) {
+	tests := []struct {
+		name string
+		env  map[string]string
+		want []string
+	}{
+		{
+			name: "github push",
+			env:  map[string]string{"GITHUB_ACTIONS": "true", "GITHUB_REF_TYPE": "branch", "GITHUB_REF_NAME": "main", "GITHUB_RUN_ID": "4242"},
+			want: []string{"branch:main", "pipeline:4242"},
+		},
+		{
+			name: "github pull request",
+			env:  map[string]string{"GITHUB_ACTIONS": "true", "GITHUB_REF_TYPE": "branch", "GITHUB_REF_NAME": "12/merge", "GITHUB_HEAD_REF": "feature", "GITHUB_RUN_ID": "4243"},
+			want: []string{"branch:feature", "pipeline:4243"},
+		},
+		{
+			name: "github release",
+			env:  map[string]string{"GITHUB_ACTIONS": "true", "GITHUB_REF_TYPE": "tag", "GITHUB_REF_NAME": "v1.2.0", "GITHUB_RUN_ID": "4244"},
+			want: []string{"release:v1.2.0", "pipeline:4244"},
+		},
+		{
+			name: "gitlab merge request",
+			env:  map[string]string{"GITLAB_CI": "true", "CI_MERGE_REQUEST_SOURCE_BRANCH_NAME": "feature", "CI_PIPELINE_ID": "77"},
+			want: []string{"branch:feature", "pipeline:77"},
+		},
+		{
+			name: "gitlab release",
+			env:  map[string]string{"GITLAB_CI": "true", "CI_COMMIT_TAG": "v1.2.0", "CI_PIPELINE_ID": "78"},
+			want: []string{"release:v1.2.0", "pipeline:78"},
+		},
+		{
+			name: "azure",
+			env:  map[string]string{"TF_BUILD": "True", "BUILD_SOURCEBRANCH": "refs/heads/main", "BUILD_BUILDID": "9"},
+			want: []string{"branch:main", "pipeline:9"},
+		},
+		{
+			name: "azure release",
+			env:  map[string]string{"TF_BUILD": "True", "BUILD_SOURCEBRANCH": "refs/tags/v1.2.0", "BUILD_BUILDID": "10"},
+			want: []string{"release:v1.2.0", "pipeline:10"},
+		},
+		{
+			name: "jenkins release",
+			env:  map[string]string{"JENKINS_URL": "https://ci.example.com/", "BRANCH_NAME": "v1.2.0", "TAG_NAME": "v1.2.0", "BUILD_TAG": "jenkins-app-5"},
+			want: []string{"release:v1.2.0", "pipeline:jenkins-app-5"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, ok := detectContext(func(key string) string { return tt.env[key] })
+			assert.True(t, ok)
+			assert.Equal(t, tt.want, c.Tags())
+		})
+	}
+
+	_, ok := detectContext(func(string) string { return "" })
+	assert.False(t, ok)
+}
@@ -0,0 +1,167 @@
+// Copyright (c) Kusari <https://www.kusari.dev/>
+// SPDX-License-Identifier: MIT
+
+// Package cleanup removes what the CLI leaves on disk over time: expired
+// scan cache entries and delta bases, old saved results and interrupted
+// uploads, Waybill binaries of earlier versions and temporary directories
+// of scans that crashed.
+package cleanup
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"time"
+
+	"github.com/kusaridev/kusari-cli/v2/pkg/repo"
+	"github.com/kusaridev/kusari-cli/v2/pkg/results"
+	"github.com/kusaridev/kusari-cli/v2/pkg/waybill"
+)
+
+// Kinds of items removed.
+const (
+	KindScanCache  = "scan-cache"
+	KindDeltaBases = "delta-bases"
+	KindResults    = "results"
+	KindUploads    = "uploads"
+	KindWaybill    = "waybill"
+	KindTemp       = "temp"
+)
+
+// Kinds lists every kind, in the order they are cleaned.
+var Kinds = []string{KindScanCache, KindDeltaBases, KindResults, KindUploads, KindWaybill, KindTemp}
+
+// tempPatterns match what scans, reports and Waybill installs create in
+// the temp directory.
+var tempPatterns = []string{"kusari-*", "waybill-*.tar.gz"}
+
+// Options selects what Clean removes.
+type Options struct {
+	// OlderThan is the minimum age of removed items. Temp directories of
+	// scans still running are young, so keep it above the longest scan.
+	OlderThan time.Duration
+	// Kinds limits cleaning to the given kinds; empty means all.
+	Kinds  []string
+	DryRun bool
+}
+
+// Item is one removed (or, in a dry run, removable) file, directory, scan
+// cache entry or delta base.
+type Item struct {
+	Kind string
+	// Path is the repository of a scan cache entry, and the platform sort
+	// key of the scan a delta base was uploaded with.
+	Path string
+	// Size is the bytes freed, 0 for scan cache entries and delta bases.
+	Size int64
+}
+
+// Clean removes the items selected by opts and returns them. It carries
+// on past errors, returning the first.
+func Clean(opts Options) ([]Item, error) {
+	for _, k := range opts.Kinds {
+		if !slices.Contains(Kinds, k) {
+			return nil, fmt.Errorf("unknown kind %q", k)
+		}
+	}
+	want := func(kind string) bool {
+		return len(opts.Kinds) == 0 || slices.Contains(opts.Kinds, kind)
+	}
+	cutoff := time.Now().Add(-opts.OlderThan)
+
+	var items []Item
+	var firstErr error
+	keep := func(err error) {
+		if err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	remove := func(kind string, paths []string) {
+		for _, p := range paths {
+			info, err := os.Lstat(p)
+			if err != nil || info.ModTime().After(cutoff) {
+				continue
+			}
+			size := diskUsage(p, info)
+			if !opts.DryRun {
+				if err := os.RemoveAll(p); err != nil {
+					keep(fmt.Errorf("failed to remove %s: %w", p, err))
+					continue
+				}
+			}
+			items = append(items, Item{Kind: kind, Path: p, Size: size})
+		}
+	}
+
+	if want(KindScanCache) {
+		pruned, err := repo.PruneCache(opts.OlderThan, opts.DryRun)
+		keep(err)
+		for _, p := range pruned {
+			items = append(items, Item{Kind: KindScanCache, Path: p})
+		}
+	}
+
+	if want(KindDeltaBases) {
+		pruned, err := repo.PruneDeltaBases(opts.OlderThan, opts.DryRun)
+		keep(err)
+		for _, key := range pruned {
+			items = append(items, Item{Kind: KindDeltaBases, Path: key})
+		}
+	}
+
+	if want(KindResults) {
+		dir, err := results.Dir()
+		keep(err)
+		if err == nil {
+			paths, err := filepath.Glob(filepath.Join(dir, "*.json"))
+			keep(err)
+			remove(KindResults, paths)
+		}
+	}
+
+	if want(KindUploads) {
+		dir, err := repo.UploadsDir()
+		keep(err)
+		if err == nil {
+			paths, err := filepath.Glob(filepath.Join(dir, "*"))
+			keep(err)
+			remove(KindUploads, paths)
+		}
+	}
+
+	if want(KindWaybill) {
+		paths, err := waybill.StaleBinaries()
+		keep(err)
+		remove(KindWaybill, paths)
+	}
+
+	if want(KindTemp) {
+		for _, pattern := range tempPatterns {
+			paths, err := filepath.Glob(filepath.Join(os.TempDir(), pattern))
+			keep(err)
+			remove(KindTemp, paths)
+		}
+	}
+
+	return items, firstErr
+}
+
+// diskUsage returns the size of the file or directory tree at path.
+func diskUsage(path string, info fs.FileInfo) int64 {
+	if !info.IsDir() {
+		return info.Size()
+	}
+	var size int64
+	_ = filepath.WalkDir(path, func(_ string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return nil
+		}
+		if fi, err := d.Info(); err == nil && !fi.IsDir() {
+			size += fi.Size()
+		}
+		return nil
+	})
+	return size
+}
@@ -0,0 +1,93 @@
+// Copyright (c) Kusari <https://www.kusari.dev/>
+// SPDX-License-Identifier: MIT
+
+package cleanup
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/kusaridev/kusari-cli/v2/pkg/waybill"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// setup creates an old and a new item of each file kind under a fresh
+// HOME and TMPDIR.
+func setup(t *testing.T) (home, tmp string) {
+	home, tmp = t.TempDir(), t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("TMPDIR", tmp)
+
+	old := time.Now().Add(-48 * time.Hour)
+	write := func(path string, age time.Time) {
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0700))
+		require.NoError(t, os.WriteFile(path, []byte("data"), 0600))
+		require.NoError(t, os.Chtimes(path, age, age))
+	}
+	write(filepath.Join(home, ".kusari", "results", "old-scan.json"), old)
+	write(filepath.Join(home, ".kusari", "results", "new-scan.json"), time.Now())
+	write(filepath.Join(home, ".kusari", "uploads", "old-upload", "journal.json"), old)
+	require.NoError(t, os.Chtimes(filepath.Join(home, ".kusari", "uploads", "old-upload"), old, old))
+	write(filepath.Join(home, ".kusari", "uploads", "new-upload", "journal.json"), time.Now())
+	bases, err := json.Marshal(map[string]any{"entries": map[string]any{
+		"a": map[string]any{"sort_key": "old-scan", "uploaded_at": old},
+		"b": map[string]any{"sort_key": "new-scan", "uploaded_at": time.Now()},
+	}})
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(filepath.Join(home, ".kusari", "delta-bases.json"), bases, 0600))
+	write(filepath.Join(home, ".kusari", "bin", "waybill-0.0.1"), old)
+	write(filepath.Join(home, ".kusari", "bin", "waybill-"+waybill.Version), old)
+	write(filepath.Join(tmp, "kusari-123", "bundle.tar.gz"), old)
+	require.NoError(t, os.Chtimes(filepath.Join(tmp, "kusari-123"), old, old))
+	write(filepath.Join(tmp, "kusari-456", "bundle.tar.gz"), time.Now())
+	write(filepath.Join(tmp, "unrelated"), old)
+	return home, tmp
+}
+
+func TestClean(t *testing.T) {
+	home, tmp := setup(t)
+
+	items, err := Clean(Options{OlderThan: 24 * time.Hour, DryRun: true})
+	require.NoError(t, err)
+	assert.Equal(t, []Item{
+		{Kind: KindDeltaBases, Path: "old-scan"},
+		{Kind: KindResults, Path: filepath.Join(home, ".kusari", "results", "old-scan.json"), Size: 4},
+		{Kind: KindUploads, Path: filepath.Join(home, ".kusari", "uploads", "old-upload"), Size: 4},
+		{Kind: KindWaybill, Path: filepath.Join(home, ".kusari", "bin", "waybill-0.0.1"), Size: 4},
+		{Kind: KindTemp, Path: filepath.Join(tmp, "kusari-123"), Size: 4},
+	}, items)
+	assert.FileExists(t, items[1].Path)
+
+	items, err = Clean(Options{OlderThan: 24 * time.Hour})
+	require.NoError(t, err)
+	assert.Len(t, items, 5)
+	for _, item := range items[1:] {
+		assert.NoFileExists(t, item.Path)
+	}
+	bases, err := os.ReadFile(filepath.Join(home, ".kusari", "delta-bases.json"))
+	require.NoError(t, err)
+	assert.Contains(t, string(bases), "new-scan")
+	assert.NotContains(t, string(bases), "old-scan")
+	assert.DirExists(t, filepath.Join(home, ".kusari", "uploads", "new-upload"))
+	assert.FileExists(t, filepath.Join(home, ".kusari", "results", "new-scan.json"))
+	assert.FileExists(t, filepath.Join(home, ".kusari", "bin", "waybill-"+waybill.Version))
+	assert.DirExists(t, filepath.Join(tmp, "kusari-456"))
+	assert.FileExists(t, filepath.Join(tmp, "unrelated"))
+}
+
+func TestCleanKinds(t *testing.T) {
+	_, tmp := setup(t)
+
+	items, err := Clean(Options{Kinds: []string{KindTemp}})
+	require.NoError(t, err)
+	require.Len(t, items, 2)
+	assert.Equal(t, filepath.Join(tmp, "kusari-123"), items[0].Path)
+	assert.Equal(t, filepath.Join(tmp, "kusari-456"), items[1].Path)
+
+	_, err = Clean(Options{Kinds: []string{"everything"}})
+	require.ErrorContains(t, err, `unknown kind "everything"`)
+}
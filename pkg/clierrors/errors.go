@@ -0,0 +1,309 @@
+// Copyright (c) Kusari <https://www.kusari.dev/>
+// SPDX-License-Identifier: MIT
+
+// Package clierrors defines the failure classes the CLI reports and the
+// process exit code each one maps to, so CI pipelines can branch on why a
+// command failed rather than just that it failed.
+package clierrors
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/kusaridev/kusari-cli/v2/pkg/auth"
+	"github.com/kusaridev/kusari-cli/v2/pkg/transport"
+)
+
+// Exit codes returned by the kusari binary. These are part of the CLI
+// contract: do not renumber, only append.
+const (
+	ExitOK              = 0
+	ExitGeneral         = 1
+	ExitValidation      = 2
+	ExitAuth            = 3
+	ExitNetwork         = 4
+	ExitPlatform        = 5
+	ExitBlockedPackages = 6
+	ExitAnalysisFailed  = 7
+	ExitPolicyDenied    = 8
+	ExitUnsupported     = 9
+	ExitReadOnly        = 10
+)
+
+// NetworkError is a failure to reach the Kusari platform or another remote
+// service (DNS, connection refused, timeout, TLS).
+type NetworkError struct {
+	Message string
+	Cause   error
+}
+
+func (e *NetworkError) Error() string { return format(e.Message, e.Cause) }
+func (e *NetworkError) Unwrap() error { return e.Cause }
+
+// PlatformError is a non-success response from the Kusari platform.
+// StatusCode is the HTTP status, or 0 when not applicable.
+type PlatformError struct {
+	StatusCode int
+	Message    string
+	Cause      error
+}
+
+func (e *PlatformError) Error() string { return format(e.Message, e.Cause) }
+func (e *PlatformError) Unwrap() error { return e.Cause }
+
+// ValidationError is invalid user input (flags, arguments, config values)
+// detected before any work is done.
+type ValidationError struct {
+	Message string
+	Cause   error
+}
+
+func (e *ValidationError) Error() string { return format(e.Message, e.Cause) }
+func (e *ValidationError) Unwrap() error { return e.Cause }
+
+// BlockedPackagesError reports that an uploaded SBOM uses packages on the
+// workspace's blocked package list. The offending packages are printed as
+// they are found. Purl is set when a single package was looked up and the
+// verdict is to block it.
+type BlockedPackagesError struct {
+	Purl string
+}
+
+func (e *BlockedPackagesError) Error() string {
+	if e.Purl != "" {
+		return fmt.Sprintf("%s is blocked", e.Purl)
+	}
+	return "blocked packages found in uploaded SBOMs"
+}
+
+// AnalysisFailedError reports that the platform accepted a scan but
+// processing it failed. Details carries the platform-provided reason.
+type AnalysisFailedError struct {
+	Message string
+	Details string
+}
+
+func (e *AnalysisFailedError) Error() string {
+	if e.Details != "" {
+		return fmt.Sprintf("%s: %s", e.Message, e.Details)
+	}
+	return e.Message
+}
+
+// PolicyDeniedError reports that `kusari policy eval` denied the input.
+// Reasons are the messages of the deny rules that matched.
+type PolicyDeniedError struct {
+	Reasons []string
+}
+
+func (e *PolicyDeniedError) Error() string {
+	return fmt.Sprintf("denied by policy: %s", strings.Join(e.Reasons, "; "))
+}
+
+// UnsupportedVersionError reports that the installed CLI is older than the
+// minimum version the Kusari platform supports.
+type UnsupportedVersionError struct {
+	Message string
+}
+
+func (e *UnsupportedVersionError) Error() string { return e.Message }
+
+// ReadOnlyError reports that --read-only blocked an operation that would
+// change something: an upload, a comment, an issue or a deletion.
+type ReadOnlyError struct {
+	Operation string
+}
+
+func (e *ReadOnlyError) Error() string {
+	return fmt.Sprintf("read-only mode: refusing to %s (unset --read-only or KUSARI_READ_ONLY to allow it)", e.Operation)
+}
+
+// NewNetworkError returns a NetworkError wrapping cause.
+func NewNetworkError(message string, cause error) *NetworkError {
+	return &NetworkError{Message: message, Cause: cause}
+}
+
+// NewPlatformError returns a PlatformError for the given HTTP status.
+func NewPlatformError(statusCode int, message string) *PlatformError {
+	return &PlatformError{StatusCode: statusCode, Message: message}
+}
+
+// forbiddenBody is what the platform says about a 403: the role or
+// permission the request lacks, when it names them, and why.
+type forbiddenBody struct {
+	RequiredRole       string `json:"required_role"`
+	RequiredPermission string `json:"required_permission"`
+	Reason             string `json:"reason"`
+	Message            string `json:"message"`
+	Detail             string `json:"detail"`
+}
+
+// NewForbiddenError returns a PlatformError for a 403 response body to
+// action, e.g. "uploading SBOMs". It names the role or permission the
+// platform reports missing, or else points at the workspace's roles,
+// rather than only saying "forbidden".
+func NewForbiddenError(action string, body []byte) *PlatformError {
+	var fb forbiddenBody
+	if json.Unmarshal(body, &fb) != nil {
+		fb = forbiddenBody{Reason: strings.TrimSpace(string(body))}
+	}
+	var missing []string
+	if fb.RequiredPermission != "" {
+		missing = append(missing, "the "+fb.RequiredPermission+" permission")
+	}
+	if fb.RequiredRole != "" {
+		missing = append(missing, "the "+fb.RequiredRole+" role")
+	}
+	var msg string
+	if len(missing) > 0 {
+		msg = fmt.Sprintf("%s is forbidden (status 403): it requires %s in this workspace, which your account or API key lacks; ask a workspace admin to grant it",
+			action, strings.Join(missing, " or "))
+	} else {
+		msg = fmt.Sprintf("%s is forbidden (status 403): your account or API key lacks the permission in this workspace; ask a workspace admin for a role that allows it, or run `kusari auth login` if the wrong account or workspace is in use",
+			action)
+	}
+	for _, reason := range []string{fb.Reason, fb.Message, fb.Detail} {
+		if reason != "" {
+			msg += " (platform said: " + reason + ")"
+			break
+		}
+	}
+	return &PlatformError{StatusCode: http.StatusForbidden, Message: msg}
+}
+
+// NewValidationError returns a ValidationError with a formatted message.
+func NewValidationError(format string, args ...any) *ValidationError {
+	return &ValidationError{Message: fmt.Sprintf(format, args...)}
+}
+
+// ExitCode maps err to the process exit code for its failure class.
+// Errors that match no class exit with ExitGeneral.
+func ExitCode(err error) int {
+	if err == nil {
+		return ExitOK
+	}
+
+	var (
+		validationErr *ValidationError
+		authErr       *auth.AuthError
+		networkErr    *NetworkError
+		platformErr   *PlatformError
+		blockedErr    *BlockedPackagesError
+		analysisErr   *AnalysisFailedError
+		policyErr     *PolicyDeniedError
+		versionErr    *UnsupportedVersionError
+		readOnlyErr   *ReadOnlyError
+		deadlineErr   *transport.DeadlineError
+		connectErr    *transport.ConnectError
+	)
+
+	switch {
+	case errors.As(err, &blockedErr):
+		return ExitBlockedPackages
+	case errors.As(err, &analysisErr):
+		return ExitAnalysisFailed
+	case errors.As(err, &policyErr):
+		return ExitPolicyDenied
+	case errors.As(err, &versionErr):
+		return ExitUnsupported
+	case errors.As(err, &readOnlyErr):
+		return ExitReadOnly
+	case errors.As(err, &validationErr):
+		return ExitValidation
+	case errors.As(err, &authErr):
+		switch authErr.Code {
+		case auth.ErrNetworkError:
+			return ExitNetwork
+		case auth.ErrInteractionRequired:
+			return ExitValidation
+		}
+		return ExitAuth
+	case errors.As(err, &networkErr), errors.As(err, &deadlineErr), errors.As(err, &connectErr):
+		return ExitNetwork
+	case errors.As(err, &platformErr):
+		if platformErr.StatusCode == http.StatusUnauthorized || platformErr.StatusCode == http.StatusForbidden {
+			return ExitAuth
+		}
+		return ExitPlatform
+	default:
+		return ExitGeneral
+	}
+}
+
+// classes names each exit code in machine-readable error output.
+var classes = map[int]string{
+	ExitGeneral:         "general",
+	ExitValidation:      "validation",
+	ExitAuth:            "auth",
+	ExitNetwork:         "network",
+	ExitPlatform:        "platform",
+	ExitBlockedPackages: "blocked_packages",
+	ExitAnalysisFailed:  "analysis_failed",
+	ExitPolicyDenied:    "policy_denied",
+	ExitUnsupported:     "unsupported_version",
+	ExitReadOnly:        "read_only",
+}
+
+// Class returns the failure class name of err, e.g. "auth" or "validation".
+func Class(err error) string {
+	return classes[ExitCode(err)]
+}
+
+// jsonError is the shape of an error written by WriteJSON.
+type jsonError struct {
+	Error     string `json:"error"`
+	Class     string `json:"class"`
+	ExitCode  int    `json:"exit_code"`
+	RequestID string `json:"request_id,omitempty"`
+}
+
+// WriteJSON writes err to w as a single-line JSON object with its message,
+// failure class, exit code and, when not empty, the request ID sent to the
+// platform, for callers that parse the CLI's stderr.
+func WriteJSON(w io.Writer, err error, requestID string) error {
+	enc := json.NewEncoder(w)
+	enc.SetEscapeHTML(false)
+	return enc.Encode(jsonError{
+		Error:     err.Error(),
+		Class:     Class(err),
+		ExitCode:  ExitCode(err),
+		RequestID: requestID,
+	})
+}
+
+// ExitCodeHelp describes every exit code, for `kusari help exit-codes`.
+func ExitCodeHelp() string {
+	codes := []struct {
+		code int
+		desc string
+	}{
+		{ExitOK, "Success"},
+		{ExitGeneral, "Unclassified error"},
+		{ExitValidation, "Invalid flags, arguments, or configuration, or input needed that non-interactive mode cannot prompt for"},
+		{ExitAuth, "Authentication failed or token missing/expired (run `kusari auth login`)"},
+		{ExitNetwork, "Could not reach the Kusari platform or a remote service, or the --deadline passed"},
+		{ExitPlatform, "The Kusari platform returned an error response (401/403 responses exit with 3)"},
+		{ExitBlockedPackages, "Uploaded SBOMs contain blocked packages (--check-blocked-packages), or package info blocks the package"},
+		{ExitAnalysisFailed, "The platform accepted the scan but analysis failed"},
+		{ExitPolicyDenied, "A policy denied the analysis or SBOMs (kusari policy eval)"},
+		{ExitUnsupported, "The CLI is older than the minimum version the platform supports (--version-check enforce)"},
+		{ExitReadOnly, "--read-only blocked an upload, comment, issue or deletion"},
+	}
+
+	sb := new(strings.Builder)
+	for _, c := range codes {
+		fmt.Fprintf(sb, "  %d  %s\n", c.code, c.desc)
+	}
+	return sb.String()
+}
+
+func format(message string, cause error) string {
+	if cause != nil {
+		return fmt.Sprintf("%s: %v", message, cause)
+	}
+	return message
+}
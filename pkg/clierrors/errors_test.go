@@ -0,0 +1,85 @@
+// Copyright (c) Kusari <https://www.kusari.dev/>
+// SPDX-License-Identifier: MIT
+
+package clierrors
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/kusaridev/kusari-cli/v2/pkg/auth"
+	"github.com/kusaridev/kusari-cli/v2/pkg/transport"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExitCode(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want int
+	}{
+		{"nil", nil, ExitOK},
+		{"plain error", errors.New("boom"), ExitGeneral},
+		{"validation", NewValidationError("bad flag %q", "x"), ExitValidation},
+		{"auth", auth.NewAuthError(auth.ErrTokenExpired, "expired"), ExitAuth},
+		{"auth network", auth.NewAuthError(auth.ErrNetworkError, "listen"), ExitNetwork},
+		{"interaction required", auth.NewAuthError(auth.ErrInteractionRequired, "set KUSARI_WORKSPACE"), ExitValidation},
+		{"network", NewNetworkError("failed to POST", errors.New("refused")), ExitNetwork},
+		{"platform", NewPlatformError(http.StatusInternalServerError, "oops"), ExitPlatform},
+		{"platform unauthorized", NewPlatformError(http.StatusUnauthorized, "nope"), ExitAuth},
+		{"platform forbidden", NewPlatformError(http.StatusForbidden, "nope"), ExitAuth},
+		{"blocked", &BlockedPackagesError{}, ExitBlockedPackages},
+		{"analysis failed", &AnalysisFailedError{Message: "processing failed"}, ExitAnalysisFailed},
+		{"policy denied", &PolicyDeniedError{Reasons: []string{"GPL-3.0 is forbidden"}}, ExitPolicyDenied},
+		{"unsupported version", &UnsupportedVersionError{Message: "too old"}, ExitUnsupported},
+		{"read-only", &ReadOnlyError{Operation: "upload"}, ExitReadOnly},
+		{"forbidden", NewForbiddenError("uploading SBOMs", nil), ExitAuth},
+		{"deadline", fmt.Errorf("failed to upload: %w", &transport.DeadlineError{Deadline: time.Minute}), ExitNetwork},
+		{"connect", fmt.Errorf("Get %q: %w", "https://x", &transport.ConnectError{Problem: "cannot resolve x", Err: errors.New("no such host")}), ExitNetwork},
+		{"wrapped", fmt.Errorf("failed to get presigned URL: %w", NewNetworkError("x", nil)), ExitNetwork},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, ExitCode(tt.err))
+		})
+	}
+}
+
+func TestErrorMessages(t *testing.T) {
+	cause := errors.New("connection refused")
+	assert.Equal(t, "failed to upload: connection refused", NewNetworkError("failed to upload", cause).Error())
+	assert.ErrorIs(t, NewNetworkError("failed to upload", cause), cause)
+	assert.Equal(t, "bad request", NewPlatformError(http.StatusBadRequest, "bad request").Error())
+	assert.Equal(t, "processing failed: out of memory", (&AnalysisFailedError{Message: "processing failed", Details: "out of memory"}).Error())
+	assert.Equal(t, "processing failed", (&AnalysisFailedError{Message: "processing failed"}).Error())
+}
+
+func TestNewForbiddenError(t *testing.T) {
+	assert.Equal(t, "uploading SBOMs is forbidden (status 403): it requires the sbom:write permission or the contributor role in this workspace, which your account or API key lacks; ask a workspace admin to grant it",
+		NewForbiddenError("uploading SBOMs", []byte(`{"required_permission":"sbom:write","required_role":"contributor"}`)).Error())
+	assert.Equal(t, "uploading SBOMs is forbidden (status 403): it requires the admin role in this workspace, which your account or API key lacks; ask a workspace admin to grant it (platform said: auditors cannot upload)",
+		NewForbiddenError("uploading SBOMs", []byte(`{"required_role":"admin","reason":"auditors cannot upload"}`)).Error())
+	assert.Equal(t, "uploading SBOMs is forbidden (status 403): your account or API key lacks the permission in this workspace; ask a workspace admin for a role that allows it, or run `kusari auth login` if the wrong account or workspace is in use (platform said: Forbidden)",
+		NewForbiddenError("uploading SBOMs", []byte("Forbidden\n")).Error())
+	assert.Equal(t, http.StatusForbidden, NewForbiddenError("uploading SBOMs", nil).StatusCode)
+}
+
+func TestWriteJSON(t *testing.T) {
+	var buf bytes.Buffer
+	err := fmt.Errorf("upload failed: %w", NewPlatformError(http.StatusForbidden, "nope"))
+	assert.NoError(t, WriteJSON(&buf, err, "0a1b2c"))
+
+	var got map[string]any
+	assert.NoError(t, json.Unmarshal(buf.Bytes(), &got))
+	assert.Equal(t, "upload failed: nope", got["error"])
+	assert.Equal(t, "auth", got["class"])
+	assert.Equal(t, float64(ExitAuth), got["exit_code"])
+	assert.Equal(t, "0a1b2c", got["request_id"])
+	assert.Equal(t, "general", Class(errors.New("boom")))
+}
@@ -0,0 +1,75 @@
+// Copyright (c) Kusari <https://www.kusari.dev/>
+// SPDX-License-Identifier: MIT
+
+// Package clock lets the time a package reads and sleeps on be replaced,
+// so tests of polling loops can run instantly.
+package clock
+
+import (
+	"sync"
+	"time"
+)
+
+// Clock is the time source of the polling loops.
+type Clock interface {
+	Now() time.Time
+	Sleep(d time.Duration)
+	After(d time.Duration) <-chan time.Time
+}
+
+// Real is the system clock.
+type Real struct{}
+
+func (Real) Now() time.Time                         { return time.Now() }
+func (Real) Sleep(d time.Duration)                  { time.Sleep(d) }
+func (Real) After(d time.Duration) <-chan time.Time { return time.After(d) }
+
+// Fake is a Clock that never waits: Sleep and After move it forward by the
+// duration right away.
+type Fake struct {
+	mu    sync.Mutex
+	now   time.Time
+	slept time.Duration
+}
+
+// NewFake returns a fake clock reading start.
+func NewFake(start time.Time) *Fake {
+	return &Fake{now: start}
+}
+
+// Now returns the fake time.
+func (f *Fake) Now() time.Time {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.now
+}
+
+// Sleep moves the clock forward by d.
+func (f *Fake) Sleep(d time.Duration) {
+	f.Advance(d)
+}
+
+// After moves the clock forward by d and returns a channel holding the new
+// time.
+func (f *Fake) After(d time.Duration) <-chan time.Time {
+	ch := make(chan time.Time, 1)
+	ch <- f.Advance(d)
+	return ch
+}
+
+// Advance moves the clock forward by d, counting it as slept, and returns
+// the new time.
+func (f *Fake) Advance(d time.Duration) time.Time {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.now = f.now.Add(d)
+	f.slept += d
+	return f.now
+}
+
+// Slept returns how long the clock was moved forward in total.
+func (f *Fake) Slept() time.Duration {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.slept
+}
@@ -0,0 +1,28 @@
+// Copyright (c) Kusari <https://www.kusari.dev/>
+// SPDX-License-Identifier: MIT
+
+package clock
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFake(t *testing.T) {
+	start := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	f := NewFake(start)
+	assert.Equal(t, start, f.Now())
+
+	f.Sleep(time.Minute)
+	assert.Equal(t, start.Add(time.Minute), f.Now())
+
+	select {
+	case now := <-f.After(time.Hour):
+		assert.Equal(t, start.Add(time.Hour+time.Minute), now)
+	default:
+		t.Fatal("After should fire right away")
+	}
+	assert.Equal(t, time.Hour+time.Minute, f.Slept())
+}
@@ -7,6 +7,7 @@ import (
 	"bytes"
 	"embed"
 	"fmt"
+	"net/url"
 	"strings"
 	"text/template"
 
@@ -20,14 +21,150 @@ var templateFS embed.FS
 type AnalysisCommentData struct {
 	FinalAnalysis *api.SecurityAnalysis
 	ConsoleURL    string
+	// Omitted is the number of findings left out of the comment.
+	Omitted int
+	// Continued is set when the omitted findings are posted in further
+	// comments rather than left to the console.
+	Continued bool
+	// Part, Parts and PartMarker are set on continuation comments.
+	Part       int
+	Parts      int
+	PartMarker string
 }
 
+// Long code fixes and dependency lists are collapsed into <details>.
+const (
+	collapseLines = 15
+	collapseItems = 10
+)
+
+// Snippets of the comment template that SetSnippets can override. The
+// mitigation snippets render one finding: a code mitigation is
+// api.CodeMitigationItem, a dependency mitigation
+// api.DependencyMitigationItem. The others get AnalysisCommentData.
+const (
+	SnippetHeader               = "header"
+	SnippetCodeMitigation       = "code_mitigation"
+	SnippetDependencyMitigation = "dependency_mitigation"
+	SnippetFooter               = "footer"
+)
+
+// snippets are the template snippets set by SetSnippets, by name.
+var snippets map[string]string
+
+// SetSnippets overrides snippets of the comment template with the
+// templates of overrides, keyed by snippet name, e.g. to add runbook links
+// to dependency findings. A trailing newline of a template is dropped. It
+// fails, going back to the default snippets, on an unknown snippet name or
+// a template that doesn't parse.
+func SetSnippets(overrides map[string]string) error {
+	snippets = nil
+	trimmed := make(map[string]string, len(overrides))
+	for name, text := range overrides {
+		switch name {
+		case SnippetHeader, SnippetCodeMitigation, SnippetDependencyMitigation, SnippetFooter:
+		default:
+			return fmt.Errorf("unknown comment template snippet %q (valid: %s, %s, %s, %s)", name,
+				SnippetHeader, SnippetCodeMitigation, SnippetDependencyMitigation, SnippetFooter)
+		}
+		trimmed[name] = strings.TrimRight(text, "\n")
+	}
+	if _, err := parseSnippets(trimmed); err != nil {
+		return err
+	}
+	snippets = trimmed
+	return nil
+}
+
+func parseTemplate() (*template.Template, error) {
+	return parseSnippets(snippets)
+}
+
+// parseSnippets parses the comment template with overrides replacing its
+// snippets.
+func parseSnippets(overrides map[string]string) (*template.Template, error) {
+	tmplContent, err := templateFS.ReadFile("templates/analysisComment.tmpl")
+	if err != nil {
+		return nil, err
+	}
+	lines := func(s string) int { return strings.Count(strings.TrimRight(s, "\n"), "\n") + 1 }
+	tmpl, err := template.New("analysisComment").Funcs(template.FuncMap{
+		"lines":         lines,
+		"long":          func(s string) bool { return lines(s) > collapseLines },
+		"collapseItems": func() int { return collapseItems },
+		"issues":        func(a *api.SecurityAnalysis) int { _, n := CheckForIssues(a); return n },
+		"badge":         Badge,
+	}).Parse(string(tmplContent))
+	if err != nil {
+		return nil, err
+	}
+	for name, text := range overrides {
+		if _, err := tmpl.New(name).Parse(text); err != nil {
+			return nil, fmt.Errorf("failed to parse comment template snippet %s: %w", name, err)
+		}
+	}
+	return tmpl, nil
+}
+
+// What was done with the summary comment, in CommentResult.SummaryAction.
+const (
+	SummaryCreated = "created"
+	SummaryUpdated = "updated"
+	SummarySkipped = "skipped"
+)
+
+// What was done with an inline comment, in InlineResult.Status.
+const (
+	InlineCreated = "created"
+	InlineUpdated = "updated"
+	InlineFailed  = "failed"
+)
+
 // CommentResult holds the result of posting a comment
 type CommentResult struct {
 	Posted               bool
 	IssuesFound          int
 	InlineCommentsPosted int
 	Message              string
+
+	// SummaryAction is SummaryCreated, SummaryUpdated or SummarySkipped.
+	// It is empty for reactions.
+	SummaryAction string
+	// Inline has an entry for each inline comment attempted, in the order
+	// of the code mitigations.
+	Inline []InlineResult
+	// Warnings are the API errors that didn't stop the summary from being
+	// posted, such as failing to find the previous summary or to post an
+	// inline comment. Callers decide whether to surface them.
+	Warnings []error
+}
+
+// InlineResult is the outcome of one inline comment.
+type InlineResult struct {
+	Path   string
+	Line   int
+	Status string // InlineCreated, InlineUpdated or InlineFailed
+	Err    error  // Set when Status is InlineFailed
+}
+
+// NewInlineResult records the outcome of the inline comment for issue:
+// status, or InlineFailed when err is set.
+func NewInlineResult(issue api.CodeMitigationItem, status string, err error) InlineResult {
+	if err != nil {
+		status = InlineFailed
+	}
+	return InlineResult{Path: issue.Path, Line: issue.LineNumber, Status: status, Err: err}
+}
+
+// InlineFailed returns the number of inline comments that failed.
+func (r *CommentResult) InlineFailed() int {
+	failed := 0
+	for _, in := range r.Inline {
+		if in.Status == InlineFailed {
+			failed++
+		}
+	}
+	return failed
 }
 
 // CheckForIssues determines if there are issues to report
@@ -49,15 +186,41 @@ func CheckForIssues(analysis *api.SecurityAnalysis) (bool, int) {
 	return issueCount > 0, issueCount
 }
 
+// NeedsSecurityReview reports whether analysis should be escalated to
+// security reviewers: the changes should not proceed and have at least
+// threshold findings. A failed analysis has no findings to review.
+func NeedsSecurityReview(analysis *api.SecurityAnalysis, threshold int) bool {
+	if analysis.ShouldProceed || analysis.FailedAnalysis {
+		return false
+	}
+	_, issueCount := CheckForIssues(analysis)
+	return issueCount >= threshold
+}
+
 // FormatComment creates a markdown comment from analysis results using the shared template
 func FormatComment(analysis *api.SecurityAnalysis, consoleURL string) string {
-	tmplContent, err := templateFS.ReadFile("templates/analysisComment.tmpl")
+	tmpl, err := parseTemplate()
 	if err != nil {
-		// Fallback to basic format if template fails
 		return FormatCommentFallback(analysis, consoleURL)
 	}
 
-	tmpl, err := template.New("analysisComment").Parse(string(tmplContent))
+	data := AnalysisCommentData{
+		FinalAnalysis: analysis,
+		ConsoleURL:    consoleURL,
+	}
+
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return FormatCommentFallback(analysis, consoleURL)
+	}
+
+	return buf.String()
+}
+
+// FormatStatusComment creates a one-line status comment with the verdict
+// and a badge, for teams that find the full comment too noisy
+func FormatStatusComment(analysis *api.SecurityAnalysis, consoleURL string) string {
+	tmpl, err := parseTemplate()
 	if err != nil {
 		return FormatCommentFallback(analysis, consoleURL)
 	}
@@ -68,13 +231,40 @@ func FormatComment(analysis *api.SecurityAnalysis, consoleURL string) string {
 	}
 
 	var buf bytes.Buffer
-	if err := tmpl.Execute(&buf, data); err != nil {
+	if err := tmpl.ExecuteTemplate(&buf, "status", data); err != nil {
 		return FormatCommentFallback(analysis, consoleURL)
 	}
 
 	return buf.String()
 }
 
+// Badge returns the URL of a shields.io badge showing the health score of
+// analysis, or its verdict when it has no health score
+func Badge(analysis *api.SecurityAnalysis) string {
+	label, message, color := "Kusari", "passed", "brightgreen"
+	switch {
+	case analysis.HealthScore > 0:
+		label, message = "Kusari health", fmt.Sprintf("%d/5", analysis.HealthScore)
+		switch {
+		case analysis.HealthScore <= 2:
+			color = "red"
+		case analysis.HealthScore == 3:
+			color = "yellow"
+		}
+	case analysis.FailedAnalysis:
+		message, color = "failed", "lightgrey"
+	case !analysis.ShouldProceed:
+		_, n := CheckForIssues(analysis)
+		message, color = fmt.Sprintf("%d issues", n), "red"
+	}
+
+	// shields.io separates label, message and color with '-', so literal
+	// dashes and underscores are doubled
+	escape := strings.NewReplacer("-", "--", "_", "__").Replace
+	return fmt.Sprintf("https://img.shields.io/badge/%s-%s-%s",
+		url.PathEscape(escape(label)), url.PathEscape(escape(message)), color)
+}
+
 // FormatCommentFallback provides a basic format if template rendering fails
 func FormatCommentFallback(analysis *api.SecurityAnalysis, consoleURL string) string {
 	var sb strings.Builder
@@ -103,6 +103,18 @@ func TestCheckForIssues(t *testing.T) {
 	}
 }
 
+func TestNeedsSecurityReview(t *testing.T) {
+	blocked := &api.SecurityAnalysis{
+		ShouldProceed:                 false,
+		RequiredDependencyMitigations: []api.DependencyMitigationItem{{Content: "a"}, {Content: "b"}},
+	}
+	assert.True(t, NeedsSecurityReview(blocked, 1))
+	assert.True(t, NeedsSecurityReview(blocked, 2))
+	assert.False(t, NeedsSecurityReview(blocked, 3))
+	assert.False(t, NeedsSecurityReview(&api.SecurityAnalysis{ShouldProceed: true}, 0))
+	assert.False(t, NeedsSecurityReview(&api.SecurityAnalysis{FailedAnalysis: true}, 0))
+}
+
 func TestSanitizePath(t *testing.T) {
 	tests := []struct {
 		name     string
@@ -238,6 +250,40 @@ func TestFormatComment(t *testing.T) {
 	}
 }
 
+func TestSetSnippets(t *testing.T) {
+	t.Cleanup(func() { snippets = nil })
+	analysis := &api.SecurityAnalysis{
+		Justification:                 "Vulnerable dependency",
+		RequiredCodeMitigations:       []api.CodeMitigationItem{{Content: "Pin the action", Path: "ci.yml", LineNumber: 4}},
+		RequiredDependencyMitigations: []api.DependencyMitigationItem{{Content: "Upgrade lodash"}, {Content: "Upgrade axios"}},
+	}
+	defaults := FormatComment(analysis, "https://console.example.com")
+
+	err := SetSnippets(map[string]string{
+		SnippetDependencyMitigation: "- {{ .Content }} ([runbook](https://wiki.acme.dev/deps))\n",
+		SnippetFooter:               "Questions? #appsec\n",
+	})
+	assert.NoError(t, err)
+	result := FormatComment(analysis, "https://console.example.com")
+	assert.Contains(t, result, "- Upgrade lodash ([runbook](https://wiki.acme.dev/deps))\n- Upgrade axios ([runbook](https://wiki.acme.dev/deps))\n")
+	assert.Contains(t, result, "Questions? #appsec\n\n<!-- IGNORE_KUSARI_COMMENT -->")
+	assert.NotContains(t, result, "--------")
+	// Snippets not overridden are left as they were
+	assert.Contains(t, result, "#### Kusari Analysis Results:")
+	assert.Contains(t, result, "- **Location:** ci.yml:4")
+
+	// A bad override goes back to the default snippets
+	assert.ErrorContains(t, SetSnippets(map[string]string{"summary": "x"}), `unknown comment template snippet "summary"`)
+	assert.Equal(t, defaults, FormatComment(analysis, "https://console.example.com"))
+	assert.NoError(t, SetSnippets(map[string]string{SnippetFooter: "Questions? #appsec\n"}))
+	assert.ErrorContains(t, SetSnippets(map[string]string{SnippetHeader: "{{ .Nope"}), "failed to parse comment template snippet header")
+	assert.Equal(t, defaults, FormatComment(analysis, "https://console.example.com"))
+
+	assert.NoError(t, SetSnippets(map[string]string{SnippetFooter: "Questions? #appsec\n"}))
+	assert.NoError(t, SetSnippets(nil))
+	assert.Equal(t, defaults, FormatComment(analysis, "https://console.example.com"))
+}
+
 func TestFormatCommentFallback(t *testing.T) {
 	tests := []struct {
 		name           string
@@ -307,6 +353,63 @@ func TestFormatCommentFallback(t *testing.T) {
 	}
 }
 
+func TestFormatStatusComment(t *testing.T) {
+	tests := []struct {
+		name           string
+		analysis       *api.SecurityAnalysis
+		consoleURL     string
+		expectContains []string
+	}{
+		{
+			name:       "should proceed",
+			analysis:   &api.SecurityAnalysis{ShouldProceed: true},
+			consoleURL: "https://console.example.com",
+			expectContains: []string{
+				":white_check_mark: No flagged issues",
+				"https://img.shields.io/badge/Kusari-passed-brightgreen",
+				"[View details](https://console.example.com)",
+				"IGNORE_KUSARI_COMMENT",
+			},
+		},
+		{
+			name: "flagged issues",
+			analysis: &api.SecurityAnalysis{
+				ShouldProceed:                 false,
+				RequiredDependencyMitigations: []api.DependencyMitigationItem{{Content: "a"}, {Content: "b"}},
+			},
+			expectContains: []string{
+				":warning: 2 flagged issue(s)",
+				"https://img.shields.io/badge/Kusari-2%20issues-red",
+			},
+		},
+		{
+			name:     "health score",
+			analysis: &api.SecurityAnalysis{ShouldProceed: true, HealthScore: 3},
+			expectContains: []string{
+				"https://img.shields.io/badge/Kusari%20health-3%2F5-yellow",
+			},
+		},
+		{
+			name:     "failed analysis",
+			analysis: &api.SecurityAnalysis{FailedAnalysis: true},
+			expectContains: []string{
+				":x: Analysis failed",
+				"https://img.shields.io/badge/Kusari-failed-lightgrey",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := FormatStatusComment(tt.analysis, tt.consoleURL)
+			for _, expected := range tt.expectContains {
+				assert.Contains(t, result, expected)
+			}
+			assert.NotContains(t, result, "Kusari Analysis Results")
+		})
+	}
+}
+
 func TestFormatInlineComment(t *testing.T) {
 	tests := []struct {
 		name           string
@@ -0,0 +1,126 @@
+// Copyright (c) Kusari <https://www.kusari.dev/>
+// SPDX-License-Identifier: MIT
+
+package comment
+
+import (
+	"cmp"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"regexp"
+	"slices"
+)
+
+// Run identifies the run posting a summary comment, so that the summaries
+// of concurrent runs, such as two retries of a pipeline, can be told apart
+// and deduplicated after posting. The zero value disables this.
+type Run struct {
+	// Key is shared by runs that should leave a single summary between
+	// them: the commit being scanned.
+	Key string
+	// ID is unique to this run.
+	ID string
+}
+
+// runHashLength is the number of hex digits of the content hash in a run
+// marker.
+const runHashLength = 16
+
+var runMarkerRegex = regexp.MustCompile(`<!-- KUSARI_RUN:([^:\s]+):([^:\s]+):([0-9a-f]+) -->`)
+
+// Marker returns the hidden marker to append to summary body when posted
+// by r, holding its key, its ID and a hash of body. It's empty for the
+// zero Run.
+func (r Run) Marker(body string) string {
+	if r.Key == "" {
+		return ""
+	}
+	return fmt.Sprintf("\n<!-- KUSARI_RUN:%s:%s:%s -->", r.Key, r.ID, contentHash(body))
+}
+
+// MarkerLength is the length of the markers r adds, so that summaries can
+// be formatted to leave room for them.
+func (r Run) MarkerLength() int {
+	return len(r.Marker(""))
+}
+
+func contentHash(body string) string {
+	sum := sha256.Sum256([]byte(body))
+	return hex.EncodeToString(sum[:])[:runHashLength]
+}
+
+// parseRunMarker returns the run that posted body and the hash of its
+// content, or false if body has no run marker.
+func parseRunMarker(body string) (Run, string, bool) {
+	m := runMarkerRegex.FindStringSubmatch(body)
+	if m == nil {
+		return Run{}, "", false
+	}
+	return Run{Key: m[1], ID: m[2]}, m[3], true
+}
+
+// PostedComment is a comment on a PR/MR, by its ID on the forge.
+type PostedComment struct {
+	ID   int64
+	Body string
+	// Author is the forge user who posted the comment.
+	Author string
+}
+
+// Dedupe decides, once r has posted body (marker included) as a new
+// summary, what to do about the summaries of other runs with the same key
+// among comments, which were listed after posting. The oldest of them is
+// kept: if it is r's own, nothing needs doing, since the other runs remove
+// theirs. Otherwise r's duplicates are to be removed and, when its content
+// differs, the kept summary updated with body. Only summaries by the
+// author of r's own are considered, so a copied marker in someone else's
+// comment never makes r update it. keep is 0 when there are no duplicates.
+func (r Run) Dedupe(body string, comments []PostedComment) (keep int64, remove []int64, update bool) {
+	if r.Key == "" {
+		return 0, nil, false
+	}
+	type posted struct {
+		PostedComment
+		run  Run
+		hash string
+	}
+	var all []posted
+	author, own := "", false
+	for _, c := range comments {
+		run, hash, ok := parseRunMarker(c.Body)
+		if ok && run.Key == r.Key && IsSummary(c.Body) {
+			all = append(all, posted{c, run, hash})
+			if run.ID == r.ID {
+				author, own = c.Author, true
+			}
+		}
+	}
+	if !own {
+		return 0, nil, false
+	}
+	var dups []posted
+	for _, d := range all {
+		if d.Author == author {
+			dups = append(dups, d)
+		}
+	}
+	if len(dups) < 2 {
+		return 0, nil, false
+	}
+	slices.SortFunc(dups, func(a, b posted) int { return cmp.Compare(a.ID, b.ID) })
+	oldest := dups[0]
+	if oldest.run.ID == r.ID {
+		return 0, nil, false
+	}
+	for _, d := range dups[1:] {
+		if d.run.ID == r.ID {
+			remove = append(remove, d.ID)
+		}
+	}
+	if len(remove) == 0 {
+		return 0, nil, false
+	}
+	_, hash, _ := parseRunMarker(body)
+	return oldest.ID, remove, oldest.hash != hash
+}
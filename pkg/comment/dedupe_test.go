@@ -0,0 +1,83 @@
+// Copyright (c) Kusari <https://www.kusari.dev/>
+// SPDX-License-Identifier: MIT
+
+package comment
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRunMarker(t *testing.T) {
+	assert.Empty(t, Run{}.Marker("body"))
+	assert.Zero(t, Run{}.MarkerLength())
+
+	run := Run{Key: "abc123", ID: "run-1"}
+	marker := run.Marker("body")
+	assert.Len(t, marker, run.MarkerLength())
+	assert.NotEqual(t, marker, run.Marker("other body"))
+
+	parsed, hash, ok := parseRunMarker("summary" + marker)
+	assert.True(t, ok)
+	assert.Equal(t, run, parsed)
+	assert.Len(t, hash, runHashLength)
+}
+
+func TestRunDedupe(t *testing.T) {
+	summary := "summary <!-- IGNORE_KUSARI_COMMENT -->"
+	first := Run{Key: "abc123", ID: "run-1"}
+	second := Run{Key: "abc123", ID: "run-2"}
+	firstBody := summary + first.Marker(summary)
+	secondBody := summary + second.Marker(summary)
+
+	comments := []PostedComment{
+		{ID: 5, Body: "LGTM"},
+		{ID: 7, Body: secondBody},
+		{ID: 6, Body: firstBody},
+	}
+
+	// The first run posted first, so the second removes its own.
+	keep, remove, update := second.Dedupe(secondBody, comments)
+	assert.Equal(t, int64(6), keep)
+	assert.Equal(t, []int64{7}, remove)
+	assert.False(t, update, "same content needs no update")
+
+	keep, remove, _ = first.Dedupe(firstBody, comments)
+	assert.Zero(t, keep)
+	assert.Empty(t, remove)
+
+	// Different findings: the kept summary takes the later run's.
+	changed := "changed " + summary
+	changedBody := changed + second.Marker(changed)
+	keep, remove, update = second.Dedupe(changedBody, []PostedComment{{ID: 6, Body: firstBody}, {ID: 7, Body: changedBody}})
+	assert.Equal(t, int64(6), keep)
+	assert.Equal(t, []int64{7}, remove)
+	assert.True(t, update)
+
+	// A summary by someone else, e.g. with a copied marker, is never kept.
+	keep, remove, _ = second.Dedupe(secondBody, []PostedComment{
+		{ID: 6, Body: firstBody, Author: "mallory"},
+		{ID: 7, Body: secondBody, Author: "kusari-bot"},
+	})
+	assert.Zero(t, keep)
+	assert.Empty(t, remove)
+}
+
+func TestRunDedupeOtherKeys(t *testing.T) {
+	summary := "summary <!-- IGNORE_KUSARI_COMMENT -->"
+	old := Run{Key: "old", ID: "run-1"}
+	run := Run{Key: "abc123", ID: "run-2"}
+	body := summary + run.Marker(summary)
+
+	keep, remove, _ := run.Dedupe(body, []PostedComment{
+		{ID: 1, Body: summary + old.Marker(summary)},
+		{ID: 2, Body: "no marker " + summary},
+		{ID: 3, Body: body},
+	})
+	assert.Zero(t, keep)
+	assert.Empty(t, remove)
+
+	keep, _, _ = Run{}.Dedupe(summary, []PostedComment{{ID: 1, Body: summary}, {ID: 2, Body: summary}})
+	assert.Zero(t, keep)
+}
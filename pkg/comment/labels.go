@@ -0,0 +1,35 @@
+// Copyright (c) Kusari <https://www.kusari.dev/>
+// SPDX-License-Identifier: MIT
+
+package comment
+
+import "github.com/kusaridev/kusari-cli/v2/api"
+
+// Labels are the PR/MR labels for each analysis outcome. An empty name is
+// never applied.
+type Labels struct {
+	Passed      string
+	Blocked     string
+	NeedsReview string
+}
+
+// For returns the label for the outcome of analysis and the other labels,
+// which should be removed. The label is empty if the outcome has none.
+func (l Labels) For(analysis *api.SecurityAnalysis) (add string, remove []string) {
+	switch {
+	case analysis.FailedAnalysis:
+		add = l.NeedsReview
+	case !analysis.ShouldProceed:
+		add = l.Blocked
+	case len(analysis.RequiredCodeMitigations) > 0 || len(analysis.RequiredDependencyMitigations) > 0:
+		add = l.NeedsReview
+	default:
+		add = l.Passed
+	}
+	for _, name := range []string{l.Passed, l.Blocked, l.NeedsReview} {
+		if name != "" && name != add {
+			remove = append(remove, name)
+		}
+	}
+	return add, remove
+}
@@ -0,0 +1,64 @@
+// Copyright (c) Kusari <https://www.kusari.dev/>
+// SPDX-License-Identifier: MIT
+
+package comment
+
+import (
+	"testing"
+
+	"github.com/kusaridev/kusari-cli/v2/api"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLabelsFor(t *testing.T) {
+	labels := Labels{Passed: "kusari:passed", Blocked: "kusari:blocked", NeedsReview: "kusari:needs-review"}
+
+	tests := []struct {
+		name       string
+		analysis   *api.SecurityAnalysis
+		wantAdd    string
+		wantRemove []string
+	}{
+		{
+			name:       "passed",
+			analysis:   &api.SecurityAnalysis{ShouldProceed: true},
+			wantAdd:    "kusari:passed",
+			wantRemove: []string{"kusari:blocked", "kusari:needs-review"},
+		},
+		{
+			name:       "blocked",
+			analysis:   &api.SecurityAnalysis{ShouldProceed: false},
+			wantAdd:    "kusari:blocked",
+			wantRemove: []string{"kusari:passed", "kusari:needs-review"},
+		},
+		{
+			name: "passed with findings",
+			analysis: &api.SecurityAnalysis{
+				ShouldProceed:                 true,
+				RequiredDependencyMitigations: []api.DependencyMitigationItem{{Content: "Upgrade"}},
+			},
+			wantAdd:    "kusari:needs-review",
+			wantRemove: []string{"kusari:passed", "kusari:blocked"},
+		},
+		{
+			name:       "failed analysis",
+			analysis:   &api.SecurityAnalysis{FailedAnalysis: true},
+			wantAdd:    "kusari:needs-review",
+			wantRemove: []string{"kusari:passed", "kusari:blocked"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			add, remove := labels.For(tt.analysis)
+			assert.Equal(t, tt.wantAdd, add)
+			assert.Equal(t, tt.wantRemove, remove)
+		})
+	}
+}
+
+func TestLabelsForSkipsEmptyNames(t *testing.T) {
+	add, remove := Labels{Blocked: "blocked"}.For(&api.SecurityAnalysis{ShouldProceed: true})
+	assert.Empty(t, add)
+	assert.Equal(t, []string{"blocked"}, remove)
+}
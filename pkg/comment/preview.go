@@ -0,0 +1,78 @@
+// Copyright (c) Kusari <https://www.kusari.dev/>
+// SPDX-License-Identifier: MIT
+
+package comment
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/kusaridev/kusari-cli/v2/api"
+)
+
+// PreviewOptions are the comment settings a preview is rendered with.
+type PreviewOptions struct {
+	// MaxLength is the platform's comment size limit, GitHubMaxLength or
+	// GitLabMaxLength.
+	MaxLength int
+	// Compact previews the one-line status comment.
+	Compact bool
+	// Reaction previews the reaction left instead of a comment.
+	Reaction bool
+	// Run marks the summary as it would be when posted.
+	Run Run
+}
+
+// FormatPreview renders the comments that posting analysis would write,
+// bodies and hidden markers included, each under a header naming it. No
+// forge is asked, so a summary that would update an earlier one is shown
+// as new, and a clean result as skipped.
+func FormatPreview(analysis *api.SecurityAnalysis, consoleURL string, opts PreviewOptions) string {
+	if analysis == nil {
+		return "No analysis results available - no comment would be posted\n"
+	}
+	hasIssues, _ := CheckForIssues(analysis)
+
+	sb := new(strings.Builder)
+	if opts.Reaction {
+		reaction := "👍"
+		if hasIssues {
+			reaction = "👎"
+		}
+		fmt.Fprintf(sb, "Reaction: %s\n", reaction)
+		return sb.String()
+	}
+	if !hasIssues {
+		sb.WriteString("No issues found - no comment would be posted, unless a previous summary exists to update:\n\n")
+	}
+
+	var bodies []string
+	if opts.Compact {
+		bodies = []string{FormatStatusComment(analysis, consoleURL)}
+	} else {
+		bodies = FormatComments(analysis, consoleURL, opts.MaxLength-opts.Run.MarkerLength())
+	}
+	bodies[0] += opts.Run.Marker(bodies[0])
+	for i, body := range bodies {
+		if len(bodies) > 1 {
+			fmt.Fprintf(sb, "===== Summary comment (part %d of %d) =====\n", i+1, len(bodies))
+		} else {
+			sb.WriteString("===== Summary comment =====\n")
+		}
+		sb.WriteString(strings.TrimRight(body, "\n"))
+		sb.WriteString("\n\n")
+	}
+
+	if opts.Compact || analysis.ShouldProceed {
+		return sb.String()
+	}
+	for _, issue := range analysis.RequiredCodeMitigations {
+		if issue.LineNumber == 0 {
+			continue
+		}
+		fmt.Fprintf(sb, "===== Inline comment on %s:%d =====\n", SanitizePath(issue.Path), issue.LineNumber)
+		sb.WriteString(FormatInlineComment(issue))
+		sb.WriteString("\n\n")
+	}
+	return sb.String()
+}
@@ -0,0 +1,64 @@
+// Copyright (c) Kusari <https://www.kusari.dev/>
+// SPDX-License-Identifier: MIT
+
+package comment
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/kusaridev/kusari-cli/v2/api"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFormatPreview(t *testing.T) {
+	analysis := &api.SecurityAnalysis{
+		ShouldProceed: false,
+		Justification: "SQL injection",
+		RequiredCodeMitigations: []api.CodeMitigationItem{
+			{Content: "Use a prepared statement", Path: "./db/query.go", LineNumber: 12, Code: "db.Query(q, id)"},
+			{Content: "No line"},
+		},
+	}
+
+	preview := FormatPreview(analysis, "https://console.example.com", PreviewOptions{MaxLength: GitHubMaxLength})
+	assert.True(t, strings.HasPrefix(preview, "===== Summary comment =====\n"))
+	assert.Contains(t, preview, "<!-- IGNORE_KUSARI_COMMENT -->")
+	assert.Contains(t, preview, "===== Inline comment on db/query.go:12 =====\n")
+	assert.Contains(t, preview, FormatInlineComment(analysis.RequiredCodeMitigations[0]))
+	assert.Equal(t, 1, strings.Count(preview, "===== Inline comment"))
+}
+
+func TestFormatPreviewStyles(t *testing.T) {
+	blocked := &api.SecurityAnalysis{
+		ShouldProceed:           false,
+		RequiredCodeMitigations: []api.CodeMitigationItem{{Content: "issue", Path: "a.go", LineNumber: 1}},
+	}
+
+	compact := FormatPreview(blocked, "", PreviewOptions{MaxLength: GitLabMaxLength, Compact: true})
+	assert.Contains(t, compact, FormatStatusComment(blocked, ""))
+	assert.NotContains(t, compact, "Inline comment")
+
+	assert.Equal(t, "Reaction: 👎\n", FormatPreview(blocked, "", PreviewOptions{Reaction: true}))
+	assert.Equal(t, "Reaction: 👍\n", FormatPreview(&api.SecurityAnalysis{ShouldProceed: true}, "", PreviewOptions{Reaction: true}))
+}
+
+func TestFormatPreviewNoIssues(t *testing.T) {
+	preview := FormatPreview(&api.SecurityAnalysis{ShouldProceed: true}, "", PreviewOptions{MaxLength: GitHubMaxLength})
+	assert.True(t, strings.HasPrefix(preview, "No issues found - no comment would be posted"))
+	assert.Contains(t, preview, "===== Summary comment =====")
+
+	assert.Contains(t, FormatPreview(nil, "", PreviewOptions{}), "No analysis results available")
+}
+
+func TestFormatPreviewParts(t *testing.T) {
+	var mitigations []api.CodeMitigationItem
+	for range 3 {
+		mitigations = append(mitigations, api.CodeMitigationItem{Content: "issue", Path: "a.go", Code: strings.Repeat("x", 400)})
+	}
+	analysis := &api.SecurityAnalysis{ShouldProceed: false, RequiredCodeMitigations: mitigations}
+
+	preview := FormatPreview(analysis, "", PreviewOptions{MaxLength: 1000})
+	assert.Contains(t, preview, "===== Summary comment (part 1 of ")
+	assert.Contains(t, preview, PartMarker(2))
+}
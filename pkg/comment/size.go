@@ -0,0 +1,215 @@
+// Copyright (c) Kusari <https://www.kusari.dev/>
+// SPDX-License-Identifier: MIT
+
+package comment
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/kusaridev/kusari-cli/v2/api"
+)
+
+// Maximum comment body sizes, in bytes. GitHub rejects comments over 65536
+// characters and GitLab notes over 1,000,000; counting bytes keeps
+// multi-byte text under both.
+const (
+	GitHubMaxLength = 65536
+	GitLabMaxLength = 1000000
+)
+
+// truncatedSuffix ends a code fix cut short to fit a comment.
+const truncatedSuffix = "\n... (truncated)"
+
+var partMarkerRegex = regexp.MustCompile(`<!-- KUSARI_COMMENT_PART:(\d+) -->`)
+
+// PartMarker returns the hidden marker identifying continuation comment
+// part n (2 or more), used to find it again on the next run.
+func PartMarker(n int) string {
+	return fmt.Sprintf("<!-- KUSARI_COMMENT_PART:%d -->", n)
+}
+
+// ParsePartMarker returns the part number of a continuation comment body,
+// or false if body isn't one.
+func ParsePartMarker(body string) (int, bool) {
+	m := partMarkerRegex.FindStringSubmatch(body)
+	if m == nil {
+		return 0, false
+	}
+	n, err := strconv.Atoi(m[1])
+	return n, err == nil
+}
+
+// IsSummary reports whether body is a Kusari summary comment, by its
+// marker or by the headings of earlier CLI versions.
+func IsSummary(body string) bool {
+	return strings.Contains(body, "IGNORE_KUSARI_COMMENT") ||
+		strings.Contains(body, "Kusari Analysis Results") ||
+		strings.Contains(body, "Kusari Security Scan Results")
+}
+
+// FormatComments formats analysis as comments of at most maxLen bytes.
+//
+// The full comment is used when it fits. Otherwise the findings are capped
+// to those that fit, with a link to the rest in the console. Without a
+// console URL to link to, or when not even one finding fits, the findings
+// are split across continuation comments instead, each marked with
+// PartMarker. A code fix too large for any comment is truncated.
+func FormatComments(analysis *api.SecurityAnalysis, consoleURL string, maxLen int) []string {
+	full := FormatComment(analysis, consoleURL)
+	if len(full) <= maxLen || analysis.ShouldProceed {
+		return []string{full}
+	}
+
+	tmpl, err := parseTemplate()
+	if err != nil {
+		return []string{full}
+	}
+	render := func(name string, data AnalysisCommentData) string {
+		var buf bytes.Buffer
+		if err := tmpl.ExecuteTemplate(&buf, name, data); err != nil {
+			return FormatCommentFallback(data.FinalAnalysis, consoleURL)
+		}
+		return buf.String()
+	}
+
+	items := findings(analysis)
+	// first renders the main comment with items[:n].
+	first := func(n int, continued bool) string {
+		return render("analysisComment", AnalysisCommentData{
+			FinalAnalysis: withFindings(analysis, items[:n]),
+			ConsoleURL:    consoleURL,
+			Omitted:       len(items) - n,
+			Continued:     continued,
+		})
+	}
+
+	if consoleURL != "" {
+		if n := fit(len(items), maxLen, func(n int) string { return first(n, false) }); n > 0 {
+			return []string{first(n, false)}
+		}
+	}
+
+	// Last resort: paginate. Parts are sized with the widest part numbers
+	// they could get, then rendered with the real ones.
+	const widest = 999
+	page := func(part, parts int, fs []finding, omitted int) string {
+		return render("continuation", AnalysisCommentData{
+			FinalAnalysis: withFindings(analysis, fs),
+			Omitted:       omitted,
+			Continued:     true,
+			Part:          part,
+			Parts:         parts,
+			PartMarker:    PartMarker(part),
+		})
+	}
+	for i := range items {
+		items[i] = shrink(items[i], func(f finding) bool {
+			return len(page(widest, widest, []finding{f}, widest)) <= maxLen
+		})
+	}
+
+	n := fit(len(items), maxLen, func(n int) string { return first(n, true) })
+	bounds := [][2]int{{0, n}}
+	for from := n; from < len(items); {
+		k := fit(len(items)-from, maxLen, func(k int) string {
+			return page(widest, widest, items[from:from+k], len(items)-from-k)
+		})
+		k = max(k, 1)
+		bounds = append(bounds, [2]int{from, from + k})
+		from += k
+	}
+
+	bodies := []string{first(n, len(bounds) > 1)}
+	for i, b := range bounds[1:] {
+		bodies = append(bodies, page(i+2, len(bounds), items[b[0]:b[1]], len(items)-b[1]))
+	}
+	return bodies
+}
+
+// fit returns the largest n in [0, limit] for which render(n) is at most
+// maxLen bytes, assuming the length grows with n.
+func fit(limit, maxLen int, render func(n int) string) int {
+	lo, hi := 0, limit
+	for lo < hi {
+		mid := (lo + hi + 1) / 2
+		if len(render(mid)) <= maxLen {
+			lo = mid
+		} else {
+			hi = mid - 1
+		}
+	}
+	return lo
+}
+
+// finding is one code or dependency mitigation, in comment order.
+type finding struct {
+	code *api.CodeMitigationItem
+	dep  *api.DependencyMitigationItem
+}
+
+func findings(a *api.SecurityAnalysis) []finding {
+	out := make([]finding, 0, len(a.RequiredCodeMitigations)+len(a.RequiredDependencyMitigations))
+	for i := range a.RequiredCodeMitigations {
+		item := a.RequiredCodeMitigations[i]
+		out = append(out, finding{code: &item})
+	}
+	for i := range a.RequiredDependencyMitigations {
+		item := a.RequiredDependencyMitigations[i]
+		out = append(out, finding{dep: &item})
+	}
+	return out
+}
+
+// withFindings returns a copy of a with only the given findings.
+func withFindings(a *api.SecurityAnalysis, fs []finding) *api.SecurityAnalysis {
+	c := *a
+	c.RequiredCodeMitigations = nil
+	c.RequiredDependencyMitigations = nil
+	for _, f := range fs {
+		if f.code != nil {
+			c.RequiredCodeMitigations = append(c.RequiredCodeMitigations, *f.code)
+		} else {
+			c.RequiredDependencyMitigations = append(c.RequiredDependencyMitigations, *f.dep)
+		}
+	}
+	return &c
+}
+
+// shrink truncates the code fix, then the text, of a finding until fits
+// reports that a comment with it alone is small enough.
+func shrink(f finding, fits func(finding) bool) finding {
+	for !fits(f) {
+		switch {
+		case f.code != nil && f.code.Code != "":
+			c := *f.code
+			c.Code = cut(c.Code)
+			f.code = &c
+		case f.code != nil && f.code.Content != "":
+			c := *f.code
+			c.Content = cut(c.Content)
+			f.code = &c
+		case f.dep != nil && f.dep.Content != "":
+			d := *f.dep
+			d.Content = cut(d.Content)
+			f.dep = &d
+		default:
+			return f
+		}
+	}
+	return f
+}
+
+// cut halves s and marks it as truncated. A string cut before loses half
+// of what remains.
+func cut(s string) string {
+	body := strings.TrimSuffix(s, truncatedSuffix)
+	body = strings.ToValidUTF8(body[:len(body)/2], "")
+	if body == "" {
+		return ""
+	}
+	return body + truncatedSuffix
+}
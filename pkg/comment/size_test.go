@@ -0,0 +1,100 @@
+// Copyright (c) Kusari <https://www.kusari.dev/>
+// SPDX-License-Identifier: MIT
+
+package comment
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/kusaridev/kusari-cli/v2/api"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func largeAnalysis(n int) *api.SecurityAnalysis {
+	a := &api.SecurityAnalysis{ShouldProceed: false, Justification: "Many issues"}
+	for i := range n {
+		a.RequiredCodeMitigations = append(a.RequiredCodeMitigations, api.CodeMitigationItem{
+			Content:    fmt.Sprintf("Finding %d", i),
+			Path:       "main.go",
+			LineNumber: i + 1,
+			Code:       strings.Repeat("x := 1\n", 5),
+		})
+	}
+	a.RequiredDependencyMitigations = []api.DependencyMitigationItem{{Content: "Upgrade golang.org/x/net"}}
+	return a
+}
+
+func TestFormatComments_Fits(t *testing.T) {
+	a := largeAnalysis(3)
+	assert.Equal(t, []string{FormatComment(a, "https://console.example.com/r")}, FormatComments(a, "https://console.example.com/r", GitHubMaxLength))
+}
+
+func TestFormatComments_CapsWithConsoleLink(t *testing.T) {
+	const maxLen = 4000
+	a := largeAnalysis(200)
+	bodies := FormatComments(a, "https://console.example.com/r", maxLen)
+	require.Len(t, bodies, 1)
+	assert.LessOrEqual(t, len(bodies[0]), maxLen)
+	assert.Contains(t, bodies[0], "Finding 0")
+	assert.Regexp(t, `\.\.\.and \d+ more finding\(s\)\. \[View all findings in the Kusari console\]\(https://console.example.com/r\)`, bodies[0])
+	assert.Contains(t, bodies[0], "IGNORE_KUSARI_COMMENT")
+}
+
+func TestFormatComments_SplitsWithoutConsoleLink(t *testing.T) {
+	const maxLen = 4000
+	a := largeAnalysis(60)
+	bodies := FormatComments(a, "", maxLen)
+	require.Greater(t, len(bodies), 1)
+
+	all := strings.Join(bodies, "\n")
+	for i := range 60 {
+		assert.Contains(t, all, fmt.Sprintf("### Finding %d\n", i))
+	}
+	assert.Contains(t, all, "Upgrade golang.org/x/net")
+
+	assert.Contains(t, bodies[0], "IGNORE_KUSARI_COMMENT")
+	assert.Contains(t, bodies[0], "continue in the next comment")
+	for i, body := range bodies {
+		assert.LessOrEqual(t, len(body), maxLen, "part %d", i+1)
+		if i == 0 {
+			_, ok := ParsePartMarker(body)
+			assert.False(t, ok)
+			continue
+		}
+		n, ok := ParsePartMarker(body)
+		assert.True(t, ok)
+		assert.Equal(t, i+1, n)
+		assert.Contains(t, body, fmt.Sprintf("part %d of %d", i+1, len(bodies)))
+		// Continuation comments must not look like the summary comment.
+		assert.NotContains(t, body, "IGNORE_KUSARI_COMMENT")
+		assert.NotContains(t, body, "Kusari Analysis Results")
+	}
+}
+
+func TestFormatComments_TruncatesOversizedFinding(t *testing.T) {
+	const maxLen = 4000
+	a := &api.SecurityAnalysis{RequiredCodeMitigations: []api.CodeMitigationItem{
+		{Content: "Huge fix", Path: "main.go", LineNumber: 1, Code: strings.Repeat("y := 2\n", 2000)},
+	}}
+	bodies := FormatComments(a, "", maxLen)
+	require.Len(t, bodies, 2)
+	for _, body := range bodies {
+		assert.LessOrEqual(t, len(body), maxLen)
+	}
+	assert.Contains(t, bodies[1], "Huge fix")
+	assert.Contains(t, bodies[1], "... (truncated)")
+}
+
+func TestFormatComment_CollapsesLongSections(t *testing.T) {
+	a := largeAnalysis(1)
+	a.RequiredCodeMitigations[0].Code = strings.Repeat("z := 3\n", 20)
+	for i := range collapseItems + 1 {
+		a.RequiredDependencyMitigations = append(a.RequiredDependencyMitigations, api.DependencyMitigationItem{Content: fmt.Sprintf("Upgrade dep %d", i)})
+	}
+	body := FormatComment(a, "")
+	assert.Contains(t, body, "<details><summary><b>Potential Code Fix</b> (20 lines)</summary>")
+	assert.Contains(t, body, "<details><summary>12 dependency mitigations</summary>")
+}
@@ -1,12 +1,18 @@
 package configuration
 
 import (
+	"bytes"
 	"errors"
 	"fmt"
+	"io"
+	"math"
 	"os"
+	"path/filepath"
 	"reflect"
+	"slices"
 
 	"github.com/kusaridev/kusari-cli/v2/api/configuration"
+	"github.com/kusaridev/kusari-cli/v2/pkg/github"
 	"gopkg.in/yaml.v3"
 )
 
@@ -19,12 +25,38 @@ var DefaultConfig = configuration.Config{
 	ContainerVersionPinningCheckEnabled:    true,
 	PostCommentOnFailure:                   true,
 	PostCommentOnSuccess:                   false,
+	CommentMode:                            configuration.CommentModeUpdate,
+	CommentStyle:                           configuration.CommentStyleFull,
+	PRLabelsEnabled:                        false,
+	PRLabelPassed:                          "kusari:passed",
+	PRLabelBlocked:                         "kusari:blocked",
+	PRLabelNeedsReview:                     "kusari:needs-review",
+	SecurityReviewers:                      []string{},
+	SecurityReviewThreshold:                1,
+	IssueLabels:                            []string{"kusari"},
+	IssueAssignees:                         []string{},
+	JiraIssueType:                          "Bug",
+	ServiceNowTable:                        "incident",
+	IaCPaths: []string{
+		"**/*.tf", "**/*.tfvars", "**/*.hcl", "**/*.bicep",
+		"**/*.yaml", "**/*.yml",
+		"**/Dockerfile", "**/Dockerfile.*", "**/Containerfile",
+	},
 	// SBOM Generation is disabled by default to avoid breaking existing implementations
 	SBOMGenerationEnabled:      false,
 	SBOMSubjectNameOverride:    "",
 	SBOMSubjectVersionOverride: "",
 }
 
+// strict makes parsing a config file fail on unknown settings and on
+// settings of the wrong type, which are otherwise dropped or converted.
+var strict bool
+
+// SetStrict sets whether config files are parsed strictly.
+func SetStrict(enabled bool) {
+	strict = enabled
+}
+
 func GenerateConfig(forceWrite bool) error {
 	// check to see if the config file already exists
 	_, err := os.Stat(ConfigFilename)
@@ -57,10 +89,21 @@ func UpdateConfig() error {
 		return fmt.Errorf("failed to read file %s: %w", ConfigFilename, err)
 	}
 
+	if err := checkStrict(configData, ConfigFilename); err != nil {
+		return err
+	}
 	var existingConfig map[string]interface{}
 	if err := yaml.Unmarshal(configData, &existingConfig); err != nil {
 		return fmt.Errorf("failed to parse config file: %w", err)
 	}
+	for _, key := range unknownSettings(existingConfig) {
+		fmt.Fprintf(os.Stderr, "Warning: dropping unknown setting %s from %s (--strict fails instead)\n", key, ConfigFilename)
+	}
+	// Filling in the defaults would override the settings of the base
+	if extends, ok := existingConfig[extendsKey]; ok {
+		fmt.Fprintf(os.Stderr, "%s extends %v: not adding defaults, which would override it\n", ConfigFilename, extends)
+		return nil
+	}
 
 	updatedConfig, err := mergeConfigs(DefaultConfig, existingConfig)
 	if err != nil {
@@ -75,71 +118,251 @@ func UpdateConfig() error {
 	return os.WriteFile(ConfigFilename, []byte(cfgYaml), 0600)
 }
 
+// Load reads the config file in dir, filling in defaults for missing
+// settings. It returns DefaultConfig if there is no config file.
+func Load(dir string) (configuration.Config, error) {
+	path := filepath.Join(dir, ConfigFilename)
+	configData, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return DefaultConfig, nil
+	} else if err != nil {
+		return DefaultConfig, fmt.Errorf("failed to read file %s: %w", path, err)
+	}
+	return parse(configData, path)
+}
+
+// parse parses the config file data read from path, filling in defaults
+// for missing settings.
+func parse(configData []byte, path string) (configuration.Config, error) {
+	if err := checkStrict(configData, path); err != nil {
+		return DefaultConfig, err
+	}
+	var existingConfig map[string]interface{}
+	if err := yaml.Unmarshal(configData, &existingConfig); err != nil {
+		return DefaultConfig, fmt.Errorf("failed to parse config file: %w", err)
+	}
+	existingConfig, err := resolveExtends(existingConfig, path)
+	if err != nil {
+		return DefaultConfig, err
+	}
+
+	cfg, err := mergeConfigs(DefaultConfig, existingConfig)
+	if err != nil {
+		return DefaultConfig, fmt.Errorf("error merging configs: %w", err)
+	}
+
+	switch cfg.CommentMode {
+	case configuration.CommentModeUpdate, configuration.CommentModeMinimize:
+	default:
+		return DefaultConfig, fmt.Errorf("invalid comment_mode %q in %s (valid: %s, %s)", cfg.CommentMode, path,
+			configuration.CommentModeUpdate, configuration.CommentModeMinimize)
+	}
+	switch cfg.CommentStyle {
+	case configuration.CommentStyleFull, configuration.CommentStyleCompact, configuration.CommentStyleReaction:
+	default:
+		return DefaultConfig, fmt.Errorf("invalid comment_style %q in %s (valid: %s, %s, %s)", cfg.CommentStyle, path,
+			configuration.CommentStyleFull, configuration.CommentStyleCompact, configuration.CommentStyleReaction)
+	}
+	return cfg, nil
+}
+
+// Push rolls the config file at path out to the repositories of
+// opts: it is committed to opts.Branch of each, with a pull request into
+// the default branch. The file is checked to be a valid config first, so
+// a typo isn't proposed to every repository.
+func Push(path string, opts github.PushOptions) ([]github.PushResult, error) {
+	configData, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read file %s: %w", path, err)
+	}
+	if _, err := parse(configData, path); err != nil {
+		return nil, err
+	}
+	if opts.Path == "" {
+		opts.Path = ConfigFilename
+	}
+	opts.Content = configData
+	return github.PushConfig(opts), nil
+}
+
+// checkStrict returns an error for the first unknown or mistyped setting
+// of the config file data read from path, in strict mode.
+func checkStrict(configData []byte, path string) error {
+	if !strict {
+		return nil
+	}
+	dec := yaml.NewDecoder(bytes.NewReader(configData))
+	dec.KnownFields(true)
+	var cfg configuration.Config
+	if err := dec.Decode(&cfg); err != nil && !errors.Is(err, io.EOF) {
+		return fmt.Errorf("invalid config file %s: %w", path, err)
+	}
+	return nil
+}
+
+// unknownSettings returns the settings of a config file that aren't
+// settings of configuration.Config, sorted.
+func unknownSettings(settings map[string]interface{}) []string {
+	known := map[string]bool{}
+	t := reflect.TypeOf(configuration.Config{})
+	for i := 0; i < t.NumField(); i++ {
+		name := t.Field(i).Tag.Get("yaml")
+		if commaIdx := findComma(name); commaIdx != -1 {
+			name = name[:commaIdx]
+		}
+		known[name] = true
+	}
+	var unknown []string
+	for key := range settings {
+		if !known[key] {
+			unknown = append(unknown, key)
+		}
+	}
+	slices.Sort(unknown)
+	return unknown
+}
+
 // A function to compare the configs and merge them together
 func mergeConfigs(defaultConfig configuration.Config, existingConfig map[string]interface{}) (configuration.Config, error) {
 	result := defaultConfig
+	if err := mergeStruct(reflect.ValueOf(&result).Elem(), existingConfig, ""); err != nil {
+		return defaultConfig, err
+	}
+	return result, nil
+}
 
-	// Use reflection to iterate over all struct fields
-	resultValue := reflect.ValueOf(&result).Elem()
-	resultType := reflect.TypeOf(result)
-
-	for i := 0; i < resultType.NumField(); i++ {
-		field := resultType.Field(i)
-
-		// Get the YAML tag name for this field
-		yamlTag := field.Tag.Get("yaml")
+// mergeStruct sets the fields of the struct v that settings has, by their
+// YAML tag names. prefix is the path of v in the config file, for errors.
+func mergeStruct(v reflect.Value, settings map[string]interface{}, prefix string) error {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
 
 		// Parse the yaml tag to extract just the field name (before any comma)
 		// e.g., "sbom_subject_name_override,omitempty" -> "sbom_subject_name_override"
-		yamlFieldName := yamlTag
-		if commaIdx := findComma(yamlTag); commaIdx != -1 {
-			yamlFieldName = yamlTag[:commaIdx]
+		yamlFieldName := field.Tag.Get("yaml")
+		if commaIdx := findComma(yamlFieldName); commaIdx != -1 {
+			yamlFieldName = yamlFieldName[:commaIdx]
 		}
 
 		// Check if this field was present in the original YAML
-		if val, exists := existingConfig[yamlFieldName]; exists {
-			resultFieldValue := resultValue.Field(i)
-
-			// Only set if the field is settable
-			if resultFieldValue.CanSet() {
-				// Convert and set the value based on field type
-				switch resultFieldValue.Kind() {
-				case reflect.Bool:
-					if boolVal, ok := val.(bool); ok {
-						resultFieldValue.SetBool(boolVal)
-					} else {
-						return defaultConfig, fmt.Errorf("could not parse %s as a boolean", yamlTag)
-					}
-				case reflect.String:
-					if stringVal, ok := val.(string); ok {
-						resultFieldValue.SetString(stringVal)
-					} else {
-						return defaultConfig, fmt.Errorf("could not parse %s as a string", yamlTag)
-					}
-				case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
-					// YAML can parse numbers as int, int64, or sometimes float64
-					switch v := val.(type) {
-					case int:
-						resultFieldValue.SetInt(int64(v))
-					case int64:
-						resultFieldValue.SetInt(v)
-					case float64:
-						resultFieldValue.SetInt(int64(v))
-					default: // We should never get here
-						return defaultConfig, fmt.Errorf("could not parse %s as an integer", yamlTag)
-					}
-				case reflect.Float32, reflect.Float64:
-					if floatVal, ok := val.(float64); ok {
-						resultFieldValue.SetFloat(floatVal)
-					}
-				default: // We should never get here
-					return defaultConfig, fmt.Errorf("could not parse %s as a %s", yamlTag, resultFieldValue.Kind())
-				}
+		val, exists := settings[yamlFieldName]
+		if !exists || !v.Field(i).CanSet() {
+			continue
+		}
+		if err := setValue(v.Field(i), val, prefix+yamlFieldName); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// setValue sets v to val, as parsed from YAML, converting it to the type
+// of v. Lists and mappings are converted element by element, and struct
+// mappings field by field. name is the path of val in the config file.
+func setValue(v reflect.Value, val interface{}, name string) error {
+	switch v.Kind() {
+	case reflect.Bool:
+		boolVal, ok := val.(bool)
+		if !ok {
+			return fmt.Errorf("could not parse %s as a boolean", name)
+		}
+		v.SetBool(boolVal)
+	case reflect.String:
+		stringVal, ok := val.(string)
+		if !ok {
+			return fmt.Errorf("could not parse %s as a string", name)
+		}
+		v.SetString(stringVal)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		// YAML can parse numbers as int, int64, or sometimes float64
+		switch n := val.(type) {
+		case int:
+			v.SetInt(int64(n))
+		case int64:
+			v.SetInt(n)
+		case float64:
+			if strict && n != math.Trunc(n) {
+				return fmt.Errorf("could not parse %s as an integer", name)
+			}
+			v.SetInt(int64(n))
+		default:
+			return fmt.Errorf("could not parse %s as an integer", name)
+		}
+	case reflect.Float32, reflect.Float64:
+		switch n := val.(type) {
+		case float64:
+			v.SetFloat(n)
+		case int:
+			v.SetFloat(float64(n))
+		default:
+			return fmt.Errorf("could not parse %s as a number", name)
+		}
+	case reflect.Slice:
+		items, ok := val.([]interface{})
+		if !ok {
+			return fmt.Errorf("could not parse %s as a list of %ss", name, kindName(v.Type().Elem()))
+		}
+		list := reflect.MakeSlice(v.Type(), len(items), len(items))
+		for i, item := range items {
+			if err := setValue(list.Index(i), item, fmt.Sprintf("%s[%d]", name, i)); err != nil {
+				return fmt.Errorf("could not parse %s as a list of %ss: %w", name, kindName(v.Type().Elem()), err)
+			}
+		}
+		v.Set(list)
+	case reflect.Map:
+		// Only maps with string keys are supported
+		m, ok := val.(map[string]interface{})
+		if !ok || v.Type().Key().Kind() != reflect.String {
+			return fmt.Errorf("could not parse %s as a mapping", name)
+		}
+		if v.Type() == reflect.TypeOf(m) {
+			// Values are kept as parsed
+			v.Set(reflect.ValueOf(m))
+			return nil
+		}
+		mapping := reflect.MakeMapWithSize(v.Type(), len(m))
+		for k, item := range m {
+			elem := reflect.New(v.Type().Elem()).Elem()
+			if err := setValue(elem, item, name+"."+k); err != nil {
+				return err
 			}
+			mapping.SetMapIndex(reflect.ValueOf(k).Convert(v.Type().Key()), elem)
+		}
+		v.Set(mapping)
+	case reflect.Struct:
+		m, ok := val.(map[string]interface{})
+		if !ok {
+			return fmt.Errorf("could not parse %s as a mapping", name)
 		}
+		return mergeStruct(v, m, name+".")
+	case reflect.Pointer:
+		elem := reflect.New(v.Type().Elem())
+		if err := setValue(elem.Elem(), val, name); err != nil {
+			return err
+		}
+		v.Set(elem)
+	default: // We should never get here
+		return fmt.Errorf("could not parse %s as a %s", name, v.Kind())
 	}
+	return nil
+}
 
-	return result, nil
+// kindName names the kind of value of type t in errors.
+func kindName(t reflect.Type) string {
+	switch t.Kind() {
+	case reflect.Bool:
+		return "boolean"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return "integer"
+	case reflect.Float32, reflect.Float64:
+		return "number"
+	case reflect.Slice:
+		return "list"
+	case reflect.Map, reflect.Struct:
+		return "mapping"
+	}
+	return t.Kind().String()
 }
 
 // findComma returns the index of the first comma in a string, or -1 if not found
@@ -11,9 +11,12 @@ import (
 	"os"
 	"os/exec"
 	"path/filepath"
+	"reflect"
 	"testing"
 
+	"github.com/kusaridev/kusari-cli/v2/pkg/github"
 	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
 )
 
 // Test generating a new file when none exists
@@ -196,6 +199,132 @@ func TestGeneratedConfigOmitsEmptySBOMFields(t *testing.T) {
 	require.NoError(t, os.Chdir(cwd))
 }
 
+// Test loading the config file of a repository
+func TestLoad(t *testing.T) {
+	dir := t.TempDir()
+
+	// No file: defaults
+	cfg, err := Load(dir)
+	require.NoError(t, err)
+	require.Equal(t, DefaultConfig, cfg)
+
+	// Missing settings are filled in from the defaults
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "kusari.yaml"), []byte("comment_mode: minimize\npost_comment_on_success: true\n"), 0600))
+	cfg, err = Load(dir)
+	require.NoError(t, err)
+	require.Equal(t, "minimize", cfg.CommentMode)
+	require.True(t, cfg.PostCommentOnSuccess)
+	require.True(t, cfg.PostCommentOnFailure)
+
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "kusari.yaml"), []byte("comment_mode: replace\n"), 0600))
+	_, err = Load(dir)
+	require.ErrorContains(t, err, `invalid comment_mode "replace"`)
+
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "kusari.yaml"), []byte("comment_style: emoji\n"), 0600))
+	_, err = Load(dir)
+	require.ErrorContains(t, err, `invalid comment_style "emoji"`)
+
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "kusari.yaml"), []byte("security_reviewers: [alice, acme/security]\n"), 0600))
+	cfg, err = Load(dir)
+	require.NoError(t, err)
+	require.Equal(t, []string{"alice", "acme/security"}, cfg.SecurityReviewers)
+
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "kusari.yaml"), []byte("security_reviewers: alice\n"), 0600))
+	_, err = Load(dir)
+	require.ErrorContains(t, err, "could not parse security_reviewers as a list of strings")
+
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "kusari.yaml"), []byte("jira_custom_fields:\n  customfield_10010: {value: High}\n"), 0600))
+	cfg, err = Load(dir)
+	require.NoError(t, err)
+	require.Equal(t, map[string]any{"customfield_10010": map[string]any{"value": "High"}}, cfg.JiraCustomFields)
+	require.Equal(t, "Bug", cfg.JiraIssueType)
+}
+
+// Test strict parsing of unknown and mistyped settings
+func TestStrict(t *testing.T) {
+	dir := t.TempDir()
+	t.Chdir(dir)
+	t.Cleanup(func() { SetStrict(false) })
+
+	require.NoError(t, os.WriteFile(ConfigFilename, []byte("comment_styel: compact\n"), 0600))
+	_, err := Load(dir)
+	require.NoError(t, err)
+
+	SetStrict(true)
+	_, err = Load(dir)
+	require.ErrorContains(t, err, "field comment_styel not found")
+	require.ErrorContains(t, UpdateConfig(), "field comment_styel not found")
+
+	require.NoError(t, os.WriteFile(ConfigFilename, []byte("security_review_threshold: 1.5\n"), 0600))
+	_, err = Load(dir)
+	require.ErrorContains(t, err, "could not parse security_review_threshold as an integer")
+
+	// Unknown settings of a base fail too
+	require.NoError(t, os.WriteFile("org.yaml", []byte("pr_labels: true\n"), 0600))
+	require.NoError(t, os.WriteFile(ConfigFilename, []byte("extends: org.yaml\n"), 0600))
+	_, err = Load(dir)
+	require.ErrorContains(t, err, "field pr_labels not found")
+
+	// Without strict, updating drops unknown settings
+	SetStrict(false)
+	require.NoError(t, os.WriteFile(ConfigFilename, []byte("comment_styel: compact\n"), 0600))
+	require.NoError(t, UpdateConfig())
+	data, err := os.ReadFile(ConfigFilename)
+	require.NoError(t, err)
+	require.NotContains(t, string(data), "comment_styel")
+}
+
+// Test merging lists and mappings of any type, and nested structures
+func TestSetValue(t *testing.T) {
+	type rule struct {
+		Name    string   `yaml:"name"`
+		Enabled *bool    `yaml:"enabled"`
+		Paths   []string `yaml:"paths,omitempty"`
+	}
+	var settings struct {
+		Thresholds map[string]int  `yaml:"thresholds"`
+		Rules      []rule          `yaml:"rules"`
+		Weights    []float64       `yaml:"weights"`
+		Nested     map[string]rule `yaml:"nested"`
+	}
+	var parsed map[string]interface{}
+	require.NoError(t, yaml.Unmarshal([]byte(`
+thresholds: {high: 1, medium: 5}
+rules:
+  - name: pinning
+    enabled: false
+    paths: [".github/**"]
+weights: [1, 0.5]
+nested:
+  ci: {name: ci}
+`), &parsed))
+	require.NoError(t, mergeStruct(reflect.ValueOf(&settings).Elem(), parsed, ""))
+	require.Equal(t, map[string]int{"high": 1, "medium": 5}, settings.Thresholds)
+	require.Len(t, settings.Rules, 1)
+	require.Equal(t, "pinning", settings.Rules[0].Name)
+	require.False(t, *settings.Rules[0].Enabled)
+	require.Equal(t, []string{".github/**"}, settings.Rules[0].Paths)
+	require.Equal(t, []float64{1, 0.5}, settings.Weights)
+	require.Equal(t, "ci", settings.Nested["ci"].Name)
+
+	require.NoError(t, yaml.Unmarshal([]byte("rules: [{name: [x]}]\n"), &parsed))
+	err := mergeStruct(reflect.ValueOf(&settings).Elem(), parsed, "")
+	require.ErrorContains(t, err, "could not parse rules[0].name as a string")
+}
+
+// Test that an invalid config file isn't pushed anywhere
+func TestPushInvalid(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "kusari.yaml")
+	require.NoError(t, os.WriteFile(path, []byte("comment_style: emoji\n"), 0600))
+	// No GitHub URL: pushing would fail on the network, not validation
+	pushed, err := Push(path, github.PushOptions{Org: "acme", Repos: []string{"api"}})
+	require.ErrorContains(t, err, `invalid comment_style "emoji"`)
+	require.Empty(t, pushed)
+
+	_, err = Push(filepath.Join(t.TempDir(), "missing.yaml"), github.PushOptions{})
+	require.ErrorContains(t, err, "failed to read file")
+}
+
 //
 // Some helper functions along the way
 //
@@ -0,0 +1,180 @@
+// Copyright (c) Kusari <https://www.kusari.dev/>
+// SPDX-License-Identifier: MIT
+
+package configuration
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/kusaridev/kusari-cli/v2/pkg/transport"
+	"gopkg.in/yaml.v3"
+)
+
+// extendsKey is the config setting naming the base config a config file
+// extends.
+const extendsKey = "extends"
+
+// maxExtendsDepth bounds how many bases a config can be stacked on.
+const maxExtendsDepth = 5
+
+// maxBaseSize bounds the size of a base config fetched from a URL.
+const maxBaseSize = 1 << 20
+
+// baseClient fetches base configs; tests replace it to trust their
+// servers.
+var baseClient = func() *http.Client {
+	return &http.Client{Timeout: transport.Timeout(30 * time.Second)}
+}
+
+// resolveExtends returns the settings of the config file read from
+// location with those of the base it extends, if any, merged under them:
+// local settings take precedence over the base's, which take precedence
+// over its own base's. The base is an https URL or a path relative to
+// location (a path or a URL itself). Base configs can set what the scan
+// reports and to whom, so paths may not leave the directory of location,
+// and URLs must be https.
+func resolveExtends(settings map[string]interface{}, location string) (map[string]interface{}, error) {
+	return resolveFrom(settings, filepath.Dir(location), location, []string{location})
+}
+
+func resolveFrom(settings map[string]interface{}, root, location string, seen []string) (map[string]interface{}, error) {
+	val, ok := settings[extendsKey]
+	if !ok {
+		return settings, nil
+	}
+	extends, ok := val.(string)
+	if !ok || extends == "" {
+		return nil, fmt.Errorf("could not parse %s as a string in %s", extendsKey, location)
+	}
+	base, err := baseLocation(root, location, extends)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s %q in %s: %w", extendsKey, extends, location, err)
+	}
+	for _, s := range seen {
+		if s == base {
+			return nil, fmt.Errorf("%s extends itself through %s", location, strings.Join(seen, " -> "))
+		}
+	}
+	if len(seen) > maxExtendsDepth {
+		return nil, fmt.Errorf("%s extends more than %d configs", seen[0], maxExtendsDepth)
+	}
+
+	data, err := readBase(base)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s, the config %s extends: %w", base, location, err)
+	}
+	if err := checkStrict(data, base); err != nil {
+		return nil, err
+	}
+	var baseSettings map[string]interface{}
+	if err := yaml.Unmarshal(data, &baseSettings); err != nil {
+		return nil, fmt.Errorf("failed to parse config file %s: %w", base, err)
+	}
+	baseSettings, err = resolveFrom(baseSettings, root, base, append(seen, base))
+	if err != nil {
+		return nil, err
+	}
+	return deepMerge(baseSettings, settings), nil
+}
+
+// baseLocation returns where the base config extends names is, relative
+// to the config at location. Paths must stay under root.
+func baseLocation(root, location, extends string) (string, error) {
+	if strings.HasPrefix(extends, "http://") {
+		return "", fmt.Errorf("base configs are only fetched over https")
+	}
+	if isURL(extends) {
+		return extends, nil
+	}
+	if isURL(location) {
+		parent, err := url.Parse(location)
+		if err != nil {
+			return "", err
+		}
+		ref, err := url.Parse(extends)
+		if err != nil {
+			return "", err
+		}
+		base := parent.ResolveReference(ref).String()
+		if !isURL(base) {
+			return "", fmt.Errorf("base configs are only fetched over https")
+		}
+		return base, nil
+	}
+	if filepath.IsAbs(extends) {
+		return "", fmt.Errorf("must be an https URL or a path relative to %s", location)
+	}
+	base := filepath.Join(filepath.Dir(location), extends)
+	if !within(root, base) {
+		return "", fmt.Errorf("%s is outside %s", base, root)
+	}
+	return base, nil
+}
+
+// within reports whether path is under dir, symbolic links resolved.
+func within(dir, path string) bool {
+	if realDir, err := filepath.EvalSymlinks(dir); err == nil {
+		dir = realDir
+		if realPath, err := filepath.EvalSymlinks(path); err == nil {
+			path = realPath
+		}
+	}
+	rel, err := filepath.Rel(dir, path)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+// isURL reports whether location is an https URL rather than a path.
+func isURL(location string) bool {
+	return strings.HasPrefix(location, "https://")
+}
+
+// readBase reads the config at location, fetching it if it is a URL.
+func readBase(location string) ([]byte, error) {
+	if !isURL(location) {
+		return os.ReadFile(location)
+	}
+	resp, err := baseClient().Get(location)
+	if err != nil {
+		return nil, fmt.Errorf("request failed: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("status %d", resp.StatusCode)
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxBaseSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(data) > maxBaseSize {
+		return nil, fmt.Errorf("larger than %d bytes", maxBaseSize)
+	}
+	return data, nil
+}
+
+// deepMerge returns base with override merged over it: mappings are merged
+// key by key, recursively, and anything else in override, lists included,
+// replaces what base has.
+func deepMerge(base, override map[string]interface{}) map[string]interface{} {
+	merged := make(map[string]interface{}, len(base)+len(override))
+	for k, v := range base {
+		merged[k] = v
+	}
+	for k, v := range override {
+		baseMap, baseOK := merged[k].(map[string]interface{})
+		overrideMap, overrideOK := v.(map[string]interface{})
+		if baseOK && overrideOK {
+			merged[k] = deepMerge(baseMap, overrideMap)
+		} else {
+			merged[k] = v
+		}
+	}
+	return merged
+}
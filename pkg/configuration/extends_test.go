@@ -0,0 +1,125 @@
+// Copyright (c) Kusari <https://www.kusari.dev/>
+// SPDX-License-Identifier: MIT
+
+package configuration
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// Test a config extending a base config by path
+func TestLoadExtendsPath(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "policy"), 0700))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "policy", "org.yaml"), []byte(`
+comment_style: compact
+security_reviewers: [acme/security]
+jira_custom_fields:
+  customfield_1: org
+  customfield_2: org
+`), 0600))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "kusari.yaml"), []byte(`
+extends: policy/org.yaml
+security_reviewers: [alice]
+jira_custom_fields:
+  customfield_2: repo
+`), 0600))
+
+	cfg, err := Load(dir)
+	require.NoError(t, err)
+	require.Equal(t, "policy/org.yaml", cfg.Extends)
+	require.Equal(t, "compact", cfg.CommentStyle, "set by the base")
+	require.Equal(t, []string{"alice"}, cfg.SecurityReviewers, "lists are replaced")
+	require.Equal(t, map[string]any{"customfield_1": "org", "customfield_2": "repo"}, cfg.JiraCustomFields, "mappings are merged")
+	require.Equal(t, DefaultConfig.IssueLabels, cfg.IssueLabels, "set by neither")
+
+	// Invalid settings of the base fail the config
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "policy", "org.yaml"), []byte("comment_style: emoji\n"), 0600))
+	_, err = Load(dir)
+	require.ErrorContains(t, err, `invalid comment_style "emoji"`)
+
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "policy", "org.yaml"), []byte("extends: ../kusari.yaml\n"), 0600))
+	_, err = Load(dir)
+	require.ErrorContains(t, err, "extends itself")
+
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "kusari.yaml"), []byte("extends: missing.yaml\n"), 0600))
+	_, err = Load(dir)
+	require.ErrorContains(t, err, "failed to read")
+
+	// Paths may not leave the repository
+	for _, extends := range []string{"../org.yaml", "policy/../../org.yaml", filepath.Join(dir, "policy", "org.yaml")} {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "kusari.yaml"), []byte("extends: "+extends+"\n"), 0600))
+		_, err = Load(dir)
+		require.ErrorContains(t, err, "invalid extends", extends)
+	}
+	outside := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(outside, "org.yaml"), []byte("comment_style: compact\n"), 0600))
+	require.NoError(t, os.Symlink(outside, filepath.Join(dir, "outside")))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "kusari.yaml"), []byte("extends: outside/org.yaml\n"), 0600))
+	_, err = Load(dir)
+	require.ErrorContains(t, err, "is outside")
+}
+
+// Test a config extending a remote base config, itself extending another
+func TestLoadExtendsURL(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/kusari/org.yaml":
+			_, _ = w.Write([]byte("extends: base.yaml\ncomment_mode: minimize\n"))
+		case "/kusari/base.yaml":
+			_, _ = w.Write([]byte("comment_mode: update\npr_labels_enabled: true\n"))
+		case "/kusari/large.yaml":
+			_, _ = w.Write(make([]byte, maxBaseSize+1))
+		case "/kusari/plain.yaml":
+			_, _ = w.Write([]byte("extends: http://config.example.com/base.yaml\n"))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+	client := baseClient
+	baseClient = server.Client
+	defer func() { baseClient = client }()
+
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "kusari.yaml"), []byte("extends: "+server.URL+"/kusari/org.yaml\n"), 0600))
+	cfg, err := Load(dir)
+	require.NoError(t, err)
+	require.Equal(t, "minimize", cfg.CommentMode, "the nearer base takes precedence")
+	require.True(t, cfg.PRLabelsEnabled)
+
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "kusari.yaml"), []byte("extends: "+server.URL+"/missing.yaml\n"), 0600))
+	_, err = Load(dir)
+	require.ErrorContains(t, err, "status 404")
+
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "kusari.yaml"), []byte("extends: "+server.URL+"/kusari/large.yaml\n"), 0600))
+	_, err = Load(dir)
+	require.ErrorContains(t, err, "larger than")
+
+	// Bases are only fetched over https
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "kusari.yaml"), []byte("extends: "+server.URL+"/kusari/plain.yaml\n"), 0600))
+	_, err = Load(dir)
+	require.ErrorContains(t, err, "only fetched over https")
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "kusari.yaml"), []byte("extends: http://config.example.com/org.yaml\n"), 0600))
+	_, err = Load(dir)
+	require.ErrorContains(t, err, "only fetched over https")
+}
+
+// Test that updating a config extending a base doesn't override the
+// base's settings with defaults
+func TestUpdateWithExtends(t *testing.T) {
+	t.Chdir(t.TempDir())
+	config := []byte("extends: https://config.example.com/kusari-org.yaml\ncomment_style: compact\n")
+	require.NoError(t, os.WriteFile(ConfigFilename, config, 0600))
+
+	require.NoError(t, UpdateConfig())
+	got, err := os.ReadFile(ConfigFilename)
+	require.NoError(t, err)
+	require.Equal(t, config, got)
+}
@@ -9,4 +9,7 @@ const (
 
 	// DefaultConsoleURL is the default Kusari console URL
 	DefaultConsoleURL = "https://console.us.kusari.cloud/"
+
+	// DefaultAuthEndpoint is the default Kusari authentication endpoint
+	DefaultAuthEndpoint = "https://auth.us.kusari.cloud/"
 )
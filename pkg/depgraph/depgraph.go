@@ -0,0 +1,333 @@
+// Copyright (c) Kusari <https://www.kusari.dev/>
+// SPDX-License-Identifier: MIT
+
+// Package depgraph resolves the dependency graph of a project with its
+// package manager (go mod graph, npm ls, pip freeze), for upload as a
+// snapshot the platform can resolve reachability against. Unlike a flat
+// SBOM, the graph keeps which package pulled in which.
+package depgraph
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/kusaridev/kusari-cli/v2/pkg/transport"
+)
+
+// SchemaVersion is the version of the Graph format.
+const SchemaVersion = "1"
+
+// Relations of a node to the project, as deps.dev names them.
+const (
+	RelationSelf     = "SELF"
+	RelationDirect   = "DIRECT"
+	RelationIndirect = "INDIRECT"
+)
+
+// Graph is the resolved dependency graph of one ecosystem of a project.
+// Nodes[0] is the project itself.
+type Graph struct {
+	SchemaVersion string `json:"schema_version"`
+	Ecosystem     string `json:"ecosystem"` // go, npm or pypi
+	Resolver      string `json:"resolver"`  // Command the graph was resolved with
+	Nodes         []Node `json:"nodes"`
+	Edges         []Edge `json:"edges"`
+}
+
+// Node is a package version in the graph.
+type Node struct {
+	Purl    string `json:"purl"`
+	Name    string `json:"name"`
+	Version string `json:"version,omitempty"`
+	// Relation is empty when the resolver doesn't tell, as pip freeze
+	// lists installed packages without who required them.
+	Relation string `json:"relation,omitempty"`
+}
+
+// Edge is a dependency of the node at index From on the node at index To.
+type Edge struct {
+	From int `json:"from"`
+	To   int `json:"to"`
+}
+
+// resolvers maps the manifest identifying an ecosystem to how its graph is
+// resolved. Python projects are found by any of their manifests.
+var resolvers = []struct {
+	manifests []string
+	resolve   func(dir string) (*Graph, error)
+}{
+	{[]string{"go.mod"}, resolveGo},
+	{[]string{"package.json"}, resolveNpm},
+	{[]string{"requirements.txt", "pyproject.toml", "setup.py"}, resolvePip},
+}
+
+// offlineEnv keeps go from downloading modules, or a toolchain, when
+// requests are restricted to allowed_hosts: the resolvers don't go through
+// the CLI's transport, so they only read what is already installed.
+var offlineEnv = []string{"GOPROXY=off", "GOFLAGS=-mod=mod", "GOTOOLCHAIN=local"}
+
+// run runs a resolver command in dir and returns its stdout.
+var run = func(dir, name string, args ...string) ([]byte, error) {
+	var stderr bytes.Buffer
+	cmd := exec.Command(name, args...)
+	cmd.Dir = dir
+	if transport.Restricted() {
+		cmd.Env = append(os.Environ(), offlineEnv...)
+	}
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return out, fmt.Errorf("%s %s: %w: %s", name, strings.Join(args, " "), err, strings.TrimSpace(stderr.String()))
+	}
+	return out, nil
+}
+
+// Resolve returns the dependency graph of each ecosystem with a manifest
+// in dir. It fails when there is none, or a resolver fails.
+func Resolve(dir string) ([]*Graph, error) {
+	var graphs []*Graph
+	for _, r := range resolvers {
+		if !slices.ContainsFunc(r.manifests, func(m string) bool { return exists(filepath.Join(dir, m)) }) {
+			continue
+		}
+		g, err := r.resolve(dir)
+		if err != nil {
+			return nil, err
+		}
+		graphs = append(graphs, g)
+	}
+	if len(graphs) == 0 {
+		return nil, fmt.Errorf("no go.mod, package.json or Python manifest in %s", dir)
+	}
+	return graphs, nil
+}
+
+func exists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
+
+// builder adds nodes to a graph once per purl.
+type builder struct {
+	g     *Graph
+	index map[string]int
+	edges map[Edge]bool
+}
+
+func newBuilder(ecosystem, resolver string) *builder {
+	return &builder{
+		g:     &Graph{SchemaVersion: SchemaVersion, Ecosystem: ecosystem, Resolver: resolver, Nodes: []Node{}, Edges: []Edge{}},
+		index: map[string]int{},
+		edges: map[Edge]bool{},
+	}
+}
+
+// node returns the index of n, adding it if it's new.
+func (b *builder) node(n Node) int {
+	if i, ok := b.index[n.Purl]; ok {
+		return i
+	}
+	b.g.Nodes = append(b.g.Nodes, n)
+	b.index[n.Purl] = len(b.g.Nodes) - 1
+	return len(b.g.Nodes) - 1
+}
+
+func (b *builder) edge(from, to int) {
+	e := Edge{From: from, To: to}
+	if !b.edges[e] && from != to {
+		b.edges[e] = true
+		b.g.Edges = append(b.g.Edges, e)
+	}
+}
+
+// graph returns the graph, with the relation of each node to the project
+// set from the edges of the root.
+func (b *builder) graph() *Graph {
+	if len(b.g.Nodes) == 0 {
+		return b.g
+	}
+	b.g.Nodes[0].Relation = RelationSelf
+	for i := range b.g.Nodes[1:] {
+		b.g.Nodes[i+1].Relation = RelationIndirect
+	}
+	for _, e := range b.g.Edges {
+		if e.From == 0 {
+			b.g.Nodes[e.To].Relation = RelationDirect
+		}
+	}
+	return b.g
+}
+
+func resolveGo(dir string) (*Graph, error) {
+	gomod, err := os.ReadFile(filepath.Join(dir, "go.mod"))
+	if err != nil {
+		return nil, err
+	}
+	module := modulePath(gomod)
+	if module == "" {
+		return nil, fmt.Errorf("no module directive in %s", filepath.Join(dir, "go.mod"))
+	}
+	out, err := run(dir, "go", "mod", "graph")
+	if err != nil {
+		return nil, err
+	}
+	return parseGoModGraph(module, out)
+}
+
+// modulePath returns the path in the module directive of gomod.
+func modulePath(gomod []byte) string {
+	for line := range strings.SplitSeq(string(gomod), "\n") {
+		if rest, ok := strings.CutPrefix(strings.TrimSpace(line), "module"); ok && rest != "" && (rest[0] == ' ' || rest[0] == '\t') {
+			return strings.Trim(strings.TrimSpace(rest), `"`)
+		}
+	}
+	return ""
+}
+
+// parseGoModGraph parses the output of go mod graph for the main module:
+// one "module@version module@version" requirement per line, the main
+// module without version. The go and toolchain versions required are
+// left out.
+func parseGoModGraph(module string, out []byte) (*Graph, error) {
+	b := newBuilder("go", "go mod graph")
+	b.node(goNode(module))
+	sc := bufio.NewScanner(bytes.NewReader(out))
+	for sc.Scan() {
+		fields := strings.Fields(sc.Text())
+		if len(fields) == 0 {
+			continue
+		}
+		if len(fields) != 2 {
+			return nil, fmt.Errorf("unexpected go mod graph line %q", sc.Text())
+		}
+		from, to := goNode(fields[0]), goNode(fields[1])
+		if to.Name == "go" || to.Name == "toolchain" {
+			continue
+		}
+		b.edge(b.node(from), b.node(to))
+	}
+	if err := sc.Err(); err != nil {
+		return nil, err
+	}
+	return b.graph(), nil
+}
+
+func goNode(s string) Node {
+	name, version, _ := strings.Cut(s, "@")
+	return Node{Purl: purl("golang", name, version), Name: name, Version: version}
+}
+
+func resolveNpm(dir string) (*Graph, error) {
+	// npm ls exits 1 on problems such as missing peer dependencies but
+	// still prints the tree.
+	args := []string{"ls", "--json", "--all"}
+	if transport.Restricted() {
+		// See offlineEnv.
+		args = append(args, "--offline")
+	}
+	out, err := run(dir, "npm", args...)
+	if err != nil && len(out) == 0 {
+		return nil, err
+	}
+	return parseNpmLs(out)
+}
+
+// npmPackage is a package in the output of npm ls --json.
+type npmPackage struct {
+	Name         string                `json:"name"`
+	Version      string                `json:"version"`
+	Dependencies map[string]npmPackage `json:"dependencies"`
+}
+
+// parseNpmLs parses the output of npm ls --json --all, a tree of the
+// installed packages.
+func parseNpmLs(out []byte) (*Graph, error) {
+	var root npmPackage
+	if err := json.Unmarshal(out, &root); err != nil {
+		return nil, fmt.Errorf("unexpected npm ls output: %w", err)
+	}
+	b := newBuilder("npm", "npm ls --json --all")
+	var walk func(from int, deps map[string]npmPackage)
+	walk = func(from int, deps map[string]npmPackage) {
+		names := make([]string, 0, len(deps))
+		for name := range deps {
+			names = append(names, name)
+		}
+		slices.Sort(names)
+		for _, name := range names {
+			dep := deps[name]
+			if dep.Version == "" {
+				continue // missing or not installed
+			}
+			_, seen := b.index[purl("npm", name, dep.Version)]
+			to := b.node(Node{Purl: purl("npm", name, dep.Version), Name: name, Version: dep.Version})
+			b.edge(from, to)
+			if !seen {
+				walk(to, dep.Dependencies)
+			}
+		}
+	}
+	walk(b.node(Node{Purl: purl("npm", root.Name, root.Version), Name: root.Name, Version: root.Version}), root.Dependencies)
+	return b.graph(), nil
+}
+
+func resolvePip(dir string) (*Graph, error) {
+	out, err := run(dir, "pip", "freeze")
+	if err != nil {
+		return nil, err
+	}
+	return parsePipFreeze(filepath.Base(dir), out), nil
+}
+
+// parsePipFreeze parses the output of pip freeze, the name==version of
+// each installed package. It has no edges.
+func parsePipFreeze(project string, out []byte) *Graph {
+	b := newBuilder("pypi", "pip freeze")
+	b.node(Node{Purl: purl("pypi", project, ""), Name: project, Relation: RelationSelf})
+	sc := bufio.NewScanner(bytes.NewReader(out))
+	for sc.Scan() {
+		name, version, ok := strings.Cut(strings.TrimSpace(sc.Text()), "==")
+		// Editable installs, comments and direct URL references have no
+		// version to resolve.
+		if !ok || strings.HasPrefix(name, "-") || strings.HasPrefix(name, "#") {
+			continue
+		}
+		name = pypiName(name)
+		b.node(Node{Purl: purl("pypi", name, version), Name: name, Version: version})
+	}
+	return b.g
+}
+
+// pypiName normalizes a Python package name as its purl does: lower case,
+// with runs of -, _ and . as -.
+func pypiName(name string) string {
+	name = strings.ToLower(name)
+	return strings.Join(strings.FieldsFunc(name, func(r rune) bool { return r == '-' || r == '_' || r == '.' }), "-")
+}
+
+// purl returns the package URL of name at version in the purl type typ.
+// The segments of name are escaped, so an npm scope's @ becomes %40.
+func purl(typ, name, version string) string {
+	segments := strings.Split(name, "/")
+	for i, s := range segments {
+		segments[i] = strings.ReplaceAll(url.PathEscape(s), "@", "%40")
+	}
+	p := "pkg:" + typ + "/" + strings.Join(segments, "/")
+	if version != "" {
+		p += "@" + url.PathEscape(version)
+	}
+	return p
+}
+
+// Dependencies returns the number of nodes in g other than the project.
+func (g *Graph) Dependencies() int {
+	return max(len(g.Nodes)-1, 0)
+}
@@ -0,0 +1,138 @@
+// Copyright (c) Kusari <https://www.kusari.dev/>
+// SPDX-License-Identifier: MIT
+
+package depgraph
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/kusaridev/kusari-cli/v2/pkg/transport"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseGoModGraph(t *testing.T) {
+	g, err := parseGoModGraph("example.com/app", []byte(`example.com/app go@1.25
+example.com/app golang.org/x/sync@v0.10.0
+example.com/app github.com/spf13/cobra@v1.8.0
+github.com/spf13/cobra@v1.8.0 github.com/spf13/pflag@v1.0.5
+github.com/spf13/cobra@v1.8.0 golang.org/x/sync@v0.10.0
+go@1.25 toolchain@go1.25
+`))
+	require.NoError(t, err)
+	assert.Equal(t, "go", g.Ecosystem)
+	assert.Equal(t, []Node{
+		{Purl: "pkg:golang/example.com/app", Name: "example.com/app", Relation: RelationSelf},
+		{Purl: "pkg:golang/golang.org/x/sync@v0.10.0", Name: "golang.org/x/sync", Version: "v0.10.0", Relation: RelationDirect},
+		{Purl: "pkg:golang/github.com/spf13/cobra@v1.8.0", Name: "github.com/spf13/cobra", Version: "v1.8.0", Relation: RelationDirect},
+		{Purl: "pkg:golang/github.com/spf13/pflag@v1.0.5", Name: "github.com/spf13/pflag", Version: "v1.0.5", Relation: RelationIndirect},
+	}, g.Nodes)
+	assert.Equal(t, []Edge{{0, 1}, {0, 2}, {2, 3}, {2, 1}}, g.Edges)
+	assert.Equal(t, 3, g.Dependencies())
+
+	_, err = parseGoModGraph("example.com/app", []byte("example.com/app\n"))
+	assert.ErrorContains(t, err, "unexpected go mod graph line")
+}
+
+func TestParseNpmLs(t *testing.T) {
+	g, err := parseNpmLs([]byte(`{
+  "name": "web",
+  "version": "1.0.0",
+  "dependencies": {
+    "@babel/core": {"version": "7.24.0", "dependencies": {"debug": {"version": "4.3.4", "dependencies": {"ms": {"version": "2.1.2"}}}}},
+    "debug": {"version": "4.3.4"},
+    "left-pad": {"required": "^1.0.0", "missing": true}
+  }
+}`))
+	require.NoError(t, err)
+	assert.Equal(t, []Node{
+		{Purl: "pkg:npm/web@1.0.0", Name: "web", Version: "1.0.0", Relation: RelationSelf},
+		{Purl: "pkg:npm/%40babel/core@7.24.0", Name: "@babel/core", Version: "7.24.0", Relation: RelationDirect},
+		{Purl: "pkg:npm/debug@4.3.4", Name: "debug", Version: "4.3.4", Relation: RelationDirect},
+		{Purl: "pkg:npm/ms@2.1.2", Name: "ms", Version: "2.1.2", Relation: RelationIndirect},
+	}, g.Nodes)
+	assert.Equal(t, []Edge{{0, 1}, {1, 2}, {2, 3}, {0, 2}}, g.Edges)
+
+	_, err = parseNpmLs([]byte("npm ERR!"))
+	assert.ErrorContains(t, err, "unexpected npm ls output")
+}
+
+func TestParsePipFreeze(t *testing.T) {
+	g := parsePipFreeze("api", []byte(`# comment
+Flask==3.0.2
+zope.interface==6.2
+-e git+https://example.com/lib.git#egg=lib
+mylib @ file:///tmp/mylib
+`))
+	assert.Equal(t, []Node{
+		{Purl: "pkg:pypi/api", Name: "api", Relation: RelationSelf},
+		{Purl: "pkg:pypi/flask@3.0.2", Name: "flask", Version: "3.0.2"},
+		{Purl: "pkg:pypi/zope-interface@6.2", Name: "zope-interface", Version: "6.2"},
+	}, g.Nodes)
+	assert.Empty(t, g.Edges)
+}
+
+func TestResolve(t *testing.T) {
+	dir := t.TempDir()
+	_, err := Resolve(dir)
+	assert.ErrorContains(t, err, "no go.mod, package.json or Python manifest")
+
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "go.mod"), []byte("module example.com/app\n"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "requirements.txt"), []byte("flask\n"), 0644))
+	var ran []string
+	orig := run
+	t.Cleanup(func() { run = orig })
+	run = func(d, name string, args ...string) ([]byte, error) {
+		assert.Equal(t, dir, d)
+		ran = append(ran, name)
+		if name == "go" {
+			return []byte("example.com/app golang.org/x/sync@v0.10.0\n"), nil
+		}
+		return []byte("Flask==3.0.2\n"), nil
+	}
+	graphs, err := Resolve(dir)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"go", "pip"}, ran)
+	require.Len(t, graphs, 2)
+	assert.Equal(t, "go", graphs[0].Ecosystem)
+	assert.Equal(t, "example.com/app", graphs[0].Nodes[0].Name)
+	assert.Equal(t, "pypi", graphs[1].Ecosystem)
+
+	run = func(d, name string, args ...string) ([]byte, error) {
+		return nil, errors.New("go: not found")
+	}
+	_, err = Resolve(dir)
+	assert.ErrorContains(t, err, "go: not found")
+}
+
+// Test that resolvers don't reach the network when requests are
+// restricted to allowed_hosts
+func TestResolveRestricted(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "package.json"), []byte(`{"name": "app"}`), 0644))
+	require.NoError(t, transport.SetAllowedHosts([]string{"api.us.kusari.cloud"}))
+	t.Cleanup(func() { _ = transport.SetAllowedHosts(nil) })
+
+	var got []string
+	orig := run
+	t.Cleanup(func() { run = orig })
+	run = func(d, name string, args ...string) ([]byte, error) {
+		got = args
+		return []byte(`{"name": "app", "version": "1.0.0"}`), nil
+	}
+	_, err := Resolve(dir)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"ls", "--json", "--all", "--offline"}, got)
+
+	// go mod graph fails rather than download a module missing from the
+	// module cache
+	run = orig
+	require.NoError(t, os.Remove(filepath.Join(dir, "package.json")))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "go.mod"), []byte("module example.com/app\n\ngo 1.21\n\nrequire example.invalid/missing v1.0.0\n"), 0644))
+	t.Setenv("GOMODCACHE", t.TempDir())
+	_, err = Resolve(dir)
+	assert.ErrorContains(t, err, "GOPROXY=off")
+}
@@ -0,0 +1,399 @@
+// Copyright (c) Kusari <https://www.kusari.dev/>
+// SPDX-License-Identifier: MIT
+
+// Package fakeplatform is a stand-in for the Kusari platform and tenant
+// APIs: it answers the workspace, presign, upload, scan result, ingestion
+// status and pico software endpoints the CLI calls from a programmable
+// Scenario, without analyzing anything. It backs `kusari dev mock-server`,
+// for testing a CI integration end to end, and the integration tests.
+//
+// One server plays both roles, so it is passed as the platform URL and as
+// the tenant endpoint. It accepts any bearer token.
+package fakeplatform
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"path"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/kusaridev/kusari-cli/v2/api"
+	"github.com/kusaridev/kusari-cli/v2/pkg/pico"
+)
+
+// UserID is the machine user the fake platform presigns uploads for.
+const UserID = "fake-user"
+
+// Upload kinds.
+const (
+	// KindBundle is a scan bundle, uploaded by kusari repo scan.
+	KindBundle = "bundle"
+	// KindDocument is an SBOM or VEX document, uploaded by kusari platform upload.
+	KindDocument = "document"
+)
+
+// Upload is a file PUT to a URL the fake platform presigned.
+type Upload struct {
+	Kind string
+	// Key is the epoch of a bundle or the docRef of a document.
+	Key    string
+	Header http.Header
+	Body   []byte
+}
+
+// Server is a fake platform serving a Scenario. It is an http.Handler, to
+// serve with httptest.NewServer or an http.Server.
+type Server struct {
+	scenario Scenario
+	mux      *http.ServeMux
+	// Log, when set, receives a line per request served.
+	Log io.Writer
+
+	mu       sync.Mutex
+	epoch    int64
+	requests []string
+	uploads  []Upload
+	polls    map[string]int
+	software []Software
+}
+
+// New returns a Server answering as scenario programs it.
+func New(scenario Scenario) *Server {
+	s := &Server{
+		scenario: scenario,
+		mux:      http.NewServeMux(),
+		epoch:    time.Now().UnixMilli(),
+		polls:    map[string]int{},
+		software: slices.Clone(scenario.Software),
+	}
+	s.mux.HandleFunc("GET /user", s.user)
+	s.mux.HandleFunc("POST /inspector/presign/bundle-upload", s.presignBundle)
+	s.mux.HandleFunc("GET /inspector/result/user", s.result)
+	s.mux.HandleFunc("POST /inspector/attestation", s.attestation)
+	s.mux.HandleFunc("POST /ingestion/presign", s.presignDocument)
+	s.mux.HandleFunc("GET /ingestion/status", s.ingestionStatus)
+	s.mux.HandleFunc("PUT /upload/workspace/", s.upload(KindBundle))
+	s.mux.HandleFunc("PUT /upload/document/{docRef}", s.upload(KindDocument))
+	s.mux.HandleFunc("GET /pico/v1/software", s.listSoftware)
+	s.mux.HandleFunc("GET /pico/v1/software/{id}", s.getSoftware)
+	s.mux.HandleFunc("GET /pico/v1/software/{id}/sboms", s.listSBOMs)
+	s.mux.HandleFunc("DELETE /pico/v1/software/{id}/sboms/{sbomID}", s.deleteSBOM)
+	return s
+}
+
+// ServeHTTP serves the fake platform. Requests other than uploads to
+// presigned URLs need a bearer token; anything the fake platform does not
+// implement, such as a bundle key or a CLI version pin, is a 404.
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	rec := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+	s.mu.Lock()
+	s.requests = append(s.requests, r.Method+" "+r.URL.Path)
+	s.mu.Unlock()
+
+	_, pattern := s.mux.Handler(r)
+	switch {
+	case pattern == "":
+		writeError(rec, http.StatusNotFound, "not implemented by the fake platform")
+	case !strings.HasPrefix(r.URL.Path, "/upload/") && !strings.HasPrefix(r.Header.Get("Authorization"), "Bearer "):
+		writeError(rec, http.StatusUnauthorized, "missing bearer token")
+	default:
+		s.mux.ServeHTTP(rec, r)
+	}
+	if s.Log != nil {
+		_, _ = fmt.Fprintf(s.Log, "%s %s -> %d\n", r.Method, r.URL.RequestURI(), rec.status)
+	}
+}
+
+// Requests returns the method and path of every request served, in order.
+func (s *Server) Requests() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return slices.Clone(s.requests)
+}
+
+// Uploads returns the files uploaded to presigned URLs, in order.
+func (s *Server) Uploads() []Upload {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return slices.Clone(s.uploads)
+}
+
+func (s *Server) workspaces() []Workspace {
+	if len(s.scenario.Workspaces) == 0 {
+		return []Workspace{DefaultWorkspace}
+	}
+	return s.scenario.Workspaces
+}
+
+func (s *Server) user(w http.ResponseWriter, r *http.Request) {
+	tenants := map[string][]string{}
+	for _, ws := range s.workspaces() {
+		tenants[ws.ID] = []string{"fake"}
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"workspaces": s.workspaces(), "workspaceTenants": tenants})
+}
+
+// presignBundle presigns a scan bundle upload with a URL laid out like the
+// platform's, which the CLI reads the workspace, user and epoch from.
+func (s *Server) presignBundle(w http.ResponseWriter, r *http.Request) {
+	if s.scenario.PresignStatus != 0 {
+		writeRaw(w, s.scenario.PresignStatus, s.scenario.PresignBody)
+		return
+	}
+	var req struct {
+		Type string `json:"type"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid presign request")
+		return
+	}
+	workspace := r.Header.Get("X-Kusari-Workspace")
+	if workspace == "" {
+		workspace = s.workspaces()[0].ID
+	}
+	s.mu.Lock()
+	s.epoch++
+	epoch := s.epoch
+	s.mu.Unlock()
+
+	u := fmt.Sprintf("%s/upload/workspace/%s/user/machine/%s/%s/%d", baseURL(r), url.PathEscape(workspace), UserID, url.PathEscape(req.Type), epoch)
+	writeJSON(w, http.StatusOK, map[string]string{"presignedUrl": u})
+}
+
+func (s *Server) presignDocument(w http.ResponseWriter, r *http.Request) {
+	if s.scenario.PresignStatus != 0 {
+		writeRaw(w, s.scenario.PresignStatus, s.scenario.PresignBody)
+		return
+	}
+	var req struct {
+		Filename string `json:"filename"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Filename == "" {
+		writeError(w, http.StatusBadRequest, "invalid presign request")
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]string{"presignedUrl": baseURL(r) + "/upload/document/" + url.PathEscape(req.Filename)})
+}
+
+func (s *Server) upload(kind string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, "failed to read upload")
+			return
+		}
+		key := path.Base(r.URL.Path)
+		if kind == KindDocument {
+			key = r.PathValue("docRef")
+		}
+		s.mu.Lock()
+		s.uploads = append(s.uploads, Upload{Kind: kind, Key: key, Header: r.Header.Clone(), Body: body})
+		s.mu.Unlock()
+		w.WriteHeader(http.StatusOK)
+	}
+}
+
+// uploaded reports whether a file of kind was uploaded under key.
+func (s *Server) uploaded(kind, key string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return slices.ContainsFunc(s.uploads, func(u Upload) bool { return u.Kind == kind && u.Key == key })
+}
+
+// poll counts a poll of key and reports whether the poll is still to be
+// answered as processing.
+func (s *Server) poll(key string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.polls[key]++
+	return s.polls[key] <= s.scenario.Processing
+}
+
+// result answers a scan's result once its bundle was uploaded, after
+// Processing polls.
+func (s *Server) result(w http.ResponseWriter, r *http.Request) {
+	sortKey := r.URL.Query().Get("sortKey")
+	epoch := sortKey[strings.LastIndex(sortKey, "|")+1:]
+	if epoch == "" || !s.uploaded(KindBundle, epoch) {
+		writeJSON(w, http.StatusOK, []api.UserInspectorResult{})
+		return
+	}
+
+	res := api.UserInspectorResult{
+		User:       UserID,
+		Sort:       sortKey,
+		StatusMeta: api.StatusMeta{SortEpoch: epoch, UpdatedAt: strconv.FormatInt(time.Now().UnixMilli(), 10)},
+	}
+	switch {
+	case s.poll("result " + sortKey):
+		res.StatusMeta.Status = "processing"
+	case s.scenario.FailedDetails != "":
+		res.StatusMeta.Status = "failed"
+		res.StatusMeta.Details = s.scenario.FailedDetails
+	default:
+		analysis := passingAnalysis
+		if s.scenario.Analysis != nil {
+			analysis = *s.scenario.Analysis
+		}
+		res.Analysis = &analysis
+		res.StatusMeta.Status = "success"
+	}
+	writeJSON(w, http.StatusOK, []api.UserInspectorResult{res})
+}
+
+func (s *Server) attestation(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusCreated, map[string]string{"status": "recorded"})
+}
+
+// ingestionStatus is an item of the ingestion status API.
+type ingestionStatus struct {
+	DocumentName string `json:"document_name"`
+	StatusMeta   struct {
+		Status      string `json:"status"`
+		UserMessage string `json:"user_message"`
+		UpdatedAt   string `json:"updated_at"`
+	} `json:"statusMeta"`
+}
+
+// ingestionStatus answers a document's ingestion status once it was
+// uploaded, after Processing polls.
+func (s *Server) ingestionStatus(w http.ResponseWriter, r *http.Request) {
+	docRef := r.URL.Query().Get("docRef")
+	if !s.uploaded(KindDocument, docRef) {
+		writeJSON(w, http.StatusOK, []ingestionStatus{})
+		return
+	}
+
+	var item ingestionStatus
+	item.DocumentName = docRef
+	item.StatusMeta.UpdatedAt = strconv.FormatInt(time.Now().UnixMilli(), 10)
+	if s.poll("ingestion " + docRef) {
+		item.StatusMeta.Status = "processing"
+	} else {
+		item.StatusMeta.Status = s.scenario.Ingestion
+		if item.StatusMeta.Status == "" {
+			item.StatusMeta.Status = "success"
+		}
+		item.StatusMeta.UserMessage = s.scenario.IngestionMessage
+	}
+	writeJSON(w, http.StatusOK, []ingestionStatus{item})
+}
+
+func (s *Server) listSoftware(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	page, _ := strconv.Atoi(q.Get("page"))
+	size, err := strconv.Atoi(q.Get("size"))
+	if err != nil || size <= 0 {
+		size = 20
+	}
+
+	s.mu.Lock()
+	var matched []Software
+	for _, sw := range s.software {
+		if strings.Contains(strings.ToLower(sw.Name), strings.ToLower(q.Get("search"))) {
+			matched = append(matched, sw)
+		}
+	}
+	s.mu.Unlock()
+
+	entries := []any{}
+	for i := page * size; i >= 0 && i < len(matched) && i < (page+1)*size; i++ {
+		entries = append(entries, matched[i].SoftwareEntry)
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"software": entries, "total": len(matched)})
+}
+
+// softwareByID returns the index of the software with the id path value,
+// or writes a 404.
+func (s *Server) softwareByID(w http.ResponseWriter, r *http.Request) (int, bool) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	i := slices.IndexFunc(s.software, func(sw Software) bool { return sw.ID == id })
+	if err != nil || i < 0 {
+		writeError(w, http.StatusNotFound, "software not found")
+		return 0, false
+	}
+	return i, true
+}
+
+func (s *Server) getSoftware(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if i, ok := s.softwareByID(w, r); ok {
+		writeJSON(w, http.StatusOK, s.software[i].SoftwareEntry)
+	}
+}
+
+func (s *Server) listSBOMs(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if i, ok := s.softwareByID(w, r); ok {
+		sboms := s.software[i].SBOMs
+		if sboms == nil {
+			sboms = []pico.SBOMVersion{}
+		}
+		writeJSON(w, http.StatusOK, map[string]any{"sboms": sboms, "total": len(sboms)})
+	}
+}
+
+func (s *Server) deleteSBOM(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	i, ok := s.softwareByID(w, r)
+	if !ok {
+		return
+	}
+	sbomID, err := strconv.Atoi(r.PathValue("sbomID"))
+	sboms := s.software[i].SBOMs
+	j := slices.IndexFunc(sboms, func(sbom pico.SBOMVersion) bool { return sbom.ID == sbomID })
+	if err != nil || j < 0 {
+		writeError(w, http.StatusNotFound, "SBOM not found")
+		return
+	}
+	// Clone so the scenario's SBOMs are left alone.
+	s.software[i].SBOMs = slices.Delete(slices.Clone(sboms), j, j+1)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// baseURL is the URL the request reached the server at, which presigned
+// URLs point back to.
+func baseURL(r *http.Request) string {
+	scheme := "http"
+	if r.TLS != nil {
+		scheme = "https"
+	}
+	return scheme + "://" + r.Host
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+func writeRaw(w http.ResponseWriter, status int, body string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_, _ = io.WriteString(w, body)
+}
+
+func writeError(w http.ResponseWriter, status int, message string) {
+	writeJSON(w, status, map[string]string{"message": message})
+}
+
+// statusRecorder records the status of a response, for the request log.
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (r *statusRecorder) WriteHeader(status int) {
+	r.status = status
+	r.ResponseWriter.WriteHeader(status)
+}
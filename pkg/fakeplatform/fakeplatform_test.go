@@ -0,0 +1,184 @@
+// Copyright (c) Kusari <https://www.kusari.dev/>
+// SPDX-License-Identifier: MIT
+
+package fakeplatform
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kusaridev/kusari-cli/v2/api"
+	"github.com/kusaridev/kusari-cli/v2/pkg/auth"
+	"github.com/kusaridev/kusari-cli/v2/pkg/pico"
+	urlBuilder "github.com/kusaridev/kusari-cli/v2/pkg/url"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// call sends a request to the fake platform and decodes its JSON answer
+// into v, returning the status.
+func call(t *testing.T, method, url, body string, v any) int {
+	t.Helper()
+	req, err := http.NewRequest(method, url, strings.NewReader(body))
+	require.NoError(t, err)
+	req.Header.Set("Authorization", "Bearer fake")
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	defer func() { _ = resp.Body.Close() }()
+	data, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	if v != nil {
+		require.NoError(t, json.Unmarshal(data, v), string(data))
+	}
+	return resp.StatusCode
+}
+
+// uploadBundle presigns and uploads a bundle, returning the sort key the
+// CLI would poll its result with.
+func uploadBundle(t *testing.T, base string) string {
+	t.Helper()
+	var presign struct {
+		PresignedURL string `json:"presignedUrl"`
+	}
+	require.Equal(t, http.StatusOK, call(t, "POST", base+"/inspector/presign/bundle-upload", `{"filename":"bundle.tar.gz","type":"diff"}`, &presign))
+	workspace, user, epoch, isMachine, err := urlBuilder.GetIDsFromUrl(presign.PresignedURL)
+	require.NoError(t, err)
+	assert.Equal(t, DefaultWorkspace.ID, workspace)
+	assert.Equal(t, UserID, user)
+	assert.True(t, isMachine)
+
+	require.Equal(t, http.StatusOK, call(t, "PUT", presign.PresignedURL, "bundle", nil))
+	return urlBuilder.CreateSortString(user, epoch, false, isMachine, "", "repo", "main")
+}
+
+func TestServer_ScanResult(t *testing.T) {
+	s := New(Scenario{Processing: 2})
+	srv := httptest.NewServer(s)
+	defer srv.Close()
+
+	var results []api.UserInspectorResult
+	resp, err := http.Get(srv.URL + "/user")
+	require.NoError(t, err)
+	_ = resp.Body.Close()
+	assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+
+	// Nothing was uploaded for this epoch yet.
+	call(t, "GET", srv.URL+"/inspector/result/user?sortKey=cli-api%7Cx%7Crepo%7Cmain%7Cmachine%7C1", "", &results)
+	assert.Empty(t, results)
+
+	sortKey := uploadBundle(t, srv.URL)
+	for range 2 {
+		call(t, "GET", srv.URL+"/inspector/result/user?sortKey="+sortKey, "", &results)
+		require.Len(t, results, 1)
+		assert.Equal(t, "processing", results[0].StatusMeta.Status)
+		assert.Nil(t, results[0].Analysis)
+	}
+	call(t, "GET", srv.URL+"/inspector/result/user?sortKey="+sortKey, "", &results)
+	require.Len(t, results, 1)
+	require.NotNil(t, results[0].Analysis)
+	assert.True(t, results[0].Analysis.RawLLMAnalysis.ShouldProceed)
+
+	uploads := s.Uploads()
+	require.Len(t, uploads, 1)
+	assert.Equal(t, KindBundle, uploads[0].Kind)
+	assert.Equal(t, "bundle", string(uploads[0].Body))
+}
+
+func TestServer_Scenarios(t *testing.T) {
+	t.Run("block", func(t *testing.T) {
+		srv := httptest.NewServer(New(Scenarios["block"]))
+		defer srv.Close()
+		var results []api.UserInspectorResult
+		call(t, "GET", srv.URL+"/inspector/result/user?sortKey="+uploadBundle(t, srv.URL), "", &results)
+		require.Len(t, results, 1)
+		assert.False(t, results[0].Analysis.RawLLMAnalysis.ShouldProceed)
+		assert.Len(t, results[0].Analysis.RawLLMAnalysis.RequiredCodeMitigations, 1)
+	})
+	t.Run("fail", func(t *testing.T) {
+		srv := httptest.NewServer(New(Scenarios["fail"]))
+		defer srv.Close()
+		var results []api.UserInspectorResult
+		call(t, "GET", srv.URL+"/inspector/result/user?sortKey="+uploadBundle(t, srv.URL), "", &results)
+		require.Len(t, results, 1)
+		assert.Equal(t, "failed", results[0].StatusMeta.Status)
+		assert.Nil(t, results[0].Analysis)
+	})
+	t.Run("forbidden", func(t *testing.T) {
+		srv := httptest.NewServer(New(Scenarios["forbidden"]))
+		defer srv.Close()
+		assert.Equal(t, http.StatusForbidden, call(t, "POST", srv.URL+"/inspector/presign/bundle-upload", `{}`, nil))
+	})
+}
+
+func TestServer_Ingestion(t *testing.T) {
+	s := New(Scenario{Ingestion: "failed", IngestionMessage: "bad document"})
+	srv := httptest.NewServer(s)
+	defer srv.Close()
+
+	type status struct {
+		StatusMeta struct {
+			Status      string `json:"status"`
+			UserMessage string `json:"user_message"`
+		} `json:"statusMeta"`
+	}
+	var statuses []status
+	call(t, "GET", srv.URL+"/ingestion/status?tenantName=fake&docRef=sha256_abc", "", &statuses)
+	assert.Empty(t, statuses)
+
+	var presign struct {
+		PresignedURL string `json:"presignedUrl"`
+	}
+	require.Equal(t, http.StatusOK, call(t, "POST", srv.URL+"/ingestion/presign", `{"filename":"sha256_abc"}`, &presign))
+	require.Equal(t, http.StatusOK, call(t, "PUT", presign.PresignedURL, "{}", nil))
+
+	call(t, "GET", srv.URL+"/ingestion/status?tenantName=fake&docRef=sha256_abc", "", &statuses)
+	require.Len(t, statuses, 1)
+	assert.Equal(t, "failed", statuses[0].StatusMeta.Status)
+	assert.Equal(t, "bad document", statuses[0].StatusMeta.UserMessage)
+	assert.Equal(t, []Upload{{Kind: KindDocument, Key: "sha256_abc", Header: s.Uploads()[0].Header, Body: []byte("{}")}}, s.Uploads())
+}
+
+func TestServer_Pico(t *testing.T) {
+	t.Setenv(auth.APIKeyEnv, "fake")
+	day := func(d int) time.Time { return time.Date(2026, 1, d, 0, 0, 0, 0, time.UTC) }
+	scenario := Scenario{Software: []Software{
+		{SoftwareEntry: pico.SoftwareEntry{ID: 1, Name: "payments"}, SBOMs: []pico.SBOMVersion{{ID: 10, Version: "1.0.0", CreatedAt: day(1)}, {ID: 11, Version: "1.1.0", CreatedAt: day(2)}}},
+		{SoftwareEntry: pico.SoftwareEntry{ID: 2, Name: "frontend"}},
+	}}
+	srv := httptest.NewServer(New(scenario))
+	defer srv.Close()
+	client := pico.NewClient(srv.URL)
+
+	software, err := client.ListAllSoftware(t.Context(), "pay")
+	require.NoError(t, err)
+	assert.Equal(t, []pico.SoftwareEntry{{ID: 1, Name: "payments"}}, software)
+
+	require.NoError(t, client.DeleteSBOM(t.Context(), 1, 10))
+	sboms, err := client.ListSBOMs(t.Context(), 1)
+	require.NoError(t, err)
+	assert.Equal(t, []pico.SBOMVersion{{ID: 11, Version: "1.1.0", CreatedAt: day(2)}}, sboms)
+	assert.Len(t, scenario.Software[0].SBOMs, 2, "the scenario is left alone")
+
+	assert.Error(t, client.DeleteSBOM(t.Context(), 1, 10))
+	_, err = client.ListSBOMs(t.Context(), 3)
+	assert.Error(t, err)
+}
+
+func TestLoadScenario(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "scenario.json")
+	require.NoError(t, os.WriteFile(path, []byte(`{"processing": 2, "software": [{"id": 4, "name": "api", "sboms": [{"id": 9, "version": "2.0.0"}]}]}`), 0600))
+	s, err := LoadScenario(path)
+	require.NoError(t, err)
+	assert.Equal(t, 2, s.Processing)
+	assert.Equal(t, []Software{{SoftwareEntry: pico.SoftwareEntry{ID: 4, Name: "api"}, SBOMs: []pico.SBOMVersion{{ID: 9, Version: "2.0.0"}}}}, s.Software)
+
+	_, err = LookupScenario("nope")
+	assert.ErrorContains(t, err, "available: block, fail, forbidden, ingestion-failed, pass, slow")
+}
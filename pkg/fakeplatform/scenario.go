@@ -0,0 +1,133 @@
+// Copyright (c) Kusari <https://www.kusari.dev/>
+// SPDX-License-Identifier: MIT
+
+package fakeplatform
+
+import (
+	"encoding/json"
+	"fmt"
+	"maps"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/kusaridev/kusari-cli/v2/api"
+	"github.com/kusaridev/kusari-cli/v2/pkg/pico"
+)
+
+// Scenario programs what the fake platform answers. The zero Scenario is a
+// platform where every scan passes with no findings and every upload is
+// ingested.
+type Scenario struct {
+	// Workspaces are returned by GET /user. Defaults to a single
+	// DefaultWorkspace.
+	Workspaces []Workspace `json:"workspaces,omitempty"`
+	// Processing is the number of result and ingestion status polls
+	// answered with a processing status before the outcome.
+	Processing int `json:"processing,omitempty"`
+	// Analysis is the result of every scan. Nil means a passing analysis
+	// with no findings.
+	Analysis *api.Analysis `json:"analysis,omitempty"`
+	// FailedDetails, when set, makes every scan fail processing with these
+	// details instead of returning Analysis.
+	FailedDetails string `json:"failed_details,omitempty"`
+	// PresignStatus, when set, makes every presign request fail with this
+	// HTTP status and PresignBody, e.g. 403 for a key without upload
+	// permission.
+	PresignStatus int    `json:"presign_status,omitempty"`
+	PresignBody   string `json:"presign_body,omitempty"`
+	// Ingestion is the final ingestion status of uploaded documents,
+	// "success" (the default) or "failed", with IngestionMessage as its user
+	// message.
+	Ingestion        string `json:"ingestion,omitempty"`
+	IngestionMessage string `json:"ingestion_message,omitempty"`
+	// Software is what the pico software API serves.
+	Software []Software `json:"software,omitempty"`
+}
+
+// Workspace is a workspace of the fake user.
+type Workspace struct {
+	ID          string `json:"id"`
+	Description string `json:"description"`
+}
+
+// Software is a software entry of the pico API and its SBOMs.
+type Software struct {
+	pico.SoftwareEntry
+	SBOMs []pico.SBOMVersion `json:"sboms,omitempty"`
+}
+
+// DefaultWorkspace is the workspace served when a Scenario sets none.
+var DefaultWorkspace = Workspace{ID: "fake-workspace", Description: "Fake workspace"}
+
+// Scenarios are the predefined scenarios, by name.
+var Scenarios = map[string]Scenario{
+	// pass: every scan passes with no findings.
+	"pass": {},
+	// block: every scan finds an issue and recommends not to proceed.
+	"block": {
+		Analysis: &api.Analysis{
+			Proceed: false,
+			Results: "## Kusari Analysis Results\n\n**Recommendation:** Do not proceed\n\nA hardcoded credential was found.\n",
+			RawLLMAnalysis: &api.SecurityAnalysis{
+				SchemaVersion:  api.SecurityAnalysisSchemaVersion,
+				Recommendation: "Do not proceed",
+				Justification:  "A hardcoded credential was found.",
+				RequiredCodeMitigations: []api.CodeMitigationItem{
+					{Path: "config.go", LineNumber: 12, Content: "Load the credential from the environment instead of hardcoding it."},
+				},
+			},
+			TruncatedCommentWithCodeMitigations:    "## Kusari Analysis Results\n\n**Recommendation:** Do not proceed\n\n- `config.go:12`: Load the credential from the environment instead of hardcoding it.\n",
+			TruncatedCommentWithoutCodeMitigations: "## Kusari Analysis Results\n\n**Recommendation:** Do not proceed\n",
+		},
+	},
+	// fail: every scan fails processing on the platform.
+	"fail": {FailedDetails: "the fake platform failed the analysis"},
+	// slow: every scan and ingestion takes a few polls to complete.
+	"slow": {Processing: 3},
+	// forbidden: the API key may not upload.
+	"forbidden": {PresignStatus: 403, PresignBody: `{"message":"User is not authorized to access this resource"}`},
+	// ingestion-failed: uploaded SBOMs fail ingestion.
+	"ingestion-failed": {Ingestion: "failed", IngestionMessage: "the fake platform could not parse the document"},
+}
+
+// passingAnalysis is the Analysis of a Scenario that sets none.
+var passingAnalysis = api.Analysis{
+	Proceed: true,
+	Results: "## Kusari Analysis Results\n\n**Recommendation:** Proceed\n\nNo issues found.\n",
+	RawLLMAnalysis: &api.SecurityAnalysis{
+		SchemaVersion:  api.SecurityAnalysisSchemaVersion,
+		Recommendation: "Proceed",
+		Justification:  "No issues found.",
+		ShouldProceed:  true,
+	},
+	TruncatedCommentWithCodeMitigations:    "## Kusari Analysis Results\n\n**Recommendation:** Proceed\n\nNo issues found.\n",
+	TruncatedCommentWithoutCodeMitigations: "## Kusari Analysis Results\n\n**Recommendation:** Proceed\n",
+}
+
+// ScenarioNames returns the names of the predefined scenarios, sorted.
+func ScenarioNames() []string {
+	return slices.Sorted(maps.Keys(Scenarios))
+}
+
+// LookupScenario returns the predefined scenario called name.
+func LookupScenario(name string) (Scenario, error) {
+	s, ok := Scenarios[name]
+	if !ok {
+		return Scenario{}, fmt.Errorf("unknown scenario %q (available: %s)", name, strings.Join(ScenarioNames(), ", "))
+	}
+	return s, nil
+}
+
+// LoadScenario reads a Scenario from a JSON file.
+func LoadScenario(path string) (Scenario, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return Scenario{}, fmt.Errorf("failed to read scenario file: %w", err)
+	}
+	var s Scenario
+	if err := json.Unmarshal(data, &s); err != nil {
+		return Scenario{}, fmt.Errorf("failed to parse scenario file %s: %w", path, err)
+	}
+	return s, nil
+}
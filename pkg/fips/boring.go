@@ -0,0 +1,14 @@
+// Copyright (c) Kusari <https://www.kusari.dev/>
+// SPDX-License-Identifier: MIT
+
+//go:build boringcrypto
+
+package fips
+
+import (
+	"crypto/boring"
+	// Restrict TLS to FIPS-approved versions, ciphers and curves.
+	_ "crypto/tls/fipsonly"
+)
+
+func boringEnabled() bool { return boring.Enabled() }
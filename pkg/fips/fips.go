@@ -0,0 +1,83 @@
+// Copyright (c) Kusari <https://www.kusari.dev/>
+// SPDX-License-Identifier: MIT
+
+// Package fips reports which crypto module the CLI runs with and keeps
+// encryption features to FIPS-approved algorithms when it is a validated
+// one.
+//
+// Two validated modules are supported: Go's own FIPS 140-3 module
+// (GOFIPS140=v1.0.0 at build time or GODEBUG=fips140=on at run time) and
+// BoringCrypto (GOEXPERIMENT=boringcrypto). Building with -tags fips
+// turns the Go module on by default and makes the CLI refuse to run
+// without one of them.
+package fips
+
+import (
+	"crypto/fips140"
+	"fmt"
+)
+
+// Crypto modes reported by Mode.
+const (
+	ModeStandard = "standard"
+	ModeFIPS140  = "fips140"
+	ModeBoring   = "boringcrypto"
+)
+
+// approved are the algorithms encryption features may use in FIPS mode.
+var approved = map[string]bool{
+	"AES-256-GCM":        true,
+	"RSA-OAEP-SHA256":    true,
+	"PBKDF2-HMAC-SHA256": true,
+	"HKDF-SHA256":        true,
+	"HMAC-SHA256":        true,
+	"SHA-256":            true,
+}
+
+// Mode returns the crypto module in use: boringcrypto, fips140 or
+// standard.
+func Mode() string {
+	switch {
+	case boringEnabled():
+		return ModeBoring
+	case fips140.Enabled():
+		return ModeFIPS140
+	}
+	return ModeStandard
+}
+
+// Enabled reports whether a FIPS-validated crypto module is in use.
+func Enabled() bool {
+	return Mode() != ModeStandard
+}
+
+// Required reports whether the CLI was built with -tags fips.
+func Required() bool {
+	return required
+}
+
+// Check returns an error when the CLI was built with -tags fips but runs
+// without a validated crypto module, e.g. because GODEBUG=fips140=off
+// overrode it.
+func Check() error {
+	if required && !Enabled() {
+		return fmt.Errorf("this kusari build requires FIPS mode, but the crypto module is %s; unset GODEBUG=fips140=off or set GODEBUG=fips140=on", Mode())
+	}
+	return nil
+}
+
+// Require returns an error when FIPS mode is enabled and one of
+// algorithms is not approved by it. Encryption features call it with the
+// algorithms they use, so a new one fails clearly in FIPS deployments
+// rather than slipping through.
+func Require(algorithms ...string) error {
+	if !Enabled() {
+		return nil
+	}
+	for _, algorithm := range algorithms {
+		if !approved[algorithm] {
+			return fmt.Errorf("%s is not a FIPS-approved algorithm and cannot be used in %s mode", algorithm, Mode())
+		}
+	}
+	return nil
+}
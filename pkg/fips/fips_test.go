@@ -0,0 +1,33 @@
+// Copyright (c) Kusari <https://www.kusari.dev/>
+// SPDX-License-Identifier: MIT
+
+package fips
+
+import (
+	"crypto/fips140"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMode(t *testing.T) {
+	if fips140.Enabled() {
+		assert.Contains(t, []string{ModeFIPS140, ModeBoring}, Mode())
+		assert.True(t, Enabled())
+	} else if !boringEnabled() {
+		assert.Equal(t, ModeStandard, Mode())
+		assert.False(t, Enabled())
+	}
+	if !required || Enabled() {
+		assert.NoError(t, Check())
+	}
+}
+
+func TestRequire(t *testing.T) {
+	assert.NoError(t, Require("AES-256-GCM", "RSA-OAEP-SHA256"))
+	if Enabled() {
+		assert.EqualError(t, Require("AES-256-GCM", "ChaCha20-Poly1305"), "ChaCha20-Poly1305 is not a FIPS-approved algorithm and cannot be used in "+Mode()+" mode")
+	} else {
+		assert.NoError(t, Require("ChaCha20-Poly1305"))
+	}
+}
@@ -0,0 +1,8 @@
+// Copyright (c) Kusari <https://www.kusari.dev/>
+// SPDX-License-Identifier: MIT
+
+//go:build !boringcrypto
+
+package fips
+
+func boringEnabled() bool { return false }
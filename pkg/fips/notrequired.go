@@ -0,0 +1,8 @@
+// Copyright (c) Kusari <https://www.kusari.dev/>
+// SPDX-License-Identifier: MIT
+
+//go:build !fips
+
+package fips
+
+const required = false
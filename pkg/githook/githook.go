@@ -0,0 +1,57 @@
+// Copyright (c) Kusari <https://www.kusari.dev/>
+// SPDX-License-Identifier: MIT
+
+// Package githook installs a git pre-push hook that runs Kusari Inspector.
+package githook
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// marker identifies hooks written by the Kusari CLI so they can be replaced
+// without --force.
+const marker = "# Installed by kusari init"
+
+const prePushHook = `#!/bin/sh
+` + marker + `
+# Scans the commits being pushed with Kusari Inspector. Skip with: git push --no-verify
+base=$(git merge-base HEAD origin/HEAD 2>/dev/null || echo HEAD~1)
+exec kusari repo scan . "$base"
+`
+
+// ErrHookExists is returned when a pre-push hook not written by kusari
+// already exists.
+var ErrHookExists = errors.New("a pre-push hook already exists (use --force to replace it)")
+
+// Install writes the pre-push hook into the repository at dir and returns
+// its path.
+func Install(dir string, force bool) (string, error) {
+	cmd := exec.Command("git", "rev-parse", "--git-path", "hooks")
+	cmd.Dir = dir
+	out, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("failed to locate git hooks directory: %w", err)
+	}
+	hooksDir := strings.TrimSpace(string(out))
+	if !filepath.IsAbs(hooksDir) {
+		hooksDir = filepath.Join(dir, hooksDir)
+	}
+
+	path := filepath.Join(hooksDir, "pre-push")
+	if existing, err := os.ReadFile(path); err == nil && !force && !strings.Contains(string(existing), marker) {
+		return path, ErrHookExists
+	}
+
+	if err := os.MkdirAll(hooksDir, 0755); err != nil {
+		return "", fmt.Errorf("failed to create hooks directory: %w", err)
+	}
+	if err := os.WriteFile(path, []byte(prePushHook), 0755); err != nil {
+		return "", fmt.Errorf("failed to write pre-push hook: %w", err)
+	}
+	return path, nil
+}
@@ -0,0 +1,38 @@
+// Copyright (c) Kusari <https://www.kusari.dev/>
+// SPDX-License-Identifier: MIT
+
+package githook
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestInstall(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, exec.Command("git", "init", "-q", dir).Run())
+
+	path, err := Install(dir, false)
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(dir, ".git", "hooks", "pre-push"), path)
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Contains(t, string(data), "kusari repo scan")
+
+	// Re-installing over our own hook is fine.
+	_, err = Install(dir, false)
+	require.NoError(t, err)
+
+	// Someone else's hook is kept unless forced.
+	require.NoError(t, os.WriteFile(path, []byte("#!/bin/sh\necho custom\n"), 0755))
+	_, err = Install(dir, false)
+	assert.ErrorIs(t, err, ErrHookExists)
+	_, err = Install(dir, true)
+	require.NoError(t, err)
+}
@@ -0,0 +1,150 @@
+// Copyright (c) Kusari <https://www.kusari.dev/>
+// SPDX-License-Identifier: MIT
+
+package github
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+)
+
+// AppOptions identifies a GitHub App an organization owns, to act as its
+// installation on the organization.
+type AppOptions struct {
+	// AppID is the App ID (or client ID) of the app.
+	AppID string
+	// PrivateKey is a PEM private key generated for the app.
+	PrivateKey []byte
+	Org        string
+	GitHubURL  string
+}
+
+// AppToken returns an installation access token of the app for its
+// installation on the organization, valid for an hour.
+func AppToken(opts AppOptions) (string, error) {
+	key, err := parseAppKey(opts.PrivateKey)
+	if err != nil {
+		return "", err
+	}
+	jwt, err := appJWT(opts.AppID, key, time.Now())
+	if err != nil {
+		return "", err
+	}
+	apiURL := apiURLOrDefault(opts.GitHubURL)
+
+	var installation struct {
+		ID int64 `json:"id"`
+	}
+	if _, err := githubRequest("GET", fmt.Sprintf("%s/orgs/%s/installation", apiURL, opts.Org), jwt, nil, &installation); err != nil {
+		return "", fmt.Errorf("failed to find the app's installation on %s: %w", opts.Org, err)
+	}
+	var token struct {
+		Token string `json:"token"`
+	}
+	if _, err := githubRequest("POST", fmt.Sprintf("%s/app/installations/%d/access_tokens", apiURL, installation.ID), jwt, nil, &token); err != nil {
+		return "", fmt.Errorf("failed to create an installation token: %w", err)
+	}
+	return token.Token, nil
+}
+
+// parseAppKey parses the PEM RSA private key of an app, as PKCS #1 (what
+// GitHub generates) or PKCS #8.
+func parseAppKey(data []byte) (*rsa.PrivateKey, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("the GitHub App private key is not PEM")
+	}
+	if key, err := x509.ParsePKCS1PrivateKey(block.Bytes); err == nil {
+		return key, nil
+	}
+	parsed, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse the GitHub App private key: %w", err)
+	}
+	key, ok := parsed.(*rsa.PrivateKey)
+	if !ok {
+		return nil, errors.New("the GitHub App private key is not an RSA key")
+	}
+	return key, nil
+}
+
+// appJWT returns the RS256 JSON Web Token authenticating as the app,
+// issued a minute before now, against clock drift, and valid for ten
+// minutes, the most GitHub accepts.
+func appJWT(appID string, key *rsa.PrivateKey, now time.Time) (string, error) {
+	header, err := json.Marshal(map[string]string{"alg": "RS256", "typ": "JWT"})
+	if err != nil {
+		return "", err
+	}
+	claims, err := json.Marshal(map[string]any{
+		"iat": now.Add(-time.Minute).Unix(),
+		"exp": now.Add(9 * time.Minute).Unix(),
+		"iss": appID,
+	})
+	if err != nil {
+		return "", err
+	}
+	enc := base64.RawURLEncoding
+	signed := enc.EncodeToString(header) + "." + enc.EncodeToString(claims)
+	digest := sha256.Sum256([]byte(signed))
+	sig, err := rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, digest[:])
+	if err != nil {
+		return "", fmt.Errorf("failed to sign the GitHub App token: %w", err)
+	}
+	return signed + "." + enc.EncodeToString(sig), nil
+}
+
+// githubRequest sends a REST API request with the JSON of body, if not
+// nil, and decodes the response into out, if not nil. It returns the
+// response status; statuses other than 2xx are errors.
+func githubRequest(method, endpoint, token string, body, out any) (int, error) {
+	var reader io.Reader
+	if body != nil {
+		jsonBody, err := json.Marshal(body)
+		if err != nil {
+			return 0, fmt.Errorf("failed to marshal request: %w", err)
+		}
+		reader = bytes.NewBuffer(jsonBody)
+	}
+
+	client := newHTTPClient(30 * time.Second)
+	req, err := http.NewRequest(method, endpoint, reader)
+	if err != nil {
+		return 0, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	req.Header.Set("Authorization", "Bearer "+token)
+	req.Header.Set("Accept", "application/vnd.github+json")
+	req.Header.Set("X-GitHub-Api-Version", "2022-11-28")
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return 0, fmt.Errorf("request failed: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return resp.StatusCode, statusError(resp)
+	}
+	if out != nil {
+		if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+			return resp.StatusCode, fmt.Errorf("failed to decode response: %w", err)
+		}
+	}
+	return resp.StatusCode, nil
+}
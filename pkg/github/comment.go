@@ -7,7 +7,6 @@ import (
 	"bytes"
 	"encoding/json"
 	"fmt"
-	"io"
 	"net/http"
 	"os"
 	"regexp"
@@ -17,6 +16,7 @@ import (
 
 	"github.com/kusaridev/kusari-cli/v2/api"
 	"github.com/kusaridev/kusari-cli/v2/pkg/comment"
+	"github.com/kusaridev/kusari-cli/v2/pkg/readonly"
 )
 
 const (
@@ -29,23 +29,42 @@ type CommentOptions struct {
 	Repo       string
 	PRNumber   int
 	GitHubURL  string
+	GraphQLURL string // Defaults to the GraphQL endpoint next to GitHubURL
 	Token      string
 	ConsoleURL string // Link to full results in Kusari console
-	Verbose    bool
+	// MinimizePrevious minimizes the previous summary comment and posts a
+	// new one instead of updating it in place
+	MinimizePrevious bool
+	// Compact posts a one-line status comment with a badge instead of the
+	// summary, and no inline comments
+	Compact bool
+	// GraphQL reads the PR's comments and head in one GraphQL query, and
+	// batches minimizations and inline comment updates, instead of a REST
+	// call for each. The REST API is used if the query fails.
+	GraphQL bool
+	// Run marks the summary so that a duplicate posted by a concurrent run
+	// with the same key is removed. The zero value doesn't.
+	Run     comment.Run
+	Verbose bool
 }
 
 // issueComment represents a GitHub issue/PR comment
 type issueComment struct {
-	ID   int64  `json:"id"`
-	Body string `json:"body"`
+	ID     int64  `json:"id"`
+	NodeID string `json:"node_id"`
+	Body   string `json:"body"`
+	User   struct {
+		Login string `json:"login"`
+	} `json:"user"`
 }
 
 // prComment represents a GitHub PR review comment
 type prComment struct {
-	ID   int64  `json:"id"`
-	Body string `json:"body"`
-	Path string `json:"path"`
-	Line int    `json:"line"`
+	ID     int64  `json:"id"`
+	NodeID string `json:"node_id"`
+	Body   string `json:"body"`
+	Path   string `json:"path"`
+	Line   int    `json:"line"`
 }
 
 // pullRequest represents minimal PR info needed for comments
@@ -53,36 +72,57 @@ type pullRequest struct {
 	Head struct {
 		SHA string `json:"sha"`
 	} `json:"head"`
+	User struct {
+		Login string `json:"login"`
+	} `json:"user"`
 }
 
 // PostComment posts scan results as a comment to a GitHub pull request
 // Returns without posting if no issues are found (ShouldProceed is true and no mitigations)
-// If an existing Kusari comment exists, it will be updated instead of creating a new one
+// If an existing Kusari comment exists, it will be updated instead of creating a new one,
+// unless opts.MinimizePrevious is set
 func PostComment(analysis *api.SecurityAnalysis, opts CommentOptions) (*comment.CommentResult, error) {
 	if analysis == nil {
 		return &comment.CommentResult{
-			Posted:      false,
-			IssuesFound: 0,
-			Message:     "No analysis results available - skipping comment",
+			Posted:        false,
+			IssuesFound:   0,
+			Message:       "No analysis results available - skipping comment",
+			SummaryAction: comment.SummarySkipped,
 		}, nil
 	}
+	if err := readonly.Check("post a GitHub pull request comment"); err != nil {
+		return nil, err
+	}
 
 	// Check if there are any issues to report
 	hasIssues, issueCount := comment.CheckForIssues(analysis)
 
-	// Determine API URL
-	apiURL := opts.GitHubURL
-	if apiURL == "" {
-		apiURL = defaultGitHubAPIURL
+	apiURL := apiURLOrDefault(opts.GitHubURL)
+	graphQLURL := opts.GraphQLURL
+	if graphQLURL == "" {
+		graphQLURL = defaultGraphQLURL(apiURL)
+	}
+	var warnings []error
+
+	var state *prState
+	if opts.GraphQL {
+		var err error
+		state, err = fetchPRState(graphQLURL, opts.Owner, opts.Repo, opts.PRNumber, opts.Token)
+		if err != nil && opts.Verbose {
+			fmt.Fprintf(os.Stderr, "GraphQL query for PR #%d failed, using the REST API: %v\n", opts.PRNumber, err)
+		}
 	}
-	apiURL = strings.TrimSuffix(apiURL, "/")
 
 	// Check for existing Kusari summary comment and update if found
-	existingCommentID, err := findExistingKusariComment(apiURL, opts.Owner, opts.Repo, opts.PRNumber, opts.Token)
+	var existingCommentID int64
+	var err error
+	if state != nil {
+		existingCommentID = findKusariComment(state.IssueComments)
+	} else {
+		existingCommentID, err = findExistingKusariComment(apiURL, opts.Owner, opts.Repo, opts.PRNumber, opts.Token)
+	}
 	if err != nil {
-		if opts.Verbose {
-			fmt.Fprintf(os.Stderr, "Warning: Could not check for existing comments: %v\n", err)
-		}
+		warnings = append(warnings, fmt.Errorf("could not check for existing comments: %w", err))
 	} else if opts.Verbose {
 		if existingCommentID > 0 {
 			fmt.Fprintf(os.Stderr, "Found existing Kusari summary comment (ID: %d)\n", existingCommentID)
@@ -94,14 +134,40 @@ func PostComment(analysis *api.SecurityAnalysis, opts CommentOptions) (*comment.
 	// If no issues and no existing comment, nothing to do
 	if !hasIssues && existingCommentID == 0 {
 		return &comment.CommentResult{
-			Posted:      false,
-			IssuesFound: 0,
-			Message:     "No issues found - skipping comment",
+			Posted:        false,
+			IssuesFound:   0,
+			Message:       "No issues found - skipping comment",
+			SummaryAction: comment.SummarySkipped,
+			Warnings:      warnings,
 		}, nil
 	}
 
-	// Format comment body from analysis results
-	commentBody := comment.FormatComment(analysis, opts.ConsoleURL)
+	// Format comment body from analysis results, split to fit GitHub's
+	// comment size limit
+	var bodies []string
+	if opts.Compact {
+		bodies = []string{comment.FormatStatusComment(analysis, opts.ConsoleURL)}
+	} else {
+		bodies = comment.FormatComments(analysis, opts.ConsoleURL, comment.GitHubMaxLength-opts.Run.MarkerLength())
+	}
+	commentBody := bodies[0] + opts.Run.Marker(bodies[0])
+
+	if opts.MinimizePrevious {
+		var minimized int
+		var err error
+		if state != nil {
+			minimized, err = minimizeComments(graphQLURL, opts.Token, previousSummaryNodeIDs(state.IssueComments))
+		} else {
+			minimized, err = minimizePreviousComments(apiURL, graphQLURL, opts.Owner, opts.Repo, opts.PRNumber, opts.Token)
+		}
+		if err != nil {
+			// Don't fail - the new comment is still posted
+			warnings = append(warnings, fmt.Errorf("could not minimize previous comments: %w", err))
+		} else if opts.Verbose && minimized > 0 {
+			fmt.Fprintf(os.Stderr, "Minimized %d previous Kusari comment(s)\n", minimized)
+		}
+		existingCommentID = 0
+	}
 
 	if existingCommentID > 0 {
 		// Update existing comment
@@ -119,43 +185,85 @@ func PostComment(analysis *api.SecurityAnalysis, opts CommentOptions) (*comment.
 		if err := createIssueComment(apiURL, opts.Owner, opts.Repo, opts.PRNumber, opts.Token, commentBody); err != nil {
 			return nil, fmt.Errorf("failed to post comment to GitHub: %w", err)
 		}
+		if opts.Run.Key != "" {
+			kept, err := dedupeSummary(apiURL, opts, commentBody)
+			if err != nil {
+				warnings = append(warnings, fmt.Errorf("could not check for duplicate summary comments: %w", err))
+			} else if kept > 0 {
+				if opts.Verbose {
+					fmt.Fprintf(os.Stderr, "A concurrent run posted a summary first; kept that one (ID: %d)\n", kept)
+				}
+				existingCommentID = kept
+			}
+		}
 	}
 
-	// Post or update inline comments for code mitigations
-	inlineCount := 0
-	if len(analysis.RequiredCodeMitigations) > 0 && !analysis.ShouldProceed {
-		posted, err := postCodeMitigationComments(analysis, opts, apiURL)
-		if err != nil {
-			// Log but don't fail - inline comments are best-effort
-			if opts.Verbose {
-				fmt.Fprintf(os.Stderr, "Warning: Failed to post some inline comments: %v\n", err)
+	if opts.MinimizePrevious {
+		for _, body := range bodies[1:] {
+			if err := createIssueComment(apiURL, opts.Owner, opts.Repo, opts.PRNumber, opts.Token, body); err != nil {
+				return nil, fmt.Errorf("failed to post continuation comments to GitHub: %w", err)
 			}
 		}
-		inlineCount = posted
+	} else {
+		// The summary was written since the state was read, but the
+		// continuation parts weren't
+		var err error
+		if state != nil {
+			err = syncContinuations(apiURL, opts.Owner, opts.Repo, opts.PRNumber, opts.Token, state.IssueComments, bodies[1:])
+		} else {
+			err = syncContinuationComments(apiURL, opts.Owner, opts.Repo, opts.PRNumber, opts.Token, bodies[1:])
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to post continuation comments to GitHub: %w", err)
+		}
+	}
+	if opts.Verbose && len(bodies) > 1 {
+		fmt.Fprintf(os.Stderr, "Summary split across %d comments to fit GitHub's size limit\n", len(bodies))
 	}
 
+	// Post or update inline comments for code mitigations. They are
+	// best-effort: failures are reported, not returned.
+	var inline []comment.InlineResult
+	if len(analysis.RequiredCodeMitigations) > 0 && !analysis.ShouldProceed && !opts.Compact {
+		var inlineWarnings []error
+		inline, inlineWarnings = postCodeMitigationComments(analysis, opts, apiURL, graphQLURL, state)
+		warnings = append(warnings, inlineWarnings...)
+	}
+
+	result := &comment.CommentResult{
+		Posted:        true,
+		IssuesFound:   issueCount,
+		SummaryAction: comment.SummaryCreated,
+		Inline:        inline,
+		Warnings:      warnings,
+	}
+	result.InlineCommentsPosted = len(inline) - result.InlineFailed()
+
 	action := "Posted"
 	if existingCommentID > 0 {
 		action = "Updated"
+		result.SummaryAction = comment.SummaryUpdated
 	}
-	message := fmt.Sprintf("%s comment with %d issue(s) to PR #%d", action, issueCount, opts.PRNumber)
-	if inlineCount > 0 {
-		message = fmt.Sprintf("%s comment with %d issue(s) and %d inline comment(s) to PR #%d", action, issueCount, inlineCount, opts.PRNumber)
+	result.Message = fmt.Sprintf("%s comment with %d issue(s) to PR #%d", action, issueCount, opts.PRNumber)
+	if result.InlineCommentsPosted > 0 {
+		result.Message = fmt.Sprintf("%s comment with %d issue(s) and %d inline comment(s) to PR #%d", action, issueCount, result.InlineCommentsPosted, opts.PRNumber)
 	}
+	return result, nil
+}
 
-	return &comment.CommentResult{
-		Posted:               true,
-		IssuesFound:          issueCount,
-		InlineCommentsPosted: inlineCount,
-		Message:              message,
-	}, nil
+// apiURLOrDefault returns apiURL, or api.github.com if it is empty
+func apiURLOrDefault(apiURL string) string {
+	if apiURL == "" {
+		apiURL = defaultGitHubAPIURL
+	}
+	return strings.TrimSuffix(apiURL, "/")
 }
 
 // listIssueComments retrieves all comments on a PR (issue comments)
 func listIssueComments(apiURL, owner, repo string, prNumber int, token string) ([]issueComment, error) {
 	endpoint := fmt.Sprintf("%s/repos/%s/%s/issues/%d/comments", apiURL, owner, repo, prNumber)
 
-	client := &http.Client{Timeout: 30 * time.Second}
+	client := newHTTPClient(30 * time.Second)
 	req, err := http.NewRequest("GET", endpoint, nil)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create request: %w", err)
@@ -172,8 +280,7 @@ func listIssueComments(apiURL, owner, repo string, prNumber int, token string) (
 	defer func() { _ = resp.Body.Close() }()
 
 	if resp.StatusCode != http.StatusOK {
-		respBody, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("GitHub API returned status %d: %s", resp.StatusCode, string(respBody))
+		return nil, statusError(resp)
 	}
 
 	var comments []issueComment
@@ -190,7 +297,12 @@ func findExistingKusariComment(apiURL, owner, repo string, prNumber int, token s
 	if err != nil {
 		return 0, err
 	}
+	return findKusariComment(comments), nil
+}
 
+// findKusariComment returns the ID of the Kusari summary comment among
+// comments, or 0 if there is none
+func findKusariComment(comments []issueComment) int64 {
 	verbose := os.Getenv("KUSARI_DEBUG") == "true"
 	if verbose {
 		fmt.Fprintf(os.Stderr, "DEBUG: Searching through %d comments for existing Kusari comment\n", len(comments))
@@ -203,7 +315,7 @@ func findExistingKusariComment(apiURL, owner, repo string, prNumber int, token s
 			if verbose {
 				fmt.Fprintf(os.Stderr, "DEBUG: Found match at comment ID %d via IGNORE_KUSARI_COMMENT marker\n", c.ID)
 			}
-			return c.ID, nil
+			return c.ID
 		}
 
 		// Legacy text-based markers for backward compatibility
@@ -212,11 +324,40 @@ func findExistingKusariComment(apiURL, owner, repo string, prNumber int, token s
 			if verbose {
 				fmt.Fprintf(os.Stderr, "DEBUG: Found match at comment ID %d via legacy text marker\n", c.ID)
 			}
-			return c.ID, nil
+			return c.ID
 		}
 	}
 
-	return 0, nil
+	return 0
+}
+
+// dedupeSummary removes the summary this run just posted as body when a
+// concurrent run of the same key posted one first, updating that one with
+// body instead. The kept summary is updated before this run's is removed,
+// so a failed update never leaves the PR without one. Returns the ID of
+// the summary kept, or 0 if it is this run's.
+func dedupeSummary(apiURL string, opts CommentOptions, body string) (int64, error) {
+	comments, err := listIssueComments(apiURL, opts.Owner, opts.Repo, opts.PRNumber, opts.Token)
+	if err != nil {
+		return 0, err
+	}
+	posted := make([]comment.PostedComment, len(comments))
+	for i, c := range comments {
+		posted[i] = comment.PostedComment{ID: c.ID, Body: c.Body, Author: c.User.Login}
+	}
+
+	keep, remove, update := opts.Run.Dedupe(body, posted)
+	if update {
+		if err := updateIssueComment(apiURL, opts.Owner, opts.Repo, keep, opts.Token, body); err != nil {
+			return 0, err
+		}
+	}
+	for _, id := range remove {
+		if err := deleteIssueComment(apiURL, opts.Owner, opts.Repo, id, opts.Token); err != nil {
+			return 0, err
+		}
+	}
+	return keep, nil
 }
 
 // createIssueComment creates a new comment on a PR
@@ -229,7 +370,7 @@ func createIssueComment(apiURL, owner, repo string, prNumber int, token, body st
 		return fmt.Errorf("failed to marshal request: %w", err)
 	}
 
-	client := &http.Client{Timeout: 30 * time.Second}
+	client := newHTTPClient(30 * time.Second)
 	req, err := http.NewRequest("POST", endpoint, bytes.NewBuffer(jsonBody))
 	if err != nil {
 		return fmt.Errorf("failed to create request: %w", err)
@@ -247,8 +388,7 @@ func createIssueComment(apiURL, owner, repo string, prNumber int, token, body st
 	defer func() { _ = resp.Body.Close() }()
 
 	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
-		respBody, _ := io.ReadAll(resp.Body)
-		return fmt.Errorf("GitHub API returned status %d: %s", resp.StatusCode, string(respBody))
+		return statusError(resp)
 	}
 
 	return nil
@@ -264,7 +404,7 @@ func updateIssueComment(apiURL, owner, repo string, commentID int64, token, body
 		return fmt.Errorf("failed to marshal request: %w", err)
 	}
 
-	client := &http.Client{Timeout: 30 * time.Second}
+	client := newHTTPClient(30 * time.Second)
 	req, err := http.NewRequest("PATCH", endpoint, bytes.NewBuffer(jsonBody))
 	if err != nil {
 		return fmt.Errorf("failed to create request: %w", err)
@@ -282,18 +422,149 @@ func updateIssueComment(apiURL, owner, repo string, commentID int64, token, body
 	defer func() { _ = resp.Body.Close() }()
 
 	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
-		respBody, _ := io.ReadAll(resp.Body)
-		return fmt.Errorf("GitHub API returned status %d: %s", resp.StatusCode, string(respBody))
+		return statusError(resp)
+	}
+
+	return nil
+}
+
+// syncContinuationComments posts bodies as continuation parts 2..n of
+// the summary comment, updating the parts left by an earlier run and
+// deleting the ones no longer needed.
+func syncContinuationComments(apiURL, owner, repo string, prNumber int, token string, bodies []string) error {
+	comments, err := listIssueComments(apiURL, owner, repo, prNumber, token)
+	if err != nil {
+		return err
+	}
+	return syncContinuations(apiURL, owner, repo, prNumber, token, comments, bodies)
+}
+
+// syncContinuations is syncContinuationComments given the PR's comments
+func syncContinuations(apiURL, owner, repo string, prNumber int, token string, comments []issueComment, bodies []string) error {
+	existing := map[int]int64{}
+	for _, c := range comments {
+		if part, ok := comment.ParsePartMarker(c.Body); ok {
+			existing[part] = c.ID
+		}
+	}
+
+	for i, body := range bodies {
+		part := i + 2
+		if id, ok := existing[part]; ok {
+			delete(existing, part)
+			if err := updateIssueComment(apiURL, owner, repo, id, token, body); err != nil {
+				return err
+			}
+			continue
+		}
+		if err := createIssueComment(apiURL, owner, repo, prNumber, token, body); err != nil {
+			return err
+		}
+	}
+
+	for _, id := range existing {
+		if err := deleteIssueComment(apiURL, owner, repo, id, token); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// deleteIssueComment deletes a comment on a PR
+func deleteIssueComment(apiURL, owner, repo string, commentID int64, token string) error {
+	endpoint := fmt.Sprintf("%s/repos/%s/%s/issues/comments/%d", apiURL, owner, repo, commentID)
+
+	client := newHTTPClient(30 * time.Second)
+	req, err := http.NewRequest("DELETE", endpoint, nil)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+
+	req.Header.Set("Authorization", "Bearer "+token)
+	req.Header.Set("Accept", "application/vnd.github+json")
+	req.Header.Set("X-GitHub-Api-Version", "2022-11-28")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("request failed: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return statusError(resp)
 	}
 
 	return nil
 }
 
+// minimizePreviousComments minimizes the latest Kusari summary comment on
+// the PR and its continuation parts as outdated. Earlier summaries were
+// minimized by the runs that replaced them. Returns how many comments were
+// minimized.
+func minimizePreviousComments(apiURL, graphQLURL, owner, repo string, prNumber int, token string) (int, error) {
+	comments, err := listIssueComments(apiURL, owner, repo, prNumber, token)
+	if err != nil {
+		return 0, err
+	}
+
+	nodeIDs := previousSummaryNodeIDs(comments)
+	for i, id := range nodeIDs {
+		if err := minimizeComment(graphQLURL, token, id); err != nil {
+			return i, err
+		}
+	}
+	return len(nodeIDs), nil
+}
+
+// previousSummaryNodeIDs returns the node IDs of the latest Kusari summary
+// comment among comments and of its continuation parts
+func previousSummaryNodeIDs(comments []issueComment) []string {
+	last := -1
+	for i, c := range comments {
+		if comment.IsSummary(c.Body) {
+			last = i
+		}
+	}
+	if last < 0 {
+		return nil
+	}
+
+	nodeIDs := []string{comments[last].NodeID}
+	for _, c := range comments[last+1:] {
+		if _, ok := comment.ParsePartMarker(c.Body); ok {
+			nodeIDs = append(nodeIDs, c.NodeID)
+		}
+	}
+	return nodeIDs
+}
+
+// minimizeCommentMutation hides a comment as outdated
+const minimizeCommentMutation = `mutation($id: ID!) {
+  minimizeComment(input: {subjectId: $id, classifier: OUTDATED}) {
+    minimizedComment { isMinimized }
+  }
+}`
+
+// minimizeComment minimizes the comment with the given GraphQL node ID
+func minimizeComment(graphQLURL, token, nodeID string) error {
+	return doGraphQL(graphQLURL, token, minimizeCommentMutation, map[string]any{"id": nodeID}, nil)
+}
+
+// defaultGraphQLURL returns the GraphQL endpoint of the REST API at apiURL:
+// https://api.github.com/graphql, or https://HOST/api/graphql for GitHub
+// Enterprise Server's https://HOST/api/v3.
+func defaultGraphQLURL(apiURL string) string {
+	if base, ok := strings.CutSuffix(apiURL, "/api/v3"); ok {
+		return base + "/api/graphql"
+	}
+	return apiURL + "/graphql"
+}
+
 // getPRInfo retrieves PR information including the head SHA
 func getPRInfo(apiURL, owner, repo string, prNumber int, token string) (*pullRequest, error) {
 	endpoint := fmt.Sprintf("%s/repos/%s/%s/pulls/%d", apiURL, owner, repo, prNumber)
 
-	client := &http.Client{Timeout: 30 * time.Second}
+	client := newHTTPClient(30 * time.Second)
 	req, err := http.NewRequest("GET", endpoint, nil)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create request: %w", err)
@@ -310,8 +581,7 @@ func getPRInfo(apiURL, owner, repo string, prNumber int, token string) (*pullReq
 	defer func() { _ = resp.Body.Close() }()
 
 	if resp.StatusCode != http.StatusOK {
-		respBody, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("GitHub API returned status %d: %s", resp.StatusCode, string(respBody))
+		return nil, statusError(resp)
 	}
 
 	var pr pullRequest
@@ -326,7 +596,7 @@ func getPRInfo(apiURL, owner, repo string, prNumber int, token string) (*pullReq
 func listPRReviewComments(apiURL, owner, repo string, prNumber int, token string) ([]prComment, error) {
 	endpoint := fmt.Sprintf("%s/repos/%s/%s/pulls/%d/comments", apiURL, owner, repo, prNumber)
 
-	client := &http.Client{Timeout: 30 * time.Second}
+	client := newHTTPClient(30 * time.Second)
 	req, err := http.NewRequest("GET", endpoint, nil)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create request: %w", err)
@@ -343,8 +613,7 @@ func listPRReviewComments(apiURL, owner, repo string, prNumber int, token string
 	defer func() { _ = resp.Body.Close() }()
 
 	if resp.StatusCode != http.StatusOK {
-		respBody, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("GitHub API returned status %d: %s", resp.StatusCode, string(respBody))
+		return nil, statusError(resp)
 	}
 
 	var comments []prComment
@@ -355,25 +624,37 @@ func listPRReviewComments(apiURL, owner, repo string, prNumber int, token string
 	return comments, nil
 }
 
-// postCodeMitigationComments posts or updates inline comments for each code mitigation
-func postCodeMitigationComments(analysis *api.SecurityAnalysis, opts CommentOptions, apiURL string) (int, error) {
-	// Get PR info for the commit SHA
-	prInfo, err := getPRInfo(apiURL, opts.Owner, opts.Repo, opts.PRNumber, opts.Token)
-	if err != nil {
-		return 0, fmt.Errorf("failed to get PR info: %w", err)
-	}
+// postCodeMitigationComments posts or updates inline comments for each code
+// mitigation, returning the outcome of each and the API errors met. The
+// head SHA and existing comments come from state when it is set, and
+// updates are then batched into one GraphQL request.
+func postCodeMitigationComments(analysis *api.SecurityAnalysis, opts CommentOptions, apiURL, graphQLURL string, state *prState) ([]comment.InlineResult, []error) {
+	var warnings []error
+	var headSHA string
+	var existingComments []prComment
+	if state != nil {
+		headSHA = state.HeadSHA
+		existingComments = state.ReviewComments
+	} else {
+		// Get PR info for the commit SHA
+		prInfo, err := getPRInfo(apiURL, opts.Owner, opts.Repo, opts.PRNumber, opts.Token)
+		if err != nil {
+			return nil, []error{fmt.Errorf("failed to get PR info for inline comments: %w", err)}
+		}
+		headSHA = prInfo.Head.SHA
 
-	// Get existing review comments
-	existingComments, err := listPRReviewComments(apiURL, opts.Owner, opts.Repo, opts.PRNumber, opts.Token)
-	if err != nil {
-		if opts.Verbose {
-			fmt.Fprintf(os.Stderr, "Warning: Could not list existing review comments: %v\n", err)
+		// Get existing review comments
+		existingComments, err = listPRReviewComments(apiURL, opts.Owner, opts.Repo, opts.PRNumber, opts.Token)
+		if err != nil {
+			warnings = append(warnings, fmt.Errorf("could not list existing review comments: %w", err))
+			existingComments = nil
 		}
-		existingComments = nil
 	}
 
-	posted := 0
-	var lastErr error
+	var results []comment.InlineResult
+	// Updates batched for GraphQL, and the index of each one's result
+	var updates []reviewCommentUpdate
+	var updateIdx []int
 
 	for _, issue := range analysis.RequiredCodeMitigations {
 		// Skip issues without line numbers
@@ -387,6 +668,17 @@ func postCodeMitigationComments(analysis *api.SecurityAnalysis, opts CommentOpti
 
 		// Check if we already have a comment at this location
 		existingCommentID := findExistingInlineComment(existingComments, sanitizedPath, issue.LineNumber)
+		if existingCommentID > 0 && state != nil {
+			if nodeID := reviewCommentNodeID(existingComments, existingCommentID); nodeID != "" {
+				if opts.Verbose {
+					fmt.Fprintf(os.Stderr, "Updating inline comment at %s:%d\n", issue.Path, issue.LineNumber)
+				}
+				updates = append(updates, reviewCommentUpdate{NodeID: nodeID, Body: message})
+				updateIdx = append(updateIdx, len(results))
+				results = append(results, comment.NewInlineResult(issue, comment.InlineUpdated, nil))
+				continue
+			}
+		}
 
 		if opts.Verbose {
 			if existingCommentID > 0 {
@@ -402,31 +694,44 @@ func postCodeMitigationComments(analysis *api.SecurityAnalysis, opts CommentOpti
 				fmt.Fprintf(os.Stderr, "Updating inline comment at %s:%d\n", issue.Path, issue.LineNumber)
 			}
 			err := updatePRReviewComment(apiURL, opts.Owner, opts.Repo, existingCommentID, opts.Token, message)
+			results = append(results, comment.NewInlineResult(issue, comment.InlineUpdated, err))
 			if err != nil {
-				lastErr = err
-				if opts.Verbose {
-					fmt.Fprintf(os.Stderr, "Warning: Failed to update inline comment at %s:%d: %v\n", issue.Path, issue.LineNumber, err)
-				}
-				continue
+				warnings = append(warnings, fmt.Errorf("failed to update inline comment at %s:%d: %w", issue.Path, issue.LineNumber, err))
 			}
 		} else {
 			// Post new comment
 			if opts.Verbose {
 				fmt.Fprintf(os.Stderr, "Posting inline comment at %s:%d\n", issue.Path, issue.LineNumber)
 			}
-			err := createPRReviewComment(apiURL, opts.Owner, opts.Repo, opts.PRNumber, opts.Token, prInfo.Head.SHA, sanitizedPath, issue.LineNumber, message)
+			err := createPRReviewComment(apiURL, opts.Owner, opts.Repo, opts.PRNumber, opts.Token, headSHA, sanitizedPath, issue.LineNumber, message)
+			results = append(results, comment.NewInlineResult(issue, comment.InlineCreated, err))
 			if err != nil {
-				lastErr = err
-				if opts.Verbose {
-					fmt.Fprintf(os.Stderr, "Warning: Failed to post inline comment at %s:%d: %v\n", issue.Path, issue.LineNumber, err)
-				}
-				continue
+				warnings = append(warnings, fmt.Errorf("failed to post inline comment at %s:%d: %w", issue.Path, issue.LineNumber, err))
 			}
 		}
-		posted++
 	}
 
-	return posted, lastErr
+	for i, err := range updateReviewComments(graphQLURL, opts.Token, updates) {
+		if err == nil {
+			continue
+		}
+		r := &results[updateIdx[i]]
+		r.Status, r.Err = comment.InlineFailed, err
+		warnings = append(warnings, fmt.Errorf("failed to update inline comment at %s:%d: %w", r.Path, r.Line, err))
+	}
+
+	return results, warnings
+}
+
+// reviewCommentNodeID returns the GraphQL node ID of the review comment
+// with the given ID, or "" if it isn't among comments
+func reviewCommentNodeID(comments []prComment, id int64) string {
+	for _, c := range comments {
+		if c.ID == id {
+			return c.NodeID
+		}
+	}
+	return ""
 }
 
 // findExistingInlineComment finds an existing Kusari inline comment at the given location
@@ -475,7 +780,7 @@ func createPRReviewComment(apiURL, owner, repo string, prNumber int, token, comm
 		return fmt.Errorf("failed to marshal request: %w", err)
 	}
 
-	client := &http.Client{Timeout: 30 * time.Second}
+	client := newHTTPClient(30 * time.Second)
 	req, err := http.NewRequest("POST", endpoint, bytes.NewBuffer(jsonBody))
 	if err != nil {
 		return fmt.Errorf("failed to create request: %w", err)
@@ -493,8 +798,7 @@ func createPRReviewComment(apiURL, owner, repo string, prNumber int, token, comm
 	defer func() { _ = resp.Body.Close() }()
 
 	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
-		respBody, _ := io.ReadAll(resp.Body)
-		return fmt.Errorf("GitHub API returned status %d: %s", resp.StatusCode, string(respBody))
+		return statusError(resp)
 	}
 
 	return nil
@@ -510,7 +814,7 @@ func updatePRReviewComment(apiURL, owner, repo string, commentID int64, token, b
 		return fmt.Errorf("failed to marshal request: %w", err)
 	}
 
-	client := &http.Client{Timeout: 30 * time.Second}
+	client := newHTTPClient(30 * time.Second)
 	req, err := http.NewRequest("PATCH", endpoint, bytes.NewBuffer(jsonBody))
 	if err != nil {
 		return fmt.Errorf("failed to create request: %w", err)
@@ -528,8 +832,7 @@ func updatePRReviewComment(apiURL, owner, repo string, commentID int64, token, b
 	defer func() { _ = resp.Body.Close() }()
 
 	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
-		respBody, _ := io.ReadAll(resp.Body)
-		return fmt.Errorf("GitHub API returned status %d: %s", resp.StatusCode, string(respBody))
+		return statusError(resp)
 	}
 
 	return nil
@@ -552,6 +855,18 @@ func GetGitHubAPIURLFromEnv() string {
 	return ""
 }
 
+// GetGitHubGraphQLURLFromEnv retrieves the GitHub GraphQL API URL from environment
+// Returns empty string if not set (will be derived from the API URL)
+func GetGitHubGraphQLURLFromEnv() string {
+	return os.Getenv("GITHUB_GRAPHQL_URL")
+}
+
+// GetRunKeyFromEnv returns the commit a GitHub Actions run is for, which
+// concurrent runs for the same PR update share
+func GetRunKeyFromEnv() string {
+	return os.Getenv("GITHUB_SHA")
+}
+
 // GetPRInfoFromEnv retrieves PR info from GitHub Actions environment variables
 // Returns owner, repo, and PR number
 func GetPRInfoFromEnv() (owner, repo string, prNumber int) {
@@ -8,6 +8,7 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"os"
+	"slices"
 	"testing"
 
 	"github.com/kusaridev/kusari-cli/v2/api"
@@ -742,6 +743,64 @@ func TestPostCommentWithCodeMitigations(t *testing.T) {
 	assert.True(t, createReviewCalled, "Should have created review comment")
 }
 
+func TestPostCommentReportsInlineFailures(t *testing.T) {
+	analysis := &api.SecurityAnalysis{
+		ShouldProceed: false,
+		RequiredCodeMitigations: []api.CodeMitigationItem{
+			{Content: "SQL injection", Path: "main.go", LineNumber: 10},
+			{Content: "No line", Path: "main.go"},
+			{Content: "Outside the diff", Path: "other.go", LineNumber: 3},
+		},
+	}
+
+	listed := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == "GET" && r.URL.Path == "/repos/owner/repo/issues/1/comments":
+			// Finding the previous summary fails; syncing continuations doesn't.
+			listed++
+			if listed == 1 {
+				w.WriteHeader(http.StatusInternalServerError)
+				return
+			}
+			_, _ = w.Write([]byte(`[]`))
+		case r.Method == "POST" && r.URL.Path == "/repos/owner/repo/issues/1/comments":
+			w.WriteHeader(http.StatusCreated)
+		case r.Method == "GET" && r.URL.Path == "/repos/owner/repo/pulls/1":
+			_, _ = w.Write([]byte(`{"head": {"sha": "abc123"}}`))
+		case r.Method == "GET" && r.URL.Path == "/repos/owner/repo/pulls/1/comments":
+			_, _ = w.Write([]byte(`[]`))
+		case r.Method == "POST" && r.URL.Path == "/repos/owner/repo/pulls/1/comments":
+			var body map[string]any
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+			if body["path"] == "other.go" {
+				w.WriteHeader(http.StatusUnprocessableEntity)
+				return
+			}
+			w.WriteHeader(http.StatusCreated)
+		default:
+			t.Fatalf("Unexpected request: %s %s", r.Method, r.URL.Path)
+		}
+	}))
+	defer server.Close()
+
+	opts := CommentOptions{Owner: "owner", Repo: "repo", PRNumber: 1, GitHubURL: server.URL, Token: "token"}
+	result, err := PostComment(analysis, opts)
+	require.NoError(t, err)
+
+	assert.True(t, result.Posted)
+	assert.Equal(t, comment.SummaryCreated, result.SummaryAction)
+	require.Len(t, result.Inline, 2)
+	assert.Equal(t, comment.InlineResult{Path: "main.go", Line: 10, Status: comment.InlineCreated}, result.Inline[0])
+	assert.Equal(t, comment.InlineFailed, result.Inline[1].Status)
+	assert.ErrorContains(t, result.Inline[1].Err, "422")
+	assert.Equal(t, 1, result.InlineCommentsPosted)
+	assert.Equal(t, 1, result.InlineFailed())
+	require.Len(t, result.Warnings, 2)
+	assert.ErrorContains(t, result.Warnings[0], "could not check for existing comments")
+	assert.ErrorContains(t, result.Warnings[1], "other.go:3")
+}
+
 // Test that the comment package integration works correctly
 func TestCommentPackageIntegration(t *testing.T) {
 	analysis := &api.SecurityAnalysis{
@@ -759,3 +818,133 @@ func TestCommentPackageIntegration(t *testing.T) {
 	assert.Contains(t, formatted, "Kusari Analysis Results")
 	assert.Contains(t, formatted, "IGNORE_KUSARI_COMMENT")
 }
+
+func TestSyncContinuationComments(t *testing.T) {
+	var requests []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, r.Method+" "+r.URL.Path)
+		if r.Method == "GET" {
+			_ = json.NewEncoder(w).Encode([]issueComment{
+				{ID: 1, Body: "summary <!-- IGNORE_KUSARI_COMMENT -->"},
+				{ID: 2, Body: "old part 2 " + comment.PartMarker(2)},
+				{ID: 3, Body: "old part 3 " + comment.PartMarker(3)},
+			})
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	// The last run posted parts 2 and 3; this one needs only part 2.
+	require.NoError(t, syncContinuationComments(server.URL, "owner", "repo", 1, "token", []string{"new part 2"}))
+	assert.Equal(t, []string{
+		"GET /repos/owner/repo/issues/1/comments",
+		"PATCH /repos/owner/repo/issues/comments/2",
+		"DELETE /repos/owner/repo/issues/comments/3",
+	}, requests)
+}
+
+func TestMinimizePreviousComments(t *testing.T) {
+	var requests []string
+	var minimized []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, r.Method+" "+r.URL.Path)
+		if r.Method == "GET" {
+			_ = json.NewEncoder(w).Encode([]issueComment{
+				{ID: 1, NodeID: "IC_1", Body: "older summary <!-- IGNORE_KUSARI_COMMENT -->"},
+				{ID: 2, NodeID: "IC_2", Body: "older part 2 " + comment.PartMarker(2)},
+				{ID: 3, NodeID: "IC_3", Body: "latest summary <!-- IGNORE_KUSARI_COMMENT -->"},
+				{ID: 4, NodeID: "IC_4", Body: "LGTM"},
+				{ID: 5, NodeID: "IC_5", Body: "latest part 2 " + comment.PartMarker(2)},
+			})
+			return
+		}
+		var req struct {
+			Variables struct {
+				ID string `json:"id"`
+			} `json:"variables"`
+		}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+		minimized = append(minimized, req.Variables.ID)
+		_, _ = w.Write([]byte(`{"data":{"minimizeComment":{"minimizedComment":{"isMinimized":true}}}}`))
+	}))
+	defer server.Close()
+
+	n, err := minimizePreviousComments(server.URL, server.URL+"/graphql", "owner", "repo", 1, "token")
+	require.NoError(t, err)
+	assert.Equal(t, 2, n)
+	assert.Equal(t, []string{"IC_3", "IC_5"}, minimized)
+	assert.Equal(t, "POST /graphql", requests[len(requests)-1])
+}
+
+func TestMinimizeCommentGraphQLError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"errors":[{"message":"Resource not accessible by integration"}]}`))
+	}))
+	defer server.Close()
+
+	err := minimizeComment(server.URL, "token", "IC_1")
+	assert.ErrorContains(t, err, "Resource not accessible by integration")
+}
+
+func TestDefaultGraphQLURL(t *testing.T) {
+	assert.Equal(t, "https://api.github.com/graphql", defaultGraphQLURL("https://api.github.com"))
+	assert.Equal(t, "https://ghe.example.com/api/graphql", defaultGraphQLURL("https://ghe.example.com/api/v3"))
+}
+
+func TestPostCommentRemovesConcurrentDuplicate(t *testing.T) {
+	analysis := &api.SecurityAnalysis{ShouldProceed: false, Justification: "Security issues found"}
+	other := comment.Run{Key: "abc123", ID: "other"}
+	run := comment.Run{Key: "abc123", ID: "this"}
+
+	for _, failUpdate := range []bool{false, true} {
+		var stored []issueComment
+		var requests []string
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			requests = append(requests, r.Method+" "+r.URL.Path)
+			switch {
+			case r.Method == "GET" && r.URL.Path == "/repos/owner/repo/issues/1/comments":
+				_ = json.NewEncoder(w).Encode(stored)
+			case r.Method == "POST" && r.URL.Path == "/repos/owner/repo/issues/1/comments":
+				var body map[string]string
+				require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+				// The other run saw no summary either, and posted just before
+				c := issueComment{ID: 9, Body: "summary <!-- IGNORE_KUSARI_COMMENT -->" + other.Marker("other findings")}
+				c.User.Login = "kusari-bot"
+				stored = append(stored, c)
+				c = issueComment{ID: 10, Body: body["body"]}
+				c.User.Login = "kusari-bot"
+				stored = append(stored, c)
+				w.WriteHeader(http.StatusCreated)
+			case r.Method == "DELETE" && r.URL.Path == "/repos/owner/repo/issues/comments/10":
+				w.WriteHeader(http.StatusNoContent)
+			case r.Method == "PATCH" && r.URL.Path == "/repos/owner/repo/issues/comments/9":
+				if failUpdate {
+					w.WriteHeader(http.StatusForbidden)
+					return
+				}
+				w.WriteHeader(http.StatusOK)
+			default:
+				t.Errorf("Unexpected request: %s %s", r.Method, r.URL.Path)
+			}
+		}))
+
+		opts := CommentOptions{Owner: "owner", Repo: "repo", PRNumber: 1, GitHubURL: server.URL, Token: "token", Run: run}
+		result, err := PostComment(analysis, opts)
+		server.Close()
+		require.NoError(t, err)
+
+		patch := slices.Index(requests, "PATCH /repos/owner/repo/issues/comments/9")
+		remove := slices.Index(requests, "DELETE /repos/owner/repo/issues/comments/10")
+		require.NotEqual(t, -1, patch)
+		if failUpdate {
+			// This run's summary is the only current one; it stays.
+			require.Len(t, result.Warnings, 1)
+			assert.Equal(t, -1, remove)
+			continue
+		}
+		assert.Empty(t, result.Warnings)
+		assert.Equal(t, comment.SummaryUpdated, result.SummaryAction)
+		assert.Greater(t, remove, patch, "the kept summary is updated before this run's is removed")
+	}
+}
@@ -0,0 +1,219 @@
+// Copyright (c) Kusari <https://www.kusari.dev/>
+// SPDX-License-Identifier: MIT
+
+package github
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+
+	"github.com/kusaridev/kusari-cli/v2/pkg/readonly"
+)
+
+// Outcomes of pushing a config file to a repository.
+const (
+	PushOpened    = "opened"    // A pull request was opened
+	PushUpdated   = "updated"   // The open pull request of an earlier push was updated
+	PushUnchanged = "unchanged" // The default branch already has the file
+)
+
+// PushOptions configures PushConfig.
+type PushOptions struct {
+	Org   string
+	Repos []string
+	// Path is where the file goes in each repository, e.g. kusari.yaml.
+	Path    string
+	Content []byte
+	// Branch is the branch the file is committed to, reset to the default
+	// branch on every push so the pull request holds only that commit. It
+	// can't be the default branch, and a branch holding commits other than
+	// that of an earlier push is never reset.
+	Branch        string
+	CommitMessage string
+	Title         string
+	Body          string
+	GitHubURL     string
+	Token         string
+	Verbose       bool
+}
+
+// PushResult is the outcome of pushing the file to one repository.
+type PushResult struct {
+	Repo    string
+	Outcome string
+	// URL is the pull request's, when one was opened or updated.
+	URL string
+	Err error
+}
+
+// contentFile is a file of the contents API.
+type contentFile struct {
+	SHA     string `json:"sha"`
+	Content string `json:"content"`
+}
+
+// gitRef is a reference of the git database API.
+type gitRef struct {
+	Object struct {
+		SHA string `json:"sha"`
+	} `json:"object"`
+}
+
+// branchCommit is a commit of the commits API.
+type branchCommit struct {
+	Commit struct {
+		Message string `json:"message"`
+	} `json:"commit"`
+	Parents []struct {
+		SHA string `json:"sha"`
+	} `json:"parents"`
+	Files []struct {
+		Filename string `json:"filename"`
+	} `json:"files"`
+}
+
+// pullRequestRef is a pull request as listed.
+type pullRequestRef struct {
+	HTMLURL string `json:"html_url"`
+}
+
+// PushConfig commits the file to the branch of each repository of the
+// organization and opens a pull request of it into the default branch, or
+// updates the one an earlier push opened. Repositories whose default
+// branch already has the file are left alone. A repository that fails
+// doesn't stop the others; its result holds the error.
+func PushConfig(opts PushOptions) []PushResult {
+	var pushed []PushResult
+	if err := readonly.Check("push a config file to GitHub"); err != nil {
+		for _, repo := range opts.Repos {
+			pushed = append(pushed, PushResult{Repo: repo, Err: err})
+		}
+		return pushed
+	}
+	apiURL := apiURLOrDefault(opts.GitHubURL)
+	for _, repo := range opts.Repos {
+		res := pushRepo(apiURL, opts, repo)
+		res.Repo = repo
+		if opts.Verbose && res.Err == nil {
+			fmt.Fprintf(os.Stderr, "%s/%s: %s %s\n", opts.Org, repo, res.Outcome, res.URL)
+		}
+		pushed = append(pushed, res)
+	}
+	return pushed
+}
+
+// pushRepo pushes the file to one repository.
+func pushRepo(apiURL string, opts PushOptions, repo string) PushResult {
+	repoURL := fmt.Sprintf("%s/repos/%s/%s", apiURL, opts.Org, repo)
+
+	var info struct {
+		DefaultBranch string `json:"default_branch"`
+	}
+	if _, err := githubRequest("GET", repoURL, opts.Token, nil, &info); err != nil {
+		return PushResult{Err: fmt.Errorf("failed to get repository: %w", err)}
+	}
+	if opts.Branch == info.DefaultBranch {
+		return PushResult{Err: fmt.Errorf("branch %s is the default branch; push to another branch", opts.Branch)}
+	}
+
+	current, err := getContent(repoURL, opts.Path, info.DefaultBranch, opts.Token)
+	if err != nil {
+		return PushResult{Err: fmt.Errorf("failed to get %s: %w", opts.Path, err)}
+	}
+	if current != nil {
+		if existing, err := base64.StdEncoding.DecodeString(strings.ReplaceAll(current.Content, "\n", "")); err == nil && bytes.Equal(existing, opts.Content) {
+			return PushResult{Outcome: PushUnchanged}
+		}
+	}
+
+	var base gitRef
+	if _, err := githubRequest("GET", repoURL+"/git/ref/heads/"+info.DefaultBranch, opts.Token, nil, &base); err != nil {
+		return PushResult{Err: fmt.Errorf("failed to get %s: %w", info.DefaultBranch, err)}
+	}
+	var head gitRef
+	status, err := githubRequest("GET", repoURL+"/git/ref/heads/"+opts.Branch, opts.Token, nil, &head)
+	switch {
+	case status == http.StatusNotFound:
+		ref := map[string]string{"ref": "refs/heads/" + opts.Branch, "sha": base.Object.SHA}
+		if _, err := githubRequest("POST", repoURL+"/git/refs", opts.Token, ref, nil); err != nil {
+			return PushResult{Err: fmt.Errorf("failed to create branch %s: %w", opts.Branch, err)}
+		}
+	case err != nil:
+		return PushResult{Err: fmt.Errorf("failed to get branch %s: %w", opts.Branch, err)}
+	default:
+		pushed, err := pushedCommit(repoURL, head.Object.SHA, base.Object.SHA, opts)
+		if err != nil {
+			return PushResult{Err: fmt.Errorf("failed to get branch %s: %w", opts.Branch, err)}
+		}
+		if !pushed {
+			return PushResult{Err: fmt.Errorf("branch %s has commits not made by a config push; merge or delete it, or push to another branch", opts.Branch)}
+		}
+		ref := map[string]any{"sha": base.Object.SHA, "force": true}
+		if _, err := githubRequest("PATCH", repoURL+"/git/refs/heads/"+opts.Branch, opts.Token, ref, nil); err != nil {
+			return PushResult{Err: fmt.Errorf("failed to reset branch %s: %w", opts.Branch, err)}
+		}
+	}
+
+	put := map[string]string{
+		"message": opts.CommitMessage,
+		"content": base64.StdEncoding.EncodeToString(opts.Content),
+		"branch":  opts.Branch,
+	}
+	if current != nil {
+		put["sha"] = current.SHA
+	}
+	if _, err := githubRequest("PUT", repoURL+"/contents/"+opts.Path, opts.Token, put, nil); err != nil {
+		return PushResult{Err: fmt.Errorf("failed to commit %s: %w", opts.Path, err)}
+	}
+
+	query := url.Values{}
+	query.Set("head", opts.Org+":"+opts.Branch)
+	query.Set("state", "open")
+	var open []pullRequestRef
+	if _, err := githubRequest("GET", repoURL+"/pulls?"+query.Encode(), opts.Token, nil, &open); err != nil {
+		return PushResult{Err: fmt.Errorf("failed to list pull requests: %w", err)}
+	}
+	if len(open) > 0 {
+		return PushResult{Outcome: PushUpdated, URL: open[0].HTMLURL}
+	}
+	pr := map[string]string{"title": opts.Title, "body": opts.Body, "head": opts.Branch, "base": info.DefaultBranch}
+	var created pullRequestRef
+	if _, err := githubRequest("POST", repoURL+"/pulls", opts.Token, pr, &created); err != nil {
+		return PushResult{Err: fmt.Errorf("failed to open pull request: %w", err)}
+	}
+	return PushResult{Outcome: PushOpened, URL: created.HTMLURL}
+}
+
+// pushedCommit reports whether the branch head sha can be reset to the
+// default branch's base without losing anyone's work: it is base itself,
+// or the commit of an earlier push, which only changes the file on top of
+// one parent with the push's message.
+func pushedCommit(repoURL, sha, base string, opts PushOptions) (bool, error) {
+	if sha == base {
+		return true, nil
+	}
+	var c branchCommit
+	if _, err := githubRequest("GET", repoURL+"/commits/"+sha, opts.Token, nil, &c); err != nil {
+		return false, err
+	}
+	return c.Commit.Message == opts.CommitMessage && len(c.Parents) == 1 &&
+		len(c.Files) == 1 && c.Files[0].Filename == opts.Path, nil
+}
+
+// getContent returns the file at path on ref, or nil if there is none.
+func getContent(repoURL, path, ref, token string) (*contentFile, error) {
+	var file contentFile
+	status, err := githubRequest("GET", repoURL+"/contents/"+path+"?ref="+url.QueryEscape(ref), token, nil, &file)
+	if status == http.StatusNotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &file, nil
+}
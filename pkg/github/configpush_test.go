@@ -0,0 +1,140 @@
+// Copyright (c) Kusari <https://www.kusari.dev/>
+// SPDX-License-Identifier: MIT
+
+package github
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeRepo is a repository of the fake GitHub of TestPushConfig.
+type fakeRepo struct {
+	config   string // kusari.yaml on main; empty if none
+	branch   bool   // the push branch exists
+	edited   bool   // the push branch has someone else's commit on top
+	openPR   bool
+	requests []string
+}
+
+func TestPushConfig(t *testing.T) {
+	content := []byte("comment_style: compact\n")
+	repos := map[string]*fakeRepo{
+		"new":       {},
+		"outdated":  {config: "comment_style: full\n", branch: true, openPR: true},
+		"unchanged": {config: string(content)},
+		"edited":    {config: "comment_style: full\n", branch: true, edited: true},
+	}
+	var commits []map[string]string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "Bearer token", r.Header.Get("Authorization"))
+		rest, ok := strings.CutPrefix(r.URL.Path, "/repos/acme/")
+		name, path, _ := strings.Cut(rest, "/")
+		repo := repos[name]
+		if !ok || repo == nil {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		repo.requests = append(repo.requests, r.Method+" "+path)
+		switch r.Method + " " + path {
+		case "GET ":
+			_, _ = w.Write([]byte(`{"default_branch": "main"}`))
+		case "GET contents/kusari.yaml":
+			assert.Equal(t, "main", r.URL.Query().Get("ref"))
+			if repo.config == "" {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			_ = json.NewEncoder(w).Encode(contentFile{SHA: "filesha", Content: base64.StdEncoding.EncodeToString([]byte(repo.config))})
+		case "GET git/ref/heads/main":
+			_, _ = w.Write([]byte(`{"object": {"sha": "mainsha"}}`))
+		case "GET git/ref/heads/kusari/config":
+			if !repo.branch {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			_, _ = w.Write([]byte(`{"object": {"sha": "oldsha"}}`))
+		case "GET commits/oldsha":
+			message := "Update kusari.yaml"
+			if repo.edited {
+				message = "Tweak the config by hand"
+			}
+			_, _ = w.Write([]byte(`{"commit": {"message": "` + message + `"}, "parents": [{"sha": "mainsha"}], "files": [{"filename": "kusari.yaml"}]}`))
+		case "POST git/refs", "PATCH git/refs/heads/kusari/config":
+			var ref map[string]any
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&ref))
+			assert.Equal(t, "mainsha", ref["sha"])
+			w.WriteHeader(http.StatusCreated)
+		case "PUT contents/kusari.yaml":
+			var commit map[string]string
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&commit))
+			commits = append(commits, commit)
+			w.WriteHeader(http.StatusCreated)
+		case "GET pulls":
+			assert.Equal(t, "acme:kusari/config", r.URL.Query().Get("head"))
+			if repo.openPR {
+				_, _ = w.Write([]byte(`[{"html_url": "https://github.com/acme/` + name + `/pull/1"}]`))
+				return
+			}
+			_, _ = w.Write([]byte(`[]`))
+		case "POST pulls":
+			var pr map[string]string
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&pr))
+			assert.Equal(t, map[string]string{"title": "Standardize", "body": "From platform", "head": "kusari/config", "base": "main"}, pr)
+			w.WriteHeader(http.StatusCreated)
+			_, _ = w.Write([]byte(`{"html_url": "https://github.com/acme/` + name + `/pull/2"}`))
+		default:
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+		}
+	}))
+	defer server.Close()
+
+	results := PushConfig(PushOptions{
+		Org: "acme", Repos: []string{"new", "outdated", "unchanged", "missing", "edited"},
+		Path: "kusari.yaml", Content: content, Branch: "kusari/config",
+		CommitMessage: "Update kusari.yaml", Title: "Standardize", Body: "From platform",
+		GitHubURL: server.URL, Token: "token",
+	})
+	require.Len(t, results, 5)
+	assert.Equal(t, PushResult{Repo: "new", Outcome: PushOpened, URL: "https://github.com/acme/new/pull/2"}, results[0])
+	assert.Equal(t, PushResult{Repo: "outdated", Outcome: PushUpdated, URL: "https://github.com/acme/outdated/pull/1"}, results[1])
+	assert.Equal(t, PushResult{Repo: "unchanged", Outcome: PushUnchanged}, results[2])
+	assert.Equal(t, "missing", results[3].Repo)
+	assert.ErrorContains(t, results[3].Err, "status 404")
+	assert.ErrorContains(t, results[4].Err, "branch kusari/config has commits not made by a config push")
+	assert.NotContains(t, repos["edited"].requests, "PATCH git/refs/heads/kusari/config", "someone else's commit is kept")
+
+	assert.Contains(t, repos["new"].requests, "POST git/refs")
+	assert.Contains(t, repos["outdated"].requests, "PATCH git/refs/heads/kusari/config")
+	assert.Equal(t, []string{"GET ", "GET contents/kusari.yaml"}, repos["unchanged"].requests)
+
+	require.Len(t, commits, 2)
+	assert.Equal(t, base64.StdEncoding.EncodeToString(content), commits[0]["content"])
+	assert.Equal(t, "kusari/config", commits[0]["branch"])
+	assert.NotContains(t, commits[0], "sha", "a new file has no sha")
+	assert.Equal(t, "filesha", commits[1]["sha"], "an existing file is replaced")
+}
+
+func TestPushConfig_DefaultBranch(t *testing.T) {
+	var requests []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, r.Method+" "+r.URL.Path)
+		_, _ = w.Write([]byte(`{"default_branch": "main"}`))
+	}))
+	defer server.Close()
+
+	results := PushConfig(PushOptions{
+		Org: "acme", Repos: []string{"app"}, Path: "kusari.yaml", Content: []byte("x: 1\n"),
+		Branch: "main", GitHubURL: server.URL, Token: "token",
+	})
+	require.Len(t, results, 1)
+	assert.ErrorContains(t, results[0].Err, "branch main is the default branch")
+	assert.Equal(t, []string{"GET /repos/acme/app"}, requests, "nothing is committed")
+}
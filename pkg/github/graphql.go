@@ -0,0 +1,243 @@
+// Copyright (c) Kusari <https://www.kusari.dev/>
+// SPDX-License-Identifier: MIT
+
+package github
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// graphQLError is an error reported in the body of a GraphQL response
+type graphQLError struct {
+	Message string `json:"message"`
+	// Path starts with the field, or alias, the error is about
+	Path []any `json:"path"`
+}
+
+// graphQLErrors is the error returned for a response with errors in it
+type graphQLErrors []graphQLError
+
+func (e graphQLErrors) Error() string {
+	return "GitHub GraphQL API error: " + e[0].Message
+}
+
+// doGraphQL runs query with variables and decodes its data into data. The
+// data is decoded even when the response also has errors, which are
+// returned as graphQLErrors.
+func doGraphQL(graphQLURL, token, query string, variables map[string]any, data any) error {
+	reqBody := map[string]any{
+		"query":     query,
+		"variables": variables,
+	}
+	jsonBody, err := json.Marshal(reqBody)
+	if err != nil {
+		return fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	client := newHTTPClient(30 * time.Second)
+	req, err := http.NewRequest("POST", graphQLURL, bytes.NewBuffer(jsonBody))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+
+	req.Header.Set("Authorization", "Bearer "+token)
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("request failed: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode != http.StatusOK {
+		return statusError(resp)
+	}
+
+	// GraphQL reports errors in the body of a 200 response
+	var result struct {
+		Data   json.RawMessage `json:"data"`
+		Errors graphQLErrors   `json:"errors"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return fmt.Errorf("failed to decode response: %w", err)
+	}
+	if data != nil && len(result.Data) > 0 && string(result.Data) != "null" {
+		if err := json.Unmarshal(result.Data, data); err != nil {
+			return fmt.Errorf("failed to decode response: %w", err)
+		}
+	}
+	if len(result.Errors) > 0 {
+		return result.Errors
+	}
+	return nil
+}
+
+// prState is what PostComment reads about a pull request before writing
+// to it
+type prState struct {
+	HeadSHA        string
+	IssueComments  []issueComment
+	ReviewComments []prComment
+}
+
+// prStateQuery fetches the head commit, the latest issue comments and the
+// first comment of the latest review threads of a pull request. Kusari's
+// inline comments each start a thread, so the replies aren't needed.
+const prStateQuery = `query($owner: String!, $repo: String!, $number: Int!) {
+  repository(owner: $owner, name: $repo) {
+    pullRequest(number: $number) {
+      headRefOid
+      comments(last: 100) {
+        nodes { id databaseId body }
+      }
+      reviewThreads(last: 100) {
+        nodes {
+          comments(first: 1) {
+            nodes { id databaseId body path line }
+          }
+        }
+      }
+    }
+  }
+}`
+
+// fetchPRState reads, in one GraphQL query, what the REST API takes a
+// call each for: the PR's head SHA, its issue comments and its review
+// comments
+func fetchPRState(graphQLURL, owner, repo string, prNumber int, token string) (*prState, error) {
+	type node struct {
+		ID         string `json:"id"`
+		DatabaseID int64  `json:"databaseId"`
+		Body       string `json:"body"`
+		Path       string `json:"path"`
+		Line       int    `json:"line"`
+	}
+	var data struct {
+		Repository *struct {
+			PullRequest *struct {
+				HeadRefOid string `json:"headRefOid"`
+				Comments   struct {
+					Nodes []node `json:"nodes"`
+				} `json:"comments"`
+				ReviewThreads struct {
+					Nodes []struct {
+						Comments struct {
+							Nodes []node `json:"nodes"`
+						} `json:"comments"`
+					} `json:"nodes"`
+				} `json:"reviewThreads"`
+			} `json:"pullRequest"`
+		} `json:"repository"`
+	}
+	variables := map[string]any{"owner": owner, "repo": repo, "number": prNumber}
+	if err := doGraphQL(graphQLURL, token, prStateQuery, variables, &data); err != nil {
+		return nil, err
+	}
+	if data.Repository == nil || data.Repository.PullRequest == nil {
+		return nil, fmt.Errorf("pull request %s/%s#%d not found", owner, repo, prNumber)
+	}
+
+	pr := data.Repository.PullRequest
+	state := &prState{HeadSHA: pr.HeadRefOid}
+	for _, n := range pr.Comments.Nodes {
+		state.IssueComments = append(state.IssueComments, issueComment{ID: n.DatabaseID, NodeID: n.ID, Body: n.Body})
+	}
+	for _, t := range pr.ReviewThreads.Nodes {
+		for _, n := range t.Comments.Nodes {
+			state.ReviewComments = append(state.ReviewComments, prComment{ID: n.DatabaseID, NodeID: n.ID, Body: n.Body, Path: n.Path, Line: n.Line})
+		}
+	}
+	return state, nil
+}
+
+// batchMutation runs mutation once per input, all in one request with
+// each under its own alias, and returns the error of each. inputType is
+// the GraphQL type of the mutation's input argument.
+func batchMutation(graphQLURL, token, mutation, inputType string, inputs []map[string]any) []error {
+	errs := make([]error, len(inputs))
+	if len(inputs) == 0 {
+		return errs
+	}
+
+	var params, fields []string
+	variables := map[string]any{}
+	for i, input := range inputs {
+		params = append(params, fmt.Sprintf("$i%d: %s!", i, inputType))
+		fields = append(fields, fmt.Sprintf("m%d: %s(input: $i%d) { clientMutationId }", i, mutation, i))
+		variables[fmt.Sprintf("i%d", i)] = input
+	}
+	query := fmt.Sprintf("mutation(%s) {\n  %s\n}", strings.Join(params, ", "), strings.Join(fields, "\n  "))
+
+	err := doGraphQL(graphQLURL, token, query, variables, nil)
+	var gqlErrs graphQLErrors
+	if !errors.As(err, &gqlErrs) {
+		// The whole request failed, or none of it did
+		for i := range errs {
+			errs[i] = err
+		}
+		return errs
+	}
+	for _, e := range gqlErrs {
+		i := -1
+		if len(e.Path) > 0 {
+			if alias, ok := e.Path[0].(string); ok {
+				_, _ = fmt.Sscanf(alias, "m%d", &i)
+			}
+		}
+		if i < 0 || i >= len(errs) {
+			// Not about any one of them, so about all of them
+			for j := range errs {
+				if errs[j] == nil {
+					errs[j] = graphQLErrors{e}
+				}
+			}
+			continue
+		}
+		if errs[i] == nil {
+			errs[i] = graphQLErrors{e}
+		}
+	}
+	return errs
+}
+
+// minimizeComments minimizes the comments with the given GraphQL node IDs
+// as outdated in one request. Returns how many were minimized and the
+// first error met.
+func minimizeComments(graphQLURL, token string, nodeIDs []string) (int, error) {
+	inputs := make([]map[string]any, len(nodeIDs))
+	for i, id := range nodeIDs {
+		inputs[i] = map[string]any{"subjectId": id, "classifier": "OUTDATED"}
+	}
+	minimized := 0
+	var firstErr error
+	for _, err := range batchMutation(graphQLURL, token, "minimizeComment", "MinimizeCommentInput", inputs) {
+		if err == nil {
+			minimized++
+		} else if firstErr == nil {
+			firstErr = err
+		}
+	}
+	return minimized, firstErr
+}
+
+// reviewCommentUpdate is an inline comment to update with a new body
+type reviewCommentUpdate struct {
+	NodeID string
+	Body   string
+}
+
+// updateReviewComments updates review comments in one request, returning
+// the error of each
+func updateReviewComments(graphQLURL, token string, updates []reviewCommentUpdate) []error {
+	inputs := make([]map[string]any, len(updates))
+	for i, u := range updates {
+		inputs[i] = map[string]any{"pullRequestReviewCommentId": u.NodeID, "body": u.Body}
+	}
+	return batchMutation(graphQLURL, token, "updatePullRequestReviewComment", "UpdatePullRequestReviewCommentInput", inputs)
+}
@@ -0,0 +1,182 @@
+// Copyright (c) Kusari <https://www.kusari.dev/>
+// SPDX-License-Identifier: MIT
+
+package github
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/kusaridev/kusari-cli/v2/api"
+	"github.com/kusaridev/kusari-cli/v2/pkg/comment"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const prStateResponse = `{"data": {"repository": {"pullRequest": {
+  "headRefOid": "abc123",
+  "comments": {"nodes": [
+    {"id": "IC_1", "databaseId": 1, "body": "LGTM"},
+    {"id": "IC_2", "databaseId": 2, "body": "summary <!-- IGNORE_KUSARI_COMMENT -->"}
+  ]},
+  "reviewThreads": {"nodes": [
+    {"comments": {"nodes": [{"id": "RC_7", "databaseId": 7, "body": "old <!-- KUSARI_INLINE:main.go:10 -->", "path": "main.go", "line": 10}]}}
+  ]}
+}}}}`
+
+type graphQLRequest struct {
+	Query     string         `json:"query"`
+	Variables map[string]any `json:"variables"`
+}
+
+func TestFetchPRState(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req graphQLRequest
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+		assert.Equal(t, map[string]any{"owner": "owner", "repo": "repo", "number": float64(1)}, req.Variables)
+		_, _ = w.Write([]byte(prStateResponse))
+	}))
+	defer server.Close()
+
+	state, err := fetchPRState(server.URL, "owner", "repo", 1, "token")
+	require.NoError(t, err)
+	assert.Equal(t, "abc123", state.HeadSHA)
+	assert.Equal(t, []issueComment{
+		{ID: 1, NodeID: "IC_1", Body: "LGTM"},
+		{ID: 2, NodeID: "IC_2", Body: "summary <!-- IGNORE_KUSARI_COMMENT -->"},
+	}, state.IssueComments)
+	assert.Equal(t, []prComment{
+		{ID: 7, NodeID: "RC_7", Body: "old <!-- KUSARI_INLINE:main.go:10 -->", Path: "main.go", Line: 10},
+	}, state.ReviewComments)
+}
+
+func TestFetchPRStateNotFound(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"data": {"repository": {"pullRequest": null}},
+			"errors": [{"message": "Could not resolve to a PullRequest with the number of 1.", "path": ["repository", "pullRequest"]}]}`))
+	}))
+	defer server.Close()
+
+	_, err := fetchPRState(server.URL, "owner", "repo", 1, "token")
+	assert.ErrorContains(t, err, "Could not resolve to a PullRequest")
+}
+
+func TestBatchMutation(t *testing.T) {
+	var query string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req graphQLRequest
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+		query = req.Query
+		assert.Len(t, req.Variables, 3)
+		_, _ = w.Write([]byte(`{"data": {"m0": {"clientMutationId": null}, "m1": null, "m2": {"clientMutationId": null}},
+			"errors": [{"message": "Could not resolve to a node with the global id of 'IC_x'", "path": ["m1"]}]}`))
+	}))
+	defer server.Close()
+
+	n, err := minimizeComments(server.URL, "token", []string{"IC_1", "IC_x", "IC_3"})
+	assert.Equal(t, 2, n)
+	assert.ErrorContains(t, err, "IC_x")
+	assert.Contains(t, query, "$i0: MinimizeCommentInput!")
+	assert.Contains(t, query, "m2: minimizeComment(input: $i2) { clientMutationId }")
+}
+
+func TestBatchMutationRequestFails(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer server.Close()
+
+	errs := updateReviewComments(server.URL, "token", []reviewCommentUpdate{{NodeID: "RC_1", Body: "a"}, {NodeID: "RC_2", Body: "b"}})
+	require.Len(t, errs, 2)
+	assert.ErrorContains(t, errs[0], "502")
+	assert.ErrorContains(t, errs[1], "502")
+	assert.Empty(t, updateReviewComments(server.URL, "token", nil))
+}
+
+func TestPostCommentGraphQL(t *testing.T) {
+	analysis := &api.SecurityAnalysis{
+		ShouldProceed: false,
+		RequiredCodeMitigations: []api.CodeMitigationItem{
+			{Content: "SQL injection", Path: "main.go", LineNumber: 10},
+			{Content: "Path traversal", Path: "main.go", LineNumber: 20},
+		},
+	}
+
+	var requests []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, r.Method+" "+r.URL.Path)
+		switch {
+		case r.Method == "POST" && r.URL.Path == "/graphql":
+			var req graphQLRequest
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+			if strings.HasPrefix(req.Query, "query") {
+				_, _ = w.Write([]byte(prStateResponse))
+				return
+			}
+			assert.Contains(t, req.Query, "updatePullRequestReviewComment")
+			assert.Equal(t, "RC_7", req.Variables["i0"].(map[string]any)["pullRequestReviewCommentId"])
+			_, _ = w.Write([]byte(`{"data": {"m0": {"clientMutationId": null}}}`))
+		case r.Method == "PATCH" && r.URL.Path == "/repos/owner/repo/issues/comments/2":
+			w.WriteHeader(http.StatusOK)
+		case r.Method == "POST" && r.URL.Path == "/repos/owner/repo/pulls/1/comments":
+			var body map[string]any
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+			assert.Equal(t, "abc123", body["commit_id"])
+			w.WriteHeader(http.StatusCreated)
+		default:
+			t.Errorf("Unexpected request: %s %s", r.Method, r.URL.Path)
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	opts := CommentOptions{Owner: "owner", Repo: "repo", PRNumber: 1, GitHubURL: server.URL, Token: "token", GraphQL: true}
+	result, err := PostComment(analysis, opts)
+	require.NoError(t, err)
+
+	assert.Equal(t, comment.SummaryUpdated, result.SummaryAction)
+	assert.Equal(t, []comment.InlineResult{
+		{Path: "main.go", Line: 10, Status: comment.InlineUpdated},
+		{Path: "main.go", Line: 20, Status: comment.InlineCreated},
+	}, result.Inline)
+	assert.Empty(t, result.Warnings)
+	// No REST reads: one query, the writes, and one batched update
+	assert.Equal(t, []string{
+		"POST /graphql",
+		"PATCH /repos/owner/repo/issues/comments/2",
+		"POST /repos/owner/repo/pulls/1/comments",
+		"POST /graphql",
+	}, requests)
+}
+
+func TestPostCommentGraphQLFallsBackToREST(t *testing.T) {
+	analysis := &api.SecurityAnalysis{ShouldProceed: false, Justification: "Security issues found"}
+
+	var requests []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, r.Method+" "+r.URL.Path)
+		switch {
+		case r.URL.Path == "/graphql":
+			w.WriteHeader(http.StatusForbidden)
+		case r.Method == "GET" && r.URL.Path == "/repos/owner/repo/issues/1/comments":
+			_, _ = w.Write([]byte(`[]`))
+		case r.Method == "POST" && r.URL.Path == "/repos/owner/repo/issues/1/comments":
+			w.WriteHeader(http.StatusCreated)
+		default:
+			t.Errorf("Unexpected request: %s %s", r.Method, r.URL.Path)
+		}
+	}))
+	defer server.Close()
+
+	opts := CommentOptions{Owner: "owner", Repo: "repo", PRNumber: 1, GitHubURL: server.URL, Token: "token", GraphQL: true}
+	result, err := PostComment(analysis, opts)
+	require.NoError(t, err)
+
+	assert.Equal(t, comment.SummaryCreated, result.SummaryAction)
+	assert.Empty(t, result.Warnings)
+	assert.Equal(t, "POST /graphql", requests[0])
+	assert.Contains(t, requests, "GET /repos/owner/repo/issues/1/comments")
+}
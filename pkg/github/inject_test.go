@@ -0,0 +1,38 @@
+// Copyright (c) Kusari <https://www.kusari.dev/>
+// SPDX-License-Identifier: MIT
+
+package github
+
+import (
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/kusaridev/kusari-cli/v2/api"
+	"github.com/kusaridev/kusari-cli/v2/pkg/comment"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(req *http.Request) (*http.Response, error) { return f(req) }
+
+func TestSetTransport(t *testing.T) {
+	var requests []string
+	SetTransport(roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		requests = append(requests, req.Method+" "+req.URL.String())
+		return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(strings.NewReader(`[]`)), Request: req}, nil
+	}))
+	t.Cleanup(func() { SetTransport(nil) })
+
+	labels := comment.Labels{Passed: "kusari:passed", Blocked: "kusari:blocked"}
+	opts := CommentOptions{Owner: "owner", Repo: "repo", PRNumber: 1, GitHubURL: "https://github.invalid", Token: "token"}
+	require.NoError(t, SetLabels(&api.SecurityAnalysis{ShouldProceed: true}, opts, labels))
+
+	assert.Equal(t, []string{
+		"GET https://github.invalid/repos/owner/repo/issues/1/labels",
+		"POST https://github.invalid/repos/owner/repo/issues/1/labels",
+	}, requests)
+}
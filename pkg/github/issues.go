@@ -0,0 +1,170 @@
+// Copyright (c) Kusari <https://www.kusari.dev/>
+// SPDX-License-Identifier: MIT
+
+package github
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/kusaridev/kusari-cli/v2/pkg/readonly"
+	"github.com/kusaridev/kusari-cli/v2/pkg/results"
+)
+
+// IssueOptions holds the configuration for opening issues on GitHub
+type IssueOptions struct {
+	Owner     string
+	Repo      string
+	GitHubURL string
+	Token     string
+	Labels    []string
+	Assignees []string
+	Verbose   bool
+}
+
+// issue represents a GitHub issue
+type issue struct {
+	Number  int    `json:"number"`
+	Body    string `json:"body"`
+	HTMLURL string `json:"html_url"`
+}
+
+// createIssueRequest is the request body for opening an issue
+type createIssueRequest struct {
+	Title     string   `json:"title"`
+	Body      string   `json:"body"`
+	Labels    []string `json:"labels,omitempty"`
+	Assignees []string `json:"assignees,omitempty"`
+}
+
+// issuesPerPage is the page size used when listing issues
+const issuesPerPage = 100
+
+// CreateIssues opens the given issues, skipping those already opened by
+// an earlier run (open or closed), and returns the URLs of the new ones
+func CreateIssues(issues []results.Issue, opts IssueOptions) ([]string, error) {
+	if err := readonly.Check("open GitHub issues"); err != nil {
+		return nil, err
+	}
+	apiURL := apiURLOrDefault(opts.GitHubURL)
+
+	existing, err := listIssues(apiURL, opts.Owner, opts.Repo, opts.Token, opts.Labels)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list issues on GitHub: %w", err)
+	}
+	opened := map[string]int{}
+	for _, i := range existing {
+		if key, ok := results.ParseIssueMarker(i.Body); ok {
+			opened[key] = i.Number
+		}
+	}
+
+	var created []string
+	for _, i := range issues {
+		if n, ok := opened[i.Key]; ok {
+			if opts.Verbose {
+				fmt.Fprintf(os.Stderr, "Skipping %q: already tracked in #%d\n", i.Title, n)
+			}
+			continue
+		}
+		u, err := createIssue(apiURL, opts.Owner, opts.Repo, opts.Token, i, opts.Labels, opts.Assignees)
+		if err != nil {
+			return created, fmt.Errorf("failed to create issue on GitHub: %w", err)
+		}
+		created = append(created, u)
+	}
+	return created, nil
+}
+
+// listIssues retrieves all issues, open and closed, with the given labels
+func listIssues(apiURL, owner, repo, token string, labels []string) ([]issue, error) {
+	var all []issue
+	for page := 1; ; page++ {
+		query := url.Values{}
+		query.Set("state", "all")
+		query.Set("per_page", fmt.Sprint(issuesPerPage))
+		query.Set("page", fmt.Sprint(page))
+		if len(labels) > 0 {
+			query.Set("labels", strings.Join(labels, ","))
+		}
+		endpoint := fmt.Sprintf("%s/repos/%s/%s/issues?%s", apiURL, owner, repo, query.Encode())
+
+		client := newHTTPClient(30 * time.Second)
+		req, err := http.NewRequest("GET", endpoint, nil)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create request: %w", err)
+		}
+
+		req.Header.Set("Authorization", "Bearer "+token)
+		req.Header.Set("Accept", "application/vnd.github+json")
+		req.Header.Set("X-GitHub-Api-Version", "2022-11-28")
+
+		resp, err := client.Do(req)
+		if err != nil {
+			return nil, fmt.Errorf("request failed: %w", err)
+		}
+
+		if resp.StatusCode != http.StatusOK {
+			err = statusError(resp)
+			_ = resp.Body.Close()
+			return nil, err
+		}
+
+		var issues []issue
+		err = json.NewDecoder(resp.Body).Decode(&issues)
+		_ = resp.Body.Close()
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode response: %w", err)
+		}
+
+		all = append(all, issues...)
+		if len(issues) < issuesPerPage {
+			return all, nil
+		}
+	}
+}
+
+// createIssue opens an issue and returns its URL
+func createIssue(apiURL, owner, repo, token string, i results.Issue, labels, assignees []string) (string, error) {
+	endpoint := fmt.Sprintf("%s/repos/%s/%s/issues", apiURL, owner, repo)
+
+	reqBody := createIssueRequest{Title: i.Title, Body: i.Body, Labels: labels, Assignees: assignees}
+	jsonBody, err := json.Marshal(reqBody)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	client := newHTTPClient(30 * time.Second)
+	req, err := http.NewRequest("POST", endpoint, bytes.NewBuffer(jsonBody))
+	if err != nil {
+		return "", fmt.Errorf("failed to create request: %w", err)
+	}
+
+	req.Header.Set("Authorization", "Bearer "+token)
+	req.Header.Set("Accept", "application/vnd.github+json")
+	req.Header.Set("X-GitHub-Api-Version", "2022-11-28")
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("request failed: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return "", statusError(resp)
+	}
+
+	var created issue
+	if err := json.NewDecoder(resp.Body).Decode(&created); err != nil {
+		return "", fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	return created.HTMLURL, nil
+}
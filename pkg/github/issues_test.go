@@ -0,0 +1,52 @@
+// Copyright (c) Kusari <https://www.kusari.dev/>
+// SPDX-License-Identifier: MIT
+
+package github
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/kusaridev/kusari-cli/v2/pkg/results"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCreateIssues(t *testing.T) {
+	var created []createIssueRequest
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/repos/owner/repo/issues", r.URL.Path)
+		if r.Method == "GET" {
+			assert.Equal(t, "all", r.URL.Query().Get("state"))
+			assert.Equal(t, "kusari", r.URL.Query().Get("labels"))
+			_ = json.NewEncoder(w).Encode([]issue{{Number: 4, Body: "old " + results.IssueMarker("finding:aaaa")}})
+			return
+		}
+		var req createIssueRequest
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+		created = append(created, req)
+		w.WriteHeader(http.StatusCreated)
+		_, _ = w.Write([]byte(`{"number": 5, "html_url": "https://github.com/owner/repo/issues/5"}`))
+	}))
+	defer server.Close()
+
+	issues := []results.Issue{
+		{Key: "finding:aaaa", Title: "Kusari: tracked", Body: results.IssueMarker("finding:aaaa")},
+		{Key: "finding:bbbb", Title: "Kusari: new", Body: results.IssueMarker("finding:bbbb")},
+	}
+	urls, err := CreateIssues(issues, IssueOptions{
+		Owner: "owner", Repo: "repo", GitHubURL: server.URL, Token: "token",
+		Labels: []string{"kusari"}, Assignees: []string{"alice"},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"https://github.com/owner/repo/issues/5"}, urls)
+	require.Len(t, created, 1)
+	assert.Equal(t, createIssueRequest{
+		Title:     "Kusari: new",
+		Body:      results.IssueMarker("finding:bbbb"),
+		Labels:    []string{"kusari"},
+		Assignees: []string{"alice"},
+	}, created[0])
+}
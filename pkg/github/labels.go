@@ -0,0 +1,159 @@
+// Copyright (c) Kusari <https://www.kusari.dev/>
+// SPDX-License-Identifier: MIT
+
+package github
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"slices"
+	"time"
+
+	"github.com/kusaridev/kusari-cli/v2/api"
+	"github.com/kusaridev/kusari-cli/v2/pkg/comment"
+	"github.com/kusaridev/kusari-cli/v2/pkg/readonly"
+)
+
+// SetLabels labels the pull request with the outcome of analysis, removing
+// the labels of the other outcomes
+func SetLabels(analysis *api.SecurityAnalysis, opts CommentOptions, labels comment.Labels) error {
+	if analysis == nil {
+		return nil
+	}
+	if err := readonly.Check("label a GitHub pull request"); err != nil {
+		return err
+	}
+	add, remove := labels.For(analysis)
+	apiURL := apiURLOrDefault(opts.GitHubURL)
+
+	current, err := listIssueLabels(apiURL, opts.Owner, opts.Repo, opts.PRNumber, opts.Token)
+	if err != nil {
+		return fmt.Errorf("failed to list labels on GitHub: %w", err)
+	}
+
+	for _, name := range remove {
+		if !slices.Contains(current, name) {
+			continue
+		}
+		if opts.Verbose {
+			fmt.Fprintf(os.Stderr, "Removing label %q from PR #%d\n", name, opts.PRNumber)
+		}
+		if err := removeIssueLabel(apiURL, opts.Owner, opts.Repo, opts.PRNumber, opts.Token, name); err != nil {
+			return fmt.Errorf("failed to remove label on GitHub: %w", err)
+		}
+	}
+
+	if add != "" && !slices.Contains(current, add) {
+		if opts.Verbose {
+			fmt.Fprintf(os.Stderr, "Adding label %q to PR #%d\n", add, opts.PRNumber)
+		}
+		if err := addIssueLabels(apiURL, opts.Owner, opts.Repo, opts.PRNumber, opts.Token, []string{add}); err != nil {
+			return fmt.Errorf("failed to add label on GitHub: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// listIssueLabels retrieves the names of the labels on a PR
+func listIssueLabels(apiURL, owner, repo string, prNumber int, token string) ([]string, error) {
+	endpoint := fmt.Sprintf("%s/repos/%s/%s/issues/%d/labels", apiURL, owner, repo, prNumber)
+
+	client := newHTTPClient(30 * time.Second)
+	req, err := http.NewRequest("GET", endpoint, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	req.Header.Set("Authorization", "Bearer "+token)
+	req.Header.Set("Accept", "application/vnd.github+json")
+	req.Header.Set("X-GitHub-Api-Version", "2022-11-28")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("request failed: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, statusError(resp)
+	}
+
+	var labels []struct {
+		Name string `json:"name"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&labels); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	names := make([]string, 0, len(labels))
+	for _, l := range labels {
+		names = append(names, l.Name)
+	}
+	return names, nil
+}
+
+// addIssueLabels adds labels to a PR, creating any that don't exist
+func addIssueLabels(apiURL, owner, repo string, prNumber int, token string, labels []string) error {
+	endpoint := fmt.Sprintf("%s/repos/%s/%s/issues/%d/labels", apiURL, owner, repo, prNumber)
+
+	reqBody := map[string][]string{"labels": labels}
+	jsonBody, err := json.Marshal(reqBody)
+	if err != nil {
+		return fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	client := newHTTPClient(30 * time.Second)
+	req, err := http.NewRequest("POST", endpoint, bytes.NewBuffer(jsonBody))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+
+	req.Header.Set("Authorization", "Bearer "+token)
+	req.Header.Set("Accept", "application/vnd.github+json")
+	req.Header.Set("X-GitHub-Api-Version", "2022-11-28")
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("request failed: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return statusError(resp)
+	}
+
+	return nil
+}
+
+// removeIssueLabel removes a label from a PR
+func removeIssueLabel(apiURL, owner, repo string, prNumber int, token, label string) error {
+	endpoint := fmt.Sprintf("%s/repos/%s/%s/issues/%d/labels/%s", apiURL, owner, repo, prNumber, url.PathEscape(label))
+
+	client := newHTTPClient(30 * time.Second)
+	req, err := http.NewRequest("DELETE", endpoint, nil)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+
+	req.Header.Set("Authorization", "Bearer "+token)
+	req.Header.Set("Accept", "application/vnd.github+json")
+	req.Header.Set("X-GitHub-Api-Version", "2022-11-28")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("request failed: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return statusError(resp)
+	}
+
+	return nil
+}
@@ -0,0 +1,48 @@
+// Copyright (c) Kusari <https://www.kusari.dev/>
+// SPDX-License-Identifier: MIT
+
+package github
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/kusaridev/kusari-cli/v2/api"
+	"github.com/kusaridev/kusari-cli/v2/pkg/comment"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSetLabels(t *testing.T) {
+	var requests []string
+	var added []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, r.Method+" "+r.URL.EscapedPath())
+		switch r.Method {
+		case "GET":
+			_, _ = w.Write([]byte(`[{"name": "kusari:passed"}, {"name": "bug"}]`))
+		case "POST":
+			var body map[string][]string
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+			added = body["labels"]
+			_, _ = w.Write([]byte(`[]`))
+		default:
+			w.WriteHeader(http.StatusOK)
+		}
+	}))
+	defer server.Close()
+
+	labels := comment.Labels{Passed: "kusari:passed", Blocked: "kusari:blocked", NeedsReview: "kusari:needs-review"}
+	opts := CommentOptions{Owner: "owner", Repo: "repo", PRNumber: 1, GitHubURL: server.URL, Token: "token"}
+	require.NoError(t, SetLabels(&api.SecurityAnalysis{ShouldProceed: false}, opts, labels))
+
+	// Only labels present on the PR are removed
+	assert.Equal(t, []string{
+		"GET /repos/owner/repo/issues/1/labels",
+		"DELETE /repos/owner/repo/issues/1/labels/kusari:passed",
+		"POST /repos/owner/repo/issues/1/labels",
+	}, requests)
+	assert.Equal(t, []string{"kusari:blocked"}, added)
+}
@@ -0,0 +1,182 @@
+// Copyright (c) Kusari <https://www.kusari.dev/>
+// SPDX-License-Identifier: MIT
+
+package github
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/kusaridev/kusari-cli/v2/api"
+	"github.com/kusaridev/kusari-cli/v2/pkg/comment"
+	"github.com/kusaridev/kusari-cli/v2/pkg/readonly"
+)
+
+// Reaction contents for a passing and a failing analysis
+const (
+	reactionPass = "+1"
+	reactionFail = "-1"
+)
+
+// reaction represents a GitHub reaction on an issue/PR
+type reaction struct {
+	ID      int64  `json:"id"`
+	Content string `json:"content"`
+	User    struct {
+		Login string `json:"login"`
+	} `json:"user"`
+}
+
+// PostReaction signals the analysis verdict with a 👍 or 👎 reaction on
+// the pull request, replacing the opposite reaction left by an earlier run
+func PostReaction(analysis *api.SecurityAnalysis, opts CommentOptions) (*comment.CommentResult, error) {
+	if analysis == nil {
+		return &comment.CommentResult{
+			Posted:      false,
+			IssuesFound: 0,
+			Message:     "No analysis results available - skipping reaction",
+		}, nil
+	}
+	if err := readonly.Check("react to a GitHub pull request"); err != nil {
+		return nil, err
+	}
+
+	hasIssues, issueCount := comment.CheckForIssues(analysis)
+	content, stale := reactionPass, reactionFail
+	if hasIssues {
+		content, stale = reactionFail, reactionPass
+	}
+
+	apiURL := apiURLOrDefault(opts.GitHubURL)
+
+	// GitHub returns the existing reaction if this user already left it, so
+	// its user tells us whose stale reaction to remove
+	posted, err := createIssueReaction(apiURL, opts.Owner, opts.Repo, opts.PRNumber, opts.Token, content)
+	if err != nil {
+		return nil, fmt.Errorf("failed to add reaction on GitHub: %w", err)
+	}
+
+	reactions, err := listIssueReactions(apiURL, opts.Owner, opts.Repo, opts.PRNumber, opts.Token)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list reactions on GitHub: %w", err)
+	}
+	for _, r := range reactions {
+		if r.Content != stale || r.User.Login != posted.User.Login {
+			continue
+		}
+		if opts.Verbose {
+			fmt.Fprintf(os.Stderr, "Removing previous %s reaction (ID: %d)\n", r.Content, r.ID)
+		}
+		if err := deleteIssueReaction(apiURL, opts.Owner, opts.Repo, opts.PRNumber, r.ID, opts.Token); err != nil {
+			return nil, fmt.Errorf("failed to remove previous reaction on GitHub: %w", err)
+		}
+	}
+
+	return &comment.CommentResult{
+		Posted:      true,
+		IssuesFound: issueCount,
+		Message:     fmt.Sprintf("Reacted %s to PR #%d", content, opts.PRNumber),
+	}, nil
+}
+
+// createIssueReaction adds a reaction to a PR
+func createIssueReaction(apiURL, owner, repo string, prNumber int, token, content string) (*reaction, error) {
+	endpoint := fmt.Sprintf("%s/repos/%s/%s/issues/%d/reactions", apiURL, owner, repo, prNumber)
+
+	reqBody := map[string]string{"content": content}
+	jsonBody, err := json.Marshal(reqBody)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	client := newHTTPClient(30 * time.Second)
+	req, err := http.NewRequest("POST", endpoint, bytes.NewBuffer(jsonBody))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	req.Header.Set("Authorization", "Bearer "+token)
+	req.Header.Set("Accept", "application/vnd.github+json")
+	req.Header.Set("X-GitHub-Api-Version", "2022-11-28")
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("request failed: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return nil, statusError(resp)
+	}
+
+	var r reaction
+	if err := json.NewDecoder(resp.Body).Decode(&r); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	return &r, nil
+}
+
+// listIssueReactions retrieves the reactions on a PR
+func listIssueReactions(apiURL, owner, repo string, prNumber int, token string) ([]reaction, error) {
+	endpoint := fmt.Sprintf("%s/repos/%s/%s/issues/%d/reactions", apiURL, owner, repo, prNumber)
+
+	client := newHTTPClient(30 * time.Second)
+	req, err := http.NewRequest("GET", endpoint, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	req.Header.Set("Authorization", "Bearer "+token)
+	req.Header.Set("Accept", "application/vnd.github+json")
+	req.Header.Set("X-GitHub-Api-Version", "2022-11-28")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("request failed: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, statusError(resp)
+	}
+
+	var reactions []reaction
+	if err := json.NewDecoder(resp.Body).Decode(&reactions); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	return reactions, nil
+}
+
+// deleteIssueReaction removes a reaction from a PR
+func deleteIssueReaction(apiURL, owner, repo string, prNumber int, reactionID int64, token string) error {
+	endpoint := fmt.Sprintf("%s/repos/%s/%s/issues/%d/reactions/%d", apiURL, owner, repo, prNumber, reactionID)
+
+	client := newHTTPClient(30 * time.Second)
+	req, err := http.NewRequest("DELETE", endpoint, nil)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+
+	req.Header.Set("Authorization", "Bearer "+token)
+	req.Header.Set("Accept", "application/vnd.github+json")
+	req.Header.Set("X-GitHub-Api-Version", "2022-11-28")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("request failed: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return statusError(resp)
+	}
+
+	return nil
+}
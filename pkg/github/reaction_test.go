@@ -0,0 +1,57 @@
+// Copyright (c) Kusari <https://www.kusari.dev/>
+// SPDX-License-Identifier: MIT
+
+package github
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/kusaridev/kusari-cli/v2/api"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPostReaction(t *testing.T) {
+	var requests []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, r.Method+" "+r.URL.Path)
+		switch r.Method {
+		case "POST":
+			var body map[string]string
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+			assert.Equal(t, "-1", body["content"])
+			_, _ = w.Write([]byte(`{"id": 10, "content": "-1", "user": {"login": "github-actions[bot]"}}`))
+		case "GET":
+			_, _ = w.Write([]byte(`[
+				{"id": 7, "content": "+1", "user": {"login": "github-actions[bot]"}},
+				{"id": 8, "content": "+1", "user": {"login": "octocat"}},
+				{"id": 10, "content": "-1", "user": {"login": "github-actions[bot]"}}
+			]`))
+		default:
+			w.WriteHeader(http.StatusNoContent)
+		}
+	}))
+	defer server.Close()
+
+	analysis := &api.SecurityAnalysis{ShouldProceed: false}
+	result, err := PostReaction(analysis, CommentOptions{Owner: "owner", Repo: "repo", PRNumber: 1, GitHubURL: server.URL, Token: "token"})
+	require.NoError(t, err)
+	assert.True(t, result.Posted)
+	assert.Equal(t, "Reacted -1 to PR #1", result.Message)
+
+	// Only our own stale thumbs up is removed
+	assert.Equal(t, []string{
+		"POST /repos/owner/repo/issues/1/reactions",
+		"GET /repos/owner/repo/issues/1/reactions",
+		"DELETE /repos/owner/repo/issues/1/reactions/7",
+	}, requests)
+}
+
+func TestPostReactionNilAnalysis(t *testing.T) {
+	result, err := PostReaction(nil, CommentOptions{})
+	require.NoError(t, err)
+	assert.False(t, result.Posted)
+}
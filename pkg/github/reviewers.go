@@ -0,0 +1,93 @@
+// Copyright (c) Kusari <https://www.kusari.dev/>
+// SPDX-License-Identifier: MIT
+
+package github
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/kusaridev/kusari-cli/v2/pkg/readonly"
+)
+
+// requestReviewersRequest is the request body for requesting PR reviewers
+type requestReviewersRequest struct {
+	Reviewers     []string `json:"reviewers,omitempty"`
+	TeamReviewers []string `json:"team_reviewers,omitempty"`
+}
+
+// RequestReviewers requests review of the pull request from reviewers:
+// user logins, or teams as org/team-slug. The PR author is skipped, since
+// GitHub rejects requesting their review.
+func RequestReviewers(opts CommentOptions, reviewers []string) error {
+	if err := readonly.Check("request GitHub pull request reviewers"); err != nil {
+		return err
+	}
+	apiURL := apiURLOrDefault(opts.GitHubURL)
+
+	pr, err := getPRInfo(apiURL, opts.Owner, opts.Repo, opts.PRNumber, opts.Token)
+	if err != nil {
+		return fmt.Errorf("failed to get PR info: %w", err)
+	}
+
+	var reqBody requestReviewersRequest
+	for _, r := range reviewers {
+		r = strings.TrimPrefix(r, "@")
+		if _, team, ok := strings.Cut(r, "/"); ok {
+			reqBody.TeamReviewers = append(reqBody.TeamReviewers, team)
+		} else if r != "" && !strings.EqualFold(r, pr.User.Login) {
+			reqBody.Reviewers = append(reqBody.Reviewers, r)
+		}
+	}
+	if len(reqBody.Reviewers) == 0 && len(reqBody.TeamReviewers) == 0 {
+		return nil
+	}
+
+	if opts.Verbose {
+		fmt.Fprintf(os.Stderr, "Requesting review on PR #%d from users %v and teams %v\n",
+			opts.PRNumber, reqBody.Reviewers, reqBody.TeamReviewers)
+	}
+	if err := createReviewRequest(apiURL, opts.Owner, opts.Repo, opts.PRNumber, opts.Token, reqBody); err != nil {
+		return fmt.Errorf("failed to request reviewers on GitHub: %w", err)
+	}
+	return nil
+}
+
+// createReviewRequest requests reviewers on a PR. Reviewers already
+// requested are left as they are.
+func createReviewRequest(apiURL, owner, repo string, prNumber int, token string, reqBody requestReviewersRequest) error {
+	endpoint := fmt.Sprintf("%s/repos/%s/%s/pulls/%d/requested_reviewers", apiURL, owner, repo, prNumber)
+
+	jsonBody, err := json.Marshal(reqBody)
+	if err != nil {
+		return fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	client := newHTTPClient(30 * time.Second)
+	req, err := http.NewRequest("POST", endpoint, bytes.NewBuffer(jsonBody))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+
+	req.Header.Set("Authorization", "Bearer "+token)
+	req.Header.Set("Accept", "application/vnd.github+json")
+	req.Header.Set("X-GitHub-Api-Version", "2022-11-28")
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("request failed: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return statusError(resp)
+	}
+
+	return nil
+}
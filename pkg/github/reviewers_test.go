@@ -0,0 +1,48 @@
+// Copyright (c) Kusari <https://www.kusari.dev/>
+// SPDX-License-Identifier: MIT
+
+package github
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRequestReviewers(t *testing.T) {
+	var got requestReviewersRequest
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/repos/owner/repo/pulls/1":
+			_, _ = w.Write([]byte(`{"head": {"sha": "abc"}, "user": {"login": "author"}}`))
+		case "/repos/owner/repo/pulls/1/requested_reviewers":
+			assert.Equal(t, "POST", r.Method)
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&got))
+			w.WriteHeader(http.StatusCreated)
+		default:
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+		}
+	}))
+	defer server.Close()
+
+	opts := CommentOptions{Owner: "owner", Repo: "repo", PRNumber: 1, GitHubURL: server.URL, Token: "token"}
+	require.NoError(t, RequestReviewers(opts, []string{"alice", "@acme/security", "Author"}))
+	assert.Equal(t, requestReviewersRequest{Reviewers: []string{"alice"}, TeamReviewers: []string{"security"}}, got)
+}
+
+func TestRequestReviewersOnlyAuthor(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != "GET" {
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+		}
+		_, _ = w.Write([]byte(`{"user": {"login": "author"}}`))
+	}))
+	defer server.Close()
+
+	opts := CommentOptions{Owner: "owner", Repo: "repo", PRNumber: 1, GitHubURL: server.URL, Token: "token"}
+	require.NoError(t, RequestReviewers(opts, []string{"author"}))
+}
@@ -0,0 +1,34 @@
+// Copyright (c) Kusari <https://www.kusari.dev/>
+// SPDX-License-Identifier: MIT
+
+package github
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// statusError describes a failed GitHub API response. A 403 names the
+// permission GitHub says the endpoint accepts, tells a rate limit apart,
+// and suggests checking the token's permissions otherwise.
+func statusError(resp *http.Response) error {
+	respBody, _ := io.ReadAll(resp.Body)
+	if resp.StatusCode != http.StatusForbidden {
+		return fmt.Errorf("GitHub API returned status %d: %s", resp.StatusCode, string(respBody))
+	}
+	if resp.Header.Get("X-RateLimit-Remaining") == "0" {
+		return fmt.Errorf("GitHub API returned status 403: the rate limit is exhausted; retry after it resets: %s", string(respBody))
+	}
+	// Fine-grained tokens and GITHUB_TOKEN are told the permissions, e.g.
+	// "pull_requests=write"; classic tokens the OAuth scopes.
+	if perms := strings.TrimSpace(resp.Header.Get("X-Accepted-GitHub-Permissions")); perms != "" {
+		return fmt.Errorf("GitHub API returned status 403: the token lacks the %s permission; grant it in the workflow's permissions: block or the token's settings",
+			strings.ReplaceAll(perms, ",", " or "))
+	}
+	if scopes := strings.TrimSpace(resp.Header.Get("X-Accepted-OAuth-Scopes")); scopes != "" {
+		return fmt.Errorf("GitHub API returned status 403: the token is missing the %s scope", scopes)
+	}
+	return fmt.Errorf("GitHub API returned status 403: %s (check that the token's permissions allow this)", string(respBody))
+}
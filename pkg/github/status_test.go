@@ -0,0 +1,32 @@
+// Copyright (c) Kusari <https://www.kusari.dev/>
+// SPDX-License-Identifier: MIT
+
+package github
+
+import (
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStatusError(t *testing.T) {
+	resp := func(status int, header http.Header, body string) *http.Response {
+		return &http.Response{StatusCode: status, Header: header, Body: io.NopCloser(strings.NewReader(body))}
+	}
+	forbidden := `{"message":"Resource not accessible by integration"}`
+
+	assert.EqualError(t, statusError(resp(http.StatusForbidden, http.Header{"X-Accepted-Github-Permissions": {"pull_requests=write"}}, forbidden)),
+		"GitHub API returned status 403: the token lacks the pull_requests=write permission; grant it in the workflow's permissions: block or the token's settings")
+	assert.EqualError(t, statusError(resp(http.StatusForbidden, http.Header{"X-Accepted-Github-Permissions": {"issues=write,pull_requests=write"}}, forbidden)),
+		"GitHub API returned status 403: the token lacks the issues=write or pull_requests=write permission; grant it in the workflow's permissions: block or the token's settings")
+	assert.EqualError(t, statusError(resp(http.StatusForbidden, http.Header{"X-Accepted-Oauth-Scopes": {"repo"}}, forbidden)),
+		"GitHub API returned status 403: the token is missing the repo scope")
+	assert.EqualError(t, statusError(resp(http.StatusForbidden, http.Header{"X-Ratelimit-Remaining": {"0"}}, `{"message":"API rate limit exceeded"}`)),
+		`GitHub API returned status 403: the rate limit is exhausted; retry after it resets: {"message":"API rate limit exceeded"}`)
+	assert.EqualError(t, statusError(resp(http.StatusForbidden, http.Header{}, forbidden)),
+		`GitHub API returned status 403: {"message":"Resource not accessible by integration"} (check that the token's permissions allow this)`)
+	assert.EqualError(t, statusError(resp(http.StatusNotFound, http.Header{}, "not found")), "GitHub API returned status 404: not found")
+}
@@ -7,7 +7,6 @@ import (
 	"bytes"
 	"encoding/json"
 	"fmt"
-	"io"
 	"net/http"
 	"os"
 	"regexp"
@@ -16,6 +15,8 @@ import (
 
 	"github.com/kusaridev/kusari-cli/v2/api"
 	"github.com/kusaridev/kusari-cli/v2/pkg/comment"
+	"github.com/kusaridev/kusari-cli/v2/pkg/output"
+	"github.com/kusaridev/kusari-cli/v2/pkg/readonly"
 )
 
 const (
@@ -29,7 +30,16 @@ type CommentOptions struct {
 	GitLabURL   string
 	Token       string
 	ConsoleURL  string // Link to full results in Kusari console
-	Verbose     bool
+	// ResolvePrevious resolves the previous summary thread and starts a
+	// new one instead of updating it in place
+	ResolvePrevious bool
+	// Compact posts a one-line status comment with a badge instead of the
+	// summary, and no inline comments
+	Compact bool
+	// Run marks the summary so that a duplicate posted by a concurrent run
+	// with the same key is removed. The zero value doesn't.
+	Run     comment.Run
+	Verbose bool
 }
 
 // mrDiffRefs holds the SHA references needed for inline comments
@@ -41,37 +51,44 @@ type mrDiffRefs struct {
 
 // mrInfo holds merge request information from GitLab API
 type mrInfo struct {
-	DiffRefs mrDiffRefs `json:"diff_refs"`
+	DiffRefs  mrDiffRefs `json:"diff_refs"`
+	Author    mrUser     `json:"author"`
+	Reviewers []mrUser   `json:"reviewers"`
+}
+
+// mrUser is a user as referenced by merge request responses
+type mrUser struct {
+	ID       int    `json:"id"`
+	Username string `json:"username"`
 }
 
 // PostComment posts scan results as a comment to a GitLab merge request
 // Returns without posting if no issues are found (ShouldProceed is true and no mitigations)
-// If an existing Kusari comment exists, it will be updated instead of creating a new one
+// If an existing Kusari comment exists, it will be updated instead of creating a new one,
+// unless opts.ResolvePrevious is set
 func PostComment(analysis *api.SecurityAnalysis, opts CommentOptions) (*comment.CommentResult, error) {
 	if analysis == nil {
 		return &comment.CommentResult{
-			Posted:      false,
-			IssuesFound: 0,
-			Message:     "No analysis results available - skipping comment",
+			Posted:        false,
+			IssuesFound:   0,
+			Message:       "No analysis results available - skipping comment",
+			SummaryAction: comment.SummarySkipped,
 		}, nil
 	}
+	if err := readonly.Check("post a GitLab merge request comment"); err != nil {
+		return nil, err
+	}
 
 	// Check if there are any issues to report
 	hasIssues, issueCount := comment.CheckForIssues(analysis)
 
-	// Determine API URL
-	apiURL := opts.GitLabURL
-	if apiURL == "" {
-		apiURL = defaultGitLabAPIURL
-	}
-	apiURL = strings.TrimSuffix(apiURL, "/")
+	apiURL := apiURLOrDefault(opts.GitLabURL)
+	var warnings []error
 
 	// Check for existing Kusari summary comment and update if found
 	existingNoteID, err := findExistingKusariNote(apiURL, opts.ProjectID, opts.MergeReqIID, opts.Token)
 	if err != nil {
-		if opts.Verbose {
-			fmt.Fprintf(os.Stderr, "Warning: Could not check for existing comments: %v\n", err)
-		}
+		warnings = append(warnings, fmt.Errorf("could not check for existing comments: %w", err))
 	} else if opts.Verbose {
 		if existingNoteID > 0 {
 			fmt.Fprintf(os.Stderr, "Found existing Kusari summary comment (note ID: %d)\n", existingNoteID)
@@ -83,16 +100,45 @@ func PostComment(analysis *api.SecurityAnalysis, opts CommentOptions) (*comment.
 	// If no issues and no existing comment, nothing to do
 	if !hasIssues && existingNoteID == 0 {
 		return &comment.CommentResult{
-			Posted:      false,
-			IssuesFound: 0,
-			Message:     "No issues found - skipping comment",
+			Posted:        false,
+			IssuesFound:   0,
+			Message:       "No issues found - skipping comment",
+			SummaryAction: comment.SummarySkipped,
+			Warnings:      warnings,
 		}, nil
 	}
 
-	// Format comment body from analysis results
-	commentBody := comment.FormatComment(analysis, opts.ConsoleURL)
+	// Format comment body from analysis results, split to fit GitLab's
+	// note size limit
+	var bodies []string
+	if opts.Compact {
+		bodies = []string{comment.FormatStatusComment(analysis, opts.ConsoleURL)}
+	} else {
+		bodies = comment.FormatComments(analysis, opts.ConsoleURL, comment.GitLabMaxLength-opts.Run.MarkerLength())
+	}
+	commentBody := bodies[0] + opts.Run.Marker(bodies[0])
+	bodies[0] = commentBody
+
+	if opts.ResolvePrevious {
+		resolved, err := resolvePreviousDiscussions(apiURL, opts.ProjectID, opts.MergeReqIID, opts.Token)
+		if err != nil {
+			// Don't fail - the new comment is still posted
+			warnings = append(warnings, fmt.Errorf("could not resolve previous comments: %w", err))
+		} else if opts.Verbose && resolved > 0 {
+			fmt.Fprintf(os.Stderr, "Resolved %d previous Kusari thread(s)\n", resolved)
+		}
 
-	if existingNoteID > 0 {
+		// Post as threads so the next run can resolve them
+		for i, body := range bodies {
+			if err := postDiscussion(apiURL, opts.ProjectID, opts.MergeReqIID, opts.Token, body); err != nil {
+				if i == 0 {
+					return nil, fmt.Errorf("failed to post comment to GitLab: %w", err)
+				}
+				return nil, fmt.Errorf("failed to post continuation comments to GitLab: %w", err)
+			}
+		}
+		existingNoteID, warnings = dedupeSummary(apiURL, opts, commentBody, warnings)
+	} else if existingNoteID > 0 {
 		// Update existing comment
 		if opts.Verbose {
 			fmt.Fprintf(os.Stderr, "Updating existing summary comment (note ID: %d)\n", existingNoteID)
@@ -112,55 +158,76 @@ func PostComment(analysis *api.SecurityAnalysis, opts CommentOptions) (*comment.
 		if err := postNote(notesEndpoint, opts.Token, commentBody); err != nil {
 			return nil, fmt.Errorf("failed to post comment to GitLab: %w", err)
 		}
+		existingNoteID, warnings = dedupeSummary(apiURL, opts, commentBody, warnings)
 	}
 
-	// Post or update inline comments for code mitigations
-	inlineCount := 0
-	if len(analysis.RequiredCodeMitigations) > 0 && !analysis.ShouldProceed {
-		posted, err := postCodeMitigationComments(analysis, opts, apiURL)
-		if err != nil {
-			// Log but don't fail - inline comments are best-effort
-			if opts.Verbose {
-				fmt.Fprintf(os.Stderr, "Warning: Failed to post some inline comments: %v\n", err)
-			}
+	if !opts.ResolvePrevious {
+		if err := syncContinuationNotes(apiURL, opts.ProjectID, opts.MergeReqIID, opts.Token, bodies[1:]); err != nil {
+			return nil, fmt.Errorf("failed to post continuation comments to GitLab: %w", err)
 		}
-		inlineCount = posted
 	}
+	if opts.Verbose && len(bodies) > 1 {
+		fmt.Fprintf(os.Stderr, "Summary split across %d comments to fit GitLab's size limit\n", len(bodies))
+	}
+
+	// Post or update inline comments for code mitigations. They are
+	// best-effort: failures are reported, not returned.
+	var inline []comment.InlineResult
+	if len(analysis.RequiredCodeMitigations) > 0 && !analysis.ShouldProceed && !opts.Compact {
+		var inlineWarnings []error
+		inline, inlineWarnings = postCodeMitigationComments(analysis, opts, apiURL)
+		warnings = append(warnings, inlineWarnings...)
+	}
+
+	result := &comment.CommentResult{
+		Posted:        true,
+		IssuesFound:   issueCount,
+		SummaryAction: comment.SummaryCreated,
+		Inline:        inline,
+		Warnings:      warnings,
+	}
+	result.InlineCommentsPosted = len(inline) - result.InlineFailed()
 
 	action := "Posted"
 	if existingNoteID > 0 {
 		action = "Updated"
+		result.SummaryAction = comment.SummaryUpdated
 	}
-	message := fmt.Sprintf("%s comment with %d issue(s) to MR !%s", action, issueCount, opts.MergeReqIID)
-	if inlineCount > 0 {
-		message = fmt.Sprintf("%s comment with %d issue(s) and %d inline comment(s) to MR !%s", action, issueCount, inlineCount, opts.MergeReqIID)
+	result.Message = fmt.Sprintf("%s comment with %d issue(s) to MR !%s", action, issueCount, opts.MergeReqIID)
+	if result.InlineCommentsPosted > 0 {
+		result.Message = fmt.Sprintf("%s comment with %d issue(s) and %d inline comment(s) to MR !%s", action, issueCount, result.InlineCommentsPosted, opts.MergeReqIID)
 	}
+	return result, nil
+}
 
-	return &comment.CommentResult{
-		Posted:               true,
-		IssuesFound:          issueCount,
-		InlineCommentsPosted: inlineCount,
-		Message:              message,
-	}, nil
+// apiURLOrDefault returns apiURL, or gitlab.com's API if it is empty
+func apiURLOrDefault(apiURL string) string {
+	if apiURL == "" {
+		apiURL = defaultGitLabAPIURL
+	}
+	return strings.TrimSuffix(apiURL, "/")
 }
 
 // mrNote represents a note (comment) on a merge request
 type mrNote struct {
-	ID   int    `json:"id"`
-	Body string `json:"body"`
+	ID     int    `json:"id"`
+	Body   string `json:"body"`
+	Author struct {
+		Username string `json:"username"`
+	} `json:"author"`
 }
 
 // listMRNotes retrieves all notes on a merge request
 func listMRNotes(apiURL, projectID, mrIID, token string) ([]mrNote, error) {
 	endpoint := fmt.Sprintf("%s/projects/%s/merge_requests/%s/notes", apiURL, projectID, mrIID)
 
-	client := &http.Client{Timeout: 30 * time.Second}
+	client := newHTTPClient(30 * time.Second)
 	req, err := http.NewRequest("GET", endpoint, nil)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
 
-	req.Header.Set("PRIVATE-TOKEN", token)
+	setAuth(req, token)
 
 	resp, err := client.Do(req)
 	if err != nil {
@@ -169,8 +236,7 @@ func listMRNotes(apiURL, projectID, mrIID, token string) ([]mrNote, error) {
 	defer func() { _ = resp.Body.Close() }()
 
 	if resp.StatusCode != http.StatusOK {
-		respBody, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("GitLab API returned status %d: %s", resp.StatusCode, string(respBody))
+		return nil, statusError(resp)
 	}
 
 	var notes []mrNote
@@ -198,7 +264,8 @@ func findExistingKusariNote(apiURL, projectID, mrIID, token string) (int, error)
 	// Check for primary marker first, then fall back to legacy text-based markers for backward compatibility
 	for i, note := range notes {
 		if verbose {
-			preview := note.Body
+			// Redact before truncating, so a token cut short is still found
+			preview := output.Redact(note.Body)
 			if len(preview) > 100 {
 				preview = preview[:100] + "..."
 			}
@@ -230,6 +297,44 @@ func findExistingKusariNote(apiURL, projectID, mrIID, token string) (int, error)
 	return 0, nil
 }
 
+// dedupeSummary removes the summary this run just posted as body when a
+// concurrent run of the same key posted one first, updating that one with
+// body instead. Returns the ID of the note kept, or 0 if it is this run's,
+// with any failure added to warnings.
+func dedupeSummary(apiURL string, opts CommentOptions, body string, warnings []error) (int, []error) {
+	if opts.Run.Key == "" {
+		return 0, warnings
+	}
+	warn := func(err error) (int, []error) {
+		return 0, append(warnings, fmt.Errorf("could not check for duplicate summary comments: %w", err))
+	}
+	notes, err := listMRNotes(apiURL, opts.ProjectID, opts.MergeReqIID, opts.Token)
+	if err != nil {
+		return warn(err)
+	}
+	posted := make([]comment.PostedComment, len(notes))
+	for i, n := range notes {
+		posted[i] = comment.PostedComment{ID: int64(n.ID), Body: n.Body, Author: n.Author.Username}
+	}
+
+	// Update the kept summary first, so a failure leaves this run's.
+	keep, remove, update := opts.Run.Dedupe(body, posted)
+	if update {
+		if err := updateNote(apiURL, opts.ProjectID, opts.MergeReqIID, int(keep), opts.Token, body); err != nil {
+			return warn(err)
+		}
+	}
+	for _, id := range remove {
+		if err := deleteNote(apiURL, opts.ProjectID, opts.MergeReqIID, int(id), opts.Token); err != nil {
+			return warn(err)
+		}
+	}
+	if keep > 0 && opts.Verbose {
+		fmt.Fprintf(os.Stderr, "A concurrent run posted a summary first; kept that one (note ID: %d)\n", keep)
+	}
+	return int(keep), warnings
+}
+
 // updateNote updates an existing note on a merge request
 func updateNote(apiURL, projectID, mrIID string, noteID int, token, body string) error {
 	endpoint := fmt.Sprintf("%s/projects/%s/merge_requests/%s/notes/%d", apiURL, projectID, mrIID, noteID)
@@ -240,14 +345,77 @@ func updateNote(apiURL, projectID, mrIID string, noteID int, token, body string)
 		return fmt.Errorf("failed to marshal request: %w", err)
 	}
 
-	client := &http.Client{Timeout: 30 * time.Second}
+	client := newHTTPClient(30 * time.Second)
 	req, err := http.NewRequest("PUT", endpoint, bytes.NewBuffer(jsonBody))
 	if err != nil {
 		return fmt.Errorf("failed to create request: %w", err)
 	}
 
 	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("PRIVATE-TOKEN", token)
+	setAuth(req, token)
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("request failed: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return statusError(resp)
+	}
+
+	return nil
+}
+
+// syncContinuationNotes posts bodies as continuation parts 2..n of the
+// summary comment, updating the parts left by an earlier run and deleting
+// the ones no longer needed.
+func syncContinuationNotes(apiURL, projectID, mrIID, token string, bodies []string) error {
+	notes, err := listMRNotes(apiURL, projectID, mrIID, token)
+	if err != nil {
+		return err
+	}
+	existing := map[int]int{}
+	for _, note := range notes {
+		if part, ok := comment.ParsePartMarker(note.Body); ok {
+			existing[part] = note.ID
+		}
+	}
+
+	notesEndpoint := fmt.Sprintf("%s/projects/%s/merge_requests/%s/notes", apiURL, projectID, mrIID)
+	for i, body := range bodies {
+		part := i + 2
+		if id, ok := existing[part]; ok {
+			delete(existing, part)
+			if err := updateNote(apiURL, projectID, mrIID, id, token, body); err != nil {
+				return err
+			}
+			continue
+		}
+		if err := postNote(notesEndpoint, token, body); err != nil {
+			return err
+		}
+	}
+
+	for _, id := range existing {
+		if err := deleteNote(apiURL, projectID, mrIID, id, token); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// deleteNote deletes a note on a merge request
+func deleteNote(apiURL, projectID, mrIID string, noteID int, token string) error {
+	endpoint := fmt.Sprintf("%s/projects/%s/merge_requests/%s/notes/%d", apiURL, projectID, mrIID, noteID)
+
+	client := newHTTPClient(30 * time.Second)
+	req, err := http.NewRequest("DELETE", endpoint, nil)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+
+	setAuth(req, token)
 
 	resp, err := client.Do(req)
 	if err != nil {
@@ -256,33 +424,144 @@ func updateNote(apiURL, projectID, mrIID string, noteID int, token, body string)
 	defer func() { _ = resp.Body.Close() }()
 
 	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
-		respBody, _ := io.ReadAll(resp.Body)
-		return fmt.Errorf("GitLab API returned status %d: %s", resp.StatusCode, string(respBody))
+		return statusError(resp)
 	}
 
 	return nil
 }
 
-// postCodeMitigationComments posts or updates inline comments for each code mitigation
-func postCodeMitigationComments(analysis *api.SecurityAnalysis, opts CommentOptions, apiURL string) (int, error) {
+// mrDiscussion represents a discussion (thread) on a merge request
+type mrDiscussion struct {
+	ID    string `json:"id"`
+	Notes []struct {
+		ID         int    `json:"id"`
+		Body       string `json:"body"`
+		Resolvable bool   `json:"resolvable"`
+		Resolved   bool   `json:"resolved"`
+	} `json:"notes"`
+}
+
+// listMRDiscussions retrieves all discussions on a merge request, oldest first
+func listMRDiscussions(apiURL, projectID, mrIID, token string) ([]mrDiscussion, error) {
+	endpoint := fmt.Sprintf("%s/projects/%s/merge_requests/%s/discussions", apiURL, projectID, mrIID)
+
+	client := newHTTPClient(30 * time.Second)
+	req, err := http.NewRequest("GET", endpoint, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	setAuth(req, token)
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("request failed: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, statusError(resp)
+	}
+
+	var discussions []mrDiscussion
+	if err := json.NewDecoder(resp.Body).Decode(&discussions); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	return discussions, nil
+}
+
+// resolvePreviousDiscussions resolves the thread of the latest Kusari
+// summary comment on the MR and those of its continuation parts. Earlier
+// summaries were resolved by the runs that replaced them, and summaries
+// posted as plain notes can't be resolved. Returns how many threads were
+// resolved.
+func resolvePreviousDiscussions(apiURL, projectID, mrIID, token string) (int, error) {
+	discussions, err := listMRDiscussions(apiURL, projectID, mrIID, token)
+	if err != nil {
+		return 0, err
+	}
+
+	last := -1
+	for i, d := range discussions {
+		if len(d.Notes) > 0 && comment.IsSummary(d.Notes[0].Body) {
+			last = i
+		}
+	}
+	if last < 0 {
+		return 0, nil
+	}
+
+	resolved := 0
+	for i, d := range discussions[last:] {
+		if len(d.Notes) == 0 {
+			continue
+		}
+		first := d.Notes[0]
+		if _, ok := comment.ParsePartMarker(first.Body); i > 0 && !ok {
+			continue
+		}
+		if !first.Resolvable || first.Resolved {
+			continue
+		}
+		if err := resolveDiscussion(apiURL, projectID, mrIID, d.ID, token); err != nil {
+			return resolved, err
+		}
+		resolved++
+	}
+	return resolved, nil
+}
+
+// resolveDiscussion marks a discussion on a merge request as resolved
+func resolveDiscussion(apiURL, projectID, mrIID, discussionID, token string) error {
+	endpoint := fmt.Sprintf("%s/projects/%s/merge_requests/%s/discussions/%s?resolved=true", apiURL, projectID, mrIID, discussionID)
+
+	client := newHTTPClient(30 * time.Second)
+	req, err := http.NewRequest("PUT", endpoint, nil)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+
+	setAuth(req, token)
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("request failed: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return statusError(resp)
+	}
+
+	return nil
+}
+
+// postDiscussion starts a resolvable thread on a merge request
+func postDiscussion(apiURL, projectID, mrIID, token, body string) error {
+	endpoint := fmt.Sprintf("%s/projects/%s/merge_requests/%s/discussions", apiURL, projectID, mrIID)
+	return postNote(endpoint, token, body)
+}
+
+// postCodeMitigationComments posts or updates inline comments for each code
+// mitigation, returning the outcome of each and the API errors met.
+func postCodeMitigationComments(analysis *api.SecurityAnalysis, opts CommentOptions, apiURL string) ([]comment.InlineResult, []error) {
 	// Get MR diff refs for positioning inline comments
 	diffRefs, err := getMRDiffRefs(apiURL, opts.ProjectID, opts.MergeReqIID, opts.Token)
 	if err != nil {
-		return 0, fmt.Errorf("failed to get MR diff refs: %w", err)
+		return nil, []error{fmt.Errorf("failed to get MR diff refs for inline comments: %w", err)}
 	}
 
 	// Get existing notes to check for updates
 	// Inline diff comments are returned by the Notes API, not the Discussions API
+	var warnings []error
 	existingNotes, err := listMRNotes(apiURL, opts.ProjectID, opts.MergeReqIID, opts.Token)
 	if err != nil {
-		if opts.Verbose {
-			fmt.Fprintf(os.Stderr, "Warning: Could not list existing notes: %v\n", err)
-		}
+		warnings = append(warnings, fmt.Errorf("could not list existing notes: %w", err))
 		existingNotes = nil
 	}
 
-	posted := 0
-	var lastErr error
+	var results []comment.InlineResult
 
 	for _, issue := range analysis.RequiredCodeMitigations {
 		// Skip issues without line numbers
@@ -310,12 +589,9 @@ func postCodeMitigationComments(analysis *api.SecurityAnalysis, opts CommentOpti
 				fmt.Fprintf(os.Stderr, "Updating inline comment at %s:%d\n", issue.Path, issue.LineNumber)
 			}
 			err := updateNote(apiURL, opts.ProjectID, opts.MergeReqIID, existingNoteID, opts.Token, message)
+			results = append(results, comment.NewInlineResult(issue, comment.InlineUpdated, err))
 			if err != nil {
-				lastErr = err
-				if opts.Verbose {
-					fmt.Fprintf(os.Stderr, "Warning: Failed to update inline comment at %s:%d: %v\n", issue.Path, issue.LineNumber, err)
-				}
-				continue
+				warnings = append(warnings, fmt.Errorf("failed to update inline comment at %s:%d: %w", issue.Path, issue.LineNumber, err))
 			}
 		} else {
 			// Post new comment
@@ -323,31 +599,27 @@ func postCodeMitigationComments(analysis *api.SecurityAnalysis, opts CommentOpti
 				fmt.Fprintf(os.Stderr, "Posting inline comment at %s:%d\n", issue.Path, issue.LineNumber)
 			}
 			err := postInlineComment(apiURL, opts.ProjectID, opts.MergeReqIID, opts.Token, diffRefs, issue.Path, issue.LineNumber, message)
+			results = append(results, comment.NewInlineResult(issue, comment.InlineCreated, err))
 			if err != nil {
-				lastErr = err
-				if opts.Verbose {
-					fmt.Fprintf(os.Stderr, "Warning: Failed to post inline comment at %s:%d: %v\n", issue.Path, issue.LineNumber, err)
-				}
-				continue
+				warnings = append(warnings, fmt.Errorf("failed to post inline comment at %s:%d: %w", issue.Path, issue.LineNumber, err))
 			}
 		}
-		posted++
 	}
 
-	return posted, lastErr
+	return results, warnings
 }
 
-// getMRDiffRefs retrieves the diff refs from a merge request
-func getMRDiffRefs(apiURL, projectID, mrIID, token string) (*mrDiffRefs, error) {
+// getMRInfo retrieves merge request information
+func getMRInfo(apiURL, projectID, mrIID, token string) (*mrInfo, error) {
 	endpoint := fmt.Sprintf("%s/projects/%s/merge_requests/%s", apiURL, projectID, mrIID)
 
-	client := &http.Client{Timeout: 30 * time.Second}
+	client := newHTTPClient(30 * time.Second)
 	req, err := http.NewRequest("GET", endpoint, nil)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
 
-	req.Header.Set("PRIVATE-TOKEN", token)
+	setAuth(req, token)
 
 	resp, err := client.Do(req)
 	if err != nil {
@@ -356,8 +628,7 @@ func getMRDiffRefs(apiURL, projectID, mrIID, token string) (*mrDiffRefs, error)
 	defer func() { _ = resp.Body.Close() }()
 
 	if resp.StatusCode != http.StatusOK {
-		respBody, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("GitLab API returned status %d: %s", resp.StatusCode, string(respBody))
+		return nil, statusError(resp)
 	}
 
 	var mr mrInfo
@@ -365,6 +636,15 @@ func getMRDiffRefs(apiURL, projectID, mrIID, token string) (*mrDiffRefs, error)
 		return nil, fmt.Errorf("failed to decode response: %w", err)
 	}
 
+	return &mr, nil
+}
+
+// getMRDiffRefs retrieves the diff refs from a merge request
+func getMRDiffRefs(apiURL, projectID, mrIID, token string) (*mrDiffRefs, error) {
+	mr, err := getMRInfo(apiURL, projectID, mrIID, token)
+	if err != nil {
+		return nil, err
+	}
 	return &mr.DiffRefs, nil
 }
 
@@ -386,7 +666,7 @@ func findExistingInlineCommentInNotes(notes []mrNote, path string, line int) int
 
 	for i, note := range notes {
 		if verbose {
-			bodyPreview := note.Body
+			bodyPreview := output.Redact(note.Body)
 			if len(bodyPreview) > 100 {
 				bodyPreview = bodyPreview[:100] + "..."
 			}
@@ -469,14 +749,14 @@ func postInlineComment(apiURL, projectID, mrIID, token string, diffRefs *mrDiffR
 		return fmt.Errorf("failed to marshal request: %w", err)
 	}
 
-	client := &http.Client{Timeout: 30 * time.Second}
+	client := newHTTPClient(30 * time.Second)
 	req, err := http.NewRequest("POST", endpoint, bytes.NewBuffer(jsonBody))
 	if err != nil {
 		return fmt.Errorf("failed to create request: %w", err)
 	}
 
 	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("PRIVATE-TOKEN", token)
+	setAuth(req, token)
 
 	resp, err := client.Do(req)
 	if err != nil {
@@ -485,8 +765,7 @@ func postInlineComment(apiURL, projectID, mrIID, token string, diffRefs *mrDiffR
 	defer func() { _ = resp.Body.Close() }()
 
 	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
-		respBody, _ := io.ReadAll(resp.Body)
-		return fmt.Errorf("GitLab API returned status %d: %s", resp.StatusCode, string(respBody))
+		return statusError(resp)
 	}
 
 	return nil
@@ -505,14 +784,14 @@ func postNote(endpoint, token, body string) error {
 		return fmt.Errorf("failed to marshal request: %w", err)
 	}
 
-	client := &http.Client{Timeout: 30 * time.Second}
+	client := newHTTPClient(30 * time.Second)
 	req, err := http.NewRequest("POST", endpoint, bytes.NewBuffer(jsonBody))
 	if err != nil {
 		return fmt.Errorf("failed to create request: %w", err)
 	}
 
 	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("PRIVATE-TOKEN", token)
+	setAuth(req, token)
 
 	resp, err := client.Do(req)
 	if err != nil {
@@ -521,18 +800,20 @@ func postNote(endpoint, token, body string) error {
 	defer func() { _ = resp.Body.Close() }()
 
 	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
-		respBody, _ := io.ReadAll(resp.Body)
-		return fmt.Errorf("GitLab API returned status %d: %s", resp.StatusCode, string(respBody))
+		return statusError(resp)
 	}
 
 	return nil
 }
 
 // GetTokenFromEnv retrieves the GitLab token from environment variables
-// It checks GITLAB_TOKEN first, then falls back to CI_JOB_TOKEN
+// It checks GITLAB_TOKEN (a personal, project or group access token) first,
+// then GITLAB_OAUTH_TOKEN, and falls back to CI_JOB_TOKEN
 func GetTokenFromEnv() string {
-	if token := os.Getenv("GITLAB_TOKEN"); token != "" {
-		return token
+	for _, env := range []string{"GITLAB_TOKEN", "GITLAB_OAUTH_TOKEN"} {
+		if token := os.Getenv(env); token != "" {
+			return token
+		}
 	}
 	return os.Getenv("CI_JOB_TOKEN")
 }
@@ -551,6 +832,12 @@ func GetGitLabAPIURLFromEnv() string {
 	return ""
 }
 
+// GetRunKeyFromEnv returns the commit a GitLab CI pipeline is for, which
+// concurrent pipelines for the same MR update share
+func GetRunKeyFromEnv() string {
+	return os.Getenv("CI_COMMIT_SHA")
+}
+
 // GetMRInfoFromEnv retrieves MR info from GitLab CI environment variables
 func GetMRInfoFromEnv() (projectID, mrIID string) {
 	return os.Getenv("CI_PROJECT_ID"), os.Getenv("CI_MERGE_REQUEST_IID")
@@ -7,6 +7,7 @@ import (
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
+	"slices"
 	"testing"
 
 	"github.com/kusaridev/kusari-cli/v2/api"
@@ -732,3 +733,130 @@ func TestPostInlineComment(t *testing.T) {
 		})
 	}
 }
+
+func TestSyncContinuationNotes(t *testing.T) {
+	var requests []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, r.Method+" "+r.URL.Path)
+		if r.Method == "GET" {
+			_ = json.NewEncoder(w).Encode([]mrNote{
+				{ID: 1, Body: "summary <!-- IGNORE_KUSARI_COMMENT -->"},
+				{ID: 2, Body: "old part 2 " + comment.PartMarker(2)},
+			})
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	require.NoError(t, syncContinuationNotes(server.URL+"/api/v4", "123", "1", "token", []string{"new part 2", "new part 3"}))
+	assert.Equal(t, []string{
+		"GET /api/v4/projects/123/merge_requests/1/notes",
+		"PUT /api/v4/projects/123/merge_requests/1/notes/2",
+		"POST /api/v4/projects/123/merge_requests/1/notes",
+	}, requests)
+}
+
+func TestResolvePreviousDiscussions(t *testing.T) {
+	var requests []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, r.Method+" "+r.URL.Path)
+		if r.Method == "GET" {
+			_, _ = w.Write([]byte(`[
+				{"id": "a", "notes": [{"id": 1, "body": "older summary <!-- IGNORE_KUSARI_COMMENT -->", "resolvable": true, "resolved": true}]},
+				{"id": "b", "notes": [{"id": 2, "body": "latest summary <!-- IGNORE_KUSARI_COMMENT -->", "resolvable": true}]},
+				{"id": "c", "notes": [{"id": 3, "body": "LGTM", "resolvable": true}]},
+				{"id": "d", "notes": [{"id": 4, "body": "latest part 2 ` + comment.PartMarker(2) + `", "resolvable": true}]}
+			]`))
+			return
+		}
+		assert.Equal(t, "true", r.URL.Query().Get("resolved"))
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	n, err := resolvePreviousDiscussions(server.URL, "123", "1", "token")
+	require.NoError(t, err)
+	assert.Equal(t, 2, n)
+	assert.Equal(t, []string{
+		"GET /projects/123/merge_requests/1/discussions",
+		"PUT /projects/123/merge_requests/1/discussions/b",
+		"PUT /projects/123/merge_requests/1/discussions/d",
+	}, requests)
+}
+
+func TestPostCommentReportsInlineFailures(t *testing.T) {
+	analysis := &api.SecurityAnalysis{
+		ShouldProceed: false,
+		RequiredCodeMitigations: []api.CodeMitigationItem{
+			{Content: "SQL injection", Path: "main.go", LineNumber: 10},
+		},
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/api/v4/projects/123/merge_requests/1/notes" && r.Method == "GET":
+			_, _ = w.Write([]byte(`[]`))
+		case r.URL.Path == "/api/v4/projects/123/merge_requests/1/notes" && r.Method == "POST":
+			w.WriteHeader(http.StatusCreated)
+			_, _ = w.Write([]byte(`{"id": 1}`))
+		case r.URL.Path == "/api/v4/projects/123/merge_requests/1" && r.Method == "GET":
+			w.WriteHeader(http.StatusForbidden)
+		default:
+			t.Fatalf("Unexpected request: %s %s", r.Method, r.URL.Path)
+		}
+	}))
+	defer server.Close()
+
+	opts := CommentOptions{ProjectID: "123", MergeReqIID: "1", GitLabURL: server.URL + "/api/v4", Token: "test-token"}
+	result, err := PostComment(analysis, opts)
+	require.NoError(t, err)
+
+	assert.True(t, result.Posted)
+	assert.Equal(t, comment.SummaryCreated, result.SummaryAction)
+	assert.Empty(t, result.Inline)
+	assert.Zero(t, result.InlineCommentsPosted)
+	require.Len(t, result.Warnings, 1)
+	assert.ErrorContains(t, result.Warnings[0], "failed to get MR diff refs")
+}
+
+func TestPostCommentRemovesConcurrentDuplicate(t *testing.T) {
+	analysis := &api.SecurityAnalysis{ShouldProceed: false, Justification: "Security issues found"}
+	other := comment.Run{Key: "abc123", ID: "other"}
+	run := comment.Run{Key: "abc123", ID: "this"}
+
+	var stored []mrNote
+	var requests []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, r.Method+" "+r.URL.Path)
+		switch {
+		case r.Method == "GET" && r.URL.Path == "/api/v4/projects/123/merge_requests/1/notes":
+			_ = json.NewEncoder(w).Encode(stored)
+		case r.Method == "POST" && r.URL.Path == "/api/v4/projects/123/merge_requests/1/notes":
+			var body noteRequest
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+			// The other pipeline saw no summary either, and posted first
+			stored = append(stored, mrNote{ID: 9, Body: "summary <!-- IGNORE_KUSARI_COMMENT -->" + other.Marker("other findings")})
+			stored = append(stored, mrNote{ID: 10, Body: body.Body})
+			w.WriteHeader(http.StatusCreated)
+		case r.Method == "DELETE" && r.URL.Path == "/api/v4/projects/123/merge_requests/1/notes/10":
+			w.WriteHeader(http.StatusNoContent)
+		case r.Method == "PUT" && r.URL.Path == "/api/v4/projects/123/merge_requests/1/notes/9":
+			w.WriteHeader(http.StatusOK)
+		default:
+			t.Errorf("Unexpected request: %s %s", r.Method, r.URL.Path)
+		}
+	}))
+	defer server.Close()
+
+	opts := CommentOptions{ProjectID: "123", MergeReqIID: "1", GitLabURL: server.URL + "/api/v4", Token: "test-token", Run: run}
+	result, err := PostComment(analysis, opts)
+	require.NoError(t, err)
+
+	assert.Empty(t, result.Warnings)
+	assert.Equal(t, comment.SummaryUpdated, result.SummaryAction)
+	put := slices.Index(requests, "PUT /api/v4/projects/123/merge_requests/1/notes/9")
+	remove := slices.Index(requests, "DELETE /api/v4/projects/123/merge_requests/1/notes/10")
+	require.NotEqual(t, -1, put)
+	assert.Greater(t, remove, put, "the kept summary is updated before this run's is removed")
+}
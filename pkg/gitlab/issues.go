@@ -0,0 +1,179 @@
+// Copyright (c) Kusari <https://www.kusari.dev/>
+// SPDX-License-Identifier: MIT
+
+package gitlab
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/kusaridev/kusari-cli/v2/pkg/readonly"
+	"github.com/kusaridev/kusari-cli/v2/pkg/results"
+)
+
+// IssueOptions holds the configuration for opening issues on GitLab
+type IssueOptions struct {
+	ProjectID string
+	GitLabURL string
+	Token     string
+	Labels    []string
+	Assignees []string // Usernames
+	Verbose   bool
+}
+
+// projectIssue represents a GitLab issue
+type projectIssue struct {
+	IID         int    `json:"iid"`
+	Description string `json:"description"`
+	WebURL      string `json:"web_url"`
+}
+
+// createIssueRequest is the request body for opening an issue
+type createIssueRequest struct {
+	Title       string `json:"title"`
+	Description string `json:"description"`
+	Labels      string `json:"labels,omitempty"`
+	AssigneeIDs []int  `json:"assignee_ids,omitempty"`
+}
+
+// issuesPerPage is the page size used when listing issues
+const issuesPerPage = 100
+
+// CreateIssues opens the given issues, skipping those already opened by
+// an earlier run (open or closed), and returns the URLs of the new ones
+func CreateIssues(issues []results.Issue, opts IssueOptions) ([]string, error) {
+	if err := readonly.Check("open GitLab issues"); err != nil {
+		return nil, err
+	}
+	apiURL := apiURLOrDefault(opts.GitLabURL)
+
+	existing, err := listProjectIssues(apiURL, opts.ProjectID, opts.Token, opts.Labels)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list issues on GitLab: %w", err)
+	}
+	opened := map[string]int{}
+	for _, i := range existing {
+		if key, ok := results.ParseIssueMarker(i.Description); ok {
+			opened[key] = i.IID
+		}
+	}
+
+	var assigneeIDs []int
+	for _, username := range opts.Assignees {
+		id, err := userIDByUsername(apiURL, opts.Token, strings.TrimPrefix(username, "@"))
+		if err != nil {
+			return nil, fmt.Errorf("failed to look up GitLab user %q: %w", username, err)
+		}
+		assigneeIDs = append(assigneeIDs, id)
+	}
+
+	var created []string
+	for _, i := range issues {
+		if iid, ok := opened[i.Key]; ok {
+			if opts.Verbose {
+				fmt.Fprintf(os.Stderr, "Skipping %q: already tracked in #%d\n", i.Title, iid)
+			}
+			continue
+		}
+		reqBody := createIssueRequest{
+			Title:       i.Title,
+			Description: i.Body,
+			Labels:      strings.Join(opts.Labels, ","),
+			AssigneeIDs: assigneeIDs,
+		}
+		u, err := createProjectIssue(apiURL, opts.ProjectID, opts.Token, reqBody)
+		if err != nil {
+			return created, fmt.Errorf("failed to create issue on GitLab: %w", err)
+		}
+		created = append(created, u)
+	}
+	return created, nil
+}
+
+// listProjectIssues retrieves all issues, open and closed, with the given labels
+func listProjectIssues(apiURL, projectID, token string, labels []string) ([]projectIssue, error) {
+	var all []projectIssue
+	for page := 1; ; page++ {
+		query := url.Values{}
+		query.Set("state", "all")
+		query.Set("per_page", fmt.Sprint(issuesPerPage))
+		query.Set("page", fmt.Sprint(page))
+		if len(labels) > 0 {
+			query.Set("labels", strings.Join(labels, ","))
+		}
+		endpoint := fmt.Sprintf("%s/projects/%s/issues?%s", apiURL, url.PathEscape(projectID), query.Encode())
+
+		client := newHTTPClient(30 * time.Second)
+		req, err := http.NewRequest("GET", endpoint, nil)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create request: %w", err)
+		}
+
+		setAuth(req, token)
+
+		resp, err := client.Do(req)
+		if err != nil {
+			return nil, fmt.Errorf("request failed: %w", err)
+		}
+
+		if resp.StatusCode != http.StatusOK {
+			err := statusError(resp)
+			_ = resp.Body.Close()
+			return nil, err
+		}
+
+		var issues []projectIssue
+		err = json.NewDecoder(resp.Body).Decode(&issues)
+		_ = resp.Body.Close()
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode response: %w", err)
+		}
+
+		all = append(all, issues...)
+		if len(issues) < issuesPerPage {
+			return all, nil
+		}
+	}
+}
+
+// createProjectIssue opens an issue and returns its URL
+func createProjectIssue(apiURL, projectID, token string, reqBody createIssueRequest) (string, error) {
+	endpoint := fmt.Sprintf("%s/projects/%s/issues", apiURL, url.PathEscape(projectID))
+
+	jsonBody, err := json.Marshal(reqBody)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	client := newHTTPClient(30 * time.Second)
+	req, err := http.NewRequest("POST", endpoint, bytes.NewBuffer(jsonBody))
+	if err != nil {
+		return "", fmt.Errorf("failed to create request: %w", err)
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	setAuth(req, token)
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("request failed: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return "", statusError(resp)
+	}
+
+	var created projectIssue
+	if err := json.NewDecoder(resp.Body).Decode(&created); err != nil {
+		return "", fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	return created.WebURL, nil
+}
@@ -0,0 +1,53 @@
+// Copyright (c) Kusari <https://www.kusari.dev/>
+// SPDX-License-Identifier: MIT
+
+package gitlab
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/kusaridev/kusari-cli/v2/pkg/results"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCreateIssues(t *testing.T) {
+	var created []createIssueRequest
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/users":
+			_, _ = w.Write([]byte(`[{"id": 5, "username": "alice"}]`))
+		case r.Method == "GET":
+			assert.Equal(t, "/projects/group/app/issues", r.URL.Path)
+			_ = json.NewEncoder(w).Encode([]projectIssue{{IID: 4, Description: results.IssueMarker("file:aaaa")}})
+		default:
+			var req createIssueRequest
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+			created = append(created, req)
+			w.WriteHeader(http.StatusCreated)
+			_, _ = w.Write([]byte(`{"iid": 5, "web_url": "https://gitlab.com/group/app/-/issues/5"}`))
+		}
+	}))
+	defer server.Close()
+
+	issues := []results.Issue{
+		{Key: "file:aaaa", Title: "Kusari: tracked", Body: results.IssueMarker("file:aaaa")},
+		{Key: "file:bbbb", Title: "Kusari: new", Body: results.IssueMarker("file:bbbb")},
+	}
+	urls, err := CreateIssues(issues, IssueOptions{
+		ProjectID: "group/app", GitLabURL: server.URL, Token: "token",
+		Labels: []string{"kusari", "security"}, Assignees: []string{"@alice"},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"https://gitlab.com/group/app/-/issues/5"}, urls)
+	require.Len(t, created, 1)
+	assert.Equal(t, createIssueRequest{
+		Title:       "Kusari: new",
+		Description: results.IssueMarker("file:bbbb"),
+		Labels:      "kusari,security",
+		AssigneeIDs: []int{5},
+	}, created[0])
+}
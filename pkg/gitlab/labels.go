@@ -0,0 +1,83 @@
+// Copyright (c) Kusari <https://www.kusari.dev/>
+// SPDX-License-Identifier: MIT
+
+package gitlab
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/kusaridev/kusari-cli/v2/api"
+	"github.com/kusaridev/kusari-cli/v2/pkg/comment"
+	"github.com/kusaridev/kusari-cli/v2/pkg/readonly"
+)
+
+// mrLabelsRequest is the request body for changing merge request labels
+type mrLabelsRequest struct {
+	AddLabels    string `json:"add_labels,omitempty"`
+	RemoveLabels string `json:"remove_labels,omitempty"`
+}
+
+// SetLabels labels the merge request with the outcome of analysis,
+// removing the labels of the other outcomes
+func SetLabels(analysis *api.SecurityAnalysis, opts CommentOptions, labels comment.Labels) error {
+	if analysis == nil {
+		return nil
+	}
+	add, remove := labels.For(analysis)
+	if add == "" && len(remove) == 0 {
+		return nil
+	}
+	if err := readonly.Check("label a GitLab merge request"); err != nil {
+		return err
+	}
+
+	apiURL := apiURLOrDefault(opts.GitLabURL)
+	if opts.Verbose {
+		fmt.Fprintf(os.Stderr, "Setting labels on MR !%s (add: %q, remove: %q)\n", opts.MergeReqIID, add, remove)
+	}
+
+	// Labels are comma-separated; GitLab ignores removing absent labels
+	// and creates missing ones
+	reqBody := mrLabelsRequest{AddLabels: add, RemoveLabels: strings.Join(remove, ",")}
+	if err := updateMergeRequest(apiURL, opts.ProjectID, opts.MergeReqIID, opts.Token, reqBody); err != nil {
+		return fmt.Errorf("failed to set labels on GitLab: %w", err)
+	}
+	return nil
+}
+
+// updateMergeRequest updates the attributes of a merge request
+func updateMergeRequest(apiURL, projectID, mrIID, token string, body any) error {
+	endpoint := fmt.Sprintf("%s/projects/%s/merge_requests/%s", apiURL, projectID, mrIID)
+
+	jsonBody, err := json.Marshal(body)
+	if err != nil {
+		return fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	client := newHTTPClient(30 * time.Second)
+	req, err := http.NewRequest("PUT", endpoint, bytes.NewBuffer(jsonBody))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	setAuth(req, token)
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("request failed: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return statusError(resp)
+	}
+
+	return nil
+}
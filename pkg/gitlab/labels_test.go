@@ -0,0 +1,33 @@
+// Copyright (c) Kusari <https://www.kusari.dev/>
+// SPDX-License-Identifier: MIT
+
+package gitlab
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/kusaridev/kusari-cli/v2/api"
+	"github.com/kusaridev/kusari-cli/v2/pkg/comment"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSetLabels(t *testing.T) {
+	var got mrLabelsRequest
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "PUT", r.Method)
+		assert.Equal(t, "/projects/123/merge_requests/1", r.URL.Path)
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&got))
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	labels := comment.Labels{Passed: "kusari:passed", Blocked: "kusari:blocked", NeedsReview: "kusari:needs-review"}
+	opts := CommentOptions{ProjectID: "123", MergeReqIID: "1", GitLabURL: server.URL, Token: "token"}
+	require.NoError(t, SetLabels(&api.SecurityAnalysis{ShouldProceed: true}, opts, labels))
+
+	assert.Equal(t, mrLabelsRequest{AddLabels: "kusari:passed", RemoveLabels: "kusari:blocked,kusari:needs-review"}, got)
+}
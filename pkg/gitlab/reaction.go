@@ -0,0 +1,214 @@
+// Copyright (c) Kusari <https://www.kusari.dev/>
+// SPDX-License-Identifier: MIT
+
+package gitlab
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/kusaridev/kusari-cli/v2/api"
+	"github.com/kusaridev/kusari-cli/v2/pkg/comment"
+	"github.com/kusaridev/kusari-cli/v2/pkg/readonly"
+)
+
+// Award emoji names for a passing and a failing analysis
+const (
+	awardPass = "thumbsup"
+	awardFail = "thumbsdown"
+)
+
+// awardEmoji represents an emoji reaction on a merge request
+type awardEmoji struct {
+	ID   int    `json:"id"`
+	Name string `json:"name"`
+	User struct {
+		ID int `json:"id"`
+	} `json:"user"`
+}
+
+// PostReaction signals the analysis verdict with a 👍 or 👎 award emoji on
+// the merge request, replacing the opposite one left by an earlier run
+func PostReaction(analysis *api.SecurityAnalysis, opts CommentOptions) (*comment.CommentResult, error) {
+	if analysis == nil {
+		return &comment.CommentResult{
+			Posted:      false,
+			IssuesFound: 0,
+			Message:     "No analysis results available - skipping reaction",
+		}, nil
+	}
+	if err := readonly.Check("react to a GitLab merge request"); err != nil {
+		return nil, err
+	}
+
+	hasIssues, issueCount := comment.CheckForIssues(analysis)
+	name, stale := awardPass, awardFail
+	if hasIssues {
+		name, stale = awardFail, awardPass
+	}
+
+	apiURL := apiURLOrDefault(opts.GitLabURL)
+
+	userID, err := currentUserID(apiURL, opts.Token)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get GitLab user: %w", err)
+	}
+
+	awards, err := listAwardEmoji(apiURL, opts.ProjectID, opts.MergeReqIID, opts.Token)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list reactions on GitLab: %w", err)
+	}
+
+	// GitLab rejects a duplicate award, so only add it if it is missing
+	awarded := false
+	for _, a := range awards {
+		if a.User.ID != userID {
+			continue
+		}
+		switch a.Name {
+		case name:
+			awarded = true
+		case stale:
+			if opts.Verbose {
+				fmt.Fprintf(os.Stderr, "Removing previous %s reaction (ID: %d)\n", a.Name, a.ID)
+			}
+			if err := deleteAwardEmoji(apiURL, opts.ProjectID, opts.MergeReqIID, a.ID, opts.Token); err != nil {
+				return nil, fmt.Errorf("failed to remove previous reaction on GitLab: %w", err)
+			}
+		}
+	}
+	if !awarded {
+		if err := createAwardEmoji(apiURL, opts.ProjectID, opts.MergeReqIID, opts.Token, name); err != nil {
+			return nil, fmt.Errorf("failed to add reaction on GitLab: %w", err)
+		}
+	}
+
+	return &comment.CommentResult{
+		Posted:      true,
+		IssuesFound: issueCount,
+		Message:     fmt.Sprintf("Reacted :%s: to MR !%s", name, opts.MergeReqIID),
+	}, nil
+}
+
+// currentUserID returns the ID of the user the token belongs to
+func currentUserID(apiURL, token string) (int, error) {
+	endpoint := fmt.Sprintf("%s/user", apiURL)
+
+	client := newHTTPClient(30 * time.Second)
+	req, err := http.NewRequest("GET", endpoint, nil)
+	if err != nil {
+		return 0, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	setAuth(req, token)
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return 0, fmt.Errorf("request failed: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode != http.StatusOK {
+		return 0, statusError(resp)
+	}
+
+	var user struct {
+		ID int `json:"id"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&user); err != nil {
+		return 0, fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	return user.ID, nil
+}
+
+// listAwardEmoji retrieves the award emoji on a merge request
+func listAwardEmoji(apiURL, projectID, mrIID, token string) ([]awardEmoji, error) {
+	endpoint := fmt.Sprintf("%s/projects/%s/merge_requests/%s/award_emoji", apiURL, projectID, mrIID)
+
+	client := newHTTPClient(30 * time.Second)
+	req, err := http.NewRequest("GET", endpoint, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	setAuth(req, token)
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("request failed: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, statusError(resp)
+	}
+
+	var awards []awardEmoji
+	if err := json.NewDecoder(resp.Body).Decode(&awards); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	return awards, nil
+}
+
+// createAwardEmoji adds an award emoji to a merge request
+func createAwardEmoji(apiURL, projectID, mrIID, token, name string) error {
+	endpoint := fmt.Sprintf("%s/projects/%s/merge_requests/%s/award_emoji", apiURL, projectID, mrIID)
+
+	reqBody := map[string]string{"name": name}
+	jsonBody, err := json.Marshal(reqBody)
+	if err != nil {
+		return fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	client := newHTTPClient(30 * time.Second)
+	req, err := http.NewRequest("POST", endpoint, bytes.NewBuffer(jsonBody))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	setAuth(req, token)
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("request failed: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return statusError(resp)
+	}
+
+	return nil
+}
+
+// deleteAwardEmoji removes an award emoji from a merge request
+func deleteAwardEmoji(apiURL, projectID, mrIID string, awardID int, token string) error {
+	endpoint := fmt.Sprintf("%s/projects/%s/merge_requests/%s/award_emoji/%d", apiURL, projectID, mrIID, awardID)
+
+	client := newHTTPClient(30 * time.Second)
+	req, err := http.NewRequest("DELETE", endpoint, nil)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+
+	setAuth(req, token)
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("request failed: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return statusError(resp)
+	}
+
+	return nil
+}
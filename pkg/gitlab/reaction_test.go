@@ -0,0 +1,67 @@
+// Copyright (c) Kusari <https://www.kusari.dev/>
+// SPDX-License-Identifier: MIT
+
+package gitlab
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/kusaridev/kusari-cli/v2/api"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPostReaction(t *testing.T) {
+	var requests []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, r.Method+" "+r.URL.Path)
+		switch {
+		case r.URL.Path == "/user":
+			_, _ = w.Write([]byte(`{"id": 42}`))
+		case r.Method == "GET":
+			_, _ = w.Write([]byte(`[
+				{"id": 1, "name": "thumbsdown", "user": {"id": 42}},
+				{"id": 2, "name": "thumbsdown", "user": {"id": 7}}
+			]`))
+		default:
+			w.WriteHeader(http.StatusOK)
+		}
+	}))
+	defer server.Close()
+
+	analysis := &api.SecurityAnalysis{ShouldProceed: true}
+	result, err := PostReaction(analysis, CommentOptions{ProjectID: "123", MergeReqIID: "1", GitLabURL: server.URL, Token: "token"})
+	require.NoError(t, err)
+	assert.True(t, result.Posted)
+	assert.Equal(t, "Reacted :thumbsup: to MR !1", result.Message)
+
+	assert.Equal(t, []string{
+		"GET /user",
+		"GET /projects/123/merge_requests/1/award_emoji",
+		"DELETE /projects/123/merge_requests/1/award_emoji/1",
+		"POST /projects/123/merge_requests/1/award_emoji",
+	}, requests)
+}
+
+func TestPostReactionAlreadyAwarded(t *testing.T) {
+	var requests []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, r.Method+" "+r.URL.Path)
+		if r.URL.Path == "/user" {
+			_, _ = w.Write([]byte(`{"id": 42}`))
+			return
+		}
+		_, _ = w.Write([]byte(`[{"id": 1, "name": "thumbsdown", "user": {"id": 42}}]`))
+	}))
+	defer server.Close()
+
+	analysis := &api.SecurityAnalysis{ShouldProceed: false}
+	_, err := PostReaction(analysis, CommentOptions{ProjectID: "123", MergeReqIID: "1", GitLabURL: server.URL, Token: "token"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{
+		"GET /user",
+		"GET /projects/123/merge_requests/1/award_emoji",
+	}, requests)
+}
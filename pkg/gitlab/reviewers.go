@@ -0,0 +1,108 @@
+// Copyright (c) Kusari <https://www.kusari.dev/>
+// SPDX-License-Identifier: MIT
+
+package gitlab
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/kusaridev/kusari-cli/v2/pkg/readonly"
+)
+
+// mrReviewersRequest is the request body for setting merge request reviewers
+type mrReviewersRequest struct {
+	ReviewerIDs []int `json:"reviewer_ids"`
+}
+
+// RequestReviewers adds reviewers (usernames) to the merge request,
+// keeping the reviewers it already has. Groups can't be reviewers on
+// GitLab, so entries of the form group/name are skipped, as is the MR
+// author.
+func RequestReviewers(opts CommentOptions, reviewers []string) error {
+	if err := readonly.Check("request GitLab merge request reviewers"); err != nil {
+		return err
+	}
+	apiURL := apiURLOrDefault(opts.GitLabURL)
+
+	mr, err := getMRInfo(apiURL, opts.ProjectID, opts.MergeReqIID, opts.Token)
+	if err != nil {
+		return fmt.Errorf("failed to get MR info: %w", err)
+	}
+
+	ids := make([]int, 0, len(mr.Reviewers)+len(reviewers))
+	for _, r := range mr.Reviewers {
+		ids = append(ids, r.ID)
+	}
+	added := 0
+	for _, r := range reviewers {
+		r = strings.TrimPrefix(r, "@")
+		if r == "" || strings.Contains(r, "/") {
+			if opts.Verbose && r != "" {
+				fmt.Fprintf(os.Stderr, "Skipping reviewer %q: GitLab groups can't be reviewers\n", r)
+			}
+			continue
+		}
+		if strings.EqualFold(r, mr.Author.Username) {
+			continue
+		}
+		id, err := userIDByUsername(apiURL, opts.Token, r)
+		if err != nil {
+			return fmt.Errorf("failed to look up GitLab user %q: %w", r, err)
+		}
+		if !slices.Contains(ids, id) {
+			ids = append(ids, id)
+			added++
+		}
+	}
+	if added == 0 {
+		return nil
+	}
+
+	if opts.Verbose {
+		fmt.Fprintf(os.Stderr, "Requesting review on MR !%s from %d user(s)\n", opts.MergeReqIID, added)
+	}
+	if err := updateMergeRequest(apiURL, opts.ProjectID, opts.MergeReqIID, opts.Token, mrReviewersRequest{ReviewerIDs: ids}); err != nil {
+		return fmt.Errorf("failed to request reviewers on GitLab: %w", err)
+	}
+	return nil
+}
+
+// userIDByUsername returns the ID of the user with the given username
+func userIDByUsername(apiURL, token, username string) (int, error) {
+	endpoint := fmt.Sprintf("%s/users?username=%s", apiURL, url.QueryEscape(username))
+
+	client := newHTTPClient(30 * time.Second)
+	req, err := http.NewRequest("GET", endpoint, nil)
+	if err != nil {
+		return 0, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	setAuth(req, token)
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return 0, fmt.Errorf("request failed: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode != http.StatusOK {
+		return 0, statusError(resp)
+	}
+
+	var users []mrUser
+	if err := json.NewDecoder(resp.Body).Decode(&users); err != nil {
+		return 0, fmt.Errorf("failed to decode response: %w", err)
+	}
+	if len(users) == 0 {
+		return 0, fmt.Errorf("no such user")
+	}
+
+	return users[0].ID, nil
+}
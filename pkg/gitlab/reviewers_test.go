@@ -0,0 +1,58 @@
+// Copyright (c) Kusari <https://www.kusari.dev/>
+// SPDX-License-Identifier: MIT
+
+package gitlab
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRequestReviewers(t *testing.T) {
+	var got mrReviewersRequest
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/users":
+			switch r.URL.Query().Get("username") {
+			case "alice":
+				_, _ = w.Write([]byte(`[{"id": 5, "username": "alice"}]`))
+			case "bob":
+				_, _ = w.Write([]byte(`[{"id": 9, "username": "bob"}]`))
+			default:
+				_, _ = w.Write([]byte(`[]`))
+			}
+		case r.Method == "GET":
+			_, _ = w.Write([]byte(`{"author": {"id": 1, "username": "author"}, "reviewers": [{"id": 9, "username": "bob"}]}`))
+		case r.Method == "PUT":
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&got))
+			w.WriteHeader(http.StatusOK)
+		}
+	}))
+	defer server.Close()
+
+	opts := CommentOptions{ProjectID: "123", MergeReqIID: "1", GitLabURL: server.URL, Token: "token"}
+	require.NoError(t, RequestReviewers(opts, []string{"alice", "bob", "author", "acme/security"}))
+
+	// Existing reviewers are kept
+	assert.Equal(t, []int{9, 5}, got.ReviewerIDs)
+}
+
+func TestRequestReviewersUnknownUser(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/users" {
+			_, _ = w.Write([]byte(`[]`))
+			return
+		}
+		_, _ = w.Write([]byte(`{"author": {"id": 1, "username": "author"}}`))
+	}))
+	defer server.Close()
+
+	opts := CommentOptions{ProjectID: "123", MergeReqIID: "1", GitLabURL: server.URL, Token: "token"}
+	err := RequestReviewers(opts, []string{"nobody"})
+	assert.ErrorContains(t, err, `failed to look up GitLab user "nobody"`)
+}
@@ -0,0 +1,156 @@
+// Copyright (c) Kusari <https://www.kusari.dev/>
+// SPDX-License-Identifier: MIT
+
+package gitlab
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/kusaridev/kusari-cli/v2/pkg/output"
+)
+
+// ScopeAPI is the token scope needed to post comments, labels and issues.
+const ScopeAPI = "api"
+
+// Kinds of GitLab tokens, by the environment variable they come from.
+const (
+	// TokenAccess is a personal, project or group access token, from
+	// GITLAB_TOKEN.
+	TokenAccess = "access token"
+	// TokenOAuth is an OAuth access token, from GITLAB_OAUTH_TOKEN.
+	TokenOAuth = "OAuth token"
+	// TokenJob is the CI job token, from CI_JOB_TOKEN.
+	TokenJob = "CI job token"
+)
+
+// TokenKind returns the kind of token, judging by the environment variable
+// it was read from. Tokens from elsewhere are taken as access tokens.
+func TokenKind(token string) string {
+	switch token {
+	case os.Getenv("GITLAB_TOKEN"):
+		return TokenAccess
+	case os.Getenv("GITLAB_OAUTH_TOKEN"):
+		return TokenOAuth
+	case os.Getenv("CI_JOB_TOKEN"):
+		return TokenJob
+	default:
+		return TokenAccess
+	}
+}
+
+// setAuth authenticates req with token, in the header its kind takes.
+func setAuth(req *http.Request, token string) {
+	switch TokenKind(token) {
+	case TokenOAuth:
+		req.Header.Set("Authorization", "Bearer "+token)
+	case TokenJob:
+		req.Header.Set("JOB-TOKEN", token)
+	default:
+		req.Header.Set("PRIVATE-TOKEN", token)
+	}
+}
+
+// tokenInfo is what GitLab reports about an access or OAuth token.
+type tokenInfo struct {
+	Name   string   `json:"name"`
+	Scopes []string `json:"scopes"`
+	// Scope is the OAuth token info field name; GitLab sends both.
+	Scope []string `json:"scope"`
+}
+
+// CheckTokenScopes checks, before any comment is posted, that token was
+// granted every scope in needed, so a missing scope is reported by name
+// rather than as a 403 from the first write. Job tokens have fixed
+// permissions and aren't checked. When GitLab can't tell (an older
+// instance, a network error), the check passes and the API calls report
+// what goes wrong.
+func CheckTokenScopes(apiURL, token string, needed ...string) error {
+	apiURL = apiURLOrDefault(apiURL)
+	kind := TokenKind(token)
+	var endpoint string
+	switch kind {
+	case TokenJob:
+		return nil
+	case TokenOAuth:
+		// Not under the API prefix.
+		endpoint = strings.TrimSuffix(apiURL, "/api/v4") + "/oauth/token/info"
+	default:
+		endpoint = apiURL + "/personal_access_tokens/self"
+	}
+
+	req, err := http.NewRequest("GET", endpoint, nil)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	setAuth(req, token)
+	resp, err := newHTTPClient(30 * time.Second).Do(req)
+	if err != nil {
+		output.Debug("GitLab token scope check failed", "error", err)
+		return nil
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+	case http.StatusUnauthorized:
+		return fmt.Errorf("GitLab rejected the %s: it is invalid, expired or revoked", kind)
+	default:
+		output.Debug("GitLab token scope check unavailable", "status", resp.StatusCode)
+		return nil
+	}
+
+	var info tokenInfo
+	if err := json.NewDecoder(resp.Body).Decode(&info); err != nil {
+		output.Debug("GitLab token scope check unreadable", "error", err)
+		return nil
+	}
+	granted := append(info.Scopes, info.Scope...)
+	var missing []string
+	for _, scope := range needed {
+		if !slices.Contains(granted, scope) {
+			missing = append(missing, scope)
+		}
+	}
+	if len(missing) == 0 {
+		return nil
+	}
+	name := kind
+	if info.Name != "" {
+		name = fmt.Sprintf("%s %q", kind, info.Name)
+	}
+	has := "no scopes"
+	if len(granted) > 0 {
+		has = strings.Join(granted, ", ")
+	}
+	return fmt.Errorf("GitLab %s is missing the %s scope (it has %s); grant it in the token's settings or create a token with it",
+		name, strings.Join(missing, ", "), has)
+}
+
+// scopeError is GitLab's body for a 403 from a token without the scope
+// the endpoint needs.
+type scopeError struct {
+	Error string `json:"error"`
+	Scope string `json:"scope"`
+}
+
+// statusError describes a failed GitLab API response. A 403 names the
+// missing scope when GitLab gives it, and suggests checking the token's
+// role otherwise.
+func statusError(resp *http.Response) error {
+	respBody, _ := io.ReadAll(resp.Body)
+	if resp.StatusCode == http.StatusForbidden {
+		var se scopeError
+		if json.Unmarshal(respBody, &se) == nil && se.Error == "insufficient_scope" && se.Scope != "" {
+			return fmt.Errorf("GitLab API returned status 403: the token is missing the %s scope", se.Scope)
+		}
+		return fmt.Errorf("GitLab API returned status 403: %s (check that the token's role on the project allows this)", string(respBody))
+	}
+	return fmt.Errorf("GitLab API returned status %d: %s", resp.StatusCode, string(respBody))
+}
@@ -0,0 +1,105 @@
+// Copyright (c) Kusari <https://www.kusari.dev/>
+// SPDX-License-Identifier: MIT
+
+package gitlab
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func setTokenEnv(t *testing.T, access, oauth, job string) {
+	t.Setenv("GITLAB_TOKEN", access)
+	t.Setenv("GITLAB_OAUTH_TOKEN", oauth)
+	t.Setenv("CI_JOB_TOKEN", job)
+}
+
+func TestSetAuth(t *testing.T) {
+	setTokenEnv(t, "glpat-access", "oauth-token", "glcbt-job")
+
+	for token, want := range map[string]string{
+		"glpat-access": "Private-Token: glpat-access",
+		"oauth-token":  "Authorization: Bearer oauth-token",
+		"glcbt-job":    "Job-Token: glcbt-job",
+		"other":        "Private-Token: other",
+	} {
+		req, err := http.NewRequest("GET", "https://gitlab.example.com", nil)
+		require.NoError(t, err)
+		setAuth(req, token)
+		require.Len(t, req.Header, 1)
+		for k, v := range req.Header {
+			assert.Equal(t, want, k+": "+v[0])
+		}
+	}
+
+	assert.Equal(t, "glpat-access", GetTokenFromEnv())
+	t.Setenv("GITLAB_TOKEN", "")
+	assert.Equal(t, "oauth-token", GetTokenFromEnv())
+}
+
+func TestCheckTokenScopes(t *testing.T) {
+	tests := []struct {
+		name    string
+		oauth   bool
+		status  int
+		body    string
+		wantErr string
+	}{
+		{name: "granted", status: http.StatusOK, body: `{"name": "kusari", "scopes": ["api", "read_repository"]}`},
+		{name: "missing scope", status: http.StatusOK, body: `{"name": "kusari", "scopes": ["read_api"]}`,
+			wantErr: `GitLab access token "kusari" is missing the api scope (it has read_api)`},
+		{name: "oauth missing scope", oauth: true, status: http.StatusOK, body: `{"scope": ["read_user"]}`,
+			wantErr: `GitLab OAuth token is missing the api scope (it has read_user)`},
+		{name: "revoked", status: http.StatusUnauthorized, wantErr: "invalid, expired or revoked"},
+		{name: "older GitLab", status: http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var path string
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				path = r.URL.Path
+				w.WriteHeader(tt.status)
+				_, _ = w.Write([]byte(tt.body))
+			}))
+			defer server.Close()
+
+			if tt.oauth {
+				setTokenEnv(t, "", "token", "")
+			} else {
+				setTokenEnv(t, "token", "", "")
+			}
+			err := CheckTokenScopes(server.URL+"/api/v4", "token", ScopeAPI)
+			if tt.wantErr != "" {
+				assert.ErrorContains(t, err, tt.wantErr)
+			} else {
+				assert.NoError(t, err)
+			}
+			if tt.oauth {
+				assert.Equal(t, "/oauth/token/info", path)
+			} else {
+				assert.Equal(t, "/api/v4/personal_access_tokens/self", path)
+			}
+		})
+	}
+
+	// Job tokens aren't checked.
+	setTokenEnv(t, "", "", "job")
+	assert.NoError(t, CheckTokenScopes("http://127.0.0.1:1/api/v4", "job", ScopeAPI))
+}
+
+func TestStatusError(t *testing.T) {
+	resp := func(status int, body string) *http.Response {
+		return &http.Response{StatusCode: status, Body: io.NopCloser(strings.NewReader(body))}
+	}
+	assert.EqualError(t, statusError(resp(http.StatusForbidden, `{"error":"insufficient_scope","error_description":"The request requires higher privileges than provided by the access token.","scope":"api"}`)),
+		"GitLab API returned status 403: the token is missing the api scope")
+	assert.EqualError(t, statusError(resp(http.StatusForbidden, `{"message":"403 Forbidden"}`)),
+		`GitLab API returned status 403: {"message":"403 Forbidden"} (check that the token's role on the project allows this)`)
+	assert.EqualError(t, statusError(resp(http.StatusNotFound, "not found")), "GitLab API returned status 404: not found")
+}
@@ -0,0 +1,169 @@
+// Copyright (c) Kusari <https://www.kusari.dev/>
+// SPDX-License-Identifier: MIT
+
+// Package image reads what a container image is built from: the base
+// images of a Dockerfile and, through the docker CLI, the config of local
+// images.
+package image
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"regexp"
+	"strings"
+
+	"github.com/kusaridev/kusari-cli/v2/api"
+)
+
+// refPattern is the form of an image reference: [host[:port]/]path[:tag][@digest].
+var refPattern = regexp.MustCompile(`^[a-zA-Z0-9][a-zA-Z0-9._-]*(:[0-9]+)?(/[a-zA-Z0-9._-]+)*(:[a-zA-Z0-9_][a-zA-Z0-9._-]{0,127})?(@sha256:[a-f0-9]{64})?$`)
+
+// ValidRef reports whether ref looks like an image reference.
+func ValidRef(ref string) bool {
+	return refPattern.MatchString(ref)
+}
+
+// Pinned reports whether ref names an image by digest.
+func Pinned(ref string) bool {
+	return strings.Contains(ref, "@sha256:")
+}
+
+// Tagged reports whether ref names a tag, rather than the implicit latest.
+func Tagged(ref string) bool {
+	name, _, _ := strings.Cut(ref, "@")
+	// A colon before the last slash is a registry port.
+	return strings.LastIndex(name, ":") > strings.LastIndex(name, "/")
+}
+
+// ParseDockerfile returns the base images of the Dockerfile read from r,
+// one per FROM instruction. scratch, and stages built FROM an earlier
+// stage, aren't base images and are left out. ARGs declared before the
+// first FROM are expanded with their defaults.
+func ParseDockerfile(r io.Reader) ([]api.BaseImage, error) {
+	var images []api.BaseImage
+	args := map[string]string{}
+	stages := map[string]bool{}
+	seenFrom := false
+
+	sc := bufio.NewScanner(r)
+	lineNo := 0
+	for {
+		instruction, start, ok := nextInstruction(sc, &lineNo)
+		if !ok {
+			break
+		}
+		fields := strings.Fields(instruction)
+		switch strings.ToUpper(fields[0]) {
+		case "ARG":
+			if seenFrom {
+				continue
+			}
+			for _, arg := range fields[1:] {
+				name, value, _ := strings.Cut(arg, "=")
+				args[name] = strings.Trim(value, `"'`)
+			}
+		case "FROM":
+			seenFrom = true
+			fields = fields[1:]
+			for len(fields) > 0 && strings.HasPrefix(fields[0], "--") {
+				fields = fields[1:] // --platform
+			}
+			if len(fields) == 0 {
+				return nil, fmt.Errorf("line %d: FROM without an image", start)
+			}
+			ref := os.Expand(fields[0], func(name string) string { return args[name] })
+			var stage string
+			if len(fields) >= 3 && strings.EqualFold(fields[1], "AS") {
+				stage = fields[2]
+			}
+			if !strings.EqualFold(ref, "scratch") && !stages[strings.ToLower(ref)] {
+				images = append(images, api.BaseImage{Ref: ref, Stage: stage, Line: start, Pinned: Pinned(ref)})
+			}
+			if stage != "" {
+				stages[strings.ToLower(stage)] = true
+			}
+		}
+	}
+	if err := sc.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read Dockerfile: %w", err)
+	}
+	return images, nil
+}
+
+// nextInstruction returns the next instruction of the Dockerfile, its
+// continuation lines joined, and the line it starts on. Comments and blank
+// lines are skipped.
+func nextInstruction(sc *bufio.Scanner, lineNo *int) (string, int, bool) {
+	var b strings.Builder
+	start := 0
+	for sc.Scan() {
+		*lineNo++
+		line := strings.TrimSpace(sc.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			if b.Len() == 0 {
+				continue
+			}
+			// Comments inside a continued instruction are dropped.
+			if strings.HasPrefix(line, "#") {
+				continue
+			}
+		}
+		if start == 0 {
+			start = *lineNo
+		}
+		if cont, ok := strings.CutSuffix(line, `\`); ok {
+			b.WriteString(cont)
+			b.WriteByte(' ')
+			continue
+		}
+		b.WriteString(line)
+		return b.String(), start, true
+	}
+	if b.Len() > 0 {
+		return b.String(), start, true
+	}
+	return "", 0, false
+}
+
+// ErrNoDocker is returned by Inspect when the docker CLI isn't installed.
+var ErrNoDocker = errors.New("docker not found on PATH")
+
+// Inspect returns what `docker image inspect` reports about the local
+// image ref: its config, layers and the digests it was pulled by.
+func Inspect(ref string) (json.RawMessage, error) {
+	docker, err := exec.LookPath("docker")
+	if err != nil {
+		return nil, ErrNoDocker
+	}
+	var stderr bytes.Buffer
+	cmd := exec.Command(docker, "image", "inspect", ref)
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("docker image inspect %s: %s", ref, strings.TrimSpace(stderr.String()))
+	}
+	var images []json.RawMessage
+	if err := json.Unmarshal(out, &images); err != nil || len(images) != 1 {
+		return nil, fmt.Errorf("unexpected output from docker image inspect %s", ref)
+	}
+	return images[0], nil
+}
+
+// RepoDigest returns the first digest reference in inspect output from
+// Inspect, which pins the image, or empty when it has none (a locally
+// built image).
+func RepoDigest(inspect json.RawMessage) string {
+	var config struct {
+		RepoDigests []string `json:"RepoDigests"`
+	}
+	if err := json.Unmarshal(inspect, &config); err != nil || len(config.RepoDigests) == 0 {
+		return ""
+	}
+	return config.RepoDigests[0]
+}
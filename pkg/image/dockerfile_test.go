@@ -0,0 +1,61 @@
+// Copyright (c) Kusari <https://www.kusari.dev/>
+// SPDX-License-Identifier: MIT
+
+package image
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/kusaridev/kusari-cli/v2/api"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testDockerfile = `# syntax=docker/dockerfile:1
+ARG GO_VERSION=1.25
+FROM --platform=$BUILDPLATFORM golang:${GO_VERSION} AS build
+RUN go build \
+    # build the binary
+    -o /app .
+
+FROM build AS test
+RUN go test ./...
+
+from gcr.io/distroless/static@sha256:0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef
+COPY --from=build /app /app
+
+FROM scratch
+FROM \
+  alpine
+`
+
+func TestParseDockerfile(t *testing.T) {
+	images, err := ParseDockerfile(strings.NewReader(testDockerfile))
+	require.NoError(t, err)
+	assert.Equal(t, []api.BaseImage{
+		{Ref: "golang:1.25", Stage: "build", Line: 3},
+		{Ref: "gcr.io/distroless/static@sha256:0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef", Line: 11, Pinned: true},
+		{Ref: "alpine", Line: 15},
+	}, images)
+
+	_, err = ParseDockerfile(strings.NewReader("FROM --platform=linux/amd64\n"))
+	assert.ErrorContains(t, err, "line 1: FROM without an image")
+}
+
+func TestRefs(t *testing.T) {
+	assert.True(t, ValidRef("ghcr.io/org/app:1.4"))
+	assert.True(t, ValidRef("localhost:5000/app@sha256:0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef"))
+	assert.False(t, ValidRef("./Dockerfile"))
+	assert.False(t, ValidRef("app:bad tag"))
+
+	assert.True(t, Tagged("golang:1.25"))
+	assert.False(t, Tagged("localhost:5000/app"))
+	assert.False(t, Tagged("alpine@sha256:abc"))
+}
+
+func TestRepoDigest(t *testing.T) {
+	assert.Equal(t, "golang@sha256:abc", RepoDigest([]byte(`{"Id":"sha256:1","RepoDigests":["golang@sha256:abc"]}`)))
+	assert.Empty(t, RepoDigest([]byte(`{"Id":"sha256:1","RepoDigests":[]}`)))
+	assert.Empty(t, RepoDigest(nil))
+}
@@ -0,0 +1,142 @@
+// Copyright (c) Kusari <https://www.kusari.dev/>
+// SPDX-License-Identifier: MIT
+
+package itsm
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/kusaridev/kusari-cli/v2/api"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func blocked() *api.SecurityAnalysis {
+	return &api.SecurityAnalysis{
+		Justification: "Hardcoded credentials",
+		RequiredCodeMitigations: []api.CodeMitigationItem{
+			{Path: "main.go", LineNumber: 3, Content: "Remove the API key"},
+		},
+		RequiredDependencyMitigations: []api.DependencyMitigationItem{
+			{Content: "Upgrade lodash"},
+		},
+	}
+}
+
+func TestNewTicket(t *testing.T) {
+	ticket := NewTicket(blocked(), "acme/app", "#12", "https://console.kusari.cloud/x")
+	assert.Equal(t, "Kusari: acme/app #12 blocked (2 finding(s))", ticket.Title)
+	assert.Equal(t, "Hardcoded credentials\n\n"+
+		"- [main.go:3] Remove the API key\n"+
+		"- [dependency] Upgrade lodash\n\n"+
+		"Full analysis: https://console.kusari.cloud/x", ticket.Description)
+	assert.Regexp(t, `^kusari-[0-9a-f]{16}$`, ticket.Fingerprint)
+	assert.Equal(t, ticket.Fingerprint, NewTicket(&api.SecurityAnalysis{}, "acme/app", "#12", "").Fingerprint)
+	assert.NotEqual(t, ticket.Fingerprint, NewTicket(blocked(), "acme/app", "#13", "").Fingerprint)
+}
+
+func TestWebhookSend(t *testing.T) {
+	t.Setenv("KUSARI_ITSM_TOKEN", "secret")
+	t.Setenv("KUSARI_CLIENT_SECRET", "client-secret")
+	var payload map[string]any
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "POST", r.Method)
+		assert.Equal(t, "Bearer secret", r.Header.Get("Authorization"))
+		assert.Equal(t, "", r.Header.Get("X-Other"))
+		assert.Equal(t, "application/json", r.Header.Get("Content-Type"))
+		payload = nil
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&payload))
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer server.Close()
+
+	ticket := NewTicket(blocked(), "acme/app", "#12", "")
+
+	hook := Webhook{URL: server.URL, Headers: map[string]string{"Authorization": "Bearer ${KUSARI_ITSM_TOKEN}", "X-Other": "$KUSARI_CLIENT_SECRET"}}
+	require.NoError(t, hook.Send(context.Background(), ticket))
+	assert.Equal(t, ticket.Title, payload["title"])
+	assert.Len(t, payload["findings"], 2)
+
+	hook.Template = `{"summary": {{json .Title}}, "priority": "P2", "count": {{len .Findings}}}`
+	require.NoError(t, hook.Send(context.Background(), ticket))
+	assert.Equal(t, map[string]any{"summary": ticket.Title, "priority": "P2", "count": float64(2)}, payload)
+
+	hook.Template = `{"summary": {{.Title}}}`
+	require.ErrorContains(t, hook.Send(context.Background(), ticket), "did not produce valid JSON")
+
+	hook.Template = `{"summary": {{json .Nope}}}`
+	require.ErrorContains(t, hook.Send(context.Background(), ticket), "failed to render ticket webhook template")
+}
+
+func TestServiceNowFile(t *testing.T) {
+	var calls []string
+	var record map[string]any
+	existing := `{"result": []}`
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls = append(calls, r.Method+" "+r.URL.Path)
+		user, pass, ok := r.BasicAuth()
+		assert.True(t, ok)
+		assert.Equal(t, "kusari", user)
+		assert.Equal(t, "pw", pass)
+		assert.Equal(t, "/api/now/table/change_request", r.URL.Path)
+
+		if r.Method == "GET" {
+			assert.Contains(t, r.URL.Query().Get("sysparm_query"), "active=true^correlation_id=kusari-")
+			_, _ = io.WriteString(w, existing)
+			return
+		}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&record))
+		w.WriteHeader(http.StatusCreated)
+		_, _ = io.WriteString(w, `{"result": {"sys_id": "abc", "number": "CHG0001"}}`)
+	}))
+	defer server.Close()
+
+	t.Setenv(EnvServiceNowUser, "kusari")
+	t.Setenv(EnvServiceNowPassword, "pw")
+	t.Setenv(EnvServiceNowToken, "")
+	sn, err := NewServiceNowFromEnv(server.URL+"/", "change_request", map[string]any{
+		"short_description": "[Security] {{.Repository}} {{.Change}}",
+		"assignment_group":  "AppSec",
+		"impact":            2,
+	})
+	require.NoError(t, err)
+
+	ticket := NewTicket(blocked(), "acme/app", "#12", "")
+	number, created, err := sn.File(context.Background(), ticket)
+	require.NoError(t, err)
+	assert.True(t, created)
+	assert.Equal(t, "CHG0001", number)
+	assert.Equal(t, map[string]any{
+		"short_description":   "[Security] acme/app #12",
+		"description":         ticket.Description,
+		"correlation_id":      ticket.Fingerprint,
+		"correlation_display": "Kusari",
+		"assignment_group":    "AppSec",
+		"impact":              float64(2),
+	}, record)
+
+	// A re-scan of the same change finds the open record
+	existing = `{"result": [{"sys_id": "abc", "number": "CHG0001"}]}`
+	number, created, err = sn.File(context.Background(), ticket)
+	require.NoError(t, err)
+	assert.False(t, created)
+	assert.Equal(t, "CHG0001", number)
+	assert.Equal(t, []string{
+		"GET /api/now/table/change_request",
+		"POST /api/now/table/change_request",
+		"GET /api/now/table/change_request",
+	}, calls)
+}
+
+func TestNewServiceNowFromEnvNoCredentials(t *testing.T) {
+	t.Setenv(EnvServiceNowUser, "kusari")
+	t.Setenv(EnvServiceNowPassword, "")
+	t.Setenv(EnvServiceNowToken, "")
+	_, err := NewServiceNowFromEnv("https://acme.service-now.com", "incident", nil)
+	require.ErrorContains(t, err, "no ServiceNow credentials found")
+}
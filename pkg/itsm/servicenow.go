@@ -0,0 +1,209 @@
+// Copyright (c) Kusari <https://www.kusari.dev/>
+// SPDX-License-Identifier: MIT
+
+package itsm
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/kusaridev/kusari-cli/v2/pkg/readonly"
+	"github.com/kusaridev/kusari-cli/v2/pkg/transport"
+)
+
+// Environment variables holding ServiceNow credentials: a user and
+// password for basic auth, or an OAuth token.
+const (
+	EnvServiceNowUser     = "SERVICENOW_USER"
+	EnvServiceNowPassword = "SERVICENOW_PASSWORD"
+	EnvServiceNowToken    = "SERVICENOW_TOKEN"
+)
+
+// defaultServiceNowFields are set on every record unless overridden. The
+// correlation ID is what a later run looks the record up by.
+var defaultServiceNowFields = map[string]any{
+	"short_description":   "{{.Title}}",
+	"description":         "{{.Description}}",
+	"correlation_id":      "{{.Fingerprint}}",
+	"correlation_display": "Kusari",
+}
+
+// ServiceNow files records through the Table API.
+type ServiceNow struct {
+	// URL is the instance, e.g. https://acme.service-now.com.
+	URL string
+	// Table is the record table, e.g. incident or change_request.
+	Table string
+	// Fields are set on the record, over the defaults. String values are
+	// templates rendered against the ticket; other values are sent as is.
+	Fields   map[string]any
+	User     string
+	Password string
+	Token    string
+}
+
+// NewServiceNowFromEnv returns a client for the instance at instanceURL
+// with credentials from the environment.
+func NewServiceNowFromEnv(instanceURL, table string, fields map[string]any) (ServiceNow, error) {
+	sn := ServiceNow{
+		URL:      strings.TrimSuffix(instanceURL, "/"),
+		Table:    table,
+		Fields:   fields,
+		User:     os.Getenv(EnvServiceNowUser),
+		Password: os.Getenv(EnvServiceNowPassword),
+		Token:    os.Getenv(EnvServiceNowToken),
+	}
+	if sn.Token == "" && (sn.User == "" || sn.Password == "") {
+		return sn, fmt.Errorf("no ServiceNow credentials found (set %s, or %s and %s)", EnvServiceNowToken, EnvServiceNowUser, EnvServiceNowPassword)
+	}
+	return sn, nil
+}
+
+// snRecord is a ServiceNow record as returned by the Table API
+type snRecord struct {
+	SysID  string `json:"sys_id"`
+	Number string `json:"number"`
+}
+
+// File files a record for t and returns its number, unless an active
+// record with the same correlation ID exists, in which case that record's
+// number is returned with created false.
+func (sn ServiceNow) File(ctx context.Context, t Ticket) (number string, created bool, err error) {
+	existing, err := sn.findActive(ctx, t.Fingerprint)
+	if err != nil {
+		return "", false, fmt.Errorf("failed to search ServiceNow: %w", err)
+	}
+	if existing != nil {
+		return existing.Number, false, nil
+	}
+
+	fields, err := sn.fields(t)
+	if err != nil {
+		return "", false, err
+	}
+	var result struct {
+		Result snRecord `json:"result"`
+	}
+	if err := sn.do(ctx, "POST", sn.tableURL(nil), fields, &result); err != nil {
+		return "", false, fmt.Errorf("failed to create ServiceNow record: %w", err)
+	}
+	return result.Result.Number, true, nil
+}
+
+// fields renders the record fields for t.
+func (sn ServiceNow) fields(t Ticket) (map[string]any, error) {
+	merged := map[string]any{}
+	for k, v := range defaultServiceNowFields {
+		merged[k] = v
+	}
+	for k, v := range sn.Fields {
+		merged[k] = v
+	}
+
+	// Render in a stable order so the first error is always the same one
+	keys := make([]string, 0, len(merged))
+	for k := range merged {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		s, ok := merged[k].(string)
+		if !ok {
+			continue
+		}
+		v, err := render("servicenow field "+k, s, t)
+		if err != nil {
+			return nil, err
+		}
+		merged[k] = v
+	}
+	return merged, nil
+}
+
+// findActive returns the active record with the given correlation ID, or
+// nil if there is none.
+func (sn ServiceNow) findActive(ctx context.Context, correlationID string) (*snRecord, error) {
+	query := url.Values{}
+	query.Set("sysparm_query", "active=true^correlation_id="+correlationID)
+	query.Set("sysparm_fields", "sys_id,number")
+	query.Set("sysparm_limit", "1")
+
+	var result struct {
+		Result []snRecord `json:"result"`
+	}
+	if err := sn.do(ctx, "GET", sn.tableURL(query), nil, &result); err != nil {
+		return nil, err
+	}
+	if len(result.Result) == 0 {
+		return nil, nil
+	}
+	return &result.Result[0], nil
+}
+
+func (sn ServiceNow) tableURL(query url.Values) string {
+	endpoint := fmt.Sprintf("%s/api/now/table/%s", sn.URL, url.PathEscape(sn.Table))
+	if len(query) > 0 {
+		endpoint += "?" + query.Encode()
+	}
+	return endpoint
+}
+
+func (sn ServiceNow) do(ctx context.Context, method, endpoint string, body, out any) error {
+	if method != "GET" {
+		if err := readonly.Check("file ServiceNow records"); err != nil {
+			return err
+		}
+	}
+	var reqBody io.Reader
+	if body != nil {
+		jsonBody, err := json.Marshal(body)
+		if err != nil {
+			return fmt.Errorf("failed to marshal request: %w", err)
+		}
+		reqBody = bytes.NewBuffer(jsonBody)
+	}
+
+	client := &http.Client{Timeout: transport.Timeout(30 * time.Second)}
+	req, err := http.NewRequestWithContext(ctx, method, endpoint, reqBody)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+
+	if sn.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+sn.Token)
+	} else {
+		req.SetBasicAuth(sn.User, sn.Password)
+	}
+	req.Header.Set("Accept", "application/json")
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("request failed: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		respBody, _ := io.ReadAll(resp.Body)
+		if resp.StatusCode == http.StatusForbidden {
+			return fmt.Errorf("ServiceNow API returned status 403: %s (check that the user has a role that can write to the table, e.g. itil)", string(respBody))
+		}
+		return fmt.Errorf("ServiceNow API returned status %d: %s", resp.StatusCode, string(respBody))
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("failed to decode response: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,106 @@
+// Copyright (c) Kusari <https://www.kusari.dev/>
+// SPDX-License-Identifier: MIT
+
+// Package itsm files IT service management records (ServiceNow incidents
+// or change requests, or any system behind a webhook) for changes Kusari
+// Inspector blocks, so they enter the same approval and incident process as
+// other production risks.
+package itsm
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"text/template"
+
+	"github.com/kusaridev/kusari-cli/v2/api"
+	"github.com/kusaridev/kusari-cli/v2/pkg/results"
+)
+
+// Ticket is what templates render a record from.
+type Ticket struct {
+	// Title is a one-line summary, e.g. "Kusari: acme/app !12 blocked".
+	Title string `json:"title"`
+	// Description lists the justification and findings as plain text.
+	Description string `json:"description"`
+	// Fingerprint identifies the change across runs, so a re-scan of the
+	// same pull request can be matched to the record it filed.
+	Fingerprint string `json:"fingerprint"`
+	// Repository and Change name what was scanned, e.g. "acme/app" and
+	// "#12" (GitHub) or "!12" (GitLab). Either may be empty outside CI.
+	Repository string `json:"repository"`
+	Change     string `json:"change"`
+	ConsoleURL string `json:"console_url"`
+
+	Analysis *api.SecurityAnalysis `json:"analysis"`
+	Findings []results.Row         `json:"findings"`
+}
+
+// NewTicket builds the ticket for a blocked analysis.
+func NewTicket(analysis *api.SecurityAnalysis, repository, change, consoleURL string) Ticket {
+	res := &results.Result{Analysis: analysis, ConsoleURL: consoleURL}
+	t := Ticket{
+		Repository: repository,
+		Change:     change,
+		ConsoleURL: consoleURL,
+		Analysis:   analysis,
+		Findings:   res.Rows(),
+	}
+
+	subject := strings.TrimSpace(repository + " " + change)
+	if subject == "" {
+		subject = "change"
+	}
+	t.Title = fmt.Sprintf("Kusari: %s blocked (%d finding(s))", subject, len(t.Findings))
+
+	sum := sha256.Sum256([]byte(repository + "\x00" + change))
+	t.Fingerprint = "kusari-" + hex.EncodeToString(sum[:8])
+
+	var sb strings.Builder
+	if analysis.Justification != "" {
+		fmt.Fprintf(&sb, "%s\n\n", analysis.Justification)
+	}
+	for _, r := range t.Findings {
+		loc := r.Type
+		if r.Path != "" {
+			loc = r.Path
+			if r.Line > 0 {
+				loc = fmt.Sprintf("%s:%d", r.Path, r.Line)
+			}
+		}
+		fmt.Fprintf(&sb, "- [%s] %s\n", loc, r.Content)
+	}
+	if analysis.Recommendation != "" {
+		fmt.Fprintf(&sb, "\nRecommendation: %s\n", analysis.Recommendation)
+	}
+	if consoleURL != "" {
+		fmt.Fprintf(&sb, "\nFull analysis: %s\n", consoleURL)
+	}
+	t.Description = strings.TrimRight(sb.String(), "\n")
+	return t
+}
+
+// templateFuncs are available to payload and field templates. json quotes
+// a value for use inside a JSON document.
+var templateFuncs = template.FuncMap{
+	"json": func(v any) (string, error) {
+		b, err := json.Marshal(v)
+		return string(b), err
+	},
+}
+
+// render executes the template text against t.
+func render(name, text string, t Ticket) (string, error) {
+	tmpl, err := template.New(name).Funcs(templateFuncs).Option("missingkey=error").Parse(text)
+	if err != nil {
+		return "", fmt.Errorf("invalid %s template: %w", name, err)
+	}
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, t); err != nil {
+		return "", fmt.Errorf("failed to render %s template: %w", name, err)
+	}
+	return buf.String(), nil
+}
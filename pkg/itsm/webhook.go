@@ -0,0 +1,100 @@
+// Copyright (c) Kusari <https://www.kusari.dev/>
+// SPDX-License-Identifier: MIT
+
+package itsm
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/kusaridev/kusari-cli/v2/pkg/readonly"
+	"github.com/kusaridev/kusari-cli/v2/pkg/transport"
+)
+
+// HeaderEnvPrefix is the prefix of the environment variables webhook
+// header values may reference.
+const HeaderEnvPrefix = "KUSARI_ITSM_"
+
+// DefaultWebhookTemplate is the payload sent when no template is set.
+const DefaultWebhookTemplate = `{
+  "title": {{json .Title}},
+  "description": {{json .Description}},
+  "fingerprint": {{json .Fingerprint}},
+  "repository": {{json .Repository}},
+  "change": {{json .Change}},
+  "console_url": {{json .ConsoleURL}},
+  "findings": {{json .Findings}}
+}`
+
+// Webhook posts a JSON payload rendered from a template, for ticketing
+// systems without a dedicated integration.
+type Webhook struct {
+	URL string
+	// Headers are sent with the request. Values may reference environment
+	// variables named with HeaderEnvPrefix as $VAR or ${VAR}, to keep
+	// credentials off the command line; other references expand to nothing.
+	Headers map[string]string
+	// Template is a text/template producing the JSON payload. Empty means
+	// DefaultWebhookTemplate.
+	Template string
+}
+
+// Send posts the payload for t.
+func (w Webhook) Send(ctx context.Context, t Ticket) error {
+	if err := readonly.Check("send a ticket webhook"); err != nil {
+		return err
+	}
+	tmpl := w.Template
+	if tmpl == "" {
+		tmpl = DefaultWebhookTemplate
+	}
+	payload, err := render("ticket webhook", tmpl, t)
+	if err != nil {
+		return err
+	}
+	if !json.Valid([]byte(payload)) {
+		return fmt.Errorf("ticket webhook template did not produce valid JSON (use {{json .Field}} to quote values)")
+	}
+
+	client := &http.Client{Timeout: transport.Timeout(30 * time.Second)}
+	req, err := http.NewRequestWithContext(ctx, "POST", w.URL, bytes.NewBufferString(payload))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range w.Headers {
+		req.Header.Set(k, expandHeader(v))
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("request failed: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		respBody, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("ticket webhook returned status %d: %s", resp.StatusCode, string(respBody))
+	}
+	return nil
+}
+
+// expandHeader expands the references in v to environment variables named
+// with HeaderEnvPrefix, and only those, so a header can't be made to carry
+// any other secret of the environment.
+func expandHeader(v string) string {
+	return os.Expand(v, func(name string) string {
+		if !strings.HasPrefix(name, HeaderEnvPrefix) {
+			return ""
+		}
+		return os.Getenv(name)
+	})
+}
@@ -0,0 +1,185 @@
+// Copyright (c) Kusari <https://www.kusari.dev/>
+// SPDX-License-Identifier: MIT
+
+// Package jira files Kusari findings as Jira tickets, so security teams can
+// track remediation in Jira rather than in PR comments.
+package jira
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/kusaridev/kusari-cli/v2/pkg/readonly"
+	"github.com/kusaridev/kusari-cli/v2/pkg/transport"
+)
+
+// Environment variables holding Jira credentials. With JIRA_USER set, the
+// token is a Jira Cloud API token used with basic auth; without it, it is a
+// Jira Data Center personal access token sent as a bearer token.
+const (
+	EnvURL   = "JIRA_URL"
+	EnvUser  = "JIRA_USER"
+	EnvToken = "JIRA_API_TOKEN"
+)
+
+// errNotFound is returned for 404 responses.
+var errNotFound = errors.New("not found")
+
+// Client handles HTTP requests to the Jira REST API (v2, which Jira Cloud
+// and Data Center both serve).
+type Client struct {
+	baseURL    string
+	user       string
+	token      string
+	httpClient *http.Client
+}
+
+// NewClient creates a new Jira API client for the site at baseURL (e.g.,
+// "https://acme.atlassian.net").
+func NewClient(baseURL, user, token string) *Client {
+	return &Client{
+		baseURL: strings.TrimSuffix(baseURL, "/"),
+		user:    user,
+		token:   token,
+		httpClient: &http.Client{
+			Timeout: transport.Timeout(30 * time.Second),
+		},
+	}
+}
+
+// NewClientFromEnv creates a client from JIRA_USER and JIRA_API_TOKEN.
+// baseURL defaults to JIRA_URL.
+func NewClientFromEnv(baseURL string) (*Client, error) {
+	if baseURL == "" {
+		baseURL = os.Getenv(EnvURL)
+	}
+	if baseURL == "" {
+		return nil, fmt.Errorf("no Jira URL (set --jira-url or %s)", EnvURL)
+	}
+	token := os.Getenv(EnvToken)
+	if token == "" {
+		return nil, fmt.Errorf("no Jira token found (set %s)", EnvToken)
+	}
+	return NewClient(baseURL, os.Getenv(EnvUser), token), nil
+}
+
+// makeRequest makes an HTTP request to the Jira API and decodes the JSON
+// response into out, if not nil.
+func (c *Client) makeRequest(ctx context.Context, method, path string, params url.Values, body, out any) error {
+	if method != "GET" {
+		if err := readonly.Check("file or update Jira issues"); err != nil {
+			return err
+		}
+	}
+	reqURL := c.baseURL + path
+	if len(params) > 0 {
+		reqURL += "?" + params.Encode()
+	}
+
+	var reqBody io.Reader
+	if body != nil {
+		jsonData, err := json.Marshal(body)
+		if err != nil {
+			return fmt.Errorf("failed to marshal request body: %w", err)
+		}
+		reqBody = bytes.NewBuffer(jsonData)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, reqURL, reqBody)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+
+	if c.user != "" {
+		req.SetBasicAuth(c.user, c.token)
+	} else {
+		req.Header.Set("Authorization", "Bearer "+c.token)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("request failed: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("failed to read response body: %w", err)
+	}
+
+	if resp.StatusCode == http.StatusNotFound {
+		return fmt.Errorf("Jira API request failed with status %d: %s: %w", resp.StatusCode, string(respBody), errNotFound)
+	}
+	if resp.StatusCode == http.StatusForbidden {
+		return fmt.Errorf("Jira API request failed with status 403: %s (check that the account has the Browse Projects and Create Issues permissions in the project)", string(respBody))
+	}
+	if resp.StatusCode >= 400 {
+		return fmt.Errorf("Jira API request failed with status %d: %s", resp.StatusCode, string(respBody))
+	}
+
+	if out != nil && len(respBody) > 0 {
+		if err := json.Unmarshal(respBody, out); err != nil {
+			return fmt.Errorf("failed to decode response: %w", err)
+		}
+	}
+	return nil
+}
+
+// Issue is a Jira issue as returned by search.
+type Issue struct {
+	Key string `json:"key"`
+}
+
+// Search returns the issues matching jql, up to 50.
+func (c *Client) Search(ctx context.Context, jql string) ([]Issue, error) {
+	params := url.Values{}
+	params.Set("jql", jql)
+	params.Set("fields", "key")
+	params.Set("maxResults", "50")
+
+	var result struct {
+		Issues []Issue `json:"issues"`
+	}
+	// Jira Cloud replaced /search with /search/jql; Data Center only has
+	// the former
+	err := c.makeRequest(ctx, "GET", "/rest/api/2/search/jql", params, nil, &result)
+	if errors.Is(err, errNotFound) {
+		err = c.makeRequest(ctx, "GET", "/rest/api/2/search", params, nil, &result)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return result.Issues, nil
+}
+
+// CreateIssue creates an issue with the given fields and returns its key.
+func (c *Client) CreateIssue(ctx context.Context, fields map[string]any) (string, error) {
+	var result struct {
+		Key string `json:"key"`
+	}
+	if err := c.makeRequest(ctx, "POST", "/rest/api/2/issue", nil, map[string]any{"fields": fields}, &result); err != nil {
+		return "", err
+	}
+	return result.Key, nil
+}
+
+// UpdateIssue sets the given fields of the issue with key.
+func (c *Client) UpdateIssue(ctx context.Context, key string, fields map[string]any) error {
+	return c.makeRequest(ctx, "PUT", "/rest/api/2/issue/"+url.PathEscape(key), nil, map[string]any{"fields": fields}, nil)
+}
+
+// BrowseURL returns the web URL of the issue with key.
+func (c *Client) BrowseURL(key string) string {
+	return c.baseURL + "/browse/" + key
+}
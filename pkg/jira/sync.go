@@ -0,0 +1,125 @@
+// Copyright (c) Kusari <https://www.kusari.dev/>
+// SPDX-License-Identifier: MIT
+
+package jira
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/kusaridev/kusari-cli/v2/pkg/results"
+)
+
+// SyncOptions holds the configuration for filing tickets in Jira.
+type SyncOptions struct {
+	ProjectKey string
+	IssueType  string
+	// Labels are added to every ticket, next to its fingerprint label.
+	Labels []string
+	// CustomFields are set on new tickets, keyed by field ID.
+	CustomFields map[string]any
+	Verbose      bool
+}
+
+// Fingerprint returns the label identifying the ticket for an issue key.
+// Jira labels cannot contain spaces, and colons read poorly in JQL.
+func Fingerprint(key string) string {
+	return "kusari-" + strings.ReplaceAll(key, ":", "-")
+}
+
+// Sync files a ticket for each issue in the project, or updates the
+// summary and description of the ticket filed by an earlier run, found by
+// its fingerprint label. It returns the URLs of the created and updated
+// tickets.
+func (c *Client) Sync(ctx context.Context, issues []results.Issue, opts SyncOptions) (created, updated []string, err error) {
+	if opts.ProjectKey == "" {
+		return nil, nil, fmt.Errorf("no Jira project (set jira_project_key in kusari.yaml)")
+	}
+
+	for _, i := range issues {
+		label := Fingerprint(i.Key)
+		description := ToWiki(i.Body)
+
+		existing, err := c.Search(ctx, fmt.Sprintf("project = %q AND labels = %q", opts.ProjectKey, label))
+		if err != nil {
+			return created, updated, fmt.Errorf("failed to search Jira: %w", err)
+		}
+
+		if len(existing) > 0 {
+			key := existing[0].Key
+			if opts.Verbose {
+				fmt.Fprintf(os.Stderr, "Updating %s for %q\n", key, i.Title)
+			}
+			if err := c.UpdateIssue(ctx, key, map[string]any{
+				"summary":     i.Title,
+				"description": description,
+			}); err != nil {
+				return created, updated, fmt.Errorf("failed to update Jira issue %s: %w", key, err)
+			}
+			updated = append(updated, c.BrowseURL(key))
+			continue
+		}
+
+		fields := map[string]any{}
+		for k, v := range opts.CustomFields {
+			fields[k] = v
+		}
+		fields["project"] = map[string]string{"key": opts.ProjectKey}
+		fields["issuetype"] = map[string]string{"name": opts.IssueType}
+		fields["summary"] = i.Title
+		fields["description"] = description
+		fields["labels"] = append(append([]string{}, opts.Labels...), label)
+
+		key, err := c.CreateIssue(ctx, fields)
+		if err != nil {
+			return created, updated, fmt.Errorf("failed to create Jira issue: %w", err)
+		}
+		created = append(created, c.BrowseURL(key))
+	}
+	return created, updated, nil
+}
+
+var (
+	markerRegex = regexp.MustCompile(`<!--.*?-->`)
+	boldRegex   = regexp.MustCompile(`\*\*(.+?)\*\*`)
+	linkRegex   = regexp.MustCompile(`\[([^\]]+)\]\(([^)\s]+)\)`)
+	monoRegex   = regexp.MustCompile("`([^`]+)`")
+)
+
+// ToWiki converts the markdown of an issue body to Jira wiki markup, which
+// the v2 API expects in descriptions. Only the constructs used by
+// results.Issue bodies are handled.
+func ToWiki(md string) string {
+	md = markerRegex.ReplaceAllString(md, "")
+
+	var sb strings.Builder
+	inCode := false
+	for _, line := range strings.Split(strings.TrimRight(md, "\n"), "\n") {
+		if strings.HasPrefix(line, "```") {
+			inCode = !inCode
+			sb.WriteString("{code}\n")
+			continue
+		}
+		if inCode {
+			sb.WriteString(line + "\n")
+			continue
+		}
+		for n := 6; n >= 1; n-- {
+			if prefix := strings.Repeat("#", n) + " "; strings.HasPrefix(line, prefix) {
+				line = fmt.Sprintf("h%d. %s", n, strings.TrimPrefix(line, prefix))
+				break
+			}
+		}
+		if strings.HasPrefix(line, "- ") {
+			line = "* " + strings.TrimPrefix(line, "- ")
+		}
+		line = boldRegex.ReplaceAllString(line, "*$1*")
+		line = linkRegex.ReplaceAllString(line, "[$1|$2]")
+		line = monoRegex.ReplaceAllString(line, "{{$1}}")
+		sb.WriteString(line + "\n")
+	}
+	return strings.TrimRight(sb.String(), "\n")
+}
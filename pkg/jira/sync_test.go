@@ -0,0 +1,117 @@
+// Copyright (c) Kusari <https://www.kusari.dev/>
+// SPDX-License-Identifier: MIT
+
+package jira
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/kusaridev/kusari-cli/v2/pkg/results"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSync(t *testing.T) {
+	var calls []string
+	var createdFields, updatedFields map[string]any
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls = append(calls, r.Method+" "+r.URL.Path)
+		user, pass, ok := r.BasicAuth()
+		assert.True(t, ok)
+		assert.Equal(t, "me@acme.com", user)
+		assert.Equal(t, "token", pass)
+
+		switch {
+		case r.URL.Path == "/rest/api/2/search/jql":
+			// Data Center: only the old search endpoint exists
+			w.WriteHeader(http.StatusNotFound)
+		case r.URL.Path == "/rest/api/2/search":
+			if strings.Contains(r.URL.Query().Get("jql"), `labels = "kusari-finding-aaaa"`) {
+				_, _ = w.Write([]byte(`{"issues": [{"key": "SEC-1"}]}`))
+				return
+			}
+			_, _ = w.Write([]byte(`{"issues": []}`))
+		case r.Method == "PUT" && r.URL.Path == "/rest/api/2/issue/SEC-1":
+			var body struct{ Fields map[string]any }
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+			updatedFields = body.Fields
+			w.WriteHeader(http.StatusNoContent)
+		case r.Method == "POST" && r.URL.Path == "/rest/api/2/issue":
+			var body struct{ Fields map[string]any }
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+			createdFields = body.Fields
+			w.WriteHeader(http.StatusCreated)
+			_, _ = w.Write([]byte(`{"key": "SEC-2"}`))
+		default:
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+		}
+	}))
+	defer server.Close()
+
+	issues := []results.Issue{
+		{Key: "finding:aaaa", Title: "Kusari: tracked", Body: "### Tracked\n\n" + results.IssueMarker("finding:aaaa")},
+		{Key: "finding:bbbb", Title: "Kusari: new", Body: "### New\n\n" + results.IssueMarker("finding:bbbb")},
+	}
+	client := NewClient(server.URL+"/", "me@acme.com", "token")
+	created, updated, err := client.Sync(context.Background(), issues, SyncOptions{
+		ProjectKey:   "SEC",
+		IssueType:    "Bug",
+		Labels:       []string{"kusari"},
+		CustomFields: map[string]any{"customfield_10010": map[string]any{"value": "High"}},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []string{server.URL + "/browse/SEC-2"}, created)
+	assert.Equal(t, []string{server.URL + "/browse/SEC-1"}, updated)
+	assert.Equal(t, []string{
+		"GET /rest/api/2/search/jql",
+		"GET /rest/api/2/search",
+		"PUT /rest/api/2/issue/SEC-1",
+		"GET /rest/api/2/search/jql",
+		"GET /rest/api/2/search",
+		"POST /rest/api/2/issue",
+	}, calls)
+
+	assert.Equal(t, map[string]any{"summary": "Kusari: tracked", "description": "h3. Tracked"}, updatedFields)
+	assert.Equal(t, map[string]any{
+		"project":           map[string]any{"key": "SEC"},
+		"issuetype":         map[string]any{"name": "Bug"},
+		"summary":           "Kusari: new",
+		"description":       "h3. New",
+		"labels":            []any{"kusari", "kusari-finding-bbbb"},
+		"customfield_10010": map[string]any{"value": "High"},
+	}, createdFields)
+}
+
+func TestSyncBearerToken(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "Bearer pat", r.Header.Get("Authorization"))
+		w.WriteHeader(http.StatusUnauthorized)
+		_, _ = w.Write([]byte(`{"errorMessages": ["unauthorized"]}`))
+	}))
+	defer server.Close()
+
+	_, _, err := NewClient(server.URL, "", "pat").Sync(context.Background(), []results.Issue{{Key: "k", Title: "t"}}, SyncOptions{ProjectKey: "SEC"})
+	require.ErrorContains(t, err, "status 401")
+}
+
+func TestToWiki(t *testing.T) {
+	md := "### Hardcoded secret\n\n" +
+		"- **Location:** main.go:3\n" +
+		"- **Potential Code Fix:**\n\n" +
+		"```\nkey := os.Getenv(\"KEY\") // **not bold**\n```\n\n" +
+		"[View the full analysis in the Kusari console](https://console.kusari.cloud/x)\n\n" +
+		"_Opened by `kusari results create-issues`._\n\n" +
+		results.IssueMarker("finding:aaaa")
+
+	assert.Equal(t, "h3. Hardcoded secret\n\n"+
+		"* *Location:* main.go:3\n"+
+		"* *Potential Code Fix:*\n\n"+
+		"{code}\nkey := os.Getenv(\"KEY\") // **not bold**\n{code}\n\n"+
+		"[View the full analysis in the Kusari console|https://console.kusari.cloud/x]\n\n"+
+		"_Opened by {{kusari results create-issues}}._", ToWiki(md))
+}
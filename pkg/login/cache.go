@@ -0,0 +1,137 @@
+// Copyright (c) Kusari <https://www.kusari.dev/>
+// SPDX-License-Identifier: MIT
+
+package login
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/kusaridev/kusari-cli/v2/pkg/auth"
+	"github.com/kusaridev/kusari-cli/v2/pkg/output"
+)
+
+const (
+	workspaceCacheFileName = "workspaces-cache.json"
+
+	// WorkspaceCacheTTLEnv overrides DefaultWorkspaceCacheTTL (a Go
+	// duration; 0 disables the cache).
+	WorkspaceCacheTTLEnv = "KUSARI_WORKSPACE_CACHE_TTL"
+	// DefaultWorkspaceCacheTTL is how long a fetched workspace list is reused.
+	DefaultWorkspaceCacheTTL = 10 * time.Minute
+)
+
+// workspaceCacheEntry is one cached FetchWorkspaces result.
+type workspaceCacheEntry struct {
+	FetchedAt        time.Time           `json:"fetchedAt"`
+	Workspaces       []Workspace         `json:"workspaces"`
+	WorkspaceTenants map[string][]string `json:"workspaceTenants"`
+}
+
+// FetchWorkspacesCached returns the workspace list from the local cache
+// when it is younger than the TTL, and otherwise fetches and caches it.
+func FetchWorkspacesCached(platformUrl string, accessToken string) ([]Workspace, map[string][]string, error) {
+	ttl := workspaceCacheTTL()
+	if ttl > 0 {
+		if entry, ok := loadWorkspaceCache()[workspaceCacheKey(platformUrl, accessToken)]; ok && time.Since(entry.FetchedAt) < ttl {
+			output.Debug("using cached workspaces", "fetchedAt", entry.FetchedAt)
+			return entry.Workspaces, entry.WorkspaceTenants, nil
+		}
+	}
+	return FetchWorkspaces(platformUrl, accessToken)
+}
+
+// ClearWorkspaceCache removes all cached workspace lists.
+func ClearWorkspaceCache() error {
+	path, err := workspaceCachePath()
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+func workspaceCacheTTL() time.Duration {
+	if v := os.Getenv(WorkspaceCacheTTLEnv); v != "" {
+		if ttl, err := time.ParseDuration(v); err == nil {
+			return ttl
+		}
+		output.Debug("ignoring invalid workspace cache TTL", "value", v)
+	}
+	return DefaultWorkspaceCacheTTL
+}
+
+// workspaceCacheKey scopes entries to the platform and the credential, so
+// a different login or API key never sees another identity's workspaces.
+// Only a hash of the token is stored.
+func workspaceCacheKey(platformUrl, accessToken string) string {
+	sum := sha256.Sum256([]byte(accessToken))
+	return strings.TrimSuffix(platformUrl, "/") + "|" + hex.EncodeToString(sum[:16])
+}
+
+func workspaceCachePath() (string, error) {
+	dir, err := auth.ConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, workspaceCacheFileName), nil
+}
+
+// loadWorkspaceCache returns the cache contents; a missing or unreadable
+// cache is treated as empty.
+func loadWorkspaceCache() map[string]workspaceCacheEntry {
+	cache := make(map[string]workspaceCacheEntry)
+	path, err := workspaceCachePath()
+	if err != nil {
+		return cache
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return cache
+	}
+	_ = json.Unmarshal(data, &cache)
+	return cache
+}
+
+// storeWorkspaceCache records a fresh result, pruning expired entries.
+// Failures are not fatal: the cache is only an optimization.
+func storeWorkspaceCache(platformUrl, accessToken string, workspaces []Workspace, workspaceTenants map[string][]string) {
+	ttl := workspaceCacheTTL()
+	if ttl <= 0 {
+		return
+	}
+
+	cache := loadWorkspaceCache()
+	for key, entry := range cache {
+		if time.Since(entry.FetchedAt) >= ttl {
+			delete(cache, key)
+		}
+	}
+	cache[workspaceCacheKey(platformUrl, accessToken)] = workspaceCacheEntry{
+		FetchedAt:        time.Now(),
+		Workspaces:       workspaces,
+		WorkspaceTenants: workspaceTenants,
+	}
+
+	path, err := workspaceCachePath()
+	if err != nil {
+		return
+	}
+	data, err := json.MarshalIndent(cache, "", "  ")
+	if err != nil {
+		return
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return
+	}
+	if err := os.WriteFile(path, data, 0600); err != nil {
+		output.Debug("failed to write workspace cache", "error", err)
+	}
+}
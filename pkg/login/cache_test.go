@@ -0,0 +1,91 @@
+// Copyright (c) Kusari <https://www.kusari.dev/>
+// SPDX-License-Identifier: MIT
+
+package login
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"github.com/kusaridev/kusari-cli/v2/pkg/auth"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFetchWorkspacesCached(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv(WorkspaceCacheTTLEnv, "")
+
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{
+			"workspaces":       []Workspace{{ID: "ws1", Description: "One"}},
+			"workspaceTenants": map[string][]string{"ws1": {"demo"}},
+		})
+	}))
+	defer server.Close()
+
+	for range 2 {
+		workspaces, tenants, err := FetchWorkspacesCached(server.URL, "token-a")
+		require.NoError(t, err)
+		assert.Equal(t, "ws1", workspaces[0].ID)
+		assert.Equal(t, []string{"demo"}, tenants["ws1"])
+	}
+	assert.Equal(t, int32(1), calls.Load())
+
+	// A different credential doesn't share the entry.
+	_, _, err := FetchWorkspacesCached(server.URL, "token-b")
+	require.NoError(t, err)
+	assert.Equal(t, int32(2), calls.Load())
+
+	require.NoError(t, ClearWorkspaceCache())
+	_, _, err = FetchWorkspacesCached(server.URL, "token-a")
+	require.NoError(t, err)
+	assert.Equal(t, int32(3), calls.Load())
+
+	t.Setenv(WorkspaceCacheTTLEnv, "0")
+	_, _, err = FetchWorkspacesCached(server.URL, "token-a")
+	require.NoError(t, err)
+	assert.Equal(t, int32(4), calls.Load())
+}
+
+func TestDefaultWorkspace(t *testing.T) {
+	workspaces := []Workspace{{ID: "ws1", Description: "One"}, {ID: "ws2", Description: "Two"}}
+
+	t.Setenv(auth.WorkspaceEnv, "")
+	ws, err := DefaultWorkspace(workspaces)
+	require.NoError(t, err)
+	assert.Equal(t, "ws1", ws.ID)
+
+	t.Setenv(auth.WorkspaceEnv, "ws2")
+	ws, err = DefaultWorkspace(workspaces)
+	require.NoError(t, err)
+	assert.Equal(t, "ws2", ws.ID)
+
+	t.Setenv(auth.WorkspaceEnv, "missing")
+	_, err = DefaultWorkspace(workspaces)
+	assert.ErrorContains(t, err, auth.WorkspaceEnv)
+
+	_, err = DefaultWorkspace(nil)
+	assert.Error(t, err)
+}
+
+func TestDefaultTenant(t *testing.T) {
+	t.Setenv(auth.TenantEnv, "")
+	tenant, err := DefaultTenant(nil)
+	require.NoError(t, err)
+	assert.Empty(t, tenant)
+
+	tenant, err = DefaultTenant([]string{"demo", "prod"})
+	require.NoError(t, err)
+	assert.Equal(t, "demo", tenant)
+
+	t.Setenv(auth.TenantEnv, "prod")
+	tenant, err = DefaultTenant([]string{"demo", "prod"})
+	require.NoError(t, err)
+	assert.Equal(t, "prod", tenant)
+}
@@ -10,9 +10,13 @@ import (
 	"io"
 	"net/http"
 	"net/url"
+	"os"
 	"time"
 
 	"github.com/kusaridev/kusari-cli/v2/pkg/auth"
+	"github.com/kusaridev/kusari-cli/v2/pkg/clierrors"
+	"github.com/kusaridev/kusari-cli/v2/pkg/output"
+	"github.com/kusaridev/kusari-cli/v2/pkg/transport"
 	urlBuilder "github.com/kusaridev/kusari-cli/v2/pkg/url"
 )
 
@@ -24,7 +28,7 @@ func Login(ctx context.Context, clientId, clientSecret, redirectUrl, authEndpoin
 		fmt.Printf(" ConsoleUrl: %s\n", consoleUrl)
 		fmt.Printf(" PlatformUrl: %s\n", platformUrl)
 		fmt.Printf(" ClientId: %s\n", clientId)
-		fmt.Printf(" CallbackUrl: %s\n", redirectUrl)
+		fmt.Printf(" CallbackUrl: %s (port %s)\n", redirectUrl, redirectPort)
 		fmt.Println()
 	}
 
@@ -47,17 +51,20 @@ func Login(ctx context.Context, clientId, clientSecret, redirectUrl, authEndpoin
 	// Convert to auth.WorkspaceInfo format and select workspace
 	var selectedWorkspace *auth.WorkspaceInfo
 
-	// If client secret is provided (CI/CD mode), auto-select first workspace and tenant
+	// If client secret is provided (CI/CD mode), auto-select a workspace and
+	// tenant: the ones named by KUSARI_WORKSPACE/KUSARI_TENANT, else the first.
 	if clientSecret != "" {
-		firstWorkspace := &workspaces[0]
-		selectedTenant := ""
-		// Get tenants for first workspace from the map
-		if tenants, ok := workspaceTenants[firstWorkspace.ID]; ok && len(tenants) > 0 {
-			selectedTenant = tenants[0]
+		defaultWorkspace, err := DefaultWorkspace(workspaces)
+		if err != nil {
+			return err
+		}
+		selectedTenant, err := DefaultTenant(workspaceTenants[defaultWorkspace.ID])
+		if err != nil {
+			return err
 		}
 		selectedWorkspace = &auth.WorkspaceInfo{
-			ID:           firstWorkspace.ID,
-			Description:  firstWorkspace.Description,
+			ID:           defaultWorkspace.ID,
+			Description:  defaultWorkspace.Description,
 			PlatformUrl:  platformUrl,
 			AuthEndpoint: currentAuthEndpoint,
 			Tenant:       selectedTenant,
@@ -131,21 +138,56 @@ func Login(ctx context.Context, clientId, clientSecret, redirectUrl, authEndpoin
 		}
 	}
 
-	// ANSI escape codes:
-	// \033[1m = bold
-	// \033[34m = blue
-	// \033[0m = reset
-	fmt.Println("\033[1m\033[34mFor more information, visit:\033[0m https://docs.kusari.cloud")
+	fmt.Println(output.Style("For more information, visit:", output.Bold, output.Blue) + " https://docs.kusari.cloud")
 	return nil
 }
 
+// DefaultWorkspace picks the workspace to use without prompting: the one
+// named by KUSARI_WORKSPACE (ID or description) if set, else the first.
+func DefaultWorkspace(workspaces []Workspace) (Workspace, error) {
+	if len(workspaces) == 0 {
+		return Workspace{}, fmt.Errorf("no workspaces available")
+	}
+	ref := os.Getenv(auth.WorkspaceEnv)
+	if ref == "" {
+		return workspaces[0], nil
+	}
+	infos := make([]auth.WorkspaceInfo, len(workspaces))
+	for i, ws := range workspaces {
+		infos[i] = auth.WorkspaceInfo{ID: ws.ID, Description: ws.Description}
+	}
+	selected, err := auth.FindWorkspace(infos, ref)
+	if err != nil {
+		return Workspace{}, clierrors.NewValidationError("%s: %v", auth.WorkspaceEnv, err)
+	}
+	return Workspace{ID: selected.ID, Description: selected.Description}, nil
+}
+
+// DefaultTenant picks the tenant to use without prompting: the one named by
+// KUSARI_TENANT if set, else the first. It returns "" when there are none.
+func DefaultTenant(tenants []string) (string, error) {
+	if len(tenants) == 0 {
+		return "", nil
+	}
+	ref := os.Getenv(auth.TenantEnv)
+	if ref == "" {
+		return tenants[0], nil
+	}
+	selected, err := auth.FindTenant(tenants, ref)
+	if err != nil {
+		return "", clierrors.NewValidationError("%s: %v", auth.TenantEnv, err)
+	}
+	return selected, nil
+}
+
 // Workspace represents a workspace with its ID and description
 type Workspace struct {
 	ID          string `json:"id"`
 	Description string `json:"description"`
 }
 
-// FetchWorkspaces retrieves all workspaces and workspace-tenant mapping for the authenticated user
+// FetchWorkspaces retrieves all workspaces and workspace-tenant mapping for the authenticated user.
+// It always calls the platform and refreshes the local cache; see FetchWorkspacesCached.
 func FetchWorkspaces(platformUrl string, accessToken string) ([]Workspace, map[string][]string, error) {
 
 	userEndpoint, err := urlBuilder.Build(platformUrl, "/user")
@@ -157,7 +199,7 @@ func FetchWorkspaces(platformUrl string, accessToken string) ([]Workspace, map[s
 	}
 
 	client := &http.Client{
-		Timeout: 10 * time.Second,
+		Timeout: transport.Timeout(10 * time.Second),
 	}
 
 	req, err := http.NewRequest("GET", *userEndpoint, nil)
@@ -206,5 +248,6 @@ func FetchWorkspaces(platformUrl string, accessToken string) ([]Workspace, map[s
 		}
 	}
 
+	storeWorkspaceCache(platformUrl, accessToken, result.Workspaces, result.WorkspaceTenants)
 	return result.Workspaces, result.WorkspaceTenants, nil
 }
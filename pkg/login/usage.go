@@ -0,0 +1,83 @@
+// Copyright (c) Kusari <https://www.kusari.dev/>
+// SPDX-License-Identifier: MIT
+
+package login
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/kusaridev/kusari-cli/v2/pkg/clierrors"
+	"github.com/kusaridev/kusari-cli/v2/pkg/transport"
+	urlBuilder "github.com/kusaridev/kusari-cli/v2/pkg/url"
+)
+
+// Quota is the use of one workspace resource against its limit.
+type Quota struct {
+	Used int64 `json:"used"`
+	// Limit is nil when the resource is unlimited.
+	Limit *int64 `json:"limit"`
+}
+
+// Remaining returns what is left of q, and false when it is unlimited.
+func (q Quota) Remaining() (int64, bool) {
+	if q.Limit == nil {
+		return 0, false
+	}
+	return max(0, *q.Limit-q.Used), true
+}
+
+// NearLimit reports whether at least fraction of q is used.
+func (q Quota) NearLimit(fraction float64) bool {
+	return q.Limit != nil && float64(q.Used) >= fraction*float64(*q.Limit)
+}
+
+// WorkspaceUsage is what a workspace has used in the current billing
+// period.
+type WorkspaceUsage struct {
+	Workspace   string    `json:"workspace"`
+	PeriodStart time.Time `json:"period_start"`
+	PeriodEnd   time.Time `json:"period_end"`
+	Scans       Quota     `json:"scans"`
+	SBOMs       Quota     `json:"sboms"`
+	// StorageBytes is in bytes.
+	StorageBytes Quota `json:"storage_bytes"`
+}
+
+// FetchWorkspaceUsage retrieves the usage and quotas of workspace.
+func FetchWorkspaceUsage(platformUrl, accessToken, workspace string) (*WorkspaceUsage, error) {
+	endpoint, err := urlBuilder.Build(platformUrl, "inspector", "workspace", "usage")
+	if err != nil {
+		return nil, fmt.Errorf("failed to build endpoint url: %w", err)
+	}
+	req, err := http.NewRequest("GET", *endpoint, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+accessToken)
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("X-Kusari-Workspace", workspace)
+
+	client := &http.Client{Timeout: transport.Timeout(10 * time.Second)}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, clierrors.NewNetworkError("failed to fetch workspace usage", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, clierrors.NewPlatformError(resp.StatusCode, "the platform does not report workspace usage yet")
+	}
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return nil, clierrors.NewPlatformError(resp.StatusCode, fmt.Sprintf("workspace usage API returned status %d: %s", resp.StatusCode, string(body)))
+	}
+	var usage WorkspaceUsage
+	if err := json.NewDecoder(resp.Body).Decode(&usage); err != nil {
+		return nil, fmt.Errorf("failed to decode workspace usage: %w", err)
+	}
+	return &usage, nil
+}
@@ -0,0 +1,57 @@
+// Copyright (c) Kusari <https://www.kusari.dev/>
+// SPDX-License-Identifier: MIT
+
+package login
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/kusaridev/kusari-cli/v2/pkg/clierrors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFetchWorkspaceUsage(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/inspector/workspace/usage", r.URL.Path)
+		assert.Equal(t, "ws1", r.Header.Get("X-Kusari-Workspace"))
+		assert.Equal(t, "Bearer token", r.Header.Get("Authorization"))
+		_, _ = w.Write([]byte(`{"workspace":"One","period_start":"2026-10-01T00:00:00Z","period_end":"2026-10-31T23:59:59Z",
+"scans":{"used":460,"limit":500},"sboms":{"used":12,"limit":null},"storage_bytes":{"used":1073741824,"limit":10737418240}}`))
+	}))
+	defer server.Close()
+
+	usage, err := FetchWorkspaceUsage(server.URL, "token", "ws1")
+	require.NoError(t, err)
+	assert.Equal(t, "One", usage.Workspace)
+	assert.Equal(t, 10, int(usage.PeriodStart.Month()))
+
+	left, ok := usage.Scans.Remaining()
+	assert.True(t, ok)
+	assert.Equal(t, int64(40), left)
+	assert.True(t, usage.Scans.NearLimit(0.9))
+
+	_, ok = usage.SBOMs.Remaining()
+	assert.False(t, ok)
+	assert.False(t, usage.SBOMs.NearLimit(0.9))
+	assert.False(t, usage.StorageBytes.NearLimit(0.9))
+}
+
+func TestFetchWorkspaceUsageNotAvailable(t *testing.T) {
+	server := httptest.NewServer(http.NotFoundHandler())
+	defer server.Close()
+
+	_, err := FetchWorkspaceUsage(server.URL, "token", "ws1")
+	var platformErr *clierrors.PlatformError
+	require.ErrorAs(t, err, &platformErr)
+	assert.Contains(t, err.Error(), "does not report workspace usage yet")
+}
+
+func TestQuotaRemainingNeverNegative(t *testing.T) {
+	limit := int64(10)
+	left, ok := Quota{Used: 12, Limit: &limit}.Remaining()
+	assert.True(t, ok)
+	assert.Zero(t, left)
+}
@@ -0,0 +1,125 @@
+// Copyright (c) Kusari <https://www.kusari.dev/>
+// SPDX-License-Identifier: MIT
+
+package lsp
+
+import (
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+
+	"github.com/kusaridev/kusari-cli/v2/pkg/sarif"
+)
+
+// diagnosticSource is the source shown next to each diagnostic.
+const diagnosticSource = "kusari"
+
+// Findings are the results of a scan: the SARIF results with a location,
+// and a one-line summary of the overall analysis.
+type Findings struct {
+	Results []sarif.SarifResult
+	Summary string
+}
+
+// ParseFindings reads the SARIF produced by `kusari repo scan
+// --output-format sarif`.
+func ParseFindings(sarifJSON string) (*Findings, error) {
+	var log sarif.SarifLog
+	if err := json.Unmarshal([]byte(sarifJSON), &log); err != nil {
+		return nil, fmt.Errorf("cached results are not SARIF; rescan to refresh them: %w", err)
+	}
+
+	findings := &Findings{}
+	for _, run := range log.Runs {
+		for _, result := range run.Results {
+			if result.RuleID == "security-analysis" {
+				findings.Summary = result.Message.Text
+				continue
+			}
+			if len(result.Locations) > 0 {
+				findings.Results = append(findings.Results, result)
+			}
+		}
+	}
+	return findings, nil
+}
+
+// Position is a zero-based LSP text position.
+type Position struct {
+	Line      int `json:"line"`
+	Character int `json:"character"`
+}
+
+// Range is an LSP text range; End is exclusive.
+type Range struct {
+	Start Position `json:"start"`
+	End   Position `json:"end"`
+}
+
+// Diagnostic is an LSP diagnostic.
+type Diagnostic struct {
+	Range    Range  `json:"range"`
+	Severity int    `json:"severity"`
+	Code     string `json:"code,omitempty"`
+	Source   string `json:"source"`
+	Message  string `json:"message"`
+}
+
+// Diagnostics groups the findings by file URI. Relative SARIF paths are
+// resolved against root.
+func (f *Findings) Diagnostics(root string) map[string][]Diagnostic {
+	byFile := map[string][]Diagnostic{}
+	for _, result := range f.Results {
+		for _, loc := range result.Locations {
+			path := filepath.FromSlash(loc.PhysicalLocation.ArtifactLocation.URI)
+			if path == "" {
+				continue
+			}
+			if !filepath.IsAbs(path) {
+				path = filepath.Join(root, path)
+			}
+			uri := pathToURI(path)
+			byFile[uri] = append(byFile[uri], Diagnostic{
+				Range:    regionRange(loc.PhysicalLocation.Region),
+				Severity: severity(result.Level),
+				Code:     result.RuleID,
+				Source:   diagnosticSource,
+				Message:  result.Message.Text,
+			})
+		}
+	}
+	return byFile
+}
+
+// regionRange converts a one-based SARIF region to an LSP range. Without
+// columns it covers the whole start line.
+func regionRange(r sarif.SarifRegion) Range {
+	line := max(r.StartLine-1, 0)
+	start := Position{Line: line, Character: max(r.StartColumn-1, 0)}
+	end := Position{Line: line + 1}
+	if r.EndLine > 0 {
+		end.Line = r.EndLine - 1
+		if r.EndColumn > 0 {
+			end.Character = r.EndColumn - 1
+		} else {
+			end.Line = r.EndLine
+		}
+	} else if r.EndColumn > 0 {
+		end = Position{Line: line, Character: r.EndColumn - 1}
+	}
+	return Range{Start: start, End: end}
+}
+
+// severity maps a SARIF level to an LSP DiagnosticSeverity.
+func severity(level string) int {
+	switch level {
+	case "error":
+		return 1
+	case "note":
+		return 3
+	case "none":
+		return 4
+	default:
+		return 2
+	}
+}
@@ -0,0 +1,263 @@
+// Copyright (c) Kusari <https://www.kusari.dev/>
+// SPDX-License-Identifier: MIT
+
+// Package lsp is a minimal Language Server Protocol server that publishes
+// the findings of the latest Kusari Inspector scan of a repository as
+// diagnostics, so editors show them inline without a dedicated extension.
+package lsp
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// RefreshCommand is the workspace/executeCommand that rescans the
+// repository and republishes diagnostics.
+const RefreshCommand = "kusari.refresh"
+
+// Source loads the findings for the repository at root. When rescan is
+// true it runs a new scan first; otherwise it returns the latest cached
+// results. Nil findings with no error means there are no results yet.
+type Source func(ctx context.Context, root string, rescan bool) (*Findings, error)
+
+// Server serves diagnostics for a single workspace root.
+type Server struct {
+	conn   *conn
+	source Source
+
+	mu         sync.Mutex
+	root       string
+	published  map[string]bool
+	shutdown   bool
+	refreshing bool
+}
+
+// NewServer returns a server that speaks LSP on r and w and gets findings
+// from source.
+func NewServer(r io.Reader, w io.Writer, source Source) *Server {
+	return &Server{
+		conn:      newConn(r, w),
+		source:    source,
+		published: map[string]bool{},
+	}
+}
+
+// Run serves requests until the client sends exit or closes the stream.
+func (s *Server) Run(ctx context.Context) error {
+	for {
+		msg, err := s.conn.read()
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+			return err
+		}
+
+		if msg.Method == "exit" {
+			s.mu.Lock()
+			clean := s.shutdown
+			s.mu.Unlock()
+			if !clean {
+				return fmt.Errorf("client exited without shutdown")
+			}
+			return nil
+		}
+
+		result, rerr := s.handle(ctx, msg)
+		if msg.ID != nil {
+			if err := s.conn.reply(msg.ID, result, rerr); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+type initializeParams struct {
+	RootURI          string `json:"rootUri"`
+	RootPath         string `json:"rootPath"`
+	WorkspaceFolders []struct {
+		URI string `json:"uri"`
+	} `json:"workspaceFolders"`
+}
+
+type executeCommandParams struct {
+	Command string `json:"command"`
+}
+
+func (s *Server) handle(ctx context.Context, msg *message) (any, *rpcError) {
+	switch msg.Method {
+	case "initialize":
+		var params initializeParams
+		if err := json.Unmarshal(msg.Params, &params); err != nil {
+			return nil, &rpcError{Code: codeInvalidParams, Message: err.Error()}
+		}
+		root, err := workspaceRoot(params)
+		if err != nil {
+			return nil, &rpcError{Code: codeInvalidParams, Message: err.Error()}
+		}
+		s.mu.Lock()
+		s.root = root
+		s.mu.Unlock()
+		return map[string]any{
+			"capabilities": map[string]any{
+				// Open/close/save notifications only: diagnostics come
+				// from the last scan, not from the buffer contents.
+				"textDocumentSync": map[string]any{"openClose": true, "change": 0, "save": true},
+				"executeCommandProvider": map[string]any{
+					"commands": []string{RefreshCommand},
+				},
+			},
+			"serverInfo": map[string]any{"name": "kusari"},
+		}, nil
+
+	case "initialized", "textDocument/didOpen", "textDocument/didSave":
+		// Cheap: the cached results are re-read so a scan run from the
+		// terminal shows up on the next open or save.
+		_ = s.publish(ctx, false)
+		return nil, nil
+
+	case "workspace/executeCommand":
+		var params executeCommandParams
+		if err := json.Unmarshal(msg.Params, &params); err != nil {
+			return nil, &rpcError{Code: codeInvalidParams, Message: err.Error()}
+		}
+		if params.Command != RefreshCommand {
+			return nil, &rpcError{Code: codeInvalidParams, Message: fmt.Sprintf("unknown command %q", params.Command)}
+		}
+		// A scan takes minutes, so it runs in the background; errors are
+		// reported with window/showMessage.
+		s.mu.Lock()
+		busy := s.refreshing
+		s.refreshing = true
+		s.mu.Unlock()
+		if busy {
+			return nil, &rpcError{Code: codeInternalError, Message: "a scan is already running"}
+		}
+		s.showMessage(messageInfo, "Kusari: scanning changes...")
+		go func() {
+			_ = s.publish(ctx, true)
+			s.mu.Lock()
+			s.refreshing = false
+			s.mu.Unlock()
+		}()
+		return nil, nil
+
+	case "shutdown":
+		s.mu.Lock()
+		s.shutdown = true
+		s.mu.Unlock()
+		return nil, nil
+
+	case "textDocument/didClose", "textDocument/didChange", "$/cancelRequest", "$/setTrace":
+		return nil, nil
+	}
+
+	if msg.ID == nil {
+		// Unknown notifications are ignored, per the spec.
+		return nil, nil
+	}
+	return nil, &rpcError{Code: codeMethodNotFound, Message: fmt.Sprintf("method %q not supported", msg.Method)}
+}
+
+// publish loads findings and sends publishDiagnostics for every file that
+// has some, clearing files that had diagnostics last time but not now.
+func (s *Server) publish(ctx context.Context, rescan bool) error {
+	s.mu.Lock()
+	root := s.root
+	s.mu.Unlock()
+	if root == "" {
+		return fmt.Errorf("no workspace root")
+	}
+
+	findings, err := s.source(ctx, root, rescan)
+	if err != nil {
+		s.showMessage(messageError, fmt.Sprintf("Kusari: %v", err))
+		return err
+	}
+
+	byFile := map[string][]Diagnostic{}
+	if findings != nil {
+		byFile = findings.Diagnostics(root)
+		if rescan && findings.Summary != "" {
+			s.showMessage(messageInfo, "Kusari: "+findings.Summary)
+		}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for uri := range s.published {
+		if _, ok := byFile[uri]; !ok {
+			byFile[uri] = []Diagnostic{}
+		}
+	}
+	s.published = map[string]bool{}
+	for uri, diags := range byFile {
+		if len(diags) > 0 {
+			s.published[uri] = true
+		}
+		if err := s.conn.notify("textDocument/publishDiagnostics", map[string]any{
+			"uri":         uri,
+			"diagnostics": diags,
+		}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// window/showMessage types.
+const (
+	messageError = 1
+	messageInfo  = 3
+)
+
+func (s *Server) showMessage(kind int, text string) {
+	_ = s.conn.notify("window/showMessage", map[string]any{"type": kind, "message": text})
+}
+
+// workspaceRoot returns the local path of the first workspace folder,
+// falling back to rootUri and the deprecated rootPath.
+func workspaceRoot(params initializeParams) (string, error) {
+	uri := params.RootURI
+	if len(params.WorkspaceFolders) > 0 {
+		uri = params.WorkspaceFolders[0].URI
+	}
+	if uri == "" {
+		if params.RootPath == "" {
+			return "", fmt.Errorf("initialize: no workspace root")
+		}
+		return filepath.Clean(params.RootPath), nil
+	}
+	return uriToPath(uri)
+}
+
+func uriToPath(uri string) (string, error) {
+	u, err := url.Parse(uri)
+	if err != nil {
+		return "", err
+	}
+	if u.Scheme != "file" {
+		return "", fmt.Errorf("unsupported workspace URI %q", uri)
+	}
+	p := u.Path
+	// file:///C:/repo on Windows
+	if len(p) >= 3 && p[0] == '/' && p[2] == ':' {
+		p = p[1:]
+	}
+	return filepath.FromSlash(p), nil
+}
+
+func pathToURI(p string) string {
+	p = filepath.ToSlash(p)
+	if !strings.HasPrefix(p, "/") {
+		p = "/" + p
+	}
+	return (&url.URL{Scheme: "file", Path: p}).String()
+}
@@ -0,0 +1,132 @@
+// Copyright (c) Kusari <https://www.kusari.dev/>
+// SPDX-License-Identifier: MIT
+
+package lsp
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/kusaridev/kusari-cli/v2/pkg/sarif"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testSARIF = `{
+  "version": "2.1.0",
+  "runs": [{
+    "tool": {"driver": {"name": "Kusari Inspector"}},
+    "results": [
+      {"ruleId": "security-analysis", "level": "warning", "message": {"text": "Fix before merging"}},
+      {"ruleId": "code-mitigation", "level": "warning", "message": {"text": "Hardcoded secret"},
+       "locations": [{"physicalLocation": {"artifactLocation": {"uri": "pkg/config.go"}, "region": {"startLine": 12}}}]},
+      {"ruleId": "dependency-mitigation", "level": "warning", "message": {"text": "Upgrade lodash"}}
+    ]
+  }]
+}`
+
+func TestParseFindings(t *testing.T) {
+	findings, err := ParseFindings(testSARIF)
+	require.NoError(t, err)
+	assert.Equal(t, "Fix before merging", findings.Summary)
+	require.Len(t, findings.Results, 1)
+
+	byFile := findings.Diagnostics("/repo")
+	diags := byFile["file:///repo/pkg/config.go"]
+	require.Len(t, diags, 1)
+	assert.Equal(t, Range{Start: Position{Line: 11}, End: Position{Line: 12}}, diags[0].Range)
+	assert.Equal(t, 2, diags[0].Severity)
+	assert.Equal(t, "Hardcoded secret", diags[0].Message)
+
+	_, err = ParseFindings("## Markdown results")
+	assert.Error(t, err)
+}
+
+func TestRegionRange(t *testing.T) {
+	assert.Equal(t, Range{Start: Position{Line: 4, Character: 2}, End: Position{Line: 4, Character: 9}},
+		regionRange(sarif.SarifRegion{StartLine: 5, StartColumn: 3, EndLine: 5, EndColumn: 10}))
+	assert.Equal(t, Range{Start: Position{Line: 4}, End: Position{Line: 7}},
+		regionRange(sarif.SarifRegion{StartLine: 5, EndLine: 7}))
+	assert.Equal(t, Range{Start: Position{}, End: Position{Line: 1}}, regionRange(sarif.SarifRegion{}))
+}
+
+func TestUriToPath(t *testing.T) {
+	p, err := uriToPath("file:///home/me/my%20repo")
+	require.NoError(t, err)
+	assert.Equal(t, "/home/me/my repo", p)
+	assert.Equal(t, "file:///home/me/my%20repo", pathToURI(p))
+
+	_, err = uriToPath("untitled:foo")
+	assert.Error(t, err)
+}
+
+func frame(t *testing.T, msgs ...map[string]any) io.Reader {
+	var buf bytes.Buffer
+	for _, m := range msgs {
+		m["jsonrpc"] = "2.0"
+		b, err := json.Marshal(m)
+		require.NoError(t, err)
+		fmt.Fprintf(&buf, "Content-Length: %d\r\n\r\n%s", len(b), b)
+	}
+	return &buf
+}
+
+func readAll(t *testing.T, out *bytes.Buffer) []message {
+	c := newConn(strings.NewReader(out.String()), io.Discard)
+	var msgs []message
+	for {
+		msg, err := c.read()
+		if err == io.EOF {
+			return msgs
+		}
+		require.NoError(t, err)
+		msgs = append(msgs, *msg)
+	}
+}
+
+func TestServer_Session(t *testing.T) {
+	var roots []string
+	source := func(_ context.Context, root string, rescan bool) (*Findings, error) {
+		roots = append(roots, root)
+		return ParseFindings(testSARIF)
+	}
+
+	in := frame(t,
+		map[string]any{"id": 1, "method": "initialize", "params": map[string]any{"rootUri": "file:///repo"}},
+		map[string]any{"method": "initialized", "params": map[string]any{}},
+		map[string]any{"id": 2, "method": "textDocument/hover", "params": map[string]any{}},
+		map[string]any{"id": 3, "method": "shutdown"},
+		map[string]any{"method": "exit"},
+	)
+	var out bytes.Buffer
+	require.NoError(t, NewServer(in, &out, source).Run(context.Background()))
+
+	msgs := readAll(t, &out)
+	require.Len(t, msgs, 4)
+
+	assert.JSONEq(t, "1", string(*msgs[0].ID))
+	caps, _ := json.Marshal(msgs[0].Result)
+	assert.Contains(t, string(caps), RefreshCommand)
+
+	assert.Equal(t, "textDocument/publishDiagnostics", msgs[1].Method)
+	assert.Contains(t, string(msgs[1].Params), "file:///repo/pkg/config.go")
+	assert.Contains(t, string(msgs[1].Params), "Hardcoded secret")
+
+	require.NotNil(t, msgs[2].Error)
+	assert.Equal(t, codeMethodNotFound, msgs[2].Error.Code)
+
+	assert.JSONEq(t, "3", string(*msgs[3].ID))
+	assert.Nil(t, msgs[3].Error)
+	assert.Equal(t, []string{"/repo"}, roots)
+}
+
+func TestServer_ExitWithoutShutdown(t *testing.T) {
+	in := frame(t, map[string]any{"method": "exit"})
+	err := NewServer(in, io.Discard, nil).Run(context.Background())
+	assert.Error(t, err)
+}
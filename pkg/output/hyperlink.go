@@ -0,0 +1,87 @@
+// Copyright (c) Kusari <https://www.kusari.dev/>
+// SPDX-License-Identifier: MIT
+
+package output
+
+import (
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Hyperlinks reports whether OSC 8 hyperlinks may be written to f: it is a
+// terminal known to support them and color is on. FORCE_HYPERLINK=1 turns
+// them on regardless, and FORCE_HYPERLINK=0 off.
+func Hyperlinks(f *os.File) bool {
+	if force, ok := os.LookupEnv("FORCE_HYPERLINK"); ok && force != "" {
+		return force != "0"
+	}
+	return !noColor && IsTerminal(f) && terminalSupportsHyperlinks()
+}
+
+// terminalSupportsHyperlinks detects, from the environment, a terminal that
+// renders OSC 8 escapes. Terminals that don't may print them as garbage,
+// so anything unknown is assumed not to.
+func terminalSupportsHyperlinks() bool {
+	if os.Getenv("CI") != "" {
+		return false
+	}
+	switch os.Getenv("TERM_PROGRAM") {
+	case "iTerm.app", "WezTerm", "vscode", "ghostty", "Tabby":
+		return true
+	}
+	if v, err := strconv.Atoi(os.Getenv("VTE_VERSION")); err == nil && v >= 5000 {
+		return true
+	}
+	for _, env := range []string{"WT_SESSION", "KONSOLE_VERSION", "KITTY_WINDOW_ID"} {
+		if os.Getenv(env) != "" {
+			return true
+		}
+	}
+	switch os.Getenv("TERM") {
+	case "xterm-kitty", "xterm-ghostty", "alacritty", "foot":
+		return true
+	}
+	return false
+}
+
+// Hyperlink returns text linking to target when f supports hyperlinks, and
+// text as is otherwise.
+func Hyperlink(f *os.File, target, text string) string {
+	if !Hyperlinks(f) {
+		return text
+	}
+	return osc8(target, text)
+}
+
+func osc8(target, text string) string {
+	return "\033]8;;" + target + "\033\\" + text + "\033]8;;\033\\"
+}
+
+// FileURL returns the file:// URL of path, made absolute against the
+// working directory.
+func FileURL(path string) string {
+	if abs, err := filepath.Abs(path); err == nil {
+		path = abs
+	}
+	return (&url.URL{Scheme: "file", Path: filepath.ToSlash(path)}).String()
+}
+
+// linkPattern matches http(s) URLs up to whitespace or an escape sequence,
+// so URLs styled by glamour are matched without their SGR codes.
+var linkPattern = regexp.MustCompile(`https?://[^\s\x1b<>"']+`)
+
+// Linkify makes every http(s) URL in s a hyperlink when f supports them.
+// Trailing punctuation is left out of the link.
+func Linkify(f *os.File, s string) string {
+	if !Hyperlinks(f) {
+		return s
+	}
+	return linkPattern.ReplaceAllStringFunc(s, func(u string) string {
+		trimmed := strings.TrimRight(u, ".,;:!?)]")
+		return osc8(trimmed, trimmed) + u[len(trimmed):]
+	})
+}
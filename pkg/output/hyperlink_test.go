@@ -0,0 +1,55 @@
+// Copyright (c) Kusari <https://www.kusari.dev/>
+// SPDX-License-Identifier: MIT
+
+package output
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHyperlink(t *testing.T) {
+	t.Setenv("FORCE_HYPERLINK", "1")
+	assert.Equal(t, "\033]8;;https://example.com\033\\docs\033]8;;\033\\", Hyperlink(os.Stdout, "https://example.com", "docs"))
+
+	t.Setenv("FORCE_HYPERLINK", "0")
+	assert.Equal(t, "docs", Hyperlink(os.Stdout, "https://example.com", "docs"))
+
+	// Tests don't run in a terminal.
+	t.Setenv("FORCE_HYPERLINK", "")
+	t.Setenv("TERM_PROGRAM", "iTerm.app")
+	assert.False(t, Hyperlinks(os.Stdout))
+}
+
+func TestTerminalSupportsHyperlinks(t *testing.T) {
+	for _, env := range []string{"CI", "TERM_PROGRAM", "VTE_VERSION", "WT_SESSION", "KONSOLE_VERSION", "KITTY_WINDOW_ID"} {
+		t.Setenv(env, "")
+	}
+	t.Setenv("TERM", "xterm-256color")
+	assert.False(t, terminalSupportsHyperlinks())
+
+	t.Setenv("VTE_VERSION", "4800")
+	assert.False(t, terminalSupportsHyperlinks())
+	t.Setenv("VTE_VERSION", "6003")
+	assert.True(t, terminalSupportsHyperlinks())
+
+	t.Setenv("CI", "true")
+	assert.False(t, terminalSupportsHyperlinks())
+}
+
+func TestLinkify(t *testing.T) {
+	t.Setenv("FORCE_HYPERLINK", "1")
+	in := "see https://docs.sigstore.dev/cosign/, or \033[4mhttps://console.example.com/r\033[0m."
+	want := "see \033]8;;https://docs.sigstore.dev/cosign/\033\\https://docs.sigstore.dev/cosign/\033]8;;\033\\, or " +
+		"\033[4m\033]8;;https://console.example.com/r\033\\https://console.example.com/r\033]8;;\033\\\033[0m."
+	assert.Equal(t, want, Linkify(os.Stdout, in))
+
+	t.Setenv("FORCE_HYPERLINK", "0")
+	assert.Equal(t, in, Linkify(os.Stdout, in))
+}
+
+func TestFileURL(t *testing.T) {
+	assert.Equal(t, "file:///repo/src/my%20file.go", FileURL("/repo/src/my file.go"))
+}
@@ -0,0 +1,212 @@
+// Copyright (c) Kusari <https://www.kusari.dev/>
+// SPDX-License-Identifier: MIT
+
+// Package output holds the process-wide presentation settings (quiet mode,
+// color) set from the global CLI flags, and the helpers the rest of the CLI
+// uses to honor them.
+package output
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/charmbracelet/glamour"
+	"github.com/charmbracelet/glamour/styles"
+	"golang.org/x/term"
+)
+
+// DefaultWidth is the markdown wrap width used when the terminal width
+// can't be detected (e.g. output redirected to a file).
+const DefaultWidth = 100
+
+var (
+	quiet   bool
+	noColor bool
+	width   int
+	wide    bool
+
+	nonInteractive bool
+	plainProgress  bool
+)
+
+// Configure sets the global output mode. Color is also disabled when the
+// NO_COLOR environment variable is set to any non-empty value
+// (https://no-color.org) or TERM is "dumb".
+func Configure(quietMode, noColorMode bool) {
+	quiet = quietMode
+	noColor = noColorMode || os.Getenv("NO_COLOR") != "" || os.Getenv("TERM") == "dumb"
+}
+
+// SetWidth sets the rendering width. A positive width overrides terminal
+// detection; wideMode disables wrapping and truncation entirely.
+func SetWidth(columns int, wideMode bool) {
+	width = columns
+	wide = wideMode
+}
+
+// WordWrap returns the column at which rendered markdown should wrap:
+// 0 (no wrapping) in wide mode, the --width value if set, the width of the
+// terminal on stdout, or DefaultWidth when stdout is not a terminal.
+func WordWrap() int {
+	if wide {
+		return 0
+	}
+	if width > 0 {
+		return width
+	}
+	if w, ok := terminalWidth(os.Stdout); ok {
+		return w
+	}
+	return DefaultWidth
+}
+
+// TableWidth returns the maximum line width for a table written to f, or 0
+// for unlimited. Tables are only constrained when f is a terminal: output
+// redirected to a file or pipe keeps full cell contents.
+func TableWidth(f *os.File) int {
+	if wide {
+		return 0
+	}
+	if !IsTerminal(f) {
+		return 0
+	}
+	if width > 0 {
+		return width
+	}
+	if w, ok := terminalWidth(f); ok {
+		return w
+	}
+	return 0
+}
+
+// Truncate shortens s to at most max runes, ending in "..." when cut.
+// max <= 0 means no limit.
+func Truncate(s string, max int) string {
+	r := []rune(s)
+	if max <= 0 || len(r) <= max {
+		return s
+	}
+	if max <= 3 {
+		return string(r[:max])
+	}
+	return string(r[:max-3]) + "..."
+}
+
+// FormatSize formats a byte count with a binary unit, e.g. "1.5 MiB".
+func FormatSize(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := int64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(n)/float64(div), "KMGTPE"[exp])
+}
+
+// IsTerminal reports whether f is attached to a terminal.
+func IsTerminal(f *os.File) bool {
+	return term.IsTerminal(int(f.Fd()))
+}
+
+func terminalWidth(f *os.File) (int, bool) {
+	if !IsTerminal(f) {
+		return 0, false
+	}
+	w, _, err := term.GetSize(int(f.Fd()))
+	if err != nil || w <= 0 {
+		return 0, false
+	}
+	return w, true
+}
+
+// SetNonInteractive turns off every interactive prompt. Commands that would
+// prompt must instead take their input from flags or environment variables,
+// or fail.
+func SetNonInteractive(v bool) {
+	nonInteractive = v
+}
+
+// NonInteractive reports whether non-interactive mode was requested.
+func NonInteractive() bool {
+	return nonInteractive
+}
+
+// Interactive reports whether the CLI may prompt: non-interactive mode is
+// off and stdin is a terminal.
+func Interactive() bool {
+	return !nonInteractive && IsTerminal(os.Stdin)
+}
+
+// Quiet reports whether spinners and progress messages are suppressed.
+func Quiet() bool {
+	return quiet
+}
+
+// Color reports whether ANSI colors and styling may be emitted.
+func Color() bool {
+	return !noColor
+}
+
+// Progressf writes a progress message to w unless quiet mode is on. Use it
+// for status chatter; final results and errors should be written directly.
+// In plain progress mode, status symbols are spelled out.
+func Progressf(w io.Writer, format string, args ...any) {
+	if quiet {
+		return
+	}
+	msg := fmt.Sprintf(format, args...)
+	if PlainProgress(w) {
+		msg = PlainSymbols(msg)
+	}
+	_, _ = io.WriteString(w, msg)
+}
+
+// GlamourStyle returns the glamour style option for rendering markdown:
+// the auto-detected style normally, or the plain "notty" style when color
+// is disabled.
+func GlamourStyle() glamour.TermRendererOption {
+	if noColor {
+		return glamour.WithStandardStyle(styles.NoTTYStyle)
+	}
+	return glamour.WithAutoStyle()
+}
+
+// PrintMarkdown renders markdown to stdout with GlamourStyle and
+// WordWrap, with its URLs linkified, printing it as is if rendering fails.
+func PrintMarkdown(content string) {
+	r, err := glamour.NewTermRenderer(GlamourStyle(), glamour.WithWordWrap(WordWrap()))
+	if err != nil {
+		fmt.Print(content)
+		return
+	}
+	rendered, err := r.Render(content)
+	if err != nil {
+		fmt.Print(content)
+		return
+	}
+	fmt.Print(Linkify(os.Stdout, rendered))
+}
+
+// ANSI SGR codes for Style.
+const (
+	Bold  = "\033[1m"
+	Blue  = "\033[34m"
+	reset = "\033[0m"
+)
+
+// Style wraps text in the given ANSI SGR codes, or returns it unchanged when
+// color is disabled.
+func Style(text string, codes ...string) string {
+	if noColor || len(codes) == 0 {
+		return text
+	}
+	var prefix string
+	for _, c := range codes {
+		prefix += c
+	}
+	return prefix + text + reset
+}
@@ -0,0 +1,96 @@
+// Copyright (c) Kusari <https://www.kusari.dev/>
+// SPDX-License-Identifier: MIT
+
+package output
+
+import (
+	"bytes"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConfigure_NoColorEnv(t *testing.T) {
+	t.Cleanup(func() { Configure(false, false) })
+
+	t.Setenv("NO_COLOR", "")
+	t.Setenv("TERM", "xterm-256color")
+	Configure(false, false)
+	assert.True(t, Color())
+
+	t.Setenv("NO_COLOR", "1")
+	Configure(false, false)
+	assert.False(t, Color())
+
+	t.Setenv("NO_COLOR", "")
+	t.Setenv("TERM", "dumb")
+	Configure(false, false)
+	assert.False(t, Color())
+
+	t.Setenv("TERM", "xterm-256color")
+	Configure(false, true)
+	assert.False(t, Color())
+}
+
+func TestProgressf(t *testing.T) {
+	t.Cleanup(func() { Configure(false, false) })
+
+	var buf bytes.Buffer
+	Configure(false, false)
+	Progressf(&buf, "step %d\n", 1)
+	assert.Equal(t, "step 1\n", buf.String())
+
+	buf.Reset()
+	Configure(true, false)
+	Progressf(&buf, "step %d\n", 2)
+	assert.Empty(t, buf.String())
+	assert.True(t, Quiet())
+}
+
+func TestStyle(t *testing.T) {
+	t.Cleanup(func() { Configure(false, false) })
+	t.Setenv("NO_COLOR", "")
+	t.Setenv("TERM", "xterm-256color")
+
+	Configure(false, false)
+	assert.Equal(t, "\033[1m\033[34mhi\033[0m", Style("hi", Bold, Blue))
+	assert.Equal(t, "hi", Style("hi"))
+
+	Configure(false, true)
+	assert.Equal(t, "hi", Style("hi", Bold, Blue))
+}
+
+func TestWordWrap(t *testing.T) {
+	t.Cleanup(func() { SetWidth(0, false) })
+
+	// go test's stdout is not a terminal, so detection falls back.
+	SetWidth(0, false)
+	assert.Equal(t, DefaultWidth, WordWrap())
+
+	SetWidth(80, false)
+	assert.Equal(t, 80, WordWrap())
+
+	SetWidth(80, true)
+	assert.Equal(t, 0, WordWrap())
+}
+
+func TestTableWidth_NotTerminal(t *testing.T) {
+	t.Cleanup(func() { SetWidth(0, false) })
+
+	f, err := os.CreateTemp(t.TempDir(), "table")
+	assert.NoError(t, err)
+	defer f.Close() //nolint:errcheck
+
+	// Redirected output is never constrained, even with --width.
+	SetWidth(80, false)
+	assert.Equal(t, 0, TableWidth(f))
+}
+
+func TestTruncate(t *testing.T) {
+	assert.Equal(t, "hello", Truncate("hello", 0))
+	assert.Equal(t, "hello", Truncate("hello", 5))
+	assert.Equal(t, "hel...", Truncate("hello world", 6))
+	assert.Equal(t, "he", Truncate("hello", 2))
+	assert.Equal(t, "✓✓...", Truncate("✓✓✓✓✓✓", 5))
+}
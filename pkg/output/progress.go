@@ -0,0 +1,179 @@
+// Copyright (c) Kusari <https://www.kusari.dev/>
+// SPDX-License-Identifier: MIT
+
+package output
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/briandowns/spinner"
+)
+
+// plainInterval is how often a plain spinner repeats its status. A var so
+// tests can shorten it.
+var plainInterval = 15 * time.Second
+
+// SetPlainProgress replaces spinner animations and status symbols with
+// plain-text status lines, for screen readers and logs. It is always on
+// when progress is not written to a terminal.
+func SetPlainProgress(on bool) {
+	plainProgress = on
+}
+
+// PlainProgress reports whether progress written to w is plain text.
+func PlainProgress(w io.Writer) bool {
+	f, ok := w.(*os.File)
+	return plainProgress || !ok || !IsTerminal(f)
+}
+
+var plainSymbols = strings.NewReplacer("✓ ", "OK: ", "✗ ", "Error: ", "⚠ ", "Warning: ", "🔒 ", "", "✓", "OK", "✗", "Error")
+
+// PlainSymbols spells out the status symbols in s.
+func PlainSymbols(s string) string {
+	return plainSymbols.Replace(s)
+}
+
+// Spinner shows the status of a long-running step: an animation in a
+// terminal or, in plain progress mode, a status line when the status
+// changes and every plainInterval. It prints nothing in quiet mode.
+type Spinner struct {
+	mu      sync.Mutex
+	w       io.Writer
+	anim    *spinner.Spinner // nil in plain mode
+	prefix  string
+	suffix  string
+	final   string
+	active  bool
+	started time.Time
+	done    chan struct{}
+}
+
+// NewSpinner returns a spinner writing to w.
+func NewSpinner(w io.Writer) *Spinner {
+	s := &Spinner{w: w}
+	if !PlainProgress(w) {
+		s.anim = spinner.New(spinner.CharSets[14], 100*time.Millisecond)
+		s.anim.Writer = w
+		if f, ok := w.(*os.File); ok {
+			s.anim.WriterFile = f
+		}
+		if quiet {
+			s.anim.Disable()
+		}
+	}
+	return s
+}
+
+// SetPrefix sets the text before the animation; it is the status in plain
+// mode, followed by the suffix.
+func (s *Spinner) SetPrefix(prefix string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	changed := prefix != s.prefix
+	s.prefix = prefix
+	s.update(changed)
+}
+
+// SetSuffix sets the text after the animation.
+func (s *Spinner) SetSuffix(suffix string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	changed := suffix != s.suffix
+	s.suffix = suffix
+	s.update(changed)
+}
+
+// SetFinal sets the message printed when the spinner stops.
+func (s *Spinner) SetFinal(msg string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.final = msg
+	if s.anim != nil {
+		s.anim.Lock()
+		s.anim.FinalMSG = msg
+		s.anim.Unlock()
+	}
+}
+
+// update applies a new prefix or suffix. Called with s.mu held.
+func (s *Spinner) update(changed bool) {
+	if s.anim != nil {
+		s.anim.Lock()
+		s.anim.Prefix, s.anim.Suffix = s.prefix, s.suffix
+		s.anim.Unlock()
+		return
+	}
+	if changed && s.active {
+		s.printStatus(false)
+	}
+}
+
+// Start starts the spinner. It does nothing if the spinner is running.
+func (s *Spinner) Start() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.anim != nil {
+		s.anim.Start()
+		return
+	}
+	if s.active || quiet {
+		return
+	}
+	s.active = true
+	s.started = time.Now()
+	s.done = make(chan struct{})
+	s.printStatus(false)
+	go s.repeat(s.done)
+}
+
+func (s *Spinner) repeat(done chan struct{}) {
+	ticker := time.NewTicker(plainInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-done:
+			return
+		case <-ticker.C:
+			s.mu.Lock()
+			if s.active {
+				s.printStatus(true)
+			}
+			s.mu.Unlock()
+		}
+	}
+}
+
+// printStatus writes the plain status line, with the time elapsed when it
+// is repeated. Called with s.mu held.
+func (s *Spinner) printStatus(repeat bool) {
+	status := strings.TrimSpace(s.prefix + s.suffix)
+	if status == "" {
+		status = "Working..."
+	}
+	if repeat {
+		status += fmt.Sprintf(" (%s elapsed)", time.Since(s.started).Round(time.Second))
+	}
+	_, _ = fmt.Fprintln(s.w, PlainSymbols(status))
+}
+
+// Stop stops the spinner and prints the final message. It does nothing if
+// the spinner isn't running, so it is safe to defer after stopping.
+func (s *Spinner) Stop() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.anim != nil {
+		s.anim.Stop()
+		return
+	}
+	if !s.active {
+		return
+	}
+	s.active = false
+	close(s.done)
+	_, _ = io.WriteString(s.w, PlainSymbols(s.final))
+}
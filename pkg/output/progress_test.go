@@ -0,0 +1,78 @@
+// Copyright (c) Kusari <https://www.kusari.dev/>
+// SPDX-License-Identifier: MIT
+
+package output
+
+import (
+	"bytes"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// syncBuffer is a bytes.Buffer safe for the spinner's goroutine.
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+func TestSpinner_Plain(t *testing.T) {
+	orig := plainInterval
+	plainInterval = 10 * time.Millisecond
+	t.Cleanup(func() { plainInterval = orig })
+
+	var w syncBuffer
+	s := NewSpinner(&w)
+	s.SetPrefix("Analysis in progress... ")
+	s.SetFinal("✓ Analysis complete!\n")
+	s.Start()
+	s.SetPrefix("Analysis in progress... ") // unchanged, not repeated
+	s.SetPrefix("Processing ")
+	assert.Eventually(t, func() bool { return strings.Contains(w.String(), "elapsed)") }, time.Second, 5*time.Millisecond)
+	s.Stop()
+	s.Stop()
+
+	lines := strings.Split(strings.TrimSuffix(w.String(), "\n"), "\n")
+	assert.Equal(t, "Analysis in progress...", lines[0])
+	assert.Equal(t, "Processing", lines[1])
+	assert.Contains(t, lines[2], "Processing (")
+	assert.Equal(t, "OK: Analysis complete!", lines[len(lines)-1])
+	assert.NotContains(t, w.String(), "✓")
+}
+
+func TestSpinner_Quiet(t *testing.T) {
+	t.Cleanup(func() { Configure(false, false) })
+	Configure(true, false)
+
+	var w syncBuffer
+	s := NewSpinner(&w)
+	s.SetFinal("done\n")
+	s.Start()
+	s.Stop()
+	assert.Empty(t, w.String())
+}
+
+func TestPlainProgress(t *testing.T) {
+	t.Cleanup(func() { SetPlainProgress(false) })
+	assert.True(t, PlainProgress(&bytes.Buffer{}))
+
+	var buf bytes.Buffer
+	Progressf(&buf, "✓ Returning cached results\n")
+	assert.Equal(t, "OK: Returning cached results\n", buf.String())
+	assert.Equal(t, "Error", PlainSymbols("✗"))
+}
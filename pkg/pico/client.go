@@ -14,6 +14,9 @@ import (
 	"time"
 
 	"github.com/kusaridev/kusari-cli/v2/pkg/auth"
+	"github.com/kusaridev/kusari-cli/v2/pkg/clierrors"
+	"github.com/kusaridev/kusari-cli/v2/pkg/readonly"
+	"github.com/kusaridev/kusari-cli/v2/pkg/transport"
 )
 
 // Client handles HTTP requests to the Kusari Pico API.
@@ -28,24 +31,25 @@ func NewClient(baseURL string) *Client {
 	return &Client{
 		baseURL: baseURL,
 		httpClient: &http.Client{
-			Timeout: 90 * time.Second,
+			Timeout: transport.Timeout(90 * time.Second),
 		},
 	}
 }
 
 // makeRequest makes an HTTP request to the Pico API with authentication.
 func (c *Client) makeRequest(ctx context.Context, method, path string, params map[string]string, body interface{}) ([]byte, error) {
+	if method != "GET" {
+		if err := readonly.Check(fmt.Sprintf("send %s %s to the Kusari platform", method, path)); err != nil {
+			return nil, err
+		}
+	}
+
 	// Load access token
-	token, err := auth.LoadToken("kusari")
+	token, err := auth.DefaultTokenProvider().Token(ctx)
 	if err != nil {
 		return nil, fmt.Errorf("failed to load auth token: %w", err)
 	}
 
-	// Check token expiry
-	if err := auth.CheckTokenExpiry(token); err != nil {
-		return nil, fmt.Errorf("token expired: %w", err)
-	}
-
 	// Build URL
 	reqURL := c.baseURL + path
 	if len(params) > 0 {
@@ -95,6 +99,9 @@ func (c *Client) makeRequest(ctx context.Context, method, path string, params ma
 	}
 
 	// Check response status
+	if resp.StatusCode == http.StatusForbidden {
+		return nil, clierrors.NewForbiddenError(method+" "+path, respBody)
+	}
 	if resp.StatusCode >= 400 {
 		return nil, fmt.Errorf("API request failed with status %d: %s", resp.StatusCode, string(respBody))
 	}
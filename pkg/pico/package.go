@@ -0,0 +1,124 @@
+// Copyright (c) Kusari <https://www.kusari.dev/>
+// SPDX-License-Identifier: MIT
+
+package pico
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// purlPattern is the form of a package URL: pkg:type/[namespace/]name[@version],
+// with optional qualifiers and subpath.
+var purlPattern = regexp.MustCompile(`^pkg:[a-zA-Z][a-zA-Z0-9.+-]*/[^@?#]+(@[^?#]+)?(\?[^#]*)?(#.*)?$`)
+
+// Verdicts on a package, from the most to the least severe.
+const (
+	VerdictBlock = "block"
+	VerdictWarn  = "warn"
+	VerdictOK    = "ok"
+)
+
+// PackageInfo is what the platform knows about one package version.
+type PackageInfo struct {
+	Purl string `json:"purl"`
+	// Blocked is whether the package is on the workspace's blocked
+	// package list, and BlockedBy the lists or policies that block it.
+	Blocked        bool            `json:"blocked"`
+	BlockedBy      []string        `json:"blocked_by,omitempty"`
+	Advisories     []Advisory      `json:"advisories"`
+	PolicyVerdicts []PolicyVerdict `json:"policy_verdicts"`
+	IsEOL          bool            `json:"is_eol"`
+	IsDeprecated   bool            `json:"is_deprecated"`
+}
+
+// Advisory is a known vulnerability affecting a package version.
+type Advisory struct {
+	ID       string `json:"id"` // CVE, GHSA, ...
+	Severity string `json:"severity,omitempty"`
+	Summary  string `json:"summary,omitempty"`
+	FixedIn  string `json:"fixed_in,omitempty"` // First version without it
+}
+
+// PolicyVerdict is the result of one workspace policy for a package.
+type PolicyVerdict struct {
+	Policy  string `json:"policy"`
+	Result  string `json:"result"` // pass, warn or fail
+	Message string `json:"message,omitempty"`
+}
+
+// ValidatePurl checks that purl is a package URL with a version, e.g.
+// pkg:npm/lodash@4.17.20.
+func ValidatePurl(purl string) error {
+	if !purlPattern.MatchString(purl) {
+		return fmt.Errorf("%q is not a package URL (e.g. pkg:npm/lodash@4.17.20)", purl)
+	}
+	if !strings.Contains(strings.SplitN(purl, "?", 2)[0], "@") {
+		return fmt.Errorf("%q has no version (e.g. pkg:npm/lodash@4.17.20)", purl)
+	}
+	return nil
+}
+
+// GetPackageInfo retrieves the blocked status, known advisories and policy
+// verdicts of the package version purl.
+func (c *Client) GetPackageInfo(ctx context.Context, purl string) (*PackageInfo, error) {
+	respBody, err := c.makeRequest(ctx, "GET", "/pico/v1/packages/purl", map[string]string{"purl": purl}, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var info PackageInfo
+	if err := json.Unmarshal(respBody, &info); err != nil {
+		return nil, fmt.Errorf("failed to parse package info: %w", err)
+	}
+	return &info, nil
+}
+
+// Verdict returns whether to block the package, warn about it, or let it
+// through, and why. Blocked packages and failed policies block; known
+// advisories, warning policies and end of life warn.
+func (p *PackageInfo) Verdict() (string, []string) {
+	var block, warn []string
+	if p.Blocked {
+		reason := "on the blocked package list"
+		if len(p.BlockedBy) > 0 {
+			reason += " (" + strings.Join(p.BlockedBy, ", ") + ")"
+		}
+		block = append(block, reason)
+	}
+	for _, v := range p.PolicyVerdicts {
+		reason := "policy " + v.Policy
+		if v.Message != "" {
+			reason += ": " + v.Message
+		}
+		switch v.Result {
+		case "fail":
+			block = append(block, reason)
+		case "warn":
+			warn = append(warn, reason)
+		}
+	}
+	switch n := len(p.Advisories); {
+	case n == 1:
+		warn = append(warn, "1 known advisory")
+	case n > 1:
+		warn = append(warn, fmt.Sprintf("%d known advisories", n))
+	}
+	if p.IsEOL {
+		warn = append(warn, "end of life")
+	}
+	if p.IsDeprecated {
+		warn = append(warn, "deprecated")
+	}
+	switch {
+	case len(block) > 0:
+		return VerdictBlock, append(block, warn...)
+	case len(warn) > 0:
+		return VerdictWarn, warn
+	default:
+		return VerdictOK, nil
+	}
+}
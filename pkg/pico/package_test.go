@@ -0,0 +1,95 @@
+// Copyright (c) Kusari <https://www.kusari.dev/>
+// SPDX-License-Identifier: MIT
+
+package pico
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidatePurl(t *testing.T) {
+	for _, purl := range []string{
+		"pkg:npm/lodash@4.17.20",
+		"pkg:npm/%40babel/core@7.24.0",
+		"pkg:golang/github.com/gin-gonic/gin@v1.9.0",
+		"pkg:maven/org.apache.commons/commons-lang3@3.14.0?type=jar",
+	} {
+		assert.NoError(t, ValidatePurl(purl), purl)
+	}
+	for _, purl := range []string{
+		"lodash@4.17.20",
+		"pkg:npm",
+		"pkg:npm/lodash",
+		"pkg:maven/org.apache.commons/commons-lang3?type=jar",
+	} {
+		assert.Error(t, ValidatePurl(purl), purl)
+	}
+}
+
+func TestPackageInfo_Verdict(t *testing.T) {
+	tests := []struct {
+		name    string
+		info    PackageInfo
+		verdict string
+		reasons []string
+	}{
+		{
+			name:    "clean",
+			info:    PackageInfo{PolicyVerdicts: []PolicyVerdict{{Policy: "licenses", Result: "pass"}}},
+			verdict: VerdictOK,
+		},
+		{
+			name:    "advisories and eol",
+			info:    PackageInfo{Advisories: []Advisory{{ID: "CVE-2021-23337"}, {ID: "CVE-2020-28500"}}, IsEOL: true},
+			verdict: VerdictWarn,
+			reasons: []string{"2 known advisories", "end of life"},
+		},
+		{
+			name:    "policy warns",
+			info:    PackageInfo{PolicyVerdicts: []PolicyVerdict{{Policy: "maintenance", Result: "warn", Message: "no release in 2 years"}}},
+			verdict: VerdictWarn,
+			reasons: []string{"policy maintenance: no release in 2 years"},
+		},
+		{
+			name: "blocked",
+			info: PackageInfo{
+				Blocked:    true,
+				BlockedBy:  []string{"critical-cves"},
+				Advisories: []Advisory{{ID: "CVE-2021-23337"}},
+			},
+			verdict: VerdictBlock,
+			reasons: []string{"on the blocked package list (critical-cves)", "1 known advisory"},
+		},
+		{
+			name:    "policy fails",
+			info:    PackageInfo{PolicyVerdicts: []PolicyVerdict{{Policy: "licenses", Result: "fail", Message: "GPL-3.0 is forbidden"}}},
+			verdict: VerdictBlock,
+			reasons: []string{"policy licenses: GPL-3.0 is forbidden"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			verdict, reasons := tt.info.Verdict()
+			assert.Equal(t, tt.verdict, verdict)
+			assert.Equal(t, tt.reasons, reasons)
+		})
+	}
+}
+
+func TestPackageInfo_Unmarshal(t *testing.T) {
+	var info PackageInfo
+	require.NoError(t, json.Unmarshal([]byte(`{
+		"purl": "pkg:npm/lodash@4.17.20",
+		"blocked": false,
+		"advisories": [{"id": "CVE-2021-23337", "severity": "HIGH", "fixed_in": "4.17.21"}],
+		"policy_verdicts": [{"policy": "licenses", "result": "pass"}],
+		"is_eol": false,
+		"is_deprecated": false
+	}`), &info))
+	assert.Equal(t, "4.17.21", info.Advisories[0].FixedIn)
+	assert.Equal(t, "pass", info.PolicyVerdicts[0].Result)
+}
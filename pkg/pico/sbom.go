@@ -0,0 +1,105 @@
+// Copyright (c) Kusari <https://www.kusari.dev/>
+// SPDX-License-Identifier: MIT
+
+package pico
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"slices"
+	"time"
+)
+
+// listPageSize is the page size used to list every item of a collection.
+const listPageSize = 100
+
+// SoftwareEntry is one software tracked by the platform.
+type SoftwareEntry struct {
+	ID   int    `json:"id"`
+	Name string `json:"name"`
+}
+
+// SBOMVersion is one SBOM uploaded for a software entry.
+type SBOMVersion struct {
+	ID        int       `json:"id"`
+	Version   string    `json:"version"`
+	URI       string    `json:"sbom_uri"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// ListAllSoftware retrieves every software entry, optionally filtered by a
+// search term, following the pages of the software list.
+func (c *Client) ListAllSoftware(ctx context.Context, search string) ([]SoftwareEntry, error) {
+	return listAll[SoftwareEntry](ctx, c, "/pico/v1/software", "software", map[string]string{"search": search})
+}
+
+// ListSBOMs retrieves every SBOM uploaded for a software entry.
+func (c *Client) ListSBOMs(ctx context.Context, softwareID int) ([]SBOMVersion, error) {
+	return listAll[SBOMVersion](ctx, c, fmt.Sprintf("/pico/v1/software/%d/sboms", softwareID), "sboms", nil)
+}
+
+// DeleteSBOM deletes one SBOM of a software entry, and what was ingested
+// from it.
+func (c *Client) DeleteSBOM(ctx context.Context, softwareID, sbomID int) error {
+	path := fmt.Sprintf("/pico/v1/software/%d/sboms/%d", softwareID, sbomID)
+	_, err := c.makeRequest(ctx, "DELETE", path, nil, nil)
+	return err
+}
+
+// listAll retrieves the items under key of every page of path, until a
+// page is not full.
+func listAll[T any](ctx context.Context, c *Client, path, key string, params map[string]string) ([]T, error) {
+	var all []T
+	for page := 0; ; page++ {
+		p := maps.Clone(params)
+		if p == nil {
+			p = map[string]string{}
+		}
+		p["page"] = fmt.Sprintf("%d", page)
+		p["size"] = fmt.Sprintf("%d", listPageSize)
+
+		respBody, err := c.makeRequest(ctx, "GET", path, p, nil)
+		if err != nil {
+			return nil, err
+		}
+		var resp map[string]json.RawMessage
+		if err := json.Unmarshal(respBody, &resp); err != nil {
+			return nil, fmt.Errorf("failed to parse response: %w", err)
+		}
+		var items []T
+		if raw, ok := resp[key]; ok {
+			if err := json.Unmarshal(raw, &items); err != nil {
+				return nil, fmt.Errorf("failed to parse %s: %w", key, err)
+			}
+		}
+		all = append(all, items...)
+		if len(items) < listPageSize {
+			return all, nil
+		}
+	}
+}
+
+// Prunable returns the SBOMs of sboms uploaded before cutoff, newest
+// first, sparing the keepLatest most recent whatever their age. Without an
+// upload time for every SBOM neither the age nor the latest can be told,
+// so a missing one is an error and nothing is prunable.
+func Prunable(sboms []SBOMVersion, cutoff time.Time, keepLatest int) ([]SBOMVersion, error) {
+	for _, s := range sboms {
+		if s.CreatedAt.IsZero() {
+			return nil, fmt.Errorf("SBOM %d has no upload time (created_at), refusing to prune", s.ID)
+		}
+	}
+	sorted := slices.Clone(sboms)
+	slices.SortStableFunc(sorted, func(a, b SBOMVersion) int {
+		return b.CreatedAt.Compare(a.CreatedAt)
+	})
+	var prunable []SBOMVersion
+	for i, s := range sorted {
+		if i >= keepLatest && s.CreatedAt.Before(cutoff) {
+			prunable = append(prunable, s)
+		}
+	}
+	return prunable, nil
+}
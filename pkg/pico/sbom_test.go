@@ -0,0 +1,58 @@
+// Copyright (c) Kusari <https://www.kusari.dev/>
+// SPDX-License-Identifier: MIT
+
+package pico
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPrunable(t *testing.T) {
+	day := func(d int) time.Time { return time.Date(2026, 1, d, 0, 0, 0, 0, time.UTC) }
+	sboms := []SBOMVersion{
+		{ID: 1, CreatedAt: day(1)},
+		{ID: 4, CreatedAt: day(4)},
+		{ID: 2, CreatedAt: day(2)},
+		{ID: 5, CreatedAt: day(5)},
+		{ID: 3, CreatedAt: day(3)},
+	}
+	ids := func(sboms []SBOMVersion) []int {
+		var ids []int
+		for _, s := range sboms {
+			ids = append(ids, s.ID)
+		}
+		return ids
+	}
+
+	prunable := func(cutoff time.Time, keepLatest int) []int {
+		p, err := Prunable(sboms, cutoff, keepLatest)
+		require.NoError(t, err)
+		return ids(p)
+	}
+
+	// Older than the cutoff, newest first.
+	assert.Equal(t, []int{3, 2, 1}, prunable(day(4), 0))
+	// The latest are kept whatever their age.
+	assert.Equal(t, []int{2, 1}, prunable(day(10), 3))
+	assert.Empty(t, prunable(day(10), 5))
+	assert.Empty(t, prunable(day(1), 0))
+
+	// A missing upload time would read as the oldest, so nothing is pruned.
+	sboms = append(sboms, SBOMVersion{ID: 6})
+	p, err := Prunable(sboms, day(10), 1)
+	assert.ErrorContains(t, err, "SBOM 6 has no upload time")
+	assert.Empty(t, p)
+}
+
+func TestSBOMVersion_Unmarshal(t *testing.T) {
+	var resp struct {
+		SBOMs []SBOMVersion `json:"sboms"`
+	}
+	require.NoError(t, json.Unmarshal([]byte(`{"sboms": [{"id": 7, "version": "1.2.0", "sbom_uri": "s3://bucket/sha256_abc", "created_at": "2026-03-01T10:00:00Z"}], "total": 1}`), &resp))
+	assert.Equal(t, []SBOMVersion{{ID: 7, Version: "1.2.0", URI: "s3://bucket/sha256_abc", CreatedAt: time.Date(2026, 3, 1, 10, 0, 0, 0, time.UTC)}}, resp.SBOMs)
+}
@@ -0,0 +1,57 @@
+// Copyright (c) Kusari <https://www.kusari.dev/>
+// SPDX-License-Identifier: MIT
+
+// Package plugin runs output plugins: kusari-output-<name> executables on
+// the PATH that read a scan's results as JSON on stdin and format them or
+// send them somewhere, so teams can add formats and notification sinks
+// without forking the CLI.
+package plugin
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"regexp"
+
+	"github.com/kusaridev/kusari-cli/v2/pkg/clierrors"
+	"github.com/kusaridev/kusari-cli/v2/pkg/output"
+)
+
+// Prefix is the prefix of an output plugin's executable name.
+const Prefix = "kusari-output-"
+
+var namePattern = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]*$`)
+
+// Lookup returns the path of the executable of the plugin name.
+func Lookup(name string) (string, error) {
+	if !namePattern.MatchString(name) {
+		return "", clierrors.NewValidationError("invalid output plugin name %q (use lowercase letters, digits, - and _)", name)
+	}
+	path, err := exec.LookPath(Prefix + name)
+	if err != nil {
+		return "", clierrors.NewValidationError("output plugin %q not found: install a %s%s executable on the PATH", name, Prefix, name)
+	}
+	return path, nil
+}
+
+// Run runs the plugin name with input on stdin and returns what it writes
+// to stdout. env is added to the plugin's environment, e.g.
+// KUSARI_CONSOLE_URL=...; its stderr is the CLI's.
+func Run(name string, input []byte, env ...string) ([]byte, error) {
+	path, err := Lookup(name)
+	if err != nil {
+		return nil, err
+	}
+	var stdout bytes.Buffer
+	cmd := exec.Command(path)
+	cmd.Stdin = bytes.NewReader(input)
+	cmd.Stdout = &stdout
+	cmd.Stderr = os.Stderr
+	cmd.Env = append(os.Environ(), env...)
+	output.Debug("running output plugin", "name", name, "path", path)
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("output plugin %s failed: %w", name, err)
+	}
+	return stdout.Bytes(), nil
+}
@@ -0,0 +1,43 @@
+// Copyright (c) Kusari <https://www.kusari.dev/>
+// SPDX-License-Identifier: MIT
+
+package plugin
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// installPlugin writes a shell script plugin to a directory put on the PATH.
+func installPlugin(t *testing.T, name, script string) {
+	t.Helper()
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, Prefix+name), []byte("#!/bin/sh\n"+script), 0755))
+	t.Setenv("PATH", dir+string(os.PathListSeparator)+os.Getenv("PATH"))
+}
+
+func TestRun(t *testing.T) {
+	installPlugin(t, "upper", `tr a-z A-Z; echo "$KUSARI_CONSOLE_URL"`)
+
+	out, err := Run("upper", []byte("{\"ok\":true}\n"), "KUSARI_CONSOLE_URL=https://console.example.com/r")
+	require.NoError(t, err)
+	assert.Equal(t, "{\"OK\":TRUE}\nhttps://console.example.com/r\n", string(out))
+}
+
+func TestRun_Fails(t *testing.T) {
+	installPlugin(t, "broken", "exit 3\n")
+
+	_, err := Run("broken", nil)
+	assert.ErrorContains(t, err, "output plugin broken failed: exit status 3")
+}
+
+func TestLookup(t *testing.T) {
+	_, err := Lookup("../evil")
+	assert.ErrorContains(t, err, "invalid output plugin name")
+	_, err = Lookup("does-not-exist")
+	assert.ErrorContains(t, err, "kusari-output-does-not-exist executable on the PATH")
+}
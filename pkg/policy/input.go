@@ -0,0 +1,155 @@
+// Copyright (c) Kusari <https://www.kusari.dev/>
+// SPDX-License-Identifier: MIT
+
+package policy
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"slices"
+
+	"github.com/kusaridev/kusari-cli/v2/api"
+	"github.com/kusaridev/kusari-cli/v2/pkg/results"
+)
+
+// Input is the document policies see as input.
+type Input struct {
+	// Analysis is the Inspector verdict (should_proceed, health_score,
+	// code_mitigations, ...). Nil when no analysis was given.
+	Analysis *api.SecurityAnalysis `json:"analysis"`
+	// Health holds the per-category checks of a risk check, keyed by
+	// category. Empty for diff scans.
+	Health api.Health `json:"health"`
+	// Score is the overall risk check score.
+	Score int `json:"score"`
+	// SBOMs is the metadata of each SBOM given.
+	SBOMs []SBOM `json:"sboms"`
+}
+
+// NewInput returns an empty Input.
+func NewInput() *Input {
+	return &Input{SBOMs: []SBOM{}}
+}
+
+// SBOM is the subset of an SPDX or CycloneDX document policies can use.
+type SBOM struct {
+	Path     string    `json:"path"`
+	Format   string    `json:"format"` // "spdx" or "cyclonedx"
+	Name     string    `json:"name"`
+	Version  string    `json:"version,omitempty"`
+	Packages []Package `json:"packages"`
+}
+
+// Package is one component of an SBOM.
+type Package struct {
+	Name     string   `json:"name"`
+	Version  string   `json:"version,omitempty"`
+	PURL     string   `json:"purl,omitempty"`
+	Licenses []string `json:"licenses"`
+}
+
+// LoadAnalysis reads Inspector results from path into in. See results.Load
+// for the accepted formats.
+func (in *Input) LoadAnalysis(path string) error {
+	res, err := results.Load(path)
+	if err != nil {
+		return err
+	}
+	in.Analysis = res.Analysis
+	in.Health = res.Health
+	in.Score = res.Score
+	return nil
+}
+
+// spdxDocument and cdxDocument are the fields read from each SBOM format.
+type spdxDocument struct {
+	SPDXID   string `json:"SPDXID"`
+	Name     string `json:"name"`
+	Packages []struct {
+		Name             string `json:"name"`
+		VersionInfo      string `json:"versionInfo"`
+		LicenseConcluded string `json:"licenseConcluded"`
+		LicenseDeclared  string `json:"licenseDeclared"`
+		ExternalRefs     []struct {
+			ReferenceType    string `json:"referenceType"`
+			ReferenceLocator string `json:"referenceLocator"`
+		} `json:"externalRefs"`
+	} `json:"packages"`
+}
+
+type cdxDocument struct {
+	BOMFormat string `json:"bomFormat"`
+	Metadata  struct {
+		Component struct {
+			Name    string `json:"name"`
+			Version string `json:"version"`
+		} `json:"component"`
+	} `json:"metadata"`
+	Components []struct {
+		Name     string `json:"name"`
+		Version  string `json:"version"`
+		PURL     string `json:"purl"`
+		Licenses []struct {
+			License struct {
+				ID   string `json:"id"`
+				Name string `json:"name"`
+			} `json:"license"`
+			Expression string `json:"expression"`
+		} `json:"licenses"`
+	} `json:"components"`
+}
+
+// LoadSBOM reads an SPDX or CycloneDX JSON document and appends its
+// metadata to in.SBOMs.
+func (in *Input) LoadSBOM(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read SBOM: %w", err)
+	}
+
+	var cdx cdxDocument
+	if err := json.Unmarshal(data, &cdx); err == nil && cdx.BOMFormat == "CycloneDX" {
+		sbom := SBOM{Path: path, Format: "cyclonedx", Name: cdx.Metadata.Component.Name, Version: cdx.Metadata.Component.Version, Packages: []Package{}}
+		for _, c := range cdx.Components {
+			pkg := Package{Name: c.Name, Version: c.Version, PURL: c.PURL, Licenses: []string{}}
+			for _, l := range c.Licenses {
+				switch {
+				case l.License.ID != "":
+					pkg.Licenses = append(pkg.Licenses, l.License.ID)
+				case l.License.Name != "":
+					pkg.Licenses = append(pkg.Licenses, l.License.Name)
+				case l.Expression != "":
+					pkg.Licenses = append(pkg.Licenses, l.Expression)
+				}
+			}
+			sbom.Packages = append(sbom.Packages, pkg)
+		}
+		in.SBOMs = append(in.SBOMs, sbom)
+		return nil
+	}
+
+	var spdx spdxDocument
+	if err := json.Unmarshal(data, &spdx); err == nil && spdx.SPDXID == "SPDXRef-DOCUMENT" {
+		sbom := SBOM{Path: path, Format: "spdx", Name: spdx.Name, Packages: []Package{}}
+		for _, p := range spdx.Packages {
+			pkg := Package{Name: p.Name, Version: p.VersionInfo, Licenses: []string{}}
+			for _, l := range []string{p.LicenseConcluded, p.LicenseDeclared} {
+				if l != "" && l != "NOASSERTION" && l != "NONE" && !slices.Contains(pkg.Licenses, l) {
+					pkg.Licenses = append(pkg.Licenses, l)
+				}
+			}
+			for _, ref := range p.ExternalRefs {
+				if ref.ReferenceType == "purl" {
+					pkg.PURL = ref.ReferenceLocator
+					break
+				}
+			}
+			sbom.Packages = append(sbom.Packages, pkg)
+		}
+		in.SBOMs = append(in.SBOMs, sbom)
+		return nil
+	}
+
+	return fmt.Errorf("%s is not an SPDX or CycloneDX JSON document", path)
+}
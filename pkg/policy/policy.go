@@ -0,0 +1,112 @@
+// Copyright (c) Kusari <https://www.kusari.dev/>
+// SPDX-License-Identifier: MIT
+
+// Package policy evaluates Rego policies against Kusari Inspector results
+// and SBOM metadata, so organization rules (health score thresholds,
+// forbidden licenses, mandatory checks) are written once and enforced the
+// same way locally, in CI and in pre-receive hooks.
+//
+// Policies are Rego modules in package kusari. Every string (or object with
+// a "msg" field) in the deny set is a reason to reject; the warn set is
+// reported but does not affect the decision:
+//
+//	package kusari
+//
+//	deny contains msg if {
+//		input.analysis.health_score < 3
+//		msg := sprintf("health score %d is below 3", [input.analysis.health_score])
+//	}
+package policy
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+
+	"github.com/open-policy-agent/opa/v1/rego"
+)
+
+// Query is the Rego package policies must define their rules in.
+const Query = "data.kusari"
+
+// Decision is the outcome of evaluating the policies.
+type Decision struct {
+	Allow bool     `json:"allow"`
+	Deny  []string `json:"deny"`
+	Warn  []string `json:"warn"`
+}
+
+// Eval evaluates the .rego files under paths (files or directories)
+// against input.
+func Eval(ctx context.Context, paths []string, input *Input) (*Decision, error) {
+	for _, p := range paths {
+		if _, err := os.Stat(p); err != nil {
+			return nil, fmt.Errorf("policy path: %w", err)
+		}
+	}
+
+	doc, err := toValue(input)
+	if err != nil {
+		return nil, err
+	}
+
+	r := rego.New(
+		rego.Query(Query),
+		rego.Load(paths, nil),
+		rego.Input(doc),
+	)
+	rs, err := r.Eval(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to evaluate policies: %w", err)
+	}
+
+	decision := &Decision{Deny: []string{}, Warn: []string{}}
+	if len(rs) > 0 && len(rs[0].Expressions) > 0 {
+		pkg, _ := rs[0].Expressions[0].Value.(map[string]any)
+		decision.Deny = messages(pkg["deny"])
+		decision.Warn = messages(pkg["warn"])
+	}
+	decision.Allow = len(decision.Deny) == 0
+	return decision, nil
+}
+
+// toValue round-trips input through JSON so policies see the same field
+// names as the JSON documents it was built from.
+func toValue(input *Input) (any, error) {
+	b, err := json.Marshal(input)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode policy input: %w", err)
+	}
+	var v any
+	if err := json.Unmarshal(b, &v); err != nil {
+		return nil, fmt.Errorf("failed to encode policy input: %w", err)
+	}
+	return v, nil
+}
+
+// messages flattens a deny/warn set into sorted strings. Set members may
+// be strings or objects with a "msg" field; anything else is shown as JSON.
+func messages(v any) []string {
+	items, _ := v.([]any)
+	out := make([]string, 0, len(items))
+	for _, item := range items {
+		switch m := item.(type) {
+		case string:
+			out = append(out, m)
+		case map[string]any:
+			if msg, ok := m["msg"].(string); ok {
+				out = append(out, msg)
+				continue
+			}
+			b, _ := json.Marshal(m)
+			out = append(out, string(b))
+		default:
+			b, _ := json.Marshal(m)
+			out = append(out, string(b))
+		}
+	}
+	sort.Strings(out)
+	return out
+}
@@ -0,0 +1,96 @@
+// Copyright (c) Kusari <https://www.kusari.dev/>
+// SPDX-License-Identifier: MIT
+
+package policy
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/kusaridev/kusari-cli/v2/api"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testPolicies = "testdata/policies"
+
+func TestEval_Allow(t *testing.T) {
+	input := NewInput()
+	input.Analysis = &api.SecurityAnalysis{ShouldProceed: true, HealthScore: 4}
+
+	decision, err := Eval(context.Background(), []string{testPolicies}, input)
+	require.NoError(t, err)
+	assert.True(t, decision.Allow)
+	assert.Empty(t, decision.Deny)
+	assert.Empty(t, decision.Warn)
+}
+
+func TestEval_Deny(t *testing.T) {
+	input := NewInput()
+	input.Analysis = &api.SecurityAnalysis{ShouldProceed: false, HealthScore: 2}
+	require.NoError(t, input.LoadSBOM("testdata/sbom.cdx.json"))
+
+	decision, err := Eval(context.Background(), []string{testPolicies}, input)
+	require.NoError(t, err)
+	assert.False(t, decision.Allow)
+	assert.Equal(t, []string{
+		"ghostscript uses forbidden license AGPL-3.0-only",
+		"health score 2 is below 3",
+	}, decision.Deny)
+	assert.Equal(t, []string{"analysis did not recommend proceeding"}, decision.Warn)
+}
+
+func TestEval_MissingPolicyPath(t *testing.T) {
+	_, err := Eval(context.Background(), []string{"testdata/nope"}, NewInput())
+	assert.Error(t, err)
+}
+
+func TestEval_InvalidPolicy(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "bad.rego"), []byte("package kusari\n\ndeny contains"), 0600))
+	_, err := Eval(context.Background(), []string{dir}, NewInput())
+	assert.Error(t, err)
+}
+
+func TestLoadSBOM(t *testing.T) {
+	input := NewInput()
+	require.NoError(t, input.LoadSBOM("testdata/sbom.cdx.json"))
+	require.NoError(t, input.LoadSBOM("testdata/sbom.spdx.json"))
+	require.Len(t, input.SBOMs, 2)
+
+	cdx := input.SBOMs[0]
+	assert.Equal(t, "cyclonedx", cdx.Format)
+	assert.Equal(t, "app", cdx.Name)
+	assert.Equal(t, Package{Name: "left-pad", Version: "1.3.0", PURL: "pkg:npm/left-pad@1.3.0", Licenses: []string{"MIT"}}, cdx.Packages[0])
+
+	spdx := input.SBOMs[1]
+	assert.Equal(t, "spdx", spdx.Format)
+	assert.Equal(t, Package{Name: "requests", Version: "2.31.0", PURL: "pkg:pypi/requests@2.31.0", Licenses: []string{"Apache-2.0"}}, spdx.Packages[0])
+	assert.Empty(t, spdx.Packages[1].Licenses)
+
+	assert.Error(t, input.LoadSBOM(testPolicies+"/rules.rego"))
+}
+
+func TestLoadAnalysis(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, content string) string {
+		p := filepath.Join(dir, name)
+		require.NoError(t, os.WriteFile(p, []byte(content), 0600))
+		return p
+	}
+
+	input := NewInput()
+	require.NoError(t, input.LoadAnalysis(write("bare.json", `{"should_proceed": true, "health_score": 4}`)))
+	assert.Equal(t, 4, input.Analysis.HealthScore)
+
+	input = NewInput()
+	require.NoError(t, input.LoadAnalysis(write("record.json",
+		`{"analysis": {"score": 7, "rawLLMAnalysis": {"health_score": 2}, "health": {"code": {"score": 3, "checks": [{"name": "branch-protection", "pass": false}]}}}}`)))
+	assert.Equal(t, 2, input.Analysis.HealthScore)
+	assert.Equal(t, 7, input.Score)
+	assert.False(t, input.Health["code"].Checks[0].Pass)
+
+	assert.Error(t, NewInput().LoadAnalysis(write("bad.json", "not json")))
+}
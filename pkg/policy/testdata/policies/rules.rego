@@ -0,0 +1,17 @@
+package kusari
+
+deny contains msg if {
+	input.analysis.health_score < 3
+	msg := sprintf("health score %d is below 3", [input.analysis.health_score])
+}
+
+deny contains {"msg": msg} if {
+	some sbom in input.sboms
+	some pkg in sbom.packages
+	"AGPL-3.0-only" in pkg.licenses
+	msg := sprintf("%s uses forbidden license AGPL-3.0-only", [pkg.name])
+}
+
+warn contains "analysis did not recommend proceeding" if {
+	not input.analysis.should_proceed
+}
@@ -0,0 +1,92 @@
+// Copyright (c) Kusari <https://www.kusari.dev/>
+// SPDX-License-Identifier: MIT
+
+package port
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+)
+
+// Bounds of the loopback callback ports registered with the Kusari IdP.
+const (
+	DefaultRangeMin = 62001
+	DefaultRangeMax = 62009
+)
+
+// Listen binds a loopback listener for the OAuth callback and returns it
+// together with the port actually bound. spec may be:
+//
+//   - "" – a random port in the default range, falling back to the others
+//   - "N" – port N; if N is in the default range, the rest of the range is
+//     tried when it is busy
+//   - "A-B" – the first free port from A to B
+//   - "0" – any free port chosen by the OS (requires an IdP that allows
+//     wildcard loopback redirect URIs)
+func Listen(host, spec string) (net.Listener, string, error) {
+	if spec == "" {
+		spec = GenerateRandomPortOrDefault()
+	}
+
+	candidates, err := candidatePorts(spec)
+	if err != nil {
+		return nil, "", err
+	}
+
+	var errs []error
+	for _, p := range candidates {
+		l, err := net.Listen("tcp", net.JoinHostPort(host, strconv.Itoa(p)))
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		return l, strconv.Itoa(l.Addr().(*net.TCPAddr).Port), nil
+	}
+
+	return nil, "", fmt.Errorf("could not bind the login callback on %s port %s (use --callback-port to pick another port or range, or 0 for any free port if your identity provider allows it): %w",
+		host, spec, errors.Join(errs...))
+}
+
+// candidatePorts expands spec into the ports to try, in order.
+func candidatePorts(spec string) ([]int, error) {
+	if lo, hi, ok := strings.Cut(spec, "-"); ok {
+		min, err1 := parsePort(lo)
+		max, err2 := parsePort(hi)
+		if err := errors.Join(err1, err2); err != nil {
+			return nil, err
+		}
+		if min == 0 || max < min {
+			return nil, fmt.Errorf("invalid callback port range %q", spec)
+		}
+		ports := make([]int, 0, max-min+1)
+		for p := min; p <= max; p++ {
+			ports = append(ports, p)
+		}
+		return ports, nil
+	}
+
+	p, err := parsePort(spec)
+	if err != nil {
+		return nil, err
+	}
+	ports := []int{p}
+	if p >= DefaultRangeMin && p <= DefaultRangeMax {
+		// Wrap around the rest of the registered range.
+		size := DefaultRangeMax - DefaultRangeMin + 1
+		for i := 1; i < size; i++ {
+			ports = append(ports, DefaultRangeMin+(p-DefaultRangeMin+i)%size)
+		}
+	}
+	return ports, nil
+}
+
+func parsePort(s string) (int, error) {
+	p, err := strconv.Atoi(strings.TrimSpace(s))
+	if err != nil || p < 0 || p > 65535 {
+		return 0, fmt.Errorf("invalid callback port %q", s)
+	}
+	return p, nil
+}
@@ -0,0 +1,54 @@
+// Copyright (c) Kusari <https://www.kusari.dev/>
+// SPDX-License-Identifier: MIT
+
+package port
+
+import (
+	"net"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_candidatePorts(t *testing.T) {
+	ports, err := candidatePorts("62008")
+	require.NoError(t, err)
+	assert.Equal(t, []int{62008, 62009, 62001, 62002, 62003, 62004, 62005, 62006, 62007}, ports)
+
+	ports, err = candidatePorts("8080")
+	require.NoError(t, err)
+	assert.Equal(t, []int{8080}, ports)
+
+	ports, err = candidatePorts("9000-9002")
+	require.NoError(t, err)
+	assert.Equal(t, []int{9000, 9001, 9002}, ports)
+
+	for _, bad := range []string{"abc", "70000", "9002-9000", "0-10"} {
+		_, err := candidatePorts(bad)
+		assert.Error(t, err, bad)
+	}
+}
+
+func TestListen_Ephemeral(t *testing.T) {
+	l, p, err := Listen("localhost", "0")
+	require.NoError(t, err)
+	defer func() { _ = l.Close() }()
+	assert.NotEqual(t, "0", p)
+}
+
+func TestListen_FallsBackWhenBusy(t *testing.T) {
+	busy, err := net.Listen("tcp", "localhost:0")
+	require.NoError(t, err)
+	defer func() { _ = busy.Close() }()
+	busyPort := busy.Addr().(*net.TCPAddr).Port
+
+	_, _, err = Listen("localhost", strconv.Itoa(busyPort))
+	assert.ErrorContains(t, err, "could not bind the login callback")
+
+	l, p, err := Listen("localhost", strconv.Itoa(busyPort)+"-"+strconv.Itoa(busyPort+50))
+	require.NoError(t, err)
+	defer func() { _ = l.Close() }()
+	assert.NotEqual(t, strconv.Itoa(busyPort), p)
+}
@@ -0,0 +1,35 @@
+// Copyright (c) Kusari <https://www.kusari.dev/>
+// SPDX-License-Identifier: MIT
+
+// Package readonly blocks the operations that change something (uploads,
+// comments, issues, tickets and deletions) when the CLI runs with
+// --read-only, so auditors can use it without side effects.
+package readonly
+
+import (
+	"sync/atomic"
+
+	"github.com/kusaridev/kusari-cli/v2/pkg/clierrors"
+)
+
+var enabled atomic.Bool
+
+// Set turns read-only mode on or off.
+func Set(on bool) {
+	enabled.Store(on)
+}
+
+// Enabled reports whether read-only mode is on.
+func Enabled() bool {
+	return enabled.Load()
+}
+
+// Check returns a *clierrors.ReadOnlyError for operation, e.g. "post a
+// pull request comment", when read-only mode is on, and nil otherwise.
+// Callers check before the first request that changes something.
+func Check(operation string) error {
+	if enabled.Load() {
+		return &clierrors.ReadOnlyError{Operation: operation}
+	}
+	return nil
+}
@@ -0,0 +1,22 @@
+// Copyright (c) Kusari <https://www.kusari.dev/>
+// SPDX-License-Identifier: MIT
+
+package readonly
+
+import (
+	"testing"
+
+	"github.com/kusaridev/kusari-cli/v2/pkg/clierrors"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCheck(t *testing.T) {
+	t.Cleanup(func() { Set(false) })
+
+	assert.NoError(t, Check("upload SBOMs"))
+
+	Set(true)
+	err := Check("upload SBOMs")
+	assert.EqualError(t, err, "read-only mode: refusing to upload SBOMs (unset --read-only or KUSARI_READ_ONLY to allow it)")
+	assert.Equal(t, clierrors.ExitReadOnly, clierrors.ExitCode(err))
+}
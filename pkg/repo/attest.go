@@ -0,0 +1,237 @@
+// Copyright (c) Kusari <https://www.kusari.dev/>
+// SPDX-License-Identifier: MIT
+
+package repo
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/exec"
+	"time"
+
+	"github.com/kusaridev/kusari-cli/v2/api"
+	"github.com/kusaridev/kusari-cli/v2/pkg/clierrors"
+	"github.com/kusaridev/kusari-cli/v2/pkg/output"
+	"github.com/kusaridev/kusari-cli/v2/pkg/readonly"
+	"github.com/kusaridev/kusari-cli/v2/pkg/transport"
+	urlBuilder "github.com/kusaridev/kusari-cli/v2/pkg/url"
+)
+
+const (
+	inTotoStatementType = "https://in-toto.io/Statement/v1"
+	inTotoLinkPredicate = "https://in-toto.io/attestation/link/v0.3"
+	attestationStep     = "kusari-scan"
+
+	// sigstoreBundleSuffix is appended to the attestation path for the
+	// cosign bundle holding its signature and certificate.
+	sigstoreBundleSuffix = ".sigstore.json"
+)
+
+// AttestOptions configures the attestation of a scan.
+type AttestOptions struct {
+	// Path is where the in-toto statement is written; its cosign bundle is
+	// written next to it, with sigstoreBundleSuffix. Empty disables
+	// attestation.
+	Path string
+	// Upload sends the signed statement to the platform, next to the result.
+	Upload bool
+	// CLIVersion is recorded as the version that ran the scan.
+	CLIVersion string
+}
+
+// inTotoStatement is an in-toto v1 statement with a link predicate.
+type inTotoStatement struct {
+	Type          string            `json:"_type"`
+	Subject       []resourceDesc    `json:"subject"`
+	PredicateType string            `json:"predicateType"`
+	Predicate     scanLinkPredicate `json:"predicate"`
+}
+
+// resourceDesc is an in-toto resource descriptor.
+type resourceDesc struct {
+	Name   string            `json:"name"`
+	Digest map[string]string `json:"digest"`
+}
+
+// scanLinkPredicate records the scan as an in-toto link: the package
+// analyzed is its material, and where the result is, its byproducts.
+type scanLinkPredicate struct {
+	Name       string         `json:"name"`
+	Command    []string       `json:"command"`
+	Materials  []resourceDesc `json:"materials"`
+	Byproducts scanByproducts `json:"byproducts"`
+}
+
+type scanByproducts struct {
+	CLIVersion  string `json:"cli_version"`
+	ScanType    string `json:"scan_type"`
+	Rev         string `json:"rev,omitempty"`
+	Workspace   string `json:"workspace"`
+	PlatformURL string `json:"platform_url"`
+	SortKey     string `json:"sort_key"`
+	ResultURL   string `json:"result_url"`
+	ScannedAt   string `json:"scanned_at"`
+}
+
+// fileSHA256 returns the hex SHA-256 of the file at path.
+func fileSHA256(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to open %s: %w", path, err)
+	}
+	defer func() { _ = f.Close() }()
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// newScanStatement describes the scan of j: the commit scanned is the
+// subject, and packageDigest the SHA-256 of the package before encryption.
+func newScanStatement(j *UploadJournal, meta *api.BundleMeta, packageDigest, cliVersion string) inTotoStatement {
+	name := meta.Remote
+	if name == "" {
+		name = meta.DirName
+	}
+	var subject []resourceDesc
+	if meta.CommitSHA != "" {
+		subject = append(subject, resourceDesc{Name: name, Digest: map[string]string{"gitCommit": meta.CommitSHA}})
+	}
+	return inTotoStatement{
+		Type:          inTotoStatementType,
+		Subject:       subject,
+		PredicateType: inTotoLinkPredicate,
+		Predicate: scanLinkPredicate{
+			Name:      attestationStep,
+			Command:   os.Args,
+			Materials: []resourceDesc{{Name: tarballName, Digest: map[string]string{"sha256": packageDigest}}},
+			Byproducts: scanByproducts{
+				CLIVersion:  cliVersion,
+				ScanType:    j.scanType(),
+				Rev:         j.Rev,
+				Workspace:   j.Workspace,
+				PlatformURL: j.PlatformURL,
+				SortKey:     j.SortKey,
+				ResultURL:   j.ResultURL,
+				ScannedAt:   clk.Now().UTC().Format(time.RFC3339),
+			},
+		},
+	}
+}
+
+// findCosign returns the path of cosign.
+func findCosign() (string, error) {
+	cosign, err := exec.LookPath("cosign")
+	if err != nil {
+		return "", clierrors.NewValidationError("attesting a scan needs cosign on PATH; see https://docs.sigstore.dev/cosign/system_config/installation/")
+	}
+	return cosign, nil
+}
+
+// sigstoreHosts are the public Sigstore services cosign keyless signing
+// contacts.
+var sigstoreHosts = []string{"fulcio.sigstore.dev", "rekor.sigstore.dev", "oauth2.sigstore.dev"}
+
+// signBlob signs the file at path with cosign keyless signing, writing the
+// bundle to bundlePath. cosign gets an identity token from the CI
+// environment, or by opening a browser.
+func signBlob(path, bundlePath string) error {
+	cosign, err := findCosign()
+	if err != nil {
+		return err
+	}
+	// cosign doesn't go through the CLI's transport, so the allowlist is
+	// checked for it up front.
+	for _, host := range sigstoreHosts {
+		if err := transport.CheckHost(host); err != nil {
+			return fmt.Errorf("cannot sign the attestation: %w", err)
+		}
+	}
+	cmd := exec.Command(cosign, "sign-blob", "--yes", "--bundle", bundlePath, path)
+	cmd.Stdout = os.Stderr
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("cosign sign-blob failed: %w", err)
+	}
+	return nil
+}
+
+// attestScan writes the signed attestation of the scan of j to opts.Path
+// and, with opts.Upload, sends it to the platform.
+func attestScan(opts AttestOptions, j *UploadJournal, meta *api.BundleMeta, packageDigest, accessToken string) error {
+	statement, err := json.MarshalIndent(newScanStatement(j, meta, packageDigest, opts.CLIVersion), "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal attestation: %w", err)
+	}
+	if err := os.WriteFile(opts.Path, append(statement, '\n'), 0600); err != nil {
+		return fmt.Errorf("failed to write attestation: %w", err)
+	}
+	bundlePath := opts.Path + sigstoreBundleSuffix
+	output.Progressf(os.Stderr, "Signing attestation with cosign...\n")
+	if err := signBlob(opts.Path, bundlePath); err != nil {
+		return err
+	}
+	output.Progressf(os.Stderr, "Attestation written to %s (signature bundle %s)\n", opts.Path, bundlePath)
+
+	if !opts.Upload {
+		return nil
+	}
+	bundle, err := os.ReadFile(bundlePath)
+	if err != nil {
+		return fmt.Errorf("failed to read signature bundle: %w", err)
+	}
+	if err := uploadAttestation(j.PlatformURL, accessToken, j.Workspace, j.SortKey, statement, bundle); err != nil {
+		return err
+	}
+	output.Progressf(os.Stderr, "Attestation uploaded\n")
+	return nil
+}
+
+// uploadAttestation stores the signed statement with the result at sortKey.
+func uploadAttestation(platformUrl, accessToken, workspace, sortKey string, statement, bundle []byte) error {
+	if err := readonly.Check("record a scan attestation"); err != nil {
+		return err
+	}
+	endpoint, err := urlBuilder.Build(platformUrl, "inspector", "attestation")
+	if err != nil {
+		return err
+	}
+	body, err := json.Marshal(map[string]any{
+		"sort_key":  sortKey,
+		"statement": json.RawMessage(statement),
+		"bundle":    json.RawMessage(bundle),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to marshal attestation: %w", err)
+	}
+	req, err := http.NewRequest("POST", *endpoint, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+accessToken)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Kusari-Workspace", workspace)
+
+	client := newHTTPClient(10 * time.Second)
+	resp, err := client.Do(req)
+	if err != nil {
+		return clierrors.NewNetworkError("failed to upload attestation", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusCreated && resp.StatusCode != http.StatusNoContent {
+		respBody, _ := io.ReadAll(resp.Body)
+		if resp.StatusCode == http.StatusForbidden {
+			return clierrors.NewForbiddenError("recording a scan attestation", respBody)
+		}
+		return clierrors.NewPlatformError(resp.StatusCode, fmt.Sprintf("attestation API returned status %d: %s", resp.StatusCode, string(respBody)))
+	}
+	return nil
+}
@@ -0,0 +1,126 @@
+// Copyright (c) Kusari <https://www.kusari.dev/>
+// SPDX-License-Identifier: MIT
+
+package repo
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/kusaridev/kusari-cli/v2/pkg/login"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeCosign puts a cosign on PATH that writes a placeholder bundle.
+func fakeCosign(t *testing.T) {
+	t.Helper()
+	bin := t.TempDir()
+	script := "#!/bin/sh\n[ \"$1\" = sign-blob ] && [ \"$3\" = --bundle ] || exit 1\necho '{\"mediaType\":\"fake\"}' > \"$4\"\n"
+	require.NoError(t, os.WriteFile(filepath.Join(bin, "cosign"), []byte(script), 0755))
+	t.Setenv("PATH", bin+string(os.PathListSeparator)+os.Getenv("PATH"))
+}
+
+func TestScan_Attestation(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	fakeCosign(t)
+	attestDir := t.TempDir()
+
+	testDir := t.TempDir()
+	runCmd(t, testDir, "git", "init")
+	runCmd(t, testDir, "git", "config", "user.email", "test@example.com")
+	runCmd(t, testDir, "git", "config", "user.name", "Test User")
+	writeFile(t, filepath.Join(testDir, "test.txt"), "test content")
+	runCmd(t, testDir, "git", "add", ".")
+	runCmd(t, testDir, "git", "commit", "-m", "initial commit")
+	writeFile(t, filepath.Join(testDir, "test.txt"), "uncommitted change")
+	headCmd := exec.Command("git", "rev-parse", "HEAD")
+	headCmd.Dir = testDir
+	head, err := headCmd.Output()
+	require.NoError(t, err)
+
+	originalDir, err := os.Getwd()
+	require.NoError(t, err)
+	defer func() { _ = os.Chdir(originalDir) }()
+
+	var uploadedDigest string
+	mock := &scanMock{
+		fileUploader: func(presignedURL, filePath string) error {
+			var err error
+			uploadedDigest, err = fileSHA256(filePath)
+			return err
+		},
+		presignedURLGetter: func(apiEndpoint string, jwtToken string, filePath, workspace string, scanType string, size int64) (string, error) {
+			return "https://example.com/workspace/test-workspace-id/user/human/test-user-id/diff/blob/123", nil
+		},
+		defaultWorkspaceGetter: func(platformUrl string, jwtToken string) ([]login.Workspace, map[string][]string, error) {
+			return []login.Workspace{{ID: "ws-1", Description: "Test Workspace"}}, nil, nil
+		},
+		token: "token",
+	}
+	err = scan(ScanOptions{Dir: testDir, Rev: "HEAD", PlatformURL: "https://platform.example.com", ConsoleURL: "https://console.example.com", OutputFormat: "markdown",
+		Attest: AttestOptions{Path: filepath.Join(attestDir, "scan.intoto.json"), CLIVersion: "v9.9.9"}}, false, mock)
+	require.NoError(t, err)
+
+	data, err := os.ReadFile(filepath.Join(attestDir, "scan.intoto.json"))
+	require.NoError(t, err)
+	var statement inTotoStatement
+	require.NoError(t, json.Unmarshal(data, &statement))
+	assert.Equal(t, inTotoStatementType, statement.Type)
+	assert.Equal(t, inTotoLinkPredicate, statement.PredicateType)
+	require.Len(t, statement.Subject, 1)
+	assert.Equal(t, strings.TrimSpace(string(head)), statement.Subject[0].Digest["gitCommit"])
+	require.Len(t, statement.Predicate.Materials, 1)
+	assert.Equal(t, uploadedDigest, statement.Predicate.Materials[0].Digest["sha256"])
+	assert.Equal(t, "v9.9.9", statement.Predicate.Byproducts.CLIVersion)
+	assert.Equal(t, "diff", statement.Predicate.Byproducts.ScanType)
+	assert.Equal(t, "ws-1", statement.Predicate.Byproducts.Workspace)
+	assert.NotEmpty(t, statement.Predicate.Byproducts.SortKey)
+	assert.Contains(t, statement.Predicate.Byproducts.ResultURL, "https://console.example.com/workspaces/test-workspace-id/analysis/")
+
+	bundle, err := os.ReadFile(filepath.Join(attestDir, "scan.intoto.json"+sigstoreBundleSuffix))
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"mediaType":"fake"}`, string(bundle))
+}
+
+func TestScan_AttestationWithoutCosign(t *testing.T) {
+	t.Setenv("PATH", t.TempDir())
+
+	err := scan(ScanOptions{Dir: t.TempDir(), Rev: "HEAD", PlatformURL: "https://platform.example.com", ConsoleURL: "https://console.example.com", OutputFormat: "markdown",
+		Attest: AttestOptions{Path: filepath.Join(t.TempDir(), "scan.intoto.json")}}, false, &scanMock{})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "needs cosign on PATH")
+}
+
+func TestUploadAttestation(t *testing.T) {
+	var got map[string]json.RawMessage
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodPost, r.Method)
+		assert.Equal(t, "/inspector/attestation", r.URL.Path)
+		assert.Equal(t, "Bearer token", r.Header.Get("Authorization"))
+		assert.Equal(t, "ws-1", r.Header.Get("X-Kusari-Workspace"))
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&got))
+		w.WriteHeader(http.StatusCreated)
+	}))
+	defer srv.Close()
+
+	err := uploadAttestation(srv.URL, "token", "ws-1", "sort-key", []byte(`{"_type":"statement"}`), []byte(`{"mediaType":"bundle"}`))
+	require.NoError(t, err)
+	assert.JSONEq(t, `"sort-key"`, string(got["sort_key"]))
+	assert.JSONEq(t, `{"_type":"statement"}`, string(got["statement"]))
+	assert.JSONEq(t, `{"mediaType":"bundle"}`, string(got["bundle"]))
+
+	failing := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+	}))
+	defer failing.Close()
+	err = uploadAttestation(failing.URL, "token", "ws-1", "sort-key", []byte(`{}`), []byte(`{}`))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "status 403")
+}
@@ -11,6 +11,7 @@ import (
 	"os"
 	"os/exec"
 	"path/filepath"
+	"slices"
 	"strings"
 	"time"
 )
@@ -197,9 +198,9 @@ func CheckCache(repoPath, baseRef string, verbose bool) (*CacheResult, error) {
 	}
 
 	// Check if cache is too old
-	if time.Since(entry.Timestamp) > CacheMaxAge {
+	if clk.Now().Sub(entry.Timestamp) > CacheMaxAge {
 		if verbose {
-			fmt.Fprintf(os.Stderr, "Cache entry expired (age: %v)\n", time.Since(entry.Timestamp))
+			fmt.Fprintf(os.Stderr, "Cache entry expired (age: %v)\n", clk.Now().Sub(entry.Timestamp))
 		}
 		return &CacheResult{Hit: false}, nil
 	}
@@ -236,6 +237,31 @@ func CheckCache(repoPath, baseRef string, verbose bool) (*CacheResult, error) {
 	return &CacheResult{Hit: false}, nil
 }
 
+// LastScanResult returns the most recent cached scan of repoPath, whatever
+// its base ref, and whether it still matches the working tree (the diff
+// against its base ref is unchanged). It returns nil when the repository
+// has no cached scan younger than CacheMaxAge.
+func LastScanResult(repoPath string) (*ScanCacheEntry, bool, error) {
+	absPath, err := filepath.Abs(repoPath)
+	if err != nil {
+		absPath = repoPath
+	}
+
+	cache, err := loadCache()
+	if err != nil {
+		return nil, false, err
+	}
+
+	entry, exists := cache.Entries[absPath]
+	if !exists || clk.Now().Sub(entry.Timestamp) > CacheMaxAge {
+		return nil, false, nil
+	}
+
+	currentHash, err := computeDiffHash(absPath, entry.BaseRef)
+	current := err == nil && currentHash == entry.DiffHash
+	return &entry, current, nil
+}
+
 // SaveToCache stores a scan result in the cache.
 func SaveToCache(repoPath, baseRef, results, consoleURL string, verbose bool) error {
 	// Normalize repo path to absolute
@@ -260,7 +286,7 @@ func SaveToCache(repoPath, baseRef, results, consoleURL string, verbose bool) er
 		BaseRef:    baseRef,
 		Results:    results,
 		ConsoleURL: consoleURL,
-		Timestamp:  time.Now(),
+		Timestamp:  clk.Now(),
 	}
 
 	// Clean up old entries while we're at it
@@ -276,7 +302,7 @@ func SaveToCache(repoPath, baseRef, results, consoleURL string, verbose bool) er
 // cleanupOldEntries removes cache entries older than CacheMaxAge.
 func cleanupOldEntries(cache *ScanCache) {
 	for path, entry := range cache.Entries {
-		if time.Since(entry.Timestamp) > CacheMaxAge {
+		if clk.Now().Sub(entry.Timestamp) > CacheMaxAge {
 			delete(cache.Entries, path)
 		}
 	}
@@ -295,3 +321,31 @@ func ClearCache() error {
 
 	return nil
 }
+
+// PruneCache removes cached scans older than maxAge, and those of
+// repositories that no longer exist. It returns the repository paths of
+// the removed entries; with dryRun the cache is left as is.
+func PruneCache(maxAge time.Duration, dryRun bool) ([]string, error) {
+	cache, err := loadCache()
+	if err != nil {
+		return nil, err
+	}
+
+	var pruned []string
+	for path, entry := range cache.Entries {
+		_, statErr := os.Stat(path)
+		if clk.Now().Sub(entry.Timestamp) > maxAge || os.IsNotExist(statErr) {
+			pruned = append(pruned, path)
+			delete(cache.Entries, path)
+		}
+	}
+	slices.Sort(pruned)
+
+	if dryRun || len(pruned) == 0 {
+		return pruned, nil
+	}
+	if len(cache.Entries) == 0 {
+		return pruned, ClearCache()
+	}
+	return pruned, saveCache(cache)
+}
@@ -110,6 +110,37 @@ func TestClearCache(t *testing.T) {
 	assert.True(t, os.IsNotExist(err))
 }
 
+func TestPruneCache(t *testing.T) {
+	tmpDir := t.TempDir()
+	origHome := os.Getenv("HOME")
+	require.NoError(t, os.Setenv("HOME", tmpDir))
+	defer func() { _ = os.Setenv("HOME", origHome) }()
+
+	fresh, old := t.TempDir(), t.TempDir()
+	gone := filepath.Join(tmpDir, "deleted-repo")
+	require.NoError(t, saveCache(&ScanCache{Entries: map[string]ScanCacheEntry{
+		fresh: {Timestamp: time.Now()},
+		old:   {Timestamp: time.Now().Add(-2 * time.Hour)},
+		gone:  {Timestamp: time.Now()},
+	}}))
+
+	// Dry run leaves the cache as is
+	pruned, err := PruneCache(time.Hour, true)
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{old, gone}, pruned)
+	cache, err := loadCache()
+	require.NoError(t, err)
+	assert.Len(t, cache.Entries, 3)
+
+	pruned, err = PruneCache(time.Hour, false)
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{old, gone}, pruned)
+	cache, err = loadCache()
+	require.NoError(t, err)
+	assert.Len(t, cache.Entries, 1)
+	assert.Contains(t, cache.Entries, fresh)
+}
+
 func TestClearCache_NoFile(t *testing.T) {
 	tmpDir := t.TempDir()
 	origHome := os.Getenv("HOME")
@@ -180,3 +211,31 @@ func TestCheckCache_NoEntry(t *testing.T) {
 	assert.NotNil(t, result)
 	assert.False(t, result.Hit)
 }
+
+func TestLastScanResult(t *testing.T) {
+	tmpDir := t.TempDir()
+	t.Setenv("HOME", tmpDir)
+	require.NoError(t, os.MkdirAll(filepath.Join(tmpDir, ".kusari"), 0700))
+
+	entry, _, err := LastScanResult("/test/repo")
+	require.NoError(t, err)
+	assert.Nil(t, entry)
+
+	require.NoError(t, saveCache(&ScanCache{
+		Entries: map[string]ScanCacheEntry{
+			"/test/repo": {DiffHash: "abc123", BaseRef: "main", Results: "test results", Timestamp: time.Now()},
+			"/old/repo":  {DiffHash: "abc123", BaseRef: "main", Results: "old", Timestamp: time.Now().Add(-2 * CacheMaxAge)},
+		},
+	}))
+
+	entry, current, err := LastScanResult("/test/repo")
+	require.NoError(t, err)
+	require.NotNil(t, entry)
+	assert.Equal(t, "test results", entry.Results)
+	assert.Equal(t, "main", entry.BaseRef)
+	assert.False(t, current, "not a git repository, so the diff can't match")
+
+	entry, _, err = LastScanResult("/old/repo")
+	require.NoError(t, err)
+	assert.Nil(t, entry)
+}
@@ -0,0 +1,75 @@
+// Copyright (c) Kusari <https://www.kusari.dev/>
+// SPDX-License-Identifier: MIT
+
+package repo
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/kusaridev/kusari-cli/v2/api"
+	"github.com/kusaridev/kusari-cli/v2/pkg/clierrors"
+)
+
+// checkNamePattern is the form of a risk check sub-scan name.
+var checkNamePattern = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]*$`)
+
+// ParseChecks normalizes the sub-scan names given to --checks: lower case,
+// without duplicates, in the order given. - and _ are the same in names.
+// A risk check limited to them with ScanOptions.Checks sends the selection
+// to the platform in the bundle metadata, and shows only those categories.
+func ParseChecks(names []string) ([]string, error) {
+	var checks []string
+	for _, name := range names {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "" {
+			continue
+		}
+		if !checkNamePattern.MatchString(name) {
+			return nil, clierrors.NewValidationError("invalid check %q (names are letters, digits, - and _, e.g. dependencies)", name)
+		}
+		if !slices.ContainsFunc(checks, func(c string) bool { return checkKey(c) == checkKey(name) }) {
+			checks = append(checks, name)
+		}
+	}
+	return checks, nil
+}
+
+// checkKey folds the spelling of a sub-scan name, so that ci-config
+// selects the ci_config category.
+func checkKey(name string) string {
+	return strings.ReplaceAll(strings.ToLower(name), "-", "_")
+}
+
+// filterHealth returns a copy of a with only the Health categories in
+// checks. An empty selection keeps every category.
+func filterHealth(a *api.Analysis, checks []string) *api.Analysis {
+	if a == nil || len(checks) == 0 {
+		return a
+	}
+	filtered := *a
+	filtered.Health = api.Health{}
+	for category, sub := range a.Health {
+		if slices.ContainsFunc(checks, func(c string) bool { return checkKey(c) == checkKey(category) }) {
+			filtered.Health[category] = sub
+		}
+	}
+	return &filtered
+}
+
+// warnMissingChecks warns about the checks a has no Health category for,
+// which the platform skipped or doesn't know.
+func warnMissingChecks(a *api.Analysis, checks []string) {
+	for _, c := range checks {
+		found := false
+		for category := range a.Health {
+			found = found || checkKey(category) == checkKey(c)
+		}
+		if !found {
+			fmt.Fprintf(os.Stderr, "Warning: the risk check returned no results for check %q\n", c)
+		}
+	}
+}
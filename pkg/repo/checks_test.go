@@ -0,0 +1,41 @@
+// Copyright (c) Kusari <https://www.kusari.dev/>
+// SPDX-License-Identifier: MIT
+
+package repo
+
+import (
+	"testing"
+
+	"github.com/kusaridev/kusari-cli/v2/api"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseChecks(t *testing.T) {
+	checks, err := ParseChecks([]string{"Dependencies", " ci-config", "", "secrets", "ci_config", "dependencies"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"dependencies", "ci-config", "secrets"}, checks)
+
+	checks, err = ParseChecks(nil)
+	require.NoError(t, err)
+	assert.Empty(t, checks)
+
+	_, err = ParseChecks([]string{"ci config"})
+	assert.ErrorContains(t, err, `invalid check "ci config"`)
+}
+
+func TestFilterHealth(t *testing.T) {
+	a := &api.Analysis{Score: 3, Health: api.Health{
+		"dependencies": {Score: 2},
+		"ci_config":    {Score: 4},
+		"maintenance":  {Score: 5},
+	}}
+
+	filtered := filterHealth(a, []string{"ci-config", "dependencies", "secrets"})
+	assert.Equal(t, api.Health{"dependencies": {Score: 2}, "ci_config": {Score: 4}}, filtered.Health)
+	assert.Equal(t, 3, filtered.Score)
+	assert.Len(t, a.Health, 3, "the analysis is left as is")
+
+	assert.Same(t, a, filterHealth(a, nil))
+	assert.Nil(t, filterHealth(nil, []string{"secrets"}))
+}
@@ -0,0 +1,375 @@
+// Copyright (c) Kusari <https://www.kusari.dev/>
+// SPDX-License-Identifier: MIT
+
+package repo
+
+import (
+	"archive/tar"
+	"compress/bzip2"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/kusaridev/kusari-cli/v2/api"
+	"github.com/kusaridev/kusari-cli/v2/pkg/output"
+)
+
+const (
+	deltaBasesFileName = "delta-bases.json"
+	deltaTarballName   = "kusari-inspector-delta.tar"
+)
+
+// deltaBase is the manifest of the last full scan bundle of a repository
+// the platform received, which the next delta bundle is made against.
+type deltaBase struct {
+	SortKey    string             `json:"sort_key"`
+	Digest     string             `json:"digest"`
+	Files      []api.ManifestFile `json:"files"`
+	UploadedAt time.Time          `json:"uploaded_at"`
+}
+
+// deltaBases are the bases of every repository, keyed by deltaKey.
+type deltaBases struct {
+	Entries map[string]deltaBase `json:"entries"`
+}
+
+// deltaKey identifies the repository at dir, its branch and the workspace
+// of platformURL its scans upload to.
+func deltaKey(platformURL, workspace, dir, branch string) string {
+	sum := sha256.Sum256([]byte(strings.Join([]string{platformURL, workspace, dir, branch}, "\x00")))
+	return hex.EncodeToString(sum[:])
+}
+
+// getDeltaBasesPath returns the path of the delta bases file.
+func getDeltaBasesPath() (string, error) {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to get home directory: %w", err)
+	}
+	return filepath.Join(homeDir, ".kusari", deltaBasesFileName), nil
+}
+
+// loadDeltaBases loads the delta bases from disk. A corrupted file starts
+// afresh: the next scans then upload whole bundles.
+func loadDeltaBases() (*deltaBases, error) {
+	path, err := getDeltaBasesPath()
+	if err != nil {
+		return nil, err
+	}
+	bases := &deltaBases{}
+	data, err := os.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("failed to read delta bases: %w", err)
+	}
+	if err == nil {
+		_ = json.Unmarshal(data, bases)
+	}
+	if bases.Entries == nil {
+		bases.Entries = make(map[string]deltaBase)
+	}
+	return bases, nil
+}
+
+// saveDeltaBases saves the delta bases to disk.
+func saveDeltaBases(bases *deltaBases) error {
+	path, err := getDeltaBasesPath()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return fmt.Errorf("failed to create delta bases directory: %w", err)
+	}
+	data, err := json.Marshal(bases)
+	if err != nil {
+		return fmt.Errorf("failed to marshal delta bases: %w", err)
+	}
+	if err := os.WriteFile(path, data, 0600); err != nil {
+		return fmt.Errorf("failed to write delta bases: %w", err)
+	}
+	return nil
+}
+
+// PruneDeltaBases removes the delta bases uploaded longer than maxAge ago;
+// the next full scan of their repository then uploads the whole bundle.
+// It returns the sort keys of the removed bases; with dryRun the file is
+// left as is.
+func PruneDeltaBases(maxAge time.Duration, dryRun bool) ([]string, error) {
+	bases, err := loadDeltaBases()
+	if err != nil {
+		return nil, err
+	}
+
+	var pruned []string
+	for key, base := range bases.Entries {
+		if clk.Now().Sub(base.UploadedAt) > maxAge {
+			pruned = append(pruned, base.SortKey)
+			delete(bases.Entries, key)
+		}
+	}
+	slices.Sort(pruned)
+
+	if dryRun || len(pruned) == 0 {
+		return pruned, nil
+	}
+	return pruned, saveDeltaBases(bases)
+}
+
+// readBundle calls fn with the header and content of each regular file of
+// the bundle at path.
+func readBundle(path string, fn func(hdr *tar.Header, content []byte) error) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("failed to open package: %w", err)
+	}
+	defer func() { _ = f.Close() }()
+	tr := tar.NewReader(bzip2.NewReader(f))
+	for {
+		hdr, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("failed to read package: %w", err)
+		}
+		if hdr.Typeflag != tar.TypeReg {
+			continue
+		}
+		content, err := io.ReadAll(tr)
+		if err != nil {
+			return fmt.Errorf("failed to read %s from package: %w", hdr.Name, err)
+		}
+		if err := fn(hdr, content); err != nil {
+			return err
+		}
+	}
+}
+
+// bundleManifest returns the manifest of what the bundle at path holds,
+// after any pre_upload hook, and its digest.
+func bundleManifest(path string) (*api.BundleManifest, string, error) {
+	manifest := &api.BundleManifest{Files: []api.ManifestFile{}}
+	err := readBundle(path, func(hdr *tar.Header, content []byte) error {
+		if hdr.Name != manifestFile {
+			manifest.Files = append(manifest.Files, manifestEntry(hdr.Name, content))
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, "", err
+	}
+	data, err := json.Marshal(manifest)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to marshal bundle manifest: %w", err)
+	}
+	sum := sha256.Sum256(data)
+	return manifest, hex.EncodeToString(sum[:]), nil
+}
+
+func manifestEntry(path string, content []byte) api.ManifestFile {
+	sum := sha256.Sum256(content)
+	return api.ManifestFile{Path: path, Size: int64(len(content)), SHA256: hex.EncodeToString(sum[:])}
+}
+
+// diffManifests returns how full, of the given digest, differs from base.
+// The metadata and patch are part of every bundle, so they are left out.
+func diffManifests(base deltaBase, full *api.BundleManifest, digest string) *api.ManifestDiff {
+	diff := &api.ManifestDiff{
+		BaseSortKey: base.SortKey,
+		BaseDigest:  base.Digest,
+		Digest:      digest,
+		Added:       []api.ManifestFile{},
+		Changed:     []api.ManifestFile{},
+		Removed:     []string{},
+	}
+	before := make(map[string]string, len(base.Files))
+	for _, f := range base.Files {
+		before[f.Path] = f.SHA256
+	}
+	for _, f := range full.Files {
+		if f.Path == metaFile || f.Path == patchFile {
+			delete(before, f.Path)
+			continue
+		}
+		sum, ok := before[f.Path]
+		delete(before, f.Path)
+		switch {
+		case !ok:
+			diff.Added = append(diff.Added, f)
+		case sum != f.SHA256:
+			diff.Changed = append(diff.Changed, f)
+		default:
+			diff.Unchanged++
+		}
+	}
+	for _, f := range base.Files {
+		if _, ok := before[f.Path]; ok {
+			diff.Removed = append(diff.Removed, f.Path)
+		}
+	}
+	return diff
+}
+
+// makeDelta writes the delta bundle of the bundle at path against diff:
+// its metadata, flagged as a delta, its patch, the files diff added or
+// changed, the manifest diff and a manifest of all of them. It returns the
+// delta bundle's path and size.
+func makeDelta(path string, diff *api.ManifestDiff) (string, int64, error) {
+	keep := map[string]bool{metaFile: true, patchFile: true}
+	for _, f := range append(diff.Added, diff.Changed...) {
+		keep[f.Path] = true
+	}
+
+	outFile := filepath.Join(tarballDir, deltaTarballName)
+	out, err := os.Create(outFile)
+	if err != nil {
+		return "", 0, fmt.Errorf("failed to create delta package: %w", err)
+	}
+	defer func() { _ = out.Close() }()
+	tw := tar.NewWriter(out)
+	manifest := &api.BundleManifest{Files: []api.ManifestFile{}}
+	var template *tar.Header
+	add := func(hdr *tar.Header, content []byte) error {
+		hdr.Size = int64(len(content))
+		if err := tw.WriteHeader(hdr); err != nil {
+			return fmt.Errorf("failed to write delta package: %w", err)
+		}
+		if _, err := tw.Write(content); err != nil {
+			return fmt.Errorf("failed to write delta package: %w", err)
+		}
+		if hdr.Name != manifestFile {
+			manifest.Files = append(manifest.Files, manifestEntry(hdr.Name, content))
+		}
+		return nil
+	}
+	err = readBundle(path, func(hdr *tar.Header, content []byte) error {
+		if !keep[hdr.Name] {
+			return nil
+		}
+		if hdr.Name == metaFile {
+			template = hdr
+			content = flagDelta(content)
+		}
+		return add(hdr, content)
+	})
+	if err != nil {
+		return "", 0, err
+	}
+	if template == nil {
+		return "", 0, errors.New("failed to make delta package: the package has no metadata")
+	}
+
+	for _, f := range []struct {
+		name string
+		v    any
+	}{{manifestDiffFile, diff}, {manifestFile, manifest}} {
+		data, err := json.Marshal(f.v)
+		if err != nil {
+			return "", 0, fmt.Errorf("failed to marshal %s: %w", f.name, err)
+		}
+		hdr := *template
+		hdr.Name = f.name
+		if err := add(&hdr, data); err != nil {
+			return "", 0, err
+		}
+	}
+	if err := tw.Close(); err != nil {
+		return "", 0, fmt.Errorf("failed to write delta package: %w", err)
+	}
+	if err := out.Close(); err != nil {
+		return "", 0, fmt.Errorf("failed to write delta package: %w", err)
+	}
+
+	if err := exec.Command("bzip2", "-f", outFile).Run(); err != nil {
+		return "", 0, fmt.Errorf("error compressing delta package: %w", err)
+	}
+	fi, err := os.Stat(outFile + ".bz2")
+	if err != nil {
+		return "", 0, fmt.Errorf("error stating delta package: %w", err)
+	}
+	return outFile + ".bz2", fi.Size(), nil
+}
+
+// flagDelta sets delta in the bundle metadata meta, keeping the fields
+// already there. Metadata that isn't JSON, which a pre_upload hook could
+// have made it, is left as is.
+func flagDelta(meta []byte) []byte {
+	var m map[string]any
+	if err := json.Unmarshal(meta, &m); err != nil {
+		return meta
+	}
+	m["delta"] = true
+	data, err := json.Marshal(m)
+	if err != nil {
+		return meta
+	}
+	return data
+}
+
+// deltaUpload is the base the next delta bundle of a repository is made
+// against once the platform received this scan's bundle.
+type deltaUpload struct {
+	key  string
+	next deltaBase
+}
+
+// prepareDelta replaces the full scan bundle in tarballDir, of size bytes,
+// with its delta against the last one uploaded for key, when there is one
+// and the delta is smaller. It returns whether it did, the bundle's size,
+// and the base to record once the bundle is uploaded.
+func prepareDelta(key string, size int64) (bool, int64, *deltaUpload, error) {
+	bundle := filepath.Join(tarballDir, tarballName)
+	manifest, digest, err := bundleManifest(bundle)
+	if err != nil {
+		return false, 0, nil, fmt.Errorf("failed to read package manifest: %w", err)
+	}
+	upload := &deltaUpload{key: key, next: deltaBase{Digest: digest, Files: manifest.Files}}
+
+	bases, err := loadDeltaBases()
+	if err != nil {
+		return false, 0, nil, err
+	}
+	base, ok := bases.Entries[key]
+	if !ok {
+		output.Progressf(os.Stderr, "No earlier --delta risk check of this repository and branch; uploading the whole package\n")
+		return false, size, upload, nil
+	}
+
+	diff := diffManifests(base, manifest, digest)
+	deltaBundle, deltaSize, err := makeDelta(bundle, diff)
+	if err != nil {
+		return false, 0, nil, err
+	}
+	if deltaSize >= size {
+		_ = os.Remove(deltaBundle)
+		output.Progressf(os.Stderr, "The delta is no smaller than the whole package; uploading the whole package\n")
+		return false, size, upload, nil
+	}
+	if err := os.Rename(deltaBundle, bundle); err != nil {
+		return false, 0, nil, fmt.Errorf("failed to replace package with its delta: %w", err)
+	}
+	output.Progressf(os.Stderr, "Uploading the delta since the last risk check: %d added, %d changed, %d removed, %d unchanged files (%s instead of %s)\n",
+		len(diff.Added), len(diff.Changed), len(diff.Removed), diff.Unchanged, output.FormatSize(deltaSize), output.FormatSize(size))
+	return true, deltaSize, upload, nil
+}
+
+// record makes the uploaded bundle, whose result is at sortKey, the base
+// of the next delta bundle.
+func (u *deltaUpload) record(sortKey string) error {
+	bases, err := loadDeltaBases()
+	if err != nil {
+		return err
+	}
+	u.next.SortKey = sortKey
+	u.next.UploadedAt = clk.Now()
+	bases.Entries[u.key] = u.next
+	return saveDeltaBases(bases)
+}
@@ -0,0 +1,120 @@
+// Copyright (c) Kusari <https://www.kusari.dev/>
+// SPDX-License-Identifier: MIT
+
+package repo
+
+import (
+	"archive/tar"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/kusaridev/kusari-cli/v2/api"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestScan_Delta(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	testDir := t.TempDir()
+	// scan changes into testDir; restore the working directory after.
+	t.Chdir(testDir)
+	runCmd(t, testDir, "git", "init")
+	runCmd(t, testDir, "git", "config", "user.email", "test@example.com")
+	runCmd(t, testDir, "git", "config", "user.name", "Test User")
+	for i := range 20 {
+		random := make([]byte, 4096)
+		_, _ = rand.Read(random)
+		writeFile(t, filepath.Join(testDir, fmt.Sprintf("file%02d.txt", i)), hex.EncodeToString(random))
+	}
+	writeFile(t, filepath.Join(testDir, "old.txt"), "removed")
+	runCmd(t, testDir, "git", "add", ".")
+	runCmd(t, testDir, "git", "commit", "-m", "initial commit")
+
+	var diff api.ManifestDiff
+	riskCheck := func() (string, []string, api.BundleMeta) {
+		var scanType string
+		var files []string
+		var meta api.BundleMeta
+		mock := bundleMock(t, &scanType, &files, &meta)
+		upload := mock.fileUploader
+		mock.fileUploader = func(presignedURL, filePath string) error {
+			require.NoError(t, readBundle(filePath, func(hdr *tar.Header, content []byte) error {
+				if hdr.Name == manifestDiffFile {
+					require.NoError(t, json.Unmarshal(content, &diff))
+				}
+				return nil
+			}))
+			return upload(presignedURL, filePath)
+		}
+		err := scan(ScanOptions{Dir: testDir, Rev: "HEAD", PlatformURL: "https://platform.example.com", ConsoleURL: "https://console.example.com", OutputFormat: "markdown", Delta: true}, true, mock)
+		require.NoError(t, err)
+		return scanType, files, meta
+	}
+
+	t.Run("first risk check uploads the whole package", func(t *testing.T) {
+		scanType, files, meta := riskCheck()
+		assert.Equal(t, "full", scanType)
+		assert.Len(t, files, 23)
+		assert.False(t, meta.Delta)
+
+		bases, err := loadDeltaBases()
+		require.NoError(t, err)
+		require.Len(t, bases.Entries, 1)
+		for _, base := range bases.Entries {
+			assert.Contains(t, base.SortKey, "123")
+			assert.Len(t, base.Files, 22)
+		}
+	})
+
+	t.Run("next risk check uploads the delta", func(t *testing.T) {
+		writeFile(t, filepath.Join(testDir, "file03.txt"), "changed")
+		writeFile(t, filepath.Join(testDir, "new.txt"), "added")
+		require.NoError(t, os.Remove(filepath.Join(testDir, "old.txt")))
+		runCmd(t, testDir, "git", "add", "-A")
+		runCmd(t, testDir, "git", "commit", "-m", "second commit")
+
+		scanType, files, meta := riskCheck()
+		assert.Equal(t, "full-delta", scanType)
+		assert.ElementsMatch(t, []string{metaFile, "file03.txt", "new.txt", manifestDiffFile, manifestFile}, files)
+		assert.True(t, meta.Delta)
+		assert.NotEmpty(t, meta.CurrentBranch)
+
+		assert.Contains(t, diff.BaseSortKey, "123")
+		require.Len(t, diff.Added, 1)
+		assert.Equal(t, "new.txt", diff.Added[0].Path)
+		require.Len(t, diff.Changed, 1)
+		assert.Equal(t, "file03.txt", diff.Changed[0].Path)
+		assert.Equal(t, []string{"old.txt"}, diff.Removed)
+		assert.Equal(t, 19, diff.Unchanged)
+		assert.NotEqual(t, diff.BaseDigest, diff.Digest)
+	})
+}
+
+func TestDiffManifests(t *testing.T) {
+	base := deltaBase{SortKey: "1", Digest: "d1", Files: []api.ManifestFile{
+		{Path: metaFile, SHA256: "m1"},
+		{Path: "a", SHA256: "a1"},
+		{Path: "b", SHA256: "b1"},
+		{Path: "c", SHA256: "c1"},
+	}}
+	full := &api.BundleManifest{Files: []api.ManifestFile{
+		{Path: metaFile, SHA256: "m2"},
+		{Path: patchFile, SHA256: "p2"},
+		{Path: "a", SHA256: "a1"},
+		{Path: "b", SHA256: "b2"},
+		{Path: "d", SHA256: "d2"},
+	}}
+	diff := diffManifests(base, full, "d2")
+	assert.Equal(t, "1", diff.BaseSortKey)
+	assert.Equal(t, "d1", diff.BaseDigest)
+	assert.Equal(t, "d2", diff.Digest)
+	assert.Equal(t, []api.ManifestFile{{Path: "d", SHA256: "d2"}}, diff.Added)
+	assert.Equal(t, []api.ManifestFile{{Path: "b", SHA256: "b2"}}, diff.Changed)
+	assert.Equal(t, []string{"c"}, diff.Removed)
+	assert.Equal(t, 1, diff.Unchanged)
+}
@@ -7,19 +7,49 @@ import (
 	"bytes"
 	"fmt"
 	"io"
+	"maps"
 	"os"
 	"os/exec"
+	"slices"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/kusaridev/kusari-cli/v2/api"
 )
 
-func generateDiff(rev string) error {
+// generateDiff writes the patch of source against opts.Rev: of the
+// working tree, untracked files included, of the index, or of HEAD. It
+// returns how the patch was normalized, or nil if it wasn't.
+func generateDiff(opts ScanOptions, source packageSource) (*api.PatchNormalization, error) {
+	rev := opts.Rev
 	if err := validateRev(rev); err != nil {
-		return err
+		return nil, err
+	}
+	switch source {
+	case sourceIndex:
+		output, err := exec.Command("git", opts.gitPaths("diff", "--cached", "--binary", rev)...).Output()
+		if err != nil {
+			return nil, fmt.Errorf("failed to run git diff: %w", err)
+		}
+		if len(output) == 0 {
+			return nil, fmt.Errorf("no staged changes: git diff --cached %v produced no output", rev)
+		}
+		return writePatch(output)
+	case sourceHead:
+		output, err := exec.Command("git", opts.gitPaths("diff", "--binary", rev, "HEAD")...).Output()
+		if err != nil {
+			return nil, fmt.Errorf("failed to run git diff: %w", err)
+		}
+		if len(output) == 0 {
+			return nil, fmt.Errorf("no committed changes: git diff %v HEAD produced no output", rev)
+		}
+		return writePatch(output)
 	}
 
 	// First, get list of untracked files (not in .gitignore)
-	untrackedOutput, err := exec.Command("git", "ls-files", "--others", "--exclude-standard").Output()
+	untrackedOutput, err := exec.Command("git", opts.gitPaths("ls-files", "--others", "--exclude-standard")...).Output()
 	if err != nil {
-		return fmt.Errorf("failed to list untracked files: %w", err)
+		return nil, fmt.Errorf("failed to list untracked files: %w", err)
 	}
 
 	// Use git add -N to add untracked files to index (intent-to-add, no content staging)
@@ -37,7 +67,7 @@ func generateDiff(rev string) error {
 		}
 		addCmd := exec.Command("git", args...)
 		if err := addCmd.Run(); err != nil {
-			return fmt.Errorf("failed to add untracked files to index: %w", err)
+			return nil, fmt.Errorf("failed to add untracked files to index: %w", err)
 		}
 		// Ensure we reset the index afterward
 		defer func() {
@@ -46,25 +76,101 @@ func generateDiff(rev string) error {
 	}
 
 	// Generate diff including both tracked and untracked files
-	output, err := exec.Command("git", "diff", "--binary", rev).Output()
+	output, err := exec.Command("git", opts.gitPaths("diff", "--binary", rev)...).Output()
 	if err != nil {
-		return fmt.Errorf("failed to run git diff: %w", err)
+		return nil, fmt.Errorf("failed to run git diff: %w", err)
 	}
 	if len(output) == 0 && !hasUntrackedFiles {
-		return fmt.Errorf("git diff command produced no output: git diff %v", rev)
+		return nil, fmt.Errorf("git diff command produced no output: git diff %v", rev)
 	}
+	return writePatch(output)
+}
 
+// writePatch normalizes output and writes it to the patch file.
+func writePatch(output []byte) (*api.PatchNormalization, error) {
+	output, norm := normalizePatch(output)
 	f, err := os.Create(patchName)
 	if err != nil {
-		return fmt.Errorf("failed to open patch file: %w", err)
+		return nil, fmt.Errorf("failed to open patch file: %w", err)
 	}
 	defer func() {
 		_ = f.Close()
 	}()
 	if _, err := io.Copy(f, bytes.NewReader(output)); err != nil {
-		return fmt.Errorf("failed to write patch file: %w", err)
+		return nil, fmt.Errorf("failed to write patch file: %w", err)
 	}
-	return nil
+	return norm, nil
+}
+
+// normalizePatch rewrites the content lines of patch as LF-terminated
+// UTF-8, so that the platform reads the same lines and line numbers as git
+// does: carriage returns before line feeds are stripped, and lines that
+// aren't valid UTF-8 are decoded as ISO-8859-1, the likeliest encoding of
+// such files. Binary patches are left as they are. It returns the patch and
+// the files that were rewritten, or nil if none were.
+func normalizePatch(patch []byte) ([]byte, *api.PatchNormalization) {
+	crlf := map[string]bool{}
+	latin1 := map[string]bool{}
+	out := make([]byte, 0, len(patch))
+	var file string
+	var inHunk, binary bool
+	for _, line := range bytes.SplitAfter(patch, []byte("\n")) {
+		switch {
+		case bytes.HasPrefix(line, []byte("diff --git ")):
+			file = patchFileName(line)
+			inHunk, binary = false, false
+		case bytes.HasPrefix(line, []byte("GIT binary patch")):
+			binary = true
+		case bytes.HasPrefix(line, []byte("@@")):
+			inHunk = !binary
+		case inHunk && len(line) > 0 && (line[0] == ' ' || line[0] == '+' || line[0] == '-'):
+			if bytes.HasSuffix(line, []byte("\r\n")) {
+				line = append(line[:len(line)-2:len(line)-2], '\n')
+				crlf[file] = true
+			}
+			if !utf8.Valid(line) {
+				line = latin1ToUTF8(line)
+				latin1[file] = true
+			}
+		}
+		out = append(out, line...)
+	}
+	if len(crlf) == 0 && len(latin1) == 0 {
+		return patch, nil
+	}
+	return out, &api.PatchNormalization{
+		CRLFFiles:   sortedKeys(crlf),
+		Latin1Files: sortedKeys(latin1),
+	}
+}
+
+// patchFileName returns the new path of a "diff --git a/old b/new" line.
+func patchFileName(line []byte) string {
+	s := strings.TrimRight(string(line), "\r\n")
+	if strings.HasSuffix(s, `"`) {
+		if i := strings.LastIndex(s, ` "b/`); i >= 0 {
+			return strings.TrimSuffix(s[i+4:], `"`)
+		}
+	}
+	if i := strings.LastIndex(s, " b/"); i >= 0 {
+		return s[i+3:]
+	}
+	return s
+}
+
+func latin1ToUTF8(b []byte) []byte {
+	out := make([]byte, 0, len(b)+len(b)/4)
+	for _, c := range b {
+		out = utf8.AppendRune(out, rune(c))
+	}
+	return out
+}
+
+func sortedKeys(m map[string]bool) []string {
+	if len(m) == 0 {
+		return nil
+	}
+	return slices.Sorted(maps.Keys(m))
 }
 
 func validateRev(rev string) error {
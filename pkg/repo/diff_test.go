@@ -0,0 +1,52 @@
+// Copyright (c) Kusari <https://www.kusari.dev/>
+// SPDX-License-Identifier: MIT
+
+package repo
+
+import (
+	"testing"
+
+	"github.com/kusaridev/kusari-cli/v2/api"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNormalizePatch(t *testing.T) {
+	tests := []struct {
+		name     string
+		patch    string
+		want     string
+		wantNorm *api.PatchNormalization
+	}{
+		{
+			name:  "unchanged",
+			patch: "diff --git a/a.txt b/a.txt\n--- a/a.txt\n+++ b/a.txt\n@@ -1 +1 @@\n-one\n+two\n",
+			want:  "diff --git a/a.txt b/a.txt\n--- a/a.txt\n+++ b/a.txt\n@@ -1 +1 @@\n-one\n+two\n",
+		},
+		{
+			name: "CRLF",
+			patch: "diff --git a/win.txt b/win.txt\n--- a/win.txt\n+++ b/win.txt\n@@ -1,2 +1,2 @@\n one\r\n-two\r\n+three\r\n" +
+				"diff --git a/unix.txt b/unix.txt\n--- a/unix.txt\n+++ b/unix.txt\n@@ -1 +1 @@\n-one\n+two\n",
+			want: "diff --git a/win.txt b/win.txt\n--- a/win.txt\n+++ b/win.txt\n@@ -1,2 +1,2 @@\n one\n-two\n+three\n" +
+				"diff --git a/unix.txt b/unix.txt\n--- a/unix.txt\n+++ b/unix.txt\n@@ -1 +1 @@\n-one\n+two\n",
+			wantNorm: &api.PatchNormalization{CRLFFiles: []string{"win.txt"}},
+		},
+		{
+			name:     "Latin-1",
+			patch:    "diff --git \"a/caf\\303\\251.txt\" \"b/caf\\303\\251.txt\"\n@@ -1 +1 @@\n-caf\xe9\r\n+caf\xe9s\r\n",
+			want:     "diff --git \"a/caf\\303\\251.txt\" \"b/caf\\303\\251.txt\"\n@@ -1 +1 @@\n-café\n+cafés\n",
+			wantNorm: &api.PatchNormalization{CRLFFiles: []string{`caf\303\251.txt`}, Latin1Files: []string{`caf\303\251.txt`}},
+		},
+		{
+			name:  "binary",
+			patch: "diff --git a/img.png b/img.png\nGIT binary patch\nliteral 4\nLcmZ\xff\r\n\n",
+			want:  "diff --git a/img.png b/img.png\nGIT binary patch\nliteral 4\nLcmZ\xff\r\n\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, norm := normalizePatch([]byte(tt.patch))
+			assert.Equal(t, tt.want, string(got))
+			assert.Equal(t, tt.wantNorm, norm)
+		})
+	}
+}
@@ -0,0 +1,280 @@
+// Copyright (c) Kusari <https://www.kusari.dev/>
+// SPDX-License-Identifier: MIT
+
+package repo
+
+import (
+	"bufio"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/binary"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/kusaridev/kusari-cli/v2/pkg/clierrors"
+	"github.com/kusaridev/kusari-cli/v2/pkg/fips"
+	urlBuilder "github.com/kusaridev/kusari-cli/v2/pkg/url"
+)
+
+// BundleEncryption selects whether scan bundles are encrypted on this
+// machine before upload, on top of TLS and server-side encryption.
+type BundleEncryption string
+
+const (
+	// BundleEncryptionNone uploads bundles as they are.
+	BundleEncryptionNone BundleEncryption = "none"
+	// BundleEncryptionAuto encrypts bundles when the workspace has a bundle
+	// key, and uploads them as they are otherwise.
+	BundleEncryptionAuto BundleEncryption = "auto"
+	// BundleEncryptionRequired encrypts every bundle, and fails the scan
+	// when the workspace has no bundle key.
+	BundleEncryptionRequired BundleEncryption = "required"
+)
+
+const (
+	// encryptedTarballName is the file name encrypted bundles are uploaded
+	// under, which tells the platform to decrypt them.
+	encryptedTarballName = tarballName + ".enc"
+
+	bundleEnvelopeMagic     = "kusari-bundle-encryption/v2\n"
+	bundleEnvelopeAlgorithm = "RSA-OAEP-256+A256GCM-CHUNKED"
+	bundleChunkSize         = 64 * 1024
+	bundleKeyLabel          = "kusari bundle key"
+	minBundleKeyBits        = 2048
+)
+
+// ParseBundleEncryption validates a user-supplied bundle encryption mode.
+// An empty mode is BundleEncryptionNone.
+func ParseBundleEncryption(s string) (BundleEncryption, error) {
+	switch mode := BundleEncryption(strings.ToLower(strings.TrimSpace(s))); mode {
+	case "":
+		return BundleEncryptionNone, nil
+	case BundleEncryptionNone, BundleEncryptionAuto, BundleEncryptionRequired:
+		return mode, nil
+	default:
+		return "", clierrors.NewValidationError("invalid bundle encryption %q (must be none, auto, or required)", s)
+	}
+}
+
+// bundleKey is the public key a workspace publishes for encrypting bundles
+// to it. Only the platform holds the private key.
+type bundleKey struct {
+	ID        string `json:"key_id"`
+	PublicKey string `json:"public_key"` // PEM-encoded PKIX RSA key
+
+	rsa *rsa.PublicKey
+}
+
+// bundleEnvelope is the header of an encrypted bundle. It is followed by
+// the bundle in chunks of ChunkSize bytes, each sealed with AES-256-GCM
+// under the wrapped key as random nonce (12 bytes) || ciphertext || tag.
+// The additional data is the header line || big-endian chunk counter (4
+// bytes) || 1 for the last chunk and 0 otherwise, so chunks can't be
+// reordered or the bundle truncated. []byte fields are base64-encoded by
+// encoding/json.
+type bundleEnvelope struct {
+	Algorithm  string `json:"alg"`
+	KeyID      string `json:"key_id"`
+	WrappedKey []byte `json:"wrapped_key"` // RSA-OAEP-SHA256, labeled bundleKeyLabel
+	ChunkSize  int    `json:"chunk_size"`
+}
+
+// fetchBundleKey gets the bundle key of workspace, or nil if it has none.
+func fetchBundleKey(platformUrl, accessToken, workspace string) (*bundleKey, error) {
+	endpoint, err := urlBuilder.Build(platformUrl, "inspector", "workspace", "bundle-key")
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequest("GET", *endpoint, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+accessToken)
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("X-Kusari-Workspace", workspace)
+
+	client := newHTTPClient(10 * time.Second)
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, clierrors.NewNetworkError("failed to fetch the workspace bundle key", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return nil, clierrors.NewPlatformError(resp.StatusCode, fmt.Sprintf("bundle key API returned status %d: %s", resp.StatusCode, string(body)))
+	}
+	var key bundleKey
+	if err := json.NewDecoder(resp.Body).Decode(&key); err != nil {
+		return nil, fmt.Errorf("failed to decode bundle key: %w", err)
+	}
+	if err := key.parse(); err != nil {
+		return nil, err
+	}
+	return &key, nil
+}
+
+// parse decodes the PEM public key of k.
+func (k *bundleKey) parse() error {
+	block, _ := pem.Decode([]byte(k.PublicKey))
+	if block == nil {
+		return fmt.Errorf("bundle key %q is not PEM-encoded", k.ID)
+	}
+	pub, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return fmt.Errorf("failed to parse bundle key %q: %w", k.ID, err)
+	}
+	rsaKey, ok := pub.(*rsa.PublicKey)
+	if !ok {
+		return fmt.Errorf("bundle key %q is a %T, not an RSA key", k.ID, pub)
+	}
+	if rsaKey.N.BitLen() < minBundleKeyBits {
+		return fmt.Errorf("bundle key %q is %d bits; at least %d are required", k.ID, rsaKey.N.BitLen(), minBundleKeyBits)
+	}
+	k.rsa = rsaKey
+	return nil
+}
+
+// encryptBundleFile encrypts the bundle at path to key in place and
+// returns its new size.
+func encryptBundleFile(path string, key *bundleKey) (int64, error) {
+	in, err := os.Open(path)
+	if err != nil {
+		return 0, fmt.Errorf("failed to open bundle: %w", err)
+	}
+	defer func() { _ = in.Close() }()
+
+	tmp := path + ".tmp"
+	out, err := os.OpenFile(tmp, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0600)
+	if err != nil {
+		return 0, fmt.Errorf("failed to create encrypted bundle: %w", err)
+	}
+	w := bufio.NewWriter(out)
+	if err := encryptBundle(w, in, key); err != nil {
+		_ = out.Close()
+		_ = os.Remove(tmp)
+		return 0, err
+	}
+	if err := w.Flush(); err != nil {
+		_ = out.Close()
+		_ = os.Remove(tmp)
+		return 0, fmt.Errorf("failed to write encrypted bundle: %w", err)
+	}
+	if err := out.Close(); err != nil {
+		_ = os.Remove(tmp)
+		return 0, fmt.Errorf("failed to write encrypted bundle: %w", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		_ = os.Remove(tmp)
+		return 0, fmt.Errorf("failed to replace bundle: %w", err)
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return 0, fmt.Errorf("failed to stat encrypted bundle: %w", err)
+	}
+	return info.Size(), nil
+}
+
+// encryptBundle writes the envelope of r, encrypted to key, to w. See
+// bundleEnvelope for the format.
+func encryptBundle(w io.Writer, r io.Reader, key *bundleKey) error {
+	if err := fips.Require("RSA-OAEP-SHA256", "AES-256-GCM"); err != nil {
+		return fmt.Errorf("%w; scan with --bundle-encryption none in FIPS mode", err)
+	}
+	dataKey := make([]byte, 32) // AES-256
+	if _, err := rand.Read(dataKey); err != nil {
+		return fmt.Errorf("failed to generate bundle key: %w", err)
+	}
+	wrapped, err := rsa.EncryptOAEP(sha256.New(), rand.Reader, key.rsa, dataKey, []byte(bundleKeyLabel))
+	if err != nil {
+		return fmt.Errorf("failed to wrap bundle key: %w", err)
+	}
+
+	header, err := json.Marshal(bundleEnvelope{
+		Algorithm:  bundleEnvelopeAlgorithm,
+		KeyID:      key.ID,
+		WrappedKey: wrapped,
+		ChunkSize:  bundleChunkSize,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to marshal bundle envelope: %w", err)
+	}
+	header = append(header, '\n')
+	if _, err := io.WriteString(w, bundleEnvelopeMagic); err != nil {
+		return fmt.Errorf("failed to write encrypted bundle: %w", err)
+	}
+	if _, err := w.Write(header); err != nil {
+		return fmt.Errorf("failed to write encrypted bundle: %w", err)
+	}
+
+	aead, err := newBundleAEAD(dataKey)
+	if err != nil {
+		return err
+	}
+	// Read a chunk ahead, to know which chunk is the last one.
+	br := bufio.NewReaderSize(r, bundleChunkSize)
+	chunk := make([]byte, bundleChunkSize)
+	var sealed []byte
+	for counter := uint32(0); ; counter++ {
+		n, err := io.ReadFull(br, chunk)
+		if err != nil && !errors.Is(err, io.EOF) && !errors.Is(err, io.ErrUnexpectedEOF) {
+			return fmt.Errorf("failed to read bundle: %w", err)
+		}
+		last := n < bundleChunkSize
+		if !last {
+			if _, err := br.Peek(1); errors.Is(err, io.EOF) {
+				last = true
+			}
+		}
+		if counter == ^uint32(0) && !last {
+			return fmt.Errorf("bundle is too large to encrypt")
+		}
+		sealed = aead.Seal(sealed[:0], nil, chunk[:n], chunkAAD(header, counter, last))
+		if _, err := w.Write(sealed); err != nil {
+			return fmt.Errorf("failed to write encrypted bundle: %w", err)
+		}
+		if last {
+			return nil
+		}
+	}
+}
+
+// newBundleAEAD returns AES-256-GCM with nonces generated by the crypto
+// module, the form FIPS 140-only mode allows.
+func newBundleAEAD(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create cipher: %w", err)
+	}
+	aead, err := cipher.NewGCMWithRandomNonce(block)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create GCM: %w", err)
+	}
+	return aead, nil
+}
+
+// chunkAAD returns the additional data of chunk counter of the bundle with
+// header.
+func chunkAAD(header []byte, counter uint32, last bool) []byte {
+	aad := make([]byte, 0, len(header)+5)
+	aad = append(aad, header...)
+	aad = binary.BigEndian.AppendUint32(aad, counter)
+	if last {
+		return append(aad, 1)
+	}
+	return append(aad, 0)
+}
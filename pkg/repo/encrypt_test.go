@@ -0,0 +1,324 @@
+// Copyright (c) Kusari <https://www.kusari.dev/>
+// SPDX-License-Identifier: MIT
+
+package repo
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/kusaridev/kusari-cli/v2/pkg/fips"
+	"github.com/kusaridev/kusari-cli/v2/pkg/login"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testBundleKey(t *testing.T) (*bundleKey, *rsa.PrivateKey) {
+	t.Helper()
+	priv, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	der, err := x509.MarshalPKIXPublicKey(&priv.PublicKey)
+	require.NoError(t, err)
+	key := &bundleKey{
+		ID:        "key-1",
+		PublicKey: string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der})),
+	}
+	require.NoError(t, key.parse())
+	return key, priv
+}
+
+// decryptBundle is what the platform does with an encrypted bundle.
+func decryptBundle(r io.Reader, priv *rsa.PrivateKey) ([]byte, error) {
+	br := bufio.NewReader(r)
+	magic, err := br.ReadString('\n')
+	if err != nil || magic != bundleEnvelopeMagic {
+		return nil, fmt.Errorf("not an encrypted bundle")
+	}
+	header, err := br.ReadBytes('\n')
+	if err != nil {
+		return nil, err
+	}
+	var env bundleEnvelope
+	if err := json.Unmarshal(header, &env); err != nil {
+		return nil, err
+	}
+	dataKey, err := rsa.DecryptOAEP(sha256.New(), nil, priv, env.WrappedKey, []byte(bundleKeyLabel))
+	if err != nil {
+		return nil, err
+	}
+	aead, err := newBundleAEAD(dataKey)
+	if err != nil {
+		return nil, err
+	}
+
+	var out bytes.Buffer
+	sealed := make([]byte, env.ChunkSize+aead.Overhead())
+	for counter := uint32(0); ; counter++ {
+		n, err := io.ReadFull(br, sealed)
+		if err != nil && !errors.Is(err, io.ErrUnexpectedEOF) {
+			return nil, err
+		}
+		last := n < len(sealed)
+		if !last {
+			if _, err := br.Peek(1); errors.Is(err, io.EOF) {
+				last = true
+			}
+		}
+		plain, err := aead.Open(nil, nil, sealed[:n], chunkAAD(header, counter, last))
+		if err != nil {
+			return nil, err
+		}
+		out.Write(plain)
+		if last {
+			return out.Bytes(), nil
+		}
+	}
+}
+
+func TestEncryptBundle_RoundTrip(t *testing.T) {
+	key, priv := testBundleKey(t)
+
+	for _, size := range []int{0, 1, bundleChunkSize - 1, bundleChunkSize, bundleChunkSize + 1, 3 * bundleChunkSize} {
+		t.Run(fmt.Sprint(size), func(t *testing.T) {
+			plain := make([]byte, size)
+			_, _ = rand.Read(plain)
+
+			var buf bytes.Buffer
+			require.NoError(t, encryptBundle(&buf, bytes.NewReader(plain), key))
+			// Shorter prefixes turn up in random ciphertext by chance.
+			if size >= 16 {
+				assert.NotContains(t, buf.String(), string(plain[:min(size, 64)]))
+			}
+
+			got, err := decryptBundle(bytes.NewReader(buf.Bytes()), priv)
+			require.NoError(t, err)
+			assert.True(t, bytes.Equal(plain, got), "decrypts to the original")
+		})
+	}
+}
+
+func TestEncryptBundle_Tampering(t *testing.T) {
+	key, priv := testBundleKey(t)
+	plain := bytes.Repeat([]byte("source code "), bundleChunkSize/4)
+
+	var buf bytes.Buffer
+	require.NoError(t, encryptBundle(&buf, bytes.NewReader(plain), key))
+	sealedChunk := bundleChunkSize + 12 + 16
+
+	t.Run("truncated at a chunk boundary", func(t *testing.T) {
+		headerEnd := len(bundleEnvelopeMagic) + bytes.IndexByte(buf.Bytes()[len(bundleEnvelopeMagic):], '\n') + 1
+		truncated := buf.Bytes()[:headerEnd+sealedChunk]
+		_, err := decryptBundle(bytes.NewReader(truncated), priv)
+		assert.Error(t, err)
+	})
+
+	t.Run("flipped byte", func(t *testing.T) {
+		flipped := bytes.Clone(buf.Bytes())
+		flipped[len(flipped)-1] ^= 1
+		_, err := decryptBundle(bytes.NewReader(flipped), priv)
+		assert.Error(t, err)
+	})
+
+	t.Run("other key", func(t *testing.T) {
+		_, other := testBundleKey(t)
+		_, err := decryptBundle(bytes.NewReader(buf.Bytes()), other)
+		assert.Error(t, err)
+	})
+}
+
+func TestEncryptBundle_FIPS(t *testing.T) {
+	if !fips.Enabled() {
+		// FIPS mode is set when the process starts, so check it in a
+		// test binary run with only approved algorithms allowed.
+		if os.Getenv("KUSARI_TEST_FIPS") != "" {
+			t.Fatal("GODEBUG=fips140=only did not enable FIPS mode")
+		}
+		cmd := exec.Command(os.Args[0], "-test.run=^TestEncryptBundle_FIPS$", "-test.count=1")
+		cmd.Env = append(os.Environ(), "GODEBUG=fips140=only", "KUSARI_TEST_FIPS=1")
+		out, err := cmd.CombinedOutput()
+		require.NoError(t, err, string(out))
+		return
+	}
+
+	key, priv := testBundleKey(t)
+	plain := bytes.Repeat([]byte("source code "), bundleChunkSize/4)
+	var buf bytes.Buffer
+	require.NoError(t, encryptBundle(&buf, bytes.NewReader(plain), key))
+	got, err := decryptBundle(bytes.NewReader(buf.Bytes()), priv)
+	require.NoError(t, err)
+	assert.True(t, bytes.Equal(plain, got), "decrypts to the original")
+}
+
+func TestEncryptBundleFile(t *testing.T) {
+	key, priv := testBundleKey(t)
+	path := filepath.Join(t.TempDir(), tarballName)
+	require.NoError(t, os.WriteFile(path, []byte("bundle contents"), 0600))
+
+	size, err := encryptBundleFile(path, key)
+	require.NoError(t, err)
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, int64(len(data)), size)
+	got, err := decryptBundle(bytes.NewReader(data), priv)
+	require.NoError(t, err)
+	assert.Equal(t, "bundle contents", string(got))
+	_, err = os.Stat(path + ".tmp")
+	assert.True(t, os.IsNotExist(err))
+}
+
+func TestParseBundleEncryption(t *testing.T) {
+	for in, want := range map[string]BundleEncryption{
+		"":          BundleEncryptionNone,
+		"none":      BundleEncryptionNone,
+		"Auto":      BundleEncryptionAuto,
+		" required": BundleEncryptionRequired,
+	} {
+		got, err := ParseBundleEncryption(in)
+		require.NoError(t, err, in)
+		assert.Equal(t, want, got, in)
+	}
+	_, err := ParseBundleEncryption("always")
+	assert.Error(t, err)
+}
+
+func TestFetchBundleKey(t *testing.T) {
+	key, _ := testBundleKey(t)
+	weak, err := rsa.GenerateKey(rand.Reader, 1024)
+	require.NoError(t, err)
+	weakDER, err := x509.MarshalPKIXPublicKey(&weak.PublicKey)
+	require.NoError(t, err)
+	weakPEM := string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: weakDER}))
+
+	tests := []struct {
+		name    string
+		status  int
+		body    string
+		wantKey bool
+		wantErr string
+	}{
+		{name: "key", status: http.StatusOK, body: fmt.Sprintf(`{"key_id":"key-1","public_key":%q}`, key.PublicKey), wantKey: true},
+		{name: "no key", status: http.StatusNotFound},
+		{name: "server error", status: http.StatusInternalServerError, body: "boom", wantErr: "status 500"},
+		{name: "not PEM", status: http.StatusOK, body: `{"key_id":"key-1","public_key":"nope"}`, wantErr: "not PEM-encoded"},
+		{name: "weak key", status: http.StatusOK, body: fmt.Sprintf(`{"key_id":"key-1","public_key":%q}`, weakPEM), wantErr: "at least 2048"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				assert.Equal(t, "/inspector/workspace/bundle-key", r.URL.Path)
+				assert.Equal(t, "Bearer token", r.Header.Get("Authorization"))
+				assert.Equal(t, "ws-1", r.Header.Get("X-Kusari-Workspace"))
+				w.WriteHeader(tt.status)
+				_, _ = w.Write([]byte(tt.body))
+			}))
+			defer srv.Close()
+
+			got, err := fetchBundleKey(srv.URL, "token", "ws-1")
+			if tt.wantErr != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			if tt.wantKey {
+				require.NotNil(t, got)
+				assert.Equal(t, "key-1", got.ID)
+			} else {
+				assert.Nil(t, got)
+			}
+		})
+	}
+}
+
+func TestScan_BundleEncryption(t *testing.T) {
+	key, priv := testBundleKey(t)
+
+	tests := []struct {
+		name     string
+		mode     string
+		key      *bundleKey
+		wantName string
+		wantErr  string
+	}{
+		{name: "off", mode: "", key: key, wantName: tarballName},
+		{name: "auto with a key", mode: "auto", key: key, wantName: encryptedTarballName},
+		{name: "auto without a key", mode: "auto", wantName: tarballName},
+		{name: "required with a key", mode: "required", key: key, wantName: encryptedTarballName},
+		{name: "required without a key", mode: "required", wantErr: "no bundle encryption key"},
+		{name: "invalid", mode: "yes", wantErr: "invalid bundle encryption"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("HOME", t.TempDir())
+			testDir := t.TempDir()
+			runCmd(t, testDir, "git", "init")
+			runCmd(t, testDir, "git", "config", "user.email", "test@example.com")
+			runCmd(t, testDir, "git", "config", "user.name", "Test User")
+			writeFile(t, filepath.Join(testDir, "test.txt"), "test content")
+			runCmd(t, testDir, "git", "add", ".")
+			runCmd(t, testDir, "git", "commit", "-m", "initial commit")
+			writeFile(t, filepath.Join(testDir, "test.txt"), "uncommitted change")
+
+			originalDir, err := os.Getwd()
+			require.NoError(t, err)
+			defer func() { _ = os.Chdir(originalDir) }()
+
+			var uploadedName string
+			var uploaded []byte
+			mock := &scanMock{
+				fileUploader: func(presignedURL, filePath string) error {
+					var err error
+					uploaded, err = os.ReadFile(filePath)
+					return err
+				},
+				presignedURLGetter: func(apiEndpoint string, jwtToken string, filePath, workspace string, scanType string, size int64) (string, error) {
+					uploadedName = filePath
+					return "https://example.com/workspace/test-workspace-id/user/human/test-user-id/diff/blob/123", nil
+				},
+				defaultWorkspaceGetter: func(platformUrl string, jwtToken string) ([]login.Workspace, map[string][]string, error) {
+					return []login.Workspace{{ID: "ws-1", Description: "Test Workspace"}}, nil, nil
+				},
+				bundleKeyGetter: func(platformUrl, jwtToken, workspace string) (*bundleKey, error) {
+					assert.Equal(t, "ws-1", workspace)
+					return tt.key, nil
+				},
+				token: "token",
+			}
+
+			err = scan(ScanOptions{Dir: testDir, Rev: "HEAD", PlatformURL: "https://platform.example.com", ConsoleURL: "https://console.example.com", OutputFormat: "markdown",
+				BundleEncryption: BundleEncryption(tt.mode)}, false, mock)
+			if tt.wantErr != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantName, uploadedName)
+			if tt.wantName == encryptedTarballName {
+				assert.True(t, strings.HasPrefix(string(uploaded), bundleEnvelopeMagic))
+				plain, err := decryptBundle(bytes.NewReader(uploaded), priv)
+				require.NoError(t, err)
+				assert.True(t, strings.HasPrefix(string(plain), "BZh"), "decrypts to a bzip2 tarball")
+			} else {
+				assert.True(t, strings.HasPrefix(string(uploaded), "BZh"))
+			}
+		})
+	}
+}
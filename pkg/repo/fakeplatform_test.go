@@ -0,0 +1,137 @@
+// Copyright (c) Kusari <https://www.kusari.dev/>
+// SPDX-License-Identifier: MIT
+
+package repo
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/kusaridev/kusari-cli/v2/pkg/clierrors"
+	"github.com/kusaridev/kusari-cli/v2/pkg/clock"
+	"github.com/kusaridev/kusari-cli/v2/pkg/fakeplatform"
+	"github.com/kusaridev/kusari-cli/v2/pkg/login"
+	"github.com/kusaridev/kusari-cli/v2/pkg/results"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// scanFakePlatform runs a diff scan with opts, the repository and platform
+// options filled in, with the real upload and polling code against a fake
+// platform serving scenario.
+func scanFakePlatform(t *testing.T, scenario fakeplatform.Scenario, opts ScanOptions) (*fakeplatform.Server, error) {
+	t.Helper()
+	t.Setenv("HOME", t.TempDir())
+	fake := clock.NewFake(time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC))
+	SetClock(fake)
+	t.Cleanup(func() { SetClock(nil) })
+
+	server := fakeplatform.New(scenario)
+	srv := httptest.NewServer(server)
+	t.Cleanup(srv.Close)
+
+	testDir := t.TempDir()
+	runCmd(t, testDir, "git", "init")
+	runCmd(t, testDir, "git", "config", "user.email", "test@example.com")
+	runCmd(t, testDir, "git", "config", "user.name", "Test User")
+	writeFile(t, filepath.Join(testDir, "test.txt"), "test content")
+	runCmd(t, testDir, "git", "add", ".")
+	runCmd(t, testDir, "git", "commit", "-m", "initial commit")
+	writeFile(t, filepath.Join(testDir, "test.txt"), "uncommitted change")
+
+	originalDir, err := os.Getwd()
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = os.Chdir(originalDir) })
+
+	mock := &scanMock{
+		fileUploader:           uploadFileToS3,
+		presignedURLGetter:     getPresignedURL,
+		defaultWorkspaceGetter: login.FetchWorkspaces,
+		bundleKeyGetter:        fetchBundleKey,
+		token:                  "fake",
+		isMachineAuth:          true,
+	}
+	opts.Dir, opts.Rev = testDir, "HEAD"
+	opts.PlatformURL, opts.ConsoleURL = srv.URL+"/", "https://console.example.com"
+	opts.Wait, opts.OverrideBranch = true, "main"
+	err = scan(opts, false, mock)
+	return server, err
+}
+
+func TestScan_FakePlatform(t *testing.T) {
+	t.Run("pass", func(t *testing.T) {
+		resultsFile := filepath.Join(t.TempDir(), "results.json")
+		server, err := scanFakePlatform(t, fakeplatform.Scenarios["slow"], ScanOptions{OutputFormat: "json-file=" + resultsFile})
+		require.NoError(t, err)
+
+		uploads := server.Uploads()
+		require.Len(t, uploads, 1)
+		assert.Equal(t, fakeplatform.KindBundle, uploads[0].Kind)
+		assert.NotEmpty(t, uploads[0].Body)
+
+		data, err := os.ReadFile(resultsFile)
+		require.NoError(t, err)
+		var res results.Result
+		require.NoError(t, json.Unmarshal(data, &res))
+		assert.True(t, res.Analysis.ShouldProceed)
+		assert.Contains(t, res.ConsoleURL, "https://console.example.com/workspaces/"+fakeplatform.DefaultWorkspace.ID+"/analysis/")
+	})
+
+	t.Run("block", func(t *testing.T) {
+		resultsFile := filepath.Join(t.TempDir(), "results.json")
+		_, err := scanFakePlatform(t, fakeplatform.Scenarios["block"], ScanOptions{OutputFormat: "json-file=" + resultsFile})
+		require.NoError(t, err)
+
+		data, err := os.ReadFile(resultsFile)
+		require.NoError(t, err)
+		var res results.Result
+		require.NoError(t, json.Unmarshal(data, &res))
+		assert.False(t, res.Analysis.ShouldProceed)
+		require.Len(t, res.Analysis.RequiredCodeMitigations, 1)
+		assert.Equal(t, "config.go", res.Analysis.RequiredCodeMitigations[0].Path)
+	})
+
+	t.Run("filtered", func(t *testing.T) {
+		// Suppressed findings are left out of everything the scan sends
+		// or saves, tickets included, not just what it prints.
+		var payload map[string]any
+		hook := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&payload))
+		}))
+		defer hook.Close()
+		blocked := fakeplatform.Scenarios["block"].Analysis.RawLLMAnalysis.RequiredCodeMitigations[0]
+
+		resultsFile := filepath.Join(t.TempDir(), "results.json")
+		_, err := scanFakePlatform(t, fakeplatform.Scenarios["block"], ScanOptions{
+			OutputFormat: "json-file=" + resultsFile,
+			Filter:       ResultFilter{Suppress: []string{blocked.ID()}},
+			TicketSinks:  TicketSinks{WebhookURL: hook.URL},
+		})
+		require.NoError(t, err)
+
+		require.NotNil(t, payload)
+		assert.Empty(t, payload["findings"])
+		saved, _, err := results.Last()
+		require.NoError(t, err)
+		assert.Empty(t, saved.Analysis.RequiredCodeMitigations)
+	})
+
+	t.Run("fail", func(t *testing.T) {
+		_, err := scanFakePlatform(t, fakeplatform.Scenarios["fail"], ScanOptions{OutputFormat: "markdown"})
+		var failed *clierrors.AnalysisFailedError
+		require.ErrorAs(t, err, &failed)
+		assert.Equal(t, "the fake platform failed the analysis", failed.Details)
+	})
+
+	t.Run("forbidden", func(t *testing.T) {
+		server, err := scanFakePlatform(t, fakeplatform.Scenarios["forbidden"], ScanOptions{OutputFormat: "markdown"})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "forbidden (status 403)")
+		assert.Empty(t, server.Uploads())
+	})
+}
@@ -0,0 +1,120 @@
+// Copyright (c) Kusari <https://www.kusari.dev/>
+// SPDX-License-Identifier: MIT
+
+package repo
+
+import (
+	"path"
+	"slices"
+	"strings"
+
+	"github.com/kusaridev/kusari-cli/v2/api"
+	"github.com/kusaridev/kusari-cli/v2/pkg/clierrors"
+)
+
+// Result levels for ResultFilter.MinLevel, lowest first, as in SARIF.
+const (
+	LevelNote    = "note"
+	LevelWarning = "warning"
+	LevelError   = "error"
+)
+
+var levelRank = map[string]int{LevelNote: 1, LevelWarning: 2, LevelError: 3}
+
+// ResultFilter narrows the findings of a diff scan before they are
+// written to the outputs. The zero value keeps everything.
+type ResultFilter struct {
+	// Paths are globs, with ** matching any number of directories; code
+	// findings outside them are left out, and so are dependency findings,
+	// which have no path. Empty keeps every path.
+	Paths []string
+	// MinLevel leaves out findings below it. Inspector doesn't grade
+	// findings individually, so they take the level of the verdict: error
+	// when the change should not proceed, warning otherwise.
+	MinLevel string
+	// Suppress are the stable IDs (api.FindingID) of findings to leave
+	// out, such as accepted risks.
+	Suppress []string
+}
+
+// ValidateLevel checks a user-supplied minimum level. Empty is no minimum.
+func ValidateLevel(level string) error {
+	if _, ok := levelRank[level]; level != "" && !ok {
+		return clierrors.NewValidationError("invalid level %q (must be note, warning or error)", level)
+	}
+	return nil
+}
+
+// active reports whether f leaves anything out.
+func (f ResultFilter) active() bool {
+	return len(f.Paths) > 0 || f.MinLevel != "" || len(f.Suppress) > 0
+}
+
+// apply returns a copy of a with only the findings f keeps.
+func (f ResultFilter) apply(a *api.Analysis) *api.Analysis {
+	if a.RawLLMAnalysis == nil {
+		return a
+	}
+	sa := *a.RawLLMAnalysis
+	level := LevelWarning
+	if !sa.ShouldProceed {
+		level = LevelError
+	}
+	keepLevel := levelRank[level] >= levelRank[f.MinLevel]
+
+	sa.RequiredCodeMitigations = nil
+	for _, m := range a.RawLLMAnalysis.RequiredCodeMitigations {
+		if keepLevel && f.matchPath(m.Path) && !slices.Contains(f.Suppress, m.ID()) {
+			sa.RequiredCodeMitigations = append(sa.RequiredCodeMitigations, m)
+		}
+	}
+	sa.RequiredDependencyMitigations = nil
+	if keepLevel && len(f.Paths) == 0 {
+		for _, m := range a.RawLLMAnalysis.RequiredDependencyMitigations {
+			if !slices.Contains(f.Suppress, m.ID()) {
+				sa.RequiredDependencyMitigations = append(sa.RequiredDependencyMitigations, m)
+			}
+		}
+	}
+
+	filtered := *a
+	filtered.RawLLMAnalysis = &sa
+	return &filtered
+}
+
+func (f ResultFilter) matchPath(p string) bool {
+	if len(f.Paths) == 0 {
+		return true
+	}
+	p = strings.TrimPrefix(path.Clean(strings.ReplaceAll(p, "\\", "/")), "./")
+	for _, glob := range f.Paths {
+		if matchGlob(strings.Split(strings.TrimPrefix(glob, "./"), "/"), strings.Split(p, "/")) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchGlob matches path segments against glob segments, where a "**"
+// segment matches zero or more path segments and the others match as
+// with path.Match.
+func matchGlob(glob, segments []string) bool {
+	for len(glob) > 0 {
+		if glob[0] == "**" {
+			for i := len(segments); i >= 0; i-- {
+				if matchGlob(glob[1:], segments[i:]) {
+					return true
+				}
+			}
+			return false
+		}
+		if len(segments) == 0 {
+			return false
+		}
+		if ok, err := path.Match(glob[0], segments[0]); err != nil || !ok {
+			return false
+		}
+		glob, segments = glob[1:], segments[1:]
+	}
+	return len(segments) == 0
+}
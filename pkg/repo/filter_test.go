@@ -0,0 +1,89 @@
+// Copyright (c) Kusari <https://www.kusari.dev/>
+// SPDX-License-Identifier: MIT
+
+package repo
+
+import (
+	"testing"
+
+	"github.com/kusaridev/kusari-cli/v2/api"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestResultFilter_MatchPath(t *testing.T) {
+	tests := []struct {
+		glob string
+		path string
+		want bool
+	}{
+		{"src/**", "src/main.go", true},
+		{"src/**", "src/pkg/deep/file.go", true},
+		{"src/**", "test/main.go", false},
+		{"**/*.go", "main.go", true},
+		{"**/*.go", "a/b/c.go", true},
+		{"**/*.go", "a/b/c.py", false},
+		{"services/*/api/**", "services/billing/api/handler.go", true},
+		{"services/*/api/**", "services/billing/web/handler.go", false},
+		{"./src/*.go", "src/main.go", true},
+		{"src/*.go", "./src/main.go", true},
+		{"src/*.go", "src/pkg/main.go", false},
+	}
+	for _, tt := range tests {
+		f := ResultFilter{Paths: []string{tt.glob}}
+		assert.Equal(t, tt.want, f.matchPath(tt.path), "%s matching %s", tt.glob, tt.path)
+	}
+}
+
+func TestResultFilter_Apply(t *testing.T) {
+	analysis := func(shouldProceed bool) *api.Analysis {
+		return &api.Analysis{RawLLMAnalysis: &api.SecurityAnalysis{
+			ShouldProceed: shouldProceed,
+			RequiredCodeMitigations: []api.CodeMitigationItem{
+				{Path: "src/api/handler.go", LineNumber: 3},
+				{Path: "web/app.js", LineNumber: 7},
+			},
+			RequiredDependencyMitigations: []api.DependencyMitigationItem{{Content: "upgrade lodash"}},
+		}}
+	}
+
+	t.Run("paths", func(t *testing.T) {
+		a := analysis(false)
+		got := ResultFilter{Paths: []string{"src/**"}}.apply(a)
+		assert.Equal(t, []api.CodeMitigationItem{{Path: "src/api/handler.go", LineNumber: 3}}, got.RawLLMAnalysis.RequiredCodeMitigations)
+		assert.Empty(t, got.RawLLMAnalysis.RequiredDependencyMitigations)
+		assert.Len(t, a.RawLLMAnalysis.RequiredCodeMitigations, 2, "the original is left as is")
+	})
+
+	t.Run("min level", func(t *testing.T) {
+		got := ResultFilter{MinLevel: LevelError}.apply(analysis(true))
+		assert.Empty(t, got.RawLLMAnalysis.RequiredCodeMitigations)
+		assert.Empty(t, got.RawLLMAnalysis.RequiredDependencyMitigations)
+
+		got = ResultFilter{MinLevel: LevelError}.apply(analysis(false))
+		assert.Len(t, got.RawLLMAnalysis.RequiredCodeMitigations, 2)
+		assert.Len(t, got.RawLLMAnalysis.RequiredDependencyMitigations, 1)
+
+		got = ResultFilter{MinLevel: LevelWarning}.apply(analysis(true))
+		assert.Len(t, got.RawLLMAnalysis.RequiredCodeMitigations, 2)
+	})
+
+	t.Run("suppress", func(t *testing.T) {
+		a := analysis(false)
+		suppress := []string{a.RawLLMAnalysis.RequiredCodeMitigations[1].ID(), a.RawLLMAnalysis.RequiredDependencyMitigations[0].ID()}
+		got := ResultFilter{Suppress: suppress}.apply(a)
+		assert.Equal(t, []api.CodeMitigationItem{{Path: "src/api/handler.go", LineNumber: 3}}, got.RawLLMAnalysis.RequiredCodeMitigations)
+		assert.Empty(t, got.RawLLMAnalysis.RequiredDependencyMitigations)
+	})
+
+	t.Run("no analysis", func(t *testing.T) {
+		a := &api.Analysis{}
+		assert.Same(t, a, ResultFilter{MinLevel: LevelError}.apply(a))
+	})
+}
+
+func TestValidateLevel(t *testing.T) {
+	for _, level := range []string{"", LevelNote, LevelWarning, LevelError} {
+		assert.NoError(t, ValidateLevel(level), level)
+	}
+	assert.Error(t, ValidateLevel("critical"))
+}
@@ -0,0 +1,72 @@
+// Copyright (c) Kusari <https://www.kusari.dev/>
+// SPDX-License-Identifier: MIT
+
+package repo
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"net/url"
+	"os/exec"
+	"slices"
+	"strings"
+)
+
+// repoFingerprint returns a stable identifier of the git repository at
+// dir, the same for all its clones wherever they are checked out, full or
+// shallow, so their results can be grouped. It is the hash of the
+// normalized origin URL, "remote:<hash>"; a repository without an origin
+// has the hash of its root commit, "root:<hash>", instead. Empty when
+// neither is known.
+func repoFingerprint(dir string) string {
+	remote, err := exec.Command("git", "-C", dir, "remote", "get-url", "origin").Output()
+	if err == nil {
+		if normalized := normalizeRemote(string(remote)); normalized != "" {
+			return fingerprint("remote", normalized)
+		}
+	}
+	shallow, err := exec.Command("git", "-C", dir, "rev-parse", "--is-shallow-repository").Output()
+	if err != nil || strings.TrimSpace(string(shallow)) != "false" {
+		return ""
+	}
+	// A history merged from several has more than one root
+	out, err := exec.Command("git", "-C", dir, "rev-list", "--max-parents=0", "HEAD").Output()
+	if roots := strings.Fields(string(out)); err == nil && len(roots) > 0 {
+		return fingerprint("root", slices.Min(roots))
+	}
+	return ""
+}
+
+func fingerprint(kind, value string) string {
+	sum := sha256.Sum256([]byte(value))
+	return kind + ":" + hex.EncodeToString(sum[:16])
+}
+
+// normalizeRemote reduces the git remote URL raw to host/path, lowercased
+// and without credentials, port or .git suffix, so the HTTPS and SSH URLs
+// of a repository are the same, e.g. github.com/kusaridev/kusari-cli.
+func normalizeRemote(raw string) string {
+	raw = strings.TrimSpace(raw)
+	var host, path string
+	if strings.Contains(raw, "://") {
+		u, err := url.Parse(raw)
+		if err != nil {
+			return ""
+		}
+		host, path = u.Hostname(), u.Path
+	} else if at, colon := strings.Index(raw, "@"), strings.Index(raw, ":"); colon > at {
+		// scp-style git@host:path
+		host, path = raw[at+1:colon], raw[colon+1:]
+	} else {
+		// A local path
+		path = raw
+	}
+	path = strings.TrimSuffix(strings.Trim(path, "/"), ".git")
+	if path == "" {
+		return ""
+	}
+	if host == "" {
+		return strings.ToLower(path)
+	}
+	return strings.ToLower(host + "/" + path)
+}
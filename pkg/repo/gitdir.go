@@ -0,0 +1,90 @@
+// Copyright (c) Kusari <https://www.kusari.dev/>
+// SPDX-License-Identifier: MIT
+
+package repo
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/kusaridev/kusari-cli/v2/pkg/clierrors"
+)
+
+// useGitDir points every git command the scan runs at gitDir, with dir as
+// its work tree, until restore is called. It does nothing without
+// --git-dir.
+func useGitDir(gitDir, dir string) (restore func(), err error) {
+	if gitDir == "" {
+		return func() {}, nil
+	}
+	absGitDir, err := filepath.Abs(gitDir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve --git-dir: %w", err)
+	}
+	absDir, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve directory: %w", err)
+	}
+	var undo []func()
+	for name, value := range map[string]string{"GIT_DIR": absGitDir, "GIT_WORK_TREE": absDir} {
+		old, had := os.LookupEnv(name)
+		if err := os.Setenv(name, value); err != nil {
+			return nil, fmt.Errorf("failed to set %s: %w", name, err)
+		}
+		undo = append(undo, func() {
+			if had {
+				_ = os.Setenv(name, old)
+			} else {
+				_ = os.Unsetenv(name)
+			}
+		})
+	}
+	return func() {
+		for _, u := range undo {
+			u()
+		}
+	}, nil
+}
+
+// checkRepoRoot fails unless dir is the root of the work tree of a git
+// repository. A worktree's .git is a file, and a bare repository's work
+// tree has none, so git is asked rather than .git looked for.
+func checkRepoRoot(dir string) error {
+	if out, err := exec.Command("git", "-C", dir, "rev-parse", "--git-dir").CombinedOutput(); err != nil {
+		return clierrors.NewValidationError("%s is not in a git repository (%s): directory must be root of repo (or pass --no-git to risk-check it as a plain snapshot)",
+			dir, strings.TrimSpace(string(out)))
+	}
+	bare, err := exec.Command("git", "-C", dir, "rev-parse", "--is-bare-repository").Output()
+	if err != nil {
+		return fmt.Errorf("failed to run git rev-parse: %w", err)
+	}
+	if strings.TrimSpace(string(bare)) == "true" {
+		return clierrors.NewValidationError("%s is a bare repository, which has no files to scan: pass a work tree of it as the directory, with --git-dir %s", dir, dir)
+	}
+	top, err := exec.Command("git", "-C", dir, "rev-parse", "--show-toplevel").Output()
+	if err != nil {
+		return clierrors.NewValidationError("%s is inside a git directory, not a work tree: directory must be root of repo", dir)
+	}
+	root := strings.TrimSpace(string(top))
+	if !sameDir(dir, root) {
+		return clierrors.NewValidationError("%s is not the root of its repository (%s): directory must be root of repo", dir, root)
+	}
+	return nil
+}
+
+// sameDir reports whether a and b are the same directory, after resolving
+// symlinks.
+func sameDir(a, b string) bool {
+	ai, err := os.Stat(a)
+	if err != nil {
+		return false
+	}
+	bi, err := os.Stat(b)
+	if err != nil {
+		return false
+	}
+	return os.SameFile(ai, bi)
+}
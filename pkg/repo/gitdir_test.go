@@ -0,0 +1,97 @@
+// Copyright (c) Kusari <https://www.kusari.dev/>
+// SPDX-License-Identifier: MIT
+
+package repo
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/kusaridev/kusari-cli/v2/api"
+	"github.com/kusaridev/kusari-cli/v2/pkg/clierrors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// initCommittedRepo creates a repository with main.go committed.
+func initCommittedRepo(t *testing.T) string {
+	t.Helper()
+	dir := t.TempDir()
+	runCmd(t, dir, "git", "init")
+	runCmd(t, dir, "git", "config", "user.email", "test@example.com")
+	runCmd(t, dir, "git", "config", "user.name", "Test User")
+	writeFile(t, filepath.Join(dir, "main.go"), "package main")
+	runCmd(t, dir, "git", "add", ".")
+	runCmd(t, dir, "git", "commit", "-m", "initial commit")
+	return dir
+}
+
+func TestCheckRepoRoot(t *testing.T) {
+	dir := initCommittedRepo(t)
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "sub"), 0755))
+	worktree := filepath.Join(t.TempDir(), "wt")
+	runCmd(t, dir, "git", "worktree", "add", worktree)
+	bare := filepath.Join(t.TempDir(), "bare.git")
+	runCmd(t, dir, "git", "clone", "--bare", dir, bare)
+
+	var ve *clierrors.ValidationError
+	assert.NoError(t, checkRepoRoot(dir))
+	assert.NoError(t, checkRepoRoot(worktree), "a worktree's .git is a file")
+
+	err := checkRepoRoot(filepath.Join(dir, "sub"))
+	require.ErrorAs(t, err, &ve)
+	assert.ErrorContains(t, err, "not the root of its repository")
+
+	err = checkRepoRoot(t.TempDir())
+	require.ErrorAs(t, err, &ve)
+	assert.ErrorContains(t, err, "not in a git repository")
+
+	err = checkRepoRoot(bare)
+	require.ErrorAs(t, err, &ve)
+	assert.ErrorContains(t, err, "--git-dir")
+}
+
+func TestScan_Worktree(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	dir := initCommittedRepo(t)
+	worktree := filepath.Join(t.TempDir(), "wt")
+	runCmd(t, dir, "git", "worktree", "add", worktree)
+	t.Chdir(worktree)
+	writeFile(t, filepath.Join(worktree, "main.go"), "package main // changed")
+
+	var scanType string
+	var files []string
+	var meta api.BundleMeta
+	err := scan(ScanOptions{Dir: worktree, Rev: "HEAD", PlatformURL: "https://platform.example.com", ConsoleURL: "https://console.example.com", OutputFormat: "markdown"}, false, bundleMock(t, &scanType, &files, &meta))
+	require.NoError(t, err)
+	assert.Equal(t, "diff", scanType)
+	assert.Contains(t, files, "main.go")
+	assert.Equal(t, filepath.Base(worktree), meta.CurrentBranch)
+	assert.Equal(t, []string{"main.go"}, meta.ChangedFiles)
+	assert.Equal(t, repoFingerprint(dir), meta.RepoFingerprint, "worktrees share their repository's fingerprint")
+	assert.NotEmpty(t, meta.RepoFingerprint)
+}
+
+func TestScan_GitDir(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	dir := initCommittedRepo(t)
+	bare := filepath.Join(t.TempDir(), "bare.git")
+	runCmd(t, dir, "git", "clone", "--bare", dir, bare)
+	workTree := t.TempDir()
+	runCmd(t, workTree, "git", "--git-dir", bare, "--work-tree", workTree, "checkout", "-f")
+	t.Chdir(workTree)
+
+	var scanType string
+	var files []string
+	var meta api.BundleMeta
+	err := scan(ScanOptions{Dir: workTree, GitDir: bare, PlatformURL: "https://platform.example.com", ConsoleURL: "https://console.example.com", OutputFormat: "markdown"}, true, bundleMock(t, &scanType, &files, &meta))
+	require.NoError(t, err)
+	assert.Equal(t, "full", scanType)
+	assert.ElementsMatch(t, []string{"main.go", metaFile, manifestFile}, files)
+	assert.NotEmpty(t, meta.CommitSHA)
+	assert.False(t, meta.GitDirty)
+
+	_, set := os.LookupEnv("GIT_DIR")
+	assert.False(t, set, "GIT_DIR is restored after the scan")
+}
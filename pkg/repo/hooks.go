@@ -0,0 +1,165 @@
+// Copyright (c) Kusari <https://www.kusari.dev/>
+// SPDX-License-Identifier: MIT
+
+package repo
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+
+	"github.com/kusaridev/kusari-cli/v2/pkg/output"
+	"gopkg.in/yaml.v3"
+)
+
+// Scan lifecycle hooks, set with ScanOptions.PreUploadHook and
+// PostResultHook, or in the hooks mapping of the user's kusari.yaml.
+const (
+	// HookPreUpload runs with the path of the packaged bundle before it is
+	// encrypted and uploaded, e.g. to redact it. A failure stops the scan.
+	HookPreUpload = "pre_upload"
+	// HookPostResult runs with the path of the saved result JSON once the
+	// results are in, e.g. to notify. A failure is only a warning.
+	HookPostResult = "post_result"
+)
+
+// userHooksFile is the kusari.yaml, in ~/.kusari, whose hooks mapping
+// sets the hooks of every scan the user runs. It is the only kusari.yaml
+// hooks are read from: that of the scanned directory belongs to whoever
+// authored the change.
+const userHooksFile = "kusari.yaml"
+
+// userHooksConfig is the content of the user's kusari.yaml.
+type userHooksConfig struct {
+	Hooks struct {
+		PreUpload  string `yaml:"pre_upload"`
+		PostResult string `yaml:"post_result"`
+	} `yaml:"hooks"`
+}
+
+// getUserHooksPath returns the path of the user's kusari.yaml.
+func getUserHooksPath() (string, error) {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to get home directory: %w", err)
+	}
+	return filepath.Join(homeDir, ".kusari", userHooksFile), nil
+}
+
+// UserHooks returns the pre_upload and post_result scripts of the hooks
+// mapping of ~/.kusari/kusari.yaml, empty when there is no such file.
+// Relative scripts are found in ~/.kusari, never in the scanned directory.
+func UserHooks() (preUpload, postResult string, err error) {
+	path, err := getUserHooksPath()
+	if err != nil {
+		return "", "", err
+	}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return "", "", nil
+	} else if err != nil {
+		return "", "", fmt.Errorf("failed to read %s: %w", path, err)
+	}
+
+	var cfg userHooksConfig
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+	if err := dec.Decode(&cfg); err != nil && !errors.Is(err, io.EOF) {
+		return "", "", fmt.Errorf("invalid %s (it holds only hooks): %w", path, err)
+	}
+	resolve := func(script string) string {
+		if script == "" || filepath.IsAbs(script) {
+			return script
+		}
+		return filepath.Join(filepath.Dir(path), script)
+	}
+	return resolve(cfg.Hooks.PreUpload), resolve(cfg.Hooks.PostResult), nil
+}
+
+// hookEnvVars are the variables of the CLI's environment hooks get. Hooks
+// never see the rest, such as KUSARI_CLIENT_SECRET or GITHUB_TOKEN.
+var hookEnvVars = []string{"PATH", "HOME", "USER", "TMPDIR", "TMP", "TEMP", "LANG", "LC_ALL", "SYSTEMROOT"}
+
+// scanHooks are the scripts of the hooks, by hook name.
+type scanHooks map[string]string
+
+// hooks returns the hooks of o; an empty script leaves a hook out.
+func (o ScanOptions) hooks() scanHooks {
+	h := scanHooks{}
+	for name, script := range map[string]string{HookPreUpload: o.PreUploadHook, HookPostResult: o.PostResultHook} {
+		if script != "" {
+			h[name] = script
+		}
+	}
+	return h
+}
+
+// hookEnv returns the environment of the hook name: the hookEnvVars that
+// are set, and KUSARI_HOOK.
+func hookEnv(name string) []string {
+	var env []string
+	for _, k := range hookEnvVars {
+		if v, ok := os.LookupEnv(k); ok {
+			env = append(env, k+"="+v)
+		}
+	}
+	return append(env, "KUSARI_HOOK="+name)
+}
+
+// run runs the script of hook name, if any, from repoDir with arg as its
+// argument. The script's output goes to stderr, so it never mixes with the
+// scan's results.
+func (h scanHooks) run(repoDir, name, arg string) error {
+	script, ok := h[name]
+	if !ok {
+		return nil
+	}
+	output.Progressf(os.Stderr, "Running %s hook %s...\n", name, script)
+	cmd := exec.Command(script, arg)
+	cmd.Dir = repoDir
+	cmd.Stdout = os.Stderr
+	cmd.Stderr = os.Stderr
+	cmd.Env = hookEnv(name)
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("%s hook %s failed: %w", name, script, err)
+	}
+	return nil
+}
+
+// preUpload runs the pre_upload hook, if any, on the packaged bundle and
+// returns the bundle's size, which the hook may have changed. Checks of an
+// image reference have no repoDir, and run no hooks.
+func (h scanHooks) preUpload(repoDir string, size int64) (int64, error) {
+	if _, ok := h[HookPreUpload]; !ok || repoDir == "" {
+		return size, nil
+	}
+	bundle := filepath.Join(tarballDir, tarballName)
+	if err := h.run(repoDir, HookPreUpload, bundle); err != nil {
+		return 0, fmt.Errorf("%w; nothing was uploaded", err)
+	}
+	info, err := os.Stat(bundle)
+	if err != nil {
+		return 0, fmt.Errorf("%s hook removed the package: %w", HookPreUpload, err)
+	}
+	return info.Size(), nil
+}
+
+// postResult runs the post_result hook, if any, from repoDir on the saved
+// result at path, unless repoDir is empty, as for an image reference.
+// Failures are reported as warnings; they never fail the scan.
+func (h scanHooks) postResult(repoDir, path string) {
+	if h[HookPostResult] == "" || repoDir == "" {
+		return
+	}
+	if path == "" {
+		fmt.Fprintf(os.Stderr, "Warning: skipping %s hook: the result could not be saved\n", HookPostResult)
+		return
+	}
+	if err := h.run(repoDir, HookPostResult, path); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+	}
+}
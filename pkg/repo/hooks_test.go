@@ -0,0 +1,103 @@
+// Copyright (c) Kusari <https://www.kusari.dev/>
+// SPDX-License-Identifier: MIT
+
+package repo
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestScanOptionsHooks(t *testing.T) {
+	var opts ScanOptions
+	require.NoError(t, opts.resolvePaths())
+	assert.Empty(t, opts.hooks())
+
+	wd, err := os.Getwd()
+	require.NoError(t, err)
+	opts = ScanOptions{PreUploadHook: "./scripts/strip.sh", PostResultHook: "/usr/local/bin/notify"}
+	require.NoError(t, opts.resolvePaths())
+	assert.Equal(t, scanHooks{HookPreUpload: filepath.Join(wd, "scripts/strip.sh"), HookPostResult: "/usr/local/bin/notify"}, opts.hooks())
+}
+
+func TestUserHooks(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	pre, post, err := UserHooks()
+	require.NoError(t, err)
+	assert.Empty(t, pre)
+	assert.Empty(t, post)
+
+	dir := filepath.Join(home, ".kusari")
+	require.NoError(t, os.MkdirAll(dir, 0700))
+	path := filepath.Join(dir, userHooksFile)
+	require.NoError(t, os.WriteFile(path, []byte("hooks:\n  pre_upload: ./scripts/strip.sh\n  post_result: /usr/local/bin/notify\n"), 0600))
+	pre, post, err = UserHooks()
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(dir, "scripts/strip.sh"), pre)
+	assert.Equal(t, "/usr/local/bin/notify", post)
+
+	require.NoError(t, os.WriteFile(path, []byte("hooks:\n  pre-upload: ./strip.sh\n"), 0600))
+	_, _, err = UserHooks()
+	assert.ErrorContains(t, err, "it holds only hooks")
+}
+
+func TestHookEnv(t *testing.T) {
+	t.Setenv("KUSARI_CLIENT_SECRET", "s3cret")
+	t.Setenv("GITHUB_TOKEN", "ghp_x")
+	t.Setenv("PATH", "/usr/bin")
+	env := hookEnv(HookPreUpload)
+	assert.Contains(t, env, "PATH=/usr/bin")
+	assert.Contains(t, env, "KUSARI_HOOK=pre_upload")
+	for _, kv := range env {
+		assert.NotContains(t, kv, "s3cret")
+		assert.NotContains(t, kv, "ghp_x")
+	}
+}
+
+func TestPreUploadHook(t *testing.T) {
+	repoDir := t.TempDir()
+	tarballDir = t.TempDir()
+	bundle := filepath.Join(tarballDir, tarballName)
+	require.NoError(t, os.WriteFile(bundle, []byte("secret"), 0600))
+	require.NoError(t, os.WriteFile(filepath.Join(repoDir, "strip.sh"), []byte("#!/bin/sh\n[ \"$KUSARI_HOOK\" = pre_upload ] && [ -z \"$KUSARI_CLIENT_SECRET\" ] && printf redacted > \"$1\"\n"), 0755))
+
+	t.Setenv("KUSARI_CLIENT_SECRET", "s3cret")
+	size, err := scanHooks{HookPreUpload: filepath.Join(repoDir, "strip.sh")}.preUpload(repoDir, 6)
+	require.NoError(t, err)
+	assert.Equal(t, int64(len("redacted")), size)
+	data, err := os.ReadFile(bundle)
+	require.NoError(t, err)
+	assert.Equal(t, "redacted", string(data))
+
+	size, err = scanHooks{}.preUpload(repoDir, 6)
+	require.NoError(t, err)
+	assert.Equal(t, int64(6), size)
+
+	require.NoError(t, os.WriteFile(filepath.Join(repoDir, "fail.sh"), []byte("#!/bin/sh\nexit 1\n"), 0755))
+	fail := filepath.Join(repoDir, "fail.sh")
+	_, err = scanHooks{HookPreUpload: fail}.preUpload(repoDir, 6)
+	assert.ErrorContains(t, err, "pre_upload hook "+fail+" failed: exit status 1; nothing was uploaded")
+}
+
+func TestPostResultHook(t *testing.T) {
+	repoDir := t.TempDir()
+	out := filepath.Join(repoDir, "notified")
+	notify := filepath.Join(t.TempDir(), "notify.sh")
+	require.NoError(t, os.WriteFile(notify, []byte("#!/bin/sh\nprintf \"$1\" > notified\n"), 0755))
+	h := scanHooks{HookPostResult: notify}
+
+	// An image reference has no directory to run hooks in.
+	h.postResult("", "/tmp/result.json")
+	_, err := os.Stat(out)
+	assert.True(t, os.IsNotExist(err))
+
+	h.postResult(repoDir, "/tmp/result.json")
+	data, err := os.ReadFile(out)
+	require.NoError(t, err)
+	assert.Equal(t, "/tmp/result.json", string(data))
+}
@@ -0,0 +1,290 @@
+// Copyright (c) Kusari <https://www.kusari.dev/>
+// SPDX-License-Identifier: MIT
+
+package repo
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/kusaridev/kusari-cli/v2/api"
+	"github.com/kusaridev/kusari-cli/v2/pkg/auth"
+	"github.com/kusaridev/kusari-cli/v2/pkg/clierrors"
+	"github.com/kusaridev/kusari-cli/v2/pkg/image"
+	"github.com/kusaridev/kusari-cli/v2/pkg/login"
+	"github.com/kusaridev/kusari-cli/v2/pkg/output"
+)
+
+const (
+	// dockerfileFile is the name the checked Dockerfile has in the bundle.
+	dockerfileFile = "Dockerfile"
+	// imageInspectFile holds the docker image inspect output of the
+	// checked images, by reference.
+	imageInspectFile = "image-inspect.json"
+)
+
+// ImageCheck analyzes a container image for base image pinning and
+// vulnerability posture. target is a Dockerfile, a directory holding one,
+// or an image reference. With inspect, the docker image inspect output of
+// the image, or of the Dockerfile's base images, is sent along; the images
+// must have been pulled. Of opts, only the platform, output, hook and
+// bundle encryption options apply.
+func ImageCheck(target string, inspect bool, opts ScanOptions) error {
+	return imageCheck(target, inspect, opts, nil)
+}
+
+func imageCheck(target string, inspect bool, opts ScanOptions, mock *scanMock) error {
+	if opts.Verbose {
+		output.SetVerbose(true)
+	}
+	output.Debug("image check options", "target", target, "platformUrl", opts.PlatformURL, "consoleUrl", opts.ConsoleURL,
+		"outputFormat", opts.OutputFormat, "inspect", inspect)
+
+	outputs, err := ParseOutputs(opts.OutputFormat)
+	if err != nil {
+		return err
+	}
+	if err := opts.resolvePaths(); err != nil {
+		return err
+	}
+	encryption, err := ParseBundleEncryption(string(opts.BundleEncryption))
+	if err != nil {
+		return err
+	}
+
+	dockerfile, err := findDockerfile(target)
+	if err != nil {
+		return err
+	}
+	meta := &api.BundleMeta{ScanType: "image", ScannedBy: scannedBy(), Image: &api.ImageMeta{}}
+	var dir string
+	var refs []string
+	if dockerfile != "" {
+		if dir, err = filepath.Abs(filepath.Dir(dockerfile)); err != nil {
+			return fmt.Errorf("failed to resolve directory: %w", err)
+		}
+		f, err := os.Open(dockerfile)
+		if err != nil {
+			return fmt.Errorf("failed to open Dockerfile: %w", err)
+		}
+		baseImages, err := image.ParseDockerfile(f)
+		_ = f.Close()
+		if err != nil {
+			return clierrors.NewValidationError("%s: %v", dockerfile, err)
+		}
+		meta.DirName = filepath.Base(dir)
+		meta.Image.Dockerfile = dockerfileFile
+		meta.Image.BaseImages = baseImages
+		for _, b := range baseImages {
+			refs = append(refs, b.Ref)
+		}
+	} else {
+		meta.DirName = imageName(target)
+		meta.Image.Ref = target
+		refs = []string{target}
+	}
+
+	var inspected map[string]json.RawMessage
+	if inspect {
+		if inspected, err = inspectImages(refs); err != nil {
+			return err
+		}
+		meta.Image.Inspected = len(inspected) > 0
+	}
+	printImageSummary(meta.Image, inspected)
+
+	fileUploader := uploadFileToS3
+	presignedURLGetter := getPresignedURL
+	defaultWorkspaceGetter := login.FetchWorkspacesCached
+	bundleKeyGetter := fetchBundleKey
+	var accessToken string
+	if mock != nil {
+		fileUploader = mock.fileUploader
+		presignedURLGetter = mock.presignedURLGetter
+		defaultWorkspaceGetter = mock.defaultWorkspaceGetter
+		bundleKeyGetter = mock.bundleKeyGetter
+		accessToken = mock.token
+	} else {
+		token, err := auth.DefaultTokenProvider().Token(context.Background())
+		if err != nil {
+			return fmt.Errorf("failed to load auth token: %w", err)
+		}
+		accessToken = token.AccessToken
+	}
+
+	tempDir, err := setupWorkingDirectory()
+	if err != nil {
+		return err
+	}
+	defer cleanupWorkingDirectory(tempDir)
+
+	output.Progressf(os.Stderr, "Packaging image check...\n")
+	size, err := packageImage(meta, dockerfile, inspected)
+	if err != nil {
+		return fmt.Errorf("failed to package image check: %w", err)
+	}
+	hooks := opts.hooks()
+	if dir == "" && len(hooks) > 0 {
+		fmt.Fprintf(os.Stderr, "Warning: skipping the scan hooks: %s is an image reference, not a Dockerfile\n", target)
+	}
+	if size, err = hooks.preUpload(dir, size); err != nil {
+		return err
+	}
+
+	workspace, workspaceDescription, err := scanWorkspace(opts.PlatformURL, accessToken, defaultWorkspaceGetter)
+	if err != nil {
+		return err
+	}
+	keyID, size, err := encryptScanBundle(encryption, opts.PlatformURL, accessToken, workspace, workspaceDescription, size, bundleKeyGetter)
+	if err != nil {
+		return err
+	}
+
+	j := &UploadJournal{
+		Dir:         dir,
+		Image:       true,
+		PlatformURL: opts.PlatformURL,
+		ConsoleURL:  opts.ConsoleURL,
+		Workspace:   workspace,
+		Size:        size,
+		DirName:     meta.DirName,
+		BundleKeyID: keyID,
+	}
+	if err := newUploadJournal(j, filepath.Join(tarballDir, tarballName)); err != nil {
+		output.Debug("upload will not be resumable", "error", err)
+	}
+	opts.CommentPlatform = ""
+	return uploadAndWait(opts, j, accessToken, presignedURLGetter, fileUploader, outputs, dir)
+}
+
+// findDockerfile returns the Dockerfile target names, or empty when target
+// is an image reference.
+func findDockerfile(target string) (string, error) {
+	fi, err := os.Stat(target)
+	switch {
+	case err == nil && fi.IsDir():
+		dockerfile := filepath.Join(target, "Dockerfile")
+		if _, err := os.Stat(dockerfile); err != nil {
+			return "", clierrors.NewValidationError("no Dockerfile in %s", target)
+		}
+		return dockerfile, nil
+	case err == nil:
+		return target, nil
+	case image.ValidRef(target):
+		return "", nil
+	default:
+		return "", clierrors.NewValidationError("%s is neither a Dockerfile nor an image reference", target)
+	}
+}
+
+// imageName returns the last path element of the image reference ref,
+// without tag and digest, e.g. golang for docker.io/library/golang:1.25.
+func imageName(ref string) string {
+	name, _, _ := strings.Cut(ref, "@")
+	name = path.Base(name)
+	name, _, _ = strings.Cut(name, ":")
+	return name
+}
+
+// inspectImages returns the docker image inspect output of the local
+// images among refs. Images that haven't been pulled are skipped with a
+// warning.
+func inspectImages(refs []string) (map[string]json.RawMessage, error) {
+	inspected := map[string]json.RawMessage{}
+	for _, ref := range refs {
+		if _, ok := inspected[ref]; ok || strings.Contains(ref, "$") {
+			continue
+		}
+		config, err := image.Inspect(ref)
+		if errors.Is(err, image.ErrNoDocker) {
+			return nil, clierrors.NewValidationError("--inspect needs the docker CLI: %v", err)
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: not inspecting %s (pull it first to include its config): %v\n", ref, err)
+			continue
+		}
+		inspected[ref] = config
+	}
+	return inspected, nil
+}
+
+// packageImage writes meta, the Dockerfile, if any, and the inspect output
+// to the working directory and bundles them. It returns the bundle's size.
+func packageImage(meta *api.BundleMeta, dockerfile string, inspected map[string]json.RawMessage) (int64, error) {
+	if err := writeMeta(meta); err != nil {
+		return 0, err
+	}
+	names := []string{metaFile}
+	if dockerfile != "" {
+		if err := copyFile(dockerfile, filepath.Join(workingDir, dockerfileFile)); err != nil {
+			return 0, fmt.Errorf("failed to copy Dockerfile: %w", err)
+		}
+		names = append(names, dockerfileFile)
+	}
+	if len(inspected) > 0 {
+		data, err := json.Marshal(inspected)
+		if err != nil {
+			return 0, fmt.Errorf("failed to marshal image config: %w", err)
+		}
+		if err := os.WriteFile(filepath.Join(workingDir, imageInspectFile), data, 0600); err != nil {
+			return 0, fmt.Errorf("failed to write image config: %w", err)
+		}
+		names = append(names, imageInspectFile)
+	}
+	return compressBundle(filepath.Join(tarballDir, tarballNameUncompressed), names...)
+}
+
+// copyFile copies the file at src to dst.
+func copyFile(src, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = in.Close() }()
+	out, err := os.OpenFile(dst, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0600)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		_ = out.Close()
+		return err
+	}
+	return out.Close()
+}
+
+// printImageSummary prints how each base image is pinned, before the
+// upload, suggesting the digest to pin unpinned ones to when the image was
+// inspected.
+func printImageSummary(im *api.ImageMeta, inspected map[string]json.RawMessage) {
+	if im.Ref != "" {
+		output.Progressf(os.Stderr, "Checking image %s\n", im.Ref)
+		return
+	}
+	if len(im.BaseImages) == 0 {
+		output.Progressf(os.Stderr, "The Dockerfile has no base images (FROM scratch)\n")
+		return
+	}
+	output.Progressf(os.Stderr, "Base images:\n")
+	for _, b := range im.BaseImages {
+		var status string
+		switch {
+		case b.Pinned:
+			status = "pinned by digest"
+		case image.Tagged(b.Ref):
+			status = "not pinned: the tag can move"
+		default:
+			status = "not pinned: no tag, so latest"
+		}
+		if digest := image.RepoDigest(inspected[b.Ref]); !b.Pinned && digest != "" {
+			status += "; pin with " + digest
+		}
+		output.Progressf(os.Stderr, "  line %d: %s (%s)\n", b.Line, b.Ref, status)
+	}
+}
@@ -0,0 +1,54 @@
+// Copyright (c) Kusari <https://www.kusari.dev/>
+// SPDX-License-Identifier: MIT
+
+package repo
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/kusaridev/kusari-cli/v2/api"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestImageCheck_Dockerfile(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	dir := filepath.Join(t.TempDir(), "service")
+	require.NoError(t, os.Mkdir(dir, 0755))
+	writeFile(t, filepath.Join(dir, "Dockerfile"), "FROM golang:1.25 AS build\nFROM build\nFROM scratch\n")
+
+	var scanType string
+	var files []string
+	var meta api.BundleMeta
+	err := imageCheck(dir, false, ScanOptions{PlatformURL: "https://platform.example.com", ConsoleURL: "https://console.example.com", OutputFormat: "markdown"}, bundleMock(t, &scanType, &files, &meta))
+	require.NoError(t, err)
+
+	assert.Equal(t, "image", scanType)
+	assert.ElementsMatch(t, []string{metaFile, dockerfileFile, manifestFile}, files)
+	assert.Equal(t, "image", meta.ScanType)
+	assert.Equal(t, "service", meta.DirName)
+	assert.Equal(t, &api.ImageMeta{
+		Dockerfile: dockerfileFile,
+		BaseImages: []api.BaseImage{{Ref: "golang:1.25", Stage: "build", Line: 1}},
+	}, meta.Image)
+}
+
+func TestImageCheck_Ref(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+
+	var scanType string
+	var files []string
+	var meta api.BundleMeta
+	err := imageCheck("ghcr.io/org/app:1.4", false, ScanOptions{PlatformURL: "https://platform.example.com", ConsoleURL: "https://console.example.com", OutputFormat: "markdown"}, bundleMock(t, &scanType, &files, &meta))
+	require.NoError(t, err)
+
+	assert.Equal(t, "image", scanType)
+	assert.Equal(t, []string{metaFile, manifestFile}, files)
+	assert.Equal(t, "app", meta.DirName)
+	assert.Equal(t, &api.ImageMeta{Ref: "ghcr.io/org/app:1.4"}, meta.Image)
+
+	err = imageCheck("./missing/Dockerfile", false, ScanOptions{PlatformURL: "https://platform.example.com", ConsoleURL: "https://console.example.com", OutputFormat: "markdown"}, bundleMock(t, &scanType, &files, &meta))
+	assert.ErrorContains(t, err, "neither a Dockerfile nor an image reference")
+}
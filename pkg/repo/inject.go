@@ -0,0 +1,38 @@
+// Copyright (c) Kusari <https://www.kusari.dev/>
+// SPDX-License-Identifier: MIT
+
+package repo
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/kusaridev/kusari-cli/v2/pkg/clock"
+	"github.com/kusaridev/kusari-cli/v2/pkg/transport"
+)
+
+var (
+	clk          clock.Clock = clock.Real{}
+	roundTripper http.RoundTripper
+)
+
+// SetClock sets the clock the package reads and polls with. nil restores
+// the system clock.
+func SetClock(c clock.Clock) {
+	if c == nil {
+		c = clock.Real{}
+	}
+	clk = c
+}
+
+// SetTransport sets the RoundTripper of the HTTP clients the package
+// creates. nil restores http.DefaultTransport.
+func SetTransport(rt http.RoundTripper) {
+	roundTripper = rt
+}
+
+// newHTTPClient returns a client with the given timeout, unless
+// --http-timeout overrides it, and the transport set with SetTransport.
+func newHTTPClient(timeout time.Duration) *http.Client {
+	return &http.Client{Timeout: transport.Timeout(timeout), Transport: roundTripper}
+}
@@ -0,0 +1,54 @@
+// Copyright (c) Kusari <https://www.kusari.dev/>
+// SPDX-License-Identifier: MIT
+
+package repo
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/kusaridev/kusari-cli/v2/pkg/clock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// countingTransport counts the requests it passes to http.DefaultTransport.
+type countingTransport struct{ n int }
+
+func (c *countingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	c.n++
+	return http.DefaultTransport.RoundTrip(req)
+}
+
+func TestQueryForIngestionStatus_InjectedClockAndTransport(t *testing.T) {
+	polls := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		polls++
+		status := "processing"
+		if polls == 3 {
+			status = "success"
+		}
+		_, _ = w.Write([]byte(`[{"statusMeta": {"status": "` + status + `"}}]`))
+	}))
+	defer server.Close()
+
+	fake := clock.NewFake(time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC))
+	rt := &countingTransport{}
+	SetClock(fake)
+	SetTransport(rt)
+	t.Cleanup(func() {
+		SetClock(nil)
+		SetTransport(nil)
+	})
+
+	start := time.Now()
+	item, err := queryForIngestionStatusWithTimeout(context.Background(), server.URL, "tenant", "doc", "token", "ws", nil)
+	require.NoError(t, err)
+	assert.Equal(t, "success", item.StatusMeta.Status)
+	assert.Equal(t, 3, rt.n)
+	assert.Equal(t, 4*time.Second, fake.Slept(), "two polling intervals")
+	assert.Less(t, time.Since(start), time.Second, "the fake clock doesn't wait")
+}
@@ -0,0 +1,356 @@
+// Copyright (c) Kusari <https://www.kusari.dev/>
+// SPDX-License-Identifier: MIT
+
+package repo
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/kusaridev/kusari-cli/v2/pkg/auth"
+	"github.com/kusaridev/kusari-cli/v2/pkg/output"
+)
+
+// journalFileName is the upload state kept next to the bundle in each
+// upload directory.
+const journalFileName = "journal.json"
+
+// ErrNoPendingUploads is returned by LatestUpload when nothing is left to
+// resume.
+var ErrNoPendingUploads = errors.New("no interrupted uploads to resume")
+
+// UploadJournal records a packaged scan bundle until its results are in,
+// so an interrupted scan can be resumed without packaging it again.
+type UploadJournal struct {
+	ID        string    `json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+
+	// Dir is the absolute path of the scanned repository and Rev the ref
+	// a diff scan compared against. Staged diff scans compared the index
+	// and CommittedOnly scans HEAD, instead of the working tree.
+	Dir           string `json:"dir"`
+	Rev           string `json:"rev,omitempty"`
+	Staged        bool   `json:"staged,omitempty"`
+	CommittedOnly bool   `json:"committed_only,omitempty"`
+	Full          bool   `json:"full"`
+	// Delta full scan bundles hold only the files changed since the last
+	// one, with the manifest diff.
+	Delta bool `json:"delta,omitempty"`
+	// PatchOnly bundles hold the metadata and patch, but no source.
+	PatchOnly bool `json:"patch_only,omitempty"`
+	// SecretsOnly bundles hold the metadata and patch, for a scan for
+	// secrets only.
+	SecretsOnly bool `json:"secrets_only,omitempty"`
+	// IaC bundles hold only infrastructure as code files, for an IaC
+	// scan.
+	IaC bool `json:"iac,omitempty"`
+	// Image bundles hold a Dockerfile or image reference, for an image
+	// check; Dir is then the Dockerfile's directory, or empty.
+	Image bool `json:"image,omitempty"`
+
+	// The presigned URL request: URLs expire, so a new one is requested
+	// from these on resume.
+	PlatformURL string `json:"platform_url"`
+	ConsoleURL  string `json:"console_url"`
+	Workspace   string `json:"workspace"`
+	Size        int64  `json:"size"`
+
+	// Bundle metadata the result's sort key is built from.
+	Remote  string `json:"remote"`
+	DirName string `json:"dir_name"`
+	Branch  string `json:"branch"`
+
+	// BundleKeyID is the workspace key the bundle was encrypted to, if it
+	// was.
+	BundleKeyID string `json:"bundle_key_id,omitempty"`
+
+	// Uploaded is set once the bundle is stored; SortKey and ResultURL
+	// then locate the result.
+	Uploaded  bool   `json:"uploaded"`
+	SortKey   string `json:"sort_key,omitempty"`
+	ResultURL string `json:"result_url,omitempty"`
+
+	// path is the upload directory, holding the journal and the bundle;
+	// empty when the upload can't be resumed. bundle is the bundle path.
+	path   string
+	bundle string
+}
+
+// UploadsDir returns the directory interrupted uploads are kept in,
+// ~/.kusari/uploads.
+func UploadsDir() (string, error) {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to get home directory: %w", err)
+	}
+	return filepath.Join(homeDir, ".kusari", "uploads"), nil
+}
+
+// newUploadJournal creates the upload directory for j and moves the bundle
+// at bundlePath into it. On error, j is left pointing at bundlePath and
+// can still be uploaded, just not resumed.
+func newUploadJournal(j *UploadJournal, bundlePath string) error {
+	dir, err := UploadsDir()
+	if err != nil {
+		return err
+	}
+	suffix := make([]byte, 4)
+	if _, err := rand.Read(suffix); err != nil {
+		return fmt.Errorf("failed to generate upload ID: %w", err)
+	}
+	j.CreatedAt = clk.Now()
+	j.ID = j.CreatedAt.UTC().Format("20060102T150405Z") + "-" + hex.EncodeToString(suffix)
+	j.path = filepath.Join(dir, j.ID)
+	if err := os.MkdirAll(j.path, 0700); err != nil {
+		return fmt.Errorf("failed to create upload directory: %w", err)
+	}
+
+	// The journal goes first: a crash in between leaves a journal whose
+	// bundle is missing, which resume reports, rather than a stray bundle
+	j.bundle = bundlePath
+	if err := j.save(); err != nil {
+		_ = os.RemoveAll(j.path)
+		j.path = ""
+		return err
+	}
+	bundle := filepath.Join(j.path, tarballName)
+	if err := moveFile(bundlePath, bundle); err != nil {
+		_ = os.RemoveAll(j.path)
+		j.path = ""
+		return fmt.Errorf("failed to keep bundle for resume: %w", err)
+	}
+	j.bundle = bundle
+	return nil
+}
+
+// scanType is the scan type of the bundle of j, as the platform names it.
+func (j *UploadJournal) scanType() string {
+	switch {
+	case j.Full && j.Delta:
+		return "full-delta"
+	case j.Full:
+		return "full"
+	case j.Image:
+		return "image"
+	case j.SecretsOnly:
+		return "secrets"
+	case j.IaC:
+		return "iac"
+	case j.PatchOnly:
+		return "patch"
+	default:
+		return "diff"
+	}
+}
+
+func (j *UploadJournal) save() error {
+	if j.path == "" {
+		return nil
+	}
+	data, err := json.MarshalIndent(j, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal upload journal: %w", err)
+	}
+	// Write then rename, so a crash never leaves a torn journal
+	tmp := filepath.Join(j.path, journalFileName+".tmp")
+	if err := os.WriteFile(tmp, data, 0600); err != nil {
+		return fmt.Errorf("failed to write upload journal: %w", err)
+	}
+	if err := os.Rename(tmp, filepath.Join(j.path, journalFileName)); err != nil {
+		return fmt.Errorf("failed to write upload journal: %w", err)
+	}
+	return nil
+}
+
+// remove deletes the journal and its bundle, once the upload needs no
+// resuming.
+func (j *UploadJournal) remove() error {
+	if j.path == "" {
+		return nil
+	}
+	return os.RemoveAll(j.path)
+}
+
+// PendingUploads returns the journals of interrupted uploads, oldest
+// first. Unreadable journals are skipped.
+func PendingUploads() ([]*UploadJournal, error) {
+	dir, err := UploadsDir()
+	if err != nil {
+		return nil, err
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to read uploads directory: %w", err)
+	}
+
+	var journals []*UploadJournal
+	for _, e := range entries {
+		if !e.IsDir() {
+			continue
+		}
+		path := filepath.Join(dir, e.Name())
+		data, err := os.ReadFile(filepath.Join(path, journalFileName))
+		if err != nil {
+			continue
+		}
+		var j UploadJournal
+		if err := json.Unmarshal(data, &j); err != nil {
+			continue
+		}
+		j.path = path
+		j.bundle = filepath.Join(path, tarballName)
+		journals = append(journals, &j)
+	}
+	slices.SortFunc(journals, func(a, b *UploadJournal) int {
+		if c := a.CreatedAt.Compare(b.CreatedAt); c != 0 {
+			return c
+		}
+		return strings.Compare(a.ID, b.ID)
+	})
+	return journals, nil
+}
+
+// LatestUpload returns the most recent interrupted upload of the
+// repository at dir, or of any repository when dir is empty.
+func LatestUpload(dir string) (*UploadJournal, error) {
+	if dir != "" {
+		abs, err := filepath.Abs(dir)
+		if err == nil {
+			dir = abs
+		}
+	}
+	journals, err := PendingUploads()
+	if err != nil {
+		return nil, err
+	}
+	for i := len(journals) - 1; i >= 0; i-- {
+		if dir == "" || journals[i].Dir == dir {
+			return journals[i], nil
+		}
+	}
+	return nil, ErrNoPendingUploads
+}
+
+// moveFile renames src to dst, copying when they are on different file
+// systems (the temp directory often is).
+func moveFile(src, dst string) error {
+	if err := os.Rename(src, dst); err == nil {
+		return nil
+	}
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = in.Close() }()
+	out, err := os.OpenFile(dst, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0600)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		_ = out.Close()
+		return err
+	}
+	if err := out.Close(); err != nil {
+		return err
+	}
+	return os.Remove(src)
+}
+
+// Resume finishes the most recent interrupted scan of the repository at
+// opts.Dir (of any repository when it is empty): it uploads the kept
+// package, unless that already succeeded, then waits for the results like
+// Scan. The options packaging the scan no longer apply.
+func Resume(opts ScanOptions) error {
+	return resume(opts, nil)
+}
+
+func resume(opts ScanOptions, mock *scanMock) error {
+	if opts.Verbose {
+		output.SetVerbose(true)
+	}
+	outputs, err := ParseOutputs(opts.OutputFormat)
+	if err != nil {
+		return err
+	}
+	if err := opts.resolvePaths(); err != nil {
+		return err
+	}
+
+	j, err := LatestUpload(opts.Dir)
+	if err != nil {
+		return err
+	}
+	accessToken, presignedURLGetter, fileUploader, err := uploadDeps(mock)
+	if err != nil {
+		return err
+	}
+
+	output.Progressf(os.Stderr, "Resuming scan of %s from %s\n", j.Dir, j.CreatedAt.Local().Format(time.DateTime))
+	return uploadAndWait(opts, j, accessToken, presignedURLGetter, fileUploader, outputs, j.Dir)
+}
+
+// Flush uploads every interrupted scan that has not been uploaded yet,
+// printing where each result will be, and discards them all. It carries
+// on past failures, which keep their package, and returns the first.
+func Flush(verbose bool) error {
+	return flush(verbose, nil)
+}
+
+func flush(verbose bool, mock *scanMock) error {
+	if verbose {
+		output.SetVerbose(true)
+	}
+	journals, err := PendingUploads()
+	if err != nil {
+		return err
+	}
+	if len(journals) == 0 {
+		fmt.Fprintln(os.Stderr, "No pending uploads")
+		return nil
+	}
+	accessToken, presignedURLGetter, fileUploader, err := uploadDeps(mock)
+	if err != nil {
+		return err
+	}
+
+	var firstErr error
+	for _, j := range journals {
+		output.Progressf(os.Stderr, "Flushing %s scan of %s\n", j.CreatedAt.Local().Format(time.DateTime), j.Dir)
+		if err := uploadAndWait(ScanOptions{Verbose: verbose}, j, accessToken, presignedURLGetter, fileUploader, nil, j.Dir); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			if firstErr == nil {
+				firstErr = err
+			}
+			continue
+		}
+		fmt.Println(j.ResultURL)
+	}
+	return firstErr
+}
+
+// uploadDeps returns the token and upload functions, or their mocks.
+func uploadDeps(mock *scanMock) (string,
+	func(apiEndpoint string, jwtToken string, filePath, workspace string, scanType string, size int64) (string, error),
+	func(presignedURL, filePath string) error, error) {
+	if mock != nil {
+		return mock.token, mock.presignedURLGetter, mock.fileUploader, nil
+	}
+	token, err := auth.DefaultTokenProvider().Token(context.Background())
+	if err != nil {
+		return "", nil, nil, fmt.Errorf("failed to load auth token: %w", err)
+	}
+	return token.AccessToken, getPresignedURL, uploadFileToS3, nil
+}
@@ -0,0 +1,121 @@
+// Copyright (c) Kusari <https://www.kusari.dev/>
+// SPDX-License-Identifier: MIT
+
+package repo
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// pendingUpload keeps a fake bundle for dir in the uploads directory.
+func pendingUpload(t *testing.T, dir string) *UploadJournal {
+	t.Helper()
+	bundle := filepath.Join(t.TempDir(), tarballName)
+	require.NoError(t, os.WriteFile(bundle, []byte("bundle"), 0600))
+
+	j := &UploadJournal{
+		Dir:         dir,
+		PlatformURL: "https://platform.example.com/",
+		ConsoleURL:  "https://console.example.com/",
+		Workspace:   "test-workspace-id",
+		Size:        6,
+		Remote:      "https://github.com/kusaridev/test",
+		DirName:     filepath.Base(dir),
+		Branch:      "main",
+	}
+	require.NoError(t, newUploadJournal(j, bundle))
+	assert.NoFileExists(t, bundle, "bundle should be moved into the uploads directory")
+	return j
+}
+
+func TestUploadJournal(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+
+	_, err := LatestUpload("")
+	assert.ErrorIs(t, err, ErrNoPendingUploads)
+
+	first := pendingUpload(t, "/src/one")
+	second := pendingUpload(t, "/src/two")
+
+	pending, err := PendingUploads()
+	require.NoError(t, err)
+	require.Len(t, pending, 2)
+	assert.Equal(t, first.ID, pending[0].ID)
+	assert.Equal(t, "/src/one", pending[0].Dir)
+	assert.FileExists(t, pending[0].bundle)
+
+	latest, err := LatestUpload("")
+	require.NoError(t, err)
+	assert.Equal(t, second.ID, latest.ID)
+
+	latest, err = LatestUpload("/src/one")
+	require.NoError(t, err)
+	assert.Equal(t, first.ID, latest.ID)
+
+	_, err = LatestUpload("/src/three")
+	assert.ErrorIs(t, err, ErrNoPendingUploads)
+}
+
+func TestResume(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	j := pendingUpload(t, "/src/one")
+
+	var uploaded string
+	mock := &scanMock{
+		fileUploader: func(presignedURL, filePath string) error {
+			uploaded = filePath
+			return nil
+		},
+		presignedURLGetter: func(apiEndpoint string, jwtToken string, filePath, workspace string, scanType string, size int64) (string, error) {
+			assert.Equal(t, "test-workspace-id", workspace)
+			assert.Equal(t, int64(6), size)
+			return "https://example.com/workspace/test-workspace-id/user/human/test-user-id/diff/blob/123", nil
+		},
+		token: "token",
+	}
+
+	require.NoError(t, resume(ScanOptions{Dir: "/src/one", OutputFormat: "markdown"}, mock))
+	assert.Equal(t, j.bundle, uploaded)
+
+	pending, err := PendingUploads()
+	require.NoError(t, err)
+	assert.Empty(t, pending, "journal should be removed once uploaded")
+}
+
+func TestFlushKeepsFailedUploads(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	pendingUpload(t, "/src/one")
+	pendingUpload(t, "/src/two")
+
+	calls := 0
+	mock := &scanMock{
+		fileUploader: func(presignedURL, filePath string) error {
+			calls++
+			if calls == 1 {
+				return errors.New("connection reset")
+			}
+			return nil
+		},
+		presignedURLGetter: func(apiEndpoint string, jwtToken string, filePath, workspace string, scanType string, size int64) (string, error) {
+			return "https://example.com/workspace/test-workspace-id/user/human/test-user-id/diff/blob/123", nil
+		},
+		token: "token",
+	}
+
+	err := flush(false, mock)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "connection reset")
+	assert.Equal(t, 2, calls, "flush should carry on past a failure")
+
+	pending, err := PendingUploads()
+	require.NoError(t, err)
+	require.Len(t, pending, 1)
+	assert.Equal(t, "/src/one", pending[0].Dir)
+	assert.False(t, pending[0].Uploaded)
+}
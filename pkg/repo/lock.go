@@ -0,0 +1,190 @@
+// Copyright (c) Kusari <https://www.kusari.dev/>
+// SPDX-License-Identifier: MIT
+
+package repo
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// scanLockName is the lock file kept in the git directory of a repository
+// while a scan of it runs.
+const scanLockName = "kusari-scan.lock"
+
+// staleLockAge is the age after which a lock taken on another host, whose
+// process can't be checked, is considered abandoned.
+const staleLockAge = 2 * time.Hour
+
+// lockPollInterval is how often a scan waiting for the lock retries.
+const lockPollInterval = time.Second
+
+// lockOwner is the content of a scan lock.
+type lockOwner struct {
+	PID     int       `json:"pid"`
+	Host    string    `json:"host"`
+	Started time.Time `json:"started"`
+}
+
+// scanLock is a held scan lock.
+type scanLock struct {
+	path  string
+	owner lockOwner
+}
+
+// acquireScanLock takes the scan lock of the repository at dir, waiting up
+// to wait for a running scan to release it. Locks whose process is gone
+// are removed. With noGit, the lock is kept in the temp directory.
+func acquireScanLock(dir string, wait time.Duration, noGit bool) (*scanLock, error) {
+	var path string
+	if noGit {
+		var err error
+		if path, err = snapshotLockPath(dir); err != nil {
+			return nil, err
+		}
+	} else {
+		gitDir, err := exec.Command("git", "-C", dir, "rev-parse", "--absolute-git-dir").Output()
+		if err != nil {
+			return nil, fmt.Errorf("failed to find the git directory of %s: %w", dir, err)
+		}
+		path = filepath.Join(strings.TrimSpace(string(gitDir)), scanLockName)
+	}
+
+	host, _ := os.Hostname()
+	lock := &scanLock{path: path, owner: lockOwner{PID: os.Getpid(), Host: host}}
+	deadline := clk.Now().Add(wait)
+	waiting := false
+	for {
+		lock.owner.Started = clk.Now().UTC()
+		created, err := lock.create()
+		if err != nil {
+			return nil, err
+		}
+		if created {
+			return lock, nil
+		}
+
+		owner, err := readLockOwner(path)
+		if errors.Is(err, fs.ErrNotExist) {
+			continue
+		}
+		if err == nil && owner.stale(host, clk.Now()) {
+			fmt.Fprintf(os.Stderr, "Removing stale scan lock of process %d (started %s)\n", owner.PID, owner.Started.Local().Format(time.RFC1123))
+			if err := removeStaleLock(path, owner); err != nil {
+				return nil, fmt.Errorf("failed to remove stale scan lock %s: %w", path, err)
+			}
+			continue
+		}
+
+		if !clk.Now().Before(deadline) {
+			holder := "another scan"
+			if err == nil {
+				holder = fmt.Sprintf("another scan (process %d on %s, started %s)", owner.PID, owner.Host, owner.Started.Local().Format(time.RFC1123))
+			}
+			return nil, fmt.Errorf("%s is running in %s; wait for it to finish or pass --lock-wait to wait for it (remove %s if no scan is running)", holder, dir, path)
+		}
+		if !waiting {
+			fmt.Fprintf(os.Stderr, "Waiting for another scan of %s to finish...\n", dir)
+			waiting = true
+		}
+		clk.Sleep(lockPollInterval)
+	}
+}
+
+// removeStaleLock removes the lock at path if it still holds the stale
+// owner. Another waiter may have removed it and taken the lock since
+// stale was read, so the lock is first renamed aside, which only one of
+// them can do, and checked there; a fresh lock is put back.
+func removeStaleLock(path string, stale lockOwner) error {
+	tombstone := fmt.Sprintf("%s.%d.stale", path, os.Getpid())
+	if err := os.Rename(path, tombstone); err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil
+		}
+		return err
+	}
+	owner, err := readLockOwner(tombstone)
+	if err == nil && owner != stale {
+		// Link fails rather than replace a lock taken meanwhile.
+		err = os.Link(tombstone, path)
+	}
+	if removeErr := os.Remove(tombstone); err == nil {
+		err = removeErr
+	}
+	return err
+}
+
+// create writes the lock file, reporting false when it already exists.
+func (l *scanLock) create() (bool, error) {
+	f, err := os.OpenFile(l.path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o644)
+	if errors.Is(err, fs.ErrExist) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to create scan lock: %w", err)
+	}
+	err = json.NewEncoder(f).Encode(l.owner)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		_ = os.Remove(l.path)
+		return false, fmt.Errorf("failed to write scan lock: %w", err)
+	}
+	return true, nil
+}
+
+// release removes the lock, unless another scan took it over as stale.
+func (l *scanLock) release() {
+	if l == nil {
+		return
+	}
+	if owner, err := readLockOwner(l.path); err == nil && owner == l.owner {
+		_ = os.Remove(l.path)
+	}
+}
+
+func readLockOwner(path string) (lockOwner, error) {
+	var owner lockOwner
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return owner, err
+	}
+	if err := json.Unmarshal(data, &owner); err != nil {
+		// Being written, or truncated by a crash; only the age tells.
+		info, statErr := os.Stat(path)
+		if statErr != nil {
+			return owner, statErr
+		}
+		return lockOwner{Started: info.ModTime()}, nil
+	}
+	return owner, nil
+}
+
+// stale reports whether the scan holding the lock is gone: its process
+// has exited, or, when it can't be checked from host, the lock is older
+// than staleLockAge.
+func (o lockOwner) stale(host string, now time.Time) bool {
+	if o.PID > 0 && o.Host == host {
+		return !processAlive(o.PID)
+	}
+	return now.Sub(o.Started) > staleLockAge
+}
+
+// processAlive reports whether a process with pid exists.
+func processAlive(pid int) bool {
+	p, err := os.FindProcess(pid)
+	if err != nil {
+		return false
+	}
+	err = p.Signal(syscall.Signal(0))
+	return !errors.Is(err, os.ErrProcessDone) && !errors.Is(err, syscall.ESRCH)
+}
@@ -0,0 +1,118 @@
+// Copyright (c) Kusari <https://www.kusari.dev/>
+// SPDX-License-Identifier: MIT
+
+package repo
+
+import (
+	"encoding/json"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/kusaridev/kusari-cli/v2/pkg/clock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestScanLock(t *testing.T) {
+	dir := t.TempDir()
+	runCmd(t, dir, "git", "init")
+	lockPath := filepath.Join(dir, ".git", scanLockName)
+
+	lock, err := acquireScanLock(dir, 0, false)
+	require.NoError(t, err)
+	assert.FileExists(t, lockPath)
+
+	// A second scan fails fast, or after waiting.
+	_, err = acquireScanLock(dir, 0, false)
+	assert.ErrorContains(t, err, "another scan (process")
+	assert.ErrorContains(t, err, "--lock-wait")
+
+	start := time.Date(2026, 10, 14, 9, 0, 0, 0, time.UTC)
+	fake := clock.NewFake(start)
+	SetClock(fake)
+	t.Cleanup(func() { SetClock(nil) })
+	_, err = acquireScanLock(dir, 3*time.Second, false)
+	assert.Error(t, err)
+	assert.Equal(t, 3*time.Second, fake.Now().Sub(start))
+
+	lock.release()
+	assert.NoFileExists(t, lockPath)
+
+	lock, err = acquireScanLock(dir, 0, false)
+	require.NoError(t, err)
+	lock.release()
+}
+
+func TestScanLockStale(t *testing.T) {
+	dir := t.TempDir()
+	runCmd(t, dir, "git", "init")
+	lockPath := filepath.Join(dir, ".git", scanLockName)
+	host, _ := os.Hostname()
+
+	// The process of a finished command is gone.
+	cmd := exec.Command("git", "--version")
+	require.NoError(t, cmd.Run())
+	writeLock := func(owner lockOwner) {
+		data, err := json.Marshal(owner)
+		require.NoError(t, err)
+		require.NoError(t, os.WriteFile(lockPath, data, 0o644))
+	}
+	writeLock(lockOwner{PID: cmd.Process.Pid, Host: host, Started: time.Now()})
+	lock, err := acquireScanLock(dir, 0, false)
+	require.NoError(t, err)
+	assert.Equal(t, os.Getpid(), lock.owner.PID)
+	lock.release()
+
+	// A lock from another host is only stale once old.
+	writeLock(lockOwner{PID: 1, Host: "elsewhere", Started: time.Now()})
+	_, err = acquireScanLock(dir, 0, false)
+	assert.ErrorContains(t, err, "on elsewhere")
+	writeLock(lockOwner{PID: 1, Host: "elsewhere", Started: time.Now().Add(-3 * time.Hour)})
+	lock, err = acquireScanLock(dir, 0, false)
+	require.NoError(t, err)
+	lock.release()
+}
+
+func TestScanLockReleaseKeepsTakenOverLock(t *testing.T) {
+	dir := t.TempDir()
+	runCmd(t, dir, "git", "init")
+	lock, err := acquireScanLock(dir, 0, false)
+	require.NoError(t, err)
+
+	other := lockOwner{PID: os.Getpid() + 1, Host: "elsewhere", Started: time.Now()}
+	data, _ := json.Marshal(other)
+	require.NoError(t, os.WriteFile(lock.path, data, 0o644))
+	lock.release()
+	assert.FileExists(t, lock.path)
+}
+
+func TestRemoveStaleLockKeepsFreshLock(t *testing.T) {
+	path := filepath.Join(t.TempDir(), scanLockName)
+	stale := lockOwner{PID: 1, Host: "elsewhere", Started: time.Date(2026, 10, 14, 6, 0, 0, 0, time.UTC)}
+	fresh := lockOwner{PID: os.Getpid(), Host: "here", Started: time.Date(2026, 10, 14, 9, 0, 0, 0, time.UTC)}
+	write := func(owner lockOwner) {
+		data, err := json.Marshal(owner)
+		require.NoError(t, err)
+		require.NoError(t, os.WriteFile(path, data, 0o644))
+	}
+
+	// Another waiter removed the stale lock and took it since it was read.
+	write(fresh)
+	require.NoError(t, removeStaleLock(path, stale))
+	owner, err := readLockOwner(path)
+	require.NoError(t, err)
+	assert.Equal(t, fresh, owner)
+
+	write(stale)
+	require.NoError(t, removeStaleLock(path, stale))
+	assert.NoFileExists(t, path)
+	entries, err := os.ReadDir(filepath.Dir(path))
+	require.NoError(t, err)
+	assert.Empty(t, entries, "no tombstone is left behind")
+
+	// Already removed by another waiter.
+	require.NoError(t, removeStaleLock(path, stale))
+}
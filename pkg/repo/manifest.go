@@ -0,0 +1,72 @@
+// Copyright (c) Kusari <https://www.kusari.dev/>
+// SPDX-License-Identifier: MIT
+
+package repo
+
+import (
+	"archive/tar"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/kusaridev/kusari-cli/v2/api"
+)
+
+// buildManifest lists the regular files of the tar at path with their
+// sizes and SHA-256, in archive order.
+func buildManifest(path string) (*api.BundleManifest, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open package: %w", err)
+	}
+	defer func() { _ = f.Close() }()
+
+	manifest := &api.BundleManifest{Files: []api.ManifestFile{}}
+	tr := tar.NewReader(f)
+	for {
+		hdr, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			return manifest, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read package: %w", err)
+		}
+		if hdr.Typeflag != tar.TypeReg {
+			continue
+		}
+		h := sha256.New()
+		n, err := io.Copy(h, tr)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s from package: %w", hdr.Name, err)
+		}
+		manifest.Files = append(manifest.Files, api.ManifestFile{
+			Path:   hdr.Name,
+			Size:   n,
+			SHA256: hex.EncodeToString(h.Sum(nil)),
+		})
+	}
+}
+
+// writeManifest writes the manifest of the tar at path to the manifest
+// file in workingDir. It returns the manifest and the SHA-256 of the file,
+// which the user can reconcile with what the platform received.
+func writeManifest(path string) (*api.BundleManifest, string, error) {
+	manifest, err := buildManifest(path)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to build bundle manifest: %w", err)
+	}
+	data, err := json.Marshal(manifest)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to marshal bundle manifest: %w", err)
+	}
+	if err := os.WriteFile(filepath.Join(workingDir, manifestFile), data, 0600); err != nil {
+		return nil, "", fmt.Errorf("failed to write bundle manifest: %w", err)
+	}
+	sum := sha256.Sum256(data)
+	return manifest, hex.EncodeToString(sum[:]), nil
+}
@@ -0,0 +1,79 @@
+// Copyright (c) Kusari <https://www.kusari.dev/>
+// SPDX-License-Identifier: MIT
+
+package repo
+
+import (
+	"archive/tar"
+	"compress/bzip2"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/kusaridev/kusari-cli/v2/api"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPackageDirectory_Manifest(t *testing.T) {
+	repoDir := t.TempDir()
+	tempDir := t.TempDir()
+	tarballDir = tempDir
+	workingDir = filepath.Join(tempDir, workingDirName)
+	require.NoError(t, os.Mkdir(workingDir, 0700))
+	metaName = filepath.Join(workingDir, metaFile)
+	patchName = filepath.Join(workingDir, patchFile)
+
+	t.Chdir(repoDir)
+
+	runCmd(t, repoDir, "git", "init")
+	writeFile(t, filepath.Join(repoDir, "main.go"), "package main")
+	require.NoError(t, os.Mkdir(filepath.Join(repoDir, "pkg"), 0700))
+	writeFile(t, filepath.Join(repoDir, "pkg", "lib.go"), "package pkg")
+	writeFile(t, metaName, `{"test": "meta"}`)
+	writeFile(t, patchName, "patch content")
+
+	_, err := packageDirectory(ScanOptions{}, false, sourceWorkingTree)
+	require.NoError(t, err)
+
+	// Read every file of the bundle, the manifest last.
+	f, err := os.Open(filepath.Join(tarballDir, tarballName))
+	require.NoError(t, err)
+	defer func() { _ = f.Close() }()
+	tr := tar.NewReader(bzip2.NewReader(f))
+	contents := map[string][]byte{}
+	var names []string
+	for {
+		hdr, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		require.NoError(t, err)
+		if hdr.Typeflag != tar.TypeReg {
+			continue
+		}
+		data, err := io.ReadAll(tr)
+		require.NoError(t, err)
+		contents[hdr.Name] = data
+		names = append(names, hdr.Name)
+	}
+	require.NotEmpty(t, names)
+	assert.Equal(t, manifestFile, names[len(names)-1])
+
+	var manifest api.BundleManifest
+	require.NoError(t, json.Unmarshal(contents[manifestFile], &manifest))
+	var listed []string
+	for _, file := range manifest.Files {
+		listed = append(listed, file.Path)
+		sum := sha256.Sum256(contents[file.Path])
+		assert.Equal(t, hex.EncodeToString(sum[:]), file.SHA256, file.Path)
+		assert.Equal(t, int64(len(contents[file.Path])), file.Size, file.Path)
+	}
+	assert.ElementsMatch(t, names[:len(names)-1], listed, "the manifest lists every other file")
+	assert.ElementsMatch(t, []string{"main.go", "pkg/lib.go", metaFile, patchFile}, listed)
+}
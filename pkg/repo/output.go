@@ -0,0 +1,189 @@
+// Copyright (c) Kusari <https://www.kusari.dev/>
+// SPDX-License-Identifier: MIT
+
+package repo
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/charmbracelet/glamour"
+	"github.com/kusaridev/kusari-cli/v2/api"
+	"github.com/kusaridev/kusari-cli/v2/pkg/clierrors"
+	"github.com/kusaridev/kusari-cli/v2/pkg/output"
+	"github.com/kusaridev/kusari-cli/v2/pkg/plugin"
+	"github.com/kusaridev/kusari-cli/v2/pkg/results"
+	"github.com/kusaridev/kusari-cli/v2/pkg/sarif"
+)
+
+// Scan output formats.
+const (
+	OutputMarkdown = "markdown"
+	OutputSARIF    = "sarif"
+	OutputJSON     = "json"
+	// OutputDefectDojo is DefectDojo's Generic Findings Import JSON.
+	OutputDefectDojo = "defectdojo"
+	// OutputPluginPrefix prefixes the name of an output plugin, e.g.
+	// "plugin:slack" runs kusari-output-slack.
+	OutputPluginPrefix = "plugin:"
+)
+
+// Output is one destination for the results of a diff scan.
+type Output struct {
+	Format string
+	// Path is the absolute path of the file to write, or empty for stdout.
+	Path string
+}
+
+// ParseOutputs parses a comma-separated list of outputs. Each item is a
+// format written to stdout (markdown, sarif, json, defectdojo, or
+// plugin:NAME) or FORMAT-file=PATH, e.g. "sarif-file=kusari.sarif,markdown".
+// At most one output other than a plugin may go to stdout; plugins, often
+// notification sinks that print nothing, can be added to it. Relative
+// paths are made absolute against the current directory, as the scan
+// writes outputs from the scanned directory.
+func ParseOutputs(spec string) ([]Output, error) {
+	var outputs []Output
+	stdout := false
+	for _, item := range strings.Split(spec, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+
+		format, path, toFile := strings.Cut(item, "=")
+		if toFile {
+			var ok bool
+			if format, ok = strings.CutSuffix(format, "-file"); !ok || path == "" {
+				return nil, clierrors.NewValidationError("invalid output %q (files are given as FORMAT-file=PATH)", item)
+			}
+			abs, err := filepath.Abs(path)
+			if err != nil {
+				return nil, fmt.Errorf("failed to resolve output %s: %w", path, err)
+			}
+			path = abs
+		}
+		if name, ok := strings.CutPrefix(format, OutputPluginPrefix); ok {
+			if _, err := plugin.Lookup(name); err != nil {
+				return nil, err
+			}
+			outputs = append(outputs, Output{Format: format, Path: path})
+			continue
+		}
+		switch format {
+		case OutputMarkdown, OutputSARIF, OutputJSON, OutputDefectDojo:
+		default:
+			return nil, clierrors.NewValidationError("invalid output format: %s (must be 'markdown', 'sarif', 'json', 'defectdojo' or 'plugin:NAME')", format)
+		}
+		if !toFile {
+			if stdout {
+				return nil, clierrors.NewValidationError("invalid output %q: only one output can be written to stdout", spec)
+			}
+			stdout = true
+		}
+		outputs = append(outputs, Output{Format: format, Path: path})
+	}
+	if len(outputs) == 0 {
+		return nil, clierrors.NewValidationError("no output format given")
+	}
+	return outputs, nil
+}
+
+// stdoutOnly reports whether outputs is a single output to stdout, the
+// only case the scan cache can answer. A plugin may do more than print, so
+// it always runs.
+func stdoutOnly(outputs []Output) bool {
+	return len(outputs) == 1 && outputs[0].Path == "" && !strings.HasPrefix(outputs[0].Format, OutputPluginPrefix)
+}
+
+// writeOutputs writes a diff scan's results to every output. markdown is
+// the cleaned-up summary; in a terminal, the findings are laid out by file
+// instead, linking to the files under repoDir. It returns what was printed
+// to stdout, for the scan cache.
+func writeOutputs(outputs []Output, a *api.Analysis, markdown, consoleURL, repoDir string) (string, error) {
+	var printed string
+	for _, o := range outputs {
+		var content string
+		switch o.Format {
+		case OutputMarkdown:
+			content = markdown
+			if o.Path == "" {
+				terminal := a.RawLLMAnalysis != nil && output.IsTerminal(os.Stdout)
+				if terminal {
+					content = terminalMarkdown(a.RawLLMAnalysis, consoleURL)
+				}
+				content = output.Linkify(os.Stdout, renderForTerminal(content))
+				if terminal && output.Hyperlinks(os.Stdout) {
+					content = linkFindings(content, a.RawLLMAnalysis, repoDir)
+				}
+			}
+		case OutputSARIF:
+			s, err := sarif.ConvertToSARIF(a.RawLLMAnalysis, consoleURL)
+			if err != nil {
+				return "", fmt.Errorf("failed to convert to SARIF: %w", err)
+			}
+			content = s
+		case OutputJSON:
+			res := results.FromAnalysis(a)
+			res.ConsoleURL = consoleURL
+			b, err := json.MarshalIndent(res, "", "  ")
+			if err != nil {
+				return "", fmt.Errorf("failed to convert to JSON: %w", err)
+			}
+			content = string(b) + "\n"
+		case OutputDefectDojo:
+			res := results.FromAnalysis(a)
+			res.ConsoleURL = consoleURL
+			var sb strings.Builder
+			if err := results.WriteDefectDojo(&sb, res, clk.Now()); err != nil {
+				return "", fmt.Errorf("failed to convert to DefectDojo JSON: %w", err)
+			}
+			content = sb.String()
+		default:
+			// Plugins get the same JSON as the json output.
+			name := strings.TrimPrefix(o.Format, OutputPluginPrefix)
+			res := results.FromAnalysis(a)
+			res.ConsoleURL = consoleURL
+			b, err := json.Marshal(res)
+			if err != nil {
+				return "", fmt.Errorf("failed to convert to JSON: %w", err)
+			}
+			out, err := plugin.Run(name, b, "KUSARI_CONSOLE_URL="+consoleURL, "KUSARI_REPO_DIR="+repoDir)
+			if err != nil {
+				return "", err
+			}
+			content = string(out)
+		}
+
+		if o.Path == "" {
+			fmt.Print(content) // stdout
+			printed = content
+			continue
+		}
+		if err := os.WriteFile(o.Path, []byte(content), 0644); err != nil {
+			return "", fmt.Errorf("failed to write %s output: %w", o.Format, err)
+		}
+		output.Progressf(os.Stderr, "Wrote %s results to %s\n", o.Format, o.Path)
+	}
+	return printed, nil
+}
+
+// renderForTerminal renders markdown with glamour, returning it unchanged
+// if rendering fails.
+func renderForTerminal(markdown string) string {
+	r, err := glamour.NewTermRenderer(
+		output.GlamourStyle(),
+		glamour.WithWordWrap(output.WordWrap()),
+	)
+	if err != nil {
+		return markdown
+	}
+	rendered, err := r.Render(markdown)
+	if err != nil {
+		return markdown
+	}
+	return rendered
+}
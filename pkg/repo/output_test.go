@@ -0,0 +1,96 @@
+// Copyright (c) Kusari <https://www.kusari.dev/>
+// SPDX-License-Identifier: MIT
+
+package repo
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/kusaridev/kusari-cli/v2/api"
+	"github.com/kusaridev/kusari-cli/v2/pkg/results"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseOutputs(t *testing.T) {
+	wd, err := os.Getwd()
+	require.NoError(t, err)
+	outputs, err := ParseOutputs("sarif-file=kusari.sarif, markdown,json-file=/tmp/result.json")
+	require.NoError(t, err)
+	// Relative paths stay relative to where the CLI was run, not the
+	// scanned directory it changes to.
+	assert.Equal(t, []Output{
+		{Format: OutputSARIF, Path: filepath.Join(wd, "kusari.sarif")},
+		{Format: OutputMarkdown},
+		{Format: OutputJSON, Path: "/tmp/result.json"},
+	}, outputs)
+	assert.False(t, stdoutOnly(outputs))
+
+	outputs, err = ParseOutputs("sarif")
+	require.NoError(t, err)
+	assert.True(t, stdoutOnly(outputs))
+
+	outputs, err = ParseOutputs("defectdojo-file=dojo.json")
+	require.NoError(t, err)
+	assert.Equal(t, []Output{{Format: OutputDefectDojo, Path: filepath.Join(wd, "dojo.json")}}, outputs)
+
+	for _, spec := range []string{"", "xml", "sarif,markdown", "sarif-file=", "sarif=out.sarif", "xml-file=out.xml", "plugin:missing"} {
+		_, err := ParseOutputs(spec)
+		assert.Error(t, err, spec)
+	}
+}
+
+func TestOutputPlugin(t *testing.T) {
+	bin := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(bin, "kusari-output-count"), []byte("#!/bin/sh\ngrep -o '\"path\"' | wc -l | tr -d ' '\n"), 0755))
+	t.Setenv("PATH", bin+string(os.PathListSeparator)+os.Getenv("PATH"))
+
+	outputs, err := ParseOutputs("markdown,plugin:count,plugin:count-file=" + filepath.Join(bin, "count.txt"))
+	require.NoError(t, err)
+	assert.Equal(t, Output{Format: "plugin:count"}, outputs[1])
+	assert.False(t, stdoutOnly(outputs[1:2]))
+
+	a := &api.Analysis{RawLLMAnalysis: &api.SecurityAnalysis{
+		RequiredCodeMitigations: []api.CodeMitigationItem{{Path: "main.go", LineNumber: 3, Content: "Remove secret"}},
+	}}
+	_, err = writeOutputs(outputs[2:], a, "## Summary\n", "https://console.example.com/r", "")
+	require.NoError(t, err)
+	count, err := os.ReadFile(outputs[2].Path)
+	require.NoError(t, err)
+	assert.Equal(t, "1\n", string(count))
+}
+
+func TestWriteOutputs_Files(t *testing.T) {
+	dir := t.TempDir()
+	outputs := []Output{
+		{Format: OutputSARIF, Path: filepath.Join(dir, "kusari.sarif")},
+		{Format: OutputMarkdown, Path: filepath.Join(dir, "summary.md")},
+		{Format: OutputJSON, Path: filepath.Join(dir, "result.json")},
+		{Format: OutputDefectDojo, Path: filepath.Join(dir, "dojo.json")},
+	}
+	a := &api.Analysis{RawLLMAnalysis: &api.SecurityAnalysis{
+		ShouldProceed:           false,
+		RequiredCodeMitigations: []api.CodeMitigationItem{{Path: "main.go", LineNumber: 3, Content: "Remove secret"}},
+	}}
+
+	printed, err := writeOutputs(outputs, a, "## Summary\n", "https://console.example.com/r", "")
+	require.NoError(t, err)
+	assert.Empty(t, printed)
+
+	md, err := os.ReadFile(outputs[1].Path)
+	require.NoError(t, err)
+	assert.Equal(t, "## Summary\n", string(md))
+
+	for _, o := range []Output{outputs[0], outputs[2]} {
+		res, err := results.Load(o.Path)
+		require.NoError(t, err, o.Format)
+		assert.Equal(t, a.RawLLMAnalysis.RequiredCodeMitigations, res.Analysis.RequiredCodeMitigations, o.Format)
+		assert.Equal(t, "https://console.example.com/r", res.ConsoleURL, o.Format)
+	}
+
+	dojo, err := os.ReadFile(outputs[3].Path)
+	require.NoError(t, err)
+	assert.Contains(t, string(dojo), `"file_path": "main.go"`)
+}
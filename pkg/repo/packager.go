@@ -15,14 +15,36 @@ import (
 	"os"
 	"os/exec"
 	"path/filepath"
+	"strconv"
 	"strings"
 	"syscall"
 
 	"github.com/kusaridev/kusari-cli/v2/api"
+	"github.com/kusaridev/kusari-cli/v2/pkg/auth"
+	"github.com/kusaridev/kusari-cli/v2/pkg/clierrors"
+	"github.com/kusaridev/kusari-cli/v2/pkg/output"
 )
 
-// PackageDirectory creates a zip file from a directory
-func packageDirectory(full bool) (int64, error) {
+// packageSource is where the source files of a bundle come from.
+type packageSource int
+
+const (
+	// sourceWorkingTree packages the tracked and untracked files of the
+	// working tree.
+	sourceWorkingTree packageSource = iota
+	// sourceIndex packages the files staged in the index.
+	sourceIndex
+	// sourceHead packages the files committed at HEAD.
+	sourceHead
+	// sourceNone packages no source files, only the metadata and patch.
+	sourceNone
+	// sourceSnapshot packages every file of the directory, without git.
+	sourceSnapshot
+)
+
+// PackageDirectory creates a zip file from a directory, with its source
+// files taken from source and left out as opts asks.
+func packageDirectory(opts ScanOptions, full bool, source packageSource) (int64, error) {
 	if err := os.Mkdir(tarballDir, 0700); err != nil {
 		if !errors.Is(err, syscall.EEXIST) {
 			return 0, fmt.Errorf("failed to make Kusari directory: %w", err)
@@ -30,54 +52,213 @@ func packageDirectory(full bool) (int64, error) {
 	}
 	outFile := filepath.Join(tarballDir, tarballNameUncompressed)
 
+	// Write the repo contents to the tarball, uncompressed so that we can append to it
+	var skipped []api.SkippedFile
+	var err error
+	switch source {
+	case sourceIndex:
+		skipped, err = archiveIndex(opts, outFile)
+	case sourceHead:
+		skipped, err = archiveTree(opts, outFile, "HEAD")
+	case sourceNone:
+		// tar --append creates the archive
+	case sourceSnapshot:
+		skipped, err = archiveSnapshot(opts, outFile)
+	default:
+		skipped, err = archiveWorkingTree(opts, outFile)
+	}
+	if err != nil {
+		return 0, err
+	}
+	if err := noteSkipped(skipped, opts.maxFileSize()); err != nil {
+		return 0, err
+	}
+
+	// Append our Inspector files
+	names := []string{metaFile}
+	if !full {
+		names = append(names, patchFile)
+	}
+	return compressBundle(outFile, names...)
+}
+
+// compressBundle appends the files called names in workingDir to the tar
+// at outFile, and then the manifest of everything in it, then compresses
+// it to the bundle. It returns the bundle's size.
+func compressBundle(outFile string, names ...string) (int64, error) {
+	if err := appendToTar(outFile, names...); err != nil {
+		return 0, fmt.Errorf("error tarring Inspector metadata: %w", err)
+	}
+	manifest, digest, err := writeManifest(outFile)
+	if err != nil {
+		return 0, err
+	}
+	if err := appendToTar(outFile, manifestFile); err != nil {
+		return 0, fmt.Errorf("error tarring bundle manifest: %w", err)
+	}
+	output.Progressf(os.Stderr, "Bundle manifest: %d files, sha256:%s\n", len(manifest.Files), digest)
+	// Compress it
+	if err := exec.Command("bzip2", outFile).Run(); err != nil {
+		return 0, fmt.Errorf("error compressing file: %w", err)
+	}
+
+	fi, err := os.Stat(outFile + ".bz2")
+	if err != nil {
+		return 0, fmt.Errorf("error stating file: %w", err)
+	}
+
+	return fi.Size(), nil
+}
+
+// appendToTar appends the files called names in workingDir to the tar at
+// outFile, creating it if needed.
+func appendToTar(outFile string, names ...string) error {
+	args := append([]string{"-C", workingDir, "--append", "-f", outFile}, names...)
+	tc := exec.Command("tar", args...)
+	tc.Env = append(tc.Env, "COPYFILE_DISABLE=1")
+	return tc.Run()
+}
+
+// archiveWorkingTree writes a tar of the working tree to outFile. It
+// returns the files it left out.
+func archiveWorkingTree(opts ScanOptions, outFile string) ([]api.SkippedFile, error) {
 	// Get list of files from git (respects .gitignore)
 	// This includes tracked files and untracked files that aren't in .gitignore
+	filesOutput, err := exec.Command("git", opts.gitPaths("ls-files")...).Output()
+	if err != nil {
+		return nil, fmt.Errorf("error getting git files list: %w", err)
+	}
+	untracked, err := exec.Command("git", opts.gitPaths("ls-files", "--others", "--exclude-standard")...).Output()
+	if err != nil {
+		return nil, fmt.Errorf("error getting git files list: %w", err)
+	}
+	filesOutput = append(filesOutput, untracked...)
+
+	var entries []packageEntry
+	for _, path := range strings.Split(string(filesOutput), "\n") {
+		if path == "" {
+			continue
+		}
+		e := packageEntry{Path: path}
+		// tar follows symlinks, so this does too
+		if fi, err := os.Stat(path); err == nil {
+			e.Size = fi.Size()
+		}
+		if !opts.IncludeBinaries && !binaryExtension(path) {
+			e.Binary = binaryContent(path)
+		}
+		entries = append(entries, e)
+	}
+	return archiveEntries(opts, outFile, entries)
+}
+
+// archiveEntries writes a tar of the files of entries to outFile, leaving
+// out those skipFiles does. It returns the files it left out.
+func archiveEntries(opts ScanOptions, outFile string, entries []packageEntry) ([]api.SkippedFile, error) {
 	filesListPath := filepath.Join(tarballDir, "files.txt")
 	defer func() {
 		_ = os.Remove(filesListPath)
 	}()
-
-	// Get tracked files and untracked files (excluding .gitignore entries)
-	gitCmd := exec.Command("sh", "-c", "git ls-files && git ls-files --others --exclude-standard")
-	filesOutput, err := gitCmd.Output()
-	if err != nil {
-		return 0, fmt.Errorf("error getting git files list: %w", err)
-	}
+	kept, skipped := opts.skipFiles(entries)
 
 	// Write file list to a temporary file
-	if err := os.WriteFile(filesListPath, filesOutput, 0600); err != nil {
-		return 0, fmt.Errorf("error writing files list: %w", err)
+	if err := os.WriteFile(filesListPath, []byte(strings.Join(kept, "\n")), 0600); err != nil {
+		return nil, fmt.Errorf("error writing files list: %w", err)
 	}
 
-	// Write the repo contents to the tarball, uncompressed so that we can append to it
-	// Use -T to specify files from list (respects .gitignore)
+	// Use -T to specify files from list
 	tc := exec.Command("tar", "-cf", outFile, "--dereference", "-T", filesListPath)
 	tc.Env = append(tc.Env, "COPYFILE_DISABLE=1")
 	if err := tc.Run(); err != nil {
-		return 0, fmt.Errorf("error taring source code: %w", err)
+		return nil, fmt.Errorf("error taring source code: %w", err)
 	}
-	// Append our Inspector files
-	args := []string{"-C", workingDir, "--append", "-f", outFile, metaFile}
-	if !full {
-		args = append(args, patchFile)
+	return skipped, nil
+}
+
+// archiveIndex writes a tar of the files staged in the index to outFile,
+// so a staged scan sees exactly what is about to be committed. It returns
+// the files it left out.
+func archiveIndex(opts ScanOptions, outFile string) ([]api.SkippedFile, error) {
+	tree, err := exec.Command("git", "write-tree").Output()
+	if err != nil {
+		return nil, fmt.Errorf("failed to run git write-tree: %w", err)
 	}
+	return archiveTree(opts, outFile, strings.TrimSpace(string(tree)))
+}
 
-	tc2 := exec.Command("tar", args...)
-	tc2.Env = append(tc2.Env, "COPYFILE_DISABLE=1")
-	if err := tc2.Run(); err != nil {
-		return 0, fmt.Errorf("error tarring Inspector metadata: %w", err)
+// archiveTree writes a tar of the files in the git tree-ish to outFile. It
+// returns the files it left out.
+func archiveTree(opts ScanOptions, outFile, treeish string) ([]api.SkippedFile, error) {
+	files, err := exec.Command("git", opts.gitPaths("ls-tree", "-r", "-l", "-z", treeish)...).Output()
+	if err != nil {
+		return nil, fmt.Errorf("error listing %s: %w", treeish, err)
 	}
-	// Compress it
-	if err := exec.Command("bzip2", outFile).Run(); err != nil {
-		return 0, fmt.Errorf("error compressing file: %w", err)
+	// git archive fails when no file matches
+	if len(opts.IaC) > 0 && len(files) == 0 {
+		return nil, clierrors.NewValidationError("no files match the IaC paths %s", strings.Join(opts.IaC, ", "))
+	}
+	entries := parseLsTree(string(files))
+	if !opts.IncludeBinaries {
+		binary, err := binaryBlobs(opts, treeish)
+		if err != nil {
+			return nil, err
+		}
+		for i := range entries {
+			entries[i].Binary = binary[entries[i].Path]
+		}
 	}
+	_, skipped := opts.skipFiles(entries)
 
-	fi, err := os.Stat(outFile + ".bz2")
+	args := opts.gitPaths("archive", "--format=tar", "-o", outFile, treeish)
+	if len(skipped) > 0 && len(opts.IaC) == 0 {
+		args = append(args, "--")
+	}
+	for _, s := range skipped {
+		args = append(args, ":(exclude,literal)"+s.Path)
+	}
+	if err := exec.Command("git", args...).Run(); err != nil {
+		return nil, fmt.Errorf("error archiving %s: %w", treeish, err)
+	}
+	return skipped, nil
+}
+
+// binaryBlobs returns the paths of the files in the git tree-ish that git
+// takes to be binary, from their content or .gitattributes.
+func binaryBlobs(opts ScanOptions, treeish string) (map[string]bool, error) {
+	emptyTree, err := exec.Command("git", "hash-object", "-t", "tree", "--stdin").Output()
 	if err != nil {
-		return 0, fmt.Errorf("error stating file: %w", err)
+		return nil, fmt.Errorf("failed to hash the empty tree: %w", err)
 	}
+	out, err := exec.Command("git", opts.gitPaths("diff", "--numstat", "-z", "--no-renames", "--no-ext-diff", strings.TrimSpace(string(emptyTree)), treeish)...).Output()
+	if err != nil {
+		return nil, fmt.Errorf("error listing the binary files of %s: %w", treeish, err)
+	}
+	binary := map[string]bool{}
+	for _, record := range strings.Split(string(out), "\x00") {
+		if path, ok := strings.CutPrefix(record, "-\t-\t"); ok {
+			binary[path] = true
+		}
+	}
+	return binary, nil
+}
 
-	return fi.Size(), nil
+// parseLsTree parses the output of git ls-tree -r -l -z into the blobs it
+// lists. Submodules are left out, as git archive does.
+func parseLsTree(out string) []packageEntry {
+	var entries []packageEntry
+	for _, record := range strings.Split(out, "\x00") {
+		info, path, ok := strings.Cut(record, "\t")
+		fields := strings.Fields(info)
+		if !ok || len(fields) != 4 || fields[1] != "blob" {
+			continue
+		}
+		size, err := strconv.ParseInt(fields[3], 10, 64)
+		if err != nil {
+			continue
+		}
+		entries = append(entries, packageEntry{Path: path, Size: size})
+	}
+	return entries
 }
 
 // sanitizeRemoteURL strips any embedded credentials from a git remote URL so
@@ -126,15 +307,28 @@ func sanitizeRemoteURL(raw string) string {
 	return u.String()
 }
 
-func createMeta(rev string, full bool, overrideBranch string) (*api.BundleMeta, error) {
+// scannedBy returns the logged-in user recorded by `kusari auth login`, so
+// results can show who ran a local scan. Empty for machine identities.
+func scannedBy() string {
+	id, err := auth.ActiveIdentity()
+	if err != nil || id == nil || id.User == id.ClientID {
+		return ""
+	}
+	return id.User
+}
+
+// createMeta writes the bundle metadata of a scan of source (not
+// sourceNone) against opts.Rev.
+func createMeta(opts ScanOptions, full bool, source packageSource) (*api.BundleMeta, error) {
+	rev := opts.Rev
 	repoDir, err := os.Getwd()
 	if err != nil {
 		return nil, fmt.Errorf("failed to get repo directory: %w", err)
 	}
 
 	var branch []byte
-	if overrideBranch != "" {
-		branch = []byte(overrideBranch)
+	if opts.OverrideBranch != "" {
+		branch = []byte(opts.OverrideBranch)
 	} else {
 		var err error
 		branch, err = exec.Command("git", "rev-parse", "--abbrev-ref", "HEAD").Output()
@@ -169,9 +363,23 @@ func createMeta(rev string, full bool, overrideBranch string) (*api.BundleMeta,
 
 	// Get list of changed files for incremental scanning support
 	var changedFiles []string
+	var changedFileStats []api.FileStat
 	if !full && rev != "" {
+		// Staged and committed-only scans compare the index or HEAD, and
+		// leave out untracked files
+		diffArgs := func(flag string) []string {
+			switch source {
+			case sourceIndex:
+				return opts.gitPaths("diff", "--cached", flag, rev)
+			case sourceHead:
+				return opts.gitPaths("diff", flag, rev, "HEAD")
+			default:
+				return opts.gitPaths("diff", flag, rev)
+			}
+		}
+
 		// For diff scans, get the list of files that changed (tracked files)
-		diffOutput, err := exec.Command("git", "diff", "--name-only", rev).Output()
+		diffOutput, err := exec.Command("git", diffArgs("--name-only")...).Output()
 		if err == nil && len(diffOutput) > 0 {
 			files := strings.SplitSeq(strings.TrimSpace(string(diffOutput)), "\n")
 			for f := range files {
@@ -181,18 +389,34 @@ func createMeta(rev string, full bool, overrideBranch string) (*api.BundleMeta,
 			}
 		}
 
+		// Lines added and deleted per tracked file
+		numstatOutput, err := exec.Command("git", diffArgs("--numstat")...).Output()
+		if err == nil {
+			changedFileStats = parseNumstat(string(numstatOutput))
+		}
+
 		// Also include untracked files (new files not yet added to git)
-		untrackedOutput, err := exec.Command("git", "ls-files", "--others", "--exclude-standard").Output()
-		if err == nil && len(untrackedOutput) > 0 {
+		untrackedOutput, err := exec.Command("git", opts.gitPaths("ls-files", "--others", "--exclude-standard")...).Output()
+		if err == nil && len(untrackedOutput) > 0 && source == sourceWorkingTree {
 			files := strings.SplitSeq(strings.TrimSpace(string(untrackedOutput)), "\n")
 			for f := range files {
 				if f != "" {
 					changedFiles = append(changedFiles, f)
+					changedFileStats = append(changedFileStats, untrackedFileStat(f))
 				}
 			}
 		}
 	}
 
+	// Non-fatal: the author is only shown to reviewers
+	var headAuthor *api.CommitAuthor
+	authorOutput, err := exec.Command("git", "log", "-1", "--format=%an%x00%ae%x00%aI", "HEAD").Output()
+	if err == nil {
+		if parts := strings.Split(strings.TrimSpace(string(authorOutput)), "\x00"); len(parts) == 3 {
+			headAuthor = &api.CommitAuthor{Name: parts[0], Email: parts[1], Date: parts[2]}
+		}
+	}
+
 	// Compute content hashes for changed files (for incremental scanning)
 	changedFileHashes := make(map[string]string)
 	for _, file := range changedFiles {
@@ -209,35 +433,101 @@ func createMeta(rev string, full bool, overrideBranch string) (*api.BundleMeta,
 		CurrentBranch:     strings.TrimSpace(string(branch)),
 		DirName:           filepath.Base(repoDir),
 		DiffCmd:           rev,
+		Staged:            source == sourceIndex,
+		CommittedOnly:     source == sourceHead,
 		Remote:            strings.TrimSpace(string(remote)),
+		RepoFingerprint:   repoFingerprint("."),
 		GitDirty:          len(status) != 0,
 		CommitSHA:         strings.TrimSpace(string(commitSHA)),
 		ChangedFiles:      changedFiles,
 		ChangedFileHashes: changedFileHashes,
+		ChangedFileStats:  changedFileStats,
+		HeadAuthor:        headAuthor,
+		ScannedBy:         scannedBy(),
 	}
-	if full {
+	switch {
+	case full:
 		meta.ScanType = "full"
-	} else {
+		meta.Checks = opts.Checks
+	case opts.SecretsOnly:
+		meta.ScanType = "secrets"
+	case len(opts.IaC) > 0:
+		meta.ScanType = "iac"
+		meta.IaCPaths = opts.IaC
+	case opts.PatchOnly:
+		meta.ScanType = "patch"
+	default:
 		meta.ScanType = "diff"
 	}
 
+	if err := writeMeta(meta); err != nil {
+		return nil, err
+	}
+	return meta, nil
+}
+
+// writeMeta writes meta to the meta file.
+func writeMeta(meta *api.BundleMeta) error {
 	metab, err := json.Marshal(meta)
 	if err != nil {
-		return nil, fmt.Errorf("failed to marshal json meta: %w", err)
+		return fmt.Errorf("failed to marshal json meta: %w", err)
 	}
 
 	f, err := os.Create(metaName)
 	if err != nil {
-		return nil, fmt.Errorf("failed to open meta file: %w", err)
+		return fmt.Errorf("failed to open meta file: %w", err)
 	}
 	defer func() {
 		_ = f.Close()
 	}()
 	if _, err := io.Copy(f, bytes.NewReader(metab)); err != nil {
-		return nil, fmt.Errorf("failed to write meta file: %w", err)
+		return fmt.Errorf("failed to write meta file: %w", err)
 	}
+	return nil
+}
 
-	return meta, nil
+// parseNumstat parses the output of git diff --numstat. Binary files are
+// listed with "-" for both counts.
+func parseNumstat(out string) []api.FileStat {
+	var stats []api.FileStat
+	for line := range strings.SplitSeq(out, "\n") {
+		fields := strings.SplitN(line, "\t", 3)
+		if len(fields) != 3 {
+			continue
+		}
+		stat := api.FileStat{Path: fields[2]}
+		if fields[0] == "-" && fields[1] == "-" {
+			stat.Binary = true
+		} else {
+			added, err1 := strconv.Atoi(fields[0])
+			deleted, err2 := strconv.Atoi(fields[1])
+			if err1 != nil || err2 != nil {
+				continue
+			}
+			stat.Added, stat.Deleted = added, deleted
+		}
+		stats = append(stats, stat)
+	}
+	return stats
+}
+
+// untrackedFileStat counts every line of an untracked file as added, the
+// way git diff would once the file is added.
+func untrackedFileStat(path string) api.FileStat {
+	stat := api.FileStat{Path: path}
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return stat
+	}
+	if bytes.IndexByte(content, 0) >= 0 {
+		stat.Binary = true
+		return stat
+	}
+	stat.Added = bytes.Count(content, []byte("\n"))
+	if len(content) > 0 && content[len(content)-1] != '\n' {
+		stat.Added++
+	}
+	return stat
 }
 
 // computeFileHash computes SHA256 hash of a file's contents
@@ -13,6 +13,7 @@ import (
 	"strings"
 	"testing"
 
+	"github.com/kusaridev/kusari-cli/v2/api"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -66,7 +67,7 @@ func TestCreateMeta_OverrideBranch(t *testing.T) {
 			metaName = filepath.Join(workingDir, metaFile)
 			patchName = filepath.Join(workingDir, patchFile)
 
-			meta, err := createMeta("HEAD", false, tt.overrideBranch)
+			meta, err := createMeta(ScanOptions{Rev: "HEAD", OverrideBranch: tt.overrideBranch}, false, sourceWorkingTree)
 			require.NoError(t, err)
 
 			if tt.wantBranch != "" {
@@ -80,6 +81,195 @@ func TestCreateMeta_OverrideBranch(t *testing.T) {
 	}
 }
 
+func TestCreateMeta_FileStats(t *testing.T) {
+	repoDir := t.TempDir()
+	tempDir := t.TempDir()
+
+	originalDir, err := os.Getwd()
+	require.NoError(t, err)
+	defer func() {
+		_ = os.Chdir(originalDir)
+	}()
+
+	require.NoError(t, os.Chdir(repoDir))
+
+	runCmd(t, repoDir, "git", "init")
+	runCmd(t, repoDir, "git", "config", "user.email", "test@example.com")
+	runCmd(t, repoDir, "git", "config", "user.name", "Test User")
+	writeFile(t, filepath.Join(repoDir, "test.txt"), "one\ntwo\nthree\n")
+	writeFile(t, filepath.Join(repoDir, "image.bin"), "a\x00b")
+	runCmd(t, repoDir, "git", "add", ".")
+	runCmd(t, repoDir, "git", "commit", "-m", "initial commit")
+
+	writeFile(t, filepath.Join(repoDir, "test.txt"), "one\nTWO\nthree\nfour\n")
+	writeFile(t, filepath.Join(repoDir, "image.bin"), "c\x00d")
+	writeFile(t, filepath.Join(repoDir, "new.txt"), "a\nb")
+
+	tarballDir = tempDir
+	workingDir = filepath.Join(tempDir, workingDirName)
+	require.NoError(t, os.Mkdir(workingDir, 0700))
+	metaName = filepath.Join(workingDir, metaFile)
+	patchName = filepath.Join(workingDir, patchFile)
+
+	meta, err := createMeta(ScanOptions{Rev: "HEAD"}, false, sourceWorkingTree)
+	require.NoError(t, err)
+
+	assert.Equal(t, []api.FileStat{
+		{Path: "image.bin", Binary: true},
+		{Path: "test.txt", Added: 2, Deleted: 1},
+		{Path: "new.txt", Added: 2},
+	}, meta.ChangedFileStats)
+	require.NotNil(t, meta.HeadAuthor)
+	assert.Equal(t, "Test User", meta.HeadAuthor.Name)
+	assert.Equal(t, "test@example.com", meta.HeadAuthor.Email)
+	assert.NotEmpty(t, meta.HeadAuthor.Date)
+
+	full, err := createMeta(ScanOptions{}, true, sourceWorkingTree)
+	require.NoError(t, err)
+	assert.Empty(t, full.ChangedFileStats)
+	assert.NotNil(t, full.HeadAuthor)
+}
+
+func TestStagedScan(t *testing.T) {
+	repoDir := t.TempDir()
+	tempDir := t.TempDir()
+
+	originalDir, err := os.Getwd()
+	require.NoError(t, err)
+	defer func() {
+		_ = os.Chdir(originalDir)
+	}()
+	require.NoError(t, os.Chdir(repoDir))
+
+	runCmd(t, repoDir, "git", "init")
+	runCmd(t, repoDir, "git", "config", "user.email", "test@example.com")
+	runCmd(t, repoDir, "git", "config", "user.name", "Test User")
+	writeFile(t, filepath.Join(repoDir, "staged.txt"), "one\n")
+	writeFile(t, filepath.Join(repoDir, "unstaged.txt"), "one\n")
+	runCmd(t, repoDir, "git", "add", ".")
+	runCmd(t, repoDir, "git", "commit", "-m", "initial commit")
+
+	writeFile(t, filepath.Join(repoDir, "staged.txt"), "one\ntwo\n")
+	runCmd(t, repoDir, "git", "add", "staged.txt")
+	// Changed again after staging: the scan must see the staged version
+	writeFile(t, filepath.Join(repoDir, "staged.txt"), "one\ntwo\nthree\n")
+	writeFile(t, filepath.Join(repoDir, "unstaged.txt"), "one\nchanged\n")
+	writeFile(t, filepath.Join(repoDir, "untracked.txt"), "new\n")
+
+	tarballDir = tempDir
+	workingDir = filepath.Join(tempDir, workingDirName)
+	require.NoError(t, os.Mkdir(workingDir, 0700))
+	metaName = filepath.Join(workingDir, metaFile)
+	patchName = filepath.Join(workingDir, patchFile)
+
+	meta, err := createMeta(ScanOptions{Rev: "HEAD"}, false, sourceIndex)
+	require.NoError(t, err)
+	assert.True(t, meta.Staged)
+	assert.Equal(t, []string{"staged.txt"}, meta.ChangedFiles)
+	assert.Equal(t, []api.FileStat{{Path: "staged.txt", Added: 1}}, meta.ChangedFileStats)
+
+	_, err = generateDiff(ScanOptions{Rev: "HEAD"}, sourceIndex)
+	require.NoError(t, err)
+	patch, err := os.ReadFile(patchName)
+	require.NoError(t, err)
+	assert.Contains(t, string(patch), "+two")
+	assert.NotContains(t, string(patch), "three")
+	assert.NotContains(t, string(patch), "unstaged.txt")
+	assert.NotContains(t, string(patch), "untracked.txt")
+
+	_, err = packageDirectory(ScanOptions{}, false, sourceIndex)
+	require.NoError(t, err)
+	f, err := os.Open(filepath.Join(tarballDir, tarballName))
+	require.NoError(t, err)
+	defer func() { _ = f.Close() }()
+	contents := map[string]string{}
+	tr := tar.NewReader(bzip2.NewReader(f))
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		require.NoError(t, err)
+		data, err := io.ReadAll(tr)
+		require.NoError(t, err)
+		contents[hdr.Name] = string(data)
+	}
+	assert.Equal(t, "one\ntwo\n", contents["staged.txt"])
+	assert.Equal(t, "one\n", contents["unstaged.txt"])
+	assert.NotContains(t, contents, "untracked.txt")
+	assert.Contains(t, contents, metaFile)
+	assert.Contains(t, contents, patchFile)
+
+	// Nothing staged
+	runCmd(t, repoDir, "git", "reset", "-q")
+	_, err = generateDiff(ScanOptions{Rev: "HEAD"}, sourceIndex)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "no staged changes")
+}
+
+func TestCommittedOnlyScan(t *testing.T) {
+	repoDir := t.TempDir()
+	tempDir := t.TempDir()
+
+	originalDir, err := os.Getwd()
+	require.NoError(t, err)
+	defer func() {
+		_ = os.Chdir(originalDir)
+	}()
+	require.NoError(t, os.Chdir(repoDir))
+
+	runCmd(t, repoDir, "git", "init")
+	runCmd(t, repoDir, "git", "config", "user.email", "test@example.com")
+	runCmd(t, repoDir, "git", "config", "user.name", "Test User")
+	writeFile(t, filepath.Join(repoDir, "committed.txt"), "one\n")
+	writeFile(t, filepath.Join(repoDir, "dirty.txt"), "one\n")
+	runCmd(t, repoDir, "git", "add", ".")
+	runCmd(t, repoDir, "git", "commit", "-m", "initial commit")
+	writeFile(t, filepath.Join(repoDir, "committed.txt"), "one\ntwo\n")
+	runCmd(t, repoDir, "git", "commit", "-am", "second commit")
+
+	writeFile(t, filepath.Join(repoDir, "dirty.txt"), "uncommitted\n")
+	writeFile(t, filepath.Join(repoDir, "untracked.txt"), "new\n")
+
+	tarballDir = tempDir
+	workingDir = filepath.Join(tempDir, workingDirName)
+	require.NoError(t, os.Mkdir(workingDir, 0700))
+	metaName = filepath.Join(workingDir, metaFile)
+	patchName = filepath.Join(workingDir, patchFile)
+
+	meta, err := createMeta(ScanOptions{Rev: "HEAD~1"}, false, sourceHead)
+	require.NoError(t, err)
+	assert.True(t, meta.CommittedOnly)
+	assert.True(t, meta.GitDirty)
+	assert.Equal(t, []string{"committed.txt"}, meta.ChangedFiles)
+
+	_, err = generateDiff(ScanOptions{Rev: "HEAD~1"}, sourceHead)
+	require.NoError(t, err)
+	patch, err := os.ReadFile(patchName)
+	require.NoError(t, err)
+	assert.Contains(t, string(patch), "+two")
+	assert.NotContains(t, string(patch), "dirty.txt")
+	assert.NotContains(t, string(patch), "untracked.txt")
+
+	_, err = packageDirectory(ScanOptions{}, false, sourceHead)
+	require.NoError(t, err)
+	files := extractTarballContents(t, filepath.Join(tarballDir, tarballName))
+	assert.ElementsMatch(t, []string{"committed.txt", "dirty.txt", metaFile, patchFile, manifestFile}, files)
+
+	_, err = generateDiff(ScanOptions{Rev: "HEAD"}, sourceHead)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "no committed changes")
+}
+
+func TestParseNumstat(t *testing.T) {
+	out := "3\t1\tmain.go\n-\t-\tlogo.png\n0\t4\tdir/with\ttab.txt\n\n"
+	assert.Equal(t, []api.FileStat{
+		{Path: "main.go", Added: 3, Deleted: 1},
+		{Path: "logo.png", Binary: true},
+		{Path: "dir/with\ttab.txt", Deleted: 4},
+	}, parseNumstat(out))
+}
+
 func TestSanitizeRemoteURL(t *testing.T) {
 	tests := []struct {
 		name string
@@ -349,7 +539,7 @@ func TestPackageDirectory(t *testing.T) {
 			}
 
 			// Execute packageDirectory
-			size, err := packageDirectory(tt.full)
+			size, err := packageDirectory(ScanOptions{}, tt.full, sourceWorkingTree)
 
 			// Check error expectations
 			if tt.expectError {
@@ -430,7 +620,7 @@ func TestPackageDirectory_NonGitRepo(t *testing.T) {
 	writeFile(t, filepath.Join(repoDir, "test.txt"), "content")
 
 	// Try to package - should fail because it's not a git repo
-	_, err = packageDirectory(false)
+	_, err = packageDirectory(ScanOptions{}, false, sourceWorkingTree)
 	if err == nil {
 		t.Error("Expected error when packaging non-git directory, got nil")
 	}
@@ -0,0 +1,51 @@
+// Copyright (c) Kusari <https://www.kusari.dev/>
+// SPDX-License-Identifier: MIT
+
+package repo
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/kusaridev/kusari-cli/v2/api"
+	apiconfig "github.com/kusaridev/kusari-cli/v2/api/configuration"
+	"github.com/kusaridev/kusari-cli/v2/pkg/comment"
+	"github.com/kusaridev/kusari-cli/v2/pkg/github"
+	"github.com/kusaridev/kusari-cli/v2/pkg/gitlab"
+	"github.com/kusaridev/kusari-cli/v2/pkg/transport"
+)
+
+// previewComment writes the comments posting analysis to platform would
+// write, as configured in cfg, to path: "-" for stdout, else an absolute
+// file path.
+func previewComment(path, platform string, analysis *api.SecurityAnalysis, consoleURL *string, cfg apiconfig.Config) error {
+	opts := comment.PreviewOptions{
+		Compact:  cfg.CommentStyle == apiconfig.CommentStyleCompact,
+		Reaction: cfg.CommentStyle == apiconfig.CommentStyleReaction,
+	}
+	switch platform {
+	case PlatformGitLab:
+		opts.MaxLength = comment.GitLabMaxLength
+		opts.Run = comment.Run{Key: gitlab.GetRunKeyFromEnv(), ID: transport.RequestID()}
+	case PlatformGitHub:
+		opts.MaxLength = comment.GitHubMaxLength
+		opts.Run = comment.Run{Key: github.GetRunKeyFromEnv(), ID: transport.RequestID()}
+	default:
+		return fmt.Errorf("unsupported comment platform: %s (supported: %s, %s)", platform, PlatformGitLab, PlatformGitHub)
+	}
+	consoleURLStr := ""
+	if consoleURL != nil {
+		consoleURLStr = *consoleURL
+	}
+	preview := comment.FormatPreview(analysis, consoleURLStr, opts)
+
+	if path == "-" {
+		_, err := fmt.Fprint(os.Stdout, preview)
+		return err
+	}
+	if err := os.WriteFile(path, []byte(preview), 0644); err != nil {
+		return fmt.Errorf("failed to write comment preview: %w", err)
+	}
+	fmt.Fprintf(os.Stderr, "Wrote %s comment preview to %s\n", platform, path)
+	return nil
+}
@@ -0,0 +1,45 @@
+// Copyright (c) Kusari <https://www.kusari.dev/>
+// SPDX-License-Identifier: MIT
+
+package repo
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/kusaridev/kusari-cli/v2/api"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPostCommentToPlatformDryRun(t *testing.T) {
+	// Would post, if the forge were asked
+	t.Setenv("GITHUB_REPOSITORY", "owner/repo")
+	t.Setenv("GITHUB_TOKEN", "token")
+	t.Setenv("GITHUB_API_URL", "http://127.0.0.1:1")
+
+	// Relative to where the CLI runs, not the scanned directory.
+	dir := t.TempDir()
+	t.Chdir(dir)
+	opts := ScanOptions{CommentPlatform: PlatformGitHub, CommentDryRun: "comments.md"}
+	require.NoError(t, opts.resolvePaths())
+	path := filepath.Join(dir, "comments.md")
+	assert.Equal(t, path, opts.CommentDryRun)
+	t.Chdir(t.TempDir())
+
+	analysis := &api.SecurityAnalysis{
+		ShouldProceed:           false,
+		RequiredCodeMitigations: []api.CodeMitigationItem{{Content: "issue", Path: "a.go", LineNumber: 3}},
+	}
+	consoleURL := "https://console.example.com/result"
+	require.NoError(t, postCommentToPlatform(opts, analysis, &consoleURL, t.TempDir()))
+
+	preview, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Contains(t, string(preview), "===== Summary comment =====")
+	assert.Contains(t, string(preview), "<!-- KUSARI_INLINE:a.go:3 -->")
+
+	opts.CommentPlatform = "bitbucket"
+	assert.ErrorContains(t, postCommentToPlatform(opts, analysis, &consoleURL, t.TempDir()), "unsupported comment platform")
+}
@@ -0,0 +1,50 @@
+// Copyright (c) Kusari <https://www.kusari.dev/>
+// SPDX-License-Identifier: MIT
+
+package repo
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/kusaridev/kusari-cli/v2/api"
+	"github.com/kusaridev/kusari-cli/v2/pkg/results"
+)
+
+// saveResult keeps a copy of a fetched analysis under ~/.kusari/results so
+// `kusari results show --last` can render it again offline. Failing to
+// save never fails the scan.
+func saveResult(a *api.Analysis, markdown, consoleURL, repoDir, baseRef string, full, verbose bool) string {
+	res := results.FromAnalysis(a)
+	res.Markdown = markdown
+	res.ConsoleURL = consoleURL
+	res.Repo = repoDir
+	if repoDir != "" {
+		res.RepoFingerprint = repoFingerprint(repoDir)
+	}
+	res.BaseRef = baseRef
+	res.Kind = "scan"
+	if full {
+		res.Kind = "risk-check"
+	}
+
+	path, err := results.Save(res)
+	if err != nil {
+		if verbose {
+			fmt.Fprintf(os.Stderr, "Warning: Failed to save results: %v\n", err)
+		}
+		return ""
+	}
+	if verbose {
+		fmt.Fprintf(os.Stderr, "Saved results to %s\n", path)
+	}
+	return path
+}
+
+// warnNewerSchema warns when the platform's analysis is in a newer format
+// than this version of the CLI knows, as parts of it won't be shown.
+func warnNewerSchema(a *api.Analysis) {
+	if warning := results.FromAnalysis(a).SchemaWarning(); warning != "" {
+		fmt.Fprintf(os.Stderr, "Warning: %s\n", warning)
+	}
+}
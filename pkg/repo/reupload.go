@@ -0,0 +1,321 @@
+// Copyright (c) Kusari <https://www.kusari.dev/>
+// SPDX-License-Identifier: MIT
+
+package repo
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net/http"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/kusaridev/kusari-cli/v2/pkg/auth"
+	"github.com/kusaridev/kusari-cli/v2/pkg/clierrors"
+	"github.com/kusaridev/kusari-cli/v2/pkg/output"
+	"github.com/kusaridev/kusari-cli/v2/pkg/readonly"
+	"github.com/kusaridev/kusari-cli/v2/pkg/sbom"
+)
+
+// DefaultReuploadCheckpoint is the checkpoint file name used when
+// ReuploadOptions.Checkpoint is empty, kept in the archive directory.
+const DefaultReuploadCheckpoint = ".kusari-reupload.json"
+
+// ReuploadOptions configures a Reupload.
+type ReuploadOptions struct {
+	// Dir is the archive of SBOMs to re-ingest.
+	Dir string
+	// Since skips SBOMs created before it; zero re-ingests all.
+	Since time.Time
+	// TenantEndpoint is the tenant API base URL.
+	TenantEndpoint string
+	// BatchSize is the number of SBOMs uploaded between checkpoint saves.
+	BatchSize int
+	// Rate caps the uploads per second; 0 is unlimited.
+	Rate float64
+	// Checkpoint is the file recording the SBOMs already uploaded, so an
+	// interrupted run resumes where it stopped. Defaults to
+	// DefaultReuploadCheckpoint in Dir.
+	Checkpoint string
+	// DryRun lists the SBOMs that would be uploaded.
+	DryRun bool
+}
+
+// reuploadCheckpoint is the layout of the checkpoint file.
+type reuploadCheckpoint struct {
+	// Uploaded maps the path of each SBOM uploaded, relative to the
+	// archive, to its document ref, so an SBOM changed since is uploaded
+	// again.
+	Uploaded map[string]string `json:"uploaded"`
+}
+
+// archivedSBOM is an SBOM of the archive to re-ingest.
+type archivedSBOM struct {
+	path, rel string
+	docRef    string
+	created   time.Time
+}
+
+// sbomTimestamps holds the creation time fields of CycloneDX and SPDX.
+type sbomTimestamps struct {
+	Metadata struct {
+		Timestamp string `json:"timestamp"`
+	} `json:"metadata"`
+	CreationInfo struct {
+		Created string `json:"created"`
+	} `json:"creationInfo"`
+}
+
+// Reupload re-ingests the archive of SBOMs in opts.Dir, oldest first. Each
+// upload carries the SBOM's original creation time in its metadata as
+// original_timestamp. Progress is saved to the checkpoint after every
+// batch and when the run stops, so running it again skips what was
+// uploaded.
+func Reupload(ctx context.Context, opts ReuploadOptions) error {
+	if opts.TenantEndpoint == "" && !opts.DryRun {
+		return clierrors.NewValidationError("tenant configuration missing. Please provide --tenant flag (e.g., --tenant demo), or run 'kusari auth login'")
+	}
+	var accessToken string
+	if !opts.DryRun {
+		if err := readonly.Check("reupload to the Kusari platform"); err != nil {
+			return err
+		}
+		token, err := auth.DefaultTokenProvider().Token(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to load auth token: %w (try running 'kusari auth login' or setting %s)", err, auth.APIKeyEnv)
+		}
+		accessToken = token.AccessToken
+	}
+	return reupload(ctx, newHTTPClient(30*time.Second), accessToken, opts)
+}
+
+func reupload(ctx context.Context, client *http.Client, accessToken string, opts ReuploadOptions) error {
+	if opts.BatchSize <= 0 {
+		return clierrors.NewValidationError("--batch-size must be positive")
+	}
+	if opts.Rate < 0 {
+		return clierrors.NewValidationError("--rate can't be negative")
+	}
+	checkpointPath := opts.Checkpoint
+	if checkpointPath == "" {
+		checkpointPath = filepath.Join(opts.Dir, DefaultReuploadCheckpoint)
+	}
+	checkpoint, err := loadReuploadCheckpoint(checkpointPath)
+	if err != nil {
+		return err
+	}
+
+	sboms, err := findArchivedSBOMs(opts.Dir, checkpointPath, opts.Since)
+	if err != nil {
+		return err
+	}
+	var pending []archivedSBOM
+	for _, s := range sboms {
+		if checkpoint.Uploaded[s.rel] != s.docRef {
+			pending = append(pending, s)
+		}
+	}
+	output.Progressf(os.Stderr, "%d SBOM(s) to upload, %d already uploaded\n", len(pending), len(sboms)-len(pending))
+
+	if opts.DryRun {
+		for _, s := range pending {
+			fmt.Printf("%s\t%s\n", s.created.UTC().Format(time.RFC3339), s.rel)
+		}
+		return nil
+	}
+
+	var interval time.Duration
+	if opts.Rate > 0 {
+		interval = time.Duration(float64(time.Second) / opts.Rate)
+	}
+	uploaded := 0
+	for i, s := range pending {
+		if err := ctx.Err(); err != nil {
+			if saveErr := checkpoint.save(checkpointPath); saveErr != nil {
+				return saveErr
+			}
+			return fmt.Errorf("re-upload interrupted after %d of %d SBOM(s); run it again to resume: %w", uploaded, len(pending), err)
+		}
+		if i > 0 && interval > 0 {
+			clk.Sleep(interval)
+		}
+
+		output.Progressf(os.Stdout, "  Uploading (%d/%d): %s\n", i+1, len(pending), s.rel)
+		if err := reuploadSBOM(client, accessToken, opts.TenantEndpoint, s); err != nil {
+			if saveErr := checkpoint.save(checkpointPath); saveErr != nil {
+				return saveErr
+			}
+			return fmt.Errorf("re-upload stopped after %d of %d SBOM(s); run it again to resume: %w", uploaded, len(pending), err)
+		}
+		checkpoint.Uploaded[s.rel] = s.docRef
+		uploaded++
+		if uploaded%opts.BatchSize == 0 {
+			if err := checkpoint.save(checkpointPath); err != nil {
+				return err
+			}
+		}
+	}
+	if err := checkpoint.save(checkpointPath); err != nil {
+		return err
+	}
+	output.Progressf(os.Stderr, "Re-uploaded %d SBOM(s); checkpoint saved to %s\n", uploaded, checkpointPath)
+	return nil
+}
+
+// reuploadSBOM uploads one archived SBOM with its original creation time.
+func reuploadSBOM(client *http.Client, accessToken, tenantEndpoint string, s archivedSBOM) error {
+	blob, err := os.ReadFile(s.path)
+	if err != nil {
+		return fmt.Errorf("error reading file: %s, err: %w", s.path, err)
+	}
+	payloadBytes, err := json.Marshal(map[string]string{"filename": s.docRef})
+	if err != nil {
+		return fmt.Errorf("error creating JSON payload: %w", err)
+	}
+	presignedUrl, err := getPresignedUrlForUpload(client, accessToken, tenantEndpoint, payloadBytes)
+	if err != nil {
+		return err
+	}
+	meta := map[string]string{
+		"original_timestamp": s.created.UTC().Format(time.RFC3339),
+		"reupload":           "true",
+	}
+	if _, err := uploadBlob(client, presignedUrl, s.path, blob, false, meta); err != nil {
+		return fmt.Errorf("failed to upload %s: %w", s.rel, err)
+	}
+	return nil
+}
+
+// maxArchivedSBOMSize is the size above which a file in the archive is
+// not re-uploaded: each is read whole to be parsed.
+var maxArchivedSBOMSize int64 = 256 << 20
+
+// findArchivedSBOMs returns the SPDX and CycloneDX documents under dir,
+// created at or after since, oldest first. Hidden files and directories,
+// such as .git and the checkpoint, are left out, as are files larger than
+// maxArchivedSBOMSize and files that aren't SBOMs.
+func findArchivedSBOMs(dir, checkpointPath string, since time.Time) ([]archivedSBOM, error) {
+	absCheckpoint, _ := filepath.Abs(checkpointPath)
+	var sboms []archivedSBOM
+	var large, other []string
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if path != dir && strings.HasPrefix(d.Name(), ".") {
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if d.IsDir() {
+			return nil
+		}
+		if abs, _ := filepath.Abs(path); abs == absCheckpoint || abs == absCheckpoint+".tmp" {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+		if !info.Mode().IsRegular() || info.Size() == 0 {
+			return nil
+		}
+		if info.Size() > maxArchivedSBOMSize {
+			large = append(large, rel)
+			return nil
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		if _, err := sbom.Parse(data); err != nil {
+			other = append(other, rel)
+			return nil
+		}
+		created := sbomCreated(data, info.ModTime())
+		if created.Before(since) {
+			return nil
+		}
+		sboms = append(sboms, archivedSBOM{path: path, rel: rel, docRef: getDocRef(data), created: created})
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read SBOM archive: %w", err)
+	}
+	if len(large) > 0 {
+		output.Progressf(os.Stderr, "Skipped %d file(s) larger than %s: %s\n", len(large), output.FormatSize(maxArchivedSBOMSize), strings.Join(large, ", "))
+	}
+	if len(other) > 0 {
+		output.Progressf(os.Stderr, "Skipped %d file(s) that aren't SPDX or CycloneDX JSON: %s\n", len(other), strings.Join(other, ", "))
+	}
+	slices.SortStableFunc(sboms, func(a, b archivedSBOM) int {
+		if c := a.created.Compare(b.created); c != 0 {
+			return c
+		}
+		return strings.Compare(a.rel, b.rel)
+	})
+	return sboms, nil
+}
+
+// sbomCreated returns the creation time recorded in the SBOM data, or
+// modTime when it has none.
+func sbomCreated(data []byte, modTime time.Time) time.Time {
+	var ts sbomTimestamps
+	if json.Unmarshal(data, &ts) == nil {
+		for _, s := range []string{ts.Metadata.Timestamp, ts.CreationInfo.Created} {
+			if t, err := time.Parse(time.RFC3339, s); err == nil {
+				return t
+			}
+		}
+	}
+	return modTime
+}
+
+// loadReuploadCheckpoint reads the checkpoint at path; a missing one is
+// empty.
+func loadReuploadCheckpoint(path string) (*reuploadCheckpoint, error) {
+	c := &reuploadCheckpoint{Uploaded: map[string]string{}}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return c, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read checkpoint: %w", err)
+	}
+	if err := json.Unmarshal(data, c); err != nil {
+		return nil, clierrors.NewValidationError("invalid checkpoint file %s: %v (delete it to start over)", path, err)
+	}
+	if c.Uploaded == nil {
+		c.Uploaded = map[string]string{}
+	}
+	return c, nil
+}
+
+// save writes c to path through a temporary file, so an interrupted write
+// leaves the previous checkpoint.
+func (c *reuploadCheckpoint) save(path string) error {
+	data, err := json.MarshalIndent(c, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal checkpoint: %w", err)
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, append(data, '\n'), 0o644); err != nil {
+		return fmt.Errorf("failed to write checkpoint: %w", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		return fmt.Errorf("failed to write checkpoint: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,154 @@
+// Copyright (c) Kusari <https://www.kusari.dev/>
+// SPDX-License-Identifier: MIT
+
+package repo
+
+import (
+	"context"
+	"encoding/json"
+	"maps"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/kusaridev/kusari-cli/v2/pkg/clock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// reuploadServer fakes the presign and S3 endpoints, failing the upload
+// of documents whose body contains fail, and records the metadata of each
+// upload.
+func reuploadServer(t *testing.T, fail *atomic.Value, metas *[]map[string]string) *httptest.Server {
+	t.Helper()
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasSuffix(r.URL.Path, "/presign") {
+			_ = json.NewEncoder(w).Encode(map[string]string{"presignedUrl": server.URL + "/s3"})
+			return
+		}
+		var wrapper struct {
+			Blob       []byte
+			UploadMeta map[string]string `json:"upload_metadata"`
+		}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&wrapper))
+		if f, _ := fail.Load().(string); f != "" && strings.Contains(string(wrapper.Blob), f) {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		*metas = append(*metas, wrapper.UploadMeta)
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestReuploadResumes(t *testing.T) {
+	fake := clock.NewFake(time.Date(2026, 10, 14, 0, 0, 0, 0, time.UTC))
+	SetClock(fake)
+	t.Cleanup(func() { SetClock(nil) })
+
+	dir := t.TempDir()
+	write := func(name, content string) {
+		require.NoError(t, os.MkdirAll(filepath.Dir(filepath.Join(dir, name)), 0o755))
+		require.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644))
+	}
+	write("old.json", `{"bomFormat":"CycloneDX","metadata":{"timestamp":"2023-06-01T00:00:00Z"}}`)
+	write("b/spdx.json", `{"spdxVersion":"SPDX-2.3","SPDXID":"SPDXRef-DOCUMENT","creationInfo":{"created":"2024-03-01T10:00:00Z"}}`)
+	write("a/cdx.json", `{"bomFormat":"CycloneDX","metadata":{"timestamp":"2024-02-01T00:00:00Z"}}`)
+	write("empty.json", ``)
+
+	var fail atomic.Value
+	fail.Store("SPDX-2.3")
+	var metas []map[string]string
+	server := reuploadServer(t, &fail, &metas)
+
+	opts := ReuploadOptions{
+		Dir:            dir,
+		Since:          time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+		TenantEndpoint: server.URL,
+		BatchSize:      1,
+		Rate:           2,
+	}
+	err := reupload(context.Background(), &http.Client{}, "token", opts)
+	require.ErrorContains(t, err, "re-upload stopped after 1 of 2 SBOM(s)")
+	require.Len(t, metas, 1)
+	assert.Equal(t, "2024-02-01T00:00:00Z", metas[0]["original_timestamp"])
+	assert.Equal(t, "true", metas[0]["reupload"])
+	assert.Equal(t, 500*time.Millisecond, fake.Now().Sub(time.Date(2026, 10, 14, 0, 0, 0, 0, time.UTC)))
+
+	checkpoint, err := loadReuploadCheckpoint(filepath.Join(dir, DefaultReuploadCheckpoint))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"a/cdx.json"}, slices.Collect(maps.Keys(checkpoint.Uploaded)))
+
+	fail.Store("")
+	metas = nil
+	require.NoError(t, reupload(context.Background(), &http.Client{}, "token", opts))
+	require.Len(t, metas, 1, "only the SBOM that failed is uploaded again")
+	assert.Equal(t, "2024-03-01T10:00:00Z", metas[0]["original_timestamp"])
+
+	metas = nil
+	require.NoError(t, reupload(context.Background(), &http.Client{}, "token", opts))
+	assert.Empty(t, metas)
+
+	// A changed SBOM is uploaded again.
+	write("a/cdx.json", `{"bomFormat":"CycloneDX","metadata":{"timestamp":"2024-02-01T00:00:00Z"},"version":2}`)
+	require.NoError(t, reupload(context.Background(), &http.Client{}, "token", opts))
+	assert.Len(t, metas, 1)
+}
+
+func TestReuploadInterrupted(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "a.json"), []byte(`{"bomFormat":"CycloneDX"}`), 0o644))
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	err := reupload(ctx, &http.Client{}, "token", ReuploadOptions{Dir: dir, TenantEndpoint: "http://127.0.0.1:0", BatchSize: 10})
+	assert.ErrorContains(t, err, "interrupted after 0 of 1")
+	assert.FileExists(t, filepath.Join(dir, DefaultReuploadCheckpoint))
+}
+
+func TestFindArchivedSBOMsModTime(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "plain.json")
+	require.NoError(t, os.WriteFile(path, []byte(`{"bomFormat":"CycloneDX"}`), 0o644))
+	mod := time.Date(2023, 5, 1, 0, 0, 0, 0, time.UTC)
+	require.NoError(t, os.Chtimes(path, mod, mod))
+
+	sboms, err := findArchivedSBOMs(dir, filepath.Join(dir, DefaultReuploadCheckpoint), time.Time{})
+	require.NoError(t, err)
+	require.Len(t, sboms, 1)
+	assert.True(t, sboms[0].created.Equal(mod))
+
+	sboms, err = findArchivedSBOMs(dir, filepath.Join(dir, DefaultReuploadCheckpoint), mod.Add(time.Hour))
+	require.NoError(t, err)
+	assert.Empty(t, sboms)
+}
+
+func TestFindArchivedSBOMsSkips(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, content string) {
+		require.NoError(t, os.MkdirAll(filepath.Dir(filepath.Join(dir, name)), 0o755))
+		require.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644))
+	}
+	cdx := `{"bomFormat":"CycloneDX","metadata":{"timestamp":"2024-02-01T00:00:00Z"}}`
+	write("sbom.json", cdx)
+	write("README.md", "# SBOM archive\n")
+	write("notes.json", `{"not":"an sbom"}`)
+	write(".hidden.json", cdx)
+	write(".git/objects/sbom.json", cdx)
+	write("large.json", `{"bomFormat":"CycloneDX","metadata":{"timestamp":"2024-02-01T00:00:00Z"},"components":[]}`)
+
+	orig := maxArchivedSBOMSize
+	maxArchivedSBOMSize = int64(len(cdx))
+	t.Cleanup(func() { maxArchivedSBOMSize = orig })
+	sboms, err := findArchivedSBOMs(dir, filepath.Join(dir, DefaultReuploadCheckpoint), time.Time{})
+	require.NoError(t, err)
+	require.Len(t, sboms, 1)
+	assert.Equal(t, "sbom.json", sboms[0].rel)
+}
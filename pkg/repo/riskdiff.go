@@ -0,0 +1,166 @@
+// Copyright (c) Kusari <https://www.kusari.dev/>
+// SPDX-License-Identifier: MIT
+
+package repo
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"maps"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/kusaridev/kusari-cli/v2/api"
+)
+
+// previousFullScan returns the most recent completed risk check of the same
+// repository and branch before the one with sortKey, or nil if there is
+// none. sortKey is URL-encoded, as built by CreateSortString.
+func previousFullScan(platformUrl, accessToken, workspace, sortKey string) (*api.UserInspectorResult, error) {
+	current, err := url.QueryUnescape(sortKey)
+	if err != nil {
+		return nil, fmt.Errorf("invalid sort key: %w", err)
+	}
+	// prefix|remoteHash|dirName|branch|userID|epoch: drop the epoch to
+	// match every scan of this repository and branch.
+	i := strings.LastIndex(current, "|")
+	if i < 0 {
+		return nil, fmt.Errorf("invalid sort key %q", current)
+	}
+	prefix := current[:i+1]
+
+	fullURL := fmt.Sprintf("%s/inspector/result/user?sortKey=%s&op=beginswith&scanType=risk-check",
+		strings.TrimSuffix(platformUrl, "/"), url.QueryEscape(prefix))
+	req, err := http.NewRequest("GET", fullURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+accessToken)
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("X-Kusari-Workspace", workspace)
+
+	client := newHTTPClient(10 * time.Second)
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("request failed: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("results API returned status %d: %s", resp.StatusCode, string(body))
+	}
+	var results []api.UserInspectorResult
+	if err := json.NewDecoder(resp.Body).Decode(&results); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	var previous *api.UserInspectorResult
+	for k := range results {
+		r := &results[k]
+		// Sort keys end in the upload epoch, so they order by time.
+		if r.Analysis == nil || r.Sort >= current {
+			continue
+		}
+		if previous == nil || r.Sort > previous.Sort {
+			previous = r
+		}
+	}
+	return previous, nil
+}
+
+// riskCheckDiffMarkdown renders what changed between the previous and
+// current risk check: the overall and per-category score deltas, and the
+// checks that started or stopped failing.
+func riskCheckDiffMarkdown(previous, current *api.Analysis, since string) string {
+	sb := new(strings.Builder)
+	if since != "" {
+		fmt.Fprintf(sb, "## Changes Since Last Full Scan (%s)\n", since)
+	} else {
+		fmt.Fprintf(sb, "## Changes Since Last Full Scan\n")
+	}
+	fmt.Fprintf(sb, "Overall score: %d/5 → %d/5 (%s)\n\n", previous.Score, current.Score, delta(current.Score-previous.Score))
+
+	categories := slices.Collect(maps.Keys(current.Health))
+	for key := range previous.Health {
+		if _, ok := current.Health[key]; !ok {
+			categories = append(categories, key)
+		}
+	}
+	slices.Sort(categories)
+
+	var changed, newFailures, resolved []string
+	for _, key := range categories {
+		before, hadBefore := previous.Health[key]
+		after, hasAfter := current.Health[key]
+		switch {
+		case !hadBefore:
+			changed = append(changed, fmt.Sprintf("| %s | - | %d/5 | new |", titleize(key), after.Score))
+		case !hasAfter:
+			changed = append(changed, fmt.Sprintf("| %s | %d/5 | - | removed |", titleize(key), before.Score))
+		case before.Score != after.Score:
+			changed = append(changed, fmt.Sprintf("| %s | %d/5 | %d/5 | %s |", titleize(key), before.Score, after.Score, delta(after.Score-before.Score)))
+		}
+
+		passedBefore := map[string]bool{}
+		for _, c := range before.Checks {
+			passedBefore[c.Name] = c.Pass
+		}
+		for _, c := range after.Checks {
+			pass, seen := passedBefore[c.Name]
+			switch {
+			case !c.Pass && (!seen || pass):
+				newFailures = append(newFailures, fmt.Sprintf("- %s: %s", titleize(key), c.Name))
+			case c.Pass && seen && !pass:
+				resolved = append(resolved, fmt.Sprintf("- %s: %s", titleize(key), c.Name))
+			}
+		}
+	}
+
+	if len(changed) > 0 {
+		fmt.Fprintln(sb, "| Category | Before | After | Change |")
+		fmt.Fprintln(sb, "|---|---|---|---|")
+		for _, row := range changed {
+			fmt.Fprintln(sb, row)
+		}
+		fmt.Fprintln(sb)
+	}
+	if len(newFailures) > 0 {
+		fmt.Fprintln(sb, "### New Failing Checks")
+		fmt.Fprintln(sb, strings.Join(newFailures, "\n"))
+		fmt.Fprintln(sb)
+	}
+	if len(resolved) > 0 {
+		fmt.Fprintln(sb, "### Resolved Checks")
+		fmt.Fprintln(sb, strings.Join(resolved, "\n"))
+		fmt.Fprintln(sb)
+	}
+	if len(changed) == 0 && len(newFailures) == 0 && len(resolved) == 0 {
+		fmt.Fprintln(sb, "No category scores or checks changed.")
+	}
+	return sb.String()
+}
+
+// scanTime formats an RFC 3339 timestamp for display, or returns it as is.
+func scanTime(ts string) string {
+	t, err := time.Parse(time.RFC3339, ts)
+	if err != nil {
+		return ts
+	}
+	return t.Local().Format(time.DateTime)
+}
+
+func delta(d int) string {
+	switch {
+	case d > 0:
+		return fmt.Sprintf("+%d", d)
+	case d < 0:
+		return fmt.Sprintf("%d", d)
+	default:
+		return "no change"
+	}
+}
@@ -0,0 +1,88 @@
+// Copyright (c) Kusari <https://www.kusari.dev/>
+// SPDX-License-Identifier: MIT
+
+package repo
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/kusaridev/kusari-cli/v2/api"
+	urlBuilder "github.com/kusaridev/kusari-cli/v2/pkg/url"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPreviousFullScan(t *testing.T) {
+	sortKey := urlBuilder.CreateSortString("user-1", "300", true, false, "https://github.com/acme/app", "app", "main")
+	prefix := "cli-user-full|" + hashOf(t, sortKey) + "|app|main|user-1|"
+
+	var gotKey string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotKey = r.URL.Query().Get("sortKey")
+		assert.Equal(t, "beginswith", r.URL.Query().Get("op"))
+		assert.Equal(t, "risk-check", r.URL.Query().Get("scanType"))
+		assert.Equal(t, "ws", r.Header.Get("X-Kusari-Workspace"))
+		_ = json.NewEncoder(w).Encode([]api.UserInspectorResult{
+			{Sort: prefix + "100", Analysis: &api.Analysis{Score: 2}},
+			{Sort: prefix + "300", Analysis: &api.Analysis{Score: 4}},
+			{Sort: prefix + "250"},
+			{Sort: prefix + "200", Analysis: &api.Analysis{Score: 3}},
+		})
+	}))
+	defer server.Close()
+
+	previous, err := previousFullScan(server.URL, "token", "ws", sortKey)
+	require.NoError(t, err)
+	assert.Equal(t, prefix, gotKey)
+	require.NotNil(t, previous)
+	assert.Equal(t, prefix+"200", previous.Sort, "the latest completed scan before this one")
+
+	first := urlBuilder.CreateSortString("user-1", "050", true, false, "https://github.com/acme/app", "app", "main")
+	previous, err = previousFullScan(server.URL, "token", "ws", first)
+	require.NoError(t, err)
+	assert.Nil(t, previous)
+}
+
+// hashOf returns the remote hash segment of a sort key.
+func hashOf(t *testing.T, sortKey string) string {
+	t.Helper()
+	unescaped, err := url.QueryUnescape(sortKey)
+	require.NoError(t, err)
+	return strings.Split(unescaped, "|")[1]
+}
+
+func TestRiskCheckDiffMarkdown(t *testing.T) {
+	previous := &api.Analysis{
+		Score: 3,
+		Health: api.Health{
+			"security": {Score: 3, Checks: []api.Check{{Name: "Branch protection", Pass: false}, {Name: "Signed releases", Pass: true}}},
+			"legacy":   {Score: 2},
+		},
+	}
+	current := &api.Analysis{
+		Score: 4,
+		Health: api.Health{
+			"security":    {Score: 4, Checks: []api.Check{{Name: "Branch protection", Pass: true}, {Name: "Signed releases", Pass: false}}},
+			"maintenance": {Score: 5, Checks: []api.Check{{Name: "Recent commits", Pass: false}}},
+		},
+	}
+
+	md := riskCheckDiffMarkdown(previous, current, "2026-10-01 02:00:00")
+	assert.Contains(t, md, "## Changes Since Last Full Scan (2026-10-01 02:00:00)")
+	assert.Contains(t, md, "Overall score: 3/5 → 4/5 (+1)")
+	assert.Contains(t, md, "| Legacy | 2/5 | - | removed |")
+	assert.Contains(t, md, "| Maintenance | - | 5/5 | new |")
+	assert.Contains(t, md, "| Security | 3/5 | 4/5 | +1 |")
+	assert.Contains(t, md, "### New Failing Checks\n- Maintenance: Recent commits\n- Security: Signed releases\n")
+	assert.Contains(t, md, "### Resolved Checks\n- Security: Branch protection\n")
+
+	unchanged := riskCheckDiffMarkdown(current, current, "")
+	assert.Contains(t, unchanged, "## Changes Since Last Full Scan\n")
+	assert.Contains(t, unchanged, "(no change)")
+	assert.Contains(t, unchanged, "No category scores or checks changed.")
+}
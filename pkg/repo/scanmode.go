@@ -0,0 +1,17 @@
+// Copyright (c) Kusari <https://www.kusari.dev/>
+// SPDX-License-Identifier: MIT
+
+package repo
+
+// gitPaths appends to the git arguments args the pathspecs of the files a
+// scan is limited to, if it is: the IaC globs of an IaC scan.
+func (o ScanOptions) gitPaths(args ...string) []string {
+	if len(o.IaC) == 0 {
+		return args
+	}
+	args = append(args, "--")
+	for _, p := range o.IaC {
+		args = append(args, ":(glob)"+p)
+	}
+	return args
+}
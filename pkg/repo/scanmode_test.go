@@ -0,0 +1,126 @@
+// Copyright (c) Kusari <https://www.kusari.dev/>
+// SPDX-License-Identifier: MIT
+
+package repo
+
+import (
+	"archive/tar"
+	"compress/bzip2"
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/kusaridev/kusari-cli/v2/api"
+	"github.com/kusaridev/kusari-cli/v2/pkg/login"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// readBundleMeta returns the metadata in the bundle at path.
+func readBundleMeta(t *testing.T, path string) api.BundleMeta {
+	t.Helper()
+	f, err := os.Open(path)
+	require.NoError(t, err)
+	defer func() { _ = f.Close() }()
+	var meta api.BundleMeta
+	tr := tar.NewReader(bzip2.NewReader(f))
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			return meta
+		}
+		require.NoError(t, err)
+		if hdr.Name == metaFile {
+			require.NoError(t, json.NewDecoder(tr).Decode(&meta))
+		}
+	}
+}
+
+// bundleMock is a scanMock recording the scan type and the bundle
+// uploaded.
+func bundleMock(t *testing.T, scanType *string, files *[]string, meta *api.BundleMeta) *scanMock {
+	return &scanMock{
+		fileUploader: func(presignedURL, filePath string) error {
+			*files = extractTarballContents(t, filePath)
+			*meta = readBundleMeta(t, filePath)
+			return nil
+		},
+		presignedURLGetter: func(apiEndpoint string, jwtToken string, filePath, workspace string, st string, size int64) (string, error) {
+			*scanType = st
+			return "https://example.com/workspace/test-workspace-id/user/human/test-user-id/diff/blob/123", nil
+		},
+		defaultWorkspaceGetter: func(platformUrl string, jwtToken string) ([]login.Workspace, map[string][]string, error) {
+			return []login.Workspace{{ID: "ws-1", Description: "Test Workspace"}}, nil, nil
+		},
+		token: "token",
+	}
+}
+
+func TestScan_SecretsOnly(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	testDir := t.TempDir()
+	runCmd(t, testDir, "git", "init")
+	runCmd(t, testDir, "git", "config", "user.email", "test@example.com")
+	runCmd(t, testDir, "git", "config", "user.name", "Test User")
+	writeFile(t, filepath.Join(testDir, "config.env"), "TOKEN=")
+	writeFile(t, filepath.Join(testDir, "main.go"), "package main")
+	runCmd(t, testDir, "git", "add", ".")
+	runCmd(t, testDir, "git", "commit", "-m", "initial commit")
+	writeFile(t, filepath.Join(testDir, "config.env"), "TOKEN=ghp_example")
+
+	var scanType string
+	var files []string
+	var meta api.BundleMeta
+	err := scan(ScanOptions{Dir: testDir, Rev: "HEAD", PlatformURL: "https://platform.example.com", ConsoleURL: "https://console.example.com", OutputFormat: "markdown", SecretsOnly: true}, false, bundleMock(t, &scanType, &files, &meta))
+	require.NoError(t, err)
+
+	assert.Equal(t, "secrets", scanType)
+	assert.ElementsMatch(t, []string{metaFile, patchFile, manifestFile}, files)
+	assert.Equal(t, "secrets", meta.ScanType)
+	assert.Equal(t, []string{"config.env"}, meta.ChangedFiles)
+}
+
+func TestUploadJournalScanType(t *testing.T) {
+	assert.Equal(t, "diff", (&UploadJournal{}).scanType())
+	assert.Equal(t, "patch", (&UploadJournal{PatchOnly: true}).scanType())
+	assert.Equal(t, "secrets", (&UploadJournal{SecretsOnly: true, PatchOnly: true}).scanType())
+	assert.Equal(t, "iac", (&UploadJournal{IaC: true}).scanType())
+	assert.Equal(t, "image", (&UploadJournal{Image: true}).scanType())
+	assert.Equal(t, "full", (&UploadJournal{Full: true}).scanType())
+	assert.Equal(t, "full-delta", (&UploadJournal{Full: true, Delta: true}).scanType())
+}
+
+func TestScan_IaC(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	testDir := t.TempDir()
+	runCmd(t, testDir, "git", "init")
+	runCmd(t, testDir, "git", "config", "user.email", "test@example.com")
+	runCmd(t, testDir, "git", "config", "user.name", "Test User")
+	require.NoError(t, os.MkdirAll(filepath.Join(testDir, "deploy", "k8s"), 0755))
+	writeFile(t, filepath.Join(testDir, "main.tf"), `resource "aws_s3_bucket" "b" {}`)
+	writeFile(t, filepath.Join(testDir, "deploy", "k8s", "app.yaml"), "kind: Deployment")
+	writeFile(t, filepath.Join(testDir, "main.go"), "package main")
+	runCmd(t, testDir, "git", "add", ".")
+	runCmd(t, testDir, "git", "commit", "-m", "initial commit")
+	writeFile(t, filepath.Join(testDir, "main.tf"), `resource "aws_s3_bucket" "b" { acl = "public-read" }`)
+	writeFile(t, filepath.Join(testDir, "main.go"), "package main // changed")
+
+	var scanType string
+	var files []string
+	var meta api.BundleMeta
+	err := scan(ScanOptions{Dir: testDir, Rev: "HEAD", PlatformURL: "https://platform.example.com", ConsoleURL: "https://console.example.com", OutputFormat: "markdown", IaC: []string{"**/*.tf", "deploy/**"}}, false, bundleMock(t, &scanType, &files, &meta))
+	require.NoError(t, err)
+
+	assert.Equal(t, "iac", scanType)
+	assert.ElementsMatch(t, []string{metaFile, patchFile, manifestFile, "main.tf", "deploy/k8s/app.yaml"}, files)
+	assert.Equal(t, "iac", meta.ScanType)
+	assert.Equal(t, []string{"**/*.tf", "deploy/**"}, meta.IaCPaths)
+	assert.Equal(t, []string{"main.tf"}, meta.ChangedFiles)
+}
+
+func TestGitPaths(t *testing.T) {
+	assert.Equal(t, []string{"diff", "HEAD"}, ScanOptions{}.gitPaths("diff", "HEAD"))
+	assert.Equal(t, []string{"diff", "HEAD", "--", ":(glob)**/*.tf"}, ScanOptions{IaC: []string{"**/*.tf"}}.gitPaths("diff", "HEAD"))
+}
@@ -4,6 +4,7 @@
 package repo
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -16,22 +17,29 @@ import (
 	"regexp"
 	"slices"
 	"strings"
+	"sync/atomic"
 	"time"
 
-	"github.com/briandowns/spinner"
-	"github.com/charmbracelet/glamour"
 	"github.com/kusaridev/kusari-cli/v2/api"
+	apiconfig "github.com/kusaridev/kusari-cli/v2/api/configuration"
 	"github.com/kusaridev/kusari-cli/v2/pkg/auth"
+	"github.com/kusaridev/kusari-cli/v2/pkg/clierrors"
+	"github.com/kusaridev/kusari-cli/v2/pkg/comment"
+	"github.com/kusaridev/kusari-cli/v2/pkg/configuration"
 	"github.com/kusaridev/kusari-cli/v2/pkg/github"
 	"github.com/kusaridev/kusari-cli/v2/pkg/gitlab"
+	"github.com/kusaridev/kusari-cli/v2/pkg/itsm"
 	"github.com/kusaridev/kusari-cli/v2/pkg/login"
-	"github.com/kusaridev/kusari-cli/v2/pkg/sarif"
+	"github.com/kusaridev/kusari-cli/v2/pkg/output"
+	"github.com/kusaridev/kusari-cli/v2/pkg/transport"
 	urlBuilder "github.com/kusaridev/kusari-cli/v2/pkg/url"
 )
 
 const (
 	patchFile               = "kusari-inspector.patch"
 	metaFile                = "kusari-inspector.json"
+	manifestFile            = "kusari-manifest.json"
+	manifestDiffFile        = "kusari-manifest-diff.json"
 	tarballNameUncompressed = "kusari-inspector.tar"
 	tarballName             = tarballNameUncompressed + ".bz2"
 	workingDirName          = "kusari-dir"
@@ -48,65 +56,267 @@ var (
 	workingDir string
 )
 
-func Scan(dir string, rev string, platformUrl string, consoleUrl string, verbose bool, wait bool, outputFormat string, commentPlatform string, fullOutput bool, overrideBranch string) error {
-	return scan(dir, rev, platformUrl, consoleUrl, verbose, wait, false, outputFormat, commentPlatform, fullOutput, overrideBranch, nil)
+// ScanOptions configures a Scan, ScanWithOutcome or RiskCheck. Dir,
+// PlatformURL and ConsoleURL are required; everything else is optional and
+// zero values mean "not set". Every scan is given its own options, so
+// nothing set for one leaks into the next scan run by the same process,
+// as the LSP server, the MCP tools and scheduled jobs run several.
+type ScanOptions struct {
+	// Dir is the root of the work tree of the repository to scan.
+	Dir string
+	// Rev is what a diff scan compares the changes against.
+	Rev         string
+	PlatformURL string
+	ConsoleURL  string
+	Verbose     bool
+	// Wait waits for the results and writes them to the outputs of
+	// OutputFormat, as ParseOutputs parses it.
+	Wait         bool
+	OutputFormat string
+	// CommentPlatform posts the results of a diff scan as a comment on the
+	// PR/MR of PlatformGitHub or PlatformGitLab.
+	CommentPlatform string
+	// CommentDryRun writes the comments CommentPlatform would post to this
+	// path ("-" for stdout) instead of calling the forge's API.
+	CommentDryRun string
+	// FullOutput writes the full results instead of the truncated summary.
+	FullOutput bool
+	// OverrideBranch is recorded instead of the branch git reports, which
+	// is HEAD in the detached state of CI.
+	OverrideBranch string
+	// Staged analyzes only the changes staged in the index, packaging the
+	// index rather than the working tree; CommittedOnly only the commits
+	// up to HEAD, packaging HEAD.
+	Staged        bool
+	CommittedOnly bool
+	// PatchOnly packages only the metadata and patch, no source files.
+	PatchOnly bool
+	// SecretsOnly makes a diff scan a secrets-only scan: the bundle holds
+	// the metadata and patch, with the "secrets" scan type, and the
+	// platform analyzes the patch for leaked secrets only.
+	SecretsOnly bool
+	// IaC, when not empty, makes a diff scan an IaC scan: the bundle has
+	// the "iac" scan type, and only the files matching these globs, with
+	// ** matching any number of directories, are packaged and diffed.
+	IaC []string
+	// Filter narrows the findings of a diff scan before they are written.
+	Filter ResultFilter
+	// TicketSinks are where a diff scan that should not proceed is filed.
+	TicketSinks TicketSinks
+	// PreUploadHook and PostResultHook are the scripts of the pre_upload
+	// and post_result hooks. They are only ever taken from the CLI's own
+	// flags and environment or from UserHooks, never from the kusari.yaml
+	// of the scanned directory, which whoever authored the change controls.
+	PreUploadHook  string
+	PostResultHook string
+	// Attest configures the attestation of the scan.
+	Attest AttestOptions
+	// BundleEncryption is the bundle encryption mode, as given by the
+	// user; empty is BundleEncryptionNone. It is validated with
+	// ParseBundleEncryption when the scan starts.
+	BundleEncryption BundleEncryption
+	// LockWait is how long to wait for a concurrent scan of the same
+	// repository to finish; 0 fails at once.
+	LockWait time.Duration
+	// MaxFileSize leaves source files larger than this many bytes out of
+	// the package. 0 is DefaultMaxFileSize; negative packages every file.
+	MaxFileSize int64
+	// IncludeBinaries packages binary files, such as images, archives and
+	// compiled objects, which bloat uploads and are rarely useful to the
+	// analysis.
+	IncludeBinaries bool
+	// GitDir runs git with this git directory and Dir as its work tree, as
+	// git --git-dir and --work-tree do: for a bare repository with a work
+	// tree elsewhere.
+	GitDir string
+	// Checks limits a risk check to the named sub-scans, as ParseChecks
+	// returns them; empty runs them all.
+	Checks []string
+	// Delta makes a risk check upload a delta bundle: only the files
+	// added or changed since the last risk check of the same repository,
+	// branch and workspace the platform received, with the manifest diff
+	// the platform rebuilds the full bundle from. The first one uploads
+	// the whole bundle.
+	Delta bool
+	// NoGit makes a risk check package every file of Dir without running
+	// git: for a copy of a repository without .git, such as a release
+	// tarball, or a machine without git. The bundle metadata is flagged
+	// no_git, and has no remote, commit or dirty state.
+	NoGit bool
 }
 
-func RiskCheck(dir string, platformUrl string, consoleUrl string, verbose bool, wait bool) error {
+// validate checks the options that can't be combined.
+func (o ScanOptions) validate() error {
+	if o.Staged && o.CommittedOnly {
+		return clierrors.NewValidationError("--staged and --committed-only can't be used together")
+	}
+	if o.GitDir != "" && o.NoGit {
+		return clierrors.NewValidationError("--git-dir and --no-git can't be used together")
+	}
+	return nil
+}
+
+// resolvePaths makes the comment dry run path and the hook scripts
+// absolute against the current directory, as they are used from the
+// scanned one.
+func (o *ScanOptions) resolvePaths() error {
+	if o.CommentDryRun != "" && o.CommentDryRun != "-" {
+		abs, err := filepath.Abs(o.CommentDryRun)
+		if err != nil {
+			return fmt.Errorf("failed to resolve %s: %w", o.CommentDryRun, err)
+		}
+		o.CommentDryRun = abs
+	}
+	for name, script := range map[string]*string{HookPreUpload: &o.PreUploadHook, HookPostResult: &o.PostResultHook} {
+		if *script == "" {
+			continue
+		}
+		abs, err := filepath.Abs(*script)
+		if err != nil {
+			return fmt.Errorf("failed to resolve %s hook %s: %w", name, *script, err)
+		}
+		*script = abs
+	}
+	return nil
+}
+
+// Scan analyzes the changes in the repository at opts.Dir against
+// opts.Rev.
+func Scan(opts ScanOptions) error {
+	_, err := ScanWithOutcome(opts)
+	return err
+}
+
+// ScanOutcome is how a scan that didn't fail concluded.
+type ScanOutcome int
+
+const (
+	// OutcomeAnalyzed is a scan the platform analyzed; once the results
+	// are in, they are saved with results.Save.
+	OutcomeAnalyzed ScanOutcome = iota
+	// OutcomeCached is a diff scan answered from the scan cache, as the
+	// diff is the same as an earlier scan's. Nothing new is saved.
+	OutcomeCached
+	// OutcomeNoChanges is a diff scan of an empty diff, which had nothing
+	// to analyze.
+	OutcomeNoChanges
+)
+
+// ScanWithOutcome is Scan, also returning how the scan concluded, for
+// callers that follow up on its saved result.
+func ScanWithOutcome(opts ScanOptions) (ScanOutcome, error) {
+	return scanOutcome(opts, false, nil)
+}
+
+// RiskCheck analyzes the whole repository at opts.Dir; with
+// CommittedOnly, as committed at HEAD. Rev, Staged, PatchOnly and the
+// output options are ignored.
+func RiskCheck(opts ScanOptions) error {
 	// default to outputformat "markdown" for now for risk check as it will link to console
 	// commentPlatform is empty for risk-check as it's not typically run in MR context
-	return scan(dir, "", platformUrl, consoleUrl, verbose, wait, true, "markdown", "", false, "", nil)
+	opts.Rev, opts.Staged, opts.PatchOnly = "", false, false
+	opts.OutputFormat, opts.CommentPlatform, opts.FullOutput = "markdown", "", false
+	return scan(opts, true, nil)
 }
 
 // scanMock facilitates use of mock values for testing
 type scanMock struct {
 	fileUploader           func(presignedURL, filePath string) error
-	presignedURLGetter     func(apiEndpoint string, jwtToken string, filePath, workspace string, full bool, size int64) (string, error)
+	presignedURLGetter     func(apiEndpoint string, jwtToken string, filePath, workspace string, scanType string, size int64) (string, error)
 	defaultWorkspaceGetter func(platformUrl string, jwtToken string) ([]login.Workspace, map[string][]string, error)
+	bundleKeyGetter        func(platformUrl, jwtToken, workspace string) (*bundleKey, error)
 	token                  string
 	isMachineAuth          bool
 }
 
-func scan(dir string, rev string, platformUrl string, consoleUrl string, verbose bool, wait bool, full bool, outputFormat string,
-	commentPlatform string, fullOutput bool, overrideBranch string, mock *scanMock) error {
-	if verbose {
-		fmt.Fprintf(os.Stderr, " dir: %s\n", dir)
-		fmt.Fprintf(os.Stderr, " rev: %s\n", rev)
-		fmt.Fprintf(os.Stderr, " platformUrl: %s\n", platformUrl)
-		fmt.Fprintf(os.Stderr, " consoleUrl: %s\n", consoleUrl)
-		fmt.Fprintf(os.Stderr, " outputFormat: %s\n", outputFormat)
-		fmt.Fprintf(os.Stderr, " overrideBranch: %s\n", overrideBranch)
-	}
-
-	// Check to see if the directory has a .git directory. If it does not, it is not the root of
-	// the repo and the scan will probably fail during analysis.
-	_, err := os.Stat(filepath.Join(dir, ".git"))
-	if os.IsNotExist(err) {
-		fmt.Fprintf(os.Stderr, "No .git directory found in %s\n  Directory must be root of repo\n", dir)
-		os.Exit(1)
+func scan(opts ScanOptions, full bool, mock *scanMock) error {
+	_, err := scanOutcome(opts, full, mock)
+	return err
+}
+
+func scanOutcome(opts ScanOptions, full bool, mock *scanMock) (ScanOutcome, error) {
+	dir, rev := opts.Dir, opts.Rev
+	if opts.Verbose {
+		output.SetVerbose(true)
+	}
+	output.Debug("scan options", "dir", dir, "rev", rev, "platformUrl", opts.PlatformURL, "consoleUrl", opts.ConsoleURL,
+		"outputFormat", opts.OutputFormat, "overrideBranch", opts.OverrideBranch, "full", full,
+		"staged", opts.Staged, "committedOnly", opts.CommittedOnly, "patchOnly", opts.PatchOnly)
+
+	if err := opts.validate(); err != nil {
+		return OutcomeAnalyzed, err
+	}
+	if err := opts.resolvePaths(); err != nil {
+		return OutcomeAnalyzed, err
+	}
+	// Fail before uploading if the scan can't be attested.
+	attest := opts.Attest
+	if attest.Path != "" {
+		if _, err := findCosign(); err != nil {
+			return OutcomeAnalyzed, err
+		}
+	}
+
+	if err := checkGit(opts, full); err != nil {
+		return OutcomeAnalyzed, err
+	}
+
+	restoreGitDir, err := useGitDir(opts.GitDir, dir)
+	if err != nil {
+		return OutcomeAnalyzed, err
+	}
+	defer restoreGitDir()
+	// Scans of anything but the root of the repo will probably fail during
+	// analysis.
+	if !opts.NoGit {
+		if err := checkRepoRoot(dir); err != nil {
+			return OutcomeAnalyzed, err
+		}
+	}
+
+	source := sourceWorkingTree
+	switch {
+	case opts.NoGit:
+		source = sourceSnapshot
+	case opts.Staged:
+		source = sourceIndex
+	case opts.CommittedOnly:
+		source = sourceHead
 	}
 
-	// For diff scans (not full), check cache first
-	if !full && wait {
-		cacheResult, cacheErr := CheckCache(dir, rev, verbose)
+	outputs, err := ParseOutputs(opts.OutputFormat)
+	if err != nil {
+		return OutcomeAnalyzed, err
+	}
+	encryption, err := ParseBundleEncryption(string(opts.BundleEncryption))
+	if err != nil {
+		return OutcomeAnalyzed, err
+	}
+	// For diff scans (not full), check cache first. The cache holds what
+	// was printed, so it can't answer when results also go to files, and
+	// is keyed on the working tree diff, so it can't answer scans of the
+	// index or HEAD. Nor can it answer for another result filter, a
+	// secrets-only scan or an IaC scan.
+	if !full && !opts.SecretsOnly && len(opts.IaC) == 0 && source == sourceWorkingTree && opts.Wait && stdoutOnly(outputs) && !opts.Filter.active() {
+		cacheResult, cacheErr := CheckCache(dir, rev, opts.Verbose)
 		if cacheErr != nil {
 			// "no changes to scan" is a valid case - return early
 			if strings.Contains(cacheErr.Error(), "no changes to scan") {
 				fmt.Fprintf(os.Stderr, "No changes to scan (git diff is empty against %s)\n", rev)
-				return nil
+				return OutcomeNoChanges, nil
 			}
 			// Other cache errors - log and continue with scan
-			if verbose {
+			if opts.Verbose {
 				fmt.Fprintf(os.Stderr, "Cache check error: %v\n", cacheErr)
 			}
 		} else if cacheResult != nil && cacheResult.Hit {
 			// Cache hit - output cached results
-			fmt.Fprintf(os.Stderr, "✓ Returning cached results (no changes since last scan)\n")
+			output.Progressf(os.Stderr, "✓ Returning cached results (no changes since last scan)\n")
 			if cacheResult.ConsoleURL != "" {
-				fmt.Fprintf(os.Stderr, "View results at: %s\n", cacheResult.ConsoleURL)
+				fmt.Fprintf(os.Stderr, "View results at: %s\n", output.Hyperlink(os.Stderr, cacheResult.ConsoleURL, cacheResult.ConsoleURL))
 			}
 			fmt.Print(cacheResult.Results)
-			return nil
+			return OutcomeCached, nil
 		}
 	}
 
@@ -118,8 +328,7 @@ func scan(dir string, rev string, platformUrl string, consoleUrl string, verbose
 			fmt.Fprintf(os.Stderr, "Warning: Error checking for monorepo: %v\n", err)
 		}
 		if isMonoRepo {
-			fmt.Fprintf(os.Stderr, "Error: Monorepo detected in %s\n", dir)
-			fmt.Fprintf(os.Stderr, "\nMonorepo indicators found:\n")
+			fmt.Fprintf(os.Stderr, "Monorepo indicators found:\n")
 			for _, indicator := range indicators {
 				fmt.Fprintf(os.Stderr, "  - %s\n", indicator)
 			}
@@ -127,28 +336,26 @@ func scan(dir string, rev string, platformUrl string, consoleUrl string, verbose
 			fmt.Fprintf(os.Stderr, "Please run risk-check on each sub-project directory separately.\n")
 			fmt.Fprintf(os.Stderr, "\nFor example:\n")
 			fmt.Fprintf(os.Stderr, "  kusari repo risk-check ./packages/project1\n")
-			fmt.Fprintf(os.Stderr, "  kusari repo risk-check ./packages/project2\n")
-			os.Exit(1)
+			fmt.Fprintf(os.Stderr, "  kusari repo risk-check ./packages/project2\n\n")
+			return OutcomeAnalyzed, clierrors.NewValidationError("monorepo detected in %s", dir)
 		}
 	}
 
 	fileUploader := uploadFileToS3
 	presignedURLGetter := getPresignedURL
-	defaultWorkspaceGetter := login.FetchWorkspaces
+	defaultWorkspaceGetter := login.FetchWorkspacesCached
+	bundleKeyGetter := fetchBundleKey
 	var accessToken string
 	if mock != nil {
 		fileUploader = mock.fileUploader
 		presignedURLGetter = mock.presignedURLGetter
 		defaultWorkspaceGetter = mock.defaultWorkspaceGetter
+		bundleKeyGetter = mock.bundleKeyGetter
 		accessToken = mock.token
 	} else {
-		token, err := auth.LoadToken("kusari")
+		token, err := auth.DefaultTokenProvider().Token(context.Background())
 		if err != nil {
-			return fmt.Errorf("failed to load auth token: %w", err)
-		}
-
-		if err := auth.CheckTokenExpiry(token); err != nil {
-			return err
+			return OutcomeAnalyzed, fmt.Errorf("failed to load auth token: %w", err)
 		}
 		accessToken = token.AccessToken
 	}
@@ -158,106 +365,241 @@ func scan(dir string, rev string, platformUrl string, consoleUrl string, verbose
 	// instead of the actual branch name.
 	isMachine := false
 	if mock == nil {
-		if ws, wsErr := auth.LoadWorkspace(platformUrl, ""); wsErr == nil {
-			isMachine = ws.IsMachine
+		isMachine = auth.DefaultTokenProvider().Machine()
+		if ws, wsErr := auth.LoadWorkspace(opts.PlatformURL, ""); wsErr == nil && ws.IsMachine {
+			isMachine = true
 		}
 	} else {
 		isMachine = mock.isMachineAuth
 	}
-	if isMachine && overrideBranch == "" {
-		return fmt.Errorf("--override-branch is required when using API key authentication (detached HEAD state in CI would report 'HEAD' as the branch name)")
+	if isMachine && opts.OverrideBranch == "" {
+		return OutcomeAnalyzed, clierrors.NewValidationError("--override-branch is required when using API key authentication (detached HEAD state in CI would report 'HEAD' as the branch name)")
 	}
 
 	if err := validateDirectory(dir); err != nil {
-		return fmt.Errorf("failed to validate directory: %w", err)
+		return OutcomeAnalyzed, fmt.Errorf("failed to validate directory: %w", err)
 	}
 
-	// Create a temporary working directory
-	tempDir, err := os.MkdirTemp(os.TempDir(), "kusari-")
+	// Keep a concurrent scan of the repository from interleaving its git
+	// operations with ours
+	lock, err := acquireScanLock(dir, opts.LockWait, opts.NoGit)
 	if err != nil {
-		return fmt.Errorf("failed to create temporary directory: %w", err)
+		return OutcomeAnalyzed, err
 	}
-	// Create the path inside it for our metadata and patch files
-	workingDir = filepath.Join(tempDir, workingDirName)
-	err = os.Mkdir(workingDir, os.FileMode(0700))
+	defer lock.release()
+
+	tempDir, err := setupWorkingDirectory()
 	if err != nil {
-		return fmt.Errorf("failed to create temporary directory: %w", err)
+		return OutcomeAnalyzed, err
 	}
-	tarballDir = tempDir
-	metaName = filepath.Join(tarballDir, workingDirName, metaFile)
-	patchName = filepath.Join(tarballDir, workingDirName, patchFile)
 
 	// Set up signal handling to clean up after ourselves
+	var resumable atomic.Bool
 	c := make(chan os.Signal, 1)
 	signal.Notify(c, os.Interrupt)
 	go func() {
 		<-c
 		cleanupWorkingDirectory(tempDir)
+		lock.release()
+		if resumable.Load() {
+			fmt.Fprintf(os.Stderr, "\nInterrupted; resume with `kusari repo scan --resume`\n")
+		}
 		os.Exit(1)
 	}()
 
+	absDir, err := filepath.Abs(dir)
+	if err != nil {
+		return OutcomeAnalyzed, fmt.Errorf("failed to resolve directory: %w", err)
+	}
 	if err := os.Chdir(dir); err != nil {
-		return fmt.Errorf("failed to change directory: %w", err)
+		return OutcomeAnalyzed, fmt.Errorf("failed to change directory: %w", err)
 	}
 	defer func() {
 		cleanupWorkingDirectory(tempDir)
 	}()
 
-	meta, err := createMeta(rev, full, overrideBranch)
+	var meta *api.BundleMeta
+	if opts.NoGit {
+		meta, err = createSnapshotMeta(opts)
+	} else {
+		meta, err = createMeta(opts, full, source)
+	}
 	if err != nil {
-		return fmt.Errorf("failed to create meta file: %w", err)
+		return OutcomeAnalyzed, fmt.Errorf("failed to create meta file: %w", err)
+	}
+	if meta.GitDirty && source == sourceWorkingTree {
+		warnDirty(full)
 	}
+	printMetaSummary(meta)
 
 	if !full {
-		fmt.Fprint(os.Stderr, "Generating diff...\n")
-		if err := generateDiff(rev); err != nil {
-			return fmt.Errorf("failed to generate diff: %w", err)
+		output.Progressf(os.Stderr, "Generating diff...\n")
+		norm, err := generateDiff(opts, source)
+		if err != nil {
+			return OutcomeAnalyzed, fmt.Errorf("failed to generate diff: %w", err)
+		}
+		if norm != nil {
+			printNormalization(norm)
+			meta.PatchNormalization = norm
+			if err := writeMeta(meta); err != nil {
+				return OutcomeAnalyzed, fmt.Errorf("failed to create meta file: %w", err)
+			}
 		}
 	}
 
-	fmt.Fprint(os.Stderr, "Packaging directory...\n")
+	packaged := source
+	switch {
+	case opts.SecretsOnly && !full:
+		packaged = sourceNone
+		output.Progressf(os.Stderr, "Packaging patch for a secrets-only scan...\n")
+	case opts.PatchOnly:
+		packaged = sourceNone
+		output.Progressf(os.Stderr, "Packaging patch (no source files)...\n")
+	case len(opts.IaC) > 0 && !full:
+		output.Progressf(os.Stderr, "Packaging IaC files (%s)...\n", strings.Join(opts.IaC, ", "))
+	case source == sourceIndex:
+		output.Progressf(os.Stderr, "Packaging staged files...\n")
+	case source == sourceHead:
+		output.Progressf(os.Stderr, "Packaging HEAD...\n")
+	case source == sourceSnapshot:
+		output.Progressf(os.Stderr, "Packaging directory snapshot (no git)...\n")
+	default:
+		output.Progressf(os.Stderr, "Packaging directory...\n")
+	}
 
-	size, err := packageDirectory(full)
+	size, err := packageDirectory(opts, full, packaged)
 	if err != nil {
-		return fmt.Errorf("failed to package directory: %w", err)
+		return OutcomeAnalyzed, fmt.Errorf("failed to package directory: %w", err)
+	}
+	if size, err = opts.hooks().preUpload(dir, size); err != nil {
+		return OutcomeAnalyzed, err
+	}
+	var packageDigest string
+	if attest.Path != "" {
+		// Of the package as analyzed, before any encryption.
+		if packageDigest, err = fileSHA256(filepath.Join(tarballDir, tarballName)); err != nil {
+			return OutcomeAnalyzed, fmt.Errorf("failed to hash package: %w", err)
+		}
 	}
 
-	var workspace string
-	var workspaceDescription string
-
-	// Load the stored workspace for the current platform
-	// Pass empty string for authEndpoint as it's not available during scans and only validated during login
-	storedWorkspace, err := auth.LoadWorkspace(platformUrl, "")
+	workspace, workspaceDescription, err := scanWorkspace(opts.PlatformURL, accessToken, defaultWorkspaceGetter)
 	if err != nil {
-		// If no workspace is stored or platform changed, try to fetch and use first workspace
-		workspaces, _, workspaceGetterErr := defaultWorkspaceGetter(platformUrl, accessToken)
-		if workspaceGetterErr != nil {
-			return fmt.Errorf("failed to get workspaces: %w. Please run `kusari auth login` to select a workspace", workspaceGetterErr)
+		return OutcomeAnalyzed, err
+	}
+	var isDelta bool
+	var deltaUp *deltaUpload
+	if full && opts.Delta {
+		key := deltaKey(opts.PlatformURL, workspace, absDir, meta.CurrentBranch)
+		if isDelta, size, deltaUp, err = prepareDelta(key, size); err != nil {
+			return OutcomeAnalyzed, fmt.Errorf("failed to prepare delta package: %w", err)
 		}
+	}
+	keyID, size, err := encryptScanBundle(encryption, opts.PlatformURL, accessToken, workspace, workspaceDescription, size, bundleKeyGetter)
+	if err != nil {
+		return OutcomeAnalyzed, err
+	}
 
-		// Use the first workspace as fallback (for CI/CD workflows)
-		workspace = workspaces[0].ID
-		workspaceDescription = workspaces[0].Description
-		fmt.Fprintf(os.Stderr, "Using workspace: %s\n", workspaceDescription)
+	// Keep the bundle and what is needed to upload it until the results
+	// are in, so `kusari repo scan --resume` can pick up after a crash.
+	j := &UploadJournal{
+		Dir:           absDir,
+		Rev:           rev,
+		Staged:        opts.Staged,
+		CommittedOnly: opts.CommittedOnly,
+		Full:          full,
+		Delta:         isDelta,
+		PatchOnly:     opts.PatchOnly,
+		SecretsOnly:   opts.SecretsOnly && !full,
+		IaC:           len(opts.IaC) > 0 && !full,
+		PlatformURL:   opts.PlatformURL,
+		ConsoleURL:    opts.ConsoleURL,
+		Workspace:     workspace,
+		Size:          size,
+		Remote:        meta.Remote,
+		DirName:       meta.DirName,
+		Branch:        meta.CurrentBranch,
+		BundleKeyID:   keyID,
+	}
+	if err := newUploadJournal(j, filepath.Join(tarballDir, tarballName)); err != nil {
+		output.Debug("upload will not be resumable", "error", err)
 	} else {
-		workspace = storedWorkspace.ID
-		workspaceDescription = storedWorkspace.Description
-		fmt.Fprintf(os.Stderr, "Using workspace: %s\n", workspaceDescription)
+		resumable.Store(true)
+	}
+
+	err = uploadAndWait(opts, j, accessToken, presignedURLGetter, fileUploader, outputs, absDir)
+	if deltaUp != nil && j.Uploaded && err == nil {
+		if recordErr := deltaUp.record(j.SortKey); recordErr != nil {
+			fmt.Fprintf(os.Stderr, "Warning: failed to record package manifest for the next --delta risk check: %v\n", recordErr)
+		}
+	}
+	// A scan whose result failed the checks was still run, so attest it.
+	if attest.Path != "" && j.Uploaded {
+		if attestErr := attestScan(attest, j, meta, packageDigest, accessToken); attestErr != nil {
+			return OutcomeAnalyzed, errors.Join(err, fmt.Errorf("failed to attest scan: %w", attestErr))
+		}
 	}
+	return OutcomeAnalyzed, err
+}
+
+// uploadAndWait uploads the bundle of j, unless an earlier attempt did,
+// then waits for its results when opts.Wait is set. The journal is removed
+// once nothing is left to resume.
+func uploadAndWait(opts ScanOptions, j *UploadJournal, accessToken string,
+	presignedURLGetter func(apiEndpoint string, jwtToken string, filePath, workspace string, scanType string, size int64) (string, error),
+	fileUploader func(presignedURL, filePath string) error,
+	outputs []Output, repoDir string) error {
+	if !j.Uploaded {
+		if err := uploadBundle(j, accessToken, presignedURLGetter, fileUploader); err != nil {
+			if j.path != "" {
+				fmt.Fprintf(os.Stderr, "The package was kept; retry with `kusari repo scan --resume` or `kusari platform flush`\n")
+			}
+			return err
+		}
+		output.Progressf(os.Stderr, "Upload successful, your scan is processing!\n")
+	}
+	// We print the URL when it is completed, but that doesn't help if it fails
+	// for some reason and the user needs to contact support.
+	output.Progressf(os.Stderr, "Once completed, you can see results at: %s\n", output.Hyperlink(os.Stderr, j.ResultURL, j.ResultURL))
 
-	apiEndpoint, err := urlBuilder.Build(platformUrl, "inspector/presign/bundle-upload")
+	// Wait for results if the user wants, or exit immediately
+	if opts.Wait {
+		resultURL := j.ResultURL
+		if err := queryForResult(opts, j.PlatformURL, j.SortKey, accessToken, &resultURL, j.Workspace, outputs, j.Full, repoDir, j.Rev, j.Staged || j.CommittedOnly || j.SecretsOnly || j.IaC || j.Image); err != nil {
+			return err
+		}
+	}
+	if err := j.remove(); err != nil {
+		output.Debug("failed to remove upload journal", "error", err)
+	}
+	return nil
+}
+
+// uploadBundle requests a presigned URL for the bundle of j, uploads it and
+// records where its result will be.
+func uploadBundle(j *UploadJournal, accessToken string,
+	presignedURLGetter func(apiEndpoint string, jwtToken string, filePath, workspace string, scanType string, size int64) (string, error),
+	fileUploader func(presignedURL, filePath string) error) error {
+	apiEndpoint, err := urlBuilder.Build(j.PlatformURL, "inspector/presign/bundle-upload")
 	if err != nil {
 		return err
 	}
 
-	presignedUrl, err := presignedURLGetter(*apiEndpoint, accessToken, tarballName, workspace, full, size)
+	name := tarballName
+	if j.BundleKeyID != "" {
+		name = encryptedTarballName
+	}
+	presignedUrl, err := presignedURLGetter(*apiEndpoint, accessToken, name, j.Workspace, j.scanType(), j.Size)
 	if err != nil {
 		return fmt.Errorf("failed to get presigned URL: %w", err)
 	}
+	output.Debug("obtained presigned URL", "url", presignedUrl, "size", j.Size)
 
-	fmt.Fprint(os.Stderr, "Uploading package repo...\n")
+	output.Progressf(os.Stderr, "Uploading package repo...\n")
 
-	if err := fileUploader(presignedUrl, filepath.Join(tarballDir, tarballName)); err != nil {
+	if _, err := os.Stat(j.bundle); err != nil {
+		return fmt.Errorf("package to upload is missing: %w", err)
+	}
+	if err := fileUploader(presignedUrl, j.bundle); err != nil {
 		return fmt.Errorf("failed to upload file to S3: %w", err)
 	}
 
@@ -266,55 +608,176 @@ func scan(dir string, rev string, platformUrl string, consoleUrl string, verbose
 		return err
 	}
 
-	sortString := urlBuilder.CreateSortString(userID, epoch, full, isMachine, meta.Remote, meta.DirName, meta.CurrentBranch)
+	sortString := urlBuilder.CreateSortString(userID, epoch, j.Full, isMachine, j.Remote, j.DirName, j.Branch)
 
 	var consoleFullUrl *string
-	var consoleUrlErr error
-	if !full {
-		consoleFullUrl, consoleUrlErr = urlBuilder.Build(consoleUrl, "workspaces", workspaceID, "analysis", sortString, "result")
-		if consoleUrlErr != nil {
-			return consoleUrlErr
-		}
+	if !j.Full {
+		consoleFullUrl, err = urlBuilder.Build(j.ConsoleURL, "workspaces", workspaceID, "analysis", sortString, "result")
 	} else {
 		// /workspaces/{{workspaceID}}/risk-check/{{repo}}/{{sortKey}}/result
-		consoleFullUrl, consoleUrlErr = urlBuilder.Build(consoleUrl, "workspaces", workspaceID, "risk-check", meta.DirName, sortString, "result")
-		if consoleUrlErr != nil {
-			return consoleUrlErr
+		consoleFullUrl, err = urlBuilder.Build(j.ConsoleURL, "workspaces", workspaceID, "risk-check", j.DirName, sortString, "result")
+	}
+	if err != nil {
+		return err
+	}
+
+	j.Uploaded = true
+	j.SortKey = sortString
+	j.ResultURL = *consoleFullUrl
+	if err := j.save(); err != nil {
+		output.Debug("failed to update upload journal", "error", err)
+	}
+	return nil
+}
+
+// scanWorkspace returns the ID and description of the workspace scans
+// upload to: KUSARI_WORKSPACE, else the workspace stored for platformUrl,
+// else the first of the user's workspaces.
+func scanWorkspace(platformUrl, accessToken string,
+	defaultWorkspaceGetter func(platformUrl string, jwtToken string) ([]login.Workspace, map[string][]string, error)) (string, string, error) {
+	// Pass empty string for authEndpoint as it's not available during scans and only validated during login
+	storedWorkspace, err := auth.LoadWorkspace(platformUrl, "")
+	if err == nil && os.Getenv(auth.WorkspaceEnv) == "" {
+		output.Progressf(os.Stderr, "Using workspace: %s\n", storedWorkspace.Description)
+		return storedWorkspace.ID, storedWorkspace.Description, nil
+	}
+
+	// If no workspace is stored or platform changed, try to fetch and use first workspace
+	workspaces, _, err := defaultWorkspaceGetter(platformUrl, accessToken)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to get workspaces: %w. Please run `kusari auth login` to select a workspace", err)
+	}
+	// Use KUSARI_WORKSPACE or the first workspace as fallback (for CI/CD workflows)
+	selected, err := login.DefaultWorkspace(workspaces)
+	if err != nil {
+		return "", "", err
+	}
+	output.Progressf(os.Stderr, "Using workspace: %s\n", selected.Description)
+	return selected.ID, selected.Description, nil
+}
+
+// encryptScanBundle encrypts the bundle in tarballDir, of size bytes, to the
+// bundle key of workspace as encryption asks. It returns the ID of the key
+// used, empty when the bundle was left unencrypted, and the bundle's size.
+func encryptScanBundle(encryption BundleEncryption, platformUrl, accessToken, workspace, workspaceDescription string, size int64,
+	bundleKeyGetter func(platformUrl, jwtToken, workspace string) (*bundleKey, error)) (string, int64, error) {
+	if encryption == BundleEncryptionNone {
+		return "", size, nil
+	}
+	key, err := bundleKeyGetter(platformUrl, accessToken, workspace)
+	if err != nil {
+		return "", 0, fmt.Errorf("failed to get bundle key: %w", err)
+	}
+	switch {
+	case key != nil:
+		output.Progressf(os.Stderr, "Encrypting package with workspace key %s...\n", key.ID)
+		if size, err = encryptBundleFile(filepath.Join(tarballDir, tarballName), key); err != nil {
+			return "", 0, fmt.Errorf("failed to encrypt package: %w", err)
 		}
+		return key.ID, size, nil
+	case encryption == BundleEncryptionRequired:
+		return "", 0, clierrors.NewValidationError("workspace %s has no bundle encryption key; ask a workspace admin to create one, or scan without --bundle-encryption required", workspaceDescription)
+	default:
+		output.Debug("workspace has no bundle key; uploading unencrypted", "workspace", workspace)
+		return "", size, nil
 	}
+}
 
-	fmt.Fprint(os.Stderr, "Upload successful, your scan is processing!\n")
-	// We print the URL when it is completed, but that doesn't help if it fails
-	// for some reason and the user needs to contact support.
-	fmt.Fprintf(os.Stderr, "Once completed, you can see results at: %s\n", *consoleFullUrl)
+// warnDirty warns that uncommitted changes are about to be uploaded.
+func warnDirty(full bool) {
+	what := "the risk check"
+	if !full {
+		what = "the diff"
+	}
+	fmt.Fprintf(os.Stderr, "Warning: the working tree has uncommitted changes; they are included in %s and uploaded.\n", what)
+	fmt.Fprintf(os.Stderr, "         Pass --committed-only to analyze only what is committed.\n")
+}
 
-	// Wait for results if the user wants, or exit immediately
-	if wait {
-		return queryForResult(platformUrl, sortString, accessToken, consoleFullUrl, workspace, outputFormat, full, commentPlatform, verbose, dir, rev, fullOutput)
+// maxSummaryFiles caps the files listed in the pre-upload summary.
+const maxSummaryFiles = 10
+
+// printMetaSummary shows what is about to be uploaded: the HEAD author and,
+// for diff scans, the changed files with their line counts.
+func printMetaSummary(meta *api.BundleMeta) {
+	if meta.HeadAuthor != nil {
+		output.Progressf(os.Stderr, "HEAD by %s <%s>\n", meta.HeadAuthor.Name, meta.HeadAuthor.Email)
+	}
+	if meta.ScanType != "diff" {
+		return
+	}
+	added, deleted := 0, 0
+	for _, s := range meta.ChangedFileStats {
+		added += s.Added
+		deleted += s.Deleted
+	}
+	output.Progressf(os.Stderr, "Analyzing %d changed file(s), +%d -%d\n", len(meta.ChangedFileStats), added, deleted)
+	for i, s := range meta.ChangedFileStats {
+		if i == maxSummaryFiles {
+			output.Progressf(os.Stderr, "  ... and %d more\n", len(meta.ChangedFileStats)-i)
+			break
+		}
+		if s.Binary {
+			output.Progressf(os.Stderr, "  %-12s %s\n", "binary", s.Path)
+		} else {
+			output.Progressf(os.Stderr, "  %-12s %s\n", fmt.Sprintf("+%d -%d", s.Added, s.Deleted), s.Path)
+		}
+	}
+}
+
+// printNormalization notes the files whose patch lines were rewritten.
+func printNormalization(norm *api.PatchNormalization) {
+	if len(norm.CRLFFiles) > 0 {
+		output.Progressf(os.Stderr, "Normalized CRLF line endings in the patch of %d file(s)\n", len(norm.CRLFFiles))
+	}
+	if len(norm.Latin1Files) > 0 {
+		output.Progressf(os.Stderr, "Decoded non-UTF-8 lines as ISO-8859-1 in the patch of %d file(s): %s\n",
+			len(norm.Latin1Files), strings.Join(norm.Latin1Files, ", "))
 	}
-	return nil
+}
+
+// setupWorkingDirectory creates the temporary directory a bundle is built
+// in, and returns it.
+func setupWorkingDirectory() (string, error) {
+	// Create a temporary working directory
+	tempDir, err := os.MkdirTemp(os.TempDir(), "kusari-")
+	if err != nil {
+		return "", fmt.Errorf("failed to create temporary directory: %w", err)
+	}
+	// Create the path inside it for our metadata and patch files
+	workingDir = filepath.Join(tempDir, workingDirName)
+	err = os.Mkdir(workingDir, os.FileMode(0700))
+	if err != nil {
+		return "", fmt.Errorf("failed to create temporary directory: %w", err)
+	}
+	tarballDir = tempDir
+	metaName = filepath.Join(tarballDir, workingDirName, metaFile)
+	patchName = filepath.Join(tarballDir, workingDirName, patchFile)
+	return tempDir, nil
 }
 
 func cleanupWorkingDirectory(tempDir string) {
 	_ = os.RemoveAll(tempDir)
 }
 
-func queryForResult(platformUrl string, sortKey string, accessToken string, consoleFullUrl *string, workspace, outputFormat string, full bool, commentPlatform string, verbose bool, repoDir string, baseRef string, fullOutput bool) error {
+// queryForResult waits for the result at sortKey and writes it to outputs,
+// filtered, commented on and filed as opts asks. Diff scan results are
+// cached unless noCache is set, for scans the cache, keyed on the working
+// tree diff, can't describe.
+func queryForResult(opts ScanOptions, platformUrl string, sortKey string, accessToken string, consoleFullUrl *string, workspace string, outputs []Output, full bool, repoDir string, baseRef string, noCache bool) error {
 	maxAttempts := 750
 	attempt := 0
 	sleepDuration := time.Second
 
 	// Create spinner for stderr
-	s := spinner.New(spinner.CharSets[14], 100*time.Millisecond)
-	s.Writer = os.Stderr // Send spinner to stderr
-	s.Prefix = "Analysis in progress... "
-	s.FinalMSG = "✓ Results found!\n"
+	s := output.NewSpinner(os.Stderr) // Send spinner to stderr
+	s.SetPrefix("Analysis in progress... ")
+	s.SetFinal("✓ Results found!\n")
 	s.Start()
 
 	// Ensure spinner stops no matter what
 	defer s.Stop()
 
-	client := &http.Client{Timeout: 10 * time.Second}
+	client := newHTTPClient(10 * time.Second)
 
 	scanType := "scan"
 	if full {
@@ -341,7 +804,13 @@ func queryForResult(platformUrl string, sortKey string, accessToken string, cons
 
 		resp, err := client.Do(req)
 		if err != nil {
-			time.Sleep(sleepDuration)
+			// Retrying can't help once the --deadline passed.
+			if transport.IsDeadline(err) {
+				s.SetFinal("✗ Gave up waiting for results\n")
+				s.Stop()
+				return err
+			}
+			clk.Sleep(sleepDuration)
 			continue
 		}
 		defer func() {
@@ -351,102 +820,93 @@ func queryForResult(platformUrl string, sortKey string, accessToken string, cons
 		if resp.StatusCode == http.StatusOK {
 			body, err := io.ReadAll(resp.Body)
 			if err != nil {
-				time.Sleep(sleepDuration)
+				clk.Sleep(sleepDuration)
 				continue
 			}
 
 			var results []api.UserInspectorResult
 			if err := json.Unmarshal(body, &results); err != nil {
-				time.Sleep(sleepDuration)
+				clk.Sleep(sleepDuration)
 				continue
 			}
 
 			if len(results) > 0 {
 				if results[0].Analysis != nil {
 					// Stop spinner before outputting results
-					s.FinalMSG = "✓ Analysis complete!\n"
+					s.SetFinal("✓ Analysis complete!\n")
 					s.Stop()
+					warnNewerSchema(results[0].Analysis)
+					if results[0].Analysis.RawLLMAnalysis != nil {
+						results[0].Analysis.RawLLMAnalysis.SortFindings()
+					}
+					// Filter once, so the comment, tickets, saved result
+					// and outputs all leave out the same findings.
+					analysis := results[0].Analysis
+					filtered := opts.Filter.active() && analysis.RawLLMAnalysis != nil
+					if filtered {
+						analysis = opts.Filter.apply(analysis)
+					}
 
 					// Post comment to the specified platform (only for diff scans, not full scans)
-					if commentPlatform != "" && !full && results[0].Analysis.RawLLMAnalysis != nil {
-						if err := postCommentToPlatform(commentPlatform, results[0].Analysis.RawLLMAnalysis, consoleFullUrl, verbose); err != nil {
+					if opts.CommentPlatform != "" && !full && analysis.RawLLMAnalysis != nil {
+						if err := postCommentToPlatform(opts, analysis.RawLLMAnalysis, consoleFullUrl, repoDir); err != nil {
 							// Log error but don't fail the scan
-							fmt.Fprintf(os.Stderr, "Warning: Failed to post %s comment: %v\n", commentPlatform, err)
+							fmt.Fprintf(os.Stderr, "Warning: Failed to post %s comment: %v\n", opts.CommentPlatform, err)
 						}
 					}
 
-					if full {
-						printFullScanResults(results[0].Analysis)
-						return nil
+					// File ITSM records for blocked diff scans, when configured
+					if !full && analysis.RawLLMAnalysis != nil {
+						fileTickets(context.Background(), opts.TicketSinks, analysis.RawLLMAnalysis, *consoleFullUrl, repoDir, opts.Verbose)
 					}
 
-					// Check output format
-					if outputFormat == "sarif" {
-						// Output sarif format
-						sarifOutput, err := sarif.ConvertToSARIF(results[0].Analysis.RawLLMAnalysis, *consoleFullUrl)
-						if err != nil {
-							return fmt.Errorf("failed to convert to SARIF: %w", err)
-						}
-
-						fmt.Fprintf(os.Stderr, "You can also view your results here: %s\n", *consoleFullUrl)
-						fmt.Print(sarifOutput) // stdout
+					if full {
+						warnMissingChecks(analysis, opts.Checks)
+						analysis = filterHealth(analysis, opts.Checks)
+						markdown := fullScanMarkdown(analysis)
+						saved := saveResult(analysis, markdown, *consoleFullUrl, repoDir, "", full, opts.Verbose)
+						output.PrintMarkdown(markdown)
 
-						// Save to cache for diff scans
-						if !full && repoDir != "" {
-							if err := SaveToCache(repoDir, baseRef, sarifOutput, *consoleFullUrl, verbose); err != nil && verbose {
-								fmt.Fprintf(os.Stderr, "Warning: Failed to cache results: %v\n", err)
-							}
+						previous, err := previousFullScan(platformUrl, accessToken, workspace, sortKey)
+						if err != nil {
+							output.Debug("failed to fetch previous full scan", "error", err)
+						} else if previous != nil {
+							output.PrintMarkdown(riskCheckDiffMarkdown(filterHealth(previous.Analysis, opts.Checks), analysis, scanTime(previous.StatusMeta.UpdatedAt)))
 						}
+						opts.hooks().postResult(repoDir, saved)
 						return nil
 					}
 
-					// Clean and format results for stdout
+					// Clean the summary up front so the saved copy matches
+					// what is printed.
 					var rawContent string
-					if fullOutput {
+					if opts.FullOutput {
 						rawContent = results[0].Analysis.Results
 					} else {
 						rawContent = results[0].Analysis.TruncatedCommentWithCodeMitigations
 					}
 					rawContent = replaceConsoleLink(rawContent, *consoleFullUrl)
-					fmt.Fprintf(os.Stderr, "You can also view your results here: %s\n", *consoleFullUrl)
 					cleanedContent := removeImageLines(rawContent)
-
-					// Render with glamour to stdout
-					r, err := glamour.NewTermRenderer(
-						glamour.WithAutoStyle(),
-						glamour.WithWordWrap(100),
-					)
-					if err != nil {
-						fmt.Print(cleanedContent) // stdout
-						// Save to cache for diff scans (save cleaned content for re-rendering)
-						if !full && repoDir != "" {
-							if cacheErr := SaveToCache(repoDir, baseRef, cleanedContent, *consoleFullUrl, verbose); cacheErr != nil && verbose {
-								fmt.Fprintf(os.Stderr, "Warning: Failed to cache results: %v\n", cacheErr)
-							}
-						}
-						return nil
+					if filtered {
+						// The platform's summary lists every finding, so
+						// render the filtered ones instead.
+						cleanedContent = comment.FormatComment(analysis.RawLLMAnalysis, *consoleFullUrl)
 					}
+					saved := saveResult(analysis, cleanedContent, *consoleFullUrl, repoDir, baseRef, full, opts.Verbose)
 
-					rendered, err := r.Render(cleanedContent)
+					fmt.Fprintf(os.Stderr, "You can also view your results here: %s\n", output.Hyperlink(os.Stderr, *consoleFullUrl, *consoleFullUrl))
+					printed, err := writeOutputs(outputs, analysis, cleanedContent, *consoleFullUrl, repoDir)
 					if err != nil {
-						fmt.Print(cleanedContent) // stdout
-						// Save to cache for diff scans
-						if !full && repoDir != "" {
-							if cacheErr := SaveToCache(repoDir, baseRef, cleanedContent, *consoleFullUrl, verbose); cacheErr != nil && verbose {
-								fmt.Fprintf(os.Stderr, "Warning: Failed to cache results: %v\n", cacheErr)
-							}
-						}
-						return nil
+						return err
 					}
 
-					fmt.Print(rendered) // stdout
-
-					// Save to cache for diff scans (save rendered content for immediate reuse)
-					if !full && repoDir != "" {
-						if cacheErr := SaveToCache(repoDir, baseRef, rendered, *consoleFullUrl, verbose); cacheErr != nil && verbose {
+					// Save what was printed to the cache for diff scans
+					if repoDir != "" && !noCache && stdoutOnly(outputs) && !opts.Filter.active() {
+						if cacheErr := SaveToCache(repoDir, baseRef, printed, *consoleFullUrl, opts.Verbose); cacheErr != nil && opts.Verbose {
 							fmt.Fprintf(os.Stderr, "Warning: Failed to cache results: %v\n", cacheErr)
 						}
 					}
+					opts.hooks().postResult(repoDir, saved)
 					return nil
 				}
 
@@ -469,25 +929,25 @@ func queryForResult(platformUrl string, sortKey string, accessToken string, cons
 					prefix = strings.ToUpper(results[0].StatusMeta.Status[:1]) + results[0].StatusMeta.Status[1:]
 				}
 
-				s.Prefix = prefix + " "
+				s.SetPrefix(prefix + " ")
 
 				if status == "failed" {
-					s.FinalMSG = prefix
+					s.SetFinal(prefix)
 					s.Stop()
 					fmt.Fprintln(os.Stderr)
-					if results[0].StatusMeta.Details != "" {
-						fmt.Fprintf(os.Stderr, "Error: %s\n", results[0].StatusMeta.Details)
+					return &clierrors.AnalysisFailedError{
+						Message: "processing failed after uploading",
+						Details: results[0].StatusMeta.Details,
 					}
-					return errors.New("processing failed after uploading")
 				}
 			}
 		}
 
-		time.Sleep(sleepDuration)
+		clk.Sleep(sleepDuration)
 	}
 
 	// If we get here, we failed
-	s.FinalMSG = "✗ No results found after maximum attempts\n"
+	s.SetFinal("✗ No results found after maximum attempts\n")
 	s.Stop()
 	return fmt.Errorf("no results found after %d attempts", maxAttempts)
 }
@@ -712,7 +1172,8 @@ func removeImageLines(content string) string {
 	return strings.TrimSpace(result)
 }
 
-func printFullScanResults(a *api.Analysis) {
+// fullScanMarkdown formats the results of a risk check as markdown.
+func fullScanMarkdown(a *api.Analysis) string {
 	sb := new(strings.Builder)
 
 	fmt.Fprintf(sb, "## Overall Score: %d/5\n", a.Score)
@@ -743,43 +1204,55 @@ func printFullScanResults(a *api.Analysis) {
 		fmt.Fprintln(sb)
 	}
 
-	cleanedContent := removeImageLines(sb.String())
-	r, err := glamour.NewTermRenderer(
-		glamour.WithAutoStyle(),
-		glamour.WithWordWrap(100),
-	)
-	if err != nil {
-		fmt.Print(cleanedContent) // stdout
-		return
-	}
-
-	rendered, err := r.Render(cleanedContent)
-	if err != nil {
-		fmt.Print(cleanedContent) // stdout
-		return
-	}
-
-	fmt.Print(rendered) // stdout
+	return removeImageLines(sb.String())
 }
 
 func titleize(s string) string {
 	return strings.ToUpper(s[0:1]) + s[1:]
 }
 
-// postCommentToPlatform dispatches comment posting to the appropriate platform
-func postCommentToPlatform(platform string, analysis *api.SecurityAnalysis, consoleURL *string, verbose bool) error {
-	switch platform {
+// postCommentToPlatform dispatches comment posting to opts.CommentPlatform,
+// following the comment settings in the repository's kusari.yaml
+func postCommentToPlatform(opts ScanOptions, analysis *api.SecurityAnalysis, consoleURL *string, repoDir string) error {
+	cfg, err := configuration.Load(repoDir)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: %v, using default comment settings\n", err)
+	}
+	if err := comment.SetSnippets(cfg.CommentTemplates); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: %v, using the default comment template\n", err)
+	}
+
+	if opts.CommentDryRun != "" {
+		return previewComment(opts.CommentDryRun, opts.CommentPlatform, analysis, consoleURL, cfg)
+	}
+
+	switch opts.CommentPlatform {
 	case PlatformGitLab:
-		return postToGitLab(analysis, consoleURL, verbose)
+		return postToGitLab(analysis, consoleURL, cfg, opts.Verbose)
 	case PlatformGitHub:
-		return postToGitHub(analysis, consoleURL, verbose)
+		return postToGitHub(analysis, consoleURL, cfg, opts.Verbose)
 	default:
-		return fmt.Errorf("unsupported comment platform: %s (supported: %s, %s)", platform, PlatformGitLab, PlatformGitHub)
+		return fmt.Errorf("unsupported comment platform: %s (supported: %s, %s)", opts.CommentPlatform, PlatformGitLab, PlatformGitHub)
+	}
+}
+
+// printCommentResult reports the outcome of posting a comment. Its warnings
+// are printed with verbose; otherwise only failed inline comments are.
+func printCommentResult(result *comment.CommentResult, verbose bool) {
+	if result.Posted || verbose {
+		fmt.Fprintf(os.Stderr, "%s\n", result.Message)
+	}
+	if verbose {
+		for _, w := range result.Warnings {
+			fmt.Fprintf(os.Stderr, "Warning: %v\n", w)
+		}
+	} else if failed := result.InlineFailed(); failed > 0 {
+		fmt.Fprintf(os.Stderr, "Warning: %d inline comment(s) could not be posted (run with --verbose for details)\n", failed)
 	}
 }
 
 // postToGitLab posts scan results as a comment to a GitLab merge request
-func postToGitLab(analysis *api.SecurityAnalysis, consoleURL *string, verbose bool) error {
+func postToGitLab(analysis *api.SecurityAnalysis, consoleURL *string, cfg apiconfig.Config, verbose bool) error {
 	// Get GitLab configuration from environment
 	projectID, mrIID := gitlab.GetMRInfoFromEnv()
 	if projectID == "" || mrIID == "" {
@@ -791,7 +1264,10 @@ func postToGitLab(analysis *api.SecurityAnalysis, consoleURL *string, verbose bo
 
 	token := gitlab.GetTokenFromEnv()
 	if token == "" {
-		return fmt.Errorf("no GitLab token found (set GITLAB_TOKEN or CI_JOB_TOKEN)")
+		return fmt.Errorf("no GitLab token found (set GITLAB_TOKEN, GITLAB_OAUTH_TOKEN or CI_JOB_TOKEN)")
+	}
+	if err := gitlab.CheckTokenScopes(gitlab.GetGitLabAPIURLFromEnv(), token, gitlab.ScopeAPI); err != nil {
+		return err
 	}
 
 	consoleURLStr := ""
@@ -800,30 +1276,46 @@ func postToGitLab(analysis *api.SecurityAnalysis, consoleURL *string, verbose bo
 	}
 
 	opts := gitlab.CommentOptions{
-		ProjectID:   projectID,
-		MergeReqIID: mrIID,
-		GitLabURL:   gitlab.GetGitLabAPIURLFromEnv(),
-		Token:       token,
-		ConsoleURL:  consoleURLStr,
-		Verbose:     verbose,
+		ProjectID:       projectID,
+		MergeReqIID:     mrIID,
+		GitLabURL:       gitlab.GetGitLabAPIURLFromEnv(),
+		Token:           token,
+		ConsoleURL:      consoleURLStr,
+		ResolvePrevious: cfg.CommentMode == apiconfig.CommentModeMinimize,
+		Compact:         cfg.CommentStyle == apiconfig.CommentStyleCompact,
+		Run:             comment.Run{Key: gitlab.GetRunKeyFromEnv(), ID: transport.RequestID()},
+		Verbose:         verbose,
 	}
 
-	result, err := gitlab.PostComment(analysis, opts)
+	post := gitlab.PostComment
+	if cfg.CommentStyle == apiconfig.CommentStyleReaction {
+		post = gitlab.PostReaction
+	}
+	result, err := post(analysis, opts)
 	if err != nil {
 		return err
 	}
 
-	if result.Posted {
-		fmt.Fprintf(os.Stderr, "%s\n", result.Message)
-	} else if verbose {
-		fmt.Fprintf(os.Stderr, "%s\n", result.Message)
+	printCommentResult(result, verbose)
+
+	if cfg.PRLabelsEnabled {
+		// Best-effort: the comment is what matters
+		if err := gitlab.SetLabels(analysis, opts, prLabels(cfg)); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+		}
+	}
+
+	if len(cfg.SecurityReviewers) > 0 && comment.NeedsSecurityReview(analysis, cfg.SecurityReviewThreshold) {
+		if err := gitlab.RequestReviewers(opts, cfg.SecurityReviewers); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+		}
 	}
 
 	return nil
 }
 
 // postToGitHub posts scan results as a comment to a GitHub pull request
-func postToGitHub(analysis *api.SecurityAnalysis, consoleURL *string, verbose bool) error {
+func postToGitHub(analysis *api.SecurityAnalysis, consoleURL *string, cfg apiconfig.Config, verbose bool) error {
 	// Get GitHub configuration from environment
 	owner, repo, prNumber := github.GetPRInfoFromEnv()
 	if owner == "" || repo == "" || prNumber == 0 {
@@ -844,25 +1336,129 @@ func postToGitHub(analysis *api.SecurityAnalysis, consoleURL *string, verbose bo
 	}
 
 	opts := github.CommentOptions{
-		Owner:      owner,
-		Repo:       repo,
-		PRNumber:   prNumber,
-		GitHubURL:  github.GetGitHubAPIURLFromEnv(),
-		Token:      token,
-		ConsoleURL: consoleURLStr,
-		Verbose:    verbose,
+		Owner:            owner,
+		Repo:             repo,
+		PRNumber:         prNumber,
+		GitHubURL:        github.GetGitHubAPIURLFromEnv(),
+		GraphQLURL:       github.GetGitHubGraphQLURLFromEnv(),
+		GraphQL:          true,
+		Token:            token,
+		ConsoleURL:       consoleURLStr,
+		MinimizePrevious: cfg.CommentMode == apiconfig.CommentModeMinimize,
+		Compact:          cfg.CommentStyle == apiconfig.CommentStyleCompact,
+		Run:              comment.Run{Key: github.GetRunKeyFromEnv(), ID: transport.RequestID()},
+		Verbose:          verbose,
 	}
 
-	result, err := github.PostComment(analysis, opts)
+	post := github.PostComment
+	if cfg.CommentStyle == apiconfig.CommentStyleReaction {
+		post = github.PostReaction
+	}
+	result, err := post(analysis, opts)
 	if err != nil {
 		return err
 	}
 
-	if result.Posted {
-		fmt.Fprintf(os.Stderr, "%s\n", result.Message)
-	} else if verbose {
-		fmt.Fprintf(os.Stderr, "%s\n", result.Message)
+	printCommentResult(result, verbose)
+
+	if cfg.PRLabelsEnabled {
+		// Best-effort: the comment is what matters
+		if err := github.SetLabels(analysis, opts, prLabels(cfg)); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+		}
+	}
+
+	if len(cfg.SecurityReviewers) > 0 && comment.NeedsSecurityReview(analysis, cfg.SecurityReviewThreshold) {
+		if err := github.RequestReviewers(opts, cfg.SecurityReviewers); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+		}
 	}
 
 	return nil
 }
+
+// TicketSinks are where blocked diff scans are filed. They only ever come
+// from the CLI's flags and environment: a kusari.yaml, which whoever
+// authored the change controls, could otherwise send the CI's credentials
+// anywhere.
+type TicketSinks struct {
+	// ServiceNowURL is the instance to file records in.
+	ServiceNowURL string
+	// WebhookURL receives a POST of the ticket payload.
+	WebhookURL string
+	// WebhookHeaders are sent with it; see itsm.Webhook.
+	WebhookHeaders map[string]string
+}
+
+// fileTickets files a ServiceNow record and/or calls the ticket webhook
+// of sinks when analysis should not proceed, with the table, fields and
+// template of the repository's kusari.yaml. The zero sinks file nothing.
+// Failures are reported as warnings; they never fail the scan.
+func fileTickets(ctx context.Context, sinks TicketSinks, analysis *api.SecurityAnalysis, consoleURL, repoDir string, verbose bool) {
+	if analysis.ShouldProceed || analysis.FailedAnalysis {
+		return
+	}
+	if sinks.ServiceNowURL == "" && sinks.WebhookURL == "" {
+		return
+	}
+	cfg, err := configuration.Load(repoDir)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: %v, filing tickets with the defaults\n", err)
+	}
+
+	repository, change := ciChange()
+	ticket := itsm.NewTicket(analysis, repository, change, consoleURL)
+
+	if sinks.ServiceNowURL != "" {
+		sn, err := itsm.NewServiceNowFromEnv(sinks.ServiceNowURL, cfg.ServiceNowTable, cfg.ServiceNowFields)
+		if err == nil {
+			var number string
+			var created bool
+			number, created, err = sn.File(ctx, ticket)
+			if created {
+				fmt.Fprintf(os.Stderr, "Filed ServiceNow %s %s\n", cfg.ServiceNowTable, number)
+			} else if err == nil && verbose {
+				fmt.Fprintf(os.Stderr, "ServiceNow %s %s is already open for this change\n", cfg.ServiceNowTable, number)
+			}
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+		}
+	}
+
+	if sinks.WebhookURL != "" {
+		hook := itsm.Webhook{URL: sinks.WebhookURL, Headers: sinks.WebhookHeaders, Template: cfg.TicketWebhookTemplate}
+		if err := hook.Send(ctx, ticket); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+		} else if verbose {
+			fmt.Fprintf(os.Stderr, "Sent ticket webhook\n")
+		}
+	}
+}
+
+// ciChange names the repository and pull or merge request being scanned,
+// from the GitHub Actions or GitLab CI environment.
+func ciChange() (repository, change string) {
+	if owner, repo, pr := github.GetPRInfoFromEnv(); owner != "" && repo != "" {
+		if pr != 0 {
+			change = fmt.Sprintf("#%d", pr)
+		}
+		return owner + "/" + repo, change
+	}
+	if _, mrIID := gitlab.GetMRInfoFromEnv(); os.Getenv("CI_PROJECT_PATH") != "" {
+		if mrIID != "" {
+			change = "!" + mrIID
+		}
+		return os.Getenv("CI_PROJECT_PATH"), change
+	}
+	return "", ""
+}
+
+// prLabels returns the outcome labels configured in cfg
+func prLabels(cfg apiconfig.Config) comment.Labels {
+	return comment.Labels{
+		Passed:      cfg.PRLabelPassed,
+		Blocked:     cfg.PRLabelBlocked,
+		NeedsReview: cfg.PRLabelNeedsReview,
+	}
+}
@@ -6,8 +6,11 @@ package repo
 import (
 	"archive/tar"
 	"compress/bzip2"
+	"context"
 	"encoding/json"
 	"io"
+	"net/http"
+	"net/http/httptest"
 	"os"
 	"os/exec"
 	"path/filepath"
@@ -56,7 +59,7 @@ func TestScan_ArchiveFormat(t *testing.T) {
 
 				return nil
 			},
-			presignedURLGetter: func(apiEndpoint string, jwtToken string, filePath, workspace string, full bool, size int64) (string, error) {
+			presignedURLGetter: func(apiEndpoint string, jwtToken string, filePath, workspace string, scanType string, size int64) (string, error) {
 				return "https://example.com/workspace/test-workspace-id/user/human/test-user-id/diff/blob/123", nil
 			},
 			defaultWorkspaceGetter: func(platformUrl string, jwtToken string) ([]login.Workspace, map[string][]string, error) {
@@ -73,7 +76,7 @@ func TestScan_ArchiveFormat(t *testing.T) {
 		}
 
 		// Run the scan with dependencies injection
-		err := scan(testDir, "HEAD", "https://platform.example.com", "https://console.example.com", false, false, full, "markdown", "", false, "", mock)
+		err := scan(ScanOptions{Dir: testDir, Rev: "HEAD", PlatformURL: "https://platform.example.com", ConsoleURL: "https://console.example.com", OutputFormat: "markdown"}, full, mock)
 		require.NoError(t, err)
 
 		// Verify upload was called
@@ -142,7 +145,7 @@ func TestScan_OverrideBranchValidation(t *testing.T) {
 				fileUploader: func(presignedURL, filePath string) error {
 					return nil
 				},
-				presignedURLGetter: func(apiEndpoint string, jwtToken string, filePath, workspace string, full bool, size int64) (string, error) {
+				presignedURLGetter: func(apiEndpoint string, jwtToken string, filePath, workspace string, scanType string, size int64) (string, error) {
 					return "https://example.com/workspace/test-workspace-id/user/human/test-user-id/diff/blob/123", nil
 				},
 				defaultWorkspaceGetter: func(platformUrl string, jwtToken string) ([]login.Workspace, map[string][]string, error) {
@@ -155,8 +158,7 @@ func TestScan_OverrideBranchValidation(t *testing.T) {
 				token: "token",
 			}
 
-			err := scan(testDir, "HEAD", "https://platform.example.com", "https://console.example.com",
-				false, false, false, "markdown", "", false, tt.overrideBranch, mock)
+			err := scan(ScanOptions{Dir: testDir, Rev: "HEAD", PlatformURL: "https://platform.example.com", ConsoleURL: "https://console.example.com", OutputFormat: "markdown", OverrideBranch: tt.overrideBranch}, false, mock)
 
 			if tt.wantErr {
 				require.Error(t, err)
@@ -540,7 +542,7 @@ func TestMonoRepoCheck_OnlyForRiskCheck(t *testing.T) {
 		fileUploader: func(presignedURL, filePath string) error {
 			return nil
 		},
-		presignedURLGetter: func(apiEndpoint string, jwtToken string, filePath, workspace string, full bool, size int64) (string, error) {
+		presignedURLGetter: func(apiEndpoint string, jwtToken string, filePath, workspace string, scanType string, size int64) (string, error) {
 			return "https://example.com/workspace/test-workspace-id/user/human/test-user-id/diff/blob/123", nil
 		},
 		defaultWorkspaceGetter: func(platformUrl string, jwtToken string) ([]login.Workspace, map[string][]string, error) {
@@ -558,7 +560,7 @@ func TestMonoRepoCheck_OnlyForRiskCheck(t *testing.T) {
 
 	t.Run("diff scan should succeed on monorepo", func(t *testing.T) {
 		// Diff scan (full=false) should succeed even with monorepo
-		err := scan(testDir, "HEAD", "https://platform.example.com", "https://console.example.com", false, false, false, "markdown", "", false, "", mock)
+		err := scan(ScanOptions{Dir: testDir, Rev: "HEAD", PlatformURL: "https://platform.example.com", ConsoleURL: "https://console.example.com", OutputFormat: "markdown"}, false, mock)
 		assert.NoError(t, err, "diff scan should succeed on monorepo")
 	})
 
@@ -572,3 +574,85 @@ func TestMonoRepoCheck_OnlyForRiskCheck(t *testing.T) {
 		assert.Contains(t, indicators, "monorepo config: lerna.json", "should detect lerna.json")
 	})
 }
+
+func TestScan_PatchOnly(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	testDir := t.TempDir()
+	runCmd(t, testDir, "git", "init")
+	runCmd(t, testDir, "git", "config", "user.email", "test@example.com")
+	runCmd(t, testDir, "git", "config", "user.name", "Test User")
+	writeFile(t, filepath.Join(testDir, "test.txt"), "test content")
+	writeFile(t, filepath.Join(testDir, "other.txt"), "unchanged")
+	runCmd(t, testDir, "git", "add", ".")
+	runCmd(t, testDir, "git", "commit", "-m", "initial commit")
+	writeFile(t, filepath.Join(testDir, "test.txt"), "uncommitted change")
+
+	var scanType string
+	var files []string
+	var meta api.BundleMeta
+	mock := &scanMock{
+		fileUploader: func(presignedURL, filePath string) error {
+			files = extractTarballContents(t, filePath)
+			f, err := os.Open(filePath)
+			require.NoError(t, err)
+			defer func() { _ = f.Close() }()
+			tr := tar.NewReader(bzip2.NewReader(f))
+			for {
+				hdr, err := tr.Next()
+				if err == io.EOF {
+					break
+				}
+				require.NoError(t, err)
+				if hdr.Name == metaFile {
+					require.NoError(t, json.NewDecoder(tr).Decode(&meta))
+				}
+			}
+			return nil
+		},
+		presignedURLGetter: func(apiEndpoint string, jwtToken string, filePath, workspace string, st string, size int64) (string, error) {
+			scanType = st
+			return "https://example.com/workspace/test-workspace-id/user/human/test-user-id/diff/blob/123", nil
+		},
+		defaultWorkspaceGetter: func(platformUrl string, jwtToken string) ([]login.Workspace, map[string][]string, error) {
+			return []login.Workspace{{ID: "ws-1", Description: "Test Workspace"}}, nil, nil
+		},
+		token: "token",
+	}
+
+	err := scan(ScanOptions{Dir: testDir, Rev: "HEAD", PlatformURL: "https://platform.example.com", ConsoleURL: "https://console.example.com", OutputFormat: "markdown", PatchOnly: true}, false, mock)
+	require.NoError(t, err)
+
+	assert.Equal(t, "patch", scanType)
+	assert.ElementsMatch(t, []string{metaFile, patchFile, manifestFile}, files)
+	assert.Equal(t, "patch", meta.ScanType)
+	assert.Equal(t, []string{"test.txt"}, meta.ChangedFiles)
+}
+
+func TestScan_StagedAndCommittedOnly(t *testing.T) {
+	err := scan(ScanOptions{Dir: t.TempDir(), Rev: "HEAD", PlatformURL: "https://platform.example.com", ConsoleURL: "https://console.example.com", OutputFormat: "markdown", Staged: true, CommittedOnly: true}, false, &scanMock{})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "can't be used together")
+}
+
+func TestFileTicketsSinksFromFlagsOnly(t *testing.T) {
+	var hits int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits++
+		assert.Equal(t, "Bearer itsm", r.Header.Get("Authorization"))
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer server.Close()
+	t.Setenv("KUSARI_ITSM_TOKEN", "itsm")
+
+	repoDir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(repoDir, "kusari.yaml"), []byte("ticket_webhook_url: "+server.URL+"\n"), 0644))
+	analysis := &api.SecurityAnalysis{Justification: "Hardcoded credentials"}
+
+	// The scanned repository can't choose where tickets go.
+	fileTickets(context.Background(), TicketSinks{}, analysis, "", repoDir, false)
+	assert.Equal(t, 0, hits)
+
+	sinks := TicketSinks{WebhookURL: server.URL, WebhookHeaders: map[string]string{"Authorization": "Bearer ${KUSARI_ITSM_TOKEN}"}}
+	fileTickets(context.Background(), sinks, analysis, "", repoDir, false)
+	assert.Equal(t, 1, hits)
+}
@@ -0,0 +1,199 @@
+// Copyright (c) Kusari <https://www.kusari.dev/>
+// SPDX-License-Identifier: MIT
+
+package repo
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/kusaridev/kusari-cli/v2/api"
+	"github.com/kusaridev/kusari-cli/v2/pkg/clierrors"
+	"github.com/kusaridev/kusari-cli/v2/pkg/output"
+)
+
+// DefaultMaxFileSize is the size above which source files are left out
+// of packages unless ScanOptions.MaxFileSize says otherwise.
+const DefaultMaxFileSize = 10 << 20
+
+// binaryExtensions are the extensions of files taken to be binary without
+// looking at their content.
+var binaryExtensions = map[string]bool{
+	".png": true, ".jpg": true, ".jpeg": true, ".gif": true, ".bmp": true, ".ico": true,
+	".webp": true, ".tif": true, ".tiff": true, ".psd": true,
+	".zip": true, ".tar": true, ".gz": true, ".tgz": true, ".bz2": true, ".xz": true,
+	".7z": true, ".rar": true,
+	".exe": true, ".a": true, ".o": true,
+	".obj": true, ".lib": true, ".class": true, ".pyc": true, ".pyo": true, ".wasm": true,
+	".pdf": true, ".docx": true, ".xlsx": true, ".pptx": true,
+	".mp3": true, ".mp4": true, ".mov": true, ".avi": true, ".wav": true, ".flac": true, ".ogg": true,
+	".woff": true, ".woff2": true, ".ttf": true, ".otf": true, ".eot": true,
+	".iso": true, ".dmg": true, ".apk": true, ".ipa": true, ".deb": true, ".rpm": true,
+	".sqlite": true, ".db": true, ".parquet": true, ".pkl": true, ".npy": true, ".onnx": true, ".pt": true, ".h5": true,
+}
+
+// dependencyExtensions are the extensions of vendored dependencies, such
+// as Java archives, Python wheels and shared libraries. They are binary,
+// but the analysis needs them to know what the code depends on, so the
+// binary rule keeps them.
+var dependencyExtensions = map[string]bool{
+	".jar": true, ".war": true, ".ear": true, ".whl": true,
+	".so": true, ".dll": true, ".dylib": true,
+}
+
+// dependencyFiles are the manifests and lockfiles of package managers,
+// packaged whatever their size: large projects have lockfiles above the
+// --max-file-size default, and without them their dependencies are
+// unknown.
+var dependencyFiles = map[string]bool{
+	"go.mod": true, "go.sum": true,
+	"package.json": true, "package-lock.json": true, "npm-shrinkwrap.json": true, "yarn.lock": true, "pnpm-lock.yaml": true, "bun.lock": true,
+	"requirements.txt": true, "pyproject.toml": true, "poetry.lock": true, "Pipfile": true, "Pipfile.lock": true, "uv.lock": true,
+	"Cargo.toml": true, "Cargo.lock": true,
+	"Gemfile": true, "Gemfile.lock": true,
+	"composer.json": true, "composer.lock": true,
+	"pom.xml": true, "build.gradle": true, "build.gradle.kts": true, "gradle.lockfile": true,
+	"packages.lock.json": true, "pubspec.lock": true, "mix.lock": true, "Package.resolved": true,
+}
+
+// sniffLen is how much of a file is read to tell whether it is binary,
+// as much as git reads.
+const sniffLen = 8000
+
+// binaryExtension reports whether path has the extension of a binary file.
+func binaryExtension(path string) bool {
+	return binaryExtensions[strings.ToLower(filepath.Ext(path))]
+}
+
+// binaryContent reports whether the file at path looks binary: like git,
+// whether its first bytes hold a NUL.
+func binaryContent(path string) bool {
+	f, err := os.Open(path)
+	if err != nil {
+		return false
+	}
+	defer func() { _ = f.Close() }()
+	head := make([]byte, sniffLen)
+	n, _ := io.ReadFull(f, head)
+	return bytes.IndexByte(head[:n], 0) >= 0
+}
+
+// sizeUnits are the suffixes ParseSize accepts, longest first.
+var sizeUnits = []struct {
+	suffix string
+	size   int64
+}{
+	{"KiB", 1 << 10}, {"MiB", 1 << 20}, {"GiB", 1 << 30},
+	{"KB", 1 << 10}, {"MB", 1 << 20}, {"GB", 1 << 30},
+	{"K", 1 << 10}, {"M", 1 << 20}, {"G", 1 << 30},
+	{"B", 1},
+}
+
+// ParseSize parses a user-supplied size such as "10MB" or "512KiB", in
+// binary units; a bare number is bytes.
+func ParseSize(s string) (int64, error) {
+	num, unit := strings.TrimSpace(s), int64(1)
+	for _, u := range sizeUnits {
+		if n, ok := strings.CutSuffix(num, u.suffix); ok {
+			num, unit = strings.TrimSpace(n), u.size
+			break
+		}
+	}
+	n, err := strconv.ParseFloat(num, 64)
+	if err != nil || n < 0 {
+		return 0, clierrors.NewValidationError("invalid size %q (e.g. 10MB, 512KiB or 0 for no limit)", s)
+	}
+	return int64(n * float64(unit)), nil
+}
+
+// packageEntry is a source file a package would hold. Binary is set when
+// its content was found to be binary.
+type packageEntry struct {
+	Path   string
+	Size   int64
+	Binary bool
+}
+
+// maxFileSize returns the size above which source files are left out of
+// the package, 0 when every file is packaged.
+func (o ScanOptions) maxFileSize() int64 {
+	switch {
+	case o.MaxFileSize == 0:
+		return DefaultMaxFileSize
+	case o.MaxFileSize < 0:
+		return 0
+	}
+	return o.MaxFileSize
+}
+
+// skipFiles splits entries into the paths to package and the files to
+// leave out. Manifests and lockfiles are never left out for their size,
+// nor vendored dependencies for being binary.
+func (o ScanOptions) skipFiles(entries []packageEntry) ([]string, []api.SkippedFile) {
+	maxSize := o.maxFileSize()
+	var kept []string
+	var skipped []api.SkippedFile
+	for _, e := range entries {
+		if maxSize > 0 && e.Size > maxSize && !dependencyFiles[filepath.Base(e.Path)] {
+			skipped = append(skipped, api.SkippedFile{Path: e.Path, Size: e.Size, Reason: api.SkipReasonSize})
+			continue
+		}
+		if !o.IncludeBinaries && (e.Binary || binaryExtension(e.Path)) && !dependencyExtensions[strings.ToLower(filepath.Ext(e.Path))] {
+			skipped = append(skipped, api.SkippedFile{Path: e.Path, Size: e.Size, Reason: api.SkipReasonBinary})
+			continue
+		}
+		kept = append(kept, e.Path)
+	}
+	return kept, skipped
+}
+
+// noteSkipped lists the files left out of the package and records them in
+// the meta file, so the analysis knows they exist. maxSize is the size
+// limit they were left out above.
+func noteSkipped(skipped []api.SkippedFile, maxSize int64) error {
+	if len(skipped) == 0 {
+		return nil
+	}
+	var large []api.SkippedFile
+	binaries := 0
+	for _, s := range skipped {
+		if s.Reason == api.SkipReasonBinary {
+			binaries++
+		} else {
+			large = append(large, s)
+		}
+	}
+	if len(large) > 0 {
+		output.Progressf(os.Stderr, "Skipped %d file(s) larger than %s (--max-file-size):\n", len(large), output.FormatSize(maxSize))
+		for _, s := range large {
+			output.Progressf(os.Stderr, "  %s (%s)\n", s.Path, output.FormatSize(s.Size))
+		}
+	}
+	if binaries > 0 && output.Verbose() {
+		output.Progressf(os.Stderr, "Skipped %d binary file(s) (--include-binaries packages them)\n", binaries)
+	}
+
+	// Kept as a map, so every field already written stays as it is.
+	data, err := os.ReadFile(metaName)
+	if err != nil {
+		return fmt.Errorf("failed to read meta file: %w", err)
+	}
+	var meta map[string]any
+	if err := json.Unmarshal(data, &meta); err != nil {
+		return fmt.Errorf("failed to parse meta file: %w", err)
+	}
+	meta["skipped_files"] = skipped
+	if data, err = json.Marshal(meta); err != nil {
+		return fmt.Errorf("failed to marshal json meta: %w", err)
+	}
+	if err := os.WriteFile(metaName, data, 0600); err != nil {
+		return fmt.Errorf("failed to write meta file: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,129 @@
+// Copyright (c) Kusari <https://www.kusari.dev/>
+// SPDX-License-Identifier: MIT
+
+package repo
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/kusaridev/kusari-cli/v2/api"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseSize(t *testing.T) {
+	tests := []struct {
+		in   string
+		want int64
+	}{
+		{"0", 0},
+		{"512", 512},
+		{"10MB", 10 << 20},
+		{"10MiB", 10 << 20},
+		{"1.5 KB", 1536},
+		{"2G", 2 << 30},
+		{"100B", 100},
+	}
+	for _, tt := range tests {
+		got, err := ParseSize(tt.in)
+		require.NoError(t, err, tt.in)
+		assert.Equal(t, tt.want, got, tt.in)
+	}
+	for _, in := range []string{"", "ten", "-1MB", "10XB"} {
+		_, err := ParseSize(in)
+		assert.Error(t, err, in)
+	}
+}
+
+func TestSkipFiles(t *testing.T) {
+	kept, skipped := ScanOptions{MaxFileSize: 100}.skipFiles([]packageEntry{
+		{Path: "main.go", Size: 10},
+		{Path: "data/big.csv", Size: 400},
+		{Path: "package-lock.json", Size: 400},
+		{Path: "web/yarn.lock", Size: 400},
+		{Path: "web/pnpm-lock.yaml", Size: 400},
+		{Path: "go.sum", Size: 400},
+		{Path: "logo.png", Size: 10},
+		{Path: "model.bin", Size: 10, Binary: true},
+		{Path: "lib/guava.jar", Size: 10, Binary: true},
+		{Path: "vendor/requests-2.32.0-py3-none-any.whl", Size: 10, Binary: true},
+		{Path: "lib/libssl.so", Size: 10, Binary: true},
+		{Path: "lib/huge.jar", Size: 400, Binary: true},
+	})
+	assert.Equal(t, []string{
+		"main.go", "package-lock.json", "web/yarn.lock", "web/pnpm-lock.yaml", "go.sum",
+		"lib/guava.jar", "vendor/requests-2.32.0-py3-none-any.whl", "lib/libssl.so",
+	}, kept, "lockfiles are kept whatever their size, vendored dependencies though binary")
+	assert.Equal(t, []api.SkippedFile{
+		{Path: "data/big.csv", Size: 400, Reason: api.SkipReasonSize},
+		{Path: "logo.png", Size: 10, Reason: api.SkipReasonBinary},
+		{Path: "model.bin", Size: 10, Reason: api.SkipReasonBinary},
+		{Path: "lib/huge.jar", Size: 400, Reason: api.SkipReasonSize},
+	}, skipped)
+
+	big := []packageEntry{{Path: "data/big.csv", Size: DefaultMaxFileSize + 1}}
+	_, skipped = ScanOptions{}.skipFiles(big)
+	assert.Len(t, skipped, 1, "the zero options leave out files above DefaultMaxFileSize")
+	_, skipped = ScanOptions{MaxFileSize: -1}.skipFiles(big)
+	assert.Empty(t, skipped, "a negative size packages every file")
+}
+
+func TestPackageDirectory_SkippedFiles(t *testing.T) {
+	for _, source := range []packageSource{sourceWorkingTree, sourceHead} {
+		repoDir := t.TempDir()
+		tempDir := t.TempDir()
+		tarballDir = tempDir
+		workingDir = filepath.Join(tempDir, workingDirName)
+		require.NoError(t, os.Mkdir(workingDir, 0700))
+		metaName = filepath.Join(workingDir, metaFile)
+		patchName = filepath.Join(workingDir, patchFile)
+
+		t.Chdir(repoDir)
+
+		runCmd(t, repoDir, "git", "init")
+		runCmd(t, repoDir, "git", "config", "user.email", "test@example.com")
+		runCmd(t, repoDir, "git", "config", "user.name", "Test User")
+		writeFile(t, filepath.Join(repoDir, "main.go"), "package main")
+		require.NoError(t, os.Mkdir(filepath.Join(repoDir, "data"), 0700))
+		writeFile(t, filepath.Join(repoDir, "data", "big set.csv"), strings.Repeat("a,b\n", 100))
+		writeFile(t, filepath.Join(repoDir, "logo.PNG"), "not sniffed")
+		writeFile(t, filepath.Join(repoDir, "model.bin"), "weights\x00")
+		runCmd(t, repoDir, "git", "add", ".")
+		runCmd(t, repoDir, "git", "commit", "-m", "initial commit")
+		writeFile(t, metaName, `{"scan_type": "diff"}`)
+		writeFile(t, patchName, "patch content")
+
+		_, err := packageDirectory(ScanOptions{MaxFileSize: 100}, false, source)
+		require.NoError(t, err)
+
+		files := extractTarballContents(t, filepath.Join(tarballDir, tarballName))
+		assert.Contains(t, files, "main.go")
+		assert.NotContains(t, files, "data/big set.csv")
+		assert.NotContains(t, files, "logo.PNG")
+		assert.NotContains(t, files, "model.bin")
+
+		data, err := os.ReadFile(metaName)
+		require.NoError(t, err)
+		var meta api.BundleMeta
+		require.NoError(t, json.Unmarshal(data, &meta))
+		assert.Equal(t, "diff", meta.ScanType, "the rest of the meta is kept")
+		assert.ElementsMatch(t, []api.SkippedFile{
+			{Path: "data/big set.csv", Size: 400, Reason: api.SkipReasonSize},
+			{Path: "logo.PNG", Size: 11, Reason: api.SkipReasonBinary},
+			{Path: "model.bin", Size: 8, Reason: api.SkipReasonBinary},
+		}, meta.SkippedFiles)
+
+		// With binaries included, only the large file is left out.
+		require.NoError(t, os.Remove(filepath.Join(tarballDir, tarballName)))
+		_, err = packageDirectory(ScanOptions{MaxFileSize: 100, IncludeBinaries: true}, false, source)
+		require.NoError(t, err)
+		files = extractTarballContents(t, filepath.Join(tarballDir, tarballName))
+		assert.Contains(t, files, "logo.PNG")
+		assert.Contains(t, files, "model.bin")
+		assert.NotContains(t, files, "data/big set.csv")
+	}
+}
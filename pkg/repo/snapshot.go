@@ -0,0 +1,118 @@
+// Copyright (c) Kusari <https://www.kusari.dev/>
+// SPDX-License-Identifier: MIT
+
+package repo
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+
+	"github.com/kusaridev/kusari-cli/v2/api"
+	"github.com/kusaridev/kusari-cli/v2/pkg/clierrors"
+)
+
+// snapshotBranch is the branch recorded for a directory snapshot when
+// --override-branch doesn't name one.
+const snapshotBranch = "snapshot"
+
+// lookGit finds git on PATH; tests replace it.
+var lookGit = func() (string, error) { return exec.LookPath("git") }
+
+// checkGit fails, before anything is packaged, when the scan needs git and
+// it isn't installed, or when --no-git is given to a scan that can't do
+// without it.
+func checkGit(opts ScanOptions, full bool) error {
+	if opts.NoGit {
+		switch {
+		case !full:
+			return clierrors.NewValidationError("--no-git only works for risk checks: a diff scan needs git to diff against")
+		case opts.CommittedOnly:
+			return clierrors.NewValidationError("--committed-only needs git; it can't be used with --no-git")
+		}
+		return nil
+	}
+	if _, err := lookGit(); err != nil {
+		if full {
+			return clierrors.NewValidationError("git is not installed or not on PATH: install it (https://git-scm.com/downloads), or pass --no-git to risk-check a plain snapshot of the directory")
+		}
+		return clierrors.NewValidationError("git is not installed or not on PATH: a diff scan needs it; install it from https://git-scm.com/downloads")
+	}
+	return nil
+}
+
+// createSnapshotMeta writes the bundle metadata of a risk check of the
+// current directory as a plain snapshot.
+func createSnapshotMeta(opts ScanOptions) (*api.BundleMeta, error) {
+	dir, err := os.Getwd()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get directory: %w", err)
+	}
+	branch := opts.OverrideBranch
+	if branch == "" {
+		branch = snapshotBranch
+	}
+	meta := &api.BundleMeta{
+		PatchName:     patchName,
+		CurrentBranch: branch,
+		DirName:       filepath.Base(dir),
+		ScanType:      "full",
+		Checks:        opts.Checks,
+		ScannedBy:     scannedBy(),
+		NoGit:         true,
+	}
+	if err := writeMeta(meta); err != nil {
+		return nil, err
+	}
+	return meta, nil
+}
+
+// archiveSnapshot writes a tar of every file under the current directory
+// to outFile, but for those of .git directories, which --no-git doesn't
+// read. With no git to apply .gitignore, ignored files are packaged too.
+// It returns the files it left out.
+func archiveSnapshot(opts ScanOptions, outFile string) ([]api.SkippedFile, error) {
+	var entries []packageEntry
+	err := filepath.WalkDir(".", func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			if d.Name() == ".git" {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		// tar follows symlinks, so this does too; symlinks to directories
+		// aren't descended into, as git doesn't
+		fi, err := os.Stat(path)
+		if err != nil || !fi.Mode().IsRegular() {
+			return nil
+		}
+		e := packageEntry{Path: path, Size: fi.Size()}
+		if !opts.IncludeBinaries && !binaryExtension(path) {
+			e.Binary = binaryContent(path)
+		}
+		entries = append(entries, e)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error listing directory: %w", err)
+	}
+	return archiveEntries(opts, outFile, entries)
+}
+
+// snapshotLockPath is the scan lock of the directory at dir when there is
+// no git directory to keep it in.
+func snapshotLockPath(dir string) (string, error) {
+	abs, err := filepath.Abs(dir)
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve directory: %w", err)
+	}
+	sum := sha256.Sum256([]byte(abs))
+	return filepath.Join(os.TempDir(), "kusari-"+hex.EncodeToString(sum[:8])+"-"+scanLockName), nil
+}
@@ -0,0 +1,76 @@
+// Copyright (c) Kusari <https://www.kusari.dev/>
+// SPDX-License-Identifier: MIT
+
+package repo
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/kusaridev/kusari-cli/v2/api"
+	"github.com/kusaridev/kusari-cli/v2/pkg/clierrors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheckGit(t *testing.T) {
+	t.Run("git missing", func(t *testing.T) {
+		orig := lookGit
+		lookGit = func() (string, error) { return "", exec.ErrNotFound }
+		t.Cleanup(func() { lookGit = orig })
+
+		err := checkGit(ScanOptions{}, true)
+		var ve *clierrors.ValidationError
+		require.ErrorAs(t, err, &ve)
+		assert.ErrorContains(t, err, "--no-git")
+		err = checkGit(ScanOptions{}, false)
+		require.ErrorAs(t, err, &ve)
+		assert.NotContains(t, err.Error(), "--no-git")
+
+		assert.NoError(t, checkGit(ScanOptions{NoGit: true}, true))
+	})
+
+	t.Run("no-git needs a risk check", func(t *testing.T) {
+		assert.ErrorContains(t, checkGit(ScanOptions{NoGit: true}, false), "only works for risk checks")
+		assert.ErrorContains(t, checkGit(ScanOptions{NoGit: true, CommittedOnly: true}, true), "--committed-only needs git")
+	})
+}
+
+func TestScan_NoGit(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	testDir := t.TempDir()
+	t.Chdir(testDir)
+	writeFile(t, filepath.Join(testDir, "main.go"), "package main")
+	require.NoError(t, os.MkdirAll(filepath.Join(testDir, "pkg"), 0755))
+	writeFile(t, filepath.Join(testDir, "pkg", "lib.go"), "package pkg")
+	require.NoError(t, os.MkdirAll(filepath.Join(testDir, ".git"), 0755))
+	writeFile(t, filepath.Join(testDir, ".git", "HEAD"), "ref: refs/heads/main")
+
+	// Only what packaging needs besides git
+	bin := t.TempDir()
+	for _, name := range []string{"tar", "bzip2"} {
+		path, err := exec.LookPath(name)
+		require.NoError(t, err)
+		require.NoError(t, os.Symlink(path, filepath.Join(bin, name)))
+	}
+	t.Setenv("PATH", bin)
+
+	var scanType string
+	var files []string
+	var meta api.BundleMeta
+	err := scan(ScanOptions{Dir: testDir, NoGit: true, PlatformURL: "https://platform.example.com", ConsoleURL: "https://console.example.com", OutputFormat: "markdown"}, true, bundleMock(t, &scanType, &files, &meta))
+	require.NoError(t, err)
+
+	assert.Equal(t, "full", scanType)
+	assert.ElementsMatch(t, []string{"main.go", "pkg/lib.go", metaFile, manifestFile}, files)
+	assert.True(t, meta.NoGit)
+	assert.Equal(t, snapshotBranch, meta.CurrentBranch)
+	assert.Equal(t, filepath.Base(testDir), meta.DirName)
+	assert.Empty(t, meta.Remote)
+	assert.Empty(t, meta.CommitSHA)
+	// The scan ran without git to find
+	_, err = exec.LookPath("git")
+	assert.ErrorIs(t, err, exec.ErrNotFound)
+}
@@ -0,0 +1,151 @@
+// Copyright (c) Kusari <https://www.kusari.dev/>
+// SPDX-License-Identifier: MIT
+
+package repo
+
+import (
+	"cmp"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/kusaridev/kusari-cli/v2/api"
+	"github.com/kusaridev/kusari-cli/v2/pkg/output"
+)
+
+// maxTerminalCodeLines caps the suggested fix shown under each finding in
+// the terminal; the console has the rest.
+const maxTerminalCodeLines = 10
+
+// terminalMarkdown lays out a diff scan result for reading in a terminal:
+// a summary table, the verdict, then the code findings grouped by file and
+// ordered by line, and the dependency findings.
+func terminalMarkdown(sa *api.SecurityAnalysis, consoleURL string) string {
+	byFile := map[string][]api.CodeMitigationItem{}
+	for _, m := range sa.RequiredCodeMitigations {
+		byFile[m.Path] = append(byFile[m.Path], m)
+	}
+	files := make([]string, 0, len(byFile))
+	for f := range byFile {
+		files = append(files, f)
+	}
+	slices.Sort(files)
+
+	sb := new(strings.Builder)
+	fmt.Fprintln(sb, "## Kusari Inspector Summary")
+	fmt.Fprintln(sb)
+	fmt.Fprintln(sb, "| | |")
+	fmt.Fprintln(sb, "|---|---|")
+	fmt.Fprintf(sb, "| Verdict | %s |\n", verdict(sa))
+	if sa.HealthScore > 0 {
+		fmt.Fprintf(sb, "| Health score | %d/5 |\n", sa.HealthScore)
+	}
+	fmt.Fprintf(sb, "| Files affected | %d |\n", len(files))
+	fmt.Fprintf(sb, "| Code findings | %d |\n", len(sa.RequiredCodeMitigations))
+	fmt.Fprintf(sb, "| Dependency findings | %d |\n", len(sa.RequiredDependencyMitigations))
+	fmt.Fprintln(sb)
+
+	if sa.Recommendation != "" {
+		fmt.Fprintf(sb, "**Recommendation:** %s\n\n", sa.Recommendation)
+	}
+	if sa.Justification != "" {
+		fmt.Fprintf(sb, "**Justification:** %s\n\n", sa.Justification)
+	}
+
+	if len(files) > 0 {
+		fmt.Fprintln(sb, "## Findings by File")
+		fmt.Fprintln(sb)
+	}
+	for _, f := range files {
+		findings := byFile[f]
+		slices.SortStableFunc(findings, func(a, b api.CodeMitigationItem) int { return cmp.Compare(a.LineNumber, b.LineNumber) })
+		name := f
+		if name == "" {
+			name = "(no file)"
+		}
+		fmt.Fprintf(sb, "### %s (%d)\n\n", name, len(findings))
+		for _, m := range findings {
+			if m.LineNumber > 0 {
+				fmt.Fprintf(sb, "- **%s:%d** %s\n", name, m.LineNumber, m.Content)
+			} else {
+				fmt.Fprintf(sb, "- %s\n", m.Content)
+			}
+			if code := strings.TrimRight(m.Code, "\n"); code != "" {
+				fmt.Fprintf(sb, "\n```\n%s\n```\n\n", truncateLines(code, maxTerminalCodeLines))
+			}
+		}
+		fmt.Fprintln(sb)
+	}
+
+	if len(sa.RequiredDependencyMitigations) > 0 {
+		fmt.Fprintln(sb, "## Dependency Findings")
+		fmt.Fprintln(sb)
+		for _, m := range sa.RequiredDependencyMitigations {
+			fmt.Fprintf(sb, "- %s\n", m.Content)
+		}
+		fmt.Fprintln(sb)
+	}
+
+	if consoleURL != "" {
+		fmt.Fprintf(sb, "Full results: %s\n", consoleURL)
+	}
+	return sb.String()
+}
+
+// linkFindings makes each path:line reference to a code finding in the
+// rendered output a file:// link to the file under repoDir.
+func linkFindings(rendered string, sa *api.SecurityAnalysis, repoDir string) string {
+	links := map[string]string{}
+	for _, m := range sa.RequiredCodeMitigations {
+		if m.Path != "" && m.LineNumber > 0 {
+			links[fmt.Sprintf("%s:%d", m.Path, m.LineNumber)] = output.FileURL(filepath.Join(repoDir, m.Path))
+		}
+	}
+	if len(links) == 0 {
+		return rendered
+	}
+	// Longest first, so "a.go:12" is not linked as "a.go:1".
+	refs := make([]string, 0, len(links))
+	for ref := range links {
+		refs = append(refs, ref)
+	}
+	slices.SortFunc(refs, func(a, b string) int { return cmp.Compare(len(b), len(a)) })
+	quoted := make([]string, len(refs))
+	for i, ref := range refs {
+		quoted[i] = regexp.QuoteMeta(ref)
+	}
+	re := regexp.MustCompile(`(?:` + strings.Join(quoted, "|") + `)(?:\D|$)`)
+	return re.ReplaceAllStringFunc(rendered, func(match string) string {
+		for _, ref := range refs {
+			if rest, ok := strings.CutPrefix(match, ref); ok {
+				return output.Hyperlink(os.Stdout, links[ref], ref) + rest
+			}
+		}
+		return match
+	})
+}
+
+func verdict(sa *api.SecurityAnalysis) string {
+	switch {
+	case sa.FailedAnalysis:
+		return "Analysis failed"
+	case !sa.ShouldProceed:
+		return "Do not proceed"
+	case len(sa.RequiredCodeMitigations) > 0 || len(sa.RequiredDependencyMitigations) > 0:
+		return "Proceed, with mitigations"
+	default:
+		return "Proceed"
+	}
+}
+
+// truncateLines keeps the first n lines of s, noting how many were cut.
+func truncateLines(s string, n int) string {
+	lines := strings.Split(s, "\n")
+	if len(lines) <= n {
+		return s
+	}
+	return strings.Join(lines[:n], "\n") + fmt.Sprintf("\n... (%d more lines)", len(lines)-n)
+}
@@ -0,0 +1,70 @@
+// Copyright (c) Kusari <https://www.kusari.dev/>
+// SPDX-License-Identifier: MIT
+
+package repo
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/kusaridev/kusari-cli/v2/api"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTerminalMarkdown(t *testing.T) {
+	sa := &api.SecurityAnalysis{
+		Recommendation: "Fix the injection before merging",
+		Justification:  "User input reaches a query",
+		HealthScore:    2,
+		RequiredCodeMitigations: []api.CodeMitigationItem{
+			{Path: "src/db.go", LineNumber: 40, Content: "Parameterize the query", Code: "db.Query(q, id)"},
+			{Path: "src/api.go", LineNumber: 7, Content: "Validate the ID"},
+			{Path: "src/db.go", LineNumber: 12, Content: "Close the rows", Code: strings.Repeat("line\n", 12)},
+		},
+		RequiredDependencyMitigations: []api.DependencyMitigationItem{{Content: "Upgrade lodash to 4.17.21"}},
+	}
+
+	got := terminalMarkdown(sa, "https://console.example.com/result")
+
+	for _, want := range []string{
+		"| Verdict | Do not proceed |",
+		"| Health score | 2/5 |",
+		"| Files affected | 2 |",
+		"| Code findings | 3 |",
+		"| Dependency findings | 1 |",
+		"**Recommendation:** Fix the injection before merging",
+		"### src/db.go (2)",
+		"- **src/db.go:40** Parameterize the query",
+		"... (2 more lines)",
+		"- Upgrade lodash to 4.17.21",
+		"Full results: https://console.example.com/result",
+	} {
+		assert.Contains(t, got, want)
+	}
+	// Files in order, findings by line.
+	assert.Less(t, strings.Index(got, "### src/api.go"), strings.Index(got, "### src/db.go"))
+	assert.Less(t, strings.Index(got, "src/db.go:12"), strings.Index(got, "src/db.go:40"))
+}
+
+func TestTerminalMarkdown_NoFindings(t *testing.T) {
+	got := terminalMarkdown(&api.SecurityAnalysis{ShouldProceed: true}, "")
+	assert.Contains(t, got, "| Verdict | Proceed |")
+	assert.Contains(t, got, "| Files affected | 0 |")
+	assert.NotContains(t, got, "Health score")
+	assert.NotContains(t, got, "Findings by File")
+	assert.NotContains(t, got, "Full results")
+}
+
+func TestLinkFindings(t *testing.T) {
+	t.Setenv("FORCE_HYPERLINK", "1")
+	sa := &api.SecurityAnalysis{RequiredCodeMitigations: []api.CodeMitigationItem{
+		{Path: "a.go", LineNumber: 1},
+		{Path: "a.go", LineNumber: 12},
+		{Path: "b.go"},
+	}}
+
+	got := linkFindings("see a.go:12 and a.go:1, not b.go or a.go:123", sa, "/repo")
+
+	assert.Equal(t, "see \033]8;;file:///repo/a.go\033\\a.go:12\033]8;;\033\\ and "+
+		"\033]8;;file:///repo/a.go\033\\a.go:1\033]8;;\033\\, not b.go or a.go:123", got)
+}
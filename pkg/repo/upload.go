@@ -6,11 +6,16 @@ package repo
 import (
 	"bytes"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
 	"os"
 	"time"
+
+	"github.com/kusaridev/kusari-cli/v2/pkg/clierrors"
+	"github.com/kusaridev/kusari-cli/v2/pkg/output"
+	"github.com/kusaridev/kusari-cli/v2/pkg/readonly"
 )
 
 // uploadToS3Options contains configuration for uploading data to S3
@@ -29,7 +34,7 @@ func uploadToS3WithOptions(opts uploadToS3Options) error {
 
 	client := opts.client
 	if client == nil {
-		client = http.DefaultClient
+		client = newHTTPClient(0)
 	}
 
 	req, err := http.NewRequest("PUT", opts.presignedURL, bytes.NewReader(opts.data))
@@ -41,9 +46,12 @@ func uploadToS3WithOptions(opts uploadToS3Options) error {
 		req.Header.Set("Content-Type", opts.contentType)
 	}
 
+	output.Debug("uploading to presigned URL", "url", opts.presignedURL, "bytes", len(opts.data), "contentType", opts.contentType)
 	resp, err := client.Do(req)
 	if err != nil {
-		return fmt.Errorf("failed to upload: %w", err)
+		// The *url.Error from client.Do embeds the presigned URL, signature
+		// included; don't let it reach the terminal or CI logs.
+		return clierrors.NewNetworkError("failed to upload", errors.New(output.Redact(err.Error())))
 	}
 	defer func() {
 		_ = resp.Body.Close()
@@ -51,7 +59,7 @@ func uploadToS3WithOptions(opts uploadToS3Options) error {
 
 	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusNoContent {
 		body, _ := io.ReadAll(resp.Body)
-		return fmt.Errorf("upload failed with status %d: %s", resp.StatusCode, string(body))
+		return clierrors.NewPlatformError(resp.StatusCode, fmt.Sprintf("upload failed with status %d: %s", resp.StatusCode, string(body)))
 	}
 
 	return nil
@@ -93,6 +101,9 @@ type presignedURLOptions struct {
 
 // getPresignedURLWithOptions is a flexible function to obtain presigned URLs
 func getPresignedURLWithOptions(opts presignedURLOptions) (string, error) {
+	if err := readonly.Check("upload to the Kusari platform"); err != nil {
+		return "", err
+	}
 	payloadBytes, err := json.Marshal(opts.payload)
 	if err != nil {
 		return "", fmt.Errorf("error creating JSON payload: %w", err)
@@ -100,9 +111,7 @@ func getPresignedURLWithOptions(opts presignedURLOptions) (string, error) {
 
 	client := opts.client
 	if client == nil {
-		client = &http.Client{
-			Timeout: 10 * time.Second,
-		}
+		client = newHTTPClient(10 * time.Second)
 	}
 
 	req, err := http.NewRequest("POST", opts.apiEndpoint, bytes.NewBuffer(payloadBytes))
@@ -118,9 +127,11 @@ func getPresignedURLWithOptions(opts presignedURLOptions) (string, error) {
 		req.Header.Set("X-Kusari-Workspace", opts.workspace)
 	}
 
+	output.Debug("requesting presigned URL", "endpoint", opts.apiEndpoint, "workspace", opts.workspace,
+		"Authorization", req.Header.Get("Authorization"))
 	resp, err := client.Do(req)
 	if err != nil {
-		return "", fmt.Errorf("failed to POST to %s, with error: %w", opts.apiEndpoint, err)
+		return "", clierrors.NewNetworkError(fmt.Sprintf("failed to POST to %s", opts.apiEndpoint), err)
 	}
 	defer func() {
 		_ = resp.Body.Close()
@@ -130,14 +141,14 @@ func getPresignedURLWithOptions(opts presignedURLOptions) (string, error) {
 		body, _ := io.ReadAll(resp.Body)
 		switch resp.StatusCode {
 		case http.StatusUnauthorized:
-			return "", fmt.Errorf("GetPresignedUrl failed with unauthorized request: %d. Body was: %s", resp.StatusCode, string(body))
+			return "", clierrors.NewPlatformError(resp.StatusCode, fmt.Sprintf("GetPresignedUrl failed with unauthorized request: %d. Body was: %s", resp.StatusCode, string(body)))
 		case http.StatusForbidden:
-			// Handle the HTTP 403 case by suggesting the user login
-			return "", fmt.Errorf("GetPresignedUrl failed with forbidden (%d). Try `kusari auth login`. Body was: %s", resp.StatusCode, string(body))
+			// Name the missing role rather than only saying forbidden
+			return "", clierrors.NewForbiddenError("requesting an upload URL", body)
 		case http.StatusBadRequest:
-			return "", fmt.Errorf("GetPresignedUrl failed with bad request (%d). Body was: %s", resp.StatusCode, string(body))
+			return "", clierrors.NewPlatformError(resp.StatusCode, fmt.Sprintf("GetPresignedUrl failed with bad request (%d). Body was: %s", resp.StatusCode, string(body)))
 		default:
-			return "", fmt.Errorf("GetPresignedUrl failed with unexpected status code: %d. Body was: %s", resp.StatusCode, string(body))
+			return "", clierrors.NewPlatformError(resp.StatusCode, fmt.Sprintf("GetPresignedUrl failed with unexpected status code: %d. Body was: %s", resp.StatusCode, string(body)))
 		}
 	}
 
@@ -159,13 +170,9 @@ func getPresignedURLWithOptions(opts presignedURLOptions) (string, error) {
 	return result.PresignedUrl, nil
 }
 
-// GetPresignedUrl utilizes authorized client to obtain the presigned URL to upload to S3
-func getPresignedURL(apiEndpoint string, jwtToken string, filePath, workspace string, full bool, size int64) (string, error) {
-	scanType := "diff"
-	if full {
-		scanType = "full"
-	}
-
+// getPresignedURL requests an upload URL for a bundle of scanType: "diff",
+// "full", "full-delta" or "patch".
+func getPresignedURL(apiEndpoint string, jwtToken string, filePath, workspace string, scanType string, size int64) (string, error) {
 	payload := map[string]any{
 		"filename":        filePath,
 		"type":            scanType,
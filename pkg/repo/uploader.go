@@ -10,19 +10,32 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
+	"maps"
 	"net/http"
 	"net/url"
 	"os"
 	"path/filepath"
 	"slices"
+	"strconv"
 	"strings"
 	"sync"
 	"text/tabwriter"
 	"time"
+	"unicode/utf8"
 
+	apiconfig "github.com/kusaridev/kusari-cli/v2/api/configuration"
 	"github.com/kusaridev/kusari-cli/v2/pkg/auth"
+	"github.com/kusaridev/kusari-cli/v2/pkg/ci"
+	"github.com/kusaridev/kusari-cli/v2/pkg/clierrors"
+	"github.com/kusaridev/kusari-cli/v2/pkg/configuration"
 	"github.com/kusaridev/kusari-cli/v2/pkg/constants"
+	"github.com/kusaridev/kusari-cli/v2/pkg/depgraph"
 	"github.com/kusaridev/kusari-cli/v2/pkg/login"
+	"github.com/kusaridev/kusari-cli/v2/pkg/output"
+	"github.com/kusaridev/kusari-cli/v2/pkg/readonly"
+	"github.com/kusaridev/kusari-cli/v2/pkg/sarif"
+	"github.com/kusaridev/kusari-cli/v2/pkg/sbom"
+	"github.com/kusaridev/kusari-cli/v2/pkg/transport"
 	"golang.org/x/sync/errgroup"
 )
 
@@ -43,6 +56,9 @@ type DocumentType string
 const (
 	DocumentSBOM    DocumentType = "SBOM"
 	DocumentOpenVEX DocumentType = "OPEN_VEX"
+	DocumentSARIF   DocumentType = "SARIF"
+	// DocumentDependencyGraph is a depgraph.Graph resolved by the CLI.
+	DocumentDependencyGraph DocumentType = "DEPENDENCY_GRAPH"
 )
 
 // FormatType describes the document format for malform checks
@@ -134,6 +150,15 @@ type uploadResults struct {
 	Sboms []sbomResult `json:"sboms"`
 }
 
+// ingestionResult is one row of the ingestion results table.
+type ingestionResult struct {
+	docRef       string
+	documentName string
+	status       string
+	userMessage  string
+	err          error
+}
+
 type blockedPackages struct {
 	Blocked         bool     `json:"blocked"`
 	BlockedPackages []string `json:"blocked_packages"`
@@ -172,8 +197,191 @@ type spdxSBOM struct {
 	Name              string `json:"name"`
 }
 
-// Upload handles the upload of SBOM or OpenVEX files to the Kusari platform
-func Upload(
+// UploadOptions configures an Upload. FilePath and TenantEndpoint are
+// required; everything else is optional and zero values mean "not set".
+type UploadOptions struct {
+	// FilePath is the SBOM/OpenVEX file, or a directory of SBOMs, to upload.
+	FilePath string
+	// Sarif is a third-party SARIF file to upload instead of FilePath.
+	Sarif string
+	// DepGraph is a project directory whose dependency graph is resolved
+	// with its package managers and uploaded instead of FilePath.
+	DepGraph string
+	// TenantEndpoint is the tenant API base URL (e.g. https://demo.api.us.kusari.cloud).
+	TenantEndpoint string
+	// PlatformURL is used for workspace lookups. Defaults to constants.DefaultPlatformURL.
+	PlatformURL string
+
+	// Upload metadata stored in the document wrapper.
+	Alias                      string
+	DocType                    string
+	IsOpenVex                  bool
+	Tag                        string
+	SoftwareID                 string
+	SbomSubject                string
+	SbomSubjectNameOverride    string
+	SbomSubjectVersionOverride string
+	// Tags are uploaded, after Tag, as the comma-separated tags metadata.
+	// The default_tags of the kusari.yaml in the working directory are
+	// added to them, and so with CITags are the branch, release and
+	// pipeline of the CI pipeline the upload runs in.
+	Tags   []string
+	CITags bool
+
+	// Repository traceability metadata.
+	Forge       string
+	Org         string
+	Repo        string
+	SubrepoPath string
+	CommitSha   string
+
+	// CheckBlockedPackages fails the upload if any SBOM uses a blocked package.
+	CheckBlockedPackages bool
+	// BlockedReport, when set, receives the blocked packages found as JSON.
+	// Requires CheckBlockedPackages.
+	BlockedReport string
+	// Waivers is a YAML file of blocked packages approved until an expiry
+	// date, which are then reported as warnings instead of failing the
+	// upload. Requires CheckBlockedPackages.
+	Waivers string
+	// Wait polls for ingestion status after uploading.
+	Wait bool
+	// ResultsFile, when set, receives machine-readable JSON results. Requires Wait.
+	ResultsFile string
+	// MapComponents maps each ingested software to a component. Requires Wait.
+	MapComponents bool
+	// ConvertTo converts SBOMs to this format (spdx or cyclonedx) before
+	// they are uploaded. CLIVersion is recorded as the converting tool.
+	ConvertTo  string
+	CLIVersion string
+	// NameRules is a central mapping file of rules normalizing SBOM subject
+	// names. Without it, the subject_name_rules and subject_name_aliases of
+	// the kusari.yaml in the working directory apply.
+	NameRules string
+}
+
+// validate checks the options that can be verified without touching the
+// filesystem or network.
+func (o UploadOptions) validate() error {
+	if o.Sarif != "" && o.DepGraph != "" {
+		return clierrors.NewValidationError("pass either --sarif or --dep-graph, not both")
+	}
+	for flag, set := range map[string]bool{"--sarif": o.Sarif != "", "--dep-graph": o.DepGraph != ""} {
+		if !set {
+			continue
+		}
+		if o.FilePath != "" {
+			return clierrors.NewValidationError("pass either --file-path or %s, not both", flag)
+		}
+		if o.IsOpenVex || o.CheckBlockedPackages || o.ResultsFile != "" || o.MapComponents || o.ConvertTo != "" {
+			return clierrors.NewValidationError("%s can't be used with --openvex, --check-blocked-packages, --results-file, --map-components or --convert-to", flag)
+		}
+	}
+	if o.FilePath == "" && o.Sarif == "" && o.DepGraph == "" {
+		return clierrors.NewValidationError("file-path is required")
+	}
+
+	if (o.BlockedReport != "" || o.Waivers != "") && !o.CheckBlockedPackages {
+		return clierrors.NewValidationError("--blocked-report and --waivers require --check-blocked-packages")
+	}
+
+	if o.ResultsFile != "" && !o.Wait {
+		return clierrors.NewValidationError("--results-file requires --wait (software IDs are only available after ingestion completes)")
+	}
+
+	if o.MapComponents && !o.Wait {
+		return clierrors.NewValidationError("--map-components requires --wait (software IDs are only available after ingestion completes)")
+	}
+
+	if o.ConvertTo != "" {
+		if o.IsOpenVex {
+			return clierrors.NewValidationError("--convert-to applies to SBOM uploads, not OpenVEX documents")
+		}
+		if _, err := sbom.ParseFormat(o.ConvertTo); err != nil {
+			return clierrors.NewValidationError("--convert-to: %v", err)
+		}
+	}
+
+	if o.MapComponents && o.IsOpenVex {
+		return clierrors.NewValidationError("--map-components applies to SBOM uploads, not OpenVEX documents")
+	}
+
+	if o.TenantEndpoint == "" {
+		return clierrors.NewValidationError("tenant configuration missing. Please provide --tenant flag (e.g., --tenant demo), or --tenant-endpoint if working in developement, or run 'kusari auth login'")
+	}
+
+	if o.IsOpenVex && (o.Tag == "" || (o.SoftwareID == "" && o.SbomSubject == "")) {
+		return clierrors.NewValidationError("when using OpenVEX, tag must be specified, and so must software-id or sbom-subject")
+	}
+
+	return nil
+}
+
+// uploadMeta builds the document wrapper upload metadata. Empty values are
+// omitted. subrepoPath is passed separately because Upload derives it from
+// FilePath when not explicitly set.
+func (o UploadOptions) uploadMeta(subrepoPath string) map[string]string {
+	uploadMeta := map[string]string{}
+	if o.Alias != "" {
+		uploadMeta["alias"] = o.Alias
+	}
+	if o.DocType != "" {
+		uploadMeta["type"] = o.DocType
+	}
+	if o.Tag != "" {
+		uploadMeta["tag"] = o.Tag
+	}
+	if tags := uniqueTags(append([]string{o.Tag}, o.Tags...)); len(tags) > 0 {
+		uploadMeta["tags"] = strings.Join(tags, ",")
+	}
+	if o.SoftwareID != "" {
+		uploadMeta["software_id"] = o.SoftwareID
+	}
+	if o.SbomSubject != "" { // only used for VEX
+		uploadMeta["sbom_subject"] = o.SbomSubject
+	}
+	if o.SbomSubjectNameOverride != "" { // only used for SBOM
+		uploadMeta["sbom_subject_name_override"] = o.SbomSubjectNameOverride
+	}
+	if o.SbomSubjectVersionOverride != "" { // only used for SBOM
+		uploadMeta["sbom_subject_version_override"] = o.SbomSubjectVersionOverride
+	}
+	// Repository traceability metadata
+	if o.Forge != "" {
+		uploadMeta["forge"] = o.Forge
+	}
+	if o.Org != "" {
+		uploadMeta["org"] = o.Org
+	}
+	if o.Repo != "" {
+		uploadMeta["repo"] = o.Repo
+	}
+	if subrepoPath != "" {
+		uploadMeta["subrepo_path"] = subrepoPath
+	}
+	// Commit SHA
+	if o.CommitSha != "" {
+		uploadMeta["commit_sha"] = o.CommitSha
+	}
+	return uploadMeta
+}
+
+// uniqueTags drops empty and repeated tags, keeping the order.
+func uniqueTags(tags []string) []string {
+	var unique []string
+	for _, t := range tags {
+		if t = strings.TrimSpace(t); t != "" && !slices.Contains(unique, t) {
+			unique = append(unique, t)
+		}
+	}
+	return unique
+}
+
+// UploadLegacy is the positional-argument form of Upload.
+//
+// Deprecated: use Upload with UploadOptions. UploadLegacy will be removed in
+// the next release.
+func UploadLegacy(
 	filePath string,
 	tenantEndpoint string,
 	platformUrl string,
@@ -195,43 +403,69 @@ func Upload(
 	resultsFile string,
 	mapComponents bool,
 ) error {
+	return Upload(context.Background(), UploadOptions{
+		FilePath:                   filePath,
+		TenantEndpoint:             tenantEndpoint,
+		PlatformURL:                platformUrl,
+		Alias:                      alias,
+		DocType:                    docType,
+		IsOpenVex:                  isOpenVex,
+		Tag:                        tag,
+		SoftwareID:                 softwareID,
+		SbomSubject:                sbomSubject,
+		SbomSubjectNameOverride:    sbomSubjectNameOverride,
+		SbomSubjectVersionOverride: sbomSubjectVersionOverride,
+		CheckBlockedPackages:       checkBlockedPackages,
+		Wait:                       wait,
+		Forge:                      forge,
+		Org:                        org,
+		Repo:                       repo,
+		SubrepoPath:                subrepoPath,
+		CommitSha:                  commitSha,
+		ResultsFile:                resultsFile,
+		MapComponents:              mapComponents,
+	})
+}
+
+// Upload handles the upload of SBOM, OpenVEX or SARIF files to the Kusari platform.
+// ctx bounds the post-upload ingestion polling, ID lookups, component
+// mapping and blocked-package checks.
+func Upload(ctx context.Context, opts UploadOptions) error {
 	// Validate required configuration
-	if filePath == "" {
-		return fmt.Errorf("file-path is required")
+	if err := opts.validate(); err != nil {
+		return err
 	}
-
-	if resultsFile != "" && !wait {
-		return fmt.Errorf("--results-file requires --wait (software IDs are only available after ingestion completes)")
+	if err := readonly.Check("upload to the Kusari platform"); err != nil {
+		return err
 	}
-
-	if mapComponents && !wait {
-		return fmt.Errorf("--map-components requires --wait (software IDs are only available after ingestion completes)")
+	// Read the waivers before uploading, so a bad file fails fast
+	var waivers []waiver
+	if opts.Waivers != "" {
+		var err error
+		if waivers, err = loadWaivers(opts.Waivers); err != nil {
+			return err
+		}
 	}
 
-	if mapComponents && isOpenVex {
-		return fmt.Errorf("--map-components applies to SBOM uploads, not OpenVEX documents")
+	filePath := opts.FilePath
+	if opts.Sarif != "" {
+		filePath = opts.Sarif
 	}
-
-	if tenantEndpoint == "" {
-		return fmt.Errorf("tenant configuration missing. Please provide --tenant flag (e.g., --tenant demo), or --tenant-endpoint if working in developement, or run 'kusari auth login'")
+	if opts.DepGraph != "" {
+		filePath = opts.DepGraph
 	}
+	tenantEndpoint := opts.TenantEndpoint
+	platformUrl := opts.PlatformURL
+	isOpenVex := opts.IsOpenVex
+	subrepoPath := opts.SubrepoPath
 
 	// Display the tenant endpoint being used
-	fmt.Printf("Using tenant endpoint: %s\n", tenantEndpoint)
+	output.Progressf(os.Stdout, "Using tenant endpoint: %s\n", tenantEndpoint)
 
-	if isOpenVex && (tag == "" || (softwareID == "" && sbomSubject == "")) {
-		return fmt.Errorf("when using OpenVEX, tag must be specified, and so must software-id or sbom-subject")
-	}
-
-	// Load the auth token
-	token, err := auth.LoadToken("kusari")
+	// Load the auth token (stored login, client credentials, or API key)
+	token, err := auth.DefaultTokenProvider().Token(ctx)
 	if err != nil {
-		return fmt.Errorf("failed to load auth token: %w (try running 'kusari auth login')", err)
-	}
-
-	// Check if token is expired
-	if err := auth.CheckTokenExpiry(token); err != nil {
-		return fmt.Errorf("auth token expired: %w (try running 'kusari auth login')", err)
+		return fmt.Errorf("failed to load auth token: %w (try running 'kusari auth login' or setting %s)", err, auth.APIKeyEnv)
 	}
 
 	accessToken := token.AccessToken
@@ -244,27 +478,30 @@ func Upload(
 	// Get workspace
 	var workspace string
 	var workspaceDescription string
+	// KUSARI_WORKSPACE takes precedence over the stored workspace.
 	storedWorkspace, err := auth.LoadWorkspace(platformUrl, "")
-	if err != nil {
-		// If no workspace is stored, try to fetch and use first workspace
-		workspaces, _, workspaceGetterErr := login.FetchWorkspaces(platformUrl, accessToken)
+	if err != nil || os.Getenv(auth.WorkspaceEnv) != "" {
+		// If no workspace is stored, try to fetch and use KUSARI_WORKSPACE or the first workspace
+		workspaces, _, workspaceGetterErr := login.FetchWorkspacesCached(platformUrl, accessToken)
 		if workspaceGetterErr != nil {
 			fmt.Fprintf(os.Stderr, "Warning: failed to get workspaces: %v\n", workspaceGetterErr)
 		} else if len(workspaces) > 0 {
-			workspace = workspaces[0].ID
-			workspaceDescription = workspaces[0].Description
-			fmt.Fprintf(os.Stderr, "Using workspace: %s\n", workspaceDescription)
+			selected, selectErr := login.DefaultWorkspace(workspaces)
+			if selectErr != nil {
+				return selectErr
+			}
+			workspace = selected.ID
+			workspaceDescription = selected.Description
+			output.Progressf(os.Stderr, "Using workspace: %s\n", workspaceDescription)
 		}
 	} else {
 		workspace = storedWorkspace.ID
 		workspaceDescription = storedWorkspace.Description
-		fmt.Fprintf(os.Stderr, "Using workspace: %s\n", workspaceDescription)
+		output.Progressf(os.Stderr, "Using workspace: %s\n", workspaceDescription)
 	}
 
 	// Create HTTP client
-	client := &http.Client{
-		Timeout: 30 * time.Second,
-	}
+	client := newHTTPClient(30 * time.Second)
 
 	// Check if path is a directory or file
 	fileInfo, err := os.Stat(filePath)
@@ -273,11 +510,19 @@ func Upload(
 	}
 
 	if fileInfo.IsDir() && isOpenVex {
-		return fmt.Errorf("OpenVEX can't be used with directories, only single files")
+		return clierrors.NewValidationError("OpenVEX can't be used with directories, only single files")
 	}
 
-	if fileInfo.IsDir() && (sbomSubjectNameOverride != "" || sbomSubjectVersionOverride != "") {
-		return fmt.Errorf("cannot override SBOM subject with directories, only single files")
+	if fileInfo.IsDir() && opts.Sarif != "" {
+		return clierrors.NewValidationError("--sarif takes a single file, not a directory")
+	}
+
+	if !fileInfo.IsDir() && opts.DepGraph != "" {
+		return clierrors.NewValidationError("--dep-graph takes a project directory, not a file")
+	}
+
+	if fileInfo.IsDir() && (opts.SbomSubjectNameOverride != "" || opts.SbomSubjectVersionOverride != "") {
+		return clierrors.NewValidationError("cannot override SBOM subject with directories, only single files")
 	}
 
 	// Auto-derive subrepo path from file-path if not explicitly set
@@ -297,59 +542,62 @@ func Upload(
 		}
 	}
 
-	// Build upload metadata
-	uploadMeta := map[string]string{}
-	if alias != "" {
-		uploadMeta["alias"] = alias
-	}
-	if docType != "" {
-		uploadMeta["type"] = docType
-	}
-	if tag != "" {
-		uploadMeta["tag"] = tag
-	}
-	if softwareID != "" {
-		uploadMeta["software_id"] = softwareID
-	}
-	if sbomSubject != "" { // only used for VEX
-		uploadMeta["sbom_subject"] = sbomSubject
-	}
-	if sbomSubjectNameOverride != "" { // only used for SBOM
-		uploadMeta["sbom_subject_name_override"] = sbomSubjectNameOverride
-	}
-	if sbomSubjectVersionOverride != "" { // only used for SBOM
-		uploadMeta["sbom_subject_version_override"] = sbomSubjectVersionOverride
+	// kusari.yaml supplies default tags and subject name rules
+	cfg, err := configuration.Load(".")
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: %v, ignoring %s\n", err, configuration.ConfigFilename)
 	}
-	// Repository traceability metadata
-	if forge != "" {
-		uploadMeta["forge"] = forge
+	opts.Tags = append(opts.Tags, cfg.DefaultTags...)
+	if opts.CITags {
+		if c, ok := ci.DetectContext(); ok {
+			opts.Tags = append(opts.Tags, c.Tags()...)
+		}
 	}
-	if org != "" {
-		uploadMeta["org"] = org
+
+	// Build upload metadata
+	uploadMeta := opts.uploadMeta(subrepoPath)
+	fingerprintDir := filePath
+	if !fileInfo.IsDir() {
+		fingerprintDir = filepath.Dir(filePath)
 	}
-	if repo != "" {
-		uploadMeta["repo"] = repo
+	if fp := repoFingerprint(fingerprintDir); fp != "" {
+		uploadMeta["repo_fingerprint"] = fp
 	}
-	if subrepoPath != "" {
-		uploadMeta["subrepo_path"] = subrepoPath
+	rework := sbomRework{tool: opts.CLIVersion}
+	if opts.ConvertTo != "" {
+		rework.to, _ = sbom.ParseFormat(opts.ConvertTo) // checked by validate
 	}
-	// Commit SHA
-	if commitSha != "" {
-		uploadMeta["commit_sha"] = commitSha
+	if opts.SbomSubjectNameOverride == "" && !isOpenVex {
+		if rework.names, err = loadNameRules(opts.NameRules, cfg); err != nil {
+			return err
+		}
 	}
 
 	var ssaus []sbomSubjectAndURI
 
 	// Upload based on file type
-	if fileInfo.IsDir() {
-		fmt.Printf("Uploading directory: %s\n", filePath)
-		ssaus, err = uploadDirectory(client, accessToken, tenantEndpoint, filePath, uploadMeta)
+	if opts.Sarif != "" {
+		output.Progressf(os.Stdout, "Uploading SARIF: %s\n", filePath)
+		ssau, err := uploadSarifFile(client, accessToken, tenantEndpoint, filePath, uploadMeta)
+		if err != nil {
+			return fmt.Errorf("SARIF upload failed: %w", err)
+		}
+		ssaus = []sbomSubjectAndURI{ssau}
+	} else if opts.DepGraph != "" {
+		output.Progressf(os.Stdout, "Resolving dependency graph: %s\n", filePath)
+		ssaus, err = uploadDepGraph(client, accessToken, tenantEndpoint, filePath, uploadMeta)
+		if err != nil {
+			return fmt.Errorf("dependency graph upload failed: %w", err)
+		}
+	} else if fileInfo.IsDir() {
+		output.Progressf(os.Stdout, "Uploading directory: %s\n", filePath)
+		ssaus, err = uploadDirectory(client, accessToken, tenantEndpoint, filePath, rework, uploadMeta)
 		if err != nil {
 			return fmt.Errorf("directory upload failed: %w", err)
 		}
 	} else {
-		fmt.Printf("Uploading file: %s\n", filePath)
-		ssau, err := uploadSingleFile(client, accessToken, tenantEndpoint, filePath, isOpenVex, uploadMeta)
+		output.Progressf(os.Stdout, "Uploading file: %s\n", filePath)
+		ssau, err := uploadSingleFile(client, accessToken, tenantEndpoint, filePath, isOpenVex, rework, uploadMeta)
 		if err != nil {
 			return fmt.Errorf("single file upload failed: %w", err)
 		}
@@ -373,15 +621,7 @@ func Upload(
 	var sbomResults []sbomResult
 
 	// Query ingestion status for each uploaded document
-	if wait && workspace != "" && tenantName != "" {
-		type ingestionResult struct {
-			docRef       string
-			documentName string
-			status       string
-			userMessage  string
-			err          error
-		}
-
+	if opts.Wait && workspace != "" && tenantName != "" {
 		// Filter out empty docRefs
 		validSSaus := make([]sbomSubjectAndURI, 0, len(ssaus))
 		for _, ssau := range ssaus {
@@ -391,20 +631,20 @@ func Upload(
 		}
 
 		if len(validSSaus) > 0 {
-			fmt.Fprintf(os.Stderr, "\nChecking ingestion status for %d document(s)...\n", len(validSSaus))
+			output.Progressf(os.Stderr, "\nChecking ingestion status for %d document(s)...\n", len(validSSaus))
 
 			// Initialize results array for tracking status
 			results := make([]ingestionResult, len(validSSaus))
 			var resultsMutex sync.Mutex
 
-			g, ctx := errgroup.WithContext(context.Background())
+			g, gctx := errgroup.WithContext(ctx)
 			g.SetLimit(5) // Limit to 5 concurrent queries
 
 			// Query all documents in parallel
 			for i, ssau := range validSSaus {
 				i, ssau := i, ssau // Capture loop variables
 				g.Go(func() error {
-					result, err := queryForIngestionStatusWithTimeout(ctx, tenantEndpoint, tenantName, ssau.docRef, accessToken, workspace, func(statusItem *IngestionStatusItem) {
+					result, err := queryForIngestionStatusWithTimeout(gctx, tenantEndpoint, tenantName, ssau.docRef, accessToken, workspace, func(statusItem *IngestionStatusItem) {
 						// Update results with interim status changes (started, processing, etc.)
 						resultsMutex.Lock()
 						results[i] = ingestionResult{
@@ -418,7 +658,7 @@ func Upload(
 						if len(shortDocRef) > 20 {
 							shortDocRef = shortDocRef[:20] + "..."
 						}
-						fmt.Fprintf(os.Stderr, "[%s] %s - %s\n", shortDocRef, statusItem.StatusMeta.Status, statusItem.StatusMeta.UserMessage)
+						output.Progressf(os.Stderr, "[%s] %s - %s\n", shortDocRef, statusItem.StatusMeta.Status, statusItem.StatusMeta.UserMessage)
 						resultsMutex.Unlock()
 					})
 
@@ -447,8 +687,11 @@ func Upload(
 				fmt.Fprintf(os.Stderr, "Warning: error during ingestion status check: %v\n", err)
 			}
 
-			// Display results in a table
+			// Display results in a table. On a terminal the free-text MESSAGE
+			// column is truncated so rows don't wrap; redirected output keeps
+			// full messages.
 			fmt.Fprintf(os.Stderr, "\nIngestion Results:\n")
+			messageWidth := ingestionMessageWidth(output.TableWidth(os.Stderr), results)
 			w := tabwriter.NewWriter(os.Stderr, 0, 0, 2, ' ', 0)
 			_, _ = fmt.Fprintln(w, "STATUS\tDOCUMENT NAME\tDOCUMENT REF\tMESSAGE")
 			_, _ = fmt.Fprintln(w, "------\t-------------\t------------\t-------")
@@ -457,6 +700,9 @@ func Upload(
 				if r.status == "failed" || r.err != nil {
 					statusSymbol = "✗"
 				}
+				if output.PlainProgress(os.Stderr) {
+					statusSymbol = output.PlainSymbols(statusSymbol)
+				}
 				docName := r.documentName
 				if docName == "" {
 					docName = "-"
@@ -468,6 +714,7 @@ func Upload(
 				if message == "" {
 					message = "-"
 				}
+				message = output.Truncate(message, messageWidth)
 				_, _ = fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", statusSymbol, docName, r.docRef, message)
 			}
 			_ = w.Flush()
@@ -477,7 +724,7 @@ func Upload(
 			// Only look up IDs when a flag needs them (--results-file or
 			// --map-components): the lookup adds post-ingestion API calls,
 			// so plain uploads skip it entirely.
-			if !isOpenVex && (resultsFile != "" || mapComponents) {
+			if !isOpenVex && (opts.ResultsFile != "" || opts.MapComponents) {
 				var successSSaus []sbomSubjectAndURI
 				for i, r := range results {
 					if r.status == "success" && r.err == nil {
@@ -485,7 +732,7 @@ func Upload(
 					}
 				}
 				if len(successSSaus) > 0 {
-					sbomResults = lookupSoftwareAndComponentIDs(context.Background(), client, accessToken, tenantEndpoint, successSSaus)
+					sbomResults = lookupSoftwareAndComponentIDs(ctx, client, accessToken, tenantEndpoint, successSSaus)
 				}
 			}
 		}
@@ -496,9 +743,9 @@ func Upload(
 	// a mapping failure the file is still written (with whatever mappings
 	// completed) before the error is returned.
 	var mapErr error
-	if mapComponents && len(sbomResults) > 0 {
-		fmt.Fprintf(os.Stderr, "\nMapping ingested software to components...\n")
-		mapErr = mapSoftwareToComponents(context.Background(), client, accessToken, tenantEndpoint, sbomResults)
+	if opts.MapComponents && len(sbomResults) > 0 {
+		output.Progressf(os.Stderr, "\nMapping ingested software to components...\n")
+		mapErr = mapSoftwareToComponents(ctx, client, accessToken, tenantEndpoint, sbomResults)
 	}
 
 	// Print the software table only after mapping, so the COMPONENT columns
@@ -510,33 +757,44 @@ func Upload(
 	// Write the machine-readable results file. Always written when requested —
 	// even when empty (e.g. no documents ingested successfully) — so pipeline
 	// scripts can rely on the file existing after a successful exit.
-	if resultsFile != "" {
-		if err := writeResultsFile(resultsFile, sbomResults); err != nil {
+	if opts.ResultsFile != "" {
+		if err := writeResultsFile(opts.ResultsFile, sbomResults); err != nil {
 			return err
 		}
-		fmt.Fprintf(os.Stderr, "Results written to %s\n", resultsFile)
+		output.Progressf(os.Stderr, "Results written to %s\n", opts.ResultsFile)
 	}
 
 	if mapErr != nil {
 		return fmt.Errorf("component mapping failed: %w", mapErr)
 	}
 
-	if checkBlockedPackages {
-		blocked, err := checkSBOMsForBlockedPackages(context.Background(), client, accessToken, tenantEndpoint, ssaus)
+	if opts.CheckBlockedPackages {
+		packages, err := checkSBOMsForBlockedPackages(ctx, client, accessToken, tenantEndpoint, ssaus)
 		if err != nil {
 			return fmt.Errorf("error checking for blocked packages: %w", err)
 		}
+		blocked := applyWaivers(packages, waivers, clk.Now())
+		printBlockedPackages(packages)
+
+		if opts.BlockedReport != "" {
+			if err := writeBlockedReport(opts.BlockedReport, packages, blocked); err != nil {
+				return err
+			}
+			output.Progressf(os.Stderr, "Blocked package report written to %s\n", opts.BlockedReport)
+		}
 
 		if blocked {
-			return fmt.Errorf("blocked packages found in uploaded SBOMs")
+			return &clierrors.BlockedPackagesError{}
 		}
+		warnWaived(packages)
 	}
 
 	return nil
 }
 
 // uploadDirectory uses filepath.Walk to walk through the directory and upload the files that are found
-func uploadDirectory(client *http.Client, accessToken, tenantEndpoint, dirPath string, uploadMeta map[string]string) ([]sbomSubjectAndURI, error) {
+func uploadDirectory(client *http.Client, accessToken, tenantEndpoint, dirPath string, rework sbomRework,
+	uploadMeta map[string]string) ([]sbomSubjectAndURI, error) {
 	var ssaus []sbomSubjectAndURI
 
 	err := filepath.Walk(dirPath, func(path string, info os.FileInfo, err error) error {
@@ -544,8 +802,8 @@ func uploadDirectory(client *http.Client, accessToken, tenantEndpoint, dirPath s
 			return err
 		}
 		if !info.IsDir() {
-			fmt.Printf("  Uploading: %s\n", path)
-			ssau, err := uploadSingleFile(client, accessToken, tenantEndpoint, path, false, uploadMeta)
+			output.Progressf(os.Stdout, "  Uploading: %s\n", path)
+			ssau, err := uploadSingleFile(client, accessToken, tenantEndpoint, path, false, rework, uploadMeta)
 			if err != nil {
 				return fmt.Errorf("uploadSingleFile failed with error: %w", err)
 			}
@@ -559,7 +817,7 @@ func uploadDirectory(client *http.Client, accessToken, tenantEndpoint, dirPath s
 
 // uploadSingleFile creates a presigned URL for the filepath and calls uploadBlob to upload the actual file
 func uploadSingleFile(client *http.Client, accessToken, tenantEndpoint, filePath string, isOpenVex bool,
-	uploadMeta map[string]string) (sbomSubjectAndURI, error) {
+	rework sbomRework, uploadMeta map[string]string) (sbomSubjectAndURI, error) {
 	// check that the file is not empty
 	checkFile, err := os.Stat(filePath)
 	if err != nil {
@@ -567,7 +825,7 @@ func uploadSingleFile(client *http.Client, accessToken, tenantEndpoint, filePath
 	}
 	// if file is empty, do not upload and return nil
 	if checkFile.Size() == 0 {
-		fmt.Printf("  Skipping empty file: %s\n", filePath)
+		output.Progressf(os.Stdout, "  Skipping empty file: %s\n", filePath)
 		return sbomSubjectAndURI{}, nil
 	}
 
@@ -575,6 +833,12 @@ func uploadSingleFile(client *http.Client, accessToken, tenantEndpoint, filePath
 	if err != nil {
 		return sbomSubjectAndURI{}, fmt.Errorf("error reading file: %s, err: %w", filePath, err)
 	}
+	if !isOpenVex {
+		if blob, err = rework.convert(filePath, blob); err != nil {
+			return sbomSubjectAndURI{}, err
+		}
+		uploadMeta = rework.normalizeSubject(filePath, blob, uploadMeta)
+	}
 
 	// Prepare the payload for the presigned URL request
 	payload := map[string]string{
@@ -598,6 +862,77 @@ func uploadSingleFile(client *http.Client, accessToken, tenantEndpoint, filePath
 	return ssau, nil
 }
 
+// loadNameRules returns the subject name rules of the central mapping file
+// path, or else of cfg, the kusari.yaml in the working directory.
+func loadNameRules(path string, cfg apiconfig.Config) (*sbom.NameRules, error) {
+	if path != "" {
+		rules, err := sbom.LoadNameRules(path)
+		if err != nil {
+			return nil, clierrors.NewValidationError("--name-rules: %v", err)
+		}
+		return rules, nil
+	}
+	rules, err := sbom.ParseNameRules(cfg.SubjectNameRules, cfg.SubjectNameAliases)
+	if err != nil {
+		return nil, clierrors.NewValidationError("%s: %v", configuration.ConfigFilename, err)
+	}
+	return rules, nil
+}
+
+// sbomRework is what is done to SBOMs before they are uploaded: conversion
+// to the format to, and normalization of their subject names. The zero
+// value leaves them as they are.
+type sbomRework struct {
+	to    string
+	tool  string
+	names *sbom.NameRules
+}
+
+// convert returns blob, the file at path, in c's format. Files that aren't
+// SBOMs are left as they are, with a warning.
+func (c sbomRework) convert(path string, blob []byte) ([]byte, error) {
+	if c.to == "" {
+		return blob, nil
+	}
+	doc, err := sbom.Parse(blob)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: not converting %s: %v\n", path, err)
+		return blob, nil
+	}
+	if doc.Format == c.to {
+		return blob, nil
+	}
+	converted, err := sbom.Convert(doc, c.to, c.tool)
+	if err != nil {
+		return nil, fmt.Errorf("failed to convert %s: %w", path, err)
+	}
+	output.Progressf(os.Stdout, "  Converted %s from %s to %s\n", path, doc.Format, c.to)
+	return converted.Marshal()
+}
+
+// normalizeSubject returns uploadMeta with the subject name of the SBOM
+// blob, as the name rules normalize it, as sbom_subject_name_override. An
+// explicit override, or a name the rules don't change, leaves uploadMeta as
+// it is.
+func (c sbomRework) normalizeSubject(path string, blob []byte, uploadMeta map[string]string) map[string]string {
+	if c.names == nil || uploadMeta["sbom_subject_name_override"] != "" {
+		return uploadMeta
+	}
+	doc, err := sbom.Parse(blob)
+	if err != nil {
+		return uploadMeta
+	}
+	name := doc.Name()
+	normalized := c.names.Normalize(name)
+	if normalized == name {
+		return uploadMeta
+	}
+	output.Progressf(os.Stdout, "  Normalized subject name of %s: %s -> %s\n", path, name, normalized)
+	meta := maps.Clone(uploadMeta)
+	meta["sbom_subject_name_override"] = normalized
+	return meta
+}
+
 // getPresignedUrlForUpload utilizes authorized client to obtain the presigned URL to upload to S3
 func getPresignedUrlForUpload(client *http.Client, accessToken, tenantEndpoint string, payloadBytes []byte) (string, error) {
 	var payload map[string]any
@@ -625,10 +960,36 @@ func uploadBlob(client *http.Client, presignedUrl, filePath string, readFile []b
 
 	docRef := getDocRef(readFile)
 
+	if err := uploadDocument(client, presignedUrl, filePath, readFile, doctype, FormatUnknown, docRef, uploadMeta); err != nil {
+		return sbomSubjectAndURI{}, err
+	}
+
+	// Get SBOM subjects and URIs for checking against the blocked package list.
+	var cdx cdxSBOM
+	if err := json.Unmarshal(readFile, &cdx); err == nil { // inverted error check
+		if cdx.BOMFormat == "CycloneDX" && cdx.Metadata.Component.Name != "" && cdx.SerialNumber != "" {
+			return applySubjectNameOverride(sbomSubjectAndURI{subject: cdx.Metadata.Component.Name, uri: cdx.SerialNumber, docRef: docRef}, uploadMeta), nil
+		}
+	}
+
+	var spdx spdxSBOM
+	if err := json.Unmarshal(readFile, &spdx); err == nil { // inverted error check
+		if spdx.SPDXID == "SPDXRef-DOCUMENT" && spdx.Name != "" && spdx.DocumentNamespace != "" {
+			return applySubjectNameOverride(sbomSubjectAndURI{subject: spdx.Name, uri: spdx.DocumentNamespace + "#DOCUMENT", docRef: docRef}, uploadMeta), nil
+		}
+	}
+
+	return sbomSubjectAndURI{docRef: docRef}, nil
+}
+
+// uploadDocument wraps blob in a Document of doctype and uploads it to
+// presignedUrl.
+func uploadDocument(client *http.Client, presignedUrl, filePath string, blob []byte, doctype DocumentType,
+	format FormatType, docRef string, uploadMeta map[string]string) error {
 	baseDoc := &Document{
-		Blob:   readFile,
+		Blob:   blob,
 		Type:   doctype,
-		Format: FormatUnknown,
+		Format: format,
 		SourceInformation: SourceInformation{
 			Collector:   "Kusari-CLI",
 			Source:      fmt.Sprintf("file:///%s", filePath),
@@ -643,36 +1004,87 @@ func uploadBlob(client *http.Client, presignedUrl, filePath string, readFile []b
 
 	docByte, err := json.Marshal(docWrapper)
 	if err != nil {
-		return sbomSubjectAndURI{}, fmt.Errorf("failed marshal of document: %w", err)
+		return fmt.Errorf("failed marshal of document: %w", err)
 	}
 
 	// Upload using the shared function
-	err = uploadToS3WithOptions(uploadToS3Options{
+	return uploadToS3WithOptions(uploadToS3Options{
 		client:       client,
 		presignedURL: presignedUrl,
 		data:         docByte,
 		contentType:  "multipart/form-data",
 	})
+}
+
+// uploadSarifFile uploads a third-party SARIF file as a SARIF document. The
+// log is uploaded as is; its tools, and its results by level, Kusari
+// severity and rule, are added to the upload metadata.
+func uploadSarifFile(client *http.Client, accessToken, tenantEndpoint, filePath string,
+	uploadMeta map[string]string) (sbomSubjectAndURI, error) {
+	blob, err := os.ReadFile(filePath)
+	if err != nil {
+		return sbomSubjectAndURI{}, fmt.Errorf("error reading file: %s, err: %w", filePath, err)
+	}
+	log, err := sarif.Parse(blob)
 	if err != nil {
+		return sbomSubjectAndURI{}, clierrors.NewValidationError("%s: %v", filePath, err)
+	}
+	summary := sarif.Summarize(log)
+	meta := maps.Clone(uploadMeta)
+	maps.Copy(meta, summary.UploadMetadata())
+	output.Progressf(os.Stdout, "  %d result(s) from %s\n", summary.Results, strings.Join(summary.Tools, ", "))
+
+	docRef := getDocRef(blob)
+	payloadBytes, err := json.Marshal(map[string]string{"filename": docRef})
+	if err != nil {
+		return sbomSubjectAndURI{}, fmt.Errorf("error creating JSON payload: %w", err)
+	}
+	presignedUrl, err := getPresignedUrlForUpload(client, accessToken, tenantEndpoint, payloadBytes)
+	if err != nil {
+		return sbomSubjectAndURI{}, err
+	}
+	if err := uploadDocument(client, presignedUrl, filePath, blob, DocumentSARIF, FormatJSON, docRef, meta); err != nil {
 		return sbomSubjectAndURI{}, err
 	}
+	return sbomSubjectAndURI{docRef: docRef, filePath: filePath}, nil
+}
 
-	// Get SBOM subjects and URIs for checking against the blocked package list.
-	var cdx cdxSBOM
-	if err := json.Unmarshal(readFile, &cdx); err == nil { // inverted error check
-		if cdx.BOMFormat == "CycloneDX" && cdx.Metadata.Component.Name != "" && cdx.SerialNumber != "" {
-			return applySubjectNameOverride(sbomSubjectAndURI{subject: cdx.Metadata.Component.Name, uri: cdx.SerialNumber, docRef: docRef}, uploadMeta), nil
-		}
+// uploadDepGraph resolves the dependency graph of each ecosystem of the
+// project in dir and uploads it as a DEPENDENCY_GRAPH document. The
+// ecosystem and its number of dependencies are added to the upload
+// metadata.
+func uploadDepGraph(client *http.Client, accessToken, tenantEndpoint, dir string,
+	uploadMeta map[string]string) ([]sbomSubjectAndURI, error) {
+	graphs, err := depgraph.Resolve(dir)
+	if err != nil {
+		return nil, err
 	}
+	var ssaus []sbomSubjectAndURI
+	for _, g := range graphs {
+		blob, err := json.Marshal(g)
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal dependency graph: %w", err)
+		}
+		meta := maps.Clone(uploadMeta)
+		meta["dependency_graph_ecosystem"] = g.Ecosystem
+		meta["dependency_graph_dependencies"] = strconv.Itoa(g.Dependencies())
+		output.Progressf(os.Stdout, "  %s: %d dependencies (%s)\n", g.Ecosystem, g.Dependencies(), g.Resolver)
 
-	var spdx spdxSBOM
-	if err := json.Unmarshal(readFile, &spdx); err == nil { // inverted error check
-		if spdx.SPDXID == "SPDXRef-DOCUMENT" && spdx.Name != "" && spdx.DocumentNamespace != "" {
-			return applySubjectNameOverride(sbomSubjectAndURI{subject: spdx.Name, uri: spdx.DocumentNamespace + "#DOCUMENT", docRef: docRef}, uploadMeta), nil
+		docRef := getDocRef(blob)
+		payloadBytes, err := json.Marshal(map[string]string{"filename": docRef})
+		if err != nil {
+			return nil, fmt.Errorf("error creating JSON payload: %w", err)
+		}
+		presignedUrl, err := getPresignedUrlForUpload(client, accessToken, tenantEndpoint, payloadBytes)
+		if err != nil {
+			return nil, err
 		}
+		if err := uploadDocument(client, presignedUrl, dir, blob, DocumentDependencyGraph, FormatJSON, docRef, meta); err != nil {
+			return nil, err
+		}
+		ssaus = append(ssaus, sbomSubjectAndURI{docRef: docRef, filePath: dir})
 	}
-
-	return sbomSubjectAndURI{docRef: docRef}, nil
+	return ssaus, nil
 }
 
 // applySubjectNameOverride replaces the file-parsed subject with the
@@ -688,16 +1100,16 @@ func applySubjectNameOverride(ssau sbomSubjectAndURI, uploadMeta map[string]stri
 	return ssau
 }
 
-// checkSBOMsForBlockedPackages checks if uploaded SBOMs contain any blocked packages
-func checkSBOMsForBlockedPackages(ctx context.Context, client *http.Client, accessToken, tenantEndpoint string, ssaus []sbomSubjectAndURI) (bool, error) {
+// checkSBOMsForBlockedPackages returns the blocked packages the uploaded
+// SBOMs contain, in the order of the SBOMs
+func checkSBOMsForBlockedPackages(ctx context.Context, client *http.Client, accessToken, tenantEndpoint string, ssaus []sbomSubjectAndURI) ([]blockedPackage, error) {
 	ctx, cancel := context.WithTimeout(ctx, 15*time.Minute)
 	defer cancel()
 
 	g, ctx := errgroup.WithContext(ctx)
 	g.SetLimit(5)
 
-	blocked := make([]bool, len(ssaus))
-	blockedPurls := make([][]string, len(ssaus))
+	found := make([][]blockedPackage, len(ssaus))
 	progress := newWaitPrinter(os.Stdout)
 
 	for i, ssau := range ssaus {
@@ -713,8 +1125,8 @@ func checkSBOMsForBlockedPackages(ctx context.Context, client *http.Client, acce
 			}
 
 			// Check for blocked packages
-			res, err := makePicoRequest(ctx, client, accessToken, tenantEndpoint, fmt.Sprintf("pico/v1/packages/blocked/check/software/%d/sbom/%d",
-				ids.SoftwareID, ids.SbomID))
+			check := fmt.Sprintf("pico/v1/packages/blocked/check/software/%d/sbom/%d", ids.SoftwareID, ids.SbomID)
+			res, err := makePicoRequest(ctx, client, accessToken, tenantEndpoint, check)
 			if err != nil {
 				return fmt.Errorf("error making request for check: %w", err)
 			}
@@ -732,8 +1144,15 @@ func checkSBOMsForBlockedPackages(ctx context.Context, client *http.Client, acce
 				}
 
 				if bps.Blocked {
-					blocked[i] = true
-					blockedPurls[i] = bps.BlockedPackages
+					for _, purl := range bps.BlockedPackages {
+						found[i] = append(found[i], blockedPackage{
+							Purl:        purl,
+							SbomSubject: ssau.subject,
+							SbomURI:     ssau.uri,
+							SoftwareID:  ids.SoftwareID,
+							SbomID:      ids.SbomID,
+						})
+					}
 				}
 			} else {
 				return fmt.Errorf("unexpected response status code for check: %d", res.StatusCode)
@@ -746,20 +1165,10 @@ func checkSBOMsForBlockedPackages(ctx context.Context, client *http.Client, acce
 	err := g.Wait()
 	progress.close()
 	if err != nil {
-		return false, err
-	}
-
-	// Report blocked packages
-	for i, v := range blocked {
-		if v {
-			fmt.Printf("\nBlocked packages found for SBOM subject %s with URI %s:\n", ssaus[i].subject, ssaus[i].uri)
-			for _, bp := range blockedPurls[i] {
-				fmt.Printf("  - %s\n", bp)
-			}
-		}
+		return nil, err
 	}
 
-	return slices.Contains(blocked, true), nil
+	return slices.Concat(found...), nil
 }
 
 // pollForSoftwareIDs polls the Pico software ID endpoint until the software and
@@ -798,7 +1207,7 @@ func pollForSoftwareIDs(ctx context.Context, client *http.Client, accessToken, t
 			select {
 			case <-ctx.Done():
 				return ids, ctx.Err()
-			case <-time.After(time.Second):
+			case <-clk.After(time.Second):
 			}
 		default:
 			res.Body.Close() //nolint:errcheck
@@ -891,6 +1300,31 @@ func lookupSoftwareAndComponentIDs(ctx context.Context, client *http.Client, acc
 	return filtered
 }
 
+// ingestionMessageWidth returns how many columns the MESSAGE cell of the
+// ingestion results table may use so rows fit in tableWidth, given the
+// widths of the other columns. Returns 0 (no limit) when tableWidth is 0.
+// Never returns less than minMessageWidth so messages stay readable on
+// narrow terminals.
+func ingestionMessageWidth(tableWidth int, results []ingestionResult) int {
+	const (
+		padding         = 2
+		minMessageWidth = 20
+	)
+	if tableWidth <= 0 {
+		return 0
+	}
+
+	nameWidth := len("DOCUMENT NAME")
+	refWidth := len("DOCUMENT REF")
+	for _, r := range results {
+		nameWidth = max(nameWidth, utf8.RuneCountInString(r.documentName))
+		refWidth = max(refWidth, utf8.RuneCountInString(r.docRef))
+	}
+
+	used := len("STATUS") + nameWidth + refWidth + 3*padding
+	return max(tableWidth-used, minMessageWidth)
+}
+
 // printSoftwareAndComponentIDs prints the looked-up IDs as a table on stdout.
 func printSoftwareAndComponentIDs(results []sbomResult) {
 	fmt.Printf("\nIngested Software Summary:\n")
@@ -962,7 +1396,7 @@ func queryForIngestionStatusWithTimeout(ctx context.Context, platformUrl, tenant
 	attempt := 0
 	sleepDuration := 2 * time.Second
 
-	client := &http.Client{Timeout: 10 * time.Second}
+	client := newHTTPClient(10 * time.Second)
 	var lastStatus string
 
 	for attempt < maxAttempts {
@@ -982,7 +1416,7 @@ func queryForIngestionStatusWithTimeout(ctx context.Context, platformUrl, tenant
 
 		req, err := http.NewRequestWithContext(ctx, "GET", fullURL, nil)
 		if err != nil {
-			time.Sleep(sleepDuration)
+			clk.Sleep(sleepDuration)
 			continue
 		}
 
@@ -992,7 +1426,10 @@ func queryForIngestionStatusWithTimeout(ctx context.Context, platformUrl, tenant
 
 		resp, err := client.Do(req)
 		if err != nil {
-			time.Sleep(sleepDuration)
+			if transport.IsDeadline(err) {
+				return nil, err
+			}
+			clk.Sleep(sleepDuration)
 			continue
 		}
 
@@ -1000,13 +1437,13 @@ func queryForIngestionStatusWithTimeout(ctx context.Context, platformUrl, tenant
 			body, err := io.ReadAll(resp.Body)
 			resp.Body.Close() //nolint:errcheck
 			if err != nil {
-				time.Sleep(sleepDuration)
+				clk.Sleep(sleepDuration)
 				continue
 			}
 
 			var results []IngestionStatusItem
 			if err := json.Unmarshal(body, &results); err != nil {
-				time.Sleep(sleepDuration)
+				clk.Sleep(sleepDuration)
 				continue
 			}
 
@@ -1035,7 +1472,7 @@ func queryForIngestionStatusWithTimeout(ctx context.Context, platformUrl, tenant
 		select {
 		case <-ctx.Done():
 			return nil, ctx.Err()
-		case <-time.After(sleepDuration):
+		case <-clk.After(sleepDuration):
 		}
 	}
 
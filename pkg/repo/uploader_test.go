@@ -4,6 +4,7 @@
 package repo
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
 	"io"
@@ -13,6 +14,11 @@ import (
 	"path/filepath"
 	"strings"
 	"testing"
+	"time"
+
+	"github.com/kusaridev/kusari-cli/v2/pkg/clock"
+	"github.com/kusaridev/kusari-cli/v2/pkg/depgraph"
+	"github.com/kusaridev/kusari-cli/v2/pkg/sbom"
 )
 
 func TestGetHash(t *testing.T) {
@@ -492,6 +498,7 @@ func TestUploadSingleFile(t *testing.T) {
 				presignServer.URL,
 				filePath,
 				tt.isOpenVex,
+				sbomRework{},
 				tt.uploadMeta,
 			)
 
@@ -591,7 +598,7 @@ func TestUploadDirectory(t *testing.T) {
 
 			client := server.Client()
 
-			ssaus, err := uploadDirectory(client, "test-token", server.URL, tmpDir, tt.uploadMeta)
+			ssaus, err := uploadDirectory(client, "test-token", server.URL, tmpDir, sbomRework{}, tt.uploadMeta)
 
 			if tt.expectError {
 				if err == nil {
@@ -722,7 +729,8 @@ func TestCheckSBOMsForBlockedPackages(t *testing.T) {
 			client := server.Client()
 			ctx := context.Background()
 
-			blocked, err := checkSBOMsForBlockedPackages(ctx, client, "test-token", server.URL, tt.ssaus)
+			found, err := checkSBOMsForBlockedPackages(ctx, client, "test-token", server.URL, tt.ssaus)
+			blocked := len(found) > 0
 
 			if tt.expectError {
 				if err == nil {
@@ -828,6 +836,10 @@ func TestPollForSoftwareIDsRetriesOn404(t *testing.T) {
 	}))
 	defer server.Close()
 
+	fake := clock.NewFake(time.Now())
+	SetClock(fake)
+	t.Cleanup(func() { SetClock(nil) })
+
 	progressOut := &strings.Builder{}
 	progress := newWaitPrinter(progressOut)
 	ids, err := pollForSoftwareIDs(context.Background(), server.Client(), "test-token", server.URL,
@@ -839,6 +851,9 @@ func TestPollForSoftwareIDsRetriesOn404(t *testing.T) {
 	if attempts != 2 {
 		t.Errorf("Expected 2 attempts, got %d", attempts)
 	}
+	if fake.Slept() != time.Second {
+		t.Errorf("Expected to wait 1s between attempts, waited %v", fake.Slept())
+	}
 	expectedProgress := "  Waiting for software info for ingested SBOMs...\n  #\n"
 	if progressOut.String() != expectedProgress {
 		t.Errorf("Expected progress output %q, got %q", expectedProgress, progressOut.String())
@@ -1060,23 +1075,34 @@ func TestWriteResultsFile(t *testing.T) {
 	}
 }
 
+func TestIngestionMessageWidth(t *testing.T) {
+	results := []ingestionResult{
+		{docRef: "sha256_0123456789abcdef", documentName: "my-sbom.json"},
+		{docRef: "sha256_short", documentName: "a-much-longer-document-name.json"},
+	}
+
+	// STATUS(6) + name(32) + ref(23) + 3*2 padding = 67 columns used
+	if got := ingestionMessageWidth(120, results); got != 53 {
+		t.Errorf("Expected 53, got %d", got)
+	}
+	if got := ingestionMessageWidth(0, results); got != 0 {
+		t.Errorf("Expected 0 (unlimited), got %d", got)
+	}
+	if got := ingestionMessageWidth(40, results); got != 20 {
+		t.Errorf("Expected minimum width 20, got %d", got)
+	}
+}
+
 func strPtr(s string) *string {
 	return &s
 }
 
 func TestUploadResultsFileRequiresWait(t *testing.T) {
-	err := Upload(
-		"/test/file.json",
-		"https://test.com",
-		"", "", "",
-		false, // isOpenVex
-		"", "", "", "", "",
-		false, // checkBlockedPackages
-		false, // wait
-		"", "", "", "", "",
-		"results.json",
-		false, // mapComponents
-	)
+	err := Upload(context.Background(), UploadOptions{
+		FilePath:       "/test/file.json",
+		TenantEndpoint: "https://test.com",
+		ResultsFile:    "results.json",
+	})
 	if err == nil {
 		t.Fatal("Expected error, got nil")
 	}
@@ -1086,18 +1112,11 @@ func TestUploadResultsFileRequiresWait(t *testing.T) {
 }
 
 func TestUploadMapComponentsRequiresWait(t *testing.T) {
-	err := Upload(
-		"/test/file.json",
-		"https://test.com",
-		"", "", "",
-		false, // isOpenVex
-		"", "", "", "", "",
-		false, // checkBlockedPackages
-		false, // wait
-		"", "", "", "", "",
-		"",   // resultsFile
-		true, // mapComponents
-	)
+	err := Upload(context.Background(), UploadOptions{
+		FilePath:       "/test/file.json",
+		TenantEndpoint: "https://test.com",
+		MapComponents:  true,
+	})
 	if err == nil {
 		t.Fatal("Expected error, got nil")
 	}
@@ -1107,18 +1126,13 @@ func TestUploadMapComponentsRequiresWait(t *testing.T) {
 }
 
 func TestUploadMapComponentsRejectsOpenVex(t *testing.T) {
-	err := Upload(
-		"/test/file.json",
-		"https://test.com",
-		"", "", "",
-		true, // isOpenVex
-		"", "", "", "", "",
-		false, // checkBlockedPackages
-		true,  // wait
-		"", "", "", "", "",
-		"",   // resultsFile
-		true, // mapComponents
-	)
+	err := Upload(context.Background(), UploadOptions{
+		FilePath:       "/test/file.json",
+		TenantEndpoint: "https://test.com",
+		IsOpenVex:      true,
+		Wait:           true,
+		MapComponents:  true,
+	})
 	if err == nil {
 		t.Fatal("Expected error, got nil")
 	}
@@ -1127,14 +1141,13 @@ func TestUploadMapComponentsRejectsOpenVex(t *testing.T) {
 	}
 }
 
-// I don't think this actually tests anything. We should get rid of it, or extract
-// upload metadata building into a func and test it here.
 func TestUploadMetadata(t *testing.T) {
 	tests := []struct {
 		name                       string
 		alias                      string
 		docType                    string
 		tag                        string
+		tags                       []string
 		softwareID                 string
 		sbomSubject                string
 		sbomSubjectNameOverride    string
@@ -1200,6 +1213,7 @@ func TestUploadMetadata(t *testing.T) {
 				"alias":                         "alias",
 				"type":                          "image",
 				"tag":                           "v1.0",
+				"tags":                          "v1.0",
 				"software_id":                   "12345",
 				"sbom_subject":                  "subject",
 				"sbom_subject_name_override":    "name-override",
@@ -1211,6 +1225,22 @@ func TestUploadMetadata(t *testing.T) {
 				"commit_sha":                    "commitSha",
 			},
 		},
+		{
+			name: "tags after the tag, without repeats",
+			tag:  "govulncheck",
+			tags: []string{"env:prod", "", "govulncheck", "branch:main"},
+			expectedMeta: map[string]string{
+				"tag":  "govulncheck",
+				"tags": "govulncheck,env:prod,branch:main",
+			},
+		},
+		{
+			name: "tags without a tag",
+			tags: []string{"pipeline:42"},
+			expectedMeta: map[string]string{
+				"tags": "pipeline:42",
+			},
+		},
 		{
 			name:         "empty fields not included",
 			forge:        "",
@@ -1223,46 +1253,21 @@ func TestUploadMetadata(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			// Build upload metadata using the same logic as the Upload function
-			uploadMeta := map[string]string{}
-			if tt.alias != "" {
-				uploadMeta["alias"] = tt.alias
-			}
-			if tt.docType != "" {
-				uploadMeta["type"] = tt.docType
-			}
-			if tt.tag != "" {
-				uploadMeta["tag"] = tt.tag
-			}
-			if tt.softwareID != "" {
-				uploadMeta["software_id"] = tt.softwareID
-			}
-			if tt.sbomSubject != "" {
-				uploadMeta["sbom_subject"] = tt.sbomSubject
-			}
-			if tt.sbomSubjectNameOverride != "" {
-				uploadMeta["sbom_subject_name_override"] = tt.sbomSubjectNameOverride
-			}
-			if tt.sbomSubjectVersionOverride != "" {
-				uploadMeta["sbom_subject_version_override"] = tt.sbomSubjectVersionOverride
-			}
-			// Repository traceability metadata
-			if tt.forge != "" {
-				uploadMeta["forge"] = tt.forge
-			}
-			if tt.org != "" {
-				uploadMeta["org"] = tt.org
-			}
-			if tt.repo != "" {
-				uploadMeta["repo"] = tt.repo
-			}
-			if tt.subrepoPath != "" {
-				uploadMeta["subrepo_path"] = tt.subrepoPath
-			}
-			// Commit SHA
-			if tt.commitSha != "" {
-				uploadMeta["commit_sha"] = tt.commitSha
+			opts := UploadOptions{
+				Alias:                      tt.alias,
+				DocType:                    tt.docType,
+				Tag:                        tt.tag,
+				Tags:                       tt.tags,
+				SoftwareID:                 tt.softwareID,
+				SbomSubject:                tt.sbomSubject,
+				SbomSubjectNameOverride:    tt.sbomSubjectNameOverride,
+				SbomSubjectVersionOverride: tt.sbomSubjectVersionOverride,
+				Forge:                      tt.forge,
+				Org:                        tt.org,
+				Repo:                       tt.repo,
+				CommitSha:                  tt.commitSha,
 			}
+			uploadMeta := opts.uploadMeta(tt.subrepoPath)
 
 			// Verify expected metadata
 			if len(uploadMeta) != len(tt.expectedMeta) {
@@ -1337,28 +1342,14 @@ func TestUpload_ValidationErrors(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			err := Upload(
-				tt.filePath,
-				tt.tenantURL,
-				"", // platformUrl
-				"", // alias
-				"", // docType
-				tt.isOpenVex,
-				tt.tag,
-				tt.softwareID,
-				tt.sbomSubject,
-				"",
-				"",
-				false,
-				false,
-				"",    // forge
-				"",    // org
-				"",    // repo
-				"",    // subrepoPath
-				"",    // commit sha
-				"",    // resultsFile
-				false, // mapComponents
-			)
+			err := Upload(context.Background(), UploadOptions{
+				FilePath:       tt.filePath,
+				TenantEndpoint: tt.tenantURL,
+				IsOpenVex:      tt.isOpenVex,
+				Tag:            tt.tag,
+				SoftwareID:     tt.softwareID,
+				SbomSubject:    tt.sbomSubject,
+			})
 
 			if !tt.expectError {
 				t.Error("Expected error, got nil")
@@ -1373,3 +1364,260 @@ func TestUpload_ValidationErrors(t *testing.T) {
 		})
 	}
 }
+
+func TestUploadSarifFile(t *testing.T) {
+	sarifLog := `{
+		"version": "2.1.0",
+		"runs": [{
+			"tool": {"driver": {"name": "Semgrep OSS", "version": "1.50.0", "rules": [
+				{"id": "sqli", "properties": {"security-severity": "9.1"}},
+				{"id": "weak-hash", "defaultConfiguration": {"level": "note"}}
+			]}},
+			"results": [
+				{"ruleId": "sqli", "level": "error", "message": {"text": "SQL injection"}},
+				{"ruleId": "weak-hash", "message": {"text": "MD5"}}
+			]
+		}]
+	}`
+
+	var uploaded DocumentWrapper
+	uploadServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := json.NewDecoder(r.Body).Decode(&uploaded); err != nil {
+			t.Errorf("Failed to decode uploaded document: %v", err)
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer uploadServer.Close()
+	presignServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(map[string]string{"presignedUrl": uploadServer.URL})
+	}))
+	defer presignServer.Close()
+
+	filePath := filepath.Join(t.TempDir(), "semgrep.sarif")
+	if err := os.WriteFile(filePath, []byte(sarifLog), 0644); err != nil {
+		t.Fatal(err)
+	}
+	ssau, err := uploadSarifFile(&http.Client{}, "test-token", presignServer.URL, filePath, map[string]string{"repo": "myrepo"})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if ssau.docRef == "" {
+		t.Error("Expected a docRef")
+	}
+	if uploaded.Document == nil || uploaded.Type != DocumentSARIF || uploaded.Format != FormatJSON {
+		t.Fatalf("Expected a JSON SARIF document, got %+v", uploaded.Document)
+	}
+	if string(uploaded.Blob) != sarifLog {
+		t.Error("Expected the SARIF log to be uploaded unchanged")
+	}
+	meta := *uploaded.UploadMetaData
+	for key, want := range map[string]string{
+		"repo":                   "myrepo",
+		"sarif_tools":            "Semgrep OSS 1.50.0",
+		"sarif_results":          "2",
+		"sarif_highest_severity": "critical",
+		"sarif_levels":           `{"error":1,"note":1}`,
+		"sarif_severities":       `{"critical":1,"low":1}`,
+		"sarif_rules":            `{"sqli":"critical","weak-hash":"low"}`,
+	} {
+		if meta[key] != want {
+			t.Errorf("Expected %s %q, got %q", key, want, meta[key])
+		}
+	}
+
+	notSarif := filepath.Join(t.TempDir(), "sbom.json")
+	if err := os.WriteFile(notSarif, []byte(`{"bomFormat": "CycloneDX"}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := uploadSarifFile(&http.Client{}, "test-token", presignServer.URL, notSarif, map[string]string{}); err == nil || !strings.Contains(err.Error(), "unsupported SARIF version") {
+		t.Errorf("Expected an unsupported SARIF version error, got %v", err)
+	}
+}
+
+func TestUpload_SarifValidation(t *testing.T) {
+	tests := []struct {
+		name          string
+		opts          UploadOptions
+		errorContains string
+	}{
+		{
+			name:          "with file path",
+			opts:          UploadOptions{Sarif: "a.sarif", FilePath: "sbom.json", TenantEndpoint: "https://test.com"},
+			errorContains: "not both",
+		},
+		{
+			name:          "with openvex",
+			opts:          UploadOptions{Sarif: "a.sarif", IsOpenVex: true, TenantEndpoint: "https://test.com"},
+			errorContains: "--sarif can't be used with",
+		},
+		{
+			name:          "with map components",
+			opts:          UploadOptions{Sarif: "a.sarif", MapComponents: true, Wait: true, TenantEndpoint: "https://test.com"},
+			errorContains: "--sarif can't be used with",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.opts.validate()
+			if err == nil || !strings.Contains(err.Error(), tt.errorContains) {
+				t.Errorf("Expected error containing '%s', got %v", tt.errorContains, err)
+			}
+		})
+	}
+	if err := (UploadOptions{Sarif: "a.sarif", TenantEndpoint: "https://test.com"}).validate(); err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+}
+
+func TestUploadDepGraph(t *testing.T) {
+	var uploaded DocumentWrapper
+	uploadServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := json.NewDecoder(r.Body).Decode(&uploaded); err != nil {
+			t.Errorf("Failed to decode uploaded document: %v", err)
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer uploadServer.Close()
+	presignServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(map[string]string{"presignedUrl": uploadServer.URL})
+	}))
+	defer presignServer.Close()
+
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "go.mod"), []byte("module example.com/app\n\ngo 1.25\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	ssaus, err := uploadDepGraph(&http.Client{}, "test-token", presignServer.URL, dir, map[string]string{"repo": "myrepo"})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(ssaus) != 1 || ssaus[0].docRef == "" {
+		t.Fatalf("Expected one document with a docRef, got %+v", ssaus)
+	}
+	if uploaded.Document == nil || uploaded.Type != DocumentDependencyGraph || uploaded.Format != FormatJSON {
+		t.Fatalf("Expected a JSON dependency graph document, got %+v", uploaded.Document)
+	}
+	var g depgraph.Graph
+	if err := json.Unmarshal(uploaded.Blob, &g); err != nil {
+		t.Fatalf("Expected a dependency graph: %v", err)
+	}
+	if g.Ecosystem != "go" || len(g.Nodes) != 1 || g.Nodes[0].Name != "example.com/app" {
+		t.Errorf("Unexpected graph %+v", g)
+	}
+	meta := *uploaded.UploadMetaData
+	for key, want := range map[string]string{
+		"repo":                          "myrepo",
+		"dependency_graph_ecosystem":    "go",
+		"dependency_graph_dependencies": "0",
+	} {
+		if meta[key] != want {
+			t.Errorf("Expected %s %q, got %q", key, want, meta[key])
+		}
+	}
+
+	if _, err := uploadDepGraph(&http.Client{}, "test-token", presignServer.URL, t.TempDir(), map[string]string{}); err == nil || !strings.Contains(err.Error(), "no go.mod") {
+		t.Errorf("Expected a missing manifest error, got %v", err)
+	}
+}
+
+func TestUpload_DepGraphValidation(t *testing.T) {
+	for _, tt := range []struct {
+		opts          UploadOptions
+		errorContains string
+	}{
+		{UploadOptions{DepGraph: ".", FilePath: "sbom.json", TenantEndpoint: "https://test.com"}, "either --file-path or --dep-graph"},
+		{UploadOptions{DepGraph: ".", Sarif: "a.sarif", TenantEndpoint: "https://test.com"}, "either --sarif or --dep-graph"},
+		{UploadOptions{DepGraph: ".", CheckBlockedPackages: true, TenantEndpoint: "https://test.com"}, "--dep-graph can't be used with"},
+	} {
+		if err := tt.opts.validate(); err == nil || !strings.Contains(err.Error(), tt.errorContains) {
+			t.Errorf("Expected error containing '%s', got %v", tt.errorContains, err)
+		}
+	}
+	if err := (UploadOptions{DepGraph: ".", TenantEndpoint: "https://test.com"}).validate(); err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+}
+
+func TestUpload_ConvertToValidation(t *testing.T) {
+	for _, tt := range []struct {
+		opts          UploadOptions
+		errorContains string
+	}{
+		{UploadOptions{FilePath: "sbom.json", ConvertTo: "swid", TenantEndpoint: "https://test.com"}, "unknown SBOM format"},
+		{UploadOptions{FilePath: "vex.json", ConvertTo: "spdx", IsOpenVex: true, Tag: "t", SoftwareID: "1", TenantEndpoint: "https://test.com"}, "not OpenVEX"},
+		{UploadOptions{Sarif: "a.sarif", ConvertTo: "spdx", TenantEndpoint: "https://test.com"}, "--sarif can't be used with"},
+	} {
+		if err := tt.opts.validate(); err == nil || !strings.Contains(err.Error(), tt.errorContains) {
+			t.Errorf("Expected error containing '%s', got %v", tt.errorContains, err)
+		}
+	}
+	if err := (UploadOptions{FilePath: "sbom.json", ConvertTo: "cyclonedx", TenantEndpoint: "https://test.com"}).validate(); err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+}
+
+func TestSbomConverter(t *testing.T) {
+	spdx := []byte(`{"spdxVersion": "SPDX-2.3", "SPDXID": "SPDXRef-DOCUMENT", "name": "app",
+		"documentNamespace": "https://example.com/app",
+		"packages": [{"SPDXID": "SPDXRef-app", "name": "app", "versionInfo": "1.0.0"}],
+		"relationships": [{"spdxElementId": "SPDXRef-DOCUMENT", "relationshipType": "DESCRIBES", "relatedSpdxElement": "SPDXRef-app"}]}`)
+	conv := sbomRework{to: "cyclonedx", tool: "v1.2.3"}
+
+	out, err := conv.convert("app.spdx.json", spdx)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	var cdx cdxSBOM
+	if err := json.Unmarshal(out, &cdx); err != nil {
+		t.Fatalf("Converted SBOM is not JSON: %v", err)
+	}
+	if cdx.BOMFormat != "CycloneDX" || cdx.Metadata.Component.Name != "app" || cdx.SerialNumber == "" {
+		t.Errorf("Expected a CycloneDX SBOM of app with a serial number, got %s", out)
+	}
+
+	// Already CycloneDX, not an SBOM, or no conversion: unchanged.
+	for _, tt := range []struct {
+		conv sbomRework
+		blob []byte
+	}{
+		{conv, out},
+		{conv, []byte("release notes")},
+		{sbomRework{}, spdx},
+	} {
+		got, err := tt.conv.convert("file", tt.blob)
+		if err != nil || !bytes.Equal(got, tt.blob) {
+			t.Errorf("Expected %s unchanged, got %s (err %v)", tt.blob, got, err)
+		}
+	}
+}
+
+func TestSbomReworkNormalizeSubject(t *testing.T) {
+	names, err := sbom.ParseNameRules([]string{"lowercase", "_ => -"}, nil)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	rework := sbomRework{names: names}
+	blob := []byte(`{"bomFormat": "CycloneDX", "serialNumber": "urn:uuid:1", "metadata": {"component": {"name": "My_App"}}}`)
+	meta := map[string]string{"tag": "t"}
+
+	got := rework.normalizeSubject("bom.json", blob, meta)
+	if got["sbom_subject_name_override"] != "my-app" || got["tag"] != "t" {
+		t.Errorf("Expected the normalized name as override, got %v", got)
+	}
+	if _, ok := meta["sbom_subject_name_override"]; ok {
+		t.Errorf("Expected the shared upload metadata unchanged, got %v", meta)
+	}
+
+	// An explicit override, a name the rules keep, or no rules: unchanged.
+	explicit := map[string]string{"sbom_subject_name_override": "App"}
+	if got := rework.normalizeSubject("bom.json", blob, explicit); got["sbom_subject_name_override"] != "App" {
+		t.Errorf("Expected the explicit override kept, got %v", got)
+	}
+	kept := []byte(`{"bomFormat": "CycloneDX", "metadata": {"component": {"name": "my-app"}}}`)
+	if got := rework.normalizeSubject("bom.json", kept, meta); len(got) != 1 {
+		t.Errorf("Expected no override, got %v", got)
+	}
+	if got := (sbomRework{}).normalizeSubject("bom.json", blob, meta); len(got) != 1 {
+		t.Errorf("Expected no override without rules, got %v", got)
+	}
+}
@@ -7,6 +7,8 @@ import (
 	"fmt"
 	"io"
 	"sync"
+
+	"github.com/kusaridev/kusari-cli/v2/pkg/output"
 )
 
 // waitPrinter prints a single shared progress line for the concurrent
@@ -27,6 +29,10 @@ func newWaitPrinter(out io.Writer) *waitPrinter {
 
 // tick records one poll retry, printing the header on the first wait.
 func (p *waitPrinter) tick() {
+	if output.Quiet() {
+		return
+	}
+
 	p.mu.Lock()
 	defer p.mu.Unlock()
 
@@ -37,7 +43,8 @@ func (p *waitPrinter) tick() {
 	_, _ = fmt.Fprint(p.out, "#")
 }
 
-// close terminates the progress line. No-op if nothing ever waited.
+// close terminates the progress line. No-op if nothing ever waited (which
+// includes quiet mode, where tick prints nothing).
 func (p *waitPrinter) close() {
 	p.mu.Lock()
 	defer p.mu.Unlock()
@@ -0,0 +1,172 @@
+// Copyright (c) Kusari <https://www.kusari.dev/>
+// SPDX-License-Identifier: MIT
+
+package repo
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/kusaridev/kusari-cli/v2/pkg/clierrors"
+	"github.com/kusaridev/kusari-cli/v2/pkg/output"
+	"gopkg.in/yaml.v3"
+)
+
+// blockedPackage is a package on the workspace's blocked package list that
+// an uploaded SBOM uses.
+type blockedPackage struct {
+	Purl        string `json:"purl"`
+	SbomSubject string `json:"sbom_subject"`
+	SbomURI     string `json:"sbom_uri"`
+	SoftwareID  int64  `json:"software_id"`
+	SbomID      int64  `json:"sbom_id"`
+	// Waiver is the waiver matching the package, expired or not.
+	Waiver *waiver `json:"waiver,omitempty"`
+	// Waived is whether Waiver is in force, downgrading the package to a
+	// warning.
+	Waived bool `json:"waived"`
+}
+
+// blockedReport is written to UploadOptions.BlockedReport.
+type blockedReport struct {
+	// Blocked is whether the upload failed: some blocked package is not
+	// waived.
+	Blocked  bool             `json:"blocked"`
+	Packages []blockedPackage `json:"packages"`
+}
+
+// waiver approves the use of a blocked package until it expires.
+type waiver struct {
+	// Purl is the package waived. Without a version, it waives every
+	// version of the package.
+	Purl string `yaml:"purl" json:"purl"`
+	// Expires is the last day, YYYY-MM-DD in UTC, on which the waiver is in
+	// force.
+	Expires    string `yaml:"expires" json:"expires"`
+	Reason     string `yaml:"reason,omitempty" json:"reason,omitempty"`
+	ApprovedBy string `yaml:"approved_by,omitempty" json:"approved_by,omitempty"`
+
+	expiresAt time.Time
+}
+
+// waiversFile is the layout of a --waivers file.
+type waiversFile struct {
+	Waivers []waiver `yaml:"waivers"`
+}
+
+// loadWaivers reads the waivers file at path.
+func loadWaivers(path string) ([]waiver, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, clierrors.NewValidationError("failed to read waivers file: %v", err)
+	}
+	var f waiversFile
+	if err := yaml.Unmarshal(data, &f); err != nil {
+		return nil, clierrors.NewValidationError("invalid waivers file %s: %v", path, err)
+	}
+	for i := range f.Waivers {
+		w := &f.Waivers[i]
+		if w.Purl == "" {
+			return nil, clierrors.NewValidationError("invalid waivers file %s: waiver %d has no purl", path, i+1)
+		}
+		day, err := time.Parse(time.DateOnly, w.Expires)
+		if err != nil {
+			return nil, clierrors.NewValidationError("invalid waivers file %s: waiver for %s needs an expires date as YYYY-MM-DD", path, w.Purl)
+		}
+		w.expiresAt = day.AddDate(0, 0, 1)
+	}
+	return f.Waivers, nil
+}
+
+// matches reports whether w waives purl.
+func (w waiver) matches(purl string) bool {
+	if w.Purl == purl {
+		return true
+	}
+	if strings.Contains(w.Purl, "@") {
+		return false
+	}
+	name, _, _ := strings.Cut(purl, "@")
+	name, _, _ = strings.Cut(name, "?")
+	name, _, _ = strings.Cut(name, "#")
+	return name == w.Purl
+}
+
+// applyWaivers marks the packages waived by a waiver in force at now, and
+// reports whether any package remains blocked.
+func applyWaivers(packages []blockedPackage, waivers []waiver, now time.Time) bool {
+	blocked := false
+	for i := range packages {
+		p := &packages[i]
+		for _, w := range waivers {
+			if !w.matches(p.Purl) {
+				continue
+			}
+			p.Waiver = &w
+			if now.Before(w.expiresAt) {
+				p.Waived = true
+				break
+			}
+		}
+		if !p.Waived {
+			blocked = true
+		}
+	}
+	return blocked
+}
+
+// printBlockedPackages lists the blocked packages by SBOM, noting those
+// waived and the waivers that expired.
+func printBlockedPackages(packages []blockedPackage) {
+	var last string
+	for _, p := range packages {
+		if sbom := p.SbomSubject + "\x00" + p.SbomURI; sbom != last {
+			fmt.Printf("\nBlocked packages found for SBOM subject %s with URI %s:\n", p.SbomSubject, p.SbomURI)
+			last = sbom
+		}
+		switch {
+		case p.Waived:
+			fmt.Printf("  - %s (waived until %s%s)\n", p.Purl, p.Waiver.Expires, waiverReason(p.Waiver))
+		case p.Waiver != nil:
+			fmt.Printf("  - %s (waiver expired %s)\n", p.Purl, p.Waiver.Expires)
+		default:
+			fmt.Printf("  - %s\n", p.Purl)
+		}
+	}
+}
+
+func waiverReason(w *waiver) string {
+	if w.Reason == "" {
+		return ""
+	}
+	return ": " + w.Reason
+}
+
+// warnWaived warns about the blocked packages let through by a waiver.
+func warnWaived(packages []blockedPackage) {
+	for _, p := range packages {
+		if p.Waived {
+			output.Progressf(os.Stderr, "⚠ Blocked package %s is waived until %s\n", p.Purl, p.Waiver.Expires)
+		}
+	}
+}
+
+// writeBlockedReport writes the blocked packages found, and whether the
+// upload failed because of them, to path as JSON.
+func writeBlockedReport(path string, packages []blockedPackage, blocked bool) error {
+	if packages == nil {
+		packages = []blockedPackage{}
+	}
+	data, err := json.MarshalIndent(blockedReport{Blocked: blocked, Packages: packages}, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal blocked package report: %w", err)
+	}
+	data = append(data, '\n')
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		return fmt.Errorf("failed to write blocked package report: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,114 @@
+// Copyright (c) Kusari <https://www.kusari.dev/>
+// SPDX-License-Identifier: MIT
+
+package repo
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeWaivers(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "waivers.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(content), 0600))
+	return path
+}
+
+func TestLoadWaivers(t *testing.T) {
+	waivers, err := loadWaivers(writeWaivers(t, `waivers:
+  - purl: pkg:npm/lodash@4.17.20
+    expires: 2026-12-31
+    reason: not reachable
+    approved_by: security@example.com
+  - purl: pkg:golang/example.com/lib
+    expires: "2026-06-30"
+`))
+	require.NoError(t, err)
+	require.Len(t, waivers, 2)
+	assert.Equal(t, "not reachable", waivers[0].Reason)
+	assert.Equal(t, time.Date(2027, 1, 1, 0, 0, 0, 0, time.UTC), waivers[0].expiresAt)
+
+	_, err = loadWaivers(writeWaivers(t, "waivers:\n  - purl: pkg:npm/a@1\n"))
+	assert.ErrorContains(t, err, "needs an expires date")
+	_, err = loadWaivers(writeWaivers(t, "waivers:\n  - expires: 2026-12-31\n"))
+	assert.ErrorContains(t, err, "has no purl")
+	_, err = loadWaivers(filepath.Join(t.TempDir(), "missing.yaml"))
+	assert.ErrorContains(t, err, "failed to read waivers file")
+}
+
+func TestWaiverMatches(t *testing.T) {
+	exact := waiver{Purl: "pkg:npm/lodash@4.17.20"}
+	assert.True(t, exact.matches("pkg:npm/lodash@4.17.20"))
+	assert.False(t, exact.matches("pkg:npm/lodash@4.17.21"))
+
+	anyVersion := waiver{Purl: "pkg:npm/lodash"}
+	assert.True(t, anyVersion.matches("pkg:npm/lodash@4.17.21"))
+	assert.True(t, anyVersion.matches("pkg:npm/lodash?arch=x86"))
+	assert.False(t, anyVersion.matches("pkg:npm/lodash-es@4.17.21"))
+}
+
+func TestApplyWaivers(t *testing.T) {
+	now := time.Date(2026, 10, 14, 12, 0, 0, 0, time.UTC)
+	waivers := []waiver{
+		{Purl: "pkg:npm/a@1", Expires: "2026-10-14", expiresAt: time.Date(2026, 10, 15, 0, 0, 0, 0, time.UTC)},
+		{Purl: "pkg:npm/b", Expires: "2026-10-13", expiresAt: time.Date(2026, 10, 14, 0, 0, 0, 0, time.UTC)},
+	}
+
+	packages := []blockedPackage{{Purl: "pkg:npm/a@1"}}
+	assert.False(t, applyWaivers(packages, waivers, now))
+	assert.True(t, packages[0].Waived)
+
+	packages = []blockedPackage{{Purl: "pkg:npm/a@1"}, {Purl: "pkg:npm/b@2"}, {Purl: "pkg:npm/c@3"}}
+	assert.True(t, applyWaivers(packages, waivers, now))
+	assert.True(t, packages[0].Waived)
+	assert.False(t, packages[1].Waived, "expired waiver")
+	require.NotNil(t, packages[1].Waiver)
+	assert.Equal(t, "2026-10-13", packages[1].Waiver.Expires)
+	assert.Nil(t, packages[2].Waiver)
+
+	assert.False(t, applyWaivers(nil, waivers, now))
+}
+
+func TestWriteBlockedReport(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "blocked.json")
+	packages := []blockedPackage{{
+		Purl:        "pkg:npm/a@1",
+		SbomSubject: "my-app",
+		SbomURI:     "urn:uuid:1",
+		SoftwareID:  1,
+		SbomID:      2,
+		Waiver:      &waiver{Purl: "pkg:npm/a@1", Expires: "2026-12-31"},
+		Waived:      true,
+	}}
+	require.NoError(t, writeBlockedReport(path, packages, false))
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	var report map[string]any
+	require.NoError(t, json.Unmarshal(data, &report))
+	assert.Equal(t, false, report["blocked"])
+	pkg := report["packages"].([]any)[0].(map[string]any)
+	assert.Equal(t, "pkg:npm/a@1", pkg["purl"])
+	assert.Equal(t, "my-app", pkg["sbom_subject"])
+	assert.NotContains(t, pkg, "policy")
+	assert.Equal(t, "2026-12-31", pkg["waiver"].(map[string]any)["expires"])
+
+	require.NoError(t, writeBlockedReport(path, nil, false))
+	data, err = os.ReadFile(path)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"blocked": false, "packages": []}`, string(data))
+}
+
+func TestUploadOptionsValidateWaivers(t *testing.T) {
+	opts := UploadOptions{FilePath: "sbom.json", TenantEndpoint: "https://demo.api.us.kusari.cloud", Wait: true, Waivers: "waivers.yaml"}
+	assert.ErrorContains(t, opts.validate(), "require --check-blocked-packages")
+	opts.CheckBlockedPackages = true
+	assert.NoError(t, opts.validate())
+}
@@ -0,0 +1,79 @@
+// Copyright (c) Kusari <https://www.kusari.dev/>
+// SPDX-License-Identifier: MIT
+
+package results
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// defectDojoReport is DefectDojo's Generic Findings Import JSON, the
+// scan_type "Generic Findings Import".
+type defectDojoReport struct {
+	Findings []defectDojoFinding `json:"findings"`
+}
+
+type defectDojoFinding struct {
+	Title            string `json:"title"`
+	Description      string `json:"description"`
+	Severity         string `json:"severity"` // Info, Low, Medium, High or Critical
+	Mitigation       string `json:"mitigation,omitempty"`
+	References       string `json:"references,omitempty"`
+	Date             string `json:"date"` // YYYY-MM-DD
+	FilePath         string `json:"file_path,omitempty"`
+	Line             int    `json:"line,omitempty"`
+	UniqueIDFromTool string `json:"unique_id_from_tool"`
+	VulnIDFromTool   string `json:"vuln_id_from_tool"`
+	StaticFinding    bool   `json:"static_finding"`
+	DynamicFinding   bool   `json:"dynamic_finding"`
+	Active           bool   `json:"active"`
+	Verified         bool   `json:"verified"`
+}
+
+// DefectDojo Generic Findings Import titles are limited to 511 characters.
+const maxDefectDojoTitle = 511
+
+// WriteDefectDojo writes the findings of res as DefectDojo Generic Findings
+// Import JSON, dated date. Each finding keeps the same unique_id_from_tool
+// across scans, so DefectDojo deduplicates reimports.
+func WriteDefectDojo(w io.Writer, res *Result, date time.Time) error {
+	report := defectDojoReport{Findings: []defectDojoFinding{}}
+	for _, r := range res.Rows() {
+		title, _, _ := strings.Cut(r.Content, "\n")
+		if len(title) > maxDefectDojoTitle {
+			title = strings.ToValidUTF8(title[:maxDefectDojoTitle-3], "") + "..."
+		}
+
+		description := r.Content
+		if r.Path != "" {
+			description = fmt.Sprintf("%s\n\n**Location:** %s", r.Content, location(r))
+		}
+		mitigation := ""
+		if r.Code != "" {
+			mitigation = fmt.Sprintf("Potential code fix:\n\n```\n%s\n```", strings.TrimRight(r.Code, "\n"))
+		}
+
+		report.Findings = append(report.Findings, defectDojoFinding{
+			Title:            title,
+			Description:      description,
+			Severity:         strings.ToUpper(r.Severity[:1]) + r.Severity[1:],
+			Mitigation:       mitigation,
+			References:       res.ConsoleURL,
+			Date:             date.Format(time.DateOnly),
+			FilePath:         r.Path,
+			Line:             r.Line,
+			UniqueIDFromTool: findingKey(r),
+			VulnIDFromTool:   "kusari-" + r.Type,
+			StaticFinding:    true,
+			Active:           true,
+		})
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(report)
+}
@@ -0,0 +1,201 @@
+// Copyright (c) Kusari <https://www.kusari.dev/>
+// SPDX-License-Identifier: MIT
+
+package results
+
+import (
+	"archive/zip"
+	"encoding/csv"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// Columns is the header of exported findings.
+var Columns = []string{"Type", "Path", "Line", "Content", "Code", "Severity", "Status", "ID"}
+
+// Row is one finding flattened for a spreadsheet.
+type Row struct {
+	// ID is the finding's stable ID, as api.FindingID.
+	ID       string `json:"id"`
+	Type     string `json:"type"` // "code" or "dependency"
+	Path     string `json:"path,omitempty"`
+	Line     int    `json:"line,omitempty"` // 0 when unknown
+	Content  string `json:"content"`
+	Code     string `json:"code,omitempty"`
+	Severity string `json:"severity"`
+	Status   string `json:"status"`
+}
+
+func (r Row) values() []string {
+	line := ""
+	if r.Line > 0 {
+		line = strconv.Itoa(r.Line)
+	}
+	return []string{r.Type, r.Path, line, r.Content, r.Code, r.Severity, r.Status, r.ID}
+}
+
+// cell returns v as a spreadsheet opening a CSV shows it literally.
+// Findings quote repository content, and a value starting with one of
+// these would be evaluated as a formula, so it is prefixed with an
+// apostrophe. XLSX inline strings are never evaluated, and need none.
+func cell(v string) string {
+	if v != "" && strings.ContainsRune("=+-@\t\r", rune(v[0])) {
+		return "'" + v
+	}
+	return v
+}
+
+// Rows flattens the code and dependency mitigations of res. Inspector
+// doesn't grade individual mitigations, so every finding takes the
+// severity of the verdict: "high" when the change should not proceed,
+// "medium" otherwise. Exported findings are always "open"; they are what
+// remains to be fixed.
+func (res *Result) Rows() []Row {
+	a := res.Analysis
+	if a == nil {
+		return nil
+	}
+	severity := "medium"
+	if !a.ShouldProceed {
+		severity = "high"
+	}
+
+	rows := make([]Row, 0, len(a.RequiredCodeMitigations)+len(a.RequiredDependencyMitigations))
+	for _, m := range a.RequiredCodeMitigations {
+		rows = append(rows, Row{
+			ID:       m.ID(),
+			Type:     "code",
+			Path:     m.Path,
+			Line:     m.LineNumber,
+			Content:  m.Content,
+			Code:     m.Code,
+			Severity: severity,
+			Status:   "open",
+		})
+	}
+	for _, m := range a.RequiredDependencyMitigations {
+		rows = append(rows, Row{
+			ID:       m.ID(),
+			Type:     "dependency",
+			Content:  m.Content,
+			Severity: severity,
+			Status:   "open",
+		})
+	}
+	return rows
+}
+
+// WriteCSV writes rows as CSV with a header line.
+func WriteCSV(w io.Writer, rows []Row) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write(Columns); err != nil {
+		return err
+	}
+	for _, r := range rows {
+		values := r.values()
+		for i, v := range values {
+			values[i] = cell(v)
+		}
+		if err := cw.Write(values); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// WriteXLSX writes rows as a single-sheet Office Open XML workbook with a
+// bold, frozen header row. The workbook is built directly with
+// archive/zip: it only needs inline strings and one cell style.
+func WriteXLSX(w io.Writer, rows []Row) error {
+	zw := zip.NewWriter(w)
+	parts := []struct {
+		name string
+		body string
+	}{
+		{"[Content_Types].xml", xlsxContentTypes},
+		{"_rels/.rels", xlsxRels},
+		{"xl/workbook.xml", xlsxWorkbook},
+		{"xl/_rels/workbook.xml.rels", xlsxWorkbookRels},
+		{"xl/styles.xml", xlsxStyles},
+		{"xl/worksheets/sheet1.xml", xlsxSheet(rows)},
+	}
+	for _, p := range parts {
+		f, err := zw.Create(p.name)
+		if err != nil {
+			return err
+		}
+		if _, err := io.WriteString(f, p.body); err != nil {
+			return err
+		}
+	}
+	return zw.Close()
+}
+
+func xlsxSheet(rows []Row) string {
+	var sb strings.Builder
+	sb.WriteString(xml.Header)
+	sb.WriteString(`<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main">`)
+	sb.WriteString(`<sheetViews><sheetView workbookViewId="0"><pane ySplit="1" topLeftCell="A2" activePane="bottomLeft" state="frozen"/></sheetView></sheetViews>`)
+	sb.WriteString(`<cols><col min="1" max="1" width="12" customWidth="1"/><col min="2" max="2" width="40" customWidth="1"/><col min="3" max="3" width="8" customWidth="1"/><col min="4" max="5" width="80" customWidth="1"/><col min="6" max="7" width="10" customWidth="1"/></cols>`)
+	sb.WriteString(`<sheetData>`)
+	xlsxRow(&sb, 1, Columns, true)
+	for i, r := range rows {
+		xlsxRow(&sb, i+2, r.values(), false)
+	}
+	sb.WriteString(`</sheetData></worksheet>`)
+	return sb.String()
+}
+
+func xlsxRow(sb *strings.Builder, n int, values []string, header bool) {
+	fmt.Fprintf(sb, `<row r="%d">`, n)
+	for i, v := range values {
+		ref := fmt.Sprintf("%c%d", 'A'+i, n)
+		style := ""
+		if header {
+			style = ` s="1"`
+		}
+		if i == 2 && v != "" && !header {
+			// Line numbers are numeric cells so they sort and filter.
+			fmt.Fprintf(sb, `<c r="%s"%s><v>%s</v></c>`, ref, style, v)
+			continue
+		}
+		fmt.Fprintf(sb, `<c r="%s" t="inlineStr"%s><is><t xml:space="preserve">`, ref, style)
+		_ = xml.EscapeText(sb, []byte(v))
+		sb.WriteString(`</t></is></c>`)
+	}
+	sb.WriteString(`</row>`)
+}
+
+const xlsxContentTypes = xml.Header + `<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types">` +
+	`<Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/>` +
+	`<Default Extension="xml" ContentType="application/xml"/>` +
+	`<Override PartName="/xl/workbook.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml"/>` +
+	`<Override PartName="/xl/worksheets/sheet1.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"/>` +
+	`<Override PartName="/xl/styles.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.styles+xml"/>` +
+	`</Types>`
+
+const xlsxRels = xml.Header + `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+	`<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="xl/workbook.xml"/>` +
+	`</Relationships>`
+
+const xlsxWorkbook = xml.Header + `<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships">` +
+	`<sheets><sheet name="Findings" sheetId="1" r:id="rId1"/></sheets>` +
+	`</workbook>`
+
+const xlsxWorkbookRels = xml.Header + `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+	`<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet1.xml"/>` +
+	`<Relationship Id="rId2" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/styles" Target="styles.xml"/>` +
+	`</Relationships>`
+
+// xlsxStyles defines cell style 0 (default) and 1 (bold header).
+const xlsxStyles = xml.Header + `<styleSheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main">` +
+	`<fonts count="2"><font><sz val="11"/><name val="Calibri"/></font><font><b/><sz val="11"/><name val="Calibri"/></font></fonts>` +
+	`<fills count="2"><fill><patternFill patternType="none"/></fill><fill><patternFill patternType="gray125"/></fill></fills>` +
+	`<borders count="1"><border><left/><right/><top/><bottom/><diagonal/></border></borders>` +
+	`<cellStyleXfs count="1"><xf numFmtId="0" fontId="0" fillId="0" borderId="0"/></cellStyleXfs>` +
+	`<cellXfs count="2"><xf numFmtId="0" fontId="0" fillId="0" borderId="0" xfId="0"/><xf numFmtId="0" fontId="1" fillId="0" borderId="0" xfId="0" applyFont="1"/></cellXfs>` +
+	`</styleSheet>`
@@ -0,0 +1,50 @@
+// Copyright (c) Kusari <https://www.kusari.dev/>
+// SPDX-License-Identifier: MIT
+
+package results
+
+import (
+	"html/template"
+	"strings"
+
+	"github.com/alecthomas/chroma/v2"
+	chromahtml "github.com/alecthomas/chroma/v2/formatters/html"
+	"github.com/alecthomas/chroma/v2/lexers"
+	"github.com/alecthomas/chroma/v2/styles"
+)
+
+// highlightStyle is the chroma style of code snippets in reports. A light
+// style, so snippets stay readable when printed.
+var highlightStyle = styles.Get("github")
+
+var highlighter = chromahtml.New(chromahtml.WithClasses(true), chromahtml.PreventSurroundingPre(false))
+
+// highlight returns code as highlighted HTML, picking the language from
+// path. Code in an unknown language is returned escaped but uncolored.
+func highlight(path, code string) template.HTML {
+	lexer := lexers.Match(path)
+	if lexer == nil {
+		lexer = lexers.Analyse(code)
+	}
+	if lexer == nil {
+		lexer = lexers.Fallback
+	}
+	it, err := chroma.Coalesce(lexer).Tokenise(nil, code)
+	if err != nil {
+		return template.HTML("<pre>" + template.HTMLEscapeString(code) + "</pre>")
+	}
+	var sb strings.Builder
+	if err := highlighter.Format(&sb, highlightStyle, it); err != nil {
+		return template.HTML("<pre>" + template.HTMLEscapeString(code) + "</pre>")
+	}
+	return template.HTML(sb.String())
+}
+
+// highlightCSS returns the stylesheet for the classes highlight emits.
+func highlightCSS() template.CSS {
+	var sb strings.Builder
+	if err := highlighter.WriteCSS(&sb, highlightStyle); err != nil {
+		return ""
+	}
+	return template.CSS(sb.String())
+}
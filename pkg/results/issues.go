@@ -0,0 +1,125 @@
+// Copyright (c) Kusari <https://www.kusari.dev/>
+// SPDX-License-Identifier: MIT
+
+package results
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/kusaridev/kusari-cli/v2/api"
+)
+
+// Issue is a tracker issue for one finding, or for the findings of one
+// file.
+type Issue struct {
+	// Key identifies the finding or file across runs; it is embedded in
+	// Body with IssueMarker so an issue is only opened once.
+	Key   string
+	Title string
+	Body  string
+}
+
+var issueMarkerRegex = regexp.MustCompile(`<!-- KUSARI_ISSUE:(\S+) -->`)
+
+// IssueMarker returns the hidden marker identifying the issue for key.
+func IssueMarker(key string) string {
+	return fmt.Sprintf("<!-- KUSARI_ISSUE:%s -->", key)
+}
+
+// ParseIssueMarker returns the key of the issue with the given body, or
+// false if body has no marker.
+func ParseIssueMarker(body string) (string, bool) {
+	m := issueMarkerRegex.FindStringSubmatch(body)
+	if m == nil {
+		return "", false
+	}
+	return m[1], true
+}
+
+// maxTitle is the length issue titles are cut to.
+const maxTitle = 100
+
+// Issues returns an issue per finding of res, or per file when byFile is
+// set. Dependency findings have no file and are grouped together.
+func (res *Result) Issues(byFile bool) []Issue {
+	rows := res.Rows()
+	if !byFile {
+		issues := make([]Issue, 0, len(rows))
+		for _, r := range rows {
+			title := r.Content
+			if r.Path != "" {
+				title = fmt.Sprintf("%s (%s)", r.Content, location(r))
+			}
+			issues = append(issues, res.issue(findingKey(r), title, []Row{r}))
+		}
+		return issues
+	}
+
+	var order []string
+	groups := map[string][]Row{}
+	for _, r := range rows {
+		if _, ok := groups[r.Path]; !ok {
+			order = append(order, r.Path)
+		}
+		groups[r.Path] = append(groups[r.Path], r)
+	}
+	issues := make([]Issue, 0, len(order))
+	for _, path := range order {
+		group := groups[path]
+		key, title := "file:"+shortHash(path), fmt.Sprintf("%d finding(s) in %s", len(group), path)
+		if path == "" {
+			key, title = "dependencies", fmt.Sprintf("%d dependency finding(s)", len(group))
+		}
+		issues = append(issues, res.issue(key, title, group))
+	}
+	return issues
+}
+
+func (res *Result) issue(key, title string, rows []Row) Issue {
+	title = "Kusari: " + title
+	if len(title) > maxTitle {
+		title = strings.ToValidUTF8(title[:maxTitle-3], "") + "..."
+	}
+
+	var sb strings.Builder
+	for _, r := range rows {
+		fmt.Fprintf(&sb, "### %s\n\n", r.Content)
+		if r.Path != "" {
+			fmt.Fprintf(&sb, "- **Location:** %s\n", location(r))
+		}
+		fmt.Fprintf(&sb, "- **Type:** %s\n- **Severity:** %s\n", r.Type, r.Severity)
+		if r.Code != "" {
+			fmt.Fprintf(&sb, "- **Potential Code Fix:**\n\n```\n%s\n```\n", strings.TrimRight(r.Code, "\n"))
+		}
+		sb.WriteString("\n")
+	}
+	if res.ConsoleURL != "" {
+		fmt.Fprintf(&sb, "[View the full analysis in the Kusari console](%s)\n\n", res.ConsoleURL)
+	}
+	sb.WriteString("_Opened by `kusari results create-issues`._\n\n")
+	sb.WriteString(IssueMarker(key))
+
+	return Issue{Key: key, Title: title, Body: sb.String()}
+}
+
+func location(r Row) string {
+	if r.Line > 0 {
+		return fmt.Sprintf("%s:%d", r.Path, r.Line)
+	}
+	return r.Path
+}
+
+// findingKey identifies a finding by its stable ID.
+func findingKey(r Row) string {
+	return "finding:" + api.FindingID(r.Type, r.Path, r.Content)
+}
+
+// shortHash keeps keys free of spaces and short enough for a marker.
+func shortHash(s string) string {
+	sum := sha256.Sum256([]byte(s))
+	return hex.EncodeToString(sum[:8])
+}
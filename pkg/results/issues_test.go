@@ -0,0 +1,64 @@
+// Copyright (c) Kusari <https://www.kusari.dev/>
+// SPDX-License-Identifier: MIT
+
+package results
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/kusaridev/kusari-cli/v2/api"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIssues(t *testing.T) {
+	res := testResult()
+	res.ConsoleURL = "https://console.example.com/r"
+
+	issues := res.Issues(false)
+	require.Len(t, issues, 2)
+	assert.Equal(t, "Kusari: Remove hardcoded <secret> (main.go:3)", issues[0].Title)
+	assert.Contains(t, issues[0].Body, "```\nkey := \"abc\"\n```")
+	assert.Contains(t, issues[0].Body, "https://console.example.com/r")
+	assert.Equal(t, "Kusari: Upgrade golang.org/x/net to v0.38.0", issues[1].Title)
+
+	for _, i := range issues {
+		key, ok := ParseIssueMarker(i.Body)
+		require.True(t, ok)
+		assert.Equal(t, i.Key, key)
+	}
+
+	// Keys don't depend on the line, which moves as the file changes
+	moved := testResult()
+	moved.Analysis.RequiredCodeMitigations[0].LineNumber = 10
+	assert.Equal(t, issues[0].Key, moved.Issues(false)[0].Key)
+}
+
+func TestIssuesByFile(t *testing.T) {
+	res := testResult()
+	res.Analysis.RequiredCodeMitigations = append(res.Analysis.RequiredCodeMitigations,
+		api.CodeMitigationItem{Path: "main.go", LineNumber: 8, Content: "Validate input"},
+		api.CodeMitigationItem{Path: "my file.go", LineNumber: 1, Content: "Close the body"},
+	)
+
+	issues := res.Issues(true)
+	require.Len(t, issues, 3)
+	assert.Equal(t, "Kusari: 2 finding(s) in main.go", issues[0].Title)
+	assert.Contains(t, issues[0].Body, "### Validate input")
+	assert.Equal(t, "Kusari: 1 finding(s) in my file.go", issues[1].Title)
+	assert.Equal(t, "Kusari: 1 dependency finding(s)", issues[2].Title)
+
+	key, ok := ParseIssueMarker(issues[1].Body)
+	require.True(t, ok)
+	assert.Equal(t, issues[1].Key, key)
+}
+
+func TestIssuesLongTitle(t *testing.T) {
+	res := &Result{Analysis: &api.SecurityAnalysis{
+		RequiredDependencyMitigations: []api.DependencyMitigationItem{{Content: strings.Repeat("x", 300)}},
+	}}
+	title := res.Issues(false)[0].Title
+	assert.Len(t, title, maxTitle)
+	assert.True(t, strings.HasSuffix(title, "..."))
+}
@@ -0,0 +1,124 @@
+// Copyright (c) Kusari <https://www.kusari.dev/>
+// SPDX-License-Identifier: MIT
+
+package results
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+)
+
+// EnvChromeBin points at the Chrome or Chromium binary used to print PDF
+// reports, when it isn't on PATH under a usual name.
+const EnvChromeBin = "KUSARI_CHROME_BIN"
+
+// chromeNames are the executables looked up on PATH, in order.
+var chromeNames = []string{"google-chrome", "google-chrome-stable", "chromium", "chromium-browser", "chrome", "msedge"}
+
+// chromePaths are the usual install locations of browsers that aren't on
+// PATH, per OS.
+var chromePaths = map[string][]string{
+	"darwin": {
+		"/Applications/Google Chrome.app/Contents/MacOS/Google Chrome",
+		"/Applications/Chromium.app/Contents/MacOS/Chromium",
+		"/Applications/Microsoft Edge.app/Contents/MacOS/Microsoft Edge",
+	},
+	"windows": {
+		`C:\Program Files\Google\Chrome\Application\chrome.exe`,
+		`C:\Program Files (x86)\Google\Chrome\Application\chrome.exe`,
+		`C:\Program Files (x86)\Microsoft\Edge\Application\msedge.exe`,
+	},
+}
+
+// FindChrome returns the path of a Chrome-compatible browser for printing
+// PDFs: EnvChromeBin if set, else the first one found on PATH or in the
+// usual install locations.
+func FindChrome() (string, error) {
+	if p := os.Getenv(EnvChromeBin); p != "" {
+		if _, err := os.Stat(p); err != nil {
+			return "", fmt.Errorf("%s=%q: %w", EnvChromeBin, p, err)
+		}
+		return p, nil
+	}
+	for _, name := range chromeNames {
+		if p, err := exec.LookPath(name); err == nil {
+			return p, nil
+		}
+	}
+	for _, p := range chromePaths[runtime.GOOS] {
+		if _, err := os.Stat(p); err == nil {
+			return p, nil
+		}
+	}
+	return "", fmt.Errorf("PDF reports need Chrome or Chromium to print the HTML report; install one or set %s=/path/to/chrome", EnvChromeBin)
+}
+
+// WritePDF renders res with the HTML report template and prints it to a
+// PDF at outPath with headless Chrome.
+func WritePDF(ctx context.Context, res *Result, opts ReportOptions, outPath string) error {
+	chrome, err := FindChrome()
+	if err != nil {
+		return err
+	}
+
+	dir, err := os.MkdirTemp("", "kusari-report-")
+	if err != nil {
+		return fmt.Errorf("failed to create temp directory: %w", err)
+	}
+	defer os.RemoveAll(dir)
+
+	htmlPath := filepath.Join(dir, "report.html")
+	f, err := os.Create(htmlPath)
+	if err != nil {
+		return fmt.Errorf("failed to create %s: %w", htmlPath, err)
+	}
+	if err := WriteHTML(f, res, opts); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("failed to write %s: %w", htmlPath, err)
+	}
+
+	// Chrome prints into the temp directory so a stale file at outPath is
+	// never mistaken for its output.
+	pdfPath := filepath.Join(dir, "report.pdf")
+	args := []string{
+		"--headless",
+		"--disable-gpu",
+		"--no-pdf-header-footer",
+		"--user-data-dir=" + filepath.Join(dir, "profile"),
+		"--print-to-pdf=" + pdfPath,
+	}
+	if runtime.GOOS == "linux" && os.Geteuid() == 0 {
+		// Chrome refuses to start as root with its sandbox enabled, which
+		// is the norm in CI containers.
+		args = append(args, "--no-sandbox")
+	}
+	fileURL := filepath.ToSlash(htmlPath)
+	if !strings.HasPrefix(fileURL, "/") {
+		fileURL = "/" + fileURL
+	}
+	args = append(args, "file://"+fileURL)
+
+	var stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, chrome, args...)
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("failed to print PDF with %s: %w\n%s", chrome, err, bytes.TrimSpace(stderr.Bytes()))
+	}
+	pdf, err := os.ReadFile(pdfPath)
+	if err != nil {
+		return fmt.Errorf("%s did not write a PDF: %s", chrome, bytes.TrimSpace(stderr.Bytes()))
+	}
+	if err := os.WriteFile(outPath, pdf, 0644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", outPath, err)
+	}
+	return nil
+}
@@ -0,0 +1,114 @@
+// Copyright (c) Kusari <https://www.kusari.dev/>
+// SPDX-License-Identifier: MIT
+
+package results
+
+import (
+	"embed"
+	"fmt"
+	"html/template"
+	"io"
+	"maps"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/kusaridev/kusari-cli/v2/api"
+)
+
+//go:embed templates/report.html.tmpl
+var templateFS embed.FS
+
+var reportTemplate = template.Must(template.New("report.html.tmpl").Funcs(template.FuncMap{
+	"titleize":     titleize,
+	"join":         strings.Join,
+	"highlight":    highlight,
+	"highlightCSS": highlightCSS,
+	"passed": func(checks []api.Check) int {
+		n := 0
+		for _, c := range checks {
+			if c.Pass {
+				n++
+			}
+		}
+		return n
+	},
+}).ParseFS(templateFS, "templates/report.html.tmpl"))
+
+// ReportOptions customizes a rendered report.
+type ReportOptions struct {
+	// Title is shown in the report header. Defaults to "Kusari Inspector
+	// Report".
+	Title string
+	// GeneratedAt is the time printed on the report. Defaults to now.
+	GeneratedAt time.Time
+}
+
+// Category is one health category of a risk check, for the report.
+type Category struct {
+	Name string
+	api.SubScan
+}
+
+type reportData struct {
+	Title       string
+	GeneratedAt string
+	*Result
+	Categories []Category
+	Code       []Row
+	Deps       []Row
+}
+
+// Categories returns the health categories of res sorted by name.
+func (res *Result) Categories() []Category {
+	keys := slices.Sorted(maps.Keys(res.Health))
+	out := make([]Category, 0, len(keys))
+	for _, k := range keys {
+		out = append(out, Category{Name: k, SubScan: res.Health[k]})
+	}
+	return out
+}
+
+// WriteHTML renders res as a single-file HTML report: styles, script and
+// highlighted snippets are inline, so it can be published as a CI artifact
+// as is. It is also the page WritePDF prints.
+func WriteHTML(w io.Writer, res *Result, opts ReportOptions) error {
+	if opts.Title == "" {
+		opts.Title = "Kusari Inspector Report"
+	}
+	if opts.GeneratedAt.IsZero() {
+		opts.GeneratedAt = time.Now()
+	}
+	if res.Analysis == nil {
+		res = &Result{Analysis: &api.SecurityAnalysis{}, Health: res.Health, Score: res.Score, ConsoleURL: res.ConsoleURL}
+	}
+
+	data := reportData{
+		Title:       opts.Title,
+		GeneratedAt: opts.GeneratedAt.Format("January 2, 2006 15:04 MST"),
+		Result:      res,
+		Categories:  res.Categories(),
+	}
+	for _, r := range res.Rows() {
+		if r.Type == "code" {
+			data.Code = append(data.Code, r)
+		} else {
+			data.Deps = append(data.Deps, r)
+		}
+	}
+
+	if err := reportTemplate.Execute(w, data); err != nil {
+		return fmt.Errorf("failed to render report: %w", err)
+	}
+	return nil
+}
+
+// titleize turns a health category key such as "supply_chain" into
+// "Supply Chain".
+func titleize(s string) string {
+	words := strings.FieldsFunc(s, func(r rune) bool { return r == '_' || r == '-' || r == ' ' })
+	for i, w := range words {
+		words[i] = strings.ToUpper(w[:1]) + w[1:]
+	}
+	return strings.Join(words, " ")
+}
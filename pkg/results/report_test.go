@@ -0,0 +1,91 @@
+// Copyright (c) Kusari <https://www.kusari.dev/>
+// SPDX-License-Identifier: MIT
+
+package results
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/kusaridev/kusari-cli/v2/api"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWriteHTML(t *testing.T) {
+	res := testResult()
+	res.Analysis.HealthScore = 2
+	res.Analysis.Recommendation = "Rotate the key before merging"
+	res.Health = api.Health{"supply_chain": {Score: 3, Checks: []api.Check{
+		{Name: "pinned-dependencies", Pass: true},
+		{Name: "signed-releases", Pass: false, Data: api.LabelWithValues{Label: "Releases", Values: []string{"v1.0.0"}}},
+	}}}
+
+	var buf bytes.Buffer
+	require.NoError(t, WriteHTML(&buf, res, ReportOptions{GeneratedAt: time.Date(2026, 1, 2, 3, 4, 0, 0, time.UTC)}))
+	html := buf.String()
+
+	assert.Contains(t, html, "<title>Kusari Inspector Report</title>")
+	assert.Contains(t, html, "January 2, 2026 03:04 UTC")
+	assert.Contains(t, html, "Flagged issues detected")
+	assert.Contains(t, html, "2/5")
+	assert.Contains(t, html, "<td>Supply Chain</td><td>3/5</td><td>1/2</td>")
+	assert.Contains(t, html, "Releases: v1.0.0")
+	assert.Contains(t, html, "main.go:3")
+	assert.Contains(t, html, "Remove hardcoded &lt;secret&gt;")
+	assert.Contains(t, html, "Upgrade golang.org/x/net to v0.38.0")
+	assert.Contains(t, html, "Rotate the key before merging")
+
+	// Self-contained: collapsible findings, highlighted snippets and their
+	// stylesheet are all inline.
+	assert.Contains(t, html, `<details class="finding high" open>`)
+	assert.Contains(t, html, `<span class="s">&#34;abc&#34;</span>`)
+	assert.Contains(t, html, ".chroma .s {")
+	assert.NotContains(t, html, `src="http`)
+	assert.NotContains(t, html, `<link`)
+}
+
+func TestWriteHTML_NoFindings(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, WriteHTML(&buf, &Result{Analysis: &api.SecurityAnalysis{ShouldProceed: true}}, ReportOptions{Title: "Acme"}))
+	assert.Contains(t, buf.String(), "<title>Acme</title>")
+	assert.Contains(t, buf.String(), "No flagged issues")
+	assert.Contains(t, buf.String(), "No findings.")
+
+	buf.Reset()
+	require.NoError(t, WriteHTML(&buf, &Result{}, ReportOptions{}))
+}
+
+func TestWritePDF(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("fake browser is a shell script")
+	}
+
+	// A stand-in browser that copies the page it was asked to print.
+	dir := t.TempDir()
+	chrome := filepath.Join(dir, "chrome")
+	require.NoError(t, os.WriteFile(chrome, []byte(`#!/bin/sh
+for a in "$@"; do
+  case "$a" in
+    --print-to-pdf=*) out="${a#--print-to-pdf=}" ;;
+    file://*) in="${a#file://}" ;;
+  esac
+done
+cp "$in" "$out"
+`), 0755))
+	t.Setenv(EnvChromeBin, chrome)
+
+	out := filepath.Join(dir, "report.pdf")
+	require.NoError(t, WritePDF(context.Background(), testResult(), ReportOptions{}, out))
+	b, err := os.ReadFile(out)
+	require.NoError(t, err)
+	assert.Contains(t, string(b), "Remove hardcoded &lt;secret&gt;")
+
+	t.Setenv(EnvChromeBin, filepath.Join(dir, "missing"))
+	assert.ErrorContains(t, WritePDF(context.Background(), testResult(), ReportOptions{}, out), EnvChromeBin)
+}
@@ -0,0 +1,159 @@
+// Copyright (c) Kusari <https://www.kusari.dev/>
+// SPDX-License-Identifier: MIT
+
+// Package results loads Kusari Inspector results from the files the CLI and
+// platform produce, and exports them in formats for people who don't use
+// the console.
+package results
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/kusaridev/kusari-cli/v2/api"
+	"github.com/kusaridev/kusari-cli/v2/pkg/sarif"
+)
+
+// Result is one Inspector analysis.
+type Result struct {
+	// SchemaVersion is the SchemaVersion the result was written with;
+	// empty in results written before it was recorded.
+	SchemaVersion string `json:"schema_version,omitempty"`
+	// Analysis is the verdict and required mitigations.
+	Analysis *api.SecurityAnalysis `json:"analysis"`
+	// Health holds the per-category checks of a risk check, keyed by
+	// category. Empty for diff scans.
+	Health api.Health `json:"health,omitempty"`
+	// Score is the overall risk check score.
+	Score int `json:"score,omitempty"`
+	// ConsoleURL links to the result in the Kusari console, when known.
+	ConsoleURL string `json:"console_url,omitempty"`
+
+	// The fields below are set on results saved with Save.
+
+	// Markdown is the summary as printed by the scan, for re-rendering.
+	Markdown string `json:"markdown,omitempty"`
+	// Kind is "scan" for diff scans and "risk-check" for full scans.
+	Kind string `json:"kind,omitempty"`
+	// Repo is the directory that was scanned.
+	Repo string `json:"repo,omitempty"`
+	// RepoFingerprint identifies the repository scanned across its
+	// clones and checkouts, as in the bundle metadata.
+	RepoFingerprint string `json:"repo_fingerprint,omitempty"`
+	// BaseRef is the git ref a diff scan compared against.
+	BaseRef string `json:"base_ref,omitempty"`
+	// SavedAt is when the result was fetched from the platform.
+	SavedAt time.Time `json:"saved_at,omitzero"`
+}
+
+// Load reads a result from path. The file may be the SARIF written by
+// `kusari repo scan --output-format sarif`, a bare SecurityAnalysis, an
+// Analysis (with rawLLMAnalysis and health), a full result record (with
+// analysis), as returned by the platform, or a Result as JSON. Its
+// findings are sorted as by api.SecurityAnalysis.SortFindings.
+func Load(path string) (*Result, error) {
+	res, err := load(path)
+	if err != nil {
+		return nil, err
+	}
+	if res.Analysis != nil {
+		res.Analysis.SortFindings()
+	}
+	return res, nil
+}
+
+func load(path string) (*Result, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read analysis: %w", err)
+	}
+
+	var probe map[string]json.RawMessage
+	if err := json.Unmarshal(data, &probe); err != nil {
+		return nil, fmt.Errorf("failed to parse analysis %s: %w", path, err)
+	}
+	if _, ok := probe["runs"]; ok {
+		var log sarif.SarifLog
+		if err := json.Unmarshal(data, &log); err != nil {
+			return nil, fmt.Errorf("failed to parse SARIF %s: %w", path, err)
+		}
+		return fromSARIF(&log), nil
+	}
+	if raw, ok := probe["analysis"]; ok {
+		var inner map[string]json.RawMessage
+		if err := json.Unmarshal(raw, &inner); err != nil {
+			return nil, fmt.Errorf("failed to parse analysis %s: %w", path, err)
+		}
+		if _, ok := inner["rawLLMAnalysis"]; !ok {
+			// A Result: saved by Save or written by `kusari repo scan
+			// --output json-file=...`.
+			var res Result
+			if err := json.Unmarshal(data, &res); err != nil {
+				return nil, fmt.Errorf("failed to parse result %s: %w", path, err)
+			}
+			return &res, nil
+		}
+		data, probe = raw, inner
+	}
+
+	if _, ok := probe["rawLLMAnalysis"]; ok {
+		var a api.Analysis
+		if err := json.Unmarshal(data, &a); err != nil {
+			return nil, fmt.Errorf("failed to parse analysis %s: %w", path, err)
+		}
+		return FromAnalysis(&a), nil
+	}
+
+	var sa api.SecurityAnalysis
+	if err := json.Unmarshal(data, &sa); err != nil {
+		return nil, fmt.Errorf("failed to parse analysis %s: %w", path, err)
+	}
+	return &Result{Analysis: &sa}, nil
+}
+
+// FromAnalysis returns the Result for a platform Analysis, sorting its
+// findings.
+func FromAnalysis(a *api.Analysis) *Result {
+	if a.RawLLMAnalysis != nil {
+		a.RawLLMAnalysis.SortFindings()
+	}
+	return &Result{SchemaVersion: SchemaVersion, Analysis: a.RawLLMAnalysis, Health: a.Health, Score: a.Score}
+}
+
+// fromSARIF rebuilds the SecurityAnalysis that sarif.ConvertToSARIF
+// encoded.
+func fromSARIF(log *sarif.SarifLog) *Result {
+	res := &Result{Analysis: &api.SecurityAnalysis{}}
+	a := res.Analysis
+	for _, run := range log.Runs {
+		for _, r := range run.Results {
+			switch r.RuleID {
+			case "security-analysis":
+				a.ShouldProceed, _ = r.Properties["should_proceed"].(bool)
+				a.FailedAnalysis, _ = r.Properties["failed_analysis"].(bool)
+				if score, ok := r.Properties["health_score"].(float64); ok {
+					a.HealthScore = int(score)
+				}
+				a.Justification, _ = r.Properties["justification"].(string)
+				a.Recommendation, _ = r.Properties["recommendation"].(string)
+				res.ConsoleURL = r.HelpUri
+			case "code-mitigation":
+				item := api.CodeMitigationItem{Content: r.Message.Text}
+				if len(r.Locations) > 0 {
+					loc := r.Locations[0].PhysicalLocation
+					item.Path = loc.ArtifactLocation.URI
+					item.LineNumber = loc.Region.StartLine
+					if loc.Region.Snippet != nil {
+						item.Code = loc.Region.Snippet.Text
+					}
+				}
+				a.RequiredCodeMitigations = append(a.RequiredCodeMitigations, item)
+			case "dependency-mitigation":
+				a.RequiredDependencyMitigations = append(a.RequiredDependencyMitigations, api.DependencyMitigationItem{Content: r.Message.Text})
+			}
+		}
+	}
+	return res
+}
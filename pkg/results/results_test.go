@@ -0,0 +1,176 @@
+// Copyright (c) Kusari <https://www.kusari.dev/>
+// SPDX-License-Identifier: MIT
+
+package results
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/kusaridev/kusari-cli/v2/api"
+	"github.com/kusaridev/kusari-cli/v2/pkg/sarif"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testResult() *Result {
+	return &Result{Analysis: &api.SecurityAnalysis{
+		ShouldProceed: false,
+		RequiredCodeMitigations: []api.CodeMitigationItem{
+			{Path: "main.go", LineNumber: 3, Content: "Remove hardcoded <secret>", Code: `key := "abc"`},
+		},
+		RequiredDependencyMitigations: []api.DependencyMitigationItem{
+			{Content: "Upgrade golang.org/x/net to v0.38.0"},
+		},
+	}}
+}
+
+func TestLoad(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, content string) string {
+		p := filepath.Join(dir, name)
+		require.NoError(t, os.WriteFile(p, []byte(content), 0600))
+		return p
+	}
+
+	res, err := Load(write("bare.json", `{"should_proceed": true, "health_score": 4}`))
+	require.NoError(t, err)
+	assert.Equal(t, 4, res.Analysis.HealthScore)
+
+	res, err = Load(write("analysis.json", `{"score": 7, "rawLLMAnalysis": {"health_score": 2}}`))
+	require.NoError(t, err)
+	assert.Equal(t, 2, res.Analysis.HealthScore)
+	assert.Equal(t, 7, res.Score)
+
+	out, err := sarif.ConvertToSARIF(&api.SecurityAnalysis{
+		ShouldProceed:           false,
+		HealthScore:             1,
+		Recommendation:          "Do not merge",
+		RequiredCodeMitigations: []api.CodeMitigationItem{{Path: "main.go", LineNumber: 3, Content: "Remove secret"}},
+	}, "https://console.example.com")
+	require.NoError(t, err)
+	res, err = Load(write("results.sarif", out))
+	require.NoError(t, err)
+	assert.False(t, res.Analysis.ShouldProceed)
+	assert.Equal(t, 1, res.Analysis.HealthScore)
+	assert.Equal(t, "Do not merge", res.Analysis.Recommendation)
+	assert.Equal(t, []api.CodeMitigationItem{{Path: "main.go", LineNumber: 3, Content: "Remove secret"}}, res.Analysis.RequiredCodeMitigations)
+
+	_, err = Load(write("bad.json", "not json"))
+	assert.Error(t, err)
+}
+
+func TestRows(t *testing.T) {
+	rows := testResult().Rows()
+	require.Len(t, rows, 2)
+	assert.Equal(t, Row{ID: api.FindingID("code", "main.go", "Remove hardcoded <secret>"), Type: "code", Path: "main.go", Line: 3, Content: "Remove hardcoded <secret>", Code: `key := "abc"`, Severity: "high", Status: "open"}, rows[0])
+	assert.Equal(t, "dependency", rows[1].Type)
+
+	assert.Empty(t, (&Result{}).Rows())
+}
+
+func TestWriteCSV(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, WriteCSV(&buf, testResult().Rows()))
+
+	records, err := csv.NewReader(&buf).ReadAll()
+	require.NoError(t, err)
+	require.Len(t, records, 3)
+	assert.Equal(t, Columns, records[0])
+	assert.Equal(t, []string{"code", "main.go", "3", "Remove hardcoded <secret>", `key := "abc"`, "high", "open", api.FindingID("code", "main.go", "Remove hardcoded <secret>")}, records[1])
+	assert.Equal(t, []string{"dependency", "", "", "Upgrade golang.org/x/net to v0.38.0", "", "high", "open", api.FindingID("dependency", "", "Upgrade golang.org/x/net to v0.38.0")}, records[2])
+}
+
+// Test that exported cells that would be evaluated as formulas are
+// written as text
+func TestExportFormulas(t *testing.T) {
+	rows := []Row{{Type: "code", Path: "@evil.go", Content: `=HYPERLINK("https://evil.example.com")`, Code: "+1+1", Severity: "-2", Status: "\tx", ID: "\rx"}}
+	want := []string{"code", "'@evil.go", "", `'=HYPERLINK("https://evil.example.com")`, "'+1+1", "'-2", "'\tx", "'\rx"}
+
+	var buf bytes.Buffer
+	require.NoError(t, WriteCSV(&buf, rows))
+	records, err := csv.NewReader(&buf).ReadAll()
+	require.NoError(t, err)
+	assert.Equal(t, want, records[1])
+
+	buf.Reset()
+	require.NoError(t, WriteXLSX(&buf, rows))
+	zr, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	require.NoError(t, err)
+	rc, err := zr.Open("xl/worksheets/sheet1.xml")
+	require.NoError(t, err)
+	sheet, err := io.ReadAll(rc)
+	require.NoError(t, err)
+	// Inline strings are never evaluated, so they are kept as they are.
+	assert.Contains(t, string(sheet), `<t xml:space="preserve">=HYPERLINK(&#34;https://evil.example.com&#34;)</t>`)
+	assert.Contains(t, string(sheet), `<t xml:space="preserve">+1+1</t>`)
+	assert.NotContains(t, string(sheet), "&#39;")
+}
+
+func TestWriteXLSX(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, WriteXLSX(&buf, testResult().Rows()))
+
+	zr, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	require.NoError(t, err)
+	files := map[string]string{}
+	for _, f := range zr.File {
+		rc, err := f.Open()
+		require.NoError(t, err)
+		b, err := io.ReadAll(rc)
+		require.NoError(t, err)
+		rc.Close()
+		files[f.Name] = string(b)
+	}
+
+	for _, name := range []string{"[Content_Types].xml", "_rels/.rels", "xl/workbook.xml", "xl/_rels/workbook.xml.rels", "xl/styles.xml"} {
+		assert.Contains(t, files, name)
+	}
+	sheet := files["xl/worksheets/sheet1.xml"]
+	assert.Contains(t, sheet, `<c r="C2"><v>3</v></c>`)
+	assert.Contains(t, sheet, "Remove hardcoded &lt;secret&gt;")
+	assert.Contains(t, sheet, `<row r="3">`)
+}
+
+func TestWriteDefectDojo(t *testing.T) {
+	res := testResult()
+	res.ConsoleURL = "https://console.kusari.cloud/x"
+	var buf bytes.Buffer
+	require.NoError(t, WriteDefectDojo(&buf, res, time.Date(2026, 3, 4, 12, 0, 0, 0, time.UTC)))
+
+	var report struct {
+		Findings []map[string]any `json:"findings"`
+	}
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &report))
+	require.Len(t, report.Findings, 2)
+
+	code := report.Findings[0]
+	assert.Equal(t, "Remove hardcoded <secret>", code["title"])
+	assert.Equal(t, "Remove hardcoded <secret>\n\n**Location:** main.go:3", code["description"])
+	assert.Equal(t, "High", code["severity"])
+	assert.Equal(t, "Potential code fix:\n\n```\nkey := \"abc\"\n```", code["mitigation"])
+	assert.Equal(t, "https://console.kusari.cloud/x", code["references"])
+	assert.Equal(t, "2026-03-04", code["date"])
+	assert.Equal(t, "main.go", code["file_path"])
+	assert.Equal(t, float64(3), code["line"])
+	assert.Equal(t, "kusari-code", code["vuln_id_from_tool"])
+	assert.Equal(t, true, code["static_finding"])
+	assert.Regexp(t, `^finding:[0-9a-f]{16}$`, code["unique_id_from_tool"])
+
+	dep := report.Findings[1]
+	assert.Equal(t, "Upgrade golang.org/x/net to v0.38.0", dep["description"])
+	assert.NotContains(t, dep, "file_path")
+	assert.NotEqual(t, code["unique_id_from_tool"], dep["unique_id_from_tool"])
+
+	// No findings is still a valid import
+	buf.Reset()
+	require.NoError(t, WriteDefectDojo(&buf, &Result{}, time.Now()))
+	assert.JSONEq(t, `{"findings": []}`, buf.String())
+}
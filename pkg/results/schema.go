@@ -0,0 +1,110 @@
+// Copyright (c) Kusari <https://www.kusari.dev/>
+// SPDX-License-Identifier: MIT
+
+package results
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+
+	"github.com/google/jsonschema-go/jsonschema"
+	"github.com/kusaridev/kusari-cli/v2/api"
+)
+
+// SchemaVersion is the version of the JSON documents the CLI writes with
+// `--output json`, recorded in their schema_version field. It changes only
+// when a field is removed, renamed or changes type; fields are added
+// without a new version.
+const SchemaVersion = "1"
+
+// schemaDialect is the JSON Schema draft the schemas are written in.
+const schemaDialect = "https://json-schema.org/draft/2020-12/schema"
+
+// schemaType is a document the CLI reads or writes that integrators can
+// get a schema for.
+type schemaType struct {
+	title string
+	typ   reflect.Type
+}
+
+var schemaTypes = map[string]schemaType{
+	"result":            {"Kusari Inspector result, as written by --output json", reflect.TypeFor[Result]()},
+	"security-analysis": {"Kusari Inspector security analysis", reflect.TypeFor[api.SecurityAnalysis]()},
+	"inspector-result":  {"Kusari Inspector result record, as returned by the platform", reflect.TypeFor[api.UserInspectorResult]()},
+}
+
+// SchemaNames returns the names Schema accepts, sorted.
+func SchemaNames() []string {
+	names := make([]string, 0, len(schemaTypes))
+	for name := range schemaTypes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Schema returns the JSON Schema of the document called name. Its $id
+// carries SchemaVersion, so a schema saved by an integrator can be matched
+// to the documents it describes. Analyses may have properties it doesn't
+// list, from platforms newer than the CLI.
+func Schema(name string) (*jsonschema.Schema, error) {
+	st, ok := schemaTypes[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown schema %q (must be one of %v)", name, SchemaNames())
+	}
+	analysis, err := jsonschema.For[api.SecurityAnalysis](nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build schema for %s: %w", name, err)
+	}
+	// Analyses of newer platforms have fields this version doesn't know,
+	// which are passed through (see api.SecurityAnalysis.Extra).
+	analysis.AdditionalProperties = nil
+	s, err := jsonschema.ForType(st.typ, &jsonschema.ForOptions{
+		TypeSchemas: map[reflect.Type]*jsonschema.Schema{reflect.TypeFor[api.SecurityAnalysis](): analysis},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to build schema for %s: %w", name, err)
+	}
+	allowNullCollections(s)
+	s.Schema = schemaDialect
+	s.ID = fmt.Sprintf("urn:kusari:cli:schema:%s:v%s", name, SchemaVersion)
+	s.Title = st.title
+	if version, ok := s.Properties["schema_version"]; ok && st.typ == reflect.TypeFor[Result]() {
+		var v any = SchemaVersion
+		version.Const = &v
+	}
+	return s, nil
+}
+
+// allowNullCollections lets every array and map in s be null, as
+// encoding/json writes nil slices and maps.
+func allowNullCollections(s *jsonschema.Schema) {
+	if s == nil {
+		return
+	}
+	// Structs have properties; maps only additionalProperties.
+	isMap := s.Type == "object" && s.Properties == nil && s.AdditionalProperties != nil
+	if s.Type == "array" || isMap {
+		s.Types = []string{"null", s.Type}
+		s.Type = ""
+	}
+	allowNullCollections(s.AdditionalProperties)
+	allowNullCollections(s.Items)
+	for _, p := range s.Properties {
+		allowNullCollections(p)
+	}
+}
+
+// SchemaWarning describes how res is newer than this version of the CLI
+// knows: written by a newer CLI, or with an analysis in a newer format, so
+// that some of it may not be shown. It is empty when res is not newer.
+func (res *Result) SchemaWarning() string {
+	switch {
+	case api.NewerVersion(res.SchemaVersion, SchemaVersion):
+		return fmt.Sprintf("this result was written in schema version %s, newer than the %s this CLI knows; upgrade the CLI to see all of it", res.SchemaVersion, SchemaVersion)
+	case res.Analysis.NewerSchema():
+		return fmt.Sprintf("the platform returned an analysis in schema version %s, newer than the %s this CLI knows; fields it doesn't know are kept in JSON output, upgrade the CLI to see them", res.Analysis.SchemaVersion, api.SecurityAnalysisSchemaVersion)
+	}
+	return ""
+}
@@ -0,0 +1,88 @@
+// Copyright (c) Kusari <https://www.kusari.dev/>
+// SPDX-License-Identifier: MIT
+
+package results
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/kusaridev/kusari-cli/v2/api"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// validate checks doc, marshaled to JSON, against the schema called name.
+func validate(t *testing.T, name string, doc any) error {
+	t.Helper()
+	s, err := Schema(name)
+	require.NoError(t, err)
+	resolved, err := s.Resolve(nil)
+	require.NoError(t, err)
+
+	data, err := json.Marshal(doc)
+	require.NoError(t, err)
+	var instance any
+	require.NoError(t, json.Unmarshal(data, &instance))
+	return resolved.Validate(instance)
+}
+
+func TestSchema(t *testing.T) {
+	assert.Equal(t, []string{"inspector-result", "result", "security-analysis"}, SchemaNames())
+
+	s, err := Schema("security-analysis")
+	require.NoError(t, err)
+	assert.Equal(t, "urn:kusari:cli:schema:security-analysis:v"+SchemaVersion, s.ID)
+	assert.Equal(t, schemaDialect, s.Schema)
+	assert.Contains(t, s.Required, "should_proceed")
+	assert.NotContains(t, s.Required, "code_mitigations")
+
+	_, err = Schema("bogus")
+	assert.ErrorContains(t, err, "unknown schema")
+}
+
+func TestSchemaValidatesOutput(t *testing.T) {
+	res := FromAnalysis(&api.Analysis{RawLLMAnalysis: testResult().Analysis})
+	assert.Equal(t, SchemaVersion, res.SchemaVersion)
+	assert.NoError(t, validate(t, "result", res))
+	assert.NoError(t, validate(t, "security-analysis", res.Analysis))
+
+	// Nil slices and maps are written as null.
+	assert.NoError(t, validate(t, "inspector-result", api.UserInspectorResult{Analysis: &api.Analysis{}}))
+
+	res.SchemaVersion = "0"
+	assert.Error(t, validate(t, "result", res))
+	assert.Error(t, validate(t, "security-analysis", map[string]any{"recommendation": 1}))
+}
+
+func TestUnknownAnalysisFields(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "analysis.json")
+	require.NoError(t, os.WriteFile(path, []byte(`{
+  "schema_version": "2",
+  "recommendation": "Fix it",
+  "should_proceed": false,
+  "severity_breakdown": {"high": 1}
+}`), 0600))
+	res, err := Load(path)
+	require.NoError(t, err)
+	assert.Equal(t, "Fix it", res.Analysis.Recommendation)
+	assert.JSONEq(t, `{"high": 1}`, string(res.Analysis.Extra["severity_breakdown"]))
+	assert.Contains(t, res.SchemaWarning(), "schema version 2")
+
+	data, err := json.Marshal(res)
+	require.NoError(t, err)
+	var out struct {
+		Analysis map[string]any `json:"analysis"`
+	}
+	require.NoError(t, json.Unmarshal(data, &out))
+	assert.Equal(t, map[string]any{"high": float64(1)}, out.Analysis["severity_breakdown"])
+	assert.Equal(t, "Fix it", out.Analysis["recommendation"])
+	assert.NoError(t, validate(t, "security-analysis", res.Analysis))
+
+	res.Analysis.SchemaVersion = api.SecurityAnalysisSchemaVersion
+	assert.Empty(t, res.SchemaWarning())
+	res.SchemaVersion = "3"
+	assert.Contains(t, res.SchemaWarning(), "upgrade the CLI")
+}
@@ -0,0 +1,113 @@
+// Copyright (c) Kusari <https://www.kusari.dev/>
+// SPDX-License-Identifier: MIT
+
+package results
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+)
+
+// maxSaved is how many results Save keeps; older ones are deleted.
+const maxSaved = 50
+
+// savedTimeFormat names saved files so they sort chronologically.
+const savedTimeFormat = "20060102T150405.000000000Z"
+
+// ErrNoSavedResults is returned by Last when nothing has been saved yet.
+var ErrNoSavedResults = errors.New("no saved results; run 'kusari repo scan' or 'kusari repo risk-check' first")
+
+// Dir returns the directory results are saved in, ~/.kusari/results.
+func Dir() (string, error) {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to get home directory: %w", err)
+	}
+	return filepath.Join(homeDir, ".kusari", "results"), nil
+}
+
+// Save writes res to Dir, stamped with the current time, and prunes all
+// but the newest maxSaved results. It returns the path written.
+func Save(res *Result) (string, error) {
+	dir, err := Dir()
+	if err != nil {
+		return "", err
+	}
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return "", fmt.Errorf("failed to create results directory: %w", err)
+	}
+
+	if res.SavedAt.IsZero() {
+		res.SavedAt = time.Now()
+	}
+	res.SchemaVersion = SchemaVersion
+	data, err := json.MarshalIndent(res, "", "  ")
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal result: %w", err)
+	}
+
+	kind := res.Kind
+	if kind == "" {
+		kind = "scan"
+	}
+	path := filepath.Join(dir, res.SavedAt.UTC().Format(savedTimeFormat)+"-"+kind+".json")
+	if err := os.WriteFile(path, data, 0600); err != nil {
+		return "", fmt.Errorf("failed to write result: %w", err)
+	}
+
+	saved, err := list(dir)
+	if err != nil {
+		return path, nil
+	}
+	for len(saved) > maxSaved {
+		_ = os.Remove(saved[0])
+		saved = saved[1:]
+	}
+	return path, nil
+}
+
+// Last returns the most recently saved result and its path.
+func Last() (*Result, string, error) {
+	dir, err := Dir()
+	if err != nil {
+		return nil, "", err
+	}
+	saved, err := list(dir)
+	if err != nil {
+		return nil, "", err
+	}
+	if len(saved) == 0 {
+		return nil, "", ErrNoSavedResults
+	}
+	path := saved[len(saved)-1]
+	res, err := Load(path)
+	if err != nil {
+		return nil, "", err
+	}
+	return res, path, nil
+}
+
+// list returns the saved result files in dir, oldest first.
+func list(dir string) ([]string, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to read results directory: %w", err)
+	}
+	var saved []string
+	for _, e := range entries {
+		if !e.IsDir() && strings.HasSuffix(e.Name(), ".json") {
+			saved = append(saved, filepath.Join(dir, e.Name()))
+		}
+	}
+	slices.Sort(saved)
+	return saved, nil
+}
@@ -0,0 +1,51 @@
+// Copyright (c) Kusari <https://www.kusari.dev/>
+// SPDX-License-Identifier: MIT
+
+package results
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/kusaridev/kusari-cli/v2/api"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSaveAndLast(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("USERPROFILE", os.Getenv("HOME"))
+
+	_, _, err := Last()
+	assert.ErrorIs(t, err, ErrNoSavedResults)
+
+	start := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	for i := range maxSaved + 2 {
+		res := testResult()
+		res.Kind = "scan"
+		res.Markdown = "## Result"
+		res.BaseRef = "origin/main"
+		res.Health = api.Health{"code": {Score: i % 5}}
+		res.SavedAt = start.Add(time.Duration(i) * time.Minute)
+		_, err := Save(res)
+		require.NoError(t, err)
+	}
+
+	dir, err := Dir()
+	require.NoError(t, err)
+	saved, err := list(dir)
+	require.NoError(t, err)
+	assert.Len(t, saved, maxSaved)
+	assert.Equal(t, "20260301T120200.000000000Z-scan.json", filepath.Base(saved[0]))
+
+	last, path, err := Last()
+	require.NoError(t, err)
+	assert.Equal(t, saved[len(saved)-1], path)
+	assert.True(t, last.SavedAt.Equal(start.Add((maxSaved+1)*time.Minute)))
+	assert.Equal(t, "## Result", last.Markdown)
+	assert.Equal(t, "origin/main", last.BaseRef)
+	assert.Equal(t, (maxSaved+1)%5, last.Health["code"].Score)
+	assert.Equal(t, testResult().Analysis, last.Analysis)
+}
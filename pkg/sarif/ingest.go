@@ -0,0 +1,176 @@
+// Copyright (c) Kusari <https://www.kusari.dev/>
+// SPDX-License-Identifier: MIT
+
+package sarif
+
+import (
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// Kusari severities, which SARIF levels and security-severity scores map to.
+const (
+	SeverityCritical = "critical"
+	SeverityHigh     = "high"
+	SeverityMedium   = "medium"
+	SeverityLow      = "low"
+	SeverityInfo     = "info"
+)
+
+// Parse decodes a third-party SARIF 2.1.0 log, such as semgrep or CodeQL
+// output, for upload to the platform.
+func Parse(data []byte) (*SarifLog, error) {
+	var log SarifLog
+	if err := json.Unmarshal(data, &log); err != nil {
+		return nil, fmt.Errorf("not a SARIF log: %w", err)
+	}
+	if log.Version != "2.1.0" {
+		return nil, fmt.Errorf("unsupported SARIF version %q (want 2.1.0)", log.Version)
+	}
+	if len(log.Runs) == 0 {
+		return nil, fmt.Errorf("SARIF log has no runs")
+	}
+	return &log, nil
+}
+
+// Summary is what a SARIF log found, by tool, level and rule.
+type Summary struct {
+	Tools      []string          // "name version" of each run's tool
+	Results    int               // Number of results
+	Levels     map[string]int    // Results per SARIF level
+	Severities map[string]int    // Results per Kusari severity
+	Rules      map[string]string // Kusari severity of each rule with results
+}
+
+// Summarize maps every result of log to a Kusari severity. A result without
+// a level takes its rule's default level, and "warning" failing that, as
+// the SARIF spec says. A rule's security-severity property (a CVSS score,
+// as semgrep and CodeQL set) takes precedence over the level.
+func Summarize(log *SarifLog) Summary {
+	s := Summary{
+		Levels:     map[string]int{},
+		Severities: map[string]int{},
+		Rules:      map[string]string{},
+	}
+	for _, run := range log.Runs {
+		tool := run.Tool.Driver.Name
+		if run.Tool.Driver.Version != "" {
+			tool += " " + run.Tool.Driver.Version
+		}
+		if tool != "" && !slices.Contains(s.Tools, tool) {
+			s.Tools = append(s.Tools, tool)
+		}
+
+		rules := map[string]SarifRule{}
+		for _, component := range append([]SarifDriver{run.Tool.Driver}, run.Tool.Extensions...) {
+			for _, rule := range component.Rules {
+				rules[rule.ID] = rule
+			}
+		}
+		for _, result := range run.Results {
+			ruleID := result.RuleID
+			if ruleID == "" && result.RuleIndex != nil && *result.RuleIndex >= 0 && *result.RuleIndex < len(run.Tool.Driver.Rules) {
+				ruleID = run.Tool.Driver.Rules[*result.RuleIndex].ID
+			}
+			rule, hasRule := rules[ruleID]
+
+			level := result.Level
+			if level == "" && hasRule && rule.DefaultConfiguration != nil {
+				level = rule.DefaultConfiguration.Level
+			}
+			if level == "" {
+				level = "warning"
+			}
+			severity := levelSeverity(level)
+			if score, ok := securitySeverity(rule); hasRule && ok {
+				severity = scoreSeverity(score)
+			}
+
+			s.Results++
+			s.Levels[level]++
+			s.Severities[severity]++
+			if ruleID != "" {
+				s.Rules[ruleID] = severity
+			}
+		}
+	}
+	return s
+}
+
+// UploadMetadata is the summary as document wrapper upload metadata. The
+// level, severity and rule maps are JSON objects.
+func (s Summary) UploadMetadata() map[string]string {
+	meta := map[string]string{
+		"sarif_tools":   strings.Join(s.Tools, ", "),
+		"sarif_results": strconv.Itoa(s.Results),
+	}
+	if highest := s.Highest(); highest != "" {
+		meta["sarif_highest_severity"] = highest
+	}
+	for key, m := range map[string]any{
+		"sarif_levels":     s.Levels,
+		"sarif_severities": s.Severities,
+		"sarif_rules":      s.Rules,
+	} {
+		// Maps of strings and ints always marshal.
+		b, _ := json.Marshal(m)
+		meta[key] = string(b)
+	}
+	return meta
+}
+
+// Highest returns the highest severity in s, or "" if it has no results.
+func (s Summary) Highest() string {
+	for _, severity := range []string{SeverityCritical, SeverityHigh, SeverityMedium, SeverityLow, SeverityInfo} {
+		if s.Severities[severity] > 0 {
+			return severity
+		}
+	}
+	return ""
+}
+
+func levelSeverity(level string) string {
+	switch level {
+	case "error":
+		return SeverityHigh
+	case "warning":
+		return SeverityMedium
+	case "note":
+		return SeverityLow
+	default:
+		return SeverityInfo
+	}
+}
+
+// securitySeverity returns the security-severity property of rule, which
+// tools set as a string or a number.
+func securitySeverity(rule SarifRule) (float64, bool) {
+	switch v := rule.Properties["security-severity"].(type) {
+	case string:
+		score, err := strconv.ParseFloat(v, 64)
+		return score, err == nil
+	case float64:
+		return v, true
+	default:
+		return 0, false
+	}
+}
+
+// scoreSeverity maps a CVSS score to a severity, with the CVSS v3 ranges.
+func scoreSeverity(score float64) string {
+	switch {
+	case score >= 9:
+		return SeverityCritical
+	case score >= 7:
+		return SeverityHigh
+	case score >= 4:
+		return SeverityMedium
+	case score > 0:
+		return SeverityLow
+	default:
+		return SeverityInfo
+	}
+}
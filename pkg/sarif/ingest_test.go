@@ -0,0 +1,86 @@
+// Copyright (c) Kusari <https://www.kusari.dev/>
+// SPDX-License-Identifier: MIT
+
+package sarif
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    string
+		wantErr string
+	}{
+		{name: "valid", data: `{"version": "2.1.0", "runs": [{"tool": {"driver": {"name": "CodeQL"}}}]}`},
+		{name: "not JSON", data: `nope`, wantErr: "not a SARIF log"},
+		{name: "old version", data: `{"version": "1.0.0", "runs": [{}]}`, wantErr: "unsupported SARIF version"},
+		{name: "no runs", data: `{"version": "2.1.0", "runs": []}`, wantErr: "no runs"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Parse([]byte(tt.data))
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("Unexpected error: %v", err)
+				}
+			} else if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Expected error containing %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestSummarize(t *testing.T) {
+	index := 1
+	log := &SarifLog{
+		Version: "2.1.0",
+		Runs: []SarifRun{
+			{
+				Tool: SarifTool{
+					Driver: SarifDriver{
+						Name:    "CodeQL",
+						Version: "2.15.0",
+						Rules: []SarifRule{
+							{ID: "js/xss", Properties: map[string]any{"security-severity": 6.1}},
+							{ID: "js/unused", DefaultConfiguration: &SarifReportingConfiguration{Level: "note"}},
+						},
+					},
+					Extensions: []SarifDriver{{
+						Name:  "codeql/js-queries",
+						Rules: []SarifRule{{ID: "js/sqli", Properties: map[string]any{"security-severity": "8.8"}}},
+					}},
+				},
+				Results: []SarifResult{
+					{RuleID: "js/xss", Level: "error"},
+					{RuleID: "js/sqli", Level: "error"},
+					{RuleIndex: &index},
+					{RuleID: "js/unknown"},
+					{RuleID: "js/unknown", Level: "none"},
+				},
+			},
+			{Tool: SarifTool{Driver: SarifDriver{Name: "CodeQL", Version: "2.15.0"}}},
+		},
+	}
+
+	got := Summarize(log)
+	want := Summary{
+		Tools:      []string{"CodeQL 2.15.0"},
+		Results:    5,
+		Levels:     map[string]int{"error": 2, "note": 1, "warning": 1, "none": 1},
+		Severities: map[string]int{SeverityMedium: 2, SeverityHigh: 1, SeverityLow: 1, SeverityInfo: 1},
+		Rules:      map[string]string{"js/xss": SeverityMedium, "js/sqli": SeverityHigh, "js/unused": SeverityLow, "js/unknown": SeverityInfo},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Summarize() = %+v, want %+v", got, want)
+	}
+	if highest := got.Highest(); highest != SeverityHigh {
+		t.Errorf("Highest() = %q, want %q", highest, SeverityHigh)
+	}
+	if highest := (Summary{}).Highest(); highest != "" {
+		t.Errorf("Highest() of no results = %q, want empty", highest)
+	}
+}
@@ -20,7 +20,8 @@ type SarifRun struct {
 }
 
 type SarifTool struct {
-	Driver SarifDriver `json:"driver"`
+	Driver     SarifDriver   `json:"driver"`
+	Extensions []SarifDriver `json:"extensions,omitempty"`
 }
 
 type SarifDriver struct {
@@ -35,19 +36,33 @@ type SarifRule struct {
 	ShortDescription SarifMultiformatMessageString `json:"shortDescription,omitempty"`
 	FullDescription  SarifMultiformatMessageString `json:"fullDescription,omitempty"`
 	Help             SarifMultiformatMessageString `json:"help,omitempty"`
-	Properties       map[string]any                `json:"properties,omitempty"`
+	// DefaultConfiguration.Level is the level of results that don't set one.
+	DefaultConfiguration *SarifReportingConfiguration `json:"defaultConfiguration,omitempty"`
+	Properties           map[string]any               `json:"properties,omitempty"`
+}
+
+type SarifReportingConfiguration struct {
+	Level string `json:"level,omitempty"`
 }
 
 type SarifResult struct {
-	RuleID     string                        `json:"ruleId"`
-	Level      string                        `json:"level,omitempty"` // "error", "warning", "note", "none"
-	Message    SarifMessage                  `json:"message"`
-	Help       SarifMultiformatMessageString `json:"help,omitempty"`
-	HelpUri    string                        `json:"helpUri,omitempty"`
-	Locations  []SarifLocation               `json:"locations,omitempty"`
-	Properties map[string]any                `json:"properties,omitempty"`
+	RuleID    string                        `json:"ruleId"`
+	RuleIndex *int                          `json:"ruleIndex,omitempty"` // Into the driver's rules, when RuleID is unset
+	Level     string                        `json:"level,omitempty"`     // "error", "warning", "note", "none"
+	Message   SarifMessage                  `json:"message"`
+	Help      SarifMultiformatMessageString `json:"help,omitempty"`
+	HelpUri   string                        `json:"helpUri,omitempty"`
+	Locations []SarifLocation               `json:"locations,omitempty"`
+	// PartialFingerprints identify the result across runs, for code
+	// scanning to track it; findings have their stable ID under
+	// FingerprintKey.
+	PartialFingerprints map[string]string `json:"partialFingerprints,omitempty"`
+	Properties          map[string]any    `json:"properties,omitempty"`
 }
 
+// FingerprintKey is the partial fingerprint holding a finding's stable ID.
+const FingerprintKey = "kusariFindingId/v1"
+
 type SarifMessage struct {
 	Text     string `json:"text"`
 	Markdown string `json:"markdown,omitempty"`
@@ -84,8 +99,10 @@ type SarifArtifactContent struct {
 	Text string `json:"text,omitempty"`
 }
 
-// ConvertToSARIF converts SecurityAnalysis to SARIF format
+// ConvertToSARIF converts SecurityAnalysis to SARIF format, with the
+// findings in the order of api.SecurityAnalysis.SortFindings.
 func ConvertToSARIF(analysis *api.SecurityAnalysis, consoleUrl string) (string, error) {
+	analysis = analysis.SortedFindings()
 	sarifLog := SarifLog{
 		Version: "2.1.0",
 		Schema:  "https://raw.githubusercontent.com/oasis-tcs/sarif-spec/master/Schemata/sarif-schema-2.1.0.json",
@@ -184,9 +201,11 @@ func ConvertToSARIF(analysis *api.SecurityAnalysis, consoleUrl string) (string,
 					},
 				},
 			},
+			PartialFingerprints: map[string]string{FingerprintKey: mitigation.ID()},
 			Properties: map[string]any{
 				"type":        "code",
 				"line_number": mitigation.LineNumber,
+				"finding_id":  mitigation.ID(),
 			},
 		}
 		sarifLog.Runs[0].Results = append(sarifLog.Runs[0].Results, result)
@@ -200,8 +219,10 @@ func ConvertToSARIF(analysis *api.SecurityAnalysis, consoleUrl string) (string,
 			Message: SarifMessage{
 				Text: mitigation.Content,
 			},
+			PartialFingerprints: map[string]string{FingerprintKey: mitigation.ID()},
 			Properties: map[string]any{
-				"type": "dependency",
+				"type":       "dependency",
+				"finding_id": mitigation.ID(),
 			},
 		}
 		sarifLog.Runs[0].Results = append(sarifLog.Runs[0].Results, result)
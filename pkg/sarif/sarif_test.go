@@ -506,6 +506,56 @@ func TestConvertToSARIF(t *testing.T) {
 	}
 }
 
+func TestConvertToSARIFStableFindings(t *testing.T) {
+	code := []api.CodeMitigationItem{
+		{Path: "b.go", LineNumber: 1, Content: "second file"},
+		{Path: "a.go", LineNumber: 9, Content: "later line"},
+		{Path: "a.go", LineNumber: 2, Content: "earlier line"},
+	}
+	deps := []api.DependencyMitigationItem{{Content: "upgrade lodash"}, {Content: "upgrade axios"}}
+	convert := func(code []api.CodeMitigationItem, deps []api.DependencyMitigationItem) SarifLog {
+		out, err := ConvertToSARIF(&api.SecurityAnalysis{RequiredCodeMitigations: code, RequiredDependencyMitigations: deps}, "")
+		if err != nil {
+			t.Fatalf("ConvertToSARIF() error = %v", err)
+		}
+		var log SarifLog
+		if err := json.Unmarshal([]byte(out), &log); err != nil {
+			t.Fatalf("Failed to parse SARIF output: %v", err)
+		}
+		return log
+	}
+
+	log := convert(code, deps)
+	var locations []string
+	for _, r := range log.Runs[0].Results[1:4] {
+		locations = append(locations, r.Locations[0].PhysicalLocation.ArtifactLocation.URI+":"+r.Message.Text)
+	}
+	if got := strings.Join(locations, ", "); got != "a.go:earlier line, a.go:later line, b.go:second file" {
+		t.Errorf("code findings not sorted by path and line: %s", got)
+	}
+	if code[0].Path != "b.go" {
+		t.Error("ConvertToSARIF() sorted the caller's findings")
+	}
+
+	// The same findings in another order give the same SARIF
+	reversed := convert([]api.CodeMitigationItem{code[2], code[1], code[0]}, []api.DependencyMitigationItem{deps[1], deps[0]})
+	for i, r := range log.Runs[0].Results {
+		if r.Message.Text != reversed.Runs[0].Results[i].Message.Text {
+			t.Errorf("result %d is %q, then %q", i, r.Message.Text, reversed.Runs[0].Results[i].Message.Text)
+		}
+	}
+
+	for _, r := range log.Runs[0].Results[1:] {
+		want := api.FindingID(r.Properties["type"].(string), "", r.Message.Text)
+		if len(r.Locations) > 0 {
+			want = api.FindingID(api.FindingCode, r.Locations[0].PhysicalLocation.ArtifactLocation.URI, r.Message.Text)
+		}
+		if r.PartialFingerprints[FingerprintKey] != want || r.Properties["finding_id"] != want {
+			t.Errorf("%s: fingerprint %v, finding_id %v, want %s", r.Message.Text, r.PartialFingerprints, r.Properties["finding_id"], want)
+		}
+	}
+}
+
 func TestBuildMessage(t *testing.T) {
 	testConsoleUrl := "https://console.kusari.dev/analysis/test123"
 
@@ -0,0 +1,446 @@
+// Copyright (c) Kusari <https://www.kusari.dev/>
+// SPDX-License-Identifier: MIT
+
+package sbom
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ConvertedFromProperty is the CycloneDX metadata property naming the SPDX
+// document a CycloneDX SBOM was converted from.
+const ConvertedFromProperty = "kusari:sbom:converted-from"
+
+// ParseFormat returns the format named s: spdx, or cyclonedx (also cdx).
+func ParseFormat(s string) (string, error) {
+	switch strings.ToLower(s) {
+	case FormatSPDX:
+		return FormatSPDX, nil
+	case FormatCycloneDX, "cdx":
+		return FormatCycloneDX, nil
+	default:
+		return "", fmt.Errorf("unknown SBOM format %q (must be 'spdx' or 'cyclonedx')", s)
+	}
+}
+
+// Convert converts d to the format to (FormatSPDX or FormatCycloneDX),
+// recording the CLI version tool as a creator. A document already in that
+// format is returned as is. The conversion keeps the packages, with their
+// purl, CPE, version, supplier, licenses and hashes, the dependencies
+// between them and what the document describes; SPDX files and snippets,
+// and CycloneDX services, are dropped.
+func Convert(d *Document, to, tool string) (*Document, error) {
+	if to != FormatSPDX && to != FormatCycloneDX {
+		return nil, fmt.Errorf("unknown SBOM format %q", to)
+	}
+	if d.Format == to {
+		return d, nil
+	}
+	var out *Document
+	if to == FormatCycloneDX {
+		out = spdxToCycloneDX(d, tool)
+	} else {
+		out = cycloneDXToSPDX(d, tool)
+	}
+	out.Path = d.Path
+	return out, nil
+}
+
+// purposes maps SPDX primary package purposes to CycloneDX component types.
+var purposes = map[string]string{
+	"APPLICATION":      "application",
+	"FRAMEWORK":        "framework",
+	"LIBRARY":          "library",
+	"CONTAINER":        "container",
+	"OPERATING-SYSTEM": "operating-system",
+	"DEVICE":           "device",
+	"FIRMWARE":         "firmware",
+	"FILE":             "file",
+}
+
+// spdxValue returns the string at key of m unless SPDX says it is unknown.
+func spdxValue(m map[string]any, key string) string {
+	v := str(m, key)
+	if v == "NOASSERTION" || v == "NONE" {
+		return ""
+	}
+	return v
+}
+
+func spdxToCycloneDX(d *Document, tool string) *Document {
+	byID := map[string]map[string]any{}
+	var components []map[string]any
+	for _, p := range objects(d.Doc, "packages") {
+		c := spdxPackageToComponent(p)
+		byID[str(p, "SPDXID")] = c
+		components = append(components, c)
+	}
+
+	describes := stringsAt(d.Doc, "documentDescribes")
+	var deps []string
+	dependsOn := map[string][]string{}
+	depend := func(from, to string) {
+		if byID[from] == nil || byID[to] == nil {
+			return
+		}
+		if _, ok := dependsOn[from]; !ok {
+			deps = append(deps, from)
+		}
+		dependsOn[from] = appendUnique(dependsOn[from], to)
+	}
+	for _, r := range objects(d.Doc, "relationships") {
+		from, to := str(r, "spdxElementId"), str(r, "relatedSpdxElement")
+		switch str(r, "relationshipType") {
+		case "DESCRIBES":
+			if from == "SPDXRef-DOCUMENT" {
+				describes = appendUnique(describes, to)
+			}
+		case "DESCRIBED_BY":
+			if to == "SPDXRef-DOCUMENT" {
+				describes = appendUnique(describes, from)
+			}
+		case "DEPENDS_ON", "CONTAINS":
+			depend(from, to)
+		case "DEPENDENCY_OF", "CONTAINED_BY":
+			depend(to, from)
+		}
+	}
+
+	// A single described package is the subject; several hang off one
+	// named after the document.
+	var root map[string]any
+	if len(describes) == 1 && byID[describes[0]] != nil {
+		root = byID[describes[0]]
+		components = deleteComponent(components, root)
+		if purposes[str(packageByID(d, describes[0]), "primaryPackagePurpose")] == "" {
+			root["type"] = "application"
+		}
+	} else {
+		root = map[string]any{"type": "application", "bom-ref": "SPDXRef-DOCUMENT", "name": str(d.Doc, "name")}
+		for _, id := range describes {
+			if byID[id] != nil {
+				if _, ok := dependsOn["SPDXRef-DOCUMENT"]; !ok {
+					deps = append(deps, "SPDXRef-DOCUMENT")
+				}
+				dependsOn["SPDXRef-DOCUMENT"] = appendUnique(dependsOn["SPDXRef-DOCUMENT"], id)
+			}
+		}
+	}
+
+	info := object(d.Doc, "creationInfo")
+	tools := []map[string]any{{"type": "application", "name": "kusari-cli", "version": tool}}
+	for _, c := range stringsAt(info, "creators") {
+		if name, ok := strings.CutPrefix(c, "Tool: "); ok {
+			tools = append(tools, map[string]any{"type": "application", "name": name})
+		}
+	}
+	meta := map[string]any{
+		"component": root,
+		"tools":     map[string]any{"components": array(tools)},
+	}
+	if created := str(info, "created"); created != "" {
+		meta["timestamp"] = created
+	}
+	serial := uuid.NewString()
+	if ns := str(d.Doc, "documentNamespace"); ns != "" {
+		// Converting the same document gives the same serial number.
+		serial = uuid.NewSHA1(uuid.NameSpaceURL, []byte(ns)).String()
+		meta["properties"] = []any{map[string]any{"name": ConvertedFromProperty, "value": ns}}
+	}
+
+	dependencies := make([]any, 0, len(deps))
+	for _, ref := range deps {
+		dependencies = append(dependencies, map[string]any{"ref": ref, "dependsOn": toAny(dependsOn[ref])})
+	}
+	return &Document{Format: FormatCycloneDX, Doc: map[string]any{
+		"bomFormat":    "CycloneDX",
+		"specVersion":  "1.5",
+		"serialNumber": "urn:uuid:" + serial,
+		"version":      1,
+		"metadata":     meta,
+		"components":   array(components),
+		"dependencies": dependencies,
+	}}
+}
+
+// packageByID returns the SPDX package of d with id.
+func packageByID(d *Document, id string) map[string]any {
+	for _, p := range objects(d.Doc, "packages") {
+		if str(p, "SPDXID") == id {
+			return p
+		}
+	}
+	return nil
+}
+
+func deleteComponent(components []map[string]any, c map[string]any) []map[string]any {
+	for i := range components {
+		if str(components[i], "bom-ref") == str(c, "bom-ref") {
+			return append(components[:i], components[i+1:]...)
+		}
+	}
+	return components
+}
+
+func spdxPackageToComponent(p map[string]any) map[string]any {
+	typ := purposes[str(p, "primaryPackagePurpose")]
+	if typ == "" {
+		typ = "library"
+	}
+	c := map[string]any{"type": typ, "bom-ref": str(p, "SPDXID"), "name": str(p, "name")}
+	if v := spdxValue(p, "versionInfo"); v != "" {
+		c["version"] = v
+	}
+	if v := str(p, "description"); v != "" {
+		c["description"] = v
+	}
+	if v := spdxValue(p, "copyrightText"); v != "" {
+		c["copyright"] = v
+	}
+	if v := spdxValue(p, "supplier"); v != "" {
+		name := v
+		if _, n, ok := strings.Cut(v, ": "); ok {
+			name = n // Organization: or Person:
+		}
+		c["supplier"] = map[string]any{"name": name}
+	}
+	if v := spdxValue(p, "licenseDeclared"); v != "" {
+		c["licenses"] = []any{map[string]any{"expression": v}}
+	} else if v := spdxValue(p, "licenseConcluded"); v != "" {
+		c["licenses"] = []any{map[string]any{"expression": v}}
+	}
+	for _, ref := range objects(p, "externalRefs") {
+		switch str(ref, "referenceType") {
+		case "purl":
+			c["purl"] = str(ref, "referenceLocator")
+		case "cpe23Type", "cpe22Type":
+			c["cpe"] = str(ref, "referenceLocator")
+		}
+	}
+	var hashes []any
+	for _, cs := range objects(p, "checksums") {
+		hashes = append(hashes, map[string]any{"alg": cdxAlgorithm(str(cs, "algorithm")), "content": str(cs, "checksumValue")})
+	}
+	if len(hashes) > 0 {
+		c["hashes"] = hashes
+	}
+	var refs []any
+	if v := spdxValue(p, "downloadLocation"); v != "" {
+		refs = append(refs, map[string]any{"type": "distribution", "url": v})
+	}
+	if v := spdxValue(p, "homepage"); v != "" {
+		refs = append(refs, map[string]any{"type": "website", "url": v})
+	}
+	if len(refs) > 0 {
+		c["externalReferences"] = refs
+	}
+	return c
+}
+
+// cdxAlgorithm returns the CycloneDX name of an SPDX checksum algorithm:
+// SHA256 is SHA-256, and SHA3_256 is SHA3-256.
+func cdxAlgorithm(alg string) string {
+	if rest, ok := strings.CutPrefix(alg, "SHA3_"); ok {
+		return "SHA3-" + rest
+	}
+	if rest, ok := strings.CutPrefix(alg, "SHA"); ok {
+		return "SHA-" + rest
+	}
+	return alg
+}
+
+// spdxAlgorithm is the inverse of cdxAlgorithm.
+func spdxAlgorithm(alg string) string {
+	if rest, ok := strings.CutPrefix(alg, "SHA3-"); ok {
+		return "SHA3_" + rest
+	}
+	if rest, ok := strings.CutPrefix(alg, "SHA-"); ok {
+		return "SHA" + rest
+	}
+	return alg
+}
+
+// spdxIDInvalid matches what an SPDX identifier can't hold.
+var spdxIDInvalid = regexp.MustCompile(`[^a-zA-Z0-9.-]+`)
+
+func cycloneDXToSPDX(d *Document, tool string) *Document {
+	used := map[string]bool{"SPDXRef-DOCUMENT": true}
+	refs := map[string]string{}
+	var packages, relationships []map[string]any
+	add := func(c map[string]any) string {
+		ref := str(c, "bom-ref")
+		base := ref
+		if base == "" {
+			base = nameVersion(str(c, "group"), str(c, "name"), str(c, "version"))
+		}
+		base = "SPDXRef-" + strings.Trim(spdxIDInvalid.ReplaceAllString(strings.TrimPrefix(base, "SPDXRef-"), "-"), "-")
+		id := base
+		for n := 2; used[id]; n++ {
+			id = fmt.Sprintf("%s-%d", base, n)
+		}
+		used[id] = true
+		if ref != "" {
+			refs[ref] = id
+		}
+		packages = append(packages, componentToSPDXPackage(c, id))
+		return id
+	}
+	// Nested components are flattened.
+	var walk func(cs []map[string]any)
+	walk = func(cs []map[string]any) {
+		for _, c := range cs {
+			add(c)
+			walk(objects(c, "components"))
+		}
+	}
+
+	meta := object(d.Doc, "metadata")
+	root := object(meta, "component")
+	name := str(root, "name")
+	if root != nil {
+		relationships = append(relationships, spdxRelationship("SPDXRef-DOCUMENT", "DESCRIBES", add(root)))
+	}
+	walk(objects(d.Doc, "components"))
+	if root == nil {
+		name = "sbom"
+		// Without a subject, the document describes every component.
+		for _, p := range packages {
+			relationships = append(relationships, spdxRelationship("SPDXRef-DOCUMENT", "DESCRIBES", str(p, "SPDXID")))
+		}
+	}
+	for _, dep := range objects(d.Doc, "dependencies") {
+		from, ok := refs[str(dep, "ref")]
+		if !ok {
+			continue
+		}
+		for _, ref := range stringsAt(dep, "dependsOn") {
+			if to, ok := refs[ref]; ok {
+				relationships = append(relationships, spdxRelationship(from, "DEPENDS_ON", to))
+			}
+		}
+	}
+
+	creators := []string{"Tool: kusari-cli-" + tool}
+	tools := objects(meta, "tools")
+	if t := object(meta, "tools"); t != nil {
+		tools = objects(t, "components")
+	}
+	for _, t := range tools {
+		creators = appendUnique(creators, "Tool: "+nameVersion("", str(t, "name"), str(t, "version")))
+	}
+	created := str(meta, "timestamp")
+	if created == "" {
+		created = time.Now().UTC().Format(time.RFC3339)
+	}
+	info := map[string]any{"created": created, "creators": toAny(creators)}
+	id := uuid.NewString()
+	if serial := str(d.Doc, "serialNumber"); serial != "" {
+		// Converting the same document gives the same namespace.
+		id = uuid.NewSHA1(uuid.NameSpaceURL, []byte(serial)).String()
+		info["comment"] = "Converted from " + serial
+	}
+	return &Document{Format: FormatSPDX, Doc: map[string]any{
+		"spdxVersion":       "SPDX-2.3",
+		"dataLicense":       "CC0-1.0",
+		"SPDXID":            "SPDXRef-DOCUMENT",
+		"name":              name,
+		"documentNamespace": "https://kusari.dev/spdxdocs/" + name + "-" + id,
+		"creationInfo":      info,
+		"packages":          array(packages),
+		"relationships":     array(relationships),
+	}}
+}
+
+func spdxRelationship(from, typ, to string) map[string]any {
+	return map[string]any{"spdxElementId": from, "relationshipType": typ, "relatedSpdxElement": to}
+}
+
+func componentToSPDXPackage(c map[string]any, id string) map[string]any {
+	name := str(c, "name")
+	if g := str(c, "group"); g != "" {
+		name = g + "/" + name
+	}
+	p := map[string]any{
+		"SPDXID":           id,
+		"name":             name,
+		"downloadLocation": "NOASSERTION",
+		"licenseConcluded": "NOASSERTION",
+		"licenseDeclared":  "NOASSERTION",
+		"copyrightText":    "NOASSERTION",
+		"filesAnalyzed":    false,
+	}
+	if v := str(c, "version"); v != "" {
+		p["versionInfo"] = v
+	}
+	if v := str(c, "description"); v != "" {
+		p["description"] = v
+	}
+	if v := str(c, "copyright"); v != "" {
+		p["copyrightText"] = v
+	}
+	if purpose := strings.ToUpper(str(c, "type")); purposes[purpose] != "" {
+		p["primaryPackagePurpose"] = purpose
+	}
+	if v := str(object(c, "supplier"), "name"); v != "" {
+		p["supplier"] = "Organization: " + v
+	}
+	if l := cdxLicense(c); l != "" {
+		p["licenseDeclared"] = l
+	}
+	var refs []any
+	if v := str(c, "purl"); v != "" {
+		refs = append(refs, map[string]any{"referenceCategory": "PACKAGE-MANAGER", "referenceType": "purl", "referenceLocator": v})
+	}
+	if v := str(c, "cpe"); v != "" {
+		refs = append(refs, map[string]any{"referenceCategory": "SECURITY", "referenceType": "cpe23Type", "referenceLocator": v})
+	}
+	if len(refs) > 0 {
+		p["externalRefs"] = refs
+	}
+	var checksums []any
+	for _, h := range objects(c, "hashes") {
+		checksums = append(checksums, map[string]any{"algorithm": spdxAlgorithm(str(h, "alg")), "checksumValue": str(h, "content")})
+	}
+	if len(checksums) > 0 {
+		p["checksums"] = checksums
+	}
+	for _, r := range objects(c, "externalReferences") {
+		switch str(r, "type") {
+		case "distribution":
+			p["downloadLocation"] = str(r, "url")
+		case "website":
+			p["homepage"] = str(r, "url")
+		}
+	}
+	return p
+}
+
+// cdxLicense returns the licenses of a component as an SPDX expression, or
+// empty when one of them isn't an SPDX license.
+func cdxLicense(c map[string]any) string {
+	var ids []string
+	for _, l := range objects(c, "licenses") {
+		if e := str(l, "expression"); e != "" {
+			ids = append(ids, e)
+			continue
+		}
+		id := str(object(l, "license"), "id")
+		if id == "" {
+			return ""
+		}
+		ids = append(ids, id)
+	}
+	if len(ids) > 1 {
+		for i, id := range ids {
+			if strings.Contains(id, " ") {
+				ids[i] = "(" + id + ")"
+			}
+		}
+	}
+	return strings.Join(ids, " AND ")
+}
@@ -0,0 +1,138 @@
+// Copyright (c) Kusari <https://www.kusari.dev/>
+// SPDX-License-Identifier: MIT
+
+package sbom
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseFormat(t *testing.T) {
+	for in, want := range map[string]string{"spdx": FormatSPDX, "CycloneDX": FormatCycloneDX, "cdx": FormatCycloneDX} {
+		got, err := ParseFormat(in)
+		require.NoError(t, err)
+		assert.Equal(t, want, got)
+	}
+	_, err := ParseFormat("swid")
+	assert.Error(t, err)
+}
+
+const richSPDX = `{
+  "spdxVersion": "SPDX-2.3",
+  "dataLicense": "CC0-1.0",
+  "SPDXID": "SPDXRef-DOCUMENT",
+  "name": "api",
+  "documentNamespace": "https://example.com/api",
+  "creationInfo": {"created": "2026-01-01T00:00:00Z", "creators": ["Tool: syft-1.0.0", "Organization: Example"]},
+  "packages": [
+    {"SPDXID": "SPDXRef-api", "name": "api", "versionInfo": "1.0.0", "primaryPackagePurpose": "APPLICATION"},
+    {"SPDXID": "SPDXRef-lodash", "name": "lodash", "versionInfo": "4.17.21",
+     "supplier": "Organization: OpenJS", "licenseConcluded": "NOASSERTION", "licenseDeclared": "MIT",
+     "downloadLocation": "https://registry.npmjs.org/lodash/-/lodash-4.17.21.tgz",
+     "checksums": [{"algorithm": "SHA256", "checksumValue": "abc"}],
+     "externalRefs": [
+       {"referenceCategory": "PACKAGE-MANAGER", "referenceType": "purl", "referenceLocator": "pkg:npm/lodash@4.17.21"},
+       {"referenceCategory": "SECURITY", "referenceType": "cpe23Type", "referenceLocator": "cpe:2.3:a:lodash:lodash:4.17.21:*:*:*:*:*:*:*"}
+     ]}
+  ],
+  "relationships": [
+    {"spdxElementId": "SPDXRef-DOCUMENT", "relationshipType": "DESCRIBES", "relatedSpdxElement": "SPDXRef-api"},
+    {"spdxElementId": "SPDXRef-lodash", "relationshipType": "DEPENDENCY_OF", "relatedSpdxElement": "SPDXRef-api"}
+  ]
+}`
+
+func TestConvert_SPDXToCycloneDX(t *testing.T) {
+	out, err := Convert(parse(t, "api.spdx.json", richSPDX), FormatCycloneDX, "v1.2.3")
+	require.NoError(t, err)
+	d := out.Doc
+
+	assert.Equal(t, FormatCycloneDX, out.Format)
+	assert.Equal(t, "api.spdx.json", out.Path)
+	assert.Equal(t, "CycloneDX", d["bomFormat"])
+	meta := object(d, "metadata")
+	assert.Equal(t, "2026-01-01T00:00:00Z", meta["timestamp"])
+	assert.Equal(t, map[string]any{"type": "application", "bom-ref": "SPDXRef-api", "name": "api", "version": "1.0.0"}, meta["component"])
+	assert.Equal(t, ConvertedFromProperty, str(objects(meta, "properties")[0], "name"))
+
+	components := objects(d, "components")
+	require.Len(t, components, 1)
+	assert.Equal(t, map[string]any{
+		"type":     "library",
+		"bom-ref":  "SPDXRef-lodash",
+		"name":     "lodash",
+		"version":  "4.17.21",
+		"purl":     "pkg:npm/lodash@4.17.21",
+		"cpe":      "cpe:2.3:a:lodash:lodash:4.17.21:*:*:*:*:*:*:*",
+		"supplier": map[string]any{"name": "OpenJS"},
+		"licenses": []any{map[string]any{"expression": "MIT"}},
+		"hashes":   []any{map[string]any{"alg": "SHA-256", "content": "abc"}},
+		"externalReferences": []any{
+			map[string]any{"type": "distribution", "url": "https://registry.npmjs.org/lodash/-/lodash-4.17.21.tgz"},
+		},
+	}, components[0])
+	assert.Equal(t, []any{map[string]any{"ref": "SPDXRef-api", "dependsOn": []any{"SPDXRef-lodash"}}}, d["dependencies"])
+
+	// The serial number follows from the namespace.
+	again, err := Convert(parse(t, "api.spdx.json", richSPDX), FormatCycloneDX, "v1.2.3")
+	require.NoError(t, err)
+	assert.Equal(t, d["serialNumber"], again.Doc["serialNumber"])
+}
+
+func TestConvert_CycloneDXToSPDX(t *testing.T) {
+	out, err := Convert(parse(t, "api.cdx.json", apiCDX), FormatSPDX, "v1.2.3")
+	require.NoError(t, err)
+	d := out.Doc
+
+	assert.Equal(t, FormatSPDX, out.Format)
+	assert.Equal(t, "SPDX-2.3", d["spdxVersion"])
+	assert.Equal(t, "api", d["name"])
+	assert.Regexp(t, `^https://kusari.dev/spdxdocs/api-`, d["documentNamespace"])
+	info := object(d, "creationInfo")
+	assert.Equal(t, []string{"Tool: kusari-cli-v1.2.3", "Tool: syft@1.0.0"}, stringsAt(info, "creators"))
+	assert.Equal(t, "Converted from urn:uuid:11111111-1111-1111-1111-111111111111", info["comment"])
+
+	var ids []string
+	for _, p := range objects(d, "packages") {
+		ids = append(ids, str(p, "SPDXID"))
+	}
+	assert.Equal(t, []string{"SPDXRef-root", "SPDXRef-lodash", "SPDXRef-a"}, ids)
+	lodash := objects(d, "packages")[1]
+	assert.Equal(t, "4.17.21", lodash["versionInfo"])
+	assert.Equal(t, "NOASSERTION", lodash["downloadLocation"])
+	assert.Equal(t, "pkg:npm/lodash@4.17.21", spdxPurl(lodash))
+
+	var rels []string
+	for _, r := range objects(d, "relationships") {
+		rels = append(rels, str(r, "spdxElementId")+" "+str(r, "relationshipType")+" "+str(r, "relatedSpdxElement"))
+	}
+	assert.Equal(t, []string{
+		"SPDXRef-DOCUMENT DESCRIBES SPDXRef-root",
+		"SPDXRef-root DEPENDS_ON SPDXRef-lodash",
+		"SPDXRef-root DEPENDS_ON SPDXRef-a",
+	}, rels)
+}
+
+func TestConvert_RoundTrip(t *testing.T) {
+	cdx, err := Convert(parse(t, "api.spdx.json", richSPDX), FormatCycloneDX, "v1")
+	require.NoError(t, err)
+	spdx, err := Convert(cdx, FormatSPDX, "v1")
+	require.NoError(t, err)
+
+	lodash := objects(spdx.Doc, "packages")[1]
+	assert.Equal(t, "SPDXRef-lodash", lodash["SPDXID"])
+	assert.Equal(t, "MIT", lodash["licenseDeclared"])
+	assert.Equal(t, "Organization: OpenJS", lodash["supplier"])
+	assert.Equal(t, []any{map[string]any{"algorithm": "SHA256", "checksumValue": "abc"}}, lodash["checksums"])
+	assert.Equal(t, "https://registry.npmjs.org/lodash/-/lodash-4.17.21.tgz", lodash["downloadLocation"])
+	assert.Equal(t, "APPLICATION", objects(spdx.Doc, "packages")[0]["primaryPackagePurpose"])
+}
+
+func TestConvert_SameFormat(t *testing.T) {
+	d := parse(t, "api.cdx.json", apiCDX)
+	out, err := Convert(d, FormatCycloneDX, "v1")
+	require.NoError(t, err)
+	assert.Same(t, d, out)
+}
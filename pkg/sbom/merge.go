@@ -0,0 +1,433 @@
+// Copyright (c) Kusari <https://www.kusari.dev/>
+// SPDX-License-Identifier: MIT
+
+package sbom
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"golang.org/x/mod/semver"
+)
+
+// MergedFromProperty is the CycloneDX metadata property naming each
+// document a merged SBOM was made from.
+const MergedFromProperty = "kusari:sbom:merged-from"
+
+// MergeOptions describe the merged document.
+type MergeOptions struct {
+	// Name and Version of what the merged SBOM describes. They default to
+	// those of the first document.
+	Name    string
+	Version string
+	// Tool is the version of the CLI, recorded as the merged SBOM's creator.
+	Tool string
+	// Now is the creation time of the merged SBOM.
+	Now time.Time
+}
+
+// Merge merges documents of one format into one. Components or packages in
+// several documents (the same purl, or else the same name and version) are
+// listed once, and their relationships kept. What each document described
+// becomes a dependency of the merged document's subject, and the documents
+// are recorded as its provenance (CycloneDX metadata properties, an SPDX
+// creation comment), along with the tools that made them.
+func Merge(docs []*Document, opts MergeOptions) (*Document, error) {
+	if len(docs) == 0 {
+		return nil, fmt.Errorf("no SBOMs to merge")
+	}
+	for _, d := range docs[1:] {
+		if d.Format != docs[0].Format {
+			return nil, fmt.Errorf("cannot merge %s %s with %s %s; convert one first", docs[0].Format, docs[0].Path, d.Format, d.Path)
+		}
+	}
+	if opts.Now.IsZero() {
+		opts.Now = time.Now()
+	}
+	merged := mergeSPDX
+	if docs[0].Format == FormatCycloneDX {
+		merged = mergeCycloneDX
+	}
+	d := merged(docs, opts)
+	if d.Name() == "" {
+		return nil, fmt.Errorf("%s describes nothing by name; name the merged SBOM", docs[0].Path)
+	}
+	return d, nil
+}
+
+// dedup assigns the merged document's ID of each component or package.
+type dedup struct {
+	byKey map[string]string
+	used  map[string]bool
+}
+
+func newDedup() *dedup {
+	return &dedup{byKey: map[string]string{}, used: map[string]bool{}}
+}
+
+// add returns the ID in the merged document of the element with key and
+// id in the i-th document, and whether the element is already in it. An
+// element without key is never a duplicate. An id that is empty or taken
+// by another element gets a -docN suffix.
+func (d *dedup) add(key, id string, i int) (string, bool) {
+	if key != "" {
+		if existing, ok := d.byKey[key]; ok {
+			return existing, true
+		}
+	}
+	if id == "" || d.used[id] {
+		base := id
+		if base == "" {
+			base = "ref"
+		}
+		id = fmt.Sprintf("%s-doc%d", base, i+1)
+		for n := 2; d.used[id]; n++ {
+			id = fmt.Sprintf("%s-doc%d-%d", base, i+1, n)
+		}
+	}
+	d.used[id] = true
+	if key != "" {
+		d.byKey[key] = id
+	}
+	return id, false
+}
+
+// source names a merged document for provenance: its serial number or
+// namespace, or its file name.
+func source(d *Document, id string) string {
+	if id != "" {
+		return id
+	}
+	return filepath.Base(d.Path)
+}
+
+// appendUnique appends s to list unless it is empty or in it.
+func appendUnique(list []string, s string) []string {
+	for _, v := range list {
+		if v == s {
+			return list
+		}
+	}
+	if s == "" {
+		return list
+	}
+	return append(list, s)
+}
+
+// maxVersion returns the greater of two dotted spec versions.
+func maxVersion(a, b string) string {
+	if semver.Compare("v"+a, "v"+b) < 0 {
+		return b
+	}
+	return a
+}
+
+func mergeCycloneDX(docs []*Document, opts MergeOptions) *Document {
+	specVersion := "1.4"
+	ids := newDedup()
+	var components, tools, services []map[string]any
+	toolSeen := map[string]bool{}
+	addTool := func(t map[string]any) {
+		key := str(t, "group") + str(t, "vendor") + "/" + str(t, "name") + "@" + str(t, "version")
+		if !toolSeen[key] {
+			toolSeen[key] = true
+			tools = append(tools, t)
+		}
+	}
+	var deps []string
+	dependsOn := map[string][]string{}
+	var roots, sources []string
+	var firstRoot map[string]any
+
+	for i, d := range docs {
+		specVersion = maxVersion(specVersion, str(d.Doc, "specVersion"))
+		sources = append(sources, source(d, str(d.Doc, "serialNumber")))
+		remap := map[string]string{}
+		add := func(c map[string]any) string {
+			key := str(c, "purl")
+			if key == "" && str(c, "name") != "" {
+				key = nameVersion(str(c, "group"), str(c, "name"), str(c, "version"))
+			}
+			old := str(c, "bom-ref")
+			id := old
+			if id == "" {
+				id = key // components without a bom-ref go by purl
+			}
+			ref, dup := ids.add(key, id, i)
+			if old != "" {
+				remap[old] = ref
+			}
+			if !dup {
+				c["bom-ref"] = ref
+				components = append(components, c)
+			}
+			return ref
+		}
+
+		meta := object(d.Doc, "metadata")
+		if root := object(meta, "component"); root != nil {
+			if firstRoot == nil {
+				firstRoot = root
+			}
+			roots = appendUnique(roots, add(root))
+		}
+		for _, c := range objects(d.Doc, "components") {
+			add(c)
+		}
+		// Tools are an array before CycloneDX 1.5 and an object of
+		// components and services after.
+		if legacy := objects(meta, "tools"); len(legacy) > 0 {
+			for _, t := range legacy {
+				addTool(t)
+			}
+		}
+		if t := object(meta, "tools"); t != nil {
+			for _, c := range objects(t, "components") {
+				addTool(c)
+			}
+			services = append(services, objects(t, "services")...)
+		}
+		for _, dep := range objects(d.Doc, "dependencies") {
+			ref := remapRef(remap, str(dep, "ref"))
+			if _, ok := dependsOn[ref]; !ok {
+				deps = append(deps, ref)
+				dependsOn[ref] = []string{}
+			}
+			on, _ := dep["dependsOn"].([]any)
+			for _, v := range on {
+				if s, ok := v.(string); ok {
+					dependsOn[ref] = appendUnique(dependsOn[ref], remapRef(remap, s))
+				}
+			}
+		}
+	}
+
+	root := map[string]any{"type": "application", "name": opts.Name, "version": opts.Version}
+	if firstRoot != nil {
+		if opts.Name == "" {
+			root["name"] = str(firstRoot, "name")
+		}
+		if opts.Version == "" {
+			root["version"] = str(firstRoot, "version")
+		}
+		if t := str(firstRoot, "type"); t != "" {
+			root["type"] = t
+		}
+	}
+	if root["version"] == "" {
+		delete(root, "version")
+	}
+	rootRef, _ := ids.add("", "merged-root", 0)
+	root["bom-ref"] = rootRef
+	rootDeps := make([]any, len(roots))
+	for i, r := range roots {
+		rootDeps[i] = r
+	}
+	dependencies := []any{map[string]any{"ref": rootRef, "dependsOn": rootDeps}}
+	for _, ref := range deps {
+		on := make([]any, len(dependsOn[ref]))
+		for i, s := range dependsOn[ref] {
+			on[i] = s
+		}
+		dependencies = append(dependencies, map[string]any{"ref": ref, "dependsOn": on})
+	}
+
+	properties := make([]any, len(sources))
+	for i, s := range sources {
+		properties[i] = map[string]any{"name": MergedFromProperty, "value": s}
+	}
+	kusari := map[string]any{"name": "kusari-cli", "version": opts.Tool}
+	meta := map[string]any{
+		"timestamp":  opts.Now.UTC().Format(time.RFC3339),
+		"component":  root,
+		"properties": properties,
+	}
+	if semver.Compare("v"+specVersion, "v1.5") >= 0 {
+		kusari["type"] = "application"
+		// Legacy tool entries become components.
+		for _, t := range tools {
+			if v := str(t, "vendor"); v != "" {
+				delete(t, "vendor")
+				if str(t, "publisher") == "" {
+					t["publisher"] = v
+				}
+			}
+			if str(t, "type") == "" {
+				t["type"] = "application"
+			}
+		}
+		t := map[string]any{"components": array(append([]map[string]any{kusari}, tools...))}
+		if len(services) > 0 {
+			t["services"] = array(services)
+		}
+		meta["tools"] = t
+	} else {
+		kusari["vendor"] = "Kusari"
+		meta["tools"] = array(append([]map[string]any{kusari}, tools...))
+	}
+
+	return &Document{Format: FormatCycloneDX, Doc: map[string]any{
+		"bomFormat":    "CycloneDX",
+		"specVersion":  specVersion,
+		"serialNumber": "urn:uuid:" + uuid.NewString(),
+		"version":      1,
+		"metadata":     meta,
+		"components":   array(components),
+		"dependencies": dependencies,
+	}}
+}
+
+// nameVersion identifies a component without purl as [group/]name[@version].
+func nameVersion(group, name, version string) string {
+	if group != "" {
+		name = group + "/" + name
+	}
+	if version != "" {
+		name += "@" + version
+	}
+	return name
+}
+
+func remapRef(remap map[string]string, ref string) string {
+	if r, ok := remap[ref]; ok {
+		return r
+	}
+	return ref
+}
+
+// spdxPurl returns the purl external reference of an SPDX package.
+func spdxPurl(p map[string]any) string {
+	for _, ref := range objects(p, "externalRefs") {
+		if str(ref, "referenceType") == "purl" {
+			return str(ref, "referenceLocator")
+		}
+	}
+	return ""
+}
+
+func mergeSPDX(docs []*Document, opts MergeOptions) *Document {
+	spdxVersion := "SPDX-2.2"
+	ids := newDedup()
+	ids.used["SPDXRef-DOCUMENT"] = true
+	var packages, files, relationships, licenses, externalRefs []map[string]any
+	var creators, sources, describes []string
+	seen := map[string]bool{}
+	once := func(key string) bool {
+		if seen[key] {
+			return false
+		}
+		seen[key] = true
+		return true
+	}
+
+	for i, d := range docs {
+		if v := str(d.Doc, "spdxVersion"); v > spdxVersion {
+			spdxVersion = v
+		}
+		sources = append(sources, source(d, str(d.Doc, "documentNamespace")))
+		for _, c := range stringsAt(object(d.Doc, "creationInfo"), "creators") {
+			creators = appendUnique(creators, c)
+		}
+		remap := map[string]string{}
+		add := func(e map[string]any, key string) bool {
+			old := str(e, "SPDXID")
+			id, dup := ids.add(key, old, i)
+			remap[old] = id
+			e["SPDXID"] = id
+			return !dup
+		}
+		for _, p := range objects(d.Doc, "packages") {
+			key := spdxPurl(p)
+			if key == "" && str(p, "name") != "" {
+				key = nameVersion("", str(p, "name"), str(p, "versionInfo"))
+			}
+			if add(p, key) {
+				packages = append(packages, p)
+			}
+		}
+		for _, f := range objects(d.Doc, "files") {
+			if add(f, "") {
+				files = append(files, f)
+			}
+		}
+		for _, id := range stringsAt(d.Doc, "documentDescribes") {
+			describes = appendUnique(describes, remapRef(remap, id))
+		}
+		for _, r := range objects(d.Doc, "relationships") {
+			r["spdxElementId"] = remapRef(remap, str(r, "spdxElementId"))
+			r["relatedSpdxElement"] = remapRef(remap, str(r, "relatedSpdxElement"))
+			if once("rel:" + str(r, "spdxElementId") + " " + str(r, "relationshipType") + " " + str(r, "relatedSpdxElement")) {
+				relationships = append(relationships, r)
+			}
+		}
+		for _, l := range objects(d.Doc, "hasExtractedLicensingInfos") {
+			if once("license:" + str(l, "licenseId")) {
+				licenses = append(licenses, l)
+			}
+		}
+		for _, r := range objects(d.Doc, "externalDocumentRefs") {
+			if once("doc:" + str(r, "externalDocumentId")) {
+				externalRefs = append(externalRefs, r)
+			}
+		}
+	}
+
+	name := opts.Name
+	if name == "" {
+		name = str(docs[0].Doc, "name")
+	}
+	if opts.Version != "" {
+		name += "-" + opts.Version
+	}
+	creators = append([]string{"Tool: kusari-cli-" + opts.Tool}, creators...)
+	doc := map[string]any{
+		"spdxVersion":       spdxVersion,
+		"dataLicense":       "CC0-1.0",
+		"SPDXID":            "SPDXRef-DOCUMENT",
+		"name":              name,
+		"documentNamespace": "https://kusari.dev/spdxdocs/" + name + "-" + uuid.NewString(),
+		"creationInfo": map[string]any{
+			"created":  opts.Now.UTC().Format(time.RFC3339),
+			"creators": toAny(creators),
+			"comment":  "Merged from " + strings.Join(sources, ", "),
+		},
+		"packages":      array(packages),
+		"relationships": array(relationships),
+	}
+	if len(files) > 0 {
+		doc["files"] = array(files)
+	}
+	if len(describes) > 0 {
+		doc["documentDescribes"] = toAny(describes)
+	}
+	if len(licenses) > 0 {
+		doc["hasExtractedLicensingInfos"] = array(licenses)
+	}
+	if len(externalRefs) > 0 {
+		doc["externalDocumentRefs"] = array(externalRefs)
+	}
+	return &Document{Format: FormatSPDX, Doc: doc}
+}
+
+// stringsAt returns the strings in the JSON array at key of m.
+func stringsAt(m map[string]any, key string) []string {
+	arr, _ := m[key].([]any)
+	var out []string
+	for _, v := range arr {
+		if s, ok := v.(string); ok {
+			out = append(out, s)
+		}
+	}
+	return out
+}
+
+func toAny(list []string) []any {
+	out := make([]any, len(list))
+	for i, s := range list {
+		out[i] = s
+	}
+	return out
+}
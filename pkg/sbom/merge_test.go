@@ -0,0 +1,211 @@
+// Copyright (c) Kusari <https://www.kusari.dev/>
+// SPDX-License-Identifier: MIT
+
+package sbom
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func parse(t *testing.T, path, data string) *Document {
+	t.Helper()
+	d, err := Parse([]byte(data))
+	require.NoError(t, err)
+	d.Path = path
+	return d
+}
+
+const apiCDX = `{
+  "bomFormat": "CycloneDX",
+  "specVersion": "1.5",
+  "serialNumber": "urn:uuid:11111111-1111-1111-1111-111111111111",
+  "metadata": {
+    "tools": {"components": [{"type": "application", "name": "syft", "version": "1.0.0"}]},
+    "component": {"type": "application", "bom-ref": "root", "name": "api", "version": "1.0.0"}
+  },
+  "components": [
+    {"type": "library", "bom-ref": "lodash", "name": "lodash", "version": "4.17.21", "purl": "pkg:npm/lodash@4.17.21"},
+    {"type": "library", "bom-ref": "a", "name": "express", "version": "4.19.2", "purl": "pkg:npm/express@4.19.2"}
+  ],
+  "dependencies": [
+    {"ref": "root", "dependsOn": ["lodash", "a"]}
+  ]
+}`
+
+const workerCDX = `{
+  "bomFormat": "CycloneDX",
+  "specVersion": "1.4",
+  "serialNumber": "urn:uuid:22222222-2222-2222-2222-222222222222",
+  "metadata": {
+    "tools": [{"vendor": "anchore", "name": "syft", "version": "0.90.0"}],
+    "component": {"type": "application", "bom-ref": "root", "name": "worker", "version": "1.0.0"}
+  },
+  "components": [
+    {"type": "library", "bom-ref": "pkg:npm/lodash@4.17.21", "name": "lodash", "version": "4.17.21", "purl": "pkg:npm/lodash@4.17.21"},
+    {"type": "library", "bom-ref": "a", "name": "axios", "version": "1.7.2", "purl": "pkg:npm/axios@1.7.2"}
+  ],
+  "dependencies": [
+    {"ref": "root", "dependsOn": ["pkg:npm/lodash@4.17.21", "a"]}
+  ]
+}`
+
+func TestMerge_CycloneDX(t *testing.T) {
+	now := time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC)
+	merged, err := Merge([]*Document{parse(t, "api.cdx.json", apiCDX), parse(t, "worker.cdx.json", workerCDX)},
+		MergeOptions{Name: "app", Version: "2.0.0", Tool: "v1.2.3", Now: now})
+	require.NoError(t, err)
+	d := merged.Doc
+
+	assert.Equal(t, "CycloneDX", d["bomFormat"])
+	assert.Equal(t, "1.5", d["specVersion"])
+	assert.Regexp(t, `^urn:uuid:`, d["serialNumber"])
+	assert.Equal(t, "app", merged.Name())
+
+	meta := object(d, "metadata")
+	assert.Equal(t, "2026-10-01T12:00:00Z", meta["timestamp"])
+	assert.Equal(t, "2.0.0", object(meta, "component")["version"])
+	var sources []string
+	for _, p := range objects(meta, "properties") {
+		assert.Equal(t, MergedFromProperty, p["name"])
+		sources = append(sources, str(p, "value"))
+	}
+	assert.Equal(t, []string{"urn:uuid:11111111-1111-1111-1111-111111111111", "urn:uuid:22222222-2222-2222-2222-222222222222"}, sources)
+
+	// The legacy tool entry becomes a component, next to kusari-cli.
+	var tools []string
+	for _, c := range objects(object(meta, "tools"), "components") {
+		tools = append(tools, str(c, "name")+"@"+str(c, "version"))
+	}
+	assert.Equal(t, []string{"kusari-cli@v1.2.3", "syft@1.0.0", "syft@0.90.0"}, tools)
+
+	// lodash is listed once; the clashing refs root and a are renamed.
+	refs := map[string]string{}
+	for _, c := range objects(d, "components") {
+		refs[str(c, "name")] = str(c, "bom-ref")
+	}
+	assert.Equal(t, map[string]string{
+		"api":     "root",
+		"lodash":  "lodash",
+		"express": "a",
+		"worker":  "root-doc2",
+		"axios":   "a-doc2",
+	}, refs)
+
+	deps := map[string][]string{}
+	for _, dep := range objects(d, "dependencies") {
+		deps[str(dep, "ref")] = stringsAt(dep, "dependsOn")
+	}
+	assert.Equal(t, map[string][]string{
+		"merged-root": {"root", "root-doc2"},
+		"root":        {"lodash", "a"},
+		"root-doc2":   {"lodash", "a-doc2"},
+	}, deps)
+}
+
+func TestMerge_CycloneDXDefaultsToFirstSubject(t *testing.T) {
+	merged, err := Merge([]*Document{parse(t, "api.cdx.json", apiCDX), parse(t, "worker.cdx.json", workerCDX)}, MergeOptions{})
+	require.NoError(t, err)
+	root := object(object(merged.Doc, "metadata"), "component")
+	assert.Equal(t, "api", root["name"])
+	assert.Equal(t, "1.0.0", root["version"])
+}
+
+const apiSPDX = `{
+  "spdxVersion": "SPDX-2.3",
+  "dataLicense": "CC0-1.0",
+  "SPDXID": "SPDXRef-DOCUMENT",
+  "name": "api",
+  "documentNamespace": "https://example.com/api",
+  "creationInfo": {"created": "2026-01-01T00:00:00Z", "creators": ["Tool: syft-1.0.0"]},
+  "packages": [
+    {"SPDXID": "SPDXRef-api", "name": "api", "versionInfo": "1.0.0"},
+    {"SPDXID": "SPDXRef-Package-1", "name": "lodash", "versionInfo": "4.17.21",
+     "externalRefs": [{"referenceCategory": "PACKAGE-MANAGER", "referenceType": "purl", "referenceLocator": "pkg:npm/lodash@4.17.21"}]}
+  ],
+  "relationships": [
+    {"spdxElementId": "SPDXRef-DOCUMENT", "relationshipType": "DESCRIBES", "relatedSpdxElement": "SPDXRef-api"},
+    {"spdxElementId": "SPDXRef-api", "relationshipType": "DEPENDS_ON", "relatedSpdxElement": "SPDXRef-Package-1"}
+  ]
+}`
+
+const workerSPDX = `{
+  "spdxVersion": "SPDX-2.2",
+  "dataLicense": "CC0-1.0",
+  "SPDXID": "SPDXRef-DOCUMENT",
+  "name": "worker",
+  "documentNamespace": "https://example.com/worker",
+  "creationInfo": {"created": "2026-01-01T00:00:00Z", "creators": ["Tool: syft-1.0.0", "Organization: Example"]},
+  "packages": [
+    {"SPDXID": "SPDXRef-worker", "name": "worker", "versionInfo": "1.0.0"},
+    {"SPDXID": "SPDXRef-lodash", "name": "lodash", "versionInfo": "4.17.21",
+     "externalRefs": [{"referenceCategory": "PACKAGE-MANAGER", "referenceType": "purl", "referenceLocator": "pkg:npm/lodash@4.17.21"}]},
+    {"SPDXID": "SPDXRef-Package-1", "name": "axios", "versionInfo": "1.7.2"}
+  ],
+  "relationships": [
+    {"spdxElementId": "SPDXRef-DOCUMENT", "relationshipType": "DESCRIBES", "relatedSpdxElement": "SPDXRef-worker"},
+    {"spdxElementId": "SPDXRef-worker", "relationshipType": "DEPENDS_ON", "relatedSpdxElement": "SPDXRef-lodash"},
+    {"spdxElementId": "SPDXRef-worker", "relationshipType": "DEPENDS_ON", "relatedSpdxElement": "SPDXRef-Package-1"}
+  ]
+}`
+
+func TestMerge_SPDX(t *testing.T) {
+	merged, err := Merge([]*Document{parse(t, "api.spdx.json", apiSPDX), parse(t, "worker.spdx.json", workerSPDX)},
+		MergeOptions{Name: "app", Tool: "v1.2.3", Now: time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC)})
+	require.NoError(t, err)
+	d := merged.Doc
+
+	assert.Equal(t, "SPDX-2.3", d["spdxVersion"])
+	assert.Equal(t, "app", d["name"])
+	assert.Regexp(t, `^https://kusari.dev/spdxdocs/app-`, d["documentNamespace"])
+	info := object(d, "creationInfo")
+	assert.Equal(t, []string{"Tool: kusari-cli-v1.2.3", "Tool: syft-1.0.0", "Organization: Example"}, stringsAt(info, "creators"))
+	assert.Equal(t, "Merged from https://example.com/api, https://example.com/worker", info["comment"])
+
+	var ids []string
+	for _, p := range objects(d, "packages") {
+		ids = append(ids, str(p, "name")+"="+str(p, "SPDXID"))
+	}
+	assert.Equal(t, []string{"api=SPDXRef-api", "lodash=SPDXRef-Package-1", "worker=SPDXRef-worker", "axios=SPDXRef-Package-1-doc2"}, ids)
+
+	var rels []string
+	for _, r := range objects(d, "relationships") {
+		rels = append(rels, str(r, "spdxElementId")+" "+str(r, "relationshipType")+" "+str(r, "relatedSpdxElement"))
+	}
+	assert.Equal(t, []string{
+		"SPDXRef-DOCUMENT DESCRIBES SPDXRef-api",
+		"SPDXRef-api DEPENDS_ON SPDXRef-Package-1",
+		"SPDXRef-DOCUMENT DESCRIBES SPDXRef-worker",
+		"SPDXRef-worker DEPENDS_ON SPDXRef-Package-1",
+		"SPDXRef-worker DEPENDS_ON SPDXRef-Package-1-doc2",
+	}, rels)
+}
+
+func TestMerge_MixedFormats(t *testing.T) {
+	_, err := Merge([]*Document{parse(t, "api.cdx.json", apiCDX), parse(t, "api.spdx.json", apiSPDX)}, MergeOptions{})
+	assert.ErrorContains(t, err, "cannot merge cyclonedx api.cdx.json with spdx api.spdx.json")
+}
+
+func TestReadWrite(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "bom.json")
+	require.NoError(t, os.WriteFile(path, []byte(apiCDX), 0600))
+	d, err := Read(path)
+	require.NoError(t, err)
+	assert.Equal(t, FormatCycloneDX, d.Format)
+
+	out := filepath.Join(dir, "out.json")
+	require.NoError(t, d.Write(out))
+	again, err := Read(out)
+	require.NoError(t, err)
+	assert.Equal(t, d.Doc, again.Doc)
+
+	require.NoError(t, os.WriteFile(path, []byte(`{"foo": 1}`), 0600))
+	_, err = Read(path)
+	assert.ErrorContains(t, err, "not an SPDX or CycloneDX JSON document")
+}
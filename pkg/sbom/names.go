@@ -0,0 +1,111 @@
+// Copyright (c) Kusari <https://www.kusari.dev/>
+// SPDX-License-Identifier: MIT
+
+package sbom
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// NameRules normalize the subject names of SBOMs, so "my-app", "my_app"
+// and "MyApp" from different build systems land under one software entry.
+// The rules are applied in order, then the result is looked up in the
+// aliases.
+type NameRules struct {
+	rules   []nameRule
+	aliases map[string]string
+}
+
+// nameRule is a regular expression replacement, or lowercasing when
+// pattern is nil.
+type nameRule struct {
+	pattern     *regexp.Regexp
+	replacement string
+}
+
+// ParseNameRules parses rules of the form "REGEX => REPLACEMENT", where the
+// replacement may refer to groups as $1, or "lowercase". Aliases map names,
+// as normalized by the rules, to the name to use instead. It returns nil when
+// there are neither rules nor aliases.
+func ParseNameRules(rules []string, aliases map[string]any) (*NameRules, error) {
+	if len(rules) == 0 && len(aliases) == 0 {
+		return nil, nil
+	}
+	n := &NameRules{aliases: map[string]string{}}
+	for _, r := range rules {
+		if strings.TrimSpace(r) == "lowercase" {
+			n.rules = append(n.rules, nameRule{})
+			continue
+		}
+		pattern, replacement, ok := strings.Cut(r, "=>")
+		if !ok {
+			return nil, fmt.Errorf("invalid subject name rule %q (want \"REGEX => REPLACEMENT\" or \"lowercase\")", r)
+		}
+		re, err := regexp.Compile(strings.TrimSpace(pattern))
+		if err != nil {
+			return nil, fmt.Errorf("invalid subject name rule %q: %w", r, err)
+		}
+		n.rules = append(n.rules, nameRule{pattern: re, replacement: strings.TrimSpace(replacement)})
+	}
+	for name, to := range aliases {
+		s, ok := to.(string)
+		if !ok {
+			return nil, fmt.Errorf("subject name alias %q must map to a name", name)
+		}
+		n.aliases[n.apply(name)] = s
+	}
+	return n, nil
+}
+
+// LoadNameRules reads a central mapping file: YAML with the
+// subject_name_rules and subject_name_aliases keys of kusari.yaml.
+func LoadNameRules(path string) (*NameRules, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	var file struct {
+		Rules   []string       `yaml:"subject_name_rules"`
+		Aliases map[string]any `yaml:"subject_name_aliases"`
+	}
+	if err := yaml.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	rules, err := ParseNameRules(file.Rules, file.Aliases)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	if rules == nil {
+		return nil, fmt.Errorf("%s has no subject_name_rules or subject_name_aliases", path)
+	}
+	return rules, nil
+}
+
+// Normalize returns name as the rules and aliases make it. A nil
+// NameRules leaves name as it is.
+func (n *NameRules) Normalize(name string) string {
+	if n == nil || name == "" {
+		return name
+	}
+	name = n.apply(name)
+	if alias, ok := n.aliases[name]; ok {
+		return alias
+	}
+	return name
+}
+
+func (n *NameRules) apply(name string) string {
+	for _, r := range n.rules {
+		if r.pattern == nil {
+			name = strings.ToLower(name)
+		} else {
+			name = r.pattern.ReplaceAllString(name, r.replacement)
+		}
+	}
+	return name
+}
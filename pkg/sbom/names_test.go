@@ -0,0 +1,69 @@
+// Copyright (c) Kusari <https://www.kusari.dev/>
+// SPDX-License-Identifier: MIT
+
+package sbom
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// kebabRules make one name of camel case, snake case and kebab case.
+var kebabRules = []string{
+	`([a-z0-9])([A-Z]) => $1-$2`,
+	"lowercase",
+	`[-_. ]+ => -`,
+}
+
+func TestNameRules_Normalize(t *testing.T) {
+	rules, err := ParseNameRules(kebabRules, map[string]any{"LegacyApp": "my-app"})
+	require.NoError(t, err)
+
+	for in, want := range map[string]string{
+		"my-app":     "my-app",
+		"my_app":     "my-app",
+		"MyApp":      "my-app",
+		"My.App":     "my-app",
+		"legacy_app": "my-app", // an alias, matched normalized
+		"other":      "other",
+		"":           "",
+	} {
+		assert.Equal(t, want, rules.Normalize(in), in)
+	}
+}
+
+func TestParseNameRules(t *testing.T) {
+	rules, err := ParseNameRules(nil, nil)
+	require.NoError(t, err)
+	assert.Nil(t, rules)
+	assert.Equal(t, "MyApp", rules.Normalize("MyApp"))
+
+	_, err = ParseNameRules([]string{"uppercase"}, nil)
+	assert.ErrorContains(t, err, `want "REGEX => REPLACEMENT" or "lowercase"`)
+	_, err = ParseNameRules([]string{"[ => x"}, nil)
+	assert.ErrorContains(t, err, "invalid subject name rule")
+	_, err = ParseNameRules(nil, map[string]any{"a": 1})
+	assert.ErrorContains(t, err, "must map to a name")
+}
+
+func TestLoadNameRules(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "names.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(`subject_name_rules:
+  - lowercase
+  - "_ => -"
+subject_name_aliases:
+  old-name: my-app
+`), 0600))
+	rules, err := LoadNameRules(path)
+	require.NoError(t, err)
+	assert.Equal(t, "my-app", rules.Normalize("My_App"))
+	assert.Equal(t, "my-app", rules.Normalize("Old_Name"))
+
+	require.NoError(t, os.WriteFile(path, []byte("other: 1\n"), 0600))
+	_, err = LoadNameRules(path)
+	assert.ErrorContains(t, err, "has no subject_name_rules")
+}
@@ -0,0 +1,120 @@
+// Copyright (c) Kusari <https://www.kusari.dev/>
+// SPDX-License-Identifier: MIT
+
+// Package sbom reworks SPDX 2.x and CycloneDX JSON documents before they
+// are uploaded, such as merging the per-module SBOMs of one build.
+// Documents are handled as generic JSON so fields this package doesn't
+// know about are kept.
+package sbom
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+)
+
+// Formats of an SBOM.
+const (
+	FormatSPDX      = "spdx"
+	FormatCycloneDX = "cyclonedx"
+)
+
+// Document is a parsed SBOM.
+type Document struct {
+	Path   string
+	Format string
+	Doc    map[string]any
+}
+
+// Read reads the SPDX or CycloneDX JSON document at path.
+func Read(path string) (*Document, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	doc, err := Parse(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	doc.Path = path
+	return doc, nil
+}
+
+// Parse parses an SPDX or CycloneDX JSON document.
+func Parse(data []byte) (*Document, error) {
+	var doc map[string]any
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("not a JSON document: %w", err)
+	}
+	switch {
+	case doc["bomFormat"] == "CycloneDX":
+		return &Document{Format: FormatCycloneDX, Doc: doc}, nil
+	case doc["SPDXID"] == "SPDXRef-DOCUMENT":
+		return &Document{Format: FormatSPDX, Doc: doc}, nil
+	default:
+		return nil, fmt.Errorf("not an SPDX or CycloneDX JSON document")
+	}
+}
+
+// Name returns the name of what d describes: the CycloneDX metadata
+// component, or the SPDX document name.
+func (d *Document) Name() string {
+	if d.Format == FormatCycloneDX {
+		return str(object(object(d.Doc, "metadata"), "component"), "name")
+	}
+	return str(d.Doc, "name")
+}
+
+// Write writes d to path as indented JSON.
+func (d *Document) Write(path string) error {
+	data, err := d.Marshal()
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(path, data, 0600); err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	return nil
+}
+
+// Marshal returns d as indented JSON.
+func (d *Document) Marshal() ([]byte, error) {
+	data, err := json.MarshalIndent(d.Doc, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal SBOM: %w", err)
+	}
+	return append(data, '\n'), nil
+}
+
+// object returns the JSON object at key of m, or nil.
+func object(m map[string]any, key string) map[string]any {
+	o, _ := m[key].(map[string]any)
+	return o
+}
+
+// objects returns the objects in the JSON array at key of m.
+func objects(m map[string]any, key string) []map[string]any {
+	arr, _ := m[key].([]any)
+	out := make([]map[string]any, 0, len(arr))
+	for _, v := range arr {
+		if o, ok := v.(map[string]any); ok {
+			out = append(out, o)
+		}
+	}
+	return out
+}
+
+// str returns the string at key of m, or empty.
+func str(m map[string]any, key string) string {
+	s, _ := m[key].(string)
+	return s
+}
+
+// array returns objs as a JSON array.
+func array(objs []map[string]any) []any {
+	out := make([]any, len(objs))
+	for i, o := range objs {
+		out[i] = o
+	}
+	return out
+}
@@ -0,0 +1,105 @@
+// Copyright (c) Kusari <https://www.kusari.dev/>
+// SPDX-License-Identifier: MIT
+
+package schedule
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/kusaridev/kusari-cli/v2/pkg/results"
+	"github.com/kusaridev/kusari-cli/v2/pkg/transport"
+)
+
+// Notification is the JSON document a scheduled run posts to its webhook.
+type Notification struct {
+	Job        string `json:"job"`
+	Repository string `json:"repository"`
+	Kind       string `json:"kind"`
+	// Status is "passed", "flagged", "unchanged" or "error".
+	Status string `json:"status"`
+	// Error is why the run failed, when Status is "error".
+	Error       string        `json:"error,omitempty"`
+	HealthScore int           `json:"health_score,omitempty"`
+	Score       int           `json:"score,omitempty"`
+	ConsoleURL  string        `json:"console_url,omitempty"`
+	Findings    []results.Row `json:"findings"`
+}
+
+// NewNotification summarizes a run of job. res is the run's result; runErr
+// its error, if it failed.
+func NewNotification(j *Job, res *results.Result, runErr error) Notification {
+	n := newNotification(j)
+	switch {
+	case runErr != nil:
+		n.Status = "error"
+		n.Error = runErr.Error()
+	case res == nil || res.Analysis == nil:
+		n.Status = "error"
+		n.Error = "no results"
+	default:
+		n.Status = "flagged"
+		if res.Analysis.ShouldProceed {
+			n.Status = "passed"
+		}
+		n.HealthScore = res.Analysis.HealthScore
+		n.Score = res.Score
+		n.ConsoleURL = res.ConsoleURL
+		n.Findings = res.Rows()
+	}
+	return n
+}
+
+// NewUnchangedNotification summarizes a diff scan run of job that had
+// nothing new to analyze: its diff was empty, or the same as an earlier
+// run's, whose results were reported then.
+func NewUnchangedNotification(j *Job) Notification {
+	n := newNotification(j)
+	n.Status = "unchanged"
+	return n
+}
+
+func newNotification(j *Job) Notification {
+	n := Notification{Job: j.Name, Repository: j.Dir, Kind: "scan", Findings: []results.Row{}}
+	if j.Full {
+		n.Kind = "risk-check"
+	}
+	return n
+}
+
+// Notify posts n to url. Header values may reference environment
+// variables as $VAR or ${VAR}, to keep tokens out of crontabs and units.
+func Notify(ctx context.Context, url string, headers map[string]string, n Notification) error {
+	body, err := json.Marshal(n)
+	if err != nil {
+		return fmt.Errorf("failed to encode notification: %w", err)
+	}
+
+	client := &http.Client{Timeout: transport.Timeout(30 * time.Second)}
+	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range headers {
+		req.Header.Set(k, os.ExpandEnv(v))
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("notification webhook request failed: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		respBody, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("notification webhook returned status %d: %s", resp.StatusCode, string(respBody))
+	}
+	return nil
+}
@@ -0,0 +1,404 @@
+// Copyright (c) Kusari <https://www.kusari.dev/>
+// SPDX-License-Identifier: MIT
+
+// Package schedule installs recurring Kusari scans of a local repository
+// as a cron entry or a systemd user timer, for repositories without CI
+// coverage.
+package schedule
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Schedulers a job can be installed with.
+const (
+	Cron    = "cron"
+	Systemd = "systemd"
+)
+
+// DefaultCron is the schedule used when none is given: nightly at 02:00.
+const DefaultCron = "0 2 * * *"
+
+// cronMarker tags the crontab lines a job owns, so reinstalling or
+// removing it leaves the rest of the crontab alone.
+const cronMarker = "# kusari-schedule:"
+
+// Job is a scheduled scan of one repository.
+type Job struct {
+	// Name identifies the job among installed ones. Defaults to the
+	// repository directory name.
+	Name string
+	// Dir is the absolute path of the repository.
+	Dir string
+	// Cron is a five-field cron expression.
+	Cron string
+	// Full runs a risk check of the whole repository. Otherwise Rev is the
+	// git ref a diff scan compares against.
+	Full bool
+	Rev  string
+	// Notify is a webhook URL the results are posted to, if any, with
+	// NotifyHeaders as "Name: value" lines.
+	Notify        string
+	NotifyHeaders []string
+	// Binary is the kusari executable the job runs.
+	Binary string
+}
+
+var namePattern = regexp.MustCompile(`[^A-Za-z0-9_.-]+`)
+
+// NewJob returns a job for the repository at dir, filling in the name and
+// the path of the running executable.
+func NewJob(dir, name string) (*Job, error) {
+	abs, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve %s: %w", dir, err)
+	}
+	if name == "" {
+		name = filepath.Base(abs)
+	}
+	name = strings.Trim(namePattern.ReplaceAllString(name, "-"), "-")
+	if name == "" {
+		return nil, fmt.Errorf("invalid job name")
+	}
+	bin, err := os.Executable()
+	if err != nil {
+		return nil, fmt.Errorf("failed to locate the kusari executable: %w", err)
+	}
+	return &Job{Name: name, Dir: abs, Cron: DefaultCron, Binary: bin}, nil
+}
+
+// Args returns the command line the job runs.
+func (j *Job) Args() []string {
+	args := []string{j.Binary, "schedule", "run", j.Dir, "--name", j.Name}
+	if j.Full {
+		args = append(args, "--full")
+	} else {
+		args = append(args, "--rev", j.Rev)
+	}
+	if j.Notify != "" {
+		args = append(args, "--notify", j.Notify)
+		for _, h := range j.NotifyHeaders {
+			args = append(args, "--notify-header", h)
+		}
+	}
+	return args
+}
+
+// LogPath is where the job's output goes, ~/.kusari/logs/schedule-<name>.log.
+func (j *Job) LogPath() (string, error) {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to get home directory: %w", err)
+	}
+	return filepath.Join(homeDir, ".kusari", "logs", "schedule-"+j.Name+".log"), nil
+}
+
+// CronLine returns the crontab entry for the job.
+func (j *Job) CronLine() (string, error) {
+	if _, err := OnCalendar(j.Cron); err != nil {
+		return "", err
+	}
+	logPath, err := j.LogPath()
+	if err != nil {
+		return "", err
+	}
+	quoted := make([]string, 0, len(j.Args()))
+	for _, a := range j.Args() {
+		quoted = append(quoted, shellQuote(a))
+	}
+	command := fmt.Sprintf("%s >> %s 2>&1", strings.Join(quoted, " "), shellQuote(logPath))
+	// cron turns an unescaped % into a newline.
+	command = strings.ReplaceAll(command, "%", `\%`)
+	return fmt.Sprintf("%s %s %s%s", j.Cron, command, cronMarker, j.Name), nil
+}
+
+// UnitName is the base name of the job's systemd units.
+func (j *Job) UnitName() string {
+	return "kusari-scan-" + j.Name
+}
+
+// SystemdUnits returns the service and timer units for the job.
+func (j *Job) SystemdUnits() (service, timer string, err error) {
+	calendar, err := OnCalendar(j.Cron)
+	if err != nil {
+		return "", "", err
+	}
+	quoted := make([]string, 0, len(j.Args()))
+	for _, a := range j.Args() {
+		quoted = append(quoted, systemdQuote(a))
+	}
+
+	service = fmt.Sprintf(`[Unit]
+Description=Kusari scan of %s
+
+[Service]
+Type=oneshot
+WorkingDirectory=%s
+ExecStart=%s
+`, j.Dir, systemdQuote(j.Dir), strings.Join(quoted, " "))
+
+	timer = fmt.Sprintf(`[Unit]
+Description=Scheduled Kusari scan of %s
+
+[Timer]
+OnCalendar=%s
+Persistent=true
+
+[Install]
+WantedBy=timers.target
+`, j.Dir, calendar)
+	return service, timer, nil
+}
+
+// InstallCron adds the job to the user's crontab, replacing an earlier
+// entry of the same name.
+func InstallCron(j *Job) error {
+	line, err := j.CronLine()
+	if err != nil {
+		return err
+	}
+	logPath, err := j.LogPath()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(logPath), 0700); err != nil {
+		return fmt.Errorf("failed to create log directory: %w", err)
+	}
+	current, err := readCrontab()
+	if err != nil {
+		return err
+	}
+	return writeCrontab(append(withoutJob(current, j.Name), line))
+}
+
+// RemoveCron removes the job from the user's crontab. It reports whether
+// there was an entry to remove.
+func RemoveCron(name string) (bool, error) {
+	current, err := readCrontab()
+	if err != nil {
+		return false, err
+	}
+	kept := withoutJob(current, name)
+	if len(kept) == len(current) {
+		return false, nil
+	}
+	return true, writeCrontab(kept)
+}
+
+// withoutJob returns the crontab lines that don't belong to job name.
+func withoutJob(lines []string, name string) []string {
+	var kept []string
+	for _, l := range lines {
+		if !strings.HasSuffix(l, cronMarker+name) {
+			kept = append(kept, l)
+		}
+	}
+	return kept
+}
+
+func readCrontab() ([]string, error) {
+	var stderr bytes.Buffer
+	cmd := exec.Command("crontab", "-l")
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		// crontab -l fails when the user has no crontab yet.
+		if strings.Contains(strings.ToLower(stderr.String()), "no crontab") {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to read crontab: %w: %s", err, strings.TrimSpace(stderr.String()))
+	}
+	text := strings.TrimRight(string(out), "\n")
+	if text == "" {
+		return nil, nil
+	}
+	return strings.Split(text, "\n"), nil
+}
+
+func writeCrontab(lines []string) error {
+	cmd := exec.Command("crontab", "-")
+	cmd.Stdin = strings.NewReader(strings.Join(lines, "\n") + "\n")
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("failed to write crontab: %w: %s", err, strings.TrimSpace(string(out)))
+	}
+	return nil
+}
+
+// systemdUserDir is where user units are installed.
+func systemdUserDir() (string, error) {
+	if dir := os.Getenv("XDG_CONFIG_HOME"); dir != "" {
+		return filepath.Join(dir, "systemd", "user"), nil
+	}
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to get home directory: %w", err)
+	}
+	return filepath.Join(homeDir, ".config", "systemd", "user"), nil
+}
+
+// InstallSystemd writes the job's units to the systemd user directory and
+// enables the timer. It returns the unit directory.
+func InstallSystemd(j *Job) (string, error) {
+	service, timer, err := j.SystemdUnits()
+	if err != nil {
+		return "", err
+	}
+	dir, err := systemdUserDir()
+	if err != nil {
+		return "", err
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", fmt.Errorf("failed to create %s: %w", dir, err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, j.UnitName()+".service"), []byte(service), 0644); err != nil {
+		return "", fmt.Errorf("failed to write service unit: %w", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, j.UnitName()+".timer"), []byte(timer), 0644); err != nil {
+		return "", fmt.Errorf("failed to write timer unit: %w", err)
+	}
+
+	if err := systemctl("daemon-reload"); err != nil {
+		return dir, err
+	}
+	return dir, systemctl("enable", "--now", j.UnitName()+".timer")
+}
+
+// RemoveSystemd disables the job's timer and deletes its units. It reports
+// whether there were units to remove.
+func RemoveSystemd(name string) (bool, error) {
+	dir, err := systemdUserDir()
+	if err != nil {
+		return false, err
+	}
+	unit := (&Job{Name: name}).UnitName()
+	timerPath := filepath.Join(dir, unit+".timer")
+	if _, err := os.Stat(timerPath); os.IsNotExist(err) {
+		return false, nil
+	}
+	if err := systemctl("disable", "--now", unit+".timer"); err != nil {
+		return true, err
+	}
+	for _, p := range []string{timerPath, filepath.Join(dir, unit+".service")} {
+		if err := os.Remove(p); err != nil && !os.IsNotExist(err) {
+			return true, fmt.Errorf("failed to remove %s: %w", p, err)
+		}
+	}
+	return true, systemctl("daemon-reload")
+}
+
+func systemctl(args ...string) error {
+	out, err := exec.Command("systemctl", append([]string{"--user"}, args...)...).CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("systemctl --user %s failed: %w: %s", strings.Join(args, " "), err, strings.TrimSpace(string(out)))
+	}
+	return nil
+}
+
+var weekdays = []string{"Sun", "Mon", "Tue", "Wed", "Thu", "Fri", "Sat", "Sun"}
+
+// OnCalendar converts a five-field cron expression (minute, hour, day of
+// month, month, day of week) to a systemd OnCalendar expression. Each
+// field may be *, a number, a range a-b, a list, or a */n or a/n step.
+func OnCalendar(cron string) (string, error) {
+	fields := strings.Fields(cron)
+	if len(fields) != 5 {
+		return "", fmt.Errorf("invalid cron expression %q: want 5 fields (minute hour day month weekday)", cron)
+	}
+	limits := [5][2]int{{0, 59}, {0, 23}, {1, 31}, {1, 12}, {0, 7}}
+	conv := make([]string, 5)
+	for i, f := range fields {
+		c, err := calendarField(f, limits[i][0], limits[i][1], i == 4)
+		if err != nil {
+			return "", fmt.Errorf("invalid cron expression %q: %w", cron, err)
+		}
+		conv[i] = c
+	}
+
+	spec := fmt.Sprintf("*-%s-%s %s:%s:00", conv[3], conv[2], conv[1], conv[0])
+	if conv[4] != "*" {
+		spec = conv[4] + " " + spec
+	}
+	return spec, nil
+}
+
+// calendarField converts one cron field. Weekday numbers become names.
+func calendarField(f string, lo, hi int, weekday bool) (string, error) {
+	if f == "*" {
+		return "*", nil
+	}
+	var parts []string
+	for _, item := range strings.Split(f, ",") {
+		rangePart, step, hasStep := strings.Cut(item, "/")
+		if hasStep {
+			n, err := strconv.Atoi(step)
+			if err != nil || n <= 0 || weekday {
+				return "", fmt.Errorf("unsupported step %q", item)
+			}
+			start := lo
+			if rangePart != "*" {
+				if start, err = bounded(rangePart, lo, hi); err != nil {
+					return "", err
+				}
+			}
+			parts = append(parts, fmt.Sprintf("%d/%d", start, n))
+			continue
+		}
+		from, to, isRange := strings.Cut(item, "-")
+		a, err := bounded(from, lo, hi)
+		if err != nil {
+			return "", err
+		}
+		if !isRange {
+			parts = append(parts, name(a, weekday))
+			continue
+		}
+		b, err := bounded(to, lo, hi)
+		if err != nil {
+			return "", err
+		}
+		if b < a {
+			return "", fmt.Errorf("invalid range %q", item)
+		}
+		parts = append(parts, name(a, weekday)+".."+name(b, weekday))
+	}
+	return strings.Join(parts, ","), nil
+}
+
+func bounded(s string, lo, hi int) (int, error) {
+	n, err := strconv.Atoi(s)
+	if err != nil || n < lo || n > hi {
+		return 0, fmt.Errorf("%q is not a number from %d to %d", s, lo, hi)
+	}
+	return n, nil
+}
+
+func name(n int, weekday bool) string {
+	if weekday {
+		return weekdays[n]
+	}
+	return fmt.Sprintf("%02d", n)
+}
+
+// shellQuote quotes s for a POSIX shell.
+func shellQuote(s string) string {
+	if s != "" && !strings.ContainsAny(s, " \t\n'\"\\$`!*?[]{}()<>|&;#~%") {
+		return s
+	}
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// systemdQuote quotes s for an ExecStart line, where % starts a specifier.
+func systemdQuote(s string) string {
+	s = strings.ReplaceAll(s, "%", "%%")
+	if s != "" && !strings.ContainsAny(s, " \t\"'\\") {
+		return s
+	}
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(s) + `"`
+}
@@ -0,0 +1,128 @@
+// Copyright (c) Kusari <https://www.kusari.dev/>
+// SPDX-License-Identifier: MIT
+
+package schedule
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/kusaridev/kusari-cli/v2/api"
+	"github.com/kusaridev/kusari-cli/v2/pkg/results"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOnCalendar(t *testing.T) {
+	tests := []struct {
+		cron string
+		want string
+	}{
+		{"0 2 * * *", "*-*-* 02:00:00"},
+		{"*/15 9-17 * * 1-5", "Mon..Fri *-*-* 09..17:0/15:00"},
+		{"30 4 1,15 * 0", "Sun *-*-01,15 04:30:00"},
+		{"0 0 1 */3 *", "*-1/3-01 00:00:00"},
+	}
+	for _, tt := range tests {
+		got, err := OnCalendar(tt.cron)
+		require.NoError(t, err, tt.cron)
+		assert.Equal(t, tt.want, got, tt.cron)
+	}
+
+	for _, bad := range []string{"0 2 * *", "60 * * * *", "0 2 * * MON", "5-1 * * * *", "0 0 * * */2"} {
+		_, err := OnCalendar(bad)
+		assert.Error(t, err, bad)
+	}
+}
+
+func TestCronLine(t *testing.T) {
+	t.Setenv("HOME", "/home/dev")
+	j := &Job{
+		Name:          "my-app",
+		Dir:           "/src/my app",
+		Cron:          DefaultCron,
+		Full:          true,
+		Notify:        "https://hooks.example.com/x?q=a%20b",
+		NotifyHeaders: []string{"Authorization: Bearer $TOKEN"},
+		Binary:        "/usr/local/bin/kusari",
+	}
+	line, err := j.CronLine()
+	require.NoError(t, err)
+	assert.Equal(t, "0 2 * * * /usr/local/bin/kusari schedule run '/src/my app' --name my-app --full"+
+		` --notify 'https://hooks.example.com/x?q=a\%20b' --notify-header 'Authorization: Bearer $TOKEN'`+
+		" >> /home/dev/.kusari/logs/schedule-my-app.log 2>&1 # kusari-schedule:my-app", line)
+
+	crontab := []string{"MAILTO=dev", "0 * * * * backup", line, "5 2 * * * other # kusari-schedule:my-app-2"}
+	assert.Equal(t, []string{"MAILTO=dev", "0 * * * * backup", "5 2 * * * other # kusari-schedule:my-app-2"}, withoutJob(crontab, "my-app"))
+}
+
+func TestSystemdUnits(t *testing.T) {
+	j := &Job{Name: "app", Dir: "/src/app", Cron: "0 3 * * 6", Rev: "origin/main", Binary: "/usr/bin/kusari"}
+	service, timer, err := j.SystemdUnits()
+	require.NoError(t, err)
+	assert.Contains(t, service, "ExecStart=/usr/bin/kusari schedule run /src/app --name app --rev origin/main\n")
+	assert.Contains(t, service, "WorkingDirectory=/src/app\n")
+	assert.Contains(t, timer, "OnCalendar=Sat *-*-* 03:00:00\n")
+	assert.Equal(t, "kusari-scan-app", j.UnitName())
+}
+
+func TestNewJob(t *testing.T) {
+	j, err := NewJob("/src/My Repo!", "")
+	require.NoError(t, err)
+	assert.Equal(t, "My-Repo", j.Name)
+	assert.Equal(t, DefaultCron, j.Cron)
+	assert.NotEmpty(t, j.Binary)
+}
+
+func TestNotify(t *testing.T) {
+	t.Setenv("HOOK_TOKEN", "s3cret")
+	var got Notification
+	var auth string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		auth = r.Header.Get("Authorization")
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&got))
+	}))
+	defer server.Close()
+
+	j := &Job{Name: "app", Dir: "/src/app", Full: true}
+	res := &results.Result{
+		Analysis: &api.SecurityAnalysis{
+			HealthScore: 3,
+			RequiredCodeMitigations: []api.CodeMitigationItem{
+				{Path: "main.go", LineNumber: 4, Content: "SQL injection"},
+			},
+		},
+		ConsoleURL: "https://console.example.com/r/1",
+	}
+	n := NewNotification(j, res, nil)
+	require.NoError(t, Notify(context.Background(), server.URL, map[string]string{"Authorization": "Bearer $HOOK_TOKEN"}, n))
+
+	assert.Equal(t, "Bearer s3cret", auth)
+	assert.Equal(t, "risk-check", got.Kind)
+	assert.Equal(t, "flagged", got.Status)
+	assert.Equal(t, 3, got.HealthScore)
+	require.Len(t, got.Findings, 1)
+	assert.Equal(t, "main.go", got.Findings[0].Path)
+
+	failed := NewNotification(j, nil, errors.New("upload failed"))
+	assert.Equal(t, "error", failed.Status)
+	assert.Equal(t, "upload failed", failed.Error)
+	assert.NotNil(t, failed.Findings)
+
+	unchanged := NewUnchangedNotification(&Job{Name: "app", Dir: "/src/app", Rev: "main"})
+	assert.Equal(t, "unchanged", unchanged.Status)
+	assert.Equal(t, "scan", unchanged.Kind)
+	assert.Empty(t, unchanged.Error)
+
+	server.Config.Handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+	})
+	err := Notify(context.Background(), server.URL, nil, failed)
+	require.Error(t, err)
+	assert.True(t, strings.Contains(err.Error(), "502"))
+}
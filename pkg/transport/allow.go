@@ -0,0 +1,111 @@
+// Copyright (c) Kusari <https://www.kusari.dev/>
+// SPDX-License-Identifier: MIT
+
+package transport
+
+import (
+	"fmt"
+	"net/http"
+	"path"
+	"strings"
+)
+
+// allowedHosts are the host patterns set with SetAllowedHosts. Nil allows
+// every host.
+var allowedHosts []string
+
+// HostNotAllowedError is a request refused because its host, or the proxy
+// it would go through, is not on the allowlist set with SetAllowedHosts.
+type HostNotAllowedError struct {
+	Host string
+}
+
+func (e *HostNotAllowedError) Error() string {
+	return fmt.Sprintf("refusing to contact %s: it is not in allowed_hosts (add it to KUSARI_ALLOWED_HOSTS or --allowed-hosts to allow it)", e.Host)
+}
+
+// SetAllowedHosts restricts every request, redirects included, to the
+// hosts matching patterns, for air-gapped and regulated deployments. A
+// pattern is a host name, case-insensitive, where * matches any run of
+// characters, e.g. *.s3.us-east-1.amazonaws.com for presigned upload
+// URLs. No patterns lifts the restriction. It fails closed: an invalid
+// pattern matches nothing, and is returned as an error with the others
+// still in force.
+func SetAllowedHosts(patterns []string) error {
+	var allowed []string
+	var bad []string
+	for _, p := range patterns {
+		p = strings.ToLower(strings.TrimSpace(p))
+		if p == "" {
+			continue
+		}
+		if _, err := path.Match(p, ""); err != nil || strings.Contains(p, "/") {
+			bad = append(bad, p)
+			continue
+		}
+		allowed = append(allowed, p)
+	}
+	if len(allowed) == 0 && len(bad) > 0 {
+		// Nothing valid to allow is still a restriction.
+		allowed = []string{}
+	}
+
+	mu.Lock()
+	allowedHosts = allowed
+	mu.Unlock()
+	if len(bad) > 0 {
+		return fmt.Errorf("invalid allowed_hosts pattern(s) %s, which allow nothing", strings.Join(bad, ", "))
+	}
+	return nil
+}
+
+// Restricted reports whether requests are restricted to an allowlist.
+func Restricted() bool {
+	mu.RLock()
+	defer mu.RUnlock()
+	return allowedHosts != nil
+}
+
+// CheckHost returns a HostNotAllowedError if host, a host name or
+// host:port, is not on the allowlist. Callers that reach the network
+// other than through http.DefaultTransport, such as tools the CLI runs,
+// check the hosts they will contact with it.
+func CheckHost(host string) error {
+	mu.RLock()
+	allowed := allowedHosts
+	mu.RUnlock()
+	return checkHost(allowed, host)
+}
+
+func checkHost(allowed []string, host string) error {
+	if allowed == nil {
+		return nil
+	}
+	name := strings.ToLower(host)
+	if h, _, ok := strings.Cut(name, "]"); ok {
+		name = strings.TrimPrefix(h, "[")
+	} else if h, _, ok := strings.Cut(name, ":"); ok {
+		name = h
+	}
+	for _, p := range allowed {
+		if ok, _ := path.Match(p, name); ok {
+			return nil
+		}
+	}
+	return &HostNotAllowedError{Host: name}
+}
+
+// checkRequest checks the host of req and of the proxy base sends it
+// through against allowed.
+func checkRequest(allowed []string, req *http.Request, base http.RoundTripper) error {
+	if allowed == nil {
+		return nil
+	}
+	if err := checkHost(allowed, req.URL.Host); err != nil {
+		return err
+	}
+	if proxy := proxyFor(req, base); proxy != nil {
+		return checkHost(allowed, proxy.Host)
+	}
+	return nil
+}
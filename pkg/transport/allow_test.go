@@ -0,0 +1,87 @@
+// Copyright (c) Kusari <https://www.kusari.dev/>
+// SPDX-License-Identifier: MIT
+
+package transport
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheckHost(t *testing.T) {
+	t.Cleanup(func() { _ = SetAllowedHosts(nil) })
+
+	assert.NoError(t, CheckHost("anything.example.com"), "no allowlist allows every host")
+	assert.False(t, Restricted())
+
+	require.NoError(t, SetAllowedHosts([]string{" API.us.kusari.cloud ", "kusari-uploads-*.s3.*.amazonaws.com", "127.0.0.1", "::1"}))
+	assert.True(t, Restricted())
+	for _, host := range []string{"api.us.kusari.cloud", "api.US.kusari.cloud:443", "kusari-uploads-prod.s3.us-east-1.amazonaws.com", "127.0.0.1:8080", "[::1]:8080"} {
+		assert.NoError(t, CheckHost(host), host)
+	}
+	for _, host := range []string{"console.us.kusari.cloud", "attacker.s3.us-east-1.amazonaws.com", "kusari.cloud.evil.com", "127.0.0.2"} {
+		var notAllowed *HostNotAllowedError
+		assert.ErrorAs(t, CheckHost(host), &notAllowed, host)
+	}
+
+	// Invalid patterns fail closed
+	err := SetAllowedHosts([]string{"[bad"})
+	assert.ErrorContains(t, err, "invalid allowed_hosts pattern(s) [bad")
+	assert.True(t, Restricted())
+	assert.Error(t, CheckHost("bad"))
+}
+
+func TestTransport_AllowedHosts(t *testing.T) {
+	t.Cleanup(func() { _ = SetAllowedHosts(nil) })
+	var sent []string
+	base := roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		sent = append(sent, req.URL.Host)
+		return &http.Response{StatusCode: http.StatusOK, Body: http.NoBody, Request: req}, nil
+	})
+	client := &http.Client{Transport: &Transport{Base: base}}
+
+	require.NoError(t, SetAllowedHosts([]string{"*.kusari.cloud"}))
+	resp, err := client.Get("https://api.us.kusari.cloud/scan")
+	require.NoError(t, err)
+	_ = resp.Body.Close()
+
+	_, err = client.Get("https://example.com/")
+	var notAllowed *HostNotAllowedError
+	require.ErrorAs(t, err, &notAllowed)
+	assert.Equal(t, "example.com", notAllowed.Host)
+	assert.ErrorContains(t, err, "refusing to contact example.com")
+	assert.Equal(t, []string{"api.us.kusari.cloud"}, sent, "refused requests are never sent")
+}
+
+func TestTransport_AllowedHostsRedirect(t *testing.T) {
+	t.Cleanup(func() { _ = SetAllowedHosts(nil) })
+	server := httptest.NewServer(http.RedirectHandler("http://elsewhere.example.com/", http.StatusFound))
+	defer server.Close()
+
+	require.NoError(t, SetAllowedHosts([]string{"127.0.0.1"}))
+	client := &http.Client{Transport: &Transport{Base: http.DefaultTransport}}
+	_, err := client.Get(server.URL)
+	var notAllowed *HostNotAllowedError
+	require.True(t, errors.As(err, &notAllowed), "got %v", err)
+	assert.Equal(t, "elsewhere.example.com", notAllowed.Host)
+}
+
+func TestTransport_AllowedHostsProxy(t *testing.T) {
+	t.Cleanup(func() { _ = SetAllowedHosts(nil) })
+	proxy, err := url.Parse("http://proxy.corp.example:3128")
+	require.NoError(t, err)
+	base := &http.Transport{Proxy: http.ProxyURL(proxy)}
+	client := &http.Client{Transport: &Transport{Base: base}}
+
+	require.NoError(t, SetAllowedHosts([]string{"api.us.kusari.cloud"}))
+	_, err = client.Get("https://api.us.kusari.cloud/")
+	var notAllowed *HostNotAllowedError
+	require.ErrorAs(t, err, &notAllowed)
+	assert.Equal(t, "proxy.corp.example", notAllowed.Host)
+}
@@ -0,0 +1,192 @@
+// Copyright (c) Kusari <https://www.kusari.dev/>
+// SPDX-License-Identifier: MIT
+
+package transport
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+	"unicode/utf8"
+
+	"github.com/kusaridev/kusari-cli/v2/pkg/output"
+)
+
+// maxCaptureBody is how much of each request and response body a capture
+// keeps.
+const maxCaptureBody = 16 << 10
+
+// sensitiveHeaders are never written to a capture, even redacted.
+var sensitiveHeaders = map[string]bool{
+	"Authorization":       true,
+	"Proxy-Authorization": true,
+	"Cookie":              true,
+	"Set-Cookie":          true,
+	"Private-Token":       true,
+	"Job-Token":           true,
+	"X-Api-Key":           true,
+}
+
+var (
+	captureDir string
+	captureSeq atomic.Int64
+)
+
+// SetCapture records every HTTP transaction of this invocation as a JSON
+// file in a new directory under dir, which is returned. Secrets are
+// redacted and bodies truncated, so a capture can be attached to a bug
+// report.
+func SetCapture(dir string) (string, error) {
+	name := time.Now().UTC().Format("20060102T150405Z") + "-" + requestID[:8]
+	path := filepath.Join(dir, name)
+	if err := os.MkdirAll(path, 0700); err != nil {
+		return "", fmt.Errorf("failed to create HTTP capture directory: %w", err)
+	}
+	mu.Lock()
+	captureDir = path
+	mu.Unlock()
+	return path, nil
+}
+
+// captureRecord is one captured transaction.
+type captureRecord struct {
+	Seq              int64             `json:"seq"`
+	RequestID        string            `json:"request_id"`
+	Method           string            `json:"method"`
+	URL              string            `json:"url"`
+	RequestHeaders   map[string]string `json:"request_headers"`
+	RequestBody      string            `json:"request_body,omitempty"`
+	RequestBodySize  int64             `json:"request_body_bytes"`
+	Status           int               `json:"status,omitempty"`
+	ResponseHeaders  map[string]string `json:"response_headers,omitempty"`
+	ResponseBody     string            `json:"response_body,omitempty"`
+	ResponseBodySize int64             `json:"response_body_bytes"`
+	Error            string            `json:"error,omitempty"`
+	StartedAt        time.Time         `json:"started_at"`
+	// DurationMS is the time until the response headers arrived.
+	DurationMS int64 `json:"duration_ms"`
+}
+
+// captureRoundTrip sends req with base, recording it in dir. The record is
+// written once the response body is closed, or right away on error.
+func captureRoundTrip(dir string, base http.RoundTripper, req *http.Request) (*http.Response, error) {
+	rec := &captureRecord{
+		Seq:            captureSeq.Add(1),
+		RequestID:      requestID,
+		Method:         req.Method,
+		URL:            output.Redact(req.URL.String()),
+		RequestHeaders: captureHeaders(req.Header),
+		StartedAt:      time.Now().UTC(),
+	}
+	var reqBody *capturingBody
+	if req.Body != nil && req.Body != http.NoBody {
+		reqBody = &capturingBody{rc: req.Body}
+		req.Body = reqBody
+	}
+
+	resp, err := base.RoundTrip(req)
+	rec.DurationMS = time.Since(rec.StartedAt).Milliseconds()
+	if reqBody != nil {
+		rec.RequestBody, rec.RequestBodySize = reqBody.text()
+	}
+	if err != nil {
+		rec.Error = output.Redact(err.Error())
+		writeCapture(dir, rec)
+		return nil, err
+	}
+
+	rec.Status = resp.StatusCode
+	rec.ResponseHeaders = captureHeaders(resp.Header)
+	respBody := &capturingBody{rc: resp.Body}
+	respBody.onClose = func() {
+		rec.ResponseBody, rec.ResponseBodySize = respBody.text()
+		writeCapture(dir, rec)
+	}
+	resp.Body = respBody
+	return resp, nil
+}
+
+func captureHeaders(h http.Header) map[string]string {
+	headers := make(map[string]string, len(h))
+	for k, v := range h {
+		value := strings.Join(v, ", ")
+		if sensitiveHeaders[http.CanonicalHeaderKey(k)] {
+			value = "REDACTED"
+		}
+		headers[k] = output.Redact(value)
+	}
+	return headers
+}
+
+// writeCapture writes rec to dir. A capture is best effort: failures are
+// only logged.
+func writeCapture(dir string, rec *captureRecord) {
+	b, err := json.MarshalIndent(rec, "", "  ")
+	if err != nil {
+		output.Debug("failed to marshal HTTP capture", "error", err)
+		return
+	}
+	name := fmt.Sprintf("%04d-%s.json", rec.Seq, rec.Method)
+	if err := os.WriteFile(filepath.Join(dir, name), append(b, '\n'), 0600); err != nil {
+		output.Debug("failed to write HTTP capture", "error", err)
+	}
+}
+
+// capturingBody keeps the first maxCaptureBody bytes read through it. The
+// transport may read a request body from another goroutine, hence mu.
+type capturingBody struct {
+	rc      io.ReadCloser
+	onClose func()
+
+	mu    sync.Mutex
+	buf   bytes.Buffer
+	total int64
+	once  sync.Once
+}
+
+func (b *capturingBody) Read(p []byte) (int, error) {
+	n, err := b.rc.Read(p)
+	b.mu.Lock()
+	b.total += int64(n)
+	if room := maxCaptureBody - b.buf.Len(); room > 0 {
+		b.buf.Write(p[:min(n, room)])
+	}
+	b.mu.Unlock()
+	return n, err
+}
+
+func (b *capturingBody) Close() error {
+	err := b.rc.Close()
+	if b.onClose != nil {
+		b.once.Do(b.onClose)
+	}
+	return err
+}
+
+// text returns the captured body, redacted, and the size read in total.
+func (b *capturingBody) text() (string, int64) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	data := b.buf.Bytes()
+	if !utf8.Valid(data) {
+		// A truncated body may end in a partial rune.
+		trimmed := bytes.ToValidUTF8(data, nil)
+		if len(data)-len(trimmed) > utf8.UTFMax || int64(len(data)) == b.total {
+			return fmt.Sprintf("[%d bytes of binary data]", b.total), b.total
+		}
+		data = trimmed
+	}
+	s := output.Redact(string(data))
+	if b.total > int64(len(data)) {
+		s += fmt.Sprintf("\n[truncated, %d bytes in total]", b.total)
+	}
+	return s, b.total
+}
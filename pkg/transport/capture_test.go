@@ -0,0 +1,90 @@
+// Copyright (c) Kusari <https://www.kusari.dev/>
+// SPDX-License-Identifier: MIT
+
+package transport
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCapture(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.Copy(io.Discard, r.Body)
+		w.Header().Set("Set-Cookie", "session=secret")
+		w.WriteHeader(http.StatusTeapot)
+		_, _ = w.Write([]byte(`{"access_token":"abc","detail":"` + strings.Repeat("x", maxCaptureBody) + `"}`))
+	}))
+	defer server.Close()
+
+	dir, err := SetCapture(t.TempDir())
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		mu.Lock()
+		captureDir = ""
+		mu.Unlock()
+	})
+
+	client := &http.Client{Transport: &Transport{Base: &http.Transport{}}}
+	req, err := http.NewRequest(http.MethodPost, server.URL+"/upload?X-Amz-Signature=deadbeef", strings.NewReader(`{"password":"hunter2"}`))
+	require.NoError(t, err)
+	req.Header.Set("Authorization", "Bearer tok")
+	resp, err := client.Do(req)
+	require.NoError(t, err)
+	_, _ = io.ReadAll(resp.Body)
+	require.NoError(t, resp.Body.Close())
+
+	files, err := filepath.Glob(filepath.Join(dir, "*-POST.json"))
+	require.NoError(t, err)
+	require.Len(t, files, 1)
+	data, err := os.ReadFile(files[0])
+	require.NoError(t, err)
+	var rec captureRecord
+	require.NoError(t, json.Unmarshal(data, &rec))
+
+	assert.Equal(t, RequestID(), rec.RequestID)
+	assert.Equal(t, server.URL+"/upload?X-Amz-Signature=REDACTED", rec.URL)
+	assert.Equal(t, "REDACTED", rec.RequestHeaders["Authorization"])
+	assert.Equal(t, `{"password":"REDACTED"}`, rec.RequestBody)
+	assert.EqualValues(t, 22, rec.RequestBodySize)
+	assert.Equal(t, http.StatusTeapot, rec.Status)
+	assert.Equal(t, "REDACTED", rec.ResponseHeaders["Set-Cookie"])
+	assert.True(t, strings.HasPrefix(rec.ResponseBody, `{"access_token":"REDACTED","detail":"xxx`))
+	assert.Contains(t, rec.ResponseBody, "[truncated,")
+	assert.Greater(t, rec.ResponseBodySize, int64(maxCaptureBody))
+	assert.NotContains(t, string(data), "tok\"")
+	assert.NotContains(t, string(data), "hunter2")
+}
+
+func TestCapture_Error(t *testing.T) {
+	dir, err := SetCapture(t.TempDir())
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		mu.Lock()
+		captureDir = ""
+		mu.Unlock()
+	})
+
+	client := &http.Client{Transport: &Transport{Base: &http.Transport{}}}
+	_, err = client.Get("http://127.0.0.1:1/unreachable")
+	require.Error(t, err)
+
+	files, err := filepath.Glob(filepath.Join(dir, "*-GET.json"))
+	require.NoError(t, err)
+	require.Len(t, files, 1)
+	data, err := os.ReadFile(files[0])
+	require.NoError(t, err)
+	var rec captureRecord
+	require.NoError(t, json.Unmarshal(data, &rec))
+	assert.NotEmpty(t, rec.Error)
+	assert.Zero(t, rec.Status)
+}
@@ -0,0 +1,225 @@
+// Copyright (c) Kusari <https://www.kusari.dev/>
+// SPDX-License-Identifier: MIT
+
+package transport
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// lookupTimeout bounds the DNS lookup made to diagnose a failed request.
+const lookupTimeout = 2 * time.Second
+
+var caBundle string
+
+// ConnectError is a request that never got a response because the host
+// didn't resolve, couldn't be connected to or failed the TLS handshake.
+// Besides the cause, it reports what the CLI saw of the connection and
+// the likely fix, rather than only the net/http error.
+type ConnectError struct {
+	// Problem says what failed, e.g. "cannot resolve demo.api.us.kusari.cloud".
+	Problem string
+	// Hint is the likely fix.
+	Hint string
+	// Addrs are the addresses the host resolves to, when it does.
+	Addrs []string
+	// Proxy is the proxy the request went through, if any.
+	Proxy string
+	// ServerName is the TLS server name (SNI) of a failed handshake.
+	ServerName string
+	Err        error
+}
+
+func (e *ConnectError) Error() string {
+	var details []string
+	if len(e.Addrs) > 0 {
+		details = append(details, "resolves to "+strings.Join(e.Addrs, ", "))
+	}
+	if e.Proxy != "" {
+		details = append(details, "proxy "+e.Proxy)
+	} else {
+		details = append(details, "no proxy")
+	}
+	if e.ServerName != "" {
+		details = append(details, "TLS server name "+e.ServerName)
+	}
+	details = append(details, "error: "+e.Err.Error())
+	return fmt.Sprintf("%s: %s (%s)", e.Problem, e.Hint, strings.Join(details, "; "))
+}
+
+func (e *ConnectError) Unwrap() error { return e.Err }
+
+// SetCABundle trusts the PEM certificates in path, on top of the system's,
+// for every request sent with http.DefaultTransport, e.g. for a
+// TLS-inspecting proxy's CA. Call it after Install.
+func SetCABundle(path string) error {
+	pem, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read CA bundle: %w", err)
+	}
+	pool, err := x509.SystemCertPool()
+	if err != nil {
+		pool = x509.NewCertPool()
+	}
+	if !pool.AppendCertsFromPEM(pem) {
+		return fmt.Errorf("no PEM certificates found in CA bundle %s", path)
+	}
+
+	t, ok := http.DefaultTransport.(*Transport)
+	if !ok {
+		return errors.New("the HTTP transport is not installed")
+	}
+	base, ok := t.Base.(*http.Transport)
+	if !ok {
+		return errors.New("the HTTP transport does not support a CA bundle")
+	}
+	base = base.Clone()
+	if base.TLSClientConfig == nil {
+		base.TLSClientConfig = &tls.Config{}
+	}
+	base.TLSClientConfig.RootCAs = pool
+	t.Base = base
+
+	mu.Lock()
+	caBundle = path
+	mu.Unlock()
+	return nil
+}
+
+// diagnose returns err as a ConnectError when it is a DNS, connection or
+// TLS failure sending req through base, and unchanged otherwise.
+func diagnose(req *http.Request, base http.RoundTripper, err error) error {
+	// A request its caller gave up on failed for that reason.
+	if req.Context().Err() != nil {
+		return err
+	}
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	if t, ok := base.(*Transport); ok {
+		base = t.Base
+	}
+	host := req.URL.Hostname()
+	mu.RLock()
+	bundle := caBundle
+	mu.RUnlock()
+
+	ce := &ConnectError{Err: err}
+	var (
+		dnsErr       *net.DNSError
+		authorityErr x509.UnknownAuthorityError
+		hostnameErr  x509.HostnameError
+		invalidErr   x509.CertificateInvalidError
+		recordErr    tls.RecordHeaderError
+		opErr        *net.OpError
+	)
+	switch {
+	case errors.As(err, &dnsErr):
+		if dnsErr.IsNotFound {
+			ce.Problem = "cannot resolve " + dnsErr.Name
+			ce.Hint = "check the host name (a wrong tenant name or region gives one that doesn't exist, e.g. https://<tenant>.api.us.kusari.cloud), or connect to the VPN if it only resolves there"
+		} else {
+			ce.Problem = "DNS lookup of " + dnsErr.Name + " failed"
+			ce.Hint = "check the network connection and DNS server, or connect to the VPN if the network requires it"
+		}
+	case errors.As(err, &authorityErr):
+		ce.Problem = "the certificate of " + host + " is not signed by a trusted authority"
+		if authorityErr.Cert != nil {
+			ce.Problem += " (issued by " + issuer(authorityErr.Cert) + ")"
+		}
+		if bundle != "" {
+			ce.Hint = "a TLS-inspecting proxy or private CA is likely in the way, and the CA bundle " + bundle + " doesn't include its certificate"
+		} else {
+			ce.Hint = "a TLS-inspecting proxy or private CA is likely in the way; pass its CA certificate with --ca-bundle (or KUSARI_CA_BUNDLE)"
+		}
+		ce.ServerName = serverName(req, base)
+	case errors.As(err, &hostnameErr):
+		ce.Problem = "the certificate presented for " + host + " is not valid for it"
+		if hostnameErr.Certificate != nil {
+			ce.Problem += " (issued by " + issuer(hostnameErr.Certificate) + ")"
+		}
+		ce.Hint = "check the host name, and that no proxy or captive portal intercepts the connection; --ca-bundle doesn't help"
+		ce.ServerName = serverName(req, base)
+	case errors.As(err, &invalidErr):
+		if invalidErr.Reason == x509.Expired {
+			ce.Problem = "the certificate of " + host + " has expired or is not yet valid"
+			ce.Hint = "check the system clock"
+		} else {
+			ce.Problem = "the certificate of " + host + " is invalid"
+			ce.Hint = "check that no proxy intercepts the connection with a certificate of its own"
+		}
+		ce.ServerName = serverName(req, base)
+	case errors.As(err, &recordErr):
+		ce.Problem = host + " did not answer with TLS"
+		ce.Hint = "check the URL's scheme and port, and the proxy settings (HTTPS_PROXY, NO_PROXY)"
+		ce.ServerName = serverName(req, base)
+	case errors.As(err, &opErr) && opErr.Op == "proxyconnect":
+		ce.Problem = "cannot connect to the proxy"
+		ce.Hint = "check HTTPS_PROXY and HTTP_PROXY, or set NO_PROXY for the host if it should be reached directly"
+	case errors.As(err, &opErr) && opErr.Op == "dial":
+		switch {
+		case errors.Is(err, syscall.ECONNREFUSED):
+			ce.Problem = "connection to " + req.URL.Host + " refused"
+			ce.Hint = "check the URL's host and port; nothing is listening there"
+		case errors.Is(err, syscall.ENETUNREACH), errors.Is(err, syscall.EHOSTUNREACH):
+			ce.Problem = req.URL.Host + " is unreachable"
+			ce.Hint = "check the network connection, or connect to the VPN if the host is only reachable there"
+		case opErr.Timeout():
+			ce.Problem = "connecting to " + req.URL.Host + " timed out"
+			ce.Hint = "a firewall may be dropping the connection; connect to the VPN, or set HTTPS_PROXY if the network requires a proxy"
+		default:
+			return err
+		}
+	default:
+		return err
+	}
+
+	if proxy := proxyFor(req, base); proxy != nil {
+		ce.Proxy = proxy.Redacted()
+	}
+	if dnsErr == nil && net.ParseIP(host) == nil {
+		ctx, cancel := context.WithTimeout(context.Background(), lookupTimeout)
+		ce.Addrs, _ = net.DefaultResolver.LookupHost(ctx, host)
+		cancel()
+	}
+	return ce
+}
+
+// proxyFor returns the proxy base sends req through, or nil.
+func proxyFor(req *http.Request, base http.RoundTripper) *url.URL {
+	t, ok := base.(*http.Transport)
+	if !ok || t.Proxy == nil {
+		return nil
+	}
+	proxy, err := t.Proxy(req)
+	if err != nil {
+		return nil
+	}
+	return proxy
+}
+
+// serverName returns the TLS server name base sends for req.
+func serverName(req *http.Request, base http.RoundTripper) string {
+	if t, ok := base.(*http.Transport); ok && t.TLSClientConfig != nil && t.TLSClientConfig.ServerName != "" {
+		return t.TLSClientConfig.ServerName
+	}
+	return req.URL.Hostname()
+}
+
+func issuer(cert *x509.Certificate) string {
+	if cert.Issuer.CommonName != "" {
+		return cert.Issuer.CommonName
+	}
+	return cert.Issuer.String()
+}
@@ -0,0 +1,106 @@
+// Copyright (c) Kusari <https://www.kusari.dev/>
+// SPDX-License-Identifier: MIT
+
+package transport
+
+import (
+	"encoding/pem"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDiagnose(t *testing.T) {
+	plain := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer plain.Close()
+	tlsServer := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer tlsServer.Close()
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	closed := l.Addr().String()
+	require.NoError(t, l.Close())
+
+	trusting := tlsServer.Client().Transport.(*http.Transport).Clone()
+	proxied := &http.Transport{Proxy: http.ProxyURL(&url.URL{Scheme: "http", User: url.UserPassword("u", "secret"), Host: closed})}
+
+	tests := []struct {
+		name    string
+		base    *http.Transport
+		url     string
+		problem string
+		hint    string
+		sni     string
+	}{
+		{"not resolving", &http.Transport{}, "https://kusari-test.invalid/", "kusari-test.invalid", "", ""},
+		{"refused", &http.Transport{}, "http://" + closed + "/", "connection to " + closed + " refused", "nothing is listening", ""},
+		{"unknown authority", &http.Transport{}, tlsServer.URL, "not signed by a trusted authority", "--ca-bundle", "127.0.0.1"},
+		{"wrong host", trusting, strings.Replace(tlsServer.URL, "127.0.0.1", "localhost", 1), "is not valid for it", "--ca-bundle doesn't help", "localhost"},
+		{"not TLS", &http.Transport{}, strings.Replace(plain.URL, "http:", "https:", 1), "127.0.0.1 did not answer with TLS", "scheme", "127.0.0.1"},
+		{"proxy down", proxied, plain.URL, "cannot connect to the proxy", "HTTPS_PROXY", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := &http.Client{Transport: &Transport{Base: tt.base}}
+			_, err := client.Get(tt.url)
+			var ce *ConnectError
+			require.ErrorAs(t, err, &ce)
+			assert.Contains(t, ce.Problem, tt.problem)
+			assert.Contains(t, ce.Hint, tt.hint)
+			assert.Equal(t, tt.sni, ce.ServerName)
+			assert.NotEmpty(t, ce.Err)
+			assert.NotContains(t, err.Error(), "secret")
+		})
+	}
+
+	t.Run("proxy reported", func(t *testing.T) {
+		client := &http.Client{Transport: &Transport{Base: proxied}}
+		_, err := client.Get(plain.URL)
+		assert.ErrorContains(t, err, "proxy http://u:xxxxx@"+closed)
+	})
+
+	t.Run("other errors unchanged", func(t *testing.T) {
+		client := &http.Client{Transport: &Transport{Base: &http.Transport{}}}
+		_, err := client.Get("unsupported://host/")
+		require.Error(t, err)
+		var ce *ConnectError
+		assert.NotErrorAs(t, err, &ce)
+	})
+}
+
+func TestSetCABundle(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+	original := http.DefaultTransport
+	t.Cleanup(func() {
+		http.DefaultTransport = original
+		mu.Lock()
+		caBundle = ""
+		mu.Unlock()
+	})
+	http.DefaultTransport = &Transport{Base: original.(*http.Transport).Clone()}
+
+	dir := t.TempDir()
+	empty := filepath.Join(dir, "empty.pem")
+	require.NoError(t, os.WriteFile(empty, []byte("not a certificate"), 0600))
+	assert.ErrorContains(t, SetCABundle(empty), "no PEM certificates found")
+
+	_, err := http.Get(server.URL)
+	var ce *ConnectError
+	require.ErrorAs(t, err, &ce)
+	assert.Contains(t, ce.Hint, "--ca-bundle")
+
+	bundle := filepath.Join(dir, "ca.pem")
+	require.NoError(t, os.WriteFile(bundle, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw}), 0600))
+	require.NoError(t, SetCABundle(bundle))
+	resp, err := http.Get(server.URL)
+	require.NoError(t, err)
+	require.NoError(t, resp.Body.Close())
+}
@@ -0,0 +1,265 @@
+// Copyright (c) Kusari <https://www.kusari.dev/>
+// SPDX-License-Identifier: MIT
+
+package transport
+
+import (
+	"bytes"
+	"cmp"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"unicode/utf8"
+
+	"github.com/kusaridev/kusari-cli/v2/pkg/output"
+)
+
+// fixtureGlob matches the fixture files of a --record directory.
+const fixtureGlob = "[0-9][0-9][0-9][0-9]-*.json"
+
+var (
+	recordDir string
+	recordSeq atomic.Int64
+	replay    *replayer
+)
+
+// fixture is one transaction recorded with --record, for --replay to
+// answer the same request with. Request bodies are not kept; secrets in
+// the URL, headers and response body are redacted.
+type fixture struct {
+	Seq             int64             `json:"seq"`
+	Method          string            `json:"method"`
+	URL             string            `json:"url"`
+	Status          int               `json:"status,omitempty"`
+	ResponseHeaders map[string]string `json:"response_headers,omitempty"`
+	ResponseBody    string            `json:"response_body,omitempty"`
+	// ResponseBodyBase64 holds a response body that is not text.
+	ResponseBodyBase64 string `json:"response_body_base64,omitempty"`
+	Error              string `json:"error,omitempty"`
+}
+
+// SetRecord records every HTTP transaction of this invocation in dir as a
+// fixture --replay can answer requests from, replacing the fixtures
+// already there.
+func SetRecord(dir string) error {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return fmt.Errorf("failed to create record directory: %w", err)
+	}
+	old, err := filepath.Glob(filepath.Join(dir, fixtureGlob))
+	if err != nil {
+		return fmt.Errorf("failed to list old fixtures: %w", err)
+	}
+	for _, path := range old {
+		if err := os.Remove(path); err != nil {
+			return fmt.Errorf("failed to remove old fixture: %w", err)
+		}
+	}
+	mu.Lock()
+	recordDir = dir
+	mu.Unlock()
+	return nil
+}
+
+// SetReplay answers every HTTP request of this invocation from the
+// fixtures recorded in dir instead of sending it. A request no fixture
+// matches fails, and so does every request when the fixtures can't be
+// read, so a replay never reaches the network.
+func SetReplay(dir string) error {
+	r, err := loadReplay(dir)
+	mu.Lock()
+	replay = r
+	mu.Unlock()
+	return err
+}
+
+// recordRoundTrip sends req with base, recording the transaction in dir.
+// The response body is read in full to be recorded, then handed back.
+func recordRoundTrip(dir string, base http.RoundTripper, req *http.Request) (*http.Response, error) {
+	f := &fixture{
+		Seq:    recordSeq.Add(1),
+		Method: req.Method,
+		URL:    output.Redact(req.URL.String()),
+	}
+	resp, err := base.RoundTrip(req)
+	if err != nil {
+		f.Error = output.Redact(err.Error())
+		writeFixture(dir, f)
+		return nil, err
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	_ = resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	f.Status = resp.StatusCode
+	f.ResponseHeaders = captureHeaders(resp.Header)
+	if utf8.Valid(body) {
+		f.ResponseBody = output.Redact(string(body))
+	} else {
+		f.ResponseBodyBase64 = base64.StdEncoding.EncodeToString(body)
+	}
+	writeFixture(dir, f)
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+	return resp, nil
+}
+
+// recorder is a RoundTripper recording the transactions of base in dir.
+type recorder struct {
+	dir  string
+	base http.RoundTripper
+}
+
+func (r recorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	return recordRoundTrip(r.dir, r.base, req)
+}
+
+// writeFixture writes f to dir. Recording is best effort: failures are
+// only logged.
+func writeFixture(dir string, f *fixture) {
+	b, err := json.MarshalIndent(f, "", "  ")
+	if err != nil {
+		output.Debug("failed to marshal fixture", "error", err)
+		return
+	}
+	name := fmt.Sprintf("%04d-%s.json", f.Seq, f.Method)
+	if err := os.WriteFile(filepath.Join(dir, name), append(b, '\n'), 0600); err != nil {
+		output.Debug("failed to write fixture", "error", err)
+	}
+}
+
+// replayer answers requests from recorded fixtures.
+type replayer struct {
+	dir string
+	err error
+
+	mu       sync.Mutex
+	fixtures []*fixture
+	used     []bool
+}
+
+func loadReplay(dir string) (*replayer, error) {
+	fixtures, err := readFixtures(dir)
+	if err != nil {
+		err = fmt.Errorf("failed to load replay fixtures: %w", err)
+		return &replayer{dir: dir, err: err}, err
+	}
+	return &replayer{dir: dir, fixtures: fixtures, used: make([]bool, len(fixtures))}, nil
+}
+
+// readFixtures reads the fixtures of dir, in the order they were recorded.
+func readFixtures(dir string) ([]*fixture, error) {
+	paths, err := filepath.Glob(filepath.Join(dir, fixtureGlob))
+	if err != nil {
+		return nil, err
+	}
+	if len(paths) == 0 {
+		return nil, fmt.Errorf("no fixtures found in %s (record them with --record %s)", dir, dir)
+	}
+	var fixtures []*fixture
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read fixture: %w", err)
+		}
+		f := &fixture{}
+		if err := json.Unmarshal(data, f); err != nil {
+			return nil, fmt.Errorf("failed to parse fixture %s: %w", path, err)
+		}
+		fixtures = append(fixtures, f)
+	}
+	slices.SortFunc(fixtures, func(a, b *fixture) int { return cmp.Compare(a.Seq, b.Seq) })
+	return fixtures, nil
+}
+
+// RoundTrip implements http.RoundTripper.
+func (r *replayer) RoundTrip(req *http.Request) (*http.Response, error) {
+	// RoundTrippers must close the request body.
+	if req.Body != nil {
+		_, _ = io.Copy(io.Discard, req.Body)
+		_ = req.Body.Close()
+	}
+	if r.err != nil {
+		return nil, r.err
+	}
+
+	u := output.Redact(req.URL.String())
+	f := r.match(req.Method, u)
+	if f == nil {
+		return nil, fmt.Errorf("no fixture in %s answers %s %s (record it again with --record)", r.dir, req.Method, u)
+	}
+	if f.Error != "" {
+		return nil, errors.New(f.Error)
+	}
+
+	body := []byte(f.ResponseBody)
+	if f.ResponseBodyBase64 != "" {
+		var err error
+		if body, err = base64.StdEncoding.DecodeString(f.ResponseBodyBase64); err != nil {
+			return nil, fmt.Errorf("invalid fixture %04d-%s.json: %w", f.Seq, f.Method, err)
+		}
+	}
+	header := http.Header{}
+	for k, v := range f.ResponseHeaders {
+		header.Set(k, v)
+	}
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", f.Status, http.StatusText(f.Status)),
+		StatusCode:    f.Status,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          io.NopCloser(bytes.NewReader(body)),
+		ContentLength: int64(len(body)),
+		Request:       req,
+	}, nil
+}
+
+// match returns the fixture answering method and u: the first unused one
+// recorded for the same URL, else for the same URL but its query (which
+// may hold a timestamp), else the last one for the URL again, as a poll
+// repeated more often than when recording.
+func (r *replayer) match(method, u string) *fixture {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	exact := func(f *fixture) bool { return f.Method == method && f.URL == u }
+	path := func(f *fixture) bool { return f.Method == method && withoutQuery(f.URL) == withoutQuery(u) }
+	for _, same := range []func(*fixture) bool{exact, path} {
+		for i, f := range r.fixtures {
+			if !r.used[i] && same(f) {
+				r.used[i] = true
+				return f
+			}
+		}
+	}
+	for _, same := range []func(*fixture) bool{exact, path} {
+		for i := len(r.fixtures) - 1; i >= 0; i-- {
+			if same(r.fixtures[i]) {
+				return r.fixtures[i]
+			}
+		}
+	}
+	return nil
+}
+
+func withoutQuery(u string) string {
+	parsed, err := url.Parse(u)
+	if err != nil {
+		before, _, _ := strings.Cut(u, "?")
+		return before
+	}
+	parsed.RawQuery = ""
+	return parsed.String()
+}
@@ -0,0 +1,116 @@
+// Copyright (c) Kusari <https://www.kusari.dev/>
+// SPDX-License-Identifier: MIT
+
+package transport
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRecordReplay(t *testing.T) {
+	polls := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.Copy(io.Discard, r.Body)
+		switch r.URL.Path {
+		case "/status":
+			polls++
+			if polls == 1 {
+				_, _ = w.Write([]byte(`{"status":"processing"}`))
+				return
+			}
+			_, _ = w.Write([]byte(`{"status":"success","token":"eyJhbGciOiJIUzI1NiJ9.eyJzdWIiOiJ4In0.c2ln"}`))
+		case "/upload":
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	t.Cleanup(func() {
+		mu.Lock()
+		recordDir, replay = "", nil
+		mu.Unlock()
+	})
+	client := &http.Client{Transport: &Transport{Base: &http.Transport{}}}
+	get := func(path string) (int, string, error) {
+		req, err := http.NewRequest(http.MethodGet, server.URL+path, nil)
+		require.NoError(t, err)
+		req.Header.Set("Authorization", "Bearer tok")
+		resp, err := client.Do(req)
+		if err != nil {
+			return 0, "", err
+		}
+		defer func() { _ = resp.Body.Close() }()
+		body, err := io.ReadAll(resp.Body)
+		require.NoError(t, err)
+		return resp.StatusCode, string(body), nil
+	}
+	put := func(path string) (int, error) {
+		req, err := http.NewRequest(http.MethodPut, server.URL+path, strings.NewReader("bundle"))
+		require.NoError(t, err)
+		resp, err := client.Do(req)
+		if err != nil {
+			return 0, err
+		}
+		return resp.StatusCode, resp.Body.Close()
+	}
+
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "0009-GET.json"), []byte("stale"), 0600))
+	require.NoError(t, SetRecord(dir))
+	_, body, err := get("/status?since=1")
+	require.NoError(t, err)
+	assert.Equal(t, `{"status":"processing"}`, body)
+	_, _, err = get("/status?since=1")
+	require.NoError(t, err)
+	status, err := put("/upload?X-Amz-Signature=deadbeef")
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusNoContent, status)
+	server.Close()
+
+	files, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	require.NoError(t, err)
+	assert.Len(t, files, 3, "the stale fixture is replaced")
+	data, err := os.ReadFile(filepath.Join(dir, "0002-GET.json"))
+	require.NoError(t, err)
+	assert.NotContains(t, string(data), "eyJhbGciOiJIUzI1NiJ9")
+	data, err = os.ReadFile(filepath.Join(dir, "0003-PUT.json"))
+	require.NoError(t, err)
+	assert.NotContains(t, string(data), "deadbeef")
+
+	// The server is gone: everything is answered from the fixtures.
+	mu.Lock()
+	recordDir = ""
+	mu.Unlock()
+	require.NoError(t, SetReplay(dir))
+	_, body, err = get("/status?since=1")
+	require.NoError(t, err)
+	assert.Equal(t, `{"status":"processing"}`, body)
+	// A timestamp in the query that changed since recording still matches.
+	_, body, err = get("/status?since=2")
+	require.NoError(t, err)
+	assert.Contains(t, body, `"status":"success"`)
+	// Polling more often than when recording repeats the last answer.
+	_, body, err = get("/status?since=1")
+	require.NoError(t, err)
+	assert.Contains(t, body, `"status":"success"`)
+	// The live signature is redacted just like the recorded one.
+	status, err = put("/upload?X-Amz-Signature=cafef00d")
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusNoContent, status)
+
+	_, _, err = get("/other")
+	assert.ErrorContains(t, err, "no fixture in "+dir+" answers GET "+server.URL+"/other")
+
+	assert.ErrorContains(t, SetReplay(t.TempDir()), "no fixtures found")
+	_, _, err = get("/status")
+	assert.ErrorContains(t, err, "failed to load replay fixtures", "a broken replay never reaches the network")
+}
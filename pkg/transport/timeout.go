@@ -0,0 +1,116 @@
+// Copyright (c) Kusari <https://www.kusari.dev/>
+// SPDX-License-Identifier: MIT
+
+package transport
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+)
+
+var (
+	requestTimeout time.Duration
+	deadline       time.Time
+	deadlineErr    *DeadlineError
+)
+
+// DeadlineError reports that the --deadline of the invocation passed.
+type DeadlineError struct {
+	Deadline time.Duration
+}
+
+func (e *DeadlineError) Error() string {
+	return fmt.Sprintf("--deadline of %s exceeded", e.Deadline)
+}
+
+// Unwrap lets errors.Is(err, context.DeadlineExceeded) match.
+func (e *DeadlineError) Unwrap() error { return context.DeadlineExceeded }
+
+// IsDeadline reports whether err is, or wraps, the DeadlineError of a
+// passed --deadline, as opposed to the timeout of a single request.
+func IsDeadline(err error) bool {
+	var d *DeadlineError
+	return errors.As(err, &d)
+}
+
+// SetTimeout sets the timeout of every HTTP request, overriding the one
+// each client picks for itself. Zero keeps the clients' own timeouts.
+func SetTimeout(d time.Duration) {
+	mu.Lock()
+	requestTimeout = d
+	mu.Unlock()
+}
+
+// Timeout returns the timeout set with SetTimeout, or def, the client's
+// own timeout, when none is set. HTTP clients are created with it.
+func Timeout(def time.Duration) time.Duration {
+	mu.RLock()
+	defer mu.RUnlock()
+	if requestTimeout > 0 {
+		return requestTimeout
+	}
+	return def
+}
+
+// SetDeadline makes every HTTP request sent more than d from now fail with
+// a DeadlineError, and cuts short those still in flight then, so the whole
+// invocation is bounded whatever it retries or polls. The returned error
+// is the one requests fail with. Zero removes the deadline.
+func SetDeadline(d time.Duration) *DeadlineError {
+	mu.Lock()
+	defer mu.Unlock()
+	if d <= 0 {
+		deadline, deadlineErr = time.Time{}, nil
+		return nil
+	}
+	deadline, deadlineErr = time.Now().Add(d), &DeadlineError{Deadline: d}
+	return deadlineErr
+}
+
+// withDeadline sends req with next, bounded by the deadline when one is
+// set.
+func withDeadline(until time.Time, err *DeadlineError, next http.RoundTripper, req *http.Request) (*http.Response, error) {
+	if until.IsZero() {
+		return next.RoundTrip(req)
+	}
+	if !time.Now().Before(until) {
+		closeBody(req)
+		return nil, err
+	}
+
+	ctx, cancel := context.WithDeadline(req.Context(), until)
+	resp, rtErr := next.RoundTrip(req.WithContext(ctx))
+	if rtErr != nil {
+		expired := errors.Is(ctx.Err(), context.DeadlineExceeded) && req.Context().Err() == nil
+		cancel()
+		if expired {
+			return nil, err
+		}
+		return nil, rtErr
+	}
+	// The context must outlive the response body, which is read after.
+	resp.Body = &cancelingBody{ReadCloser: resp.Body, cancel: cancel}
+	return resp, nil
+}
+
+func closeBody(req *http.Request) {
+	if req.Body != nil {
+		_ = req.Body.Close()
+	}
+}
+
+// cancelingBody cancels the request's context once its body is closed.
+type cancelingBody struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (b *cancelingBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.cancel()
+	return err
+}
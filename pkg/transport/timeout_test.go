@@ -0,0 +1,73 @@
+// Copyright (c) Kusari <https://www.kusari.dev/>
+// SPDX-License-Identifier: MIT
+
+package transport
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTimeout(t *testing.T) {
+	t.Cleanup(func() { SetTimeout(0) })
+	assert.Equal(t, 10*time.Second, Timeout(10*time.Second))
+	SetTimeout(2 * time.Minute)
+	assert.Equal(t, 2*time.Minute, Timeout(10*time.Second))
+	assert.Equal(t, 2*time.Minute, Timeout(0))
+}
+
+func TestDeadline(t *testing.T) {
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/slow" {
+			select {
+			case <-release:
+			case <-r.Context().Done():
+			}
+		}
+		_, _ = w.Write([]byte("ok"))
+	}))
+	defer server.Close()
+	defer close(release)
+	t.Cleanup(func() { SetDeadline(0) })
+	client := &http.Client{Transport: &Transport{Base: &http.Transport{}}}
+
+	expired := SetDeadline(time.Hour)
+	resp, err := client.Get(server.URL + "/fast")
+	require.NoError(t, err)
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	require.NoError(t, resp.Body.Close())
+	assert.Equal(t, "ok", string(body), "the body outlives the request")
+	assert.Equal(t, "--deadline of 1h0m0s exceeded", expired.Error())
+
+	// A request canceled by its caller is not a deadline.
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, server.URL+"/fast", nil)
+	require.NoError(t, err)
+	_, err = client.Do(req)
+	require.Error(t, err)
+	assert.False(t, IsDeadline(err))
+
+	// In flight when the deadline passes.
+	SetDeadline(50 * time.Millisecond)
+	_, err = client.Get(server.URL + "/slow")
+	require.Error(t, err)
+	assert.True(t, IsDeadline(err))
+	assert.True(t, errors.Is(err, context.DeadlineExceeded))
+
+	// Sent after it passed.
+	start := time.Now()
+	_, err = client.Get(server.URL + "/fast")
+	assert.True(t, IsDeadline(err))
+	assert.Less(t, time.Since(start), time.Second)
+}
@@ -0,0 +1,134 @@
+// Copyright (c) Kusari <https://www.kusari.dev/>
+// SPDX-License-Identifier: MIT
+
+// Package transport identifies the CLI on every outgoing HTTP request: it
+// sets a User-Agent naming the CLI version and platform, and an
+// X-Request-ID shared by all requests of one invocation, so a failure a
+// user reports can be found in the platform's logs. With --debug-http, it
+// also records every transaction for the user to attach to a report, and
+// with --record and --replay it records transactions as fixtures and
+// answers requests from them, for deterministic pipeline tests. With
+// allowed_hosts, it refuses requests to any other host. Requests
+// that fail to connect are diagnosed with what was resolved, the proxy in
+// effect and a likely fix.
+package transport
+
+import (
+	"net/http"
+	"runtime"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"github.com/google/uuid"
+)
+
+// RequestIDHeader carries the invocation's request ID.
+const RequestIDHeader = "X-Request-ID"
+
+var (
+	mu        sync.RWMutex
+	userAgent = UserAgent("dev", "")
+
+	requestID = uuid.NewString()
+	sent      atomic.Bool
+)
+
+// UserAgent returns the User-Agent for the given CLI version,
+// "kusari-cli/<version> <os>/<arch>", followed by extra when not empty.
+func UserAgent(version, extra string) string {
+	ua := "kusari-cli/" + strings.TrimPrefix(version, "v") + " " + runtime.GOOS + "/" + runtime.GOARCH
+	if extra = strings.TrimSpace(extra); extra != "" {
+		ua += " " + extra
+	}
+	return ua
+}
+
+// Install sets the User-Agent for the given version and extra product
+// tokens, and wraps http.DefaultTransport so every client that doesn't set
+// its own transport sends it. Calling it again only updates the User-Agent.
+func Install(version, extra string) {
+	mu.Lock()
+	userAgent = UserAgent(version, extra)
+	mu.Unlock()
+	if _, ok := http.DefaultTransport.(*Transport); !ok {
+		http.DefaultTransport = &Transport{Base: http.DefaultTransport}
+	}
+}
+
+// RequestID returns the request ID sent with every request.
+func RequestID() string {
+	return requestID
+}
+
+// Sent reports whether any request has been sent with the request ID, that
+// is whether it is worth quoting in an error.
+func Sent() bool {
+	return sent.Load()
+}
+
+// Transport adds the User-Agent and X-Request-ID headers to requests
+// before passing them to Base, recording them when a capture or a record
+// directory is set. With a replay set, Base is not used: requests are
+// answered from the replayed fixtures. Requests are bounded by the
+// deadline set with SetDeadline, and DNS, connection and TLS failures
+// are returned as a ConnectError. Hosts not on the allowlist set with
+// SetAllowedHosts are refused.
+// Headers the caller already set are kept.
+type Transport struct {
+	Base http.RoundTripper
+}
+
+// RoundTrip implements http.RoundTripper.
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	mu.RLock()
+	ua := userAgent
+	capture := captureDir
+	record := recordDir
+	replayer := replay
+	until, expired := deadline, deadlineErr
+	allowed := allowedHosts
+	mu.RUnlock()
+
+	if err := checkRequest(allowed, req, t.Base); err != nil {
+		closeBody(req)
+		return nil, err
+	}
+
+	// RoundTrippers must not modify the caller's request.
+	req = req.Clone(req.Context())
+	if req.Header.Get("User-Agent") == "" {
+		req.Header.Set("User-Agent", ua)
+	}
+	if req.Header.Get(RequestIDHeader) == "" {
+		req.Header.Set(RequestIDHeader, requestID)
+	}
+	sent.Store(true)
+
+	base := t.Base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	if replayer != nil {
+		base = replayer
+	}
+	if record != "" {
+		base = recorder{dir: record, base: base}
+	}
+	if capture != "" {
+		next := base
+		base = roundTripFunc(func(req *http.Request) (*http.Response, error) {
+			return captureRoundTrip(capture, next, req)
+		})
+	}
+	resp, err := withDeadline(until, expired, base, req)
+	if err != nil && replayer == nil {
+		err = diagnose(req, t.Base, err)
+	}
+	return resp, err
+}
+
+// roundTripFunc is a function used as an http.RoundTripper.
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(req *http.Request) (*http.Response, error) { return f(req) }
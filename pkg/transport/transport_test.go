@@ -0,0 +1,54 @@
+// Copyright (c) Kusari <https://www.kusari.dev/>
+// SPDX-License-Identifier: MIT
+
+package transport
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUserAgent(t *testing.T) {
+	platform := runtime.GOOS + "/" + runtime.GOARCH
+	assert.Equal(t, "kusari-cli/1.2.3 "+platform, UserAgent("v1.2.3", ""))
+	assert.Equal(t, "kusari-cli/dev "+platform+" my-pipeline/4", UserAgent("dev", " my-pipeline/4 "))
+}
+
+func TestTransport(t *testing.T) {
+	var got []http.Header
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = append(got, r.Header.Clone())
+	}))
+	defer server.Close()
+
+	Install("1.0.0", "ci/1")
+	client := &http.Client{}
+
+	resp, err := client.Get(server.URL)
+	require.NoError(t, err)
+	_ = resp.Body.Close()
+
+	req, err := http.NewRequest(http.MethodGet, server.URL, nil)
+	require.NoError(t, err)
+	req.Header.Set("User-Agent", "custom")
+	resp, err = client.Do(req)
+	require.NoError(t, err)
+	_ = resp.Body.Close()
+
+	require.Len(t, got, 2)
+	assert.Equal(t, UserAgent("1.0.0", "ci/1"), got[0].Get("User-Agent"))
+	assert.Equal(t, "custom", got[1].Get("User-Agent"))
+	assert.Equal(t, RequestID(), got[0].Get(RequestIDHeader))
+	assert.Equal(t, RequestID(), got[1].Get(RequestIDHeader), "one request ID per invocation")
+	assert.Empty(t, req.Header.Get(RequestIDHeader), "the caller's request must not be modified")
+	assert.True(t, Sent())
+
+	Install("1.0.1", "")
+	_, ok := http.DefaultTransport.(*Transport).Base.(*Transport)
+	assert.False(t, ok, "Install must not wrap the transport twice")
+}
@@ -0,0 +1,124 @@
+// Copyright (c) Kusari <https://www.kusari.dev/>
+// SPDX-License-Identifier: MIT
+
+package versioncheck
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"time"
+
+	"golang.org/x/mod/semver"
+)
+
+// ReleasesURL lists the CLI's GitHub releases.
+const ReleasesURL = "https://api.github.com/repos/kusaridev/kusari-cli/releases"
+
+// Release channels. Beta follows pre-releases as well as stable releases.
+const (
+	ChannelStable = "stable"
+	ChannelBeta   = "beta"
+)
+
+// Release is a published CLI release.
+type Release struct {
+	Version     string    `json:"version"`
+	URL         string    `json:"url"`
+	PublishedAt time.Time `json:"published_at"`
+}
+
+// Status compares the running CLI against the latest releases.
+type Status struct {
+	Current          string   `json:"current"`
+	Channel          string   `json:"channel"`
+	LatestStable     *Release `json:"latest_stable,omitempty"`
+	LatestPrerelease *Release `json:"latest_prerelease,omitempty"`
+	// Latest is the newest release on Channel, and UpdateAvailable
+	// whether it is newer than Current. Development builds never have an
+	// update available.
+	Latest          *Release `json:"latest,omitempty"`
+	UpdateAvailable bool     `json:"update_available"`
+}
+
+// githubRelease is the subset of the GitHub release object used here.
+type githubRelease struct {
+	TagName     string    `json:"tag_name"`
+	HTMLURL     string    `json:"html_url"`
+	Draft       bool      `json:"draft"`
+	Prerelease  bool      `json:"prerelease"`
+	PublishedAt time.Time `json:"published_at"`
+}
+
+// CheckReleases returns the Status of version on channel, from the
+// releases listed at releasesURL. GITHUB_TOKEN, when set, is sent to avoid
+// the anonymous rate limit.
+func CheckReleases(ctx context.Context, releasesURL, version, channel string) (*Status, error) {
+	if channel != ChannelStable && channel != ChannelBeta {
+		return nil, fmt.Errorf("unknown release channel %q (must be %s or %s)", channel, ChannelStable, ChannelBeta)
+	}
+	releases, err := listReleases(ctx, releasesURL)
+	if err != nil {
+		return nil, err
+	}
+
+	status := &Status{Current: version, Channel: channel}
+	for _, r := range releases {
+		v := canonical(r.TagName)
+		if r.Draft || !semver.IsValid(v) {
+			continue
+		}
+		release := &Release{Version: v, URL: r.HTMLURL, PublishedAt: r.PublishedAt}
+		latest := &status.LatestStable
+		if r.Prerelease || semver.Prerelease(v) != "" {
+			latest = &status.LatestPrerelease
+		}
+		if *latest == nil || semver.Compare(v, (*latest).Version) > 0 {
+			*latest = release
+		}
+	}
+
+	status.Latest = status.LatestStable
+	if channel == ChannelBeta && status.LatestPrerelease != nil &&
+		(status.Latest == nil || semver.Compare(status.LatestPrerelease.Version, status.Latest.Version) > 0) {
+		status.Latest = status.LatestPrerelease
+	}
+	if current := canonical(version); status.Latest != nil && semver.IsValid(current) {
+		status.UpdateAvailable = semver.Compare(status.Latest.Version, current) > 0
+	}
+	return status, nil
+}
+
+func listReleases(ctx context.Context, releasesURL string) ([]githubRelease, error) {
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, releasesURL+"?per_page=100", nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Accept", "application/vnd.github+json")
+	req.Header.Set("X-GitHub-Api-Version", "2022-11-28")
+	if token := os.Getenv("GITHUB_TOKEN"); token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list releases: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return nil, fmt.Errorf("GitHub API returned status %d: %s", resp.StatusCode, string(body))
+	}
+	var releases []githubRelease
+	if err := json.NewDecoder(resp.Body).Decode(&releases); err != nil {
+		return nil, fmt.Errorf("failed to decode releases: %w", err)
+	}
+	return releases, nil
+}
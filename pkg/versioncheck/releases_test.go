@@ -0,0 +1,53 @@
+// Copyright (c) Kusari <https://www.kusari.dev/>
+// SPDX-License-Identifier: MIT
+
+package versioncheck
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const releasesJSON = `[
+  {"tag_name": "v2.1.0-rc.1", "html_url": "https://example.com/v2.1.0-rc.1", "prerelease": true},
+  {"tag_name": "v2.2.0", "html_url": "https://example.com/v2.2.0", "draft": true},
+  {"tag_name": "v2.0.1", "html_url": "https://example.com/v2.0.1"},
+  {"tag_name": "v1.9.0", "html_url": "https://example.com/v1.9.0"},
+  {"tag_name": "nightly", "html_url": "https://example.com/nightly", "prerelease": true}
+]`
+
+func TestCheckReleases(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(releasesJSON))
+	}))
+	defer server.Close()
+	ctx := context.Background()
+
+	status, err := CheckReleases(ctx, server.URL, "v1.9.0", ChannelStable)
+	require.NoError(t, err)
+	assert.Equal(t, "v2.0.1", status.LatestStable.Version)
+	assert.Equal(t, "v2.1.0-rc.1", status.LatestPrerelease.Version)
+	assert.Equal(t, "v2.0.1", status.Latest.Version)
+	assert.True(t, status.UpdateAvailable)
+
+	status, err = CheckReleases(ctx, server.URL, "2.0.1", ChannelStable)
+	require.NoError(t, err)
+	assert.False(t, status.UpdateAvailable)
+
+	status, err = CheckReleases(ctx, server.URL, "2.0.1", ChannelBeta)
+	require.NoError(t, err)
+	assert.Equal(t, "v2.1.0-rc.1", status.Latest.Version)
+	assert.True(t, status.UpdateAvailable)
+
+	status, err = CheckReleases(ctx, server.URL, "dev", ChannelBeta)
+	require.NoError(t, err)
+	assert.False(t, status.UpdateAvailable, "development builds have no update")
+
+	_, err = CheckReleases(ctx, server.URL, "v1.0.0", "nightly")
+	assert.Error(t, err)
+}
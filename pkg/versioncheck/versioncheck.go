@@ -0,0 +1,198 @@
+// Copyright (c) Kusari <https://www.kusari.dev/>
+// SPDX-License-Identifier: MIT
+
+// Package versioncheck asks the Kusari platform for the oldest CLI version
+// it still supports, so a stale binary baked into a CI image fails with a
+// clear message instead of confusing API errors.
+//
+// It also compares the running CLI against the latest GitHub releases, for
+// `kusari version --check`.
+package versioncheck
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/kusaridev/kusari-cli/v2/pkg/auth"
+	"github.com/kusaridev/kusari-cli/v2/pkg/clierrors"
+	"github.com/kusaridev/kusari-cli/v2/pkg/output"
+	"github.com/kusaridev/kusari-cli/v2/pkg/url"
+	"golang.org/x/mod/semver"
+)
+
+// Modes of the check, set with --version-check.
+const (
+	// ModeWarn prints a warning and carries on. The default.
+	ModeWarn = "warn"
+	// ModeEnforce refuses to run with an unsupported version.
+	ModeEnforce = "enforce"
+	// ModeOff skips the check.
+	ModeOff = "off"
+)
+
+const (
+	cacheFileName = "version-check.json"
+	// cacheTTL is how long the platform's answer is reused, so the check
+	// costs one request a day rather than one per command.
+	cacheTTL = 24 * time.Hour
+	timeout  = 5 * time.Second
+)
+
+// Requirement is the platform's answer, from GET <platform>/cli/version.
+type Requirement struct {
+	MinimumVersion string `json:"minimum_version"`
+	LatestVersion  string `json:"latest_version,omitempty"`
+	// Message, when set, is shown with the warning, e.g. upgrade notes.
+	Message string `json:"message,omitempty"`
+}
+
+// ValidMode reports whether mode is one of the modes above.
+func ValidMode(mode string) bool {
+	return mode == ModeWarn || mode == ModeEnforce || mode == ModeOff
+}
+
+// Check compares version against the minimum version the platform at
+// platformURL supports. In ModeWarn an unsupported version is reported on
+// stderr; in ModeEnforce it is returned as an error. Development builds,
+// and any failure to get the requirement, pass: the check must never stop
+// a command the platform would have served.
+func Check(ctx context.Context, platformURL, version, mode string) error {
+	if mode == ModeOff || !semver.IsValid(canonical(version)) {
+		return nil
+	}
+	req, err := requirement(ctx, platformURL)
+	if err != nil {
+		output.Debug("skipping CLI version check", "error", err)
+		return nil
+	}
+	minimum := canonical(req.MinimumVersion)
+	if !semver.IsValid(minimum) || semver.Compare(canonical(version), minimum) >= 0 {
+		return nil
+	}
+
+	msg := fmt.Sprintf("kusari %s is older than %s, the oldest version the Kusari platform supports; upgrade the CLI",
+		canonical(version), minimum)
+	if req.Message != "" {
+		msg += ": " + req.Message
+	}
+	if mode == ModeEnforce {
+		return &clierrors.UnsupportedVersionError{Message: msg}
+	}
+	fmt.Fprintf(os.Stderr, "Warning: %s\n", msg)
+	return nil
+}
+
+// canonical adds the "v" prefix semver expects.
+func canonical(v string) string {
+	if v != "" && !strings.HasPrefix(v, "v") {
+		return "v" + v
+	}
+	return v
+}
+
+// requirement returns the cached requirement of platformURL if it is
+// fresh, and otherwise fetches and caches it.
+func requirement(ctx context.Context, platformURL string) (*Requirement, error) {
+	key := strings.TrimSuffix(platformURL, "/")
+	cache := loadCache()
+	if entry, ok := cache[key]; ok && time.Since(entry.FetchedAt) < cacheTTL {
+		return &entry.Requirement, nil
+	}
+
+	req, err := fetch(ctx, platformURL)
+	if err != nil {
+		return nil, err
+	}
+	cache[key] = cacheEntry{FetchedAt: time.Now(), Requirement: *req}
+	storeCache(cache)
+	return req, nil
+}
+
+func fetch(ctx context.Context, platformURL string) (*Requirement, error) {
+	endpoint, err := url.Build(platformURL, "cli", "version")
+	if err != nil {
+		return nil, err
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodGet, *endpoint, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	resp, err := http.DefaultClient.Do(httpReq)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query CLI version requirement: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode == http.StatusNotFound {
+		// The platform doesn't pin a version; cached like any answer.
+		return &Requirement{}, nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return nil, fmt.Errorf("CLI version API returned status %d: %s", resp.StatusCode, string(body))
+	}
+	var req Requirement
+	if err := json.NewDecoder(resp.Body).Decode(&req); err != nil {
+		return nil, fmt.Errorf("failed to decode CLI version requirement: %w", err)
+	}
+	return &req, nil
+}
+
+// cacheEntry is one platform's cached requirement.
+type cacheEntry struct {
+	FetchedAt   time.Time   `json:"fetchedAt"`
+	Requirement Requirement `json:"requirement"`
+}
+
+func cachePath() (string, error) {
+	dir, err := auth.ConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, cacheFileName), nil
+}
+
+// loadCache returns the cache contents; a missing or unreadable cache is
+// treated as empty.
+func loadCache() map[string]cacheEntry {
+	cache := make(map[string]cacheEntry)
+	path, err := cachePath()
+	if err != nil {
+		return cache
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return cache
+	}
+	_ = json.Unmarshal(data, &cache)
+	return cache
+}
+
+// storeCache writes cache. Failures are not fatal: the next command just
+// asks the platform again.
+func storeCache(cache map[string]cacheEntry) {
+	path, err := cachePath()
+	if err != nil {
+		return
+	}
+	data, err := json.MarshalIndent(cache, "", "  ")
+	if err != nil {
+		return
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return
+	}
+	if err := os.WriteFile(path, data, 0600); err != nil {
+		output.Debug("failed to write version check cache", "error", err)
+	}
+}
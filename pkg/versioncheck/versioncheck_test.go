@@ -0,0 +1,66 @@
+// Copyright (c) Kusari <https://www.kusari.dev/>
+// SPDX-License-Identifier: MIT
+
+package versioncheck
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/kusaridev/kusari-cli/v2/pkg/clierrors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func platform(t *testing.T, status int, body string) (*httptest.Server, *int) {
+	t.Helper()
+	calls := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		assert.Equal(t, "/cli/version", r.URL.Path)
+		w.WriteHeader(status)
+		_, _ = w.Write([]byte(body))
+	}))
+	t.Cleanup(server.Close)
+	return server, &calls
+}
+
+func TestCheck(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	server, calls := platform(t, http.StatusOK, `{"minimum_version": "1.5.0", "message": "see the changelog"}`)
+	ctx := context.Background()
+
+	assert.NoError(t, Check(ctx, server.URL, "v1.5.0", ModeEnforce))
+	assert.NoError(t, Check(ctx, server.URL, "1.6.2", ModeEnforce))
+	assert.NoError(t, Check(ctx, server.URL, "v1.4.0", ModeWarn))
+
+	err := Check(ctx, server.URL, "v1.4.0", ModeEnforce)
+	var versionErr *clierrors.UnsupportedVersionError
+	require.True(t, errors.As(err, &versionErr))
+	assert.Contains(t, err.Error(), "v1.4.0 is older than v1.5.0")
+	assert.Contains(t, err.Error(), "see the changelog")
+	assert.Equal(t, clierrors.ExitUnsupported, clierrors.ExitCode(err))
+
+	assert.Equal(t, 1, *calls, "the requirement should be cached")
+}
+
+func TestCheckSkips(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	ctx := context.Background()
+
+	server, calls := platform(t, http.StatusOK, `{"minimum_version": "v9.0.0"}`)
+	assert.NoError(t, Check(ctx, server.URL, "dev", ModeEnforce), "development builds are not checked")
+	assert.NoError(t, Check(ctx, server.URL, "v1.0.0", ModeOff))
+	assert.Equal(t, 0, *calls)
+
+	missing, missingCalls := platform(t, http.StatusNotFound, "not found")
+	assert.NoError(t, Check(ctx, missing.URL, "v1.0.0", ModeEnforce), "a platform without the endpoint must not block")
+	assert.NoError(t, Check(ctx, missing.URL, "v1.0.0", ModeEnforce))
+	assert.Equal(t, 1, *missingCalls)
+
+	broken, _ := platform(t, http.StatusInternalServerError, "oops")
+	assert.NoError(t, Check(ctx, broken.URL, "v1.0.0", ModeEnforce))
+}
@@ -24,7 +24,7 @@ import (
 	"sync"
 	"time"
 
-	"github.com/briandowns/spinner"
+	"github.com/kusaridev/kusari-cli/v2/pkg/output"
 )
 
 type asset struct {
@@ -158,10 +158,9 @@ func install(ctx context.Context, binPath string) (string, error) {
 		return "", err
 	}
 
-	fmt.Fprintf(os.Stderr, "kusari: Waybill %s not found locally, downloading from %s\n", Version, Repo)
-	s := spinner.New(spinner.CharSets[14], 100*time.Millisecond)
-	s.Writer = os.Stderr
-	s.Suffix = " downloading " + a.Filename
+	output.Progressf(os.Stderr, "kusari: Waybill %s not found locally, downloading from %s\n", Version, Repo)
+	s := output.NewSpinner(os.Stderr)
+	s.SetSuffix(" downloading " + a.Filename)
 	s.Start()
 	defer s.Stop()
 
@@ -171,7 +170,7 @@ func install(ctx context.Context, binPath string) (string, error) {
 	}
 	defer func() { _ = os.Remove(archive) }()
 
-	s.Suffix = " extracting"
+	s.SetSuffix(" extracting")
 	tmp := binPath + ".tmp"
 	if err := extractTarGz(archive, tmp); err != nil {
 		return "", err
@@ -184,7 +183,7 @@ func install(ctx context.Context, binPath string) (string, error) {
 	}
 
 	s.Stop()
-	fmt.Fprintf(os.Stderr, "kusari: installed Waybill %s to %s\n", Version, binPath)
+	output.Progressf(os.Stderr, "kusari: installed Waybill %s to %s\n", Version, binPath)
 	return binPath, nil
 }
 
@@ -262,3 +261,23 @@ func extractTarGz(archivePath, destPath string) error {
 		return out.Close()
 	}
 }
+
+// StaleBinaries returns the Waybill binaries installed in ~/.kusari/bin by
+// earlier CLI versions, which pinned a different Version.
+func StaleBinaries() ([]string, error) {
+	current, err := cachedBinaryPath()
+	if err != nil {
+		return nil, err
+	}
+	matches, err := filepath.Glob(filepath.Join(filepath.Dir(current), "waybill-*"))
+	if err != nil {
+		return nil, err
+	}
+	var stale []string
+	for _, m := range matches {
+		if m != current {
+			stale = append(stale, m)
+		}
+	}
+	return stale, nil
+}
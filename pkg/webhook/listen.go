@@ -0,0 +1,82 @@
+// Copyright (c) Kusari <https://www.kusari.dev/>
+// SPDX-License-Identifier: MIT
+
+package webhook
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/kusaridev/kusari-cli/v2/pkg/clock"
+)
+
+// maxBodySize bounds the deliveries a Handler reads.
+const maxBodySize = 10 << 20
+
+// HandlerOptions configure a Handler.
+type HandlerOptions struct {
+	// Secret verifies the deliveries' signatures.
+	Secret []byte
+	// Out receives a line per delivery.
+	Out io.Writer
+	// Raw also writes each delivery's body, indented.
+	Raw bool
+	// Clock stamps the lines; clock.Real when nil.
+	Clock clock.Clock
+}
+
+// Handler returns an http.Handler that verifies each delivery POSTed to
+// it and writes what it received to opts.Out. Deliveries with a bad
+// signature are answered 401, undecodable ones 400, so the platform shows
+// them as failed.
+func Handler(opts HandlerOptions) http.Handler {
+	if opts.Clock == nil {
+		opts.Clock = clock.Real{}
+	}
+	var mu sync.Mutex
+	logf := func(format string, args ...any) {
+		mu.Lock()
+		defer mu.Unlock()
+		fmt.Fprintf(opts.Out, opts.Clock.Now().Format(time.RFC3339)+" "+format+"\n", args...)
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			w.Header().Set("Allow", http.MethodPost)
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxBodySize))
+		if err != nil {
+			logf("✗ %s: failed to read body: %v", r.RemoteAddr, err)
+			http.Error(w, "failed to read body", http.StatusBadRequest)
+			return
+		}
+		if err := Verify(opts.Secret, body, r.Header.Get(SignatureHeader)); err != nil {
+			logf("✗ %s: rejected: %v", r.RemoteAddr, err)
+			http.Error(w, "invalid signature", http.StatusUnauthorized)
+			return
+		}
+		e, err := ParseEvent(body)
+		if err != nil {
+			logf("✗ %s: %v", r.RemoteAddr, err)
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		logf("✓ %s", e.Summary())
+		if opts.Raw {
+			var indented bytes.Buffer
+			if json.Indent(&indented, body, "", "  ") == nil {
+				mu.Lock()
+				fmt.Fprintln(opts.Out, indented.String())
+				mu.Unlock()
+			}
+		}
+		w.WriteHeader(http.StatusNoContent)
+	})
+}
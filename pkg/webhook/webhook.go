@@ -0,0 +1,112 @@
+// Copyright (c) Kusari <https://www.kusari.dev/>
+// SPDX-License-Identifier: MIT
+
+// Package webhook verifies and decodes the analysis events the Kusari
+// platform delivers to webhooks.
+//
+// Each delivery is a JSON event POSTed with the HMAC-SHA256 of the body,
+// keyed with the webhook's secret, in the X-Kusari-Signature header as
+// "sha256=<hex>".
+package webhook
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/kusaridev/kusari-cli/v2/api"
+)
+
+// SignatureHeader is the header carrying a delivery's signature.
+const SignatureHeader = "X-Kusari-Signature"
+
+const signaturePrefix = "sha256="
+
+var (
+	// ErrNoSignature is returned for a delivery without a signature.
+	ErrNoSignature = errors.New("missing " + SignatureHeader + " signature")
+	// ErrBadSignature is returned when the signature doesn't match the
+	// body and secret.
+	ErrBadSignature = errors.New("signature does not match the payload (wrong secret, or the body was modified)")
+)
+
+// Event is an analysis event delivered to a webhook.
+type Event struct {
+	Event      string                `json:"event"`
+	ID         string                `json:"id,omitempty"`
+	Workspace  string                `json:"workspace,omitempty"`
+	Repository string                `json:"repository,omitempty"`
+	Ref        string                `json:"ref,omitempty"`
+	ConsoleURL string                `json:"console_url,omitempty"`
+	Analysis   *api.SecurityAnalysis `json:"analysis,omitempty"`
+}
+
+// Sign returns the signature of body under secret, as sent in
+// SignatureHeader.
+func Sign(secret, body []byte) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write(body)
+	return signaturePrefix + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Verify checks that signature is that of body under secret.
+func Verify(secret, body []byte, signature string) error {
+	signature = strings.TrimSpace(signature)
+	if signature == "" {
+		return ErrNoSignature
+	}
+	sum, ok := strings.CutPrefix(signature, signaturePrefix)
+	if !ok {
+		return fmt.Errorf("unsupported signature %q: expected %s<hex>", signature, signaturePrefix)
+	}
+	got, err := hex.DecodeString(sum)
+	if err != nil {
+		return fmt.Errorf("malformed signature: %w", err)
+	}
+	mac := hmac.New(sha256.New, secret)
+	mac.Write(body)
+	if !hmac.Equal(got, mac.Sum(nil)) {
+		return ErrBadSignature
+	}
+	return nil
+}
+
+// ParseEvent decodes the event in body.
+func ParseEvent(body []byte) (*Event, error) {
+	var e Event
+	if err := json.Unmarshal(body, &e); err != nil {
+		return nil, fmt.Errorf("invalid event payload: %w", err)
+	}
+	if e.Event == "" {
+		return nil, errors.New("invalid event payload: no event type")
+	}
+	return &e, nil
+}
+
+// Summary describes e in one line.
+func (e *Event) Summary() string {
+	parts := []string{e.Event}
+	if e.Repository != "" {
+		subject := e.Repository
+		if e.Ref != "" {
+			subject += "@" + e.Ref
+		}
+		parts = append(parts, subject)
+	}
+	if a := e.Analysis; a != nil {
+		status := "passed"
+		if !a.ShouldProceed {
+			status = "flagged"
+		}
+		parts = append(parts, fmt.Sprintf("%s (health score %d, %d code and %d dependency findings)",
+			status, a.HealthScore, len(a.RequiredCodeMitigations), len(a.RequiredDependencyMitigations)))
+	}
+	if e.ConsoleURL != "" {
+		parts = append(parts, e.ConsoleURL)
+	}
+	return strings.Join(parts, " ")
+}
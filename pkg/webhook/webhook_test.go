@@ -0,0 +1,82 @@
+// Copyright (c) Kusari <https://www.kusari.dev/>
+// SPDX-License-Identifier: MIT
+
+package webhook
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kusaridev/kusari-cli/v2/pkg/clock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testEvent = `{"event":"analysis.completed","repository":"acme/app","ref":"main","console_url":"https://console.example.com/a/1",
+"analysis":{"should_proceed":false,"health_score":3,"code_mitigations":[{"path":"a.go","line_number":1,"content":"x"}]}}`
+
+func TestSignVerify(t *testing.T) {
+	secret := []byte("s3cret")
+	body := []byte(testEvent)
+	sig := Sign(secret, body)
+	assert.True(t, strings.HasPrefix(sig, "sha256="))
+	assert.Len(t, sig, len("sha256=")+64)
+
+	assert.NoError(t, Verify(secret, body, sig))
+	assert.NoError(t, Verify(secret, body, " "+sig+"\n"))
+	assert.ErrorIs(t, Verify([]byte("other"), body, sig), ErrBadSignature)
+	assert.ErrorIs(t, Verify(secret, append(body, ' '), sig), ErrBadSignature)
+	assert.ErrorIs(t, Verify(secret, body, ""), ErrNoSignature)
+	assert.ErrorContains(t, Verify(secret, body, "sha1=abc"), "unsupported signature")
+	assert.ErrorContains(t, Verify(secret, body, "sha256=zz"), "malformed signature")
+}
+
+func TestParseEvent(t *testing.T) {
+	e, err := ParseEvent([]byte(testEvent))
+	require.NoError(t, err)
+	assert.Equal(t, "analysis.completed acme/app@main flagged (health score 3, 1 code and 0 dependency findings) https://console.example.com/a/1", e.Summary())
+
+	_, err = ParseEvent([]byte(`{"repository":"acme/app"}`))
+	assert.ErrorContains(t, err, "no event type")
+	_, err = ParseEvent([]byte(`not json`))
+	assert.ErrorContains(t, err, "invalid event payload")
+}
+
+func TestHandler(t *testing.T) {
+	secret := []byte("s3cret")
+	var out bytes.Buffer
+	h := Handler(HandlerOptions{
+		Secret: secret,
+		Out:    &out,
+		Raw:    true,
+		Clock:  clock.NewFake(time.Date(2026, 10, 14, 9, 0, 0, 0, time.UTC)),
+	})
+
+	post := func(body, sig string) int {
+		req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(body))
+		if sig != "" {
+			req.Header.Set(SignatureHeader, sig)
+		}
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		return rec.Code
+	}
+
+	assert.Equal(t, http.StatusNoContent, post(testEvent, Sign(secret, []byte(testEvent))))
+	assert.Contains(t, out.String(), "2026-10-14T09:00:00Z ✓ analysis.completed acme/app@main flagged")
+	assert.Contains(t, out.String(), `  "event": "analysis.completed",`)
+
+	out.Reset()
+	assert.Equal(t, http.StatusUnauthorized, post(testEvent, Sign([]byte("other"), []byte(testEvent))))
+	assert.Contains(t, out.String(), "rejected: signature does not match")
+	assert.Equal(t, http.StatusUnauthorized, post(testEvent, ""))
+	assert.Equal(t, http.StatusBadRequest, post(`{}`, Sign(secret, []byte(`{}`))))
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	assert.Equal(t, http.StatusMethodNotAllowed, rec.Code)
+}